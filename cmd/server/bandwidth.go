@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/core"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/time/rate"
+)
+
+// downloadRoutes are the routes throttled by bandwidth: they send images to
+// devices and API clients.
+var downloadRoutes = map[string]bool{
+	"/api/image":                    true,
+	"/api/image.png":                true,
+	"/api/image.jpg":                true,
+	"/api/image.bmp":                true,
+	"/api/image.bin":                true,
+	"/api/devices/:name/image.png":  true,
+	"/api/image/patch":              true,
+	"/api/images/:id/processed.png": true,
+	"/api/images/:id/processed.jpg": true,
+	"/api/images/:id/processed.bmp": true,
+	"/api/images/:id/processed.bin": true,
+	"/api/images/:id/original.png":  true,
+	"/api/images/:id/preview.png":   true,
+	"/api/timelapse.gif":            true,
+	core.BlobURLPrefix + ":file":    true,
+}
+
+// bandwidthMiddleware throttles the responses of download routes to
+// b.BytesPerSecond with a token bucket per response. A connection serves one
+// request at a time, so this caps the bandwidth of each connection.
+func bandwidthMiddleware(b config.Bandwidth) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !downloadRoutes[c.Path()] {
+				return next(c)
+			}
+			// The request's own context rather than one bounded by limits:
+			// a throttled download may take longer than the route timeout.
+			c.Response().Writer = &throttledWriter{
+				ResponseWriter: c.Response().Writer,
+				ctx:            c.Request().Context(),
+				limiter:        rate.NewLimiter(rate.Limit(b.BytesPerSecond), int(b.Burst)),
+			}
+			return next(c)
+		}
+	}
+}
+
+// throttledWriter is an http.ResponseWriter writing the body no faster than
+// its limiter allows.
+type throttledWriter struct {
+	http.ResponseWriter
+	ctx     context.Context
+	limiter *rate.Limiter
+}
+
+// Write sends p in chunks of at most the burst size, waiting for the tokens
+// of each chunk. It stops when the client goes away.
+func (w *throttledWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := min(len(p), w.limiter.Burst())
+		if err := w.limiter.WaitN(w.ctx, n); err != nil {
+			return written, err
+		}
+		m, err := w.ResponseWriter.Write(p[:n])
+		written += m
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// Flush sends buffered data to the client.
+func (w *throttledWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the wrapped writer for http.ResponseController.
+func (w *throttledWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// corsMiddleware answers cross-origin requests to the /api/ routes so browser
+// clients served from another origin can use the API. The htmx frontend and
+// static pages stay same-origin only.
+func corsMiddleware(c config.CORS) echo.MiddlewareFunc {
+	return middleware.CORSWithConfig(middleware.CORSConfig{
+		Skipper: func(ctx echo.Context) bool {
+			return !strings.HasPrefix(ctx.Request().URL.Path, "/api/")
+		},
+		AllowOrigins:     c.AllowedOrigins,
+		AllowMethods:     c.AllowedMethods,
+		AllowHeaders:     c.AllowedHeaders,
+		AllowCredentials: c.AllowCredentials,
+		ExposeHeaders:    []string{"Retry-After", "Content-Range"},
+		MaxAge:           int(c.MaxAge.Seconds()),
+	})
+}
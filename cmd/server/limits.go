@@ -0,0 +1,89 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// uploadRoutes are the routes limited by limits.uploads: they take large
+// bodies or process many images in one request.
+var uploadRoutes = map[string]bool{
+	http.MethodPost + " /api/image":                 true,
+	http.MethodPost + " /api/images/bulk/upload":    true,
+	http.MethodPost + " /api/images/bulk/reprocess": true,
+	http.MethodPost + " /api/import":                true,
+	http.MethodPost + " /api/calibration/photo":     true,
+	http.MethodPost + " /htmx/uploadImage":          true,
+	http.MethodPost + " /htmx/import":               true,
+	http.MethodPost + " /htmx/calibration/photo":    true,
+}
+
+// streamingRoutes keep their connection open on purpose and are never timed
+// out; the long poll of /api/image/next bounds its wait itself.
+var streamingRoutes = map[string]bool{
+	http.MethodGet + " /api/reprocess/events": true,
+	http.MethodGet + " /api/image/next":       true,
+}
+
+// routeGroup returns the limits group of the matched route: "uploads", "api",
+// "htmx" or "" for routes without limits, such as static pages.
+func routeGroup(c echo.Context) string {
+	route := c.Request().Method + " " + c.Path()
+	switch {
+	case uploadRoutes[route]:
+		return "uploads"
+	case strings.HasPrefix(c.Path(), "/api/"):
+		return "api"
+	case strings.HasPrefix(c.Path(), "/htmx/"):
+		return "htmx"
+	}
+	return ""
+}
+
+// limitsMiddleware applies echo's body limit and context timeout middleware
+// with the limits of each request's route group.
+func limitsMiddleware(limits config.Limits) echo.MiddlewareFunc {
+	groups := map[string]echo.MiddlewareFunc{
+		"api":     routeLimitsMiddleware(limits.API),
+		"htmx":    routeLimitsMiddleware(limits.HTMX),
+		"uploads": routeLimitsMiddleware(limits.Uploads),
+	}
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		handlers := make(map[string]echo.HandlerFunc, len(groups))
+		for name, mw := range groups {
+			handlers[name] = mw(next)
+		}
+		return func(c echo.Context) error {
+			if h, ok := handlers[routeGroup(c)]; ok {
+				return h(c)
+			}
+			return next(c)
+		}
+	}
+}
+
+// routeLimitsMiddleware chains the body limit and timeout of l; disabled
+// limits are left out.
+func routeLimitsMiddleware(l config.RouteLimits) echo.MiddlewareFunc {
+	var chain []echo.MiddlewareFunc
+	if l.MaxBodyBytes > 0 {
+		chain = append(chain, middleware.BodyLimit(strconv.FormatInt(l.MaxBodyBytes, 10)))
+	}
+	if l.Timeout > 0 {
+		chain = append(chain, middleware.ContextTimeoutWithConfig(middleware.ContextTimeoutConfig{
+			Timeout: l.Timeout,
+			Skipper: func(c echo.Context) bool { return streamingRoutes[c.Request().Method+" "+c.Path()] },
+		}))
+	}
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		for i := len(chain) - 1; i >= 0; i-- {
+			next = chain[i](next)
+		}
+		return next
+	}
+}
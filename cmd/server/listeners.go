@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/jo-hoe/goframe/internal/config"
+)
+
+// openListener opens a TCP or unix domain socket listener, or takes over the
+// sockets passed by systemd under one name. A stale socket file left behind
+// by an unclean shutdown is removed first.
+func openListener(l config.Listener) ([]net.Listener, error) {
+	network, address := l.Network()
+	if network == "systemd" {
+		return systemdListeners(address)
+	}
+	if network == "unix" {
+		if err := os.Remove(address); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("removing stale socket %s: %w", address, err)
+		}
+	}
+	ln, err := net.Listen(network, address)
+	if err != nil {
+		return nil, fmt.Errorf("listening on %s: %w", l.Address, err)
+	}
+	return []net.Listener{ln}, nil
+}
+
+// serve starts one http.Server per listener, all sharing handler. Listeners
+// that were already opened are closed again if a later one fails.
+func serve(name string, handler http.Handler, listeners []config.Listener) ([]*http.Server, error) {
+	opened := make([]net.Listener, 0, len(listeners))
+	for _, l := range listeners {
+		lns, err := openListener(l)
+		if err != nil {
+			for _, o := range opened {
+				_ = o.Close()
+			}
+			return nil, err
+		}
+		opened = append(opened, lns...)
+	}
+
+	servers := make([]*http.Server, 0, len(opened))
+	for _, ln := range opened {
+		srv := &http.Server{Handler: handler, ReadHeaderTimeout: readHeaderTimeout}
+		servers = append(servers, srv)
+		go func() {
+			slog.Info("http server listening", "server", name, "network", ln.Addr().Network(), "address", ln.Addr().String())
+			if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				slog.Error("http server error", "server", name, "address", ln.Addr().String(), "error", err)
+			}
+		}()
+	}
+	return servers, nil
+}
+
+// shutdown gracefully stops all servers, waiting for in-flight requests until ctx expires.
+func shutdown(ctx context.Context, servers []*http.Server) {
+	for _, srv := range servers {
+		if err := srv.Shutdown(ctx); err != nil {
+			slog.Error("server shutdown error", "error", err)
+		}
+	}
+}
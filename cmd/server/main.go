@@ -2,31 +2,51 @@ package main
 
 import (
 	"context"
-	"errors"
 	"flag"
-	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"runtime/debug"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/jo-hoe/goframe/internal/apihandler"
+	"github.com/jo-hoe/goframe/internal/chaos"
 	"github.com/jo-hoe/goframe/internal/config"
 	"github.com/jo-hoe/goframe/internal/core"
 	frontend "github.com/jo-hoe/goframe/internal/frontend"
+	"github.com/jo-hoe/goframe/internal/imageprocessing"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 )
 
-func getConfigPath() string {
+// readHeaderTimeout bounds how long a client may take to send request headers.
+const readHeaderTimeout = 30 * time.Second
+
+// flags holds the command line options.
+type flags struct {
+	configPath string
+	// seedDemo loads the embedded sample images into an empty library.
+	seedDemo bool
+	// dev serves the frontend templates and assets from the source tree and
+	// reloads them when they change.
+	dev bool
+}
+
+func parseFlags() flags {
 	configFlag := flag.String("config", "", "path to config file")
+	seedDemo := flag.Bool("seed-demo", false, "add sample images when the library is empty")
+	dev := flag.Bool("dev", false, "load frontend templates and assets from "+frontend.SourceViewsDir+" and reload them on change")
 	flag.Parse()
-	if *configFlag != "" {
-		return *configFlag
+	return flags{configPath: getConfigPath(*configFlag), seedDemo: *seedDemo, dev: *dev}
+}
+
+func getConfigPath(configFlag string) string {
+	if configFlag != "" {
+		return configFlag
 	}
 	if configPath := os.Getenv("CONFIG_PATH"); configPath != "" {
 		return configPath
@@ -54,8 +74,24 @@ func parseLogLevel(s string) slog.Level {
 	}
 }
 
+// runSelfTest runs the pipeline self-test unless mode is off and exits the
+// process on failure if mode is fail.
+func runSelfTest(coreService *core.CoreService, mode string) {
+	if mode == config.StartupSelfTestOff {
+		return
+	}
+	if err := coreService.SelfTest(); err != nil {
+		if mode == config.StartupSelfTestFail {
+			slog.Error("pipeline self-test failed", "error", err)
+			os.Exit(1)
+		}
+		slog.Warn("pipeline self-test failed; uploads will likely fail too", "error", err)
+	}
+}
+
 func main() {
-	configPath := getConfigPath()
+	opts := parseFlags()
+	configPath := opts.configPath
 	config, err := config.LoadServerConfig(configPath)
 	if err != nil {
 		slog.Error("failed to load config", "path", configPath, "error", err)
@@ -66,44 +102,143 @@ func main() {
 	handler := slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: level})
 	slog.SetDefault(slog.New(handler))
 	slog.Info("logging initialized", "level", config.LogLevel)
+	if limit := config.Runtime.MemoryLimitMB; limit > 0 {
+		debug.SetMemoryLimit(int64(limit) << 20)
+		slog.Info("memory limit set", "limitMB", limit, "lowMemory", config.Runtime.LowMemory(),
+			"memoryBudgetBytes", config.Uploads.MemoryBudgetBytes, "reprocessingWorkers", config.Reprocessing.Workers)
+	}
+	if imageprocessing.ScalingBackend != "go" {
+		slog.Info("accelerated image scaling enabled", "backend", imageprocessing.ScalingBackend)
+	}
 
 	coreService, err := core.NewCoreService(config)
 	if err != nil {
 		slog.Error("failed to initialise core service", "error", err)
 		os.Exit(1)
 	}
+	runSelfTest(coreService, config.StartupSelfTest)
+	if !config.ReadOnly {
+		if _, err := coreService.RecoverStagedUploads(context.Background()); err != nil {
+			slog.Error("failed to recover interrupted uploads", "error", err)
+		}
+	}
+	if opts.seedDemo && !config.ReadOnly {
+		if err := coreService.SeedDemoImagesIfEmpty(context.Background()); err != nil {
+			slog.Error("failed to seed demo images", "error", err)
+		}
+	}
 	server := defineServer()
+	if config.CORS.Enabled() {
+		slog.Info("cors enabled for /api/", "origins", config.CORS.AllowedOrigins)
+		server.Use(corsMiddleware(config.CORS))
+	}
+	if config.Bandwidth.Enabled() {
+		slog.Info("image downloads throttled", "bytesPerSecond", config.Bandwidth.BytesPerSecond, "burst", config.Bandwidth.Burst)
+		server.Use(bandwidthMiddleware(config.Bandwidth))
+	}
+	server.Use(limitsMiddleware(config.Limits))
+	if config.Chaos.Enabled {
+		if injector := chaos.New(config.Chaos); injector != nil {
+			slog.Warn("chaos mode enabled; requests and storage operations fail on purpose",
+				"errorRate", config.Chaos.ErrorRate, "latency", config.Chaos.Latency,
+				"databaseErrorRate", config.Chaos.DatabaseErrorRate, "pipelineDelay", config.Chaos.PipelineDelay)
+			server.Use(injector.Middleware)
+		} else {
+			slog.Warn("chaos mode is configured but not compiled in; rebuild with -tags chaos to enable it")
+		}
+	}
+	if config.ReadOnly {
+		slog.Info("read-only mode enabled; mutating requests are rejected")
+		server.Use(readOnlyMiddleware)
+	}
 
 	api := apihandler.NewAPIService(coreService)
 	api.SetRoutes(server)
+	management := server
+	if len(config.ManagementListeners) > 0 {
+		management = defineServer()
+		if config.CORS.Enabled() {
+			management.Use(corsMiddleware(config.CORS))
+		}
+		management.Use(limitsMiddleware(config.Limits))
+		if config.ReadOnly {
+			management.Use(readOnlyMiddleware)
+		}
+		management.GET("/probe", func(c echo.Context) error {
+			return c.String(http.StatusOK, "Management API is running")
+		})
+	}
+	api.SetManagementRoutes(management)
 	frontendService := frontend.NewFrontendService(config, coreService)
+	if opts.dev {
+		if err := frontendService.UseViewsFromDisk(frontend.SourceViewsDir); err != nil {
+			slog.Error("failed to enable developer mode; run the server from the repository root", "error", err)
+			os.Exit(1)
+		}
+		slog.Warn("developer mode enabled; frontend templates and assets are loaded from disk", "dir", frontend.SourceViewsDir)
+	}
 	frontendService.SetRoutes(server)
 
-	portString := fmt.Sprintf(":%d", config.Port)
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	if config.Replication.PrimaryURL != "" {
+		go core.NewReplicator(coreService, config.Replication).Run(backgroundCtx)
+	}
+	go coreService.RunAlertChecks(backgroundCtx)
+	go coreService.RunPregeneration(backgroundCtx)
+	go coreService.RunOriginalCompressionMigration(backgroundCtx)
+	go coreService.RunGarbageCollection(backgroundCtx)
+	if !config.ReadOnly {
+		go coreService.RunSources(backgroundCtx)
+	}
 
-	go func() {
-		if err := server.Start(portString); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			slog.Error("http server error", "error", err)
+	servers, err := serve("main", server, config.Listeners)
+	if err != nil {
+		slog.Error("failed to start http server", "error", err)
+		os.Exit(1)
+	}
+	if management != server {
+		managementServers, err := serve("management", management, config.ManagementListeners)
+		if err != nil {
+			slog.Error("failed to start management server", "error", err)
+			shutdown(context.Background(), servers)
+			os.Exit(1)
 		}
-	}()
+		servers = append(servers, managementServers...)
+	}
+
+	sdNotify("READY=1")
+	go runWatchdog(backgroundCtx)
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 	<-quit
 	slog.Info("shutdown signal received")
+	sdNotify("STOPPING=1")
+	stopBackground()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	if err := server.Shutdown(ctx); err != nil {
-		slog.Error("server shutdown error", "error", err)
-	}
+	shutdown(ctx, servers)
 
 	if err := coreService.Close(); err != nil {
 		slog.Error("core service close error", "error", err)
 	}
 }
 
+// readOnlyMiddleware rejects every request that could modify state.
+func readOnlyMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		switch c.Request().Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			return next(c)
+		}
+		c.Response().Header().Set(echo.HeaderAllow, "GET, HEAD, OPTIONS")
+		return c.String(http.StatusMethodNotAllowed, "Server is read-only")
+	}
+}
+
 func defineServer() *echo.Echo {
 	e := echo.New()
 
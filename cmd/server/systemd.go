@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// listenFDsStart is the first file descriptor passed by socket activation.
+const listenFDsStart = 3
+
+// activatedListeners returns the sockets systemd passed to this process by
+// their FileDescriptorName, which defaults to the name of the socket unit.
+// They are taken over once; the environment is cleared so child processes do
+// not claim them as well. Since systemd keeps its own copy of every socket,
+// connections arriving during a restart wait in the backlog instead of being
+// refused.
+var activatedListeners = sync.OnceValues(func() (map[string][]net.Listener, error) {
+	defer func() {
+		for _, key := range []string{"LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES"} {
+			_ = os.Unsetenv(key)
+		}
+	}()
+	if pid, err := strconv.Atoi(os.Getenv("LISTEN_PID")); err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count <= 0 {
+		return nil, nil
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	files := make([]*os.File, count)
+	for i := range count {
+		files[i] = os.NewFile(uintptr(listenFDsStart+i), fmt.Sprintf("fd %d", listenFDsStart+i))
+	}
+	return fileListeners(files, names)
+})
+
+// fileListeners turns the sockets passed by systemd into listeners, grouped
+// by their names in the same order. The files are closed.
+func fileListeners(files []*os.File, names []string) (map[string][]net.Listener, error) {
+	defer func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}()
+	listeners := make(map[string][]net.Listener, len(files))
+	for i, f := range files {
+		name := "unknown"
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		ln, err := net.FileListener(f)
+		if err != nil {
+			for _, group := range listeners {
+				for _, l := range group {
+					_ = l.Close()
+				}
+			}
+			return nil, fmt.Errorf("socket %s (%s) passed by systemd is not a listening socket: %w", f.Name(), name, err)
+		}
+		listeners[name] = append(listeners[name], ln)
+	}
+	return listeners, nil
+}
+
+// systemdListeners returns all sockets systemd passed under name, e.g. both
+// sockets of a unit with ListenStream for IPv4 and IPv6, so one listener
+// entry serves them all.
+func systemdListeners(name string) ([]net.Listener, error) {
+	listeners, err := activatedListeners()
+	if err != nil {
+		return nil, err
+	}
+	return takeListeners(listeners, name)
+}
+
+// takeListeners removes the listeners named name from listeners, so that a
+// name is served only once.
+func takeListeners(listeners map[string][]net.Listener, name string) ([]net.Listener, error) {
+	taken := listeners[name]
+	if len(taken) == 0 {
+		return nil, fmt.Errorf("no socket named %q was passed by systemd", name)
+	}
+	delete(listeners, name)
+	return taken, nil
+}
+
+// sdNotify sends state to the systemd service manager, e.g. "READY=1". It
+// does nothing unless the service runs with Type=notify.
+func sdNotify(state string) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return
+	}
+	if strings.HasPrefix(socket, "@") {
+		// Abstract socket names start with a NUL byte.
+		socket = "\x00" + socket[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		slog.Warn("failed to notify systemd", "state", state, "error", err)
+		return
+	}
+	defer func() { _ = conn.Close() }()
+	if _, err := conn.Write([]byte(state)); err != nil {
+		slog.Warn("failed to notify systemd", "state", state, "error", err)
+	}
+}
+
+// watchdogInterval returns how often systemd expects a keep-alive, or 0 when
+// WatchdogSec= is not set for this process.
+func watchdogInterval() time.Duration {
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond
+}
+
+// runWatchdog pings the systemd watchdog at half its interval until ctx is
+// cancelled, so systemd restarts the server when it hangs.
+func runWatchdog(ctx context.Context) {
+	interval := watchdogInterval()
+	if interval <= 0 {
+		return
+	}
+	slog.Info("systemd watchdog enabled", "interval", interval)
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sdNotify("WATCHDOG=1")
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"net"
+	"os"
+	"testing"
+)
+
+func TestFileListeners_TwoSocketUnit(t *testing.T) {
+	var files []*os.File
+	var addrs []string
+	for _, address := range []string{"127.0.0.1:0", "127.0.0.1:0"} {
+		ln, err := net.Listen("tcp", address)
+		if err != nil {
+			t.Fatalf("Listen failed: %v", err)
+		}
+		f, err := ln.(*net.TCPListener).File()
+		if err != nil {
+			t.Fatalf("File failed: %v", err)
+		}
+		_ = ln.Close()
+		files = append(files, f)
+		addrs = append(addrs, ln.Addr().String())
+	}
+
+	listeners, err := fileListeners(files, []string{"goframe.socket", "goframe.socket"})
+	if err != nil {
+		t.Fatalf("fileListeners failed: %v", err)
+	}
+	taken, err := takeListeners(listeners, "goframe.socket")
+	if err != nil {
+		t.Fatalf("takeListeners failed: %v", err)
+	}
+	if len(taken) != 2 {
+		t.Fatalf("expected both sockets of the unit, got %d", len(taken))
+	}
+	for i, ln := range taken {
+		defer func() { _ = ln.Close() }()
+		if ln.Addr().String() != addrs[i] {
+			t.Errorf("expected socket %d on %s, got %s", i, addrs[i], ln.Addr())
+		}
+		conn, err := net.Dial("tcp", addrs[i])
+		if err != nil {
+			t.Errorf("expected socket %d to accept connections: %v", i, err)
+			continue
+		}
+		_ = conn.Close()
+	}
+	if _, err := takeListeners(listeners, "goframe.socket"); err == nil {
+		t.Error("expected the sockets to be taken only once")
+	}
+}
@@ -1,13 +1,25 @@
 package apihandler
 
 import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"mime/multipart"
 	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
+	"unicode"
 
+	"github.com/jo-hoe/goframe/internal/config"
 	"github.com/jo-hoe/goframe/internal/core"
+	"github.com/jo-hoe/goframe/internal/database"
 
 	"github.com/labstack/echo/v4"
 )
@@ -24,38 +36,710 @@ func NewAPIService(coreService *core.CoreService) *APIService {
 	}
 }
 
-// SetRoutes registers all API routes on the given Echo instance.
+// SetRoutes registers the public API routes on the given Echo instance.
 func (s *APIService) SetRoutes(e *echo.Echo) {
 	e.GET("/probe", func(c echo.Context) error {
 		return c.String(200, "API Service is running")
 	})
 
-	e.GET("/api/image.png", s.handleGetCurrentImage)
+	getWithHead(e, "/api/image", s.handleGetCurrentImage)
+	getWithHead(e, "/api/image.png", s.handleGetCurrentImage)
+	getWithHead(e, "/api/devices/:name/image.png", s.handleGetDeviceProfileImage)
+	e.GET("/api/devices", s.handleListDevices)
+	e.POST("/api/devices", s.handleRegisterDevice)
+	getWithHead(e, "/api/image.jpg", s.handleGetCurrentImage)
+	getWithHead(e, "/api/image.bmp", s.handleGetCurrentImage)
+	getWithHead(e, "/api/image.bin", s.handleGetCurrentImage)
+	e.GET("/api/image/next", s.handleWaitForImageChange)
+	e.GET("/api/image/patch", s.handleGetCurrentImagePatch)
+	e.GET("/api/rotation", s.handleGetRotation)
+	e.GET("/api/rotation/simulate", s.handleSimulateRotation)
+	e.GET("/api/schedules", s.handleGetSchedules)
 	e.POST("/api/image", s.handleUploadImage)
-	e.GET("/api/images/:id/processed.png", s.handleGetProcessedImageByID)
-	e.GET("/api/images/:id/original.png", s.handleGetOriginalImageByID)
+	e.GET("/api/estimate", s.handleEstimateProcessing)
+	getWithHead(e, "/api/images/:id/processed.png", s.handleGetProcessedImageByID)
+	getWithHead(e, "/api/images/:id/processed.jpg", s.handleGetProcessedImageAs)
+	getWithHead(e, "/api/images/:id/processed.bmp", s.handleGetProcessedImageAs)
+	getWithHead(e, "/api/images/:id/processed.bin", s.handleGetProcessedImageAs)
+	getWithHead(e, "/api/images/:id/original.png", s.handleGetOriginalImageByID)
+	getWithHead(e, "/api/images/:id/preview.png", s.handleGetDitherPreview)
 	e.GET("/api/images", s.handleListImages)
+	e.GET("/api/images/:id", s.handleGetImageDetails)
 	e.DELETE("/api/images/:id", s.handleDeleteImageByID)
+	e.PATCH("/api/images/:id", s.handlePatchImage)
+	e.POST("/api/images/:id/position", s.handleMoveImage)
+	e.GET("/api/images/:id/similar", s.handleGetSimilarImages)
+	e.GET("/api/images/:id/events", s.handleGetImageEvents)
+	e.PUT("/api/images/:id/alt", s.handlePutAltText)
+	e.PUT("/api/images/:id/fit", s.handlePutFit)
+	e.PUT("/api/images/:id/flat-art", s.handlePutFlatArt)
+	e.PUT("/api/images/:id/crop", s.handlePutCrop)
+	e.GET("/api/crop-presets", s.handleListCropPresets)
+	e.POST("/api/images/archive", s.handleArchiveImages)
+	e.POST("/api/images/unarchive", s.handleUnarchiveImages)
+	e.POST("/api/images/bulk/upload", s.handleBulkUpload)
+	e.POST("/api/images/bulk/delete", s.handleBulkDelete)
+	e.POST("/api/images/bulk/reprocess", s.handleBulkReprocess)
+	e.POST("/api/reprocess", s.handleStartReprocessAll)
+	e.GET("/api/reprocess", s.handleGetReprocessProgress)
+	e.GET("/api/reprocess/events", s.handleReprocessEvents)
+	e.DELETE("/api/reprocess", s.handleCancelReprocessAll)
+	e.POST("/api/demo", s.handleSeedDemo)
+	e.DELETE("/api/demo", s.handleRemoveDemo)
+	e.GET("/api/moderation/pending", s.handleListPendingImages)
+	e.POST("/api/moderation/:id/approve", s.handleApproveImage)
+	e.POST("/api/moderation/:id/reject", s.handleRejectImage)
+	e.GET("/api/sources", s.handleListSources)
+	e.POST("/api/sources/:name/enable", s.handleEnableSource)
+	e.POST("/api/sources/:name/disable", s.handleDisableSource)
+	e.GET("/api/version", s.handleGetVersion)
+	e.GET("/api/sync/changes", s.handleGetChanges)
+	getWithHead(e, core.BlobURLPrefix+":file", s.handleGetBlob)
+	e.GET("/api/groups", s.handleListFrameGroups)
+	e.PUT("/api/groups/:name", s.handlePutFrameGroup)
+	e.DELETE("/api/groups/:name", s.handleDeleteFrameGroup)
+	e.GET("/api/stories", s.handleListStories)
+	e.PUT("/api/stories/:name", s.handlePutStory)
+	e.DELETE("/api/stories/:name", s.handleDeleteStory)
+	e.GET("/api/mats", s.handleListMats)
+	e.PUT("/api/mat", s.handlePutMat)
+	e.DELETE("/api/mat", s.handleDeleteMat)
+	getWithHead(e, "/api/calibration/chart.png", s.handleGetCalibrationChart)
+	e.GET("/api/calibrations", s.handleListCalibrations)
+	e.GET("/api/calibration", s.handleGetCalibration)
+	e.PUT("/api/calibration", s.handlePutCalibration)
+	e.POST("/api/calibration/photo", s.handleCalibrateFromPhoto)
+	e.DELETE("/api/calibration", s.handleDeleteCalibration)
+	e.GET("/api/import/directories", s.handleListImportDirectories)
+	e.GET("/api/import/files", s.handleScanImportDirectory)
+	e.POST("/api/import", s.handleImportFiles)
+	e.GET("/api/history", s.handleGetDisplayHistory)
+	e.GET("/api/stats", s.handleGetStats)
+	e.GET("/api/storage", s.handleGetStorage)
+	e.POST("/api/storage/cleanup", s.handleCleanUpStorage)
+	getWithHead(e, "/api/timelapse.gif", s.handleGetTimelapse)
+	getWithHead(e, "/api/testpattern", s.handleGetTestPattern)
 }
 
+// SetManagementRoutes registers metrics and admin routes. They are served by the
+// regular listeners unless dedicated management listeners are configured.
+func (s *APIService) SetManagementRoutes(e *echo.Echo) {
+	e.GET("/api/metrics", s.handleGetMetrics)
+	e.GET("/api/config", s.handleGetConfig)
+	e.GET("/api/admin/gc", s.handleGetGarbageReport)
+	e.GET("/api/integrations", s.handleGetIntegrations)
+	e.GET("/api/admin/quotas", s.handleListQuotas, s.requireQuotaAdmin)
+	e.DELETE("/api/admin/quotas/:key", s.handleResetQuota, s.requireQuotaAdmin)
+}
+
+// handleGetConfig returns the effective configuration with secrets redacted and
+// the source of every value: file, env, default or database.
+func (s *APIService) handleGetConfig(ctx echo.Context) error {
+	settings, err := s.coreService.GetEffectiveConfig(ctx.Request().Context())
+	if err != nil {
+		slog.Error("failed to get effective configuration", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to get configuration")
+	}
+	return ctx.JSON(http.StatusOK, map[string]any{"settings": settings})
+}
+
+// handleGetGarbageReport runs a dry-run garbage collection and lists the blobs
+// the next collection would delete.
+// handleGetIntegrations reports the health of the external services goframe calls.
+func (s *APIService) handleGetIntegrations(ctx echo.Context) error {
+	return ctx.JSON(http.StatusOK, s.coreService.GetIntegrationHealth())
+}
+
+func (s *APIService) handleGetGarbageReport(ctx echo.Context) error {
+	report, err := s.coreService.CollectGarbage(ctx.Request().Context(), true)
+	if err != nil {
+		slog.Error("failed to find orphaned blobs", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to find orphaned blobs")
+	}
+	return ctx.JSON(http.StatusOK, report)
+}
+
+// handleGetCurrentImage redirects to the image to display, as a PNG, JPEG,
+// BMP or packed palette indices by the route's extension or in the configured
+// imageTargetType without one.
+// Devices identify themselves with ?device=<id> so frame groups can keep
+// members in sync, and may report their battery level with ?battery=<percent>
+// for the overlay.
 func (s *APIService) handleGetCurrentImage(ctx echo.Context) error {
-	now := time.Now()
-	imageID, err := s.coreService.GetImageForTime(ctx.Request().Context(), now)
+	device := ctx.QueryParam("device")
+	if problem := s.reportDevice(ctx, device); problem != "" {
+		return ctx.String(http.StatusBadRequest, problem)
+	}
+	imageID, err := s.coreService.GetImageForDevice(ctx.Request().Context(), device)
 	if err != nil {
-		slog.Error("failed to get current image id", "error", err, "at", now, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		slog.Error("failed to get current image id", "device", device, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
 		return ctx.String(http.StatusInternalServerError, "Failed to get current image")
 	}
 
-	imageURL, err := s.coreService.GetImageURL(ctx.Request().Context(), imageID, "processed")
+	format, ok := core.FormatForExtension(path.Ext(ctx.Path()))
+	if !ok {
+		format = s.coreService.ImageTargetFormat()
+	}
+	imageURL, err := s.coreService.GetDeviceImageURLAs(ctx.Request().Context(), device, imageID, format)
 	if err != nil {
 		slog.Error("failed to get image url", "imageId", imageID, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
 		return ctx.String(http.StatusInternalServerError, "Failed to get image URL")
 	}
+	s.coreService.RunPreServeHooks(ctx.Request().Context(), device, imageID)
+
+	// The current image changes on rotation, so the redirect itself must not be cached.
+	ctx.Response().Header().Set("Cache-Control", "no-cache")
+	return ctx.Redirect(http.StatusFound, imageURL)
+}
+
+// Bounds of the ?timeout of /api/image/next.
+const (
+	defaultImageWaitTimeout = 55 * time.Second
+	maxImageWaitTimeout     = 5 * time.Minute
+)
+
+// handleWaitForImageChange is a long poll for always-on displays. It answers
+// like /api/image as soon as the image the device should show no longer has
+// the ETag ?since, and with 304 Not Modified once ?timeout (default 55s, at
+// most 5m) has passed. Without ?since it answers at once.
+func (s *APIService) handleWaitForImageChange(ctx echo.Context) error {
+	timeout := defaultImageWaitTimeout
+	if raw := ctx.QueryParam("timeout"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 {
+			slog.Info("invalid wait timeout", "timeout", raw, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+			return ctx.String(http.StatusBadRequest, "Invalid timeout")
+		}
+		timeout = min(parsed, maxImageWaitTimeout)
+	}
+	device := ctx.QueryParam("device")
+	if problem := s.reportDevice(ctx, device); problem != "" {
+		return ctx.String(http.StatusBadRequest, problem)
+	}
+
+	reqCtx := ctx.Request().Context()
+	waitCtx, cancel := context.WithTimeout(reqCtx, timeout)
+	defer cancel()
+	image, changed, err := s.coreService.WaitForImageChange(waitCtx, device, imageTagFromETag(ctx.QueryParam("since")))
+	if err != nil {
+		slog.Error("failed to wait for image change", "device", device, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to get current image")
+	}
+	if reqCtx.Err() != nil {
+		// The device hung up.
+		return nil
+	}
 
+	format := s.coreService.ImageTargetFormat()
+	header := ctx.Response().Header()
+	header.Set("Cache-Control", "no-cache")
+	if isSHA256Hex(image.Tag) {
+		header.Set("ETag", blobETag(image.Tag, format))
+	}
+	if !changed {
+		return ctx.NoContent(http.StatusNotModified)
+	}
+	imageURL, err := s.coreService.GetDeviceImageURLAs(reqCtx, device, image.ID, format)
+	if err != nil {
+		slog.Error("failed to get image url", "imageId", image.ID, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to get image URL")
+	}
+	s.coreService.RunPreServeHooks(reqCtx, device, image.ID)
 	return ctx.Redirect(http.StatusFound, imageURL)
 }
 
+// imageTagFromETag returns the tag of core.DeviceImage an ETag stands for: the
+// blob ETags of other formats carry the extension after the hash.
+func imageTagFromETag(etag string) string {
+	etag = strings.Trim(strings.TrimPrefix(etag, "W/"), `"`)
+	if hash := strings.TrimSuffix(etag, path.Ext(etag)); isSHA256Hex(hash) {
+		return hash
+	}
+	return etag
+}
+
+// handleGetDeviceProfileImage serves the current image rendered with the
+// pipeline of the device profile :name.
+func (s *APIService) handleGetDeviceProfileImage(ctx echo.Context) error {
+	name := ctx.Param("name")
+	if problem := s.reportDevice(ctx, name); problem != "" {
+		return ctx.String(http.StatusBadRequest, problem)
+	}
+	imageID, data, err := s.coreService.GetDeviceProfileImage(ctx.Request().Context(), name)
+	if errors.Is(err, core.ErrDeviceProfileNotFound) {
+		return ctx.String(http.StatusNotFound, "Device profile not found")
+	}
+	if err != nil {
+		slog.Error("failed to render device profile image", "profile", name, "imageId", imageID, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to get current image")
+	}
+	s.coreService.RunPreServeHooks(ctx.Request().Context(), name, imageID)
+	// The current image changes on rotation, so the response must be revalidated.
+	ctx.Response().Header().Set("Cache-Control", "no-cache")
+	return serveImage(ctx, "image/png", data)
+}
+
+func (s *APIService) handleListDevices(ctx echo.Context) error {
+	devices, err := s.coreService.GetDevices(ctx.Request().Context())
+	if err != nil {
+		slog.Error("failed to list devices", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to list devices")
+	}
+	ctx.Response().Header().Set("Cache-Control", "no-cache")
+	return ctx.JSON(http.StatusOK, devices)
+}
+
+// deviceItem is a device registration as exchanged by POST /api/devices;
+// RegisteredAt is set by the server.
+type deviceItem struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	RegisteredAt time.Time `json:"registeredAt"`
+}
+
+// handleRegisterDevice registers a frame, or renames a registered one.
+func (s *APIService) handleRegisterDevice(ctx echo.Context) error {
+	var req deviceItem
+	if err := ctx.Bind(&req); err != nil {
+		slog.Info("invalid device body", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusBadRequest, "Invalid device body")
+	}
+	device, err := s.coreService.RegisterDevice(ctx.Request().Context(), database.Device{ID: req.ID, Name: req.Name})
+	if err != nil {
+		if errors.Is(err, core.ErrInvalidDevice) {
+			return ctx.String(http.StatusBadRequest, err.Error())
+		}
+		slog.Error("failed to register device", "device", req.ID, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to register device")
+	}
+	return ctx.JSON(http.StatusOK, deviceItem{ID: device.ID, Name: device.Name, RegisteredAt: device.RegisteredAt})
+}
+
+// handleGetRotation tells a device (?device=<id>) which image to show and when
+// the next rotation happens, so it can schedule its next wake-up.
+func (s *APIService) handleGetRotation(ctx echo.Context) error {
+	device := ctx.QueryParam("device")
+	if problem := s.reportDevice(ctx, device); problem != "" {
+		return ctx.String(http.StatusBadRequest, problem)
+	}
+	info, err := s.coreService.GetRotationInfo(ctx.Request().Context(), device)
+	if err != nil {
+		slog.Error("failed to get rotation info", "device", device, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to get rotation info")
+	}
+	ctx.Response().Header().Set("Cache-Control", "no-cache")
+	return ctx.JSON(http.StatusOK, info)
+}
+
+// handleSimulateRotation lists the image a device (?device=<id>) will show on
+// each of the next ?days= days (default 30).
+func (s *APIService) handleSimulateRotation(ctx echo.Context) error {
+	days, err := intQueryParam(ctx, "days", 30)
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "Invalid days")
+	}
+	sim, err := s.coreService.SimulateRotation(ctx.Request().Context(), ctx.QueryParam("device"), days)
+	if errors.Is(err, core.ErrInvalidSimulationDays) {
+		return ctx.String(http.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		slog.Error("failed to simulate rotation", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to simulate rotation")
+	}
+	ctx.Response().Header().Set("Cache-Control", "no-cache")
+	return ctx.JSON(http.StatusOK, sim)
+}
+
+// handleGetSchedules lists when each image of the rotation order is shown.
+func (s *APIService) handleGetSchedules(ctx echo.Context) error {
+	schedules, err := s.coreService.GetImageSchedules(ctx.Request().Context())
+	if err != nil {
+		slog.Error("failed to get image schedules", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to get image schedules")
+	}
+	ctx.Response().Header().Set("Cache-Control", "no-cache")
+	return ctx.JSON(http.StatusOK, schedules)
+}
+
+// handleGetCurrentImagePatch returns the rectangles that changed between the
+// processed image identified by ?since=<sha256> and the current one, so panels
+// supporting partial refresh only redraw what changed.
+func (s *APIService) handleGetCurrentImagePatch(ctx echo.Context) error {
+	since := ctx.QueryParam("since")
+	if since != "" && !isSHA256Hex(since) {
+		slog.Info("invalid since hash", "since", since, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusBadRequest, "Invalid since hash")
+	}
+	if problem := s.reportDevice(ctx, ctx.QueryParam("device")); problem != "" {
+		return ctx.String(http.StatusBadRequest, problem)
+	}
+	patch, err := s.coreService.GetCurrentImagePatch(ctx.Request().Context(), ctx.QueryParam("device"), since)
+	if err != nil {
+		slog.Error("failed to compute image patch", "since", since, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to compute image patch")
+	}
+	ctx.Response().Header().Set("Cache-Control", "no-cache")
+	return ctx.JSON(http.StatusOK, patch)
+}
+
+func (s *APIService) handleListFrameGroups(ctx echo.Context) error {
+	groups, err := s.coreService.GetFrameGroups(ctx.Request().Context())
+	if err != nil {
+		slog.Error("failed to list frame groups", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to list frame groups")
+	}
+	return ctx.JSON(http.StatusOK, groups)
+}
+
+// frameGroupRequest is the body accepted by PUT /api/groups/:name.
+type frameGroupRequest struct {
+	Devices []string `json:"devices"`
+	Offset  int      `json:"offset"`
+}
+
+func (s *APIService) handlePutFrameGroup(ctx echo.Context) error {
+	var req frameGroupRequest
+	if err := ctx.Bind(&req); err != nil {
+		slog.Info("invalid frame group body", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusBadRequest, "Invalid frame group body")
+	}
+	group := database.FrameGroup{Name: ctx.Param("name"), Devices: req.Devices, Offset: req.Offset}
+	if err := s.coreService.SaveFrameGroup(ctx.Request().Context(), group); err != nil {
+		if errors.Is(err, core.ErrInvalidFrameGroup) {
+			return ctx.String(http.StatusBadRequest, err.Error())
+		}
+		slog.Error("failed to save frame group", "group", group.Name, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to save frame group")
+	}
+	return ctx.JSON(http.StatusOK, group)
+}
+
+func (s *APIService) handleDeleteFrameGroup(ctx echo.Context) error {
+	name := ctx.Param("name")
+	if err := s.coreService.DeleteFrameGroup(ctx.Request().Context(), name); err != nil {
+		if errors.Is(err, core.ErrFrameGroupNotFound) {
+			return ctx.String(http.StatusNotFound, "Frame group not found")
+		}
+		slog.Error("failed to delete frame group", "group", name, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to delete frame group")
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// storyItem is a story as exchanged by the story API.
+type storyItem struct {
+	Name     string   `json:"name"`
+	ImageIDs []string `json:"imageIds"`
+}
+
+func (s *APIService) handleListStories(ctx echo.Context) error {
+	stories, err := s.coreService.GetStories(ctx.Request().Context())
+	if err != nil {
+		slog.Error("failed to list stories", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to list stories")
+	}
+	items := make([]storyItem, len(stories))
+	for i, story := range stories {
+		items[i] = storyItem{Name: story.Name, ImageIDs: story.ImageIDs}
+	}
+	return ctx.JSON(http.StatusOK, items)
+}
+
+func (s *APIService) handlePutStory(ctx echo.Context) error {
+	var req storyItem
+	if err := ctx.Bind(&req); err != nil {
+		slog.Info("invalid story body", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusBadRequest, "Invalid story body")
+	}
+	story := database.Story{Name: ctx.Param("name"), ImageIDs: req.ImageIDs}
+	if err := s.coreService.SaveStory(ctx.Request().Context(), story); err != nil {
+		if errors.Is(err, core.ErrInvalidStory) {
+			return ctx.String(http.StatusBadRequest, err.Error())
+		}
+		slog.Error("failed to save story", "story", story.Name, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to save story")
+	}
+	return ctx.JSON(http.StatusOK, storyItem{Name: story.Name, ImageIDs: story.ImageIDs})
+}
+
+func (s *APIService) handleDeleteStory(ctx echo.Context) error {
+	name := ctx.Param("name")
+	if err := s.coreService.DeleteStory(ctx.Request().Context(), name); err != nil {
+		if errors.Is(err, core.ErrStoryNotFound) {
+			return ctx.String(http.StatusNotFound, "Story not found")
+		}
+		slog.Error("failed to delete story", "story", name, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to delete story")
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// matItem is a mat as exchanged by the mat API. Device is empty for the
+// default mat applied to devices without their own.
+type matItem struct {
+	Device       string `json:"device"`
+	Color        string `json:"color"`
+	Width        int    `json:"width"`
+	CornerRadius int    `json:"cornerRadius"`
+}
+
+func (s *APIService) handleListMats(ctx echo.Context) error {
+	mats, err := s.coreService.GetMats(ctx.Request().Context())
+	if err != nil {
+		slog.Error("failed to list mats", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to list mats")
+	}
+	items := make([]matItem, 0, len(mats))
+	for device, mat := range mats {
+		items = append(items, matItem{Device: device, Color: mat.Color, Width: mat.Width, CornerRadius: mat.CornerRadius})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Device < items[j].Device })
+	return ctx.JSON(http.StatusOK, items)
+}
+
+// handlePutMat sets the mat for ?device=<id>, or the default for all devices
+// when device is omitted.
+func (s *APIService) handlePutMat(ctx echo.Context) error {
+	var req matItem
+	if err := ctx.Bind(&req); err != nil {
+		slog.Info("invalid mat body", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusBadRequest, "Invalid mat body")
+	}
+	device := ctx.QueryParam("device")
+	mat := database.Mat{Color: req.Color, Width: req.Width, CornerRadius: req.CornerRadius}
+	if err := s.coreService.SaveMat(ctx.Request().Context(), device, mat); err != nil {
+		if errors.Is(err, core.ErrInvalidMat) {
+			return ctx.String(http.StatusBadRequest, err.Error())
+		}
+		slog.Error("failed to save mat", "device", device, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to save mat")
+	}
+	req.Device = device
+	return ctx.JSON(http.StatusOK, req)
+}
+
+func (s *APIService) handleDeleteMat(ctx echo.Context) error {
+	device := ctx.QueryParam("device")
+	if err := s.coreService.DeleteMat(ctx.Request().Context(), device); err != nil {
+		slog.Error("failed to delete mat", "device", device, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to delete mat")
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// handleGetCalibrationChart renders the chart to show on a frame while calibrating it.
+func (s *APIService) handleGetCalibrationChart(ctx echo.Context) error {
+	data, err := s.coreService.GetCalibrationChart()
+	if err != nil {
+		slog.Error("failed to render calibration chart", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to render calibration chart")
+	}
+	ctx.Response().Header().Set("Cache-Control", "no-cache")
+	return serveImage(ctx, "image/png", data)
+}
+
+// handleListCalibrations lists the devices with a calibrated palette.
+func (s *APIService) handleListCalibrations(ctx echo.Context) error {
+	palettes, err := s.coreService.GetPalettes(ctx.Request().Context())
+	if err != nil {
+		slog.Error("failed to list calibrations", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to list calibrations")
+	}
+	items := make([]core.Calibration, 0, len(palettes))
+	for device, palette := range palettes {
+		items = append(items, core.Calibration{Device: device, Colors: palette.Colors, CalibratedAt: &palette.CalibratedAt})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Device < items[j].Device })
+	return ctx.JSON(http.StatusOK, items)
+}
+
+// handleGetCalibration returns the palette ?device=<id> is dithered with.
+func (s *APIService) handleGetCalibration(ctx echo.Context) error {
+	device := ctx.QueryParam("device")
+	calibration, err := s.coreService.GetCalibration(ctx.Request().Context(), device)
+	switch {
+	case errors.Is(err, core.ErrInvalidCalibration):
+		return ctx.String(http.StatusBadRequest, err.Error())
+	case err != nil:
+		slog.Error("failed to get calibration", "device", device, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to get calibration")
+	}
+	return ctx.JSON(http.StatusOK, calibration)
+}
+
+// calibrationRequest lists the colors the panel shows for the chart's patches.
+type calibrationRequest struct {
+	Measured []string `json:"measured"`
+}
+
+// handlePutCalibration calibrates ?device=<id> from the colors its panel shows
+// for the patches of the calibration chart.
+func (s *APIService) handlePutCalibration(ctx echo.Context) error {
+	var req calibrationRequest
+	if err := ctx.Bind(&req); err != nil {
+		slog.Info("invalid calibration body", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusBadRequest, "Invalid calibration body")
+	}
+	device := ctx.QueryParam("device")
+	palette, err := s.coreService.CalibratePalette(ctx.Request().Context(), device, req.Measured)
+	return s.calibrationResponse(ctx, device, palette, err)
+}
+
+// handleCalibrateFromPhoto calibrates ?device=<id> from a photo of its panel
+// showing the calibration chart, uploaded as the multipart field "photo".
+func (s *APIService) handleCalibrateFromPhoto(ctx echo.Context) error {
+	file, err := ctx.FormFile("photo")
+	if err != nil {
+		slog.Info("no photo provided in multipart form", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusBadRequest, "No photo provided")
+	}
+	src, err := file.Open()
+	if err != nil {
+		slog.Error("failed to open photo", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to read photo")
+	}
+	defer func() { _ = src.Close() }()
+	photo, err := io.ReadAll(src)
+	if err != nil {
+		slog.Error("failed to read photo", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to read photo")
+	}
+	device := ctx.QueryParam("device")
+	palette, err := s.coreService.CalibratePaletteFromPhoto(ctx.Request().Context(), device, photo)
+	return s.calibrationResponse(ctx, device, palette, err)
+}
+
+func (s *APIService) calibrationResponse(ctx echo.Context, device string, palette database.Palette, err error) error {
+	switch {
+	case errors.Is(err, core.ErrInvalidCalibration):
+		return ctx.String(http.StatusBadRequest, err.Error())
+	case err != nil:
+		slog.Error("failed to calibrate palette", "device", device, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to calibrate palette")
+	}
+	return ctx.JSON(http.StatusOK, core.Calibration{Device: device, Colors: palette.Colors, CalibratedAt: &palette.CalibratedAt})
+}
+
+func (s *APIService) handleDeleteCalibration(ctx echo.Context) error {
+	device := ctx.QueryParam("device")
+	if err := s.coreService.DeletePalette(ctx.Request().Context(), device); err != nil {
+		slog.Error("failed to delete calibration", "device", device, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to delete calibration")
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (s *APIService) handleListImportDirectories(ctx echo.Context) error {
+	dirs := s.coreService.ImportDirectories()
+	if dirs == nil {
+		dirs = []string{}
+	}
+	return ctx.JSON(http.StatusOK, dirs)
+}
+
+func (s *APIService) handleScanImportDirectory(ctx echo.Context) error {
+	dir := ctx.QueryParam("dir")
+	scan, err := s.coreService.ScanImportDirectory(dir)
+	if err != nil {
+		if errors.Is(err, core.ErrImportDirectoryNotAllowed) {
+			return ctx.String(http.StatusForbidden, "Directory is not configured for bulk import")
+		}
+		slog.Error("failed to scan import directory", "dir", dir, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to scan directory")
+	}
+	return ctx.JSON(http.StatusOK, scan)
+}
+
+// importRequest is the body accepted by POST /api/import.
+type importRequest struct {
+	Directory string   `json:"directory"`
+	Files     []string `json:"files"`
+	// Order is the order the files join the rotation in (see core.ImportOrder).
+	Order string `json:"order"`
+}
+
+// handleImportFiles imports files from a server-side directory. The response
+// lists the outcome per file; individual failures do not fail the request.
+func (s *APIService) handleImportFiles(ctx echo.Context) error {
+	var req importRequest
+	if err := ctx.Bind(&req); err != nil || len(req.Files) == 0 {
+		slog.Info("invalid import request", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusBadRequest, "Invalid import request")
+	}
+	order, err := core.ParseImportOrder(req.Order)
+	if err != nil {
+		slog.Info("invalid import order", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusBadRequest, "Invalid import order")
+	}
+	results, err := s.coreService.ImportFiles(ctx.Request().Context(), req.Directory, req.Files, order)
+	if err != nil {
+		if errors.Is(err, core.ErrImportDirectoryNotAllowed) {
+			return ctx.String(http.StatusForbidden, "Directory is not configured for bulk import")
+		}
+		slog.Error("failed to import files", "dir", req.Directory, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to import files")
+	}
+	return ctx.JSON(http.StatusOK, results)
+}
+
+// handleGetBlob serves an image by its SHA-256 digest. The content behind a
+// digest never changes, so responses are marked immutable for CDNs and proxies.
+func (s *APIService) handleGetBlob(ctx echo.Context) error {
+	ext := path.Ext(ctx.Param("file"))
+	hash := strings.TrimSuffix(ctx.Param("file"), ext)
+	format, ok := core.FormatForExtension(ext)
+	if !ok || !isSHA256Hex(hash) {
+		slog.Info("invalid blob name", "file", ctx.Param("file"), "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusNotFound, "Image not found")
+	}
+
+	etag := blobETag(hash, format)
+	header := ctx.Response().Header()
+	header.Set("ETag", etag)
+	header.Set("Cache-Control", "public, max-age=31536000, immutable")
+	if match := ctx.Request().Header.Get("If-None-Match"); match == etag || match == "*" {
+		return ctx.NoContent(http.StatusNotModified)
+	}
+
+	data, err := s.coreService.GetBlobByHashAs(ctx.Request().Context(), hash, format)
+	if err != nil {
+		header.Del("ETag")
+		header.Del("Cache-Control")
+		slog.Info("blob not found", "hash", hash, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusNotFound, "Image not found")
+	}
+	return serveImage(ctx, format.ContentType(), data)
+}
+
+// blobETag returns the ETag of the blob whose PNG has the digest hash in the
+// given format. The hash names the PNG, so other formats get an ETag of their
+// own.
+func blobETag(hash string, format core.ImageFormat) string {
+	if format == core.FormatPNG {
+		return `"` + hash + `"`
+	}
+	return `"` + hash + format.Extension() + `"`
+}
+
+// isSHA256Hex reports whether s is a lowercase hex-encoded SHA-256 digest.
+func isSHA256Hex(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
 func (s *APIService) handleUploadImage(ctx echo.Context) error {
+	// Parts above the spool threshold are buffered in temp files instead of memory.
+	if err := ctx.Request().ParseMultipartForm(s.coreService.UploadSpoolThreshold()); err != nil {
+		slog.Info("invalid multipart form", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusBadRequest, "Invalid multipart form")
+	}
 	form, err := ctx.MultipartForm()
 	if err != nil {
 		slog.Info("invalid multipart form", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
@@ -75,33 +759,75 @@ func (s *APIService) handleUploadImage(ctx echo.Context) error {
 		return ctx.String(http.StatusBadRequest, "No file provided")
 	}
 
+	quotaKey := core.QuotaKey(ctx.Request().Header.Get("X-API-Key"), ctx.RealIP())
+	releaseQuota, err := s.coreService.ReserveUploadQuota(quotaKey, fh.Size)
+	if err != nil {
+		slog.Info("upload rejected by quota", "client", quotaKey, "sizeBytes", fh.Size, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return quotaErrorResponse(ctx, err)
+	}
+
 	src, err := fh.Open()
 	if err != nil {
+		releaseQuota()
 		slog.Error("failed to open uploaded file", "file", fh.Filename, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
 		return ctx.String(http.StatusInternalServerError, "Failed to open uploaded file")
 	}
 	defer func() { _ = src.Close() }()
 
-	data, err := io.ReadAll(src)
+	source := firstFormValue(form, "source")
+	attribution := database.Attribution{
+		SourceURL: firstFormValue(form, "sourceUrl"),
+		Author:    firstFormValue(form, "author"),
+		License:   firstFormValue(form, "license"),
+	}
+
+	apiImg, err := s.coreService.AddImageFromReader(ctx.Request().Context(), src, source, attribution)
 	if err != nil {
-		slog.Error("failed to read uploaded file", "file", fh.Filename, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
-		return ctx.String(http.StatusInternalServerError, "Failed to read uploaded file")
+		releaseQuota()
+		if errors.Is(err, core.ErrFormatDisabled) {
+			slog.Info("upload rejected: format disabled", "file", fh.Filename, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+			return ctx.String(http.StatusUnsupportedMediaType, err.Error())
+		}
+		if errors.Is(err, core.ErrUploadRejected) {
+			slog.Info("upload rejected by hook", "file", fh.Filename, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+			return ctx.String(http.StatusUnprocessableEntity, err.Error())
+		}
+		if errors.Is(err, core.ErrSVGLimit) {
+			slog.Info("upload rejected: svg exceeds limits", "file", fh.Filename, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+			return ctx.String(http.StatusUnprocessableEntity, err.Error())
+		}
+		slog.Error("failed to process uploaded image", "file", fh.Filename, "sizeBytes", fh.Size, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to process uploaded image")
 	}
 
-	source := ""
-	if sv := form.Value["source"]; len(sv) > 0 {
-		source = sv[0]
+	status := "active"
+	if apiImg.Pending {
+		status = "pending"
+	}
+	response := map[string]any{
+		"id":     apiImg.ID,
+		"status": status,
 	}
+	if len(apiImg.Similar) > 0 {
+		response["similar"] = apiImg.Similar
+	}
+	return ctx.JSON(http.StatusCreated, response)
+}
 
-	apiImg, err := s.coreService.AddImage(ctx.Request().Context(), data, source)
-	if err != nil {
-		slog.Error("failed to process uploaded image", "file", fh.Filename, "sizeBytes", len(data), "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
-		return ctx.String(http.StatusInternalServerError, "Failed to process uploaded image")
+// quotaErrorResponse maps quota errors to 429 (too many uploads) or 413 (too many bytes).
+func quotaErrorResponse(ctx echo.Context, err error) error {
+	if errors.Is(err, core.ErrStorageQuotaExceeded) {
+		return ctx.String(http.StatusRequestEntityTooLarge, "Storage quota exceeded")
 	}
+	return ctx.String(http.StatusTooManyRequests, "Upload quota exceeded")
+}
 
-	return ctx.JSON(http.StatusCreated, map[string]string{
-		"id": apiImg.ID,
-	})
+// firstFormValue returns the first value of the named multipart field, or "" if absent.
+func firstFormValue(form *multipart.Form, key string) string {
+	if v := form.Value[key]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
 }
 
 func (s *APIService) handleGetProcessedImageByID(ctx echo.Context) error {
@@ -110,6 +836,14 @@ func (s *APIService) handleGetProcessedImageByID(ctx echo.Context) error {
 		slog.Info("missing image id parameter", "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
 		return ctx.String(http.StatusBadRequest, "Missing image id")
 	}
+	if s.coreService.ServesProcessedOnDemand() {
+		data, err := s.coreService.GetProcessedImage(ctx.Request().Context(), id)
+		if err != nil {
+			slog.Info("processed image not available", "imageId", id, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+			return ctx.String(http.StatusNotFound, "Image not found")
+		}
+		return serveImage(ctx, "image/png", data)
+	}
 	imageURL, err := s.coreService.GetImageURL(ctx.Request().Context(), id, "processed")
 	if err != nil {
 		slog.Info("processed image not found", "imageId", id, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
@@ -118,12 +852,39 @@ func (s *APIService) handleGetProcessedImageByID(ctx echo.Context) error {
 	return ctx.Redirect(http.StatusFound, imageURL)
 }
 
+// handleGetProcessedImageAs serves the processed image in the format of the
+// route's extension; only PNGs are stored, so it is encoded on request.
+func (s *APIService) handleGetProcessedImageAs(ctx echo.Context) error {
+	id := ctx.Param("id")
+	format, ok := core.FormatForExtension(path.Ext(ctx.Path()))
+	if id == "" || !ok {
+		slog.Info("missing image id parameter", "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusBadRequest, "Missing image id")
+	}
+	data, err := s.coreService.GetProcessedImageAs(ctx.Request().Context(), id, format)
+	if err != nil {
+		slog.Info("processed image not available", "imageId", id, "format", format, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusNotFound, "Image not found")
+	}
+	return serveImage(ctx, format.ContentType(), data)
+}
+
 func (s *APIService) handleGetOriginalImageByID(ctx echo.Context) error {
 	id := ctx.Param("id")
 	if id == "" {
 		slog.Info("missing image id parameter", "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
 		return ctx.String(http.StatusBadRequest, "Missing image id")
 	}
+	if s.coreService.ServesOriginalsDirectly() {
+		size, err := s.coreService.OriginalImageSize(ctx.Request().Context(), id)
+		if err != nil {
+			slog.Info("original image not available", "imageId", id, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+			return ctx.String(http.StatusNotFound, "Image not found")
+		}
+		return serveImageStream(ctx, "image/png", size, func() (io.ReadCloser, error) {
+			return s.coreService.OpenOriginalImage(ctx.Request().Context(), id)
+		})
+	}
 	imageURL, err := s.coreService.GetImageURL(ctx.Request().Context(), id, "original")
 	if err != nil {
 		slog.Info("original image not found", "imageId", id, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
@@ -138,38 +899,1006 @@ type imageListItem struct {
 	ProcessedURL string    `json:"processedUrl"`
 	OriginalURL  string    `json:"originalUrl"`
 	Source       string    `json:"source,omitempty"`
+	SourceURL    string    `json:"sourceUrl,omitempty"`
+	Author       string    `json:"author,omitempty"`
+	License      string    `json:"license,omitempty"`
+	Archived     bool      `json:"archived,omitempty"`
+	Pending      bool      `json:"pending,omitempty"`
+	AltText      string    `json:"altText,omitempty"`
+	Title        string    `json:"title,omitempty"`
+	Description  string    `json:"description,omitempty"`
+	Fit          string    `json:"fit,omitempty"`
+	FlatArt      string    `json:"flatArt,omitempty"`
+	// Crop is the region of interest as fractions of the original's size.
+	Crop *database.Region `json:"crop,omitempty"`
+	// Original and Processed describe the renditions so clients need not
+	// download them to learn their size. Fields unknown for older images are
+	// omitted.
+	Original  renditionItem `json:"original"`
+	Processed renditionItem `json:"processed"`
+}
+
+// renditionItem describes the original or processed rendition of an image.
+type renditionItem struct {
+	URL       string    `json:"url"`
+	Width     int       `json:"width,omitempty"`
+	Height    int       `json:"height,omitempty"`
+	Bytes     int64     `json:"bytes,omitempty"`
+	SHA256    string    `json:"sha256,omitempty"`
+	CreatedAt time.Time `json:"createdAt,omitzero"`
+	UpdatedAt time.Time `json:"updatedAt,omitzero"`
+}
+
+func newRenditionItem(url, hash string, createdAt time.Time, r database.Rendition) renditionItem {
+	item := renditionItem{URL: url, Width: r.Width, Height: r.Height, Bytes: r.Bytes, SHA256: hash, UpdatedAt: r.UpdatedAt}
+	if hash != "" || r.Bytes > 0 {
+		// Processed images generated on demand are not stored and have no dates.
+		item.CreatedAt = createdAt
+	}
+	return item
+}
+
+func (s *APIService) imageListItem(ctx context.Context, img *database.Image) imageListItem {
+	processedURL, _ := s.coreService.GetImageURL(ctx, img.ID, "processed")
+	originalURL, _ := s.coreService.GetImageURL(ctx, img.ID, "original")
+	return imageListItem{
+		Original:     newRenditionItem(originalURL, img.OriginalHash, img.CreatedAt, img.Original),
+		Processed:    newRenditionItem(processedURL, img.ProcessedHash, img.CreatedAt, img.Processed),
+		ID:           img.ID,
+		CreatedAt:    img.CreatedAt,
+		ProcessedURL: processedURL,
+		OriginalURL:  originalURL,
+		Source:       img.Source,
+		SourceURL:    img.Attribution.SourceURL,
+		Author:       img.Attribution.Author,
+		License:      img.Attribution.License,
+		Archived:     img.Archived,
+		Pending:      img.Pending,
+		AltText:      img.AltText,
+		Title:        img.Title,
+		Description:  img.Description,
+		Fit:          img.Fit,
+		FlatArt:      img.FlatArt,
+		Crop:         img.Crop,
+	}
+}
+
+// ndjsonFlushEvery is the number of streamed list items between flushes.
+const ndjsonFlushEvery = 100
+
+// wantsNDJSON reports whether the client asked for newline-delimited JSON via
+// ?format=ndjson or an Accept header of application/x-ndjson.
+func wantsNDJSON(ctx echo.Context) bool {
+	return ctx.QueryParam("format") == "ndjson" ||
+		strings.Contains(ctx.Request().Header.Get(echo.HeaderAccept), "application/x-ndjson")
 }
 
+// handleListImages lists images in display order. ?archived=true lists archived
+// images instead, ?archived=all both; ?q= filters by ID, source and attribution.
+// NDJSON clients receive one item per line, flushed as the response is written.
 func (s *APIService) handleListImages(ctx echo.Context) error {
-	images, err := s.coreService.GetOrderedImages(ctx.Request().Context())
+	archived, ok := core.ParseArchiveFilter(ctx.QueryParam("archived"))
+	if !ok {
+		return ctx.String(http.StatusBadRequest, "archived must be true, false or all")
+	}
+	images, err := s.coreService.ListImages(ctx.Request().Context(), core.ImageFilter{Archived: archived, Query: ctx.QueryParam("q")})
 	if err != nil {
 		slog.Error("failed to list images", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
 		return ctx.String(http.StatusInternalServerError, "Failed to list images")
 	}
+	if wantsNDJSON(ctx) {
+		return s.streamImageList(ctx, images)
+	}
 	items := make([]imageListItem, 0, len(images))
 	for _, img := range images {
-		processedURL, _ := s.coreService.GetImageURL(ctx.Request().Context(), img.ID, "processed")
-		originalURL, _ := s.coreService.GetImageURL(ctx.Request().Context(), img.ID, "original")
-		items = append(items, imageListItem{
-			ID:           img.ID,
-			CreatedAt:    img.CreatedAt,
-			ProcessedURL: processedURL,
-			OriginalURL:  originalURL,
-			Source:       img.Source,
-		})
+		items = append(items, s.imageListItem(ctx.Request().Context(), img))
 	}
 	return ctx.JSON(http.StatusOK, items)
 }
 
-func (s *APIService) handleDeleteImageByID(ctx echo.Context) error {
-	id := ctx.Param("id")
-	if id == "" {
-		slog.Info("missing image id parameter for delete", "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
-		return ctx.String(http.StatusBadRequest, "Missing image id")
+// streamImageList writes images as newline-delimited JSON using chunked
+// transfer encoding, so clients can render the first items before the last
+// ones are encoded. It stops early when the client goes away.
+func (s *APIService) streamImageList(ctx echo.Context, images []*database.Image) error {
+	reqCtx := ctx.Request().Context()
+	res := ctx.Response()
+	res.Header().Set(echo.HeaderContentType, "application/x-ndjson")
+	res.Header().Set("X-Content-Type-Options", "nosniff")
+	res.WriteHeader(http.StatusOK)
+
+	enc := json.NewEncoder(res)
+	for i, img := range images {
+		if reqCtx.Err() != nil {
+			return nil
+		}
+		if err := enc.Encode(s.imageListItem(reqCtx, img)); err != nil {
+			slog.Info("stopped streaming image list", "sent", i, "total", len(images), "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+			return nil
+		}
+		if (i+1)%ndjsonFlushEvery == 0 {
+			res.Flush()
+		}
 	}
-	if err := s.coreService.DeleteImage(ctx.Request().Context(), id); err != nil {
+	res.Flush()
+	return nil
+}
+
+type similarImageItem struct {
+	imageListItem
+	Distance int `json:"distance"`
+}
+
+// handleGetImageDetails responds with the list item of a single image.
+func (s *APIService) handleGetImageDetails(ctx echo.Context) error {
+	id := ctx.Param("id")
+	img, err := s.coreService.GetImageDetails(ctx.Request().Context(), id)
+	if errors.Is(err, core.ErrImageNotFound) {
+		return ctx.String(http.StatusNotFound, "Image not found")
+	}
+	if err != nil {
+		slog.Error("failed to get image", "imageId", id, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to get image")
+	}
+	return ctx.JSON(http.StatusOK, s.imageListItem(ctx.Request().Context(), img))
+}
+
+// handlePatchImage updates the title and description of an image with a body
+// such as {"title": "...", "description": "..."}; omitted fields are kept. It
+// responds with the updated image.
+func (s *APIService) handlePatchImage(ctx echo.Context) error {
+	id := ctx.Param("id")
+	var req core.ImageTextUpdate
+	if err := ctx.Bind(&req); err != nil {
+		slog.Info("invalid image update body", "imageId", id, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusBadRequest, "Invalid image update body")
+	}
+	img, err := s.coreService.UpdateImageText(ctx.Request().Context(), id, req)
+	switch {
+	case errors.Is(err, core.ErrInvalidImageText):
+		return ctx.String(http.StatusBadRequest, err.Error())
+	case errors.Is(err, core.ErrImageNotFound):
+		return ctx.String(http.StatusNotFound, "Image not found")
+	case errors.Is(err, core.ErrImageLocked):
+		return ctx.String(http.StatusConflict, err.Error())
+	case err != nil:
+		slog.Error("failed to update image", "imageId", id, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to update image")
+	}
+	return ctx.JSON(http.StatusOK, s.imageListItem(ctx.Request().Context(), img))
+}
+
+// altTextRequest is the body accepted by PUT /api/images/:id/alt.
+type altTextRequest struct {
+	AltText string `json:"altText"`
+}
+
+func (s *APIService) handlePutAltText(ctx echo.Context) error {
+	id := ctx.Param("id")
+	var req altTextRequest
+	if err := ctx.Bind(&req); err != nil {
+		slog.Info("invalid alt text body", "imageId", id, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusBadRequest, "Invalid alt text body")
+	}
+	err := s.coreService.SetAltText(ctx.Request().Context(), id, req.AltText)
+	switch {
+	case errors.Is(err, core.ErrInvalidAltText):
+		return ctx.String(http.StatusBadRequest, err.Error())
+	case errors.Is(err, core.ErrImageNotFound):
+		return ctx.String(http.StatusNotFound, "Image not found")
+	case errors.Is(err, core.ErrImageLocked):
+		return ctx.String(http.StatusConflict, err.Error())
+	case err != nil:
+		slog.Error("failed to set alt text", "imageId", id, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to set alt text")
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// fitRequest is the body accepted by PUT /api/images/:id/fit.
+type fitRequest struct {
+	Fit string `json:"fit"`
+}
+
+// handlePutFit stores the fit preference of an image and reprocesses it.
+func (s *APIService) handlePutFit(ctx echo.Context) error {
+	id := ctx.Param("id")
+	var req fitRequest
+	if err := ctx.Bind(&req); err != nil {
+		slog.Info("invalid fit body", "imageId", id, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusBadRequest, "Invalid fit body")
+	}
+	err := s.coreService.SetImageFit(ctx.Request().Context(), id, req.Fit)
+	switch {
+	case errors.Is(err, core.ErrInvalidFit):
+		return ctx.String(http.StatusBadRequest, err.Error())
+	case errors.Is(err, core.ErrImageNotFound):
+		return ctx.String(http.StatusNotFound, "Image not found")
+	case errors.Is(err, core.ErrImageLocked):
+		return ctx.String(http.StatusConflict, err.Error())
+	case err != nil:
+		slog.Error("failed to set fit", "imageId", id, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to set fit")
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// flatArtRequest is the body accepted by PUT /api/images/:id/flat-art.
+type flatArtRequest struct {
+	FlatArt string `json:"flatArt"`
+}
+
+// handlePutFlatArt stores the flat-art preference of an image and reprocesses it.
+func (s *APIService) handlePutFlatArt(ctx echo.Context) error {
+	id := ctx.Param("id")
+	var req flatArtRequest
+	if err := ctx.Bind(&req); err != nil {
+		slog.Info("invalid flat-art body", "imageId", id, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusBadRequest, "Invalid flat-art body")
+	}
+	err := s.coreService.SetImageFlatArt(ctx.Request().Context(), id, req.FlatArt)
+	switch {
+	case errors.Is(err, core.ErrInvalidFlatArt):
+		return ctx.String(http.StatusBadRequest, err.Error())
+	case errors.Is(err, core.ErrImageNotFound):
+		return ctx.String(http.StatusNotFound, "Image not found")
+	case errors.Is(err, core.ErrImageLocked):
+		return ctx.String(http.StatusConflict, err.Error())
+	case err != nil:
+		slog.Error("failed to set flat art", "imageId", id, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to set flat art")
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// cropRequest is the body accepted by PUT /api/images/:id/crop.
+type cropRequest struct {
+	Crop *database.Region `json:"crop"`
+}
+
+// handlePutCrop stores the region of interest of an image and reprocesses it;
+// a null crop restores the whole image.
+func (s *APIService) handlePutCrop(ctx echo.Context) error {
+	id := ctx.Param("id")
+	var req cropRequest
+	if err := ctx.Bind(&req); err != nil {
+		slog.Info("invalid crop body", "imageId", id, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusBadRequest, "Invalid crop body")
+	}
+	err := s.coreService.SetImageCrop(ctx.Request().Context(), id, req.Crop)
+	switch {
+	case errors.Is(err, core.ErrInvalidCrop):
+		return ctx.String(http.StatusBadRequest, err.Error())
+	case errors.Is(err, core.ErrImageNotFound):
+		return ctx.String(http.StatusNotFound, "Image not found")
+	case errors.Is(err, core.ErrImageLocked):
+		return ctx.String(http.StatusConflict, err.Error())
+	case err != nil:
+		slog.Error("failed to set crop", "imageId", id, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to set crop")
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// cropPresetItem is an aspect ratio offered for cropping and the devices it
+// fills; "" stands for devices without a mat of their own.
+type cropPresetItem struct {
+	Width   int      `json:"width"`
+	Height  int      `json:"height"`
+	Devices []string `json:"devices"`
+}
+
+// handleListCropPresets lists the picture sizes of the panel and of devices
+// whose mat leaves a smaller opening, largest first.
+func (s *APIService) handleListCropPresets(ctx echo.Context) error {
+	presets, err := s.coreService.GetCropPresets(ctx.Request().Context())
+	if err != nil {
+		slog.Error("failed to list crop presets", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to list crop presets")
+	}
+	items := make([]cropPresetItem, 0, len(presets))
+	for _, preset := range presets {
+		items = append(items, cropPresetItem{Width: preset.Width, Height: preset.Height, Devices: preset.DeviceIDs})
+	}
+	return ctx.JSON(http.StatusOK, items)
+}
+
+// handleGetSimilarImages lists images that look near-identical to :id, closest
+// first. ?maxDistance= overrides the configured perceptual hash distance.
+func (s *APIService) handleGetSimilarImages(ctx echo.Context) error {
+	id := ctx.Param("id")
+	maxDistance, err := intQueryParam(ctx, "maxDistance", -1)
+	if err != nil || maxDistance > 64 {
+		return ctx.String(http.StatusBadRequest, "Invalid maxDistance")
+	}
+	similar, err := s.coreService.FindSimilarImages(ctx.Request().Context(), id, maxDistance)
+	switch {
+	case errors.Is(err, core.ErrImageNotFound):
+		return ctx.String(http.StatusNotFound, err.Error())
+	case err != nil:
+		slog.Error("failed to find similar images", "imageId", id, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to find similar images")
+	}
+	items := make([]similarImageItem, 0, len(similar))
+	for _, img := range similar {
+		items = append(items, similarImageItem{imageListItem: s.imageListItem(ctx.Request().Context(), img.Image), Distance: img.Distance})
+	}
+	return ctx.JSON(http.StatusOK, items)
+}
+
+// handleGetImageEvents serves the lifecycle log of an image, oldest first.
+func (s *APIService) handleGetImageEvents(ctx echo.Context) error {
+	id := ctx.Param("id")
+	events, err := s.coreService.GetImageEvents(ctx.Request().Context(), id)
+	switch {
+	case errors.Is(err, core.ErrImageNotFound):
+		return ctx.String(http.StatusNotFound, err.Error())
+	case err != nil:
+		slog.Error("failed to read image events", "imageId", id, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to read image events")
+	}
+	return ctx.JSON(http.StatusOK, events)
+}
+
+// handleStartReprocessAll starts reprocessing every image in the background.
+func (s *APIService) handleStartReprocessAll(ctx echo.Context) error {
+	progress, err := s.coreService.StartReprocessAll(ctx.Request().Context())
+	switch {
+	case errors.Is(err, core.ErrReprocessRunning):
+		return ctx.String(http.StatusConflict, "Reprocessing is already in progress")
+	case err != nil:
+		slog.Error("failed to start reprocessing", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to start reprocessing")
+	}
+	return ctx.JSON(http.StatusAccepted, progress)
+}
+
+func (s *APIService) handleGetReprocessProgress(ctx echo.Context) error {
+	ctx.Response().Header().Set("Cache-Control", "no-cache")
+	return ctx.JSON(http.StatusOK, s.coreService.GetReprocessProgress())
+}
+
+// handleCancelReprocessAll stops a running reprocess-all run; images already
+// being processed are finished.
+func (s *APIService) handleCancelReprocessAll(ctx echo.Context) error {
+	if !s.coreService.CancelReprocessAll() {
+		return ctx.String(http.StatusNotFound, "Reprocessing is not running")
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// handleReprocessEvents streams reprocessing progress as server-sent events:
+// one "progress" event per change, ending after the event that reports the run
+// is no longer running.
+func (s *APIService) handleReprocessEvents(ctx echo.Context) error {
+	updates, unsubscribe := s.coreService.SubscribeReprocessProgress()
+	defer unsubscribe()
+
+	res := ctx.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.WriteHeader(http.StatusOK)
+	reqCtx := ctx.Request().Context()
+	for {
+		select {
+		case <-reqCtx.Done():
+			return nil
+		case progress := <-updates:
+			data, err := json.Marshal(progress)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(res, "event: progress\ndata: %s\n\n", data); err != nil {
+				return nil
+			}
+			res.Flush()
+			if !progress.Running {
+				return nil
+			}
+		}
+	}
+}
+
+// handleSeedDemo adds the embedded sample images and returns their IDs; the
+// list is empty when demo images are already stored.
+func (s *APIService) handleSeedDemo(ctx echo.Context) error {
+	ids, err := s.coreService.SeedDemoImages(ctx.Request().Context())
+	if err != nil {
+		slog.Error("failed to seed demo images", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to seed demo images")
+	}
+	if ids == nil {
+		ids = []string{}
+	}
+	return ctx.JSON(http.StatusOK, map[string][]string{"ids": ids})
+}
+
+// handleRemoveDemo deletes all demo images.
+func (s *APIService) handleRemoveDemo(ctx echo.Context) error {
+	removed, err := s.coreService.RemoveDemoImages(ctx.Request().Context())
+	if err != nil {
+		slog.Error("failed to remove demo images", "removed", removed, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to remove demo images")
+	}
+	return ctx.JSON(http.StatusOK, map[string]int{"removed": removed})
+}
+
+// handleListPendingImages lists images awaiting moderation, newest first.
+func (s *APIService) handleListPendingImages(ctx echo.Context) error {
+	images, err := s.coreService.GetPendingImages(ctx.Request().Context())
+	if err != nil {
+		slog.Error("failed to list pending images", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to list pending images")
+	}
+	items := make([]imageListItem, 0, len(images))
+	for _, img := range images {
+		items = append(items, s.imageListItem(ctx.Request().Context(), img))
+	}
+	return ctx.JSON(http.StatusOK, items)
+}
+
+func (s *APIService) handleApproveImage(ctx echo.Context) error {
+	return s.handleModerate(ctx, s.coreService.ApproveImage)
+}
+
+func (s *APIService) handleRejectImage(ctx echo.Context) error {
+	return s.handleModerate(ctx, s.coreService.RejectImage)
+}
+
+// handleListSources returns the configured image sources and their last sync.
+func (s *APIService) handleListSources(ctx echo.Context) error {
+	return ctx.JSON(http.StatusOK, s.coreService.GetSourceStatuses())
+}
+
+func (s *APIService) handleEnableSource(ctx echo.Context) error {
+	return s.handleSetSourceEnabled(ctx, true)
+}
+
+func (s *APIService) handleDisableSource(ctx echo.Context) error {
+	return s.handleSetSourceEnabled(ctx, false)
+}
+
+// handleSetSourceEnabled pauses or resumes an image source until the next restart.
+func (s *APIService) handleSetSourceEnabled(ctx echo.Context, enabled bool) error {
+	err := s.coreService.SetSourceEnabled(ctx.Param("name"), enabled)
+	switch {
+	case errors.Is(err, core.ErrSourceNotFound):
+		return ctx.String(http.StatusNotFound, err.Error())
+	case errors.Is(err, core.ErrSourceInvalid):
+		return ctx.String(http.StatusConflict, err.Error())
+	case err != nil:
+		return ctx.String(http.StatusInternalServerError, "Failed to update image source")
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (s *APIService) handleModerate(ctx echo.Context, apply func(context.Context, string) error) error {
+	id := ctx.Param("id")
+	err := apply(ctx.Request().Context(), id)
+	switch {
+	case errors.Is(err, core.ErrImageNotFound):
+		return ctx.String(http.StatusNotFound, err.Error())
+	case errors.Is(err, core.ErrNotPending), errors.Is(err, core.ErrImageLocked):
+		return ctx.String(http.StatusConflict, err.Error())
+	case err != nil:
+		slog.Error("failed to moderate image", "imageId", id, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to moderate image")
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (s *APIService) handleDeleteImageByID(ctx echo.Context) error {
+	id := ctx.Param("id")
+	if id == "" {
+		slog.Info("missing image id parameter for delete", "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusBadRequest, "Missing image id")
+	}
+	err := s.coreService.DeleteImageChecked(ctx.Request().Context(), id, ctx.QueryParam("force") == "true")
+	if errors.Is(err, core.ErrCurrentImageProtected) {
+		msg := "Image is currently displayed and cannot be deleted"
+		if s.coreService.CurrentImageDeletion() == config.CurrentImageDeletionWarn {
+			msg = "Image is currently displayed; repeat with ?force=true to delete it"
+		}
+		return ctx.String(http.StatusConflict, msg)
+	}
+	if errors.Is(err, core.ErrImageLocked) {
+		return ctx.String(http.StatusConflict, err.Error())
+	}
+	if err != nil {
 		slog.Info("attempted to delete non-existing image", "imageId", id, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
 		return ctx.String(http.StatusNotFound, "Image not found")
 	}
 	return ctx.NoContent(http.StatusNoContent)
 }
+
+// handleMoveImage moves one image relative to another ({"before": id} or
+// {"after": id}) or to an index ({"index": n}) and returns the new order.
+func (s *APIService) handleMoveImage(ctx echo.Context) error {
+	id := ctx.Param("id")
+	var pos core.ImagePosition
+	if err := ctx.Bind(&pos); err != nil {
+		slog.Info("invalid position body", "imageId", id, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusBadRequest, "Invalid position body")
+	}
+	order, err := s.coreService.MoveImage(ctx.Request().Context(), id, pos)
+	var cooldown *core.RefreshCooldownError
+	switch {
+	case errors.Is(err, core.ErrInvalidPosition):
+		return ctx.String(http.StatusBadRequest, err.Error())
+	case errors.Is(err, core.ErrImageNotInOrder):
+		return ctx.String(http.StatusNotFound, err.Error())
+	case errors.Is(err, core.ErrCurrentImageChanged):
+		return ctx.String(http.StatusConflict, err.Error())
+	case errors.As(err, &cooldown):
+		ctx.Response().Header().Set("Retry-After", strconv.FormatInt(int64((cooldown.RetryAfter+time.Second-1)/time.Second), 10))
+		return ctx.String(http.StatusTooManyRequests, err.Error())
+	case err != nil:
+		slog.Error("failed to move image", "imageId", id, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to move image")
+	}
+	return ctx.JSON(http.StatusOK, map[string][]string{"order": order})
+}
+
+// archiveRequest is the body accepted by the bulk archive endpoints.
+type archiveRequest struct {
+	IDs []string `json:"ids"`
+}
+
+func (s *APIService) handleArchiveImages(ctx echo.Context) error {
+	return s.handleSetArchived(ctx, s.coreService.ArchiveImages)
+}
+
+func (s *APIService) handleUnarchiveImages(ctx echo.Context) error {
+	return s.handleSetArchived(ctx, s.coreService.UnarchiveImages)
+}
+
+func (s *APIService) handleSetArchived(ctx echo.Context, apply func(context.Context, []string) error) error {
+	var req archiveRequest
+	if err := ctx.Bind(&req); err != nil || len(req.IDs) == 0 {
+		slog.Info("invalid archive request", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusBadRequest, "Invalid archive request")
+	}
+	if err := apply(ctx.Request().Context(), req.IDs); err != nil {
+		if errors.Is(err, core.ErrImageNotFound) {
+			return ctx.String(http.StatusNotFound, err.Error())
+		}
+		if errors.Is(err, core.ErrImageLocked) {
+			return ctx.String(http.StatusConflict, err.Error())
+		}
+		slog.Error("failed to update archived state", "ids", req.IDs, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to update archived state")
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// bulkRequest is the body accepted by the bulk delete and reprocess endpoints.
+type bulkRequest struct {
+	IDs []string `json:"ids"`
+	// Mode is "best-effort" (default) or "all-or-nothing".
+	Mode string `json:"mode"`
+	// Force confirms deleting the current image (delete only).
+	Force bool `json:"force"`
+}
+
+// bulkItemResponse is the per-item entry of a multi-status response. Status is
+// the HTTP status the item would have received as a single request; skipped
+// and rolled back items report 424 Failed Dependency.
+type bulkItemResponse struct {
+	ID      string           `json:"id,omitempty"`
+	Name    string           `json:"name,omitempty"`
+	Status  int              `json:"status"`
+	Outcome core.BulkOutcome `json:"outcome"`
+	Error   string           `json:"error,omitempty"`
+	Pending bool             `json:"pending,omitempty"`
+}
+
+// bulkResponse is the body of all bulk endpoints.
+type bulkResponse struct {
+	Mode      core.BulkMode      `json:"mode"`
+	Succeeded int                `json:"succeeded"`
+	Failed    int                `json:"failed"`
+	Items     []bulkItemResponse `json:"items"`
+}
+
+// bulkResult writes result as 200 when every item succeeded and as 207
+// Multi-Status otherwise. successStatus is the per-item status of succeeded items.
+func bulkResult(ctx echo.Context, result *core.BulkResult, successStatus int) error {
+	resp := bulkResponse{
+		Mode:      result.Mode,
+		Succeeded: result.Count(core.BulkSucceeded),
+		Failed:    result.Count(core.BulkFailed),
+		Items:     make([]bulkItemResponse, 0, len(result.Items)),
+	}
+	for _, item := range result.Items {
+		entry := bulkItemResponse{ID: item.ID, Name: item.Name, Outcome: item.Outcome, Pending: item.Pending}
+		switch item.Outcome {
+		case core.BulkSucceeded:
+			entry.Status = successStatus
+		case core.BulkFailed:
+			entry.Status, entry.Error = bulkErrorStatus(item.Err), item.Err.Error()
+		default:
+			entry.Status = http.StatusFailedDependency
+		}
+		resp.Items = append(resp.Items, entry)
+	}
+	if resp.Succeeded == len(result.Items) {
+		return ctx.JSON(http.StatusOK, resp)
+	}
+	slog.Info("bulk operation partially failed", "mode", result.Mode, "succeeded", resp.Succeeded, "failed", resp.Failed, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+	return ctx.JSON(http.StatusMultiStatus, resp)
+}
+
+// bulkErrorStatus maps the error of a failed bulk item to an HTTP status.
+func bulkErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, core.ErrImageNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, core.ErrCurrentImageProtected), errors.Is(err, core.ErrImageLocked):
+		return http.StatusConflict
+	case errors.Is(err, core.ErrStorageQuotaExceeded):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, core.ErrUploadQuotaExceeded):
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
+
+// bindBulkRequest reads a bulk request with at least one ID and a valid mode.
+func bindBulkRequest(ctx echo.Context) (bulkRequest, core.BulkMode, bool) {
+	var req bulkRequest
+	if err := ctx.Bind(&req); err != nil || len(req.IDs) == 0 {
+		slog.Info("invalid bulk request", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return req, "", false
+	}
+	mode, err := core.ParseBulkMode(req.Mode)
+	if err != nil {
+		slog.Info("invalid bulk mode", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return req, "", false
+	}
+	return req, mode, true
+}
+
+func (s *APIService) handleBulkDelete(ctx echo.Context) error {
+	req, mode, ok := bindBulkRequest(ctx)
+	if !ok {
+		return ctx.String(http.StatusBadRequest, "Invalid bulk request")
+	}
+	result := s.coreService.BulkDeleteImages(ctx.Request().Context(), req.IDs, req.Force, mode)
+	return bulkResult(ctx, result, http.StatusNoContent)
+}
+
+func (s *APIService) handleBulkReprocess(ctx echo.Context) error {
+	req, mode, ok := bindBulkRequest(ctx)
+	if !ok {
+		return ctx.String(http.StatusBadRequest, "Invalid bulk request")
+	}
+	result := s.coreService.BulkReprocessImages(ctx.Request().Context(), req.IDs, mode)
+	return bulkResult(ctx, result, http.StatusNoContent)
+}
+
+// handleBulkUpload adds every file of a multipart form as an image. The
+// optional "mode" field selects best-effort or all-or-nothing and "order" the
+// order the images join the rotation in; "source" and the attribution fields
+// apply to all files as with a single upload.
+func (s *APIService) handleBulkUpload(ctx echo.Context) error {
+	if err := ctx.Request().ParseMultipartForm(s.coreService.UploadSpoolThreshold()); err != nil {
+		slog.Info("invalid multipart form", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusBadRequest, "Invalid multipart form")
+	}
+	form, err := ctx.MultipartForm()
+	if err != nil {
+		slog.Info("invalid multipart form", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusBadRequest, "Invalid multipart form")
+	}
+	defer func() { _ = form.RemoveAll() }()
+
+	mode, err := core.ParseBulkMode(firstFormValue(form, "mode"))
+	if err != nil {
+		slog.Info("invalid bulk mode", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusBadRequest, "Invalid bulk mode")
+	}
+	order, err := core.ParseImportOrder(firstFormValue(form, "order"))
+	if err != nil {
+		slog.Info("invalid import order", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusBadRequest, "Invalid import order")
+	}
+	fields := make([]string, 0, len(form.File))
+	for field := range form.File {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	var files []*multipart.FileHeader
+	for _, field := range fields {
+		files = append(files, form.File[field]...)
+	}
+	if len(files) == 0 {
+		slog.Info("no file provided in multipart form", "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusBadRequest, "No file provided")
+	}
+
+	quotaKey := core.QuotaKey(ctx.Request().Header.Get("X-API-Key"), ctx.RealIP())
+	uploads := make([]core.BulkUpload, len(files))
+	releases := make([]func(), len(files))
+	for i, fh := range files {
+		uploads[i] = core.BulkUpload{Name: fh.Filename, Open: func() (io.ReadSeekCloser, error) { return fh.Open() }}
+		releases[i], uploads[i].Err = s.coreService.ReserveUploadQuota(quotaKey, fh.Size)
+	}
+
+	attribution := database.Attribution{
+		SourceURL: firstFormValue(form, "sourceUrl"),
+		Author:    firstFormValue(form, "author"),
+		License:   firstFormValue(form, "license"),
+	}
+	result := s.coreService.BulkAddImages(ctx.Request().Context(), uploads, firstFormValue(form, "source"), attribution, mode, order)
+	for i, item := range result.Items {
+		if item.Outcome != core.BulkSucceeded && uploads[i].Err == nil {
+			releases[i]()
+		}
+	}
+	return bulkResult(ctx, result, http.StatusCreated)
+}
+
+// dayRangeParams reads the optional ?from= and ?to= days (YYYY-MM-DD).
+func dayRangeParams(ctx echo.Context) (from, to string, ok bool) {
+	from, to = ctx.QueryParam("from"), ctx.QueryParam("to")
+	for _, day := range []string{from, to} {
+		if day == "" {
+			continue
+		}
+		if _, err := time.Parse(time.DateOnly, day); err != nil {
+			return "", "", false
+		}
+	}
+	return from, to, true
+}
+
+func (s *APIService) handleGetDisplayHistory(ctx echo.Context) error {
+	from, to, ok := dayRangeParams(ctx)
+	if !ok {
+		return ctx.String(http.StatusBadRequest, "from and to must be dates (YYYY-MM-DD)")
+	}
+	records, err := s.coreService.GetDisplayHistory(ctx.Request().Context(), from, to)
+	if err != nil {
+		slog.Error("failed to read display history", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to read display history")
+	}
+	return ctx.JSON(http.StatusOK, records)
+}
+
+// handleGetStats serves the upload, storage, poll and processing statistics
+// behind the dashboard.
+func (s *APIService) handleGetStats(ctx echo.Context) error {
+	stats, err := s.coreService.GetStats(ctx.Request().Context())
+	if err != nil {
+		slog.Error("failed to compute stats", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to compute stats")
+	}
+	return ctx.JSON(http.StatusOK, stats)
+}
+
+// handleGetStorage reports stored bytes per category and storage warnings.
+func (s *APIService) handleGetStorage(ctx echo.Context) error {
+	report, err := s.coreService.GetStorageReport(ctx.Request().Context())
+	if err != nil {
+		slog.Error("failed to compute storage usage", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to compute storage usage")
+	}
+	return ctx.JSON(http.StatusOK, report)
+}
+
+// handleCleanUpStorage purges the trash and regenerable processed images.
+func (s *APIService) handleCleanUpStorage(ctx echo.Context) error {
+	report, err := s.coreService.CleanUpStorage(ctx.Request().Context())
+	if err != nil {
+		slog.Error("failed to clean up storage", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to clean up storage")
+	}
+	return ctx.JSON(http.StatusOK, report)
+}
+
+// handleGetTestPattern renders the diagnostic image ?type=stripes|gradient|palette
+// at the configured panel size and palette; ?width= and ?height= override the size.
+func (s *APIService) handleGetTestPattern(ctx echo.Context) error {
+	width, err := intQueryParam(ctx, "width", 0)
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "Invalid width")
+	}
+	height, err := intQueryParam(ctx, "height", 0)
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "Invalid height")
+	}
+	data, err := s.coreService.GetTestPattern(ctx.QueryParam("type"), width, height)
+	switch {
+	case errors.Is(err, core.ErrInvalidTestPattern):
+		return ctx.String(http.StatusBadRequest, err.Error())
+	case err != nil:
+		slog.Error("failed to render test pattern", "type", ctx.QueryParam("type"), "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to render test pattern")
+	}
+	ctx.Response().Header().Set("Cache-Control", "no-cache")
+	return serveImage(ctx, "image/png", data)
+}
+
+// handleEstimateProcessing estimates the pipeline runtime and peak memory of
+// an upload of ?width= x ?height= pixels, before any pixels are sent.
+func (s *APIService) handleEstimateProcessing(ctx echo.Context) error {
+	width, err := intQueryParam(ctx, "width", 0)
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "Invalid width")
+	}
+	height, err := intQueryParam(ctx, "height", 0)
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "Invalid height")
+	}
+	estimate, err := s.coreService.EstimateProcessing(width, height)
+	switch {
+	case errors.Is(err, core.ErrInvalidEstimate):
+		return ctx.String(http.StatusBadRequest, err.Error())
+	case err != nil:
+		slog.Error("failed to estimate processing", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to estimate processing")
+	}
+	return ctx.JSON(http.StatusOK, estimate)
+}
+
+// handleGetDitherPreview renders an image with the pipeline's dithering step
+// replaced by ?algorithm= (default floyd-steinberg), ?strength= (0-1, default
+// 1) and ?palette= (see ExperimentPalettes, default the configured palette).
+// Nothing is stored.
+func (s *APIService) handleGetDitherPreview(ctx echo.Context) error {
+	variant := core.DitherVariant{
+		Algorithm: ctx.QueryParam("algorithm"),
+		Strength:  1,
+		Palette:   ctx.QueryParam("palette"),
+	}
+	if variant.Algorithm == "" {
+		variant.Algorithm = "floyd-steinberg"
+	}
+	if raw := ctx.QueryParam("strength"); raw != "" {
+		strength, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return ctx.String(http.StatusBadRequest, "Invalid strength")
+		}
+		variant.Strength = strength
+	}
+	data, err := s.coreService.PreviewDitherVariant(ctx.Request().Context(), ctx.Param("id"), variant)
+	switch {
+	case errors.Is(err, core.ErrInvalidExperiment):
+		return ctx.String(http.StatusBadRequest, err.Error())
+	case errors.Is(err, core.ErrImageNotFound):
+		return ctx.String(http.StatusNotFound, "Image not found")
+	case err != nil:
+		slog.Error("failed to render dither preview", "id", ctx.Param("id"), "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to render preview")
+	}
+	ctx.Response().Header().Set("Cache-Control", "no-cache")
+	return serveImage(ctx, "image/png", data)
+}
+
+// handleGetTimelapse renders what the frame showed between ?from= and ?to= as
+// an animated GIF, one frame per day. ?width= (default 320) sets the size and
+// ?delay= (default 50) the time per frame in hundredths of a second.
+func (s *APIService) handleGetTimelapse(ctx echo.Context) error {
+	from, to, ok := dayRangeParams(ctx)
+	if !ok {
+		return ctx.String(http.StatusBadRequest, "from and to must be dates (YYYY-MM-DD)")
+	}
+	width, err := intQueryParam(ctx, "width", 320)
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "Invalid width")
+	}
+	delay, err := intQueryParam(ctx, "delay", 50)
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "Invalid delay")
+	}
+
+	data, err := s.coreService.RenderTimelapse(ctx.Request().Context(), from, to, width, delay)
+	switch {
+	case errors.Is(err, core.ErrInvalidTimelapse):
+		return ctx.String(http.StatusBadRequest, err.Error())
+	case errors.Is(err, core.ErrNoDisplayHistory):
+		return ctx.String(http.StatusNotFound, "No display history for this period")
+	case err != nil:
+		slog.Error("failed to render time-lapse", "from", from, "to", to, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to render time-lapse")
+	}
+	return serveImage(ctx, "image/gif", data)
+}
+
+// intQueryParam parses an optional integer query parameter.
+func intQueryParam(ctx echo.Context, name string, fallback int) (int, error) {
+	v := ctx.QueryParam(name)
+	if v == "" {
+		return fallback, nil
+	}
+	return strconv.Atoi(v)
+}
+
+// maxFirmwareLength bounds the ?firmware= version kept per device.
+const maxFirmwareLength = 64
+
+// reportDevice checks the device ID of a poll and records the
+// ?battery=<percent> and ?firmware=<version> the device sent with it. It
+// returns the response text for device IDs no device could register and for
+// battery levels that are not a percentage, and "" otherwise; firmware
+// versions that are too long or not printable are ignored, so they never
+// keep a frame from getting its image.
+func (s *APIService) reportDevice(ctx echo.Context, device string) (problem string) {
+	if device != "" && !core.ValidDeviceID(device) {
+		slog.Info("invalid device id", "device", device, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return "Invalid device"
+	}
+	if firmware := ctx.QueryParam("firmware"); firmware != "" && device != "" {
+		if len(firmware) <= maxFirmwareLength && !strings.ContainsFunc(firmware, func(r rune) bool { return !unicode.IsPrint(r) }) {
+			s.coreService.ReportFirmware(device, firmware)
+		} else {
+			slog.Info("ignoring invalid firmware version", "device", device, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		}
+	}
+	raw := ctx.QueryParam("battery")
+	if raw == "" {
+		return ""
+	}
+	percent, err := strconv.Atoi(raw)
+	if err != nil || percent < 0 || percent > 100 {
+		slog.Info("invalid battery level", "device", device, "battery", raw, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return "Invalid battery level"
+	}
+	s.coreService.ReportBattery(device, percent)
+	return ""
+}
+
+func (s *APIService) handleGetMetrics(ctx echo.Context) error {
+	return ctx.JSON(http.StatusOK, s.coreService.GetMetrics())
+}
+
+func (s *APIService) handleGetVersion(ctx echo.Context) error {
+	return ctx.JSON(http.StatusOK, s.coreService.GetVersion())
+}
+
+// handleGetChanges serves the change feed polled by replicating secondaries.
+func (s *APIService) handleGetChanges(ctx echo.Context) error {
+	var since time.Time
+	if raw := ctx.QueryParam("since"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			slog.Info("invalid since parameter", "since", raw, "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+			return ctx.String(http.StatusBadRequest, "Invalid since parameter")
+		}
+		since = parsed
+	}
+	changes, err := s.coreService.GetChanges(ctx.Request().Context(), since)
+	if err != nil {
+		slog.Error("failed to get changes", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+		return ctx.String(http.StatusInternalServerError, "Failed to get changes")
+	}
+	return ctx.JSON(http.StatusOK, changes)
+}
+
+// requireQuotaAdmin guards the quota admin API with the configured bearer token.
+// The API is hidden entirely when no token is configured.
+func (s *APIService) requireQuotaAdmin(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		token := s.coreService.QuotaAdminToken()
+		if token == "" {
+			return ctx.String(http.StatusNotFound, "Not found")
+		}
+		got, ok := strings.CutPrefix(ctx.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			slog.Info("unauthorized quota admin request", "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+			return ctx.String(http.StatusUnauthorized, "Unauthorized")
+		}
+		return next(ctx)
+	}
+}
+
+func (s *APIService) handleListQuotas(ctx echo.Context) error {
+	return ctx.JSON(http.StatusOK, s.coreService.GetQuotaUsage())
+}
+
+func (s *APIService) handleResetQuota(ctx echo.Context) error {
+	key := ctx.Param("key")
+	if !s.coreService.ResetQuota(key) {
+		return ctx.String(http.StatusNotFound, "Quota key not found")
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,122 @@
+package apihandler
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// getWithHead registers h for GET and HEAD. Device firmwares often check the
+// size of an image with HEAD before downloading it.
+func getWithHead(e *echo.Echo, path string, h echo.HandlerFunc) {
+	e.GET(path, h)
+	e.HEAD(path, h)
+}
+
+// serveImage writes data as the response. It answers HEAD requests with the
+// headers only and Range requests with 206 Partial Content, so devices can
+// resume interrupted downloads. Conditional headers are evaluated against the
+// ETag header if one is set.
+func serveImage(ctx echo.Context, contentType string, data []byte) error {
+	ctx.Response().Header().Set(echo.HeaderContentType, contentType)
+	http.ServeContent(ctx.Response(), ctx.Request(), "", time.Time{}, bytes.NewReader(data))
+	return nil
+}
+
+// serveImageStream is serveImage for a blob that is streamed rather than held
+// in memory. open is only called when the body is sent and again for every
+// range that starts before the current position. When size is unknown (0)
+// the whole stream is sent and ranges are not supported.
+func serveImageStream(ctx echo.Context, contentType string, size int64, open func() (io.ReadCloser, error)) error {
+	if size <= 0 {
+		body, err := open()
+		if err != nil {
+			slog.Info("image not available", "error", err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+			return ctx.String(http.StatusNotFound, "Image not found")
+		}
+		defer func() { _ = body.Close() }()
+		return ctx.Stream(http.StatusOK, contentType, body)
+	}
+	content := &lazySeeker{open: open, size: size}
+	defer func() { _ = content.Close() }()
+	ctx.Response().Header().Set(echo.HeaderContentType, contentType)
+	http.ServeContent(ctx.Response(), ctx.Request(), "", time.Time{}, content)
+	if content.err != nil {
+		// The headers are sent by now, so the client sees a truncated body.
+		slog.Warn("failed to stream image", "error", content.err, "method", ctx.Request().Method, "path", ctx.Request().URL.Path)
+	}
+	return nil
+}
+
+// lazySeeker is an io.ReadSeeker over a stream of known size. The stream is
+// opened on the first read and skipped forward to the position sought.
+type lazySeeker struct {
+	open func() (io.ReadCloser, error)
+	size int64
+
+	pos  int64
+	body io.ReadCloser
+	// offset is the position of body.
+	offset int64
+	// err is the first error opening the stream, logged after serving.
+	err error
+}
+
+var errSeekOutOfRange = errors.New("seek position out of range")
+
+func (s *lazySeeker) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += s.pos
+	case io.SeekEnd:
+		offset += s.size
+	}
+	if offset < 0 || offset > s.size {
+		return s.pos, errSeekOutOfRange
+	}
+	s.pos = offset
+	return offset, nil
+}
+
+func (s *lazySeeker) Read(p []byte) (int, error) {
+	if s.pos >= s.size {
+		return 0, io.EOF
+	}
+	if s.body != nil && s.offset > s.pos {
+		_ = s.body.Close()
+		s.body = nil
+	}
+	if s.body == nil {
+		body, err := s.open()
+		if err != nil {
+			if s.err == nil {
+				s.err = err
+			}
+			return 0, err
+		}
+		s.body, s.offset = body, 0
+	}
+	if s.offset < s.pos {
+		skipped, err := io.CopyN(io.Discard, s.body, s.pos-s.offset)
+		s.offset += skipped
+		if err != nil {
+			return 0, err
+		}
+	}
+	n, err := s.body.Read(p[:min(int64(len(p)), s.size-s.pos)])
+	s.pos += int64(n)
+	s.offset += int64(n)
+	return n, err
+}
+
+func (s *lazySeeker) Close() error {
+	if s.body == nil {
+		return nil
+	}
+	return s.body.Close()
+}
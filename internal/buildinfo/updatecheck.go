@@ -0,0 +1,147 @@
+package buildinfo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultReleaseURL is the GitHub API endpoint for the latest goframe release.
+const DefaultReleaseURL = "https://api.github.com/repos/jo-hoe/goframe/releases/latest"
+
+// Release describes the latest published release.
+type Release struct {
+	Version string `json:"version"`
+	URL     string `json:"url"`
+}
+
+// UpdateChecker periodically looks up the latest GitHub release. Lookups run in
+// the background so callers never block on GitHub; until the first lookup
+// completes Latest reports no release.
+type UpdateChecker struct {
+	releaseURL string
+	interval   time.Duration
+	httpClient *http.Client
+
+	mu         sync.Mutex
+	latest     *Release
+	checkedAt  time.Time
+	refreshing bool
+	nowFn      func() time.Time
+}
+
+// NewUpdateChecker returns a checker that refreshes at most once per interval.
+func NewUpdateChecker(releaseURL string, interval time.Duration) *UpdateChecker {
+	return &UpdateChecker{
+		releaseURL: releaseURL,
+		interval:   interval,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		nowFn:      time.Now,
+	}
+}
+
+// Latest returns the most recently fetched release, or nil if none is known yet.
+// A stale result triggers an asynchronous refresh.
+func (c *UpdateChecker) Latest() *Release {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.refreshing && c.nowFn().Sub(c.checkedAt) >= c.interval {
+		c.refreshing = true
+		go c.refresh()
+	}
+	return c.latest
+}
+
+func (c *UpdateChecker) refresh() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	release, err := c.fetch(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.refreshing = false
+	c.checkedAt = c.nowFn()
+	if err != nil {
+		slog.Warn("UpdateChecker: failed to fetch latest release", "url", c.releaseURL, "error", err)
+		return
+	}
+	c.latest = release
+}
+
+func (c *UpdateChecker) fetch(ctx context.Context) (*Release, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.releaseURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("requesting latest release: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		TagName string `json:"tag_name"`
+		HTMLURL string `json:"html_url"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decoding release: %w", err)
+	}
+	if body.TagName == "" {
+		return nil, fmt.Errorf("release has no tag name")
+	}
+	return &Release{Version: body.TagName, URL: body.HTMLURL}, nil
+}
+
+// IsNewer reports whether latest is a higher semantic version than current.
+// Non-numeric versions such as "dev" never compare as outdated.
+func IsNewer(latest, current string) bool {
+	l, ok := parseVersion(latest)
+	if !ok {
+		return false
+	}
+	c, ok := parseVersion(current)
+	if !ok {
+		return false
+	}
+	for i := range l {
+		if l[i] != c[i] {
+			return l[i] > c[i]
+		}
+	}
+	return false
+}
+
+// parseVersion parses "v1.2.3" (pre-release and build suffixes ignored) into its numeric parts.
+func parseVersion(v string) ([3]int, bool) {
+	var parts [3]int
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	fields := strings.Split(v, ".")
+	if len(fields) == 0 || len(fields) > 3 {
+		return parts, false
+	}
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil || n < 0 {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
@@ -0,0 +1,71 @@
+package buildinfo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIsNewer(t *testing.T) {
+	tests := []struct {
+		latest, current string
+		want            bool
+	}{
+		{"v1.2.0", "v1.1.9", true},
+		{"v1.10.0", "v1.9.0", true},
+		{"v2.0.0", "v1.99.99", true},
+		{"v1.2.0", "v1.2.0", false},
+		{"v1.2.0", "v1.3.0", false},
+		{"1.2.1", "v1.2.0", true},
+		{"v1.2.1", "v1.2.0-rc.1", true},
+		{"v1.2.1", "dev", false},
+		{"latest", "v1.0.0", false},
+	}
+	for _, tt := range tests {
+		if got := IsNewer(tt.latest, tt.current); got != tt.want {
+			t.Errorf("IsNewer(%q, %q) = %v, want %v", tt.latest, tt.current, got, tt.want)
+		}
+	}
+}
+
+func TestUpdateChecker_FetchesInBackground(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"tag_name":"v9.9.9","html_url":"https://github.com/jo-hoe/goframe/releases/tag/v9.9.9"}`))
+	}))
+	defer srv.Close()
+
+	checker := NewUpdateChecker(srv.URL, time.Hour)
+	if got := checker.Latest(); got != nil {
+		t.Fatalf("Expected no release before first fetch, got %+v", got)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	var release *Release
+	for release == nil && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		release = checker.Latest()
+	}
+	if release == nil {
+		t.Fatal("Expected release to be fetched")
+	}
+	if release.Version != "v9.9.9" {
+		t.Errorf("Expected version v9.9.9, got %q", release.Version)
+	}
+}
+
+func TestUpdateChecker_KeepsLastReleaseOnError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "rate limited", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	checker := NewUpdateChecker(srv.URL, 0)
+	checker.latest = &Release{Version: "v1.0.0"}
+	checker.refresh()
+
+	if checker.latest == nil || checker.latest.Version != "v1.0.0" {
+		t.Errorf("Expected previous release to be kept, got %+v", checker.latest)
+	}
+}
@@ -0,0 +1,169 @@
+// Package chaos injects artificial failures (slow requests, 500s, storage
+// errors and a slow pipeline) so device firmware can be tested against a
+// misbehaving server. Injection is only compiled into binaries built with
+// -tags chaos; in other builds New returns nil and all methods are no-ops.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+	"github.com/labstack/echo/v4"
+)
+
+// ErrInjected is returned by storage operations that fail on purpose.
+var ErrInjected = errors.New("chaos: injected failure")
+
+// Injector decides which requests and operations fail. A nil Injector injects nothing.
+type Injector struct {
+	cfg config.Chaos
+	// roll returns a random number in [0, 1).
+	roll  func() float64
+	sleep func(time.Duration)
+}
+
+// New returns an Injector for cfg, or nil when chaos mode is disabled or not
+// compiled in.
+func New(cfg config.Chaos) *Injector {
+	if !cfg.Enabled || !compiledIn {
+		return nil
+	}
+	return newInjector(cfg)
+}
+
+func newInjector(cfg config.Chaos) *Injector {
+	return &Injector{cfg: cfg, roll: rand.Float64, sleep: time.Sleep}
+}
+
+// Middleware delays and fails requests whose path matches the configured prefixes.
+func (i *Injector) Middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	if i == nil {
+		return next
+	}
+	return func(c echo.Context) error {
+		if !i.matches(c.Request().URL.Path) {
+			return next(c)
+		}
+		if i.cfg.Latency > 0 {
+			i.sleep(time.Duration(i.roll() * float64(i.cfg.Latency)))
+		}
+		if i.roll() < i.cfg.ErrorRate {
+			slog.Info("chaos: failing request", "method", c.Request().Method, "path", c.Request().URL.Path)
+			return c.String(http.StatusInternalServerError, "Injected failure")
+		}
+		return next(c)
+	}
+}
+
+func (i *Injector) matches(path string) bool {
+	for _, prefix := range i.cfg.PathPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// SlowPipeline blocks for the configured pipeline delay.
+func (i *Injector) SlowPipeline() {
+	if i == nil || i.cfg.PipelineDelay <= 0 {
+		return
+	}
+	i.sleep(i.cfg.PipelineDelay)
+}
+
+// WrapDatabase returns db with image reads and writes failing at the
+// configured rate, or db itself when no storage errors are injected.
+func (i *Injector) WrapDatabase(db database.DatabaseService) database.DatabaseService {
+	if i == nil || i.cfg.DatabaseErrorRate <= 0 {
+		return db
+	}
+	return &faultyDatabase{DatabaseService: db, injector: i}
+}
+
+// fail reports whether a storage operation should fail, logging it if so.
+func (i *Injector) fail(op string) error {
+	if i.roll() < i.cfg.DatabaseErrorRate {
+		slog.Info("chaos: failing storage operation", "operation", op)
+		return ErrInjected
+	}
+	return nil
+}
+
+// faultyDatabase fails the image operations devices and uploads depend on;
+// all other methods are passed through.
+type faultyDatabase struct {
+	database.DatabaseService
+	injector *Injector
+}
+
+func (f *faultyDatabase) CreateImage(ctx context.Context, original []byte, processed []byte, createdAt time.Time, source string, attribution database.Attribution, afterID string, pending bool) (string, error) {
+	if err := f.injector.fail("CreateImage"); err != nil {
+		return "", err
+	}
+	return f.DatabaseService.CreateImage(ctx, original, processed, createdAt, source, attribution, afterID, pending)
+}
+
+func (f *faultyDatabase) GetImageMetadata(ctx context.Context) ([]*database.Image, error) {
+	if err := f.injector.fail("GetImageMetadata"); err != nil {
+		return nil, err
+	}
+	return f.DatabaseService.GetImageMetadata(ctx)
+}
+
+func (f *faultyDatabase) GetImageByID(ctx context.Context, id string) (*database.Image, error) {
+	if err := f.injector.fail("GetImageByID"); err != nil {
+		return nil, err
+	}
+	return f.DatabaseService.GetImageByID(ctx, id)
+}
+
+func (f *faultyDatabase) GetCurrentImageID(ctx context.Context) (string, error) {
+	if err := f.injector.fail("GetCurrentImageID"); err != nil {
+		return "", err
+	}
+	return f.DatabaseService.GetCurrentImageID(ctx)
+}
+
+func (f *faultyDatabase) GetImageData(ctx context.Context, id, variant string) ([]byte, error) {
+	if err := f.injector.fail("GetImageData"); err != nil {
+		return nil, err
+	}
+	return f.DatabaseService.GetImageData(ctx, id, variant)
+}
+
+func (f *faultyDatabase) OpenImageData(ctx context.Context, id, variant string) (io.ReadCloser, error) {
+	if err := f.injector.fail("OpenImageData"); err != nil {
+		return nil, err
+	}
+	return f.DatabaseService.OpenImageData(ctx, id, variant)
+}
+
+func (f *faultyDatabase) DeleteImage(ctx context.Context, id string) error {
+	if err := f.injector.fail("DeleteImage"); err != nil {
+		return err
+	}
+	return f.DatabaseService.DeleteImage(ctx, id)
+}
+
+func (f *faultyDatabase) UpdateOrder(ctx context.Context, order []string) error {
+	if err := f.injector.fail("UpdateOrder"); err != nil {
+		return err
+	}
+	return f.DatabaseService.UpdateOrder(ctx, order)
+}
+
+func (f *faultyDatabase) SwapCurrentImage(ctx context.Context, expectedID string, order []string) error {
+	if err := f.injector.fail("SwapCurrentImage"); err != nil {
+		return err
+	}
+	return f.DatabaseService.SwapCurrentImage(ctx, expectedID, order)
+}
@@ -0,0 +1,99 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+	"github.com/labstack/echo/v4"
+)
+
+// testInjector returns an Injector whose rolls are taken from rolls in turn
+// and whose sleeps are recorded instead of performed.
+func testInjector(cfg config.Chaos, rolls ...float64) (*Injector, *[]time.Duration) {
+	var slept []time.Duration
+	i := newInjector(cfg)
+	i.roll = func() float64 {
+		r := rolls[0]
+		rolls = append(rolls[1:], r)
+		return r
+	}
+	i.sleep = func(d time.Duration) { slept = append(slept, d) }
+	return i, &slept
+}
+
+func TestNew_DisabledOrNotCompiledIn(t *testing.T) {
+	if New(config.Chaos{}) != nil {
+		t.Error("expected no injector when chaos mode is disabled")
+	}
+	if got := New(config.Chaos{Enabled: true}); (got != nil) != compiledIn {
+		t.Errorf("expected an injector only in chaos builds, got %v", got)
+	}
+
+	var nilInjector *Injector
+	db := database.NewFakeDatabase("")
+	if nilInjector.WrapDatabase(db) != database.DatabaseService(db) {
+		t.Error("expected a nil injector to return the database unchanged")
+	}
+	nilInjector.SlowPipeline()
+}
+
+func TestMiddleware(t *testing.T) {
+	cfg := config.Chaos{Enabled: true, ErrorRate: 0.5, Latency: time.Second, PathPrefixes: []string{"/api/"}}
+	tests := []struct {
+		path       string
+		roll       float64
+		wantStatus int
+		wantSleep  bool
+	}{
+		{path: "/api/image.png", roll: 0.4, wantStatus: http.StatusInternalServerError, wantSleep: true},
+		{path: "/api/image.png", roll: 0.6, wantStatus: http.StatusOK, wantSleep: true},
+		{path: "/probe", roll: 0.1, wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		injector, slept := testInjector(cfg, tt.roll)
+		e := echo.New()
+		e.Use(injector.Middleware)
+		e.GET("/*", func(c echo.Context) error { return c.String(http.StatusOK, "ok") })
+
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s with roll %g: expected status %d, got %d", tt.path, tt.roll, tt.wantStatus, rec.Code)
+		}
+		if (len(*slept) > 0) != tt.wantSleep {
+			t.Errorf("%s: expected sleep=%v, got %v", tt.path, tt.wantSleep, *slept)
+		}
+	}
+}
+
+func TestWrapDatabase_FailsAtRate(t *testing.T) {
+	ctx := context.Background()
+	db := database.NewFakeDatabase("")
+	injector, _ := testInjector(config.Chaos{Enabled: true, DatabaseErrorRate: 0.5}, 0.9, 0.1)
+	faulty := injector.WrapDatabase(db)
+
+	if _, err := faulty.GetImageMetadata(ctx); err != nil {
+		t.Fatalf("expected first call to pass, got %v", err)
+	}
+	if _, err := faulty.GetImageMetadata(ctx); !errors.Is(err, ErrInjected) {
+		t.Fatalf("expected second call to fail with ErrInjected, got %v", err)
+	}
+	// Methods without injection are passed through.
+	if _, err := faulty.GetMats(ctx); err != nil {
+		t.Errorf("expected GetMats to pass through, got %v", err)
+	}
+}
+
+func TestSlowPipeline(t *testing.T) {
+	injector, slept := testInjector(config.Chaos{Enabled: true, PipelineDelay: 3 * time.Second}, 0)
+	injector.SlowPipeline()
+	if len(*slept) != 1 || (*slept)[0] != 3*time.Second {
+		t.Errorf("expected a 3s delay, got %v", *slept)
+	}
+}
@@ -2,4 +2,8 @@ package common
 
 type ApiImage struct {
 	ID string
+	// Pending is set when the image awaits moderation before entering the rotation.
+	Pending bool
+	// Similar lists existing images that look near-identical to this one.
+	Similar []string
 }
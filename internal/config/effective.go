@@ -0,0 +1,142 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Sources of a Setting.
+const (
+	// SourceFile marks settings read from the config file.
+	SourceFile = "file"
+	// SourceEnv marks settings read from environment variables.
+	SourceEnv = "env"
+	// SourceDefault marks settings left at their built-in default.
+	SourceDefault = "default"
+	// SourceDatabase marks settings stored by the server itself, such as
+	// calibrated palettes that override the configured one for a device.
+	SourceDatabase = "database"
+)
+
+// Redacted replaces the values of secrets in EffectiveSettings.
+const Redacted = "[redacted]"
+
+// secretKeyParts mark setting keys whose values are redacted.
+var secretKeyParts = []string{"password", "secret", "token", "accesskey", "apikey"}
+
+// Setting is one value of the effective configuration, keyed by its YAML path
+// such as "database.endpoint" or "commands[0].width".
+type Setting struct {
+	Key    string `json:"key"`
+	Value  any    `json:"value"`
+	Source string `json:"source"`
+}
+
+// EffectiveSettings lists the configuration after defaults and environment
+// variables were applied, sorted by key, with secrets redacted.
+func EffectiveSettings(cfg *ServiceConfig) ([]Setting, error) {
+	values, err := settingValues(cfg)
+	if err != nil {
+		return nil, err
+	}
+	settings := make([]Setting, 0, len(values))
+	for key, value := range values {
+		settings = append(settings, Setting{Key: key, Value: redact(key, value), Source: cfg.source(key)})
+	}
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Key < settings[j].Key })
+	return settings, nil
+}
+
+// setFromEnv fills an empty setting from an environment variable and records it.
+func (cfg *ServiceConfig) setFromEnv(key string, target *string, name string) {
+	if *target != "" {
+		return
+	}
+	if value := os.Getenv(name); value != "" {
+		*target = value
+		cfg.envKeys[key] = true
+	}
+}
+
+// source reports where the setting with the given key came from. Values of
+// lists without nested settings count as one setting.
+func (cfg *ServiceConfig) source(key string) string {
+	switch {
+	case cfg.envKeys[key]:
+		return SourceEnv
+	case cfg.fileKeys[key]:
+		return SourceFile
+	}
+	return SourceDefault
+}
+
+// settingValues flattens the configuration into its settings by YAML path.
+func settingValues(cfg *ServiceConfig) (map[string]any, error) {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("encoding configuration: %w", err)
+	}
+	var tree map[string]any
+	if err := yaml.Unmarshal(data, &tree); err != nil {
+		return nil, fmt.Errorf("decoding configuration: %w", err)
+	}
+	return flattenSettings("", tree), nil
+}
+
+// flattenSettings maps nested YAML values to dotted keys. Lists of mappings
+// are indexed ("commands[0].name"); other lists are kept as one value.
+func flattenSettings(prefix string, value any) map[string]any {
+	out := map[string]any{}
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			for k, leaf := range flattenSettings(key, child) {
+				out[k] = leaf
+			}
+		}
+	case []any:
+		if !containsMappings(v) {
+			out[prefix] = v
+			break
+		}
+		for i, child := range v {
+			for k, leaf := range flattenSettings(fmt.Sprintf("%s[%d]", prefix, i), child) {
+				out[k] = leaf
+			}
+		}
+	default:
+		out[prefix] = v
+	}
+	return out
+}
+
+// containsMappings reports whether any element of the list is a mapping.
+func containsMappings(list []any) bool {
+	for _, item := range list {
+		if _, ok := item.(map[string]any); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// redact hides the value of secret settings unless it is empty.
+func redact(key string, value any) any {
+	if value == nil || value == "" {
+		return value
+	}
+	name := strings.ToLower(key[strings.LastIndex(key, ".")+1:])
+	for _, part := range secretKeyParts {
+		if strings.Contains(name, part) {
+			return Redacted
+		}
+	}
+	return value
+}
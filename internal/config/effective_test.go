@@ -0,0 +1,56 @@
+package config
+
+import "testing"
+
+func settingsByKey(t *testing.T, cfg *ServiceConfig) map[string]Setting {
+	t.Helper()
+	settings, err := EffectiveSettings(cfg)
+	if err != nil {
+		t.Fatalf("EffectiveSettings failed: %v", err)
+	}
+	byKey := make(map[string]Setting, len(settings))
+	for _, s := range settings {
+		byKey[s.Key] = s
+	}
+	return byKey
+}
+
+func TestEffectiveSettings_Sources(t *testing.T) {
+	t.Setenv("RUSTFS_SECRET_KEY", "from-env")
+	cfg, err := LoadServerConfig(writeTestConfig(t, `
+port: 8080
+database:
+  accessKey: from-file
+commands:
+  - name: ScaleCommand
+    width: 200
+`))
+	if err != nil {
+		t.Fatalf("LoadServerConfig failed: %v", err)
+	}
+
+	settings := settingsByKey(t, cfg)
+	tests := []struct {
+		key    string
+		value  any
+		source string
+	}{
+		{"port", 8080, SourceFile},
+		{"commands[0].name", "ScaleCommand", SourceFile},
+		{"commands[0].width", 200, SourceFile},
+		{"database.accessKey", Redacted, SourceFile},
+		{"database.secretKey", Redacted, SourceEnv},
+		{"timezone", "UTC", SourceDefault},
+		{"metadataIndexTTL", "30s", SourceDefault},
+	}
+	for _, tt := range tests {
+		got, ok := settings[tt.key]
+		if !ok {
+			t.Errorf("missing setting %q", tt.key)
+			continue
+		}
+		if got.Value != tt.value || got.Source != tt.source {
+			t.Errorf("%s = %v (%s), want %v (%s)", tt.key, got.Value, got.Source, tt.value, tt.source)
+		}
+	}
+}
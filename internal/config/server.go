@@ -1,12 +1,61 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
+const (
+	// ProcessedImageModeStored runs the pipeline at upload and stores the processed PNG (default).
+	ProcessedImageModeStored = "stored"
+	// ProcessedImageModeOnDemand stores only the original and runs the pipeline on first
+	// request, keeping results in a bounded in-memory cache.
+	ProcessedImageModeOnDemand = "onDemand"
+)
+
+const (
+	// ImageTargetTypePNG serves images to devices as PNG (default).
+	ImageTargetTypePNG = "png"
+	// ImageTargetTypeJPEG serves images to devices as JPEG, which small
+	// microcontrollers often decode much faster.
+	ImageTargetTypeJPEG = "jpeg"
+	// ImageTargetTypeBMP serves images to devices as 24-bit BMP for frames
+	// that decode nothing else.
+	ImageTargetTypeBMP = "bmp"
+	// ImageTargetTypePacked serves images to devices as packed palette
+	// indices that e-paper controllers take without decoding.
+	ImageTargetTypePacked = "packed"
+)
+
+const (
+	// CurrentImageDeletionAllow deletes the current image like any other (default).
+	CurrentImageDeletionAllow = "allow"
+	// CurrentImageDeletionWarn requires the client to confirm deleting the current image.
+	CurrentImageDeletionWarn = "warn"
+	// CurrentImageDeletionBlock refuses to delete the current image.
+	CurrentImageDeletionBlock = "block"
+	// CurrentImageDeletionAdvance deletes the current image, moves the frame on to
+	// the next one right away and sends a notification.
+	CurrentImageDeletionAdvance = "advance"
+)
+
+const (
+	// StartupSelfTestWarn logs a warning when the pipeline self-test fails (default).
+	StartupSelfTestWarn = "warn"
+	// StartupSelfTestFail exits the server when the pipeline self-test fails.
+	StartupSelfTestFail = "fail"
+	// StartupSelfTestOff skips the pipeline self-test.
+	StartupSelfTestOff = "off"
+)
+
 // Database holds database connection configuration.
 type Database struct {
 	Type         string `yaml:"type"`
@@ -15,19 +64,587 @@ type Database struct {
 	AccessKey    string `yaml:"accessKey"`
 	SecretKey    string `yaml:"secretKey"`
 	ImageBaseURL string `yaml:"imageBaseURL"`
+	// CompressOriginals stores original uploads zstd-compressed. Existing
+	// originals are converted in the background at startup, in either direction.
+	CompressOriginals bool `yaml:"compressOriginals"`
+	// IDFormat is the format of new image IDs: uuidv4 (default), uuidv7, ulid
+	// or nanoid. uuidv7 and ulid sort by creation time.
+	IDFormat string `yaml:"idFormat"`
+}
+
+// ProcessedImages controls whether processed images are persisted or regenerated on demand.
+type ProcessedImages struct {
+	// Mode is ProcessedImageModeStored (default) or ProcessedImageModeOnDemand.
+	Mode string `yaml:"mode"`
+	// CacheTTL is how long a generated image stays cached in onDemand mode (default 1h).
+	CacheTTL time.Duration `yaml:"cacheTTL"`
+	// CacheMaxEntries bounds the number of cached images in onDemand mode (default 16,
+	// 4 with a low runtime.memoryLimitMB).
+	CacheMaxEntries int `yaml:"cacheMaxEntries"`
+}
+
+// NormalizeAtRest replaces the stored original with a reduced archival copy
+// once the processed image has been generated.
+type NormalizeAtRest struct {
+	// Enabled turns on archival copies (default off).
+	Enabled bool `yaml:"enabled"`
+	// MaxDimension limits the long side of the archival copy in pixels (default 2048).
+	MaxDimension int `yaml:"maxDimension"`
+	// Quality trades fidelity for size, 1-100; it sets BitsPerChannel to
+	// ceil(quality * 8 / 100), so 100 keeps full depth. Set at most one of the two.
+	Quality int `yaml:"quality"`
+	// BitsPerChannel quantizes each color channel, 1-8 (default 8 = no quantization).
+	BitsPerChannel int `yaml:"bitsPerChannel"`
+}
+
+// Uploads bounds the memory used while ingesting uploaded images.
+type Uploads struct {
+	// SpoolThresholdBytes is the multipart size above which uploads are buffered
+	// in temporary files instead of memory (default 4 MiB).
+	SpoolThresholdBytes int64 `yaml:"spoolThresholdBytes"`
+	// MemoryBudgetBytes caps the estimated memory of all decode operations running
+	// at the same time; further uploads wait until budget is released (default 256 MiB,
+	// at most a quarter of runtime.memoryLimitMB).
+	MemoryBudgetBytes int64 `yaml:"memoryBudgetBytes"`
+}
+
+// RouteLimits bounds the requests of one group of routes.
+type RouteLimits struct {
+	// Timeout cancels the request's context after this long, which aborts
+	// storage calls and the pipeline; a negative value disables it.
+	Timeout time.Duration `yaml:"timeout"`
+	// MaxBodyBytes rejects larger request bodies with 413; a negative value
+	// disables the limit.
+	MaxBodyBytes int64 `yaml:"maxBodyBytes"`
+}
+
+// Limits bounds the duration and body size of requests per group of routes.
+type Limits struct {
+	// API covers the /api/ routes except uploads (default 60s, 1 MiB).
+	API RouteLimits `yaml:"api"`
+	// HTMX covers the UI's /htmx/ routes except uploads (default 30s, 1 MiB).
+	HTMX RouteLimits `yaml:"htmx"`
+	// Uploads covers uploads, imports, bulk reprocessing and calibration
+	// photos (default 10m, 128 MiB).
+	Uploads RouteLimits `yaml:"uploads"`
+}
+
+// unixAddressPrefix marks a listener address as a unix domain socket path.
+const unixAddressPrefix = "unix:"
+
+// systemdAddressPrefix marks a listener address as a socket passed by systemd
+// socket activation, named by the FileDescriptorName of the socket unit.
+const systemdAddressPrefix = "systemd:"
+
+// Listener is an address the server accepts connections on.
+type Listener struct {
+	// Address is "host:port" for TCP, "unix:/path/to.sock" for a unix domain
+	// socket or "systemd:<name>" for a socket passed by systemd.
+	Address string `yaml:"address"`
+}
+
+// Network splits the address into the network and address arguments of
+// net.Listen. Sockets passed by systemd have the network "systemd" and their
+// file descriptor name as address.
+func (l Listener) Network() (network, address string) {
+	if path, ok := strings.CutPrefix(l.Address, unixAddressPrefix); ok {
+		return "unix", path
+	}
+	if name, ok := strings.CutPrefix(l.Address, systemdAddressPrefix); ok {
+		return "systemd", name
+	}
+	return "tcp", l.Address
+}
+
+// Quotas limits uploads per client, identified by its X-API-Key header or,
+// when absent, its IP address. Zero limits are unlimited.
+type Quotas struct {
+	// UploadsPerDay is the maximum number of uploads per client and calendar day.
+	UploadsPerDay int `yaml:"uploadsPerDay"`
+	// MaxStoredBytes is the maximum number of bytes a client may upload in total.
+	MaxStoredBytes int64 `yaml:"maxStoredBytes"`
+	// AdminToken protects /api/admin/quotas; requests must send "Authorization: Bearer <token>".
+	// The admin endpoints are disabled when empty.
+	AdminToken string `yaml:"adminToken"`
+}
+
+// CORS lets web clients on other origins, such as dashboards, call the /api/
+// routes. It is disabled while AllowedOrigins is empty.
+type CORS struct {
+	// AllowedOrigins are the origins allowed to call the API, e.g.
+	// "https://dashboard.example.com"; "*" allows any origin.
+	AllowedOrigins []string `yaml:"allowedOrigins"`
+	// AllowedMethods are the methods cross-origin requests may use (default
+	// GET, HEAD, POST, PUT, PATCH and DELETE).
+	AllowedMethods []string `yaml:"allowedMethods"`
+	// AllowedHeaders are the request headers cross-origin requests may send
+	// (default Content-Type, Authorization and X-API-Key).
+	AllowedHeaders []string `yaml:"allowedHeaders"`
+	// AllowCredentials lets browsers send cookies and HTTP authentication; it
+	// cannot be combined with the "*" origin.
+	AllowCredentials bool `yaml:"allowCredentials"`
+	// MaxAge is how long browsers may cache a preflight response (default 10m).
+	MaxAge time.Duration `yaml:"maxAge"`
+}
+
+// Enabled reports whether any origin is allowed.
+func (c CORS) Enabled() bool {
+	return len(c.AllowedOrigins) > 0
+}
+
+// Replication configures this instance as a secondary that mirrors a primary.
+type Replication struct {
+	// PrimaryURL is the base URL of the primary goframe server (e.g. "http://goframe.lan:8080").
+	// Replication is disabled when empty.
+	PrimaryURL string `yaml:"primaryURL"`
+	// Interval is the time between two polls of the primary (default 5m).
+	Interval time.Duration `yaml:"interval"`
+}
+
+// Panel describes limits of the display panel of the frames.
+type Panel struct {
+	// MinRefreshInterval is the shortest time the panel's manufacturer
+	// recommends between refreshes (default 0, no limit). Frames read it from
+	// the rotation API, and requests that would show another image sooner are
+	// rejected.
+	MinRefreshInterval time.Duration `yaml:"minRefreshInterval"`
+}
+
+// Pregeneration renders the next day's images shortly before the rotation boundary.
+type Pregeneration struct {
+	// Enabled turns on pre-generation (default off).
+	Enabled bool `yaml:"enabled"`
+	// Lead is how long before midnight the next images are rendered (default 5m).
+	Lead time.Duration `yaml:"lead"`
+}
+
+// GarbageCollection removes stored blobs that no image references, e.g. left
+// behind by failed uploads or interrupted deletes.
+type GarbageCollection struct {
+	// Enabled turns on the periodic collection (default off). The dry-run report
+	// is available either way.
+	Enabled bool `yaml:"enabled"`
+	// Interval is the time between two collections (default 24h).
+	Interval time.Duration `yaml:"interval"`
+	// MinAge keeps blobs younger than this, so uploads in progress are not
+	// collected before their image is registered (default 1h).
+	MinAge time.Duration `yaml:"minAge"`
+}
+
+// SVG bounds the work of rasterizing uploaded SVGs, so a hostile or
+// pathological file cannot tie up the server.
+type SVG struct {
+	// MaxLongSidePixelCount scales SVGs with a larger explicit size down
+	// (default 8192); it must not be below svgFallbackLongSidePixelCount.
+	MaxLongSidePixelCount int `yaml:"maxLongSidePixelCount"`
+	// MaxElements rejects SVGs with more elements (default 100000).
+	MaxElements int `yaml:"maxElements"`
+	// RenderTimeout rejects SVGs that take longer to render (default 10s).
+	RenderTimeout time.Duration `yaml:"renderTimeout"`
+}
+
+// Bandwidth throttles image downloads, so a fleet of frames refreshing at
+// once does not saturate a weak uplink.
+type Bandwidth struct {
+	// BytesPerSecond caps the rate at which each connection downloads an
+	// image (0 = unlimited).
+	BytesPerSecond int64 `yaml:"bytesPerSecond"`
+	// Burst is how many bytes are sent at full speed before the rate applies
+	// (default 32 KiB, at most BytesPerSecond).
+	Burst int64 `yaml:"burst"`
+}
+
+// Enabled reports whether downloads are throttled.
+func (b Bandwidth) Enabled() bool {
+	return b.BytesPerSecond > 0
+}
+
+// Display locales accepted by display.locale.
+const (
+	// DisplayLocaleISO formats like ISO 8601 with a 24-hour clock and weeks
+	// starting on Monday (default).
+	DisplayLocaleISO = "iso"
+)
+
+// DisplayLocales lists the values accepted by display.locale.
+var DisplayLocales = []string{DisplayLocaleISO, "en-US", "en-GB", "de-DE", "fr-FR", "nl-NL"}
+
+// Clocks accepted by display.clock.
+const (
+	DisplayClock12h = "12h"
+	DisplayClock24h = "24h"
+)
+
+// Display controls how dates and times are shown in the frontend and in the
+// human-readable fields of the API.
+type Display struct {
+	// Locale sets the date format and weekday names (default "iso").
+	Locale string `yaml:"locale"`
+	// Clock is "12h" or "24h" (default: the locale's).
+	Clock string `yaml:"clock"`
+	// FirstDayOfWeek is a weekday name such as "monday" (default: the locale's).
+	FirstDayOfWeek string `yaml:"firstDayOfWeek"`
+}
+
+// lowMemoryLimitMB is the memory limit below which defaults shrink (see Runtime).
+const lowMemoryLimitMB = 1024
+
+// Runtime tunes the server for the memory of its host.
+type Runtime struct {
+	// MemoryLimitMB is a soft limit for the memory of the whole process in MiB
+	// (0 = none), passed to the Go runtime so it collects garbage more eagerly
+	// as the limit is approached. Below 1024 the defaults of the decode budget,
+	// the reprocessing workers and the image caches shrink to match.
+	MemoryLimitMB int `yaml:"memoryLimitMB"`
+}
+
+// LowMemory reports whether a memory limit below lowMemoryLimitMB is set.
+func (r Runtime) LowMemory() bool {
+	return r.MemoryLimitMB > 0 && r.MemoryLimitMB < lowMemoryLimitMB
+}
+
+// Reprocessing configures reprocessing the whole library.
+type Reprocessing struct {
+	// Workers is the number of images processed in parallel (default 2, 1 with a
+	// low runtime.memoryLimitMB).
+	Workers int `yaml:"workers"`
+}
+
+// Overlay widget types.
+const (
+	// OverlayWidgetClock shows the time in the rotation timezone.
+	OverlayWidgetClock = "clock"
+	// OverlayWidgetWeather shows an icon and the temperature of the current weather.
+	OverlayWidgetWeather = "weather"
+	// OverlayWidgetBattery shows the battery level the device last reported.
+	OverlayWidgetBattery = "battery"
+	// OverlayWidgetCountdown shows the time until the next image.
+	OverlayWidgetCountdown = "countdown"
+	// OverlayWidgetText shows a template filled in with the image's title,
+	// description and attribution.
+	OverlayWidgetText = "text"
+)
+
+// Overlay positions.
+const (
+	OverlayPositionTopLeft     = "topLeft"
+	OverlayPositionTopRight    = "topRight"
+	OverlayPositionBottomLeft  = "bottomLeft"
+	OverlayPositionBottomRight = "bottomRight"
+)
+
+// OverlayWidget is one line of an overlay. Which fields apply depends on Type.
+type OverlayWidget struct {
+	// Type is clock, weather, battery, countdown or text.
+	Type string `yaml:"type"`
+	// Format is the Go time layout of the clock (default "15:04").
+	Format string `yaml:"format"`
+	// Latitude and Longitude locate the weather forecast.
+	Latitude  float64 `yaml:"latitude"`
+	Longitude float64 `yaml:"longitude"`
+	// URL is the Open-Meteo compatible forecast API (default "https://api.open-meteo.com/v1/forecast").
+	URL string `yaml:"url"`
+	// Template is the Go text/template of a text widget, e.g. "{{.Title}}". It
+	// can use .Title, .Description, .Author, .License and .Source; the widget
+	// is left out when the result is empty.
+	Template string `yaml:"template"`
+}
+
+// DeviceProfile renders images for one kind of frame with its own pipeline,
+// so frames with different panels can pull from the same server.
+type DeviceProfile struct {
+	// Name identifies the profile in /api/devices/<name>/image.png and is the
+	// device ID its frames rotate as.
+	Name string `yaml:"name"`
+	// Commands replace the top-level pipeline for the profile; their
+	// ScaleCommand sets its resolution.
+	Commands []CommandConfig `yaml:"commands"`
+}
+
+// Overlay stacks widgets onto the current image of some devices when they
+// fetch it, for frames that show a photo together with information.
+type Overlay struct {
+	// Devices lists the device IDs the overlay applies to; empty applies it to
+	// all devices not listed by another overlay.
+	Devices []string `yaml:"devices"`
+	// Position is the corner of the widget panel: topLeft (default), topRight,
+	// bottomLeft or bottomRight.
+	Position string          `yaml:"position"`
+	Widgets  []OverlayWidget `yaml:"widgets"`
+}
+
+// Chaos injects artificial failures so device firmware can be tested against a
+// misbehaving server. It only takes effect in binaries built with -tags chaos.
+type Chaos struct {
+	// Enabled turns on failure injection (default off).
+	Enabled bool `yaml:"enabled"`
+	// ErrorRate is the share of requests answered with 500, between 0 and 1.
+	ErrorRate float64 `yaml:"errorRate"`
+	// Latency delays requests by a random duration up to this value.
+	Latency time.Duration `yaml:"latency"`
+	// DatabaseErrorRate is the share of storage operations that fail, between 0 and 1.
+	DatabaseErrorRate float64 `yaml:"databaseErrorRate"`
+	// PipelineDelay slows down every run of the processing pipeline by this duration.
+	PipelineDelay time.Duration `yaml:"pipelineDelay"`
+	// PathPrefixes limits request errors and latency to these path prefixes (default "/api/").
+	PathPrefixes []string `yaml:"pathPrefixes"`
+}
+
+// UpdateCheck controls the optional lookup of the latest goframe release on GitHub.
+type UpdateCheck struct {
+	// Enabled turns on the update check (default off).
+	Enabled bool `yaml:"enabled"`
+	// Interval is the minimum time between two GitHub lookups (default 24h).
+	Interval time.Duration `yaml:"interval"`
+}
+
+// Notification channel types.
+const (
+	NotificationChannelEmail    = "email"
+	NotificationChannelNtfy     = "ntfy"
+	NotificationChannelPushover = "pushover"
+	NotificationChannelWebhook  = "webhook"
+)
+
+// NotificationChannel is one destination for owner alerts. Which fields are
+// required depends on Type.
+type NotificationChannel struct {
+	// Type is one of email, ntfy, pushover or webhook.
+	Type string `yaml:"type"`
+	// URL is the ntfy topic URL (e.g. "https://ntfy.sh/my-frame") or the webhook URL.
+	URL string `yaml:"url"`
+	// Token is the optional ntfy access token or the Pushover application token.
+	Token string `yaml:"token"`
+	// User is the Pushover user key.
+	User string `yaml:"user"`
+	// SMTPHost and SMTPPort address the mail server for email (port defaults to 587).
+	SMTPHost string `yaml:"smtpHost"`
+	SMTPPort int    `yaml:"smtpPort"`
+	// Username and Password authenticate against the mail server when set.
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	// From and To are the email sender and recipients.
+	From string   `yaml:"from"`
+	To   []string `yaml:"to"`
+}
+
+// Notifications alerts the owner about conditions that need attention.
+// Alerts are disabled when no channel is configured.
+type Notifications struct {
+	Channels []NotificationChannel `yaml:"channels"`
+	// ProcessingFailureThreshold is the number of consecutive failed uploads that
+	// triggers an alert (default 3).
+	ProcessingFailureThreshold int `yaml:"processingFailureThreshold"`
+	// StorageLimitBytes is the intended maximum size of all stored images; 0 disables the check.
+	StorageLimitBytes int64 `yaml:"storageLimitBytes"`
+	// StorageWarnRatio is the share of StorageLimitBytes that triggers an alert (default 0.9).
+	StorageWarnRatio float64 `yaml:"storageWarnRatio"`
+	// FrameStaleAfter alerts when a registered or grouped frame has not polled
+	// for this long; 0 disables the check.
+	FrameStaleAfter time.Duration `yaml:"frameStaleAfter"`
+	// CheckInterval is the time between storage and frame checks (default 5m).
+	CheckInterval time.Duration `yaml:"checkInterval"`
+}
+
+// BulkImport lets the UI import images from directories on the server, e.g. a
+// mounted NAS share, without uploading them over HTTP.
+type BulkImport struct {
+	// Directories lists the directories that may be scanned and imported from.
+	// Bulk import is disabled when empty.
+	Directories []string `yaml:"directories"`
+}
+
+// Moderation holds new images in a pending state until they are approved.
+type Moderation struct {
+	// Enabled sends new images to the approval queue instead of the rotation (default off).
+	Enabled bool `yaml:"enabled"`
+	// TrustedSources lists image sources that skip moderation, e.g. a scheduler's
+	// sourceName, "import" or "source:<name>" for a configured source. Images
+	// mirrored from a primary and the demo images are always trusted.
+	TrustedSources []string `yaml:"trustedSources"`
+	// WebhookURL, when set, receives every pending image and may approve or reject it.
+	WebhookURL string `yaml:"webhookURL"`
+	// WebhookTimeout bounds a webhook call (default 10s); images stay pending on timeout.
+	WebhookTimeout time.Duration `yaml:"webhookTimeout"`
+}
+
+// Captioning generates alt text for new images that have none.
+type Captioning struct {
+	// WebhookURL, when set, receives every new image and may answer with its alt text.
+	WebhookURL string `yaml:"webhookURL"`
+	// WebhookTimeout bounds a webhook call (default 30s); the image keeps no alt text on timeout.
+	WebhookTimeout time.Duration `yaml:"webhookTimeout"`
+}
+
+// Hook points at which configured hooks run.
+const (
+	// HookPreUpload runs before a new image is stored; a failing hook rejects it.
+	HookPreUpload = "preUpload"
+	// HookPostProcess runs after an image was processed, without waiting for it.
+	HookPostProcess = "postProcess"
+	// HookPreServe runs before the current image is served to a device.
+	HookPreServe = "preServe"
+)
+
+// Hook calls a webhook or runs a local command with the metadata of an image
+// at a hook point, for workflows such as virus scans, external backups or
+// notifications. The JSON event is posted to url or written to the standard
+// input of command.
+type Hook struct {
+	// On is the hook point: HookPreUpload, HookPostProcess or HookPreServe.
+	On string `yaml:"on"`
+	// URL receives the event as a JSON POST; a non-2xx status fails the hook.
+	URL string `yaml:"url"`
+	// Command is the executable and its arguments, run without a shell; a
+	// non-zero exit status fails the hook.
+	Command []string `yaml:"command"`
+	// Timeout bounds one run of the hook (default 10s).
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// Source configures an image source that is polled for new images, e.g. a
+// directory or a URL. Parameters of the source type are declared inline, like
+// those of commands.
+type Source struct {
+	// Name identifies the source in the status and in the recorded image source.
+	Name string `yaml:"name"`
+	// Type selects the source implementation, e.g. "directory" or "url".
+	Type string `yaml:"type"`
+	// Interval is the time between two polls (default 1h).
+	Interval time.Duration `yaml:"interval"`
+	// Disabled keeps the source from polling until it is enabled at runtime.
+	Disabled bool           `yaml:"disabled"`
+	Params   map[string]any `yaml:",inline"`
+}
+
+// Integrations tunes how calls to external services, such as webhooks, image
+// sources, the weather API and a replication primary, are retried and paused.
+type Integrations struct {
+	// Retries is the number of retries of a failed background call (default 2).
+	// Calls made while a request waits are not retried. A negative value disables retries.
+	Retries int `yaml:"retries"`
+	// RetryBaseDelay is the delay before the first retry (default 1s); it
+	// doubles per retry up to RetryMaxDelay (default 30s).
+	RetryBaseDelay time.Duration `yaml:"retryBaseDelay"`
+	RetryMaxDelay  time.Duration `yaml:"retryMaxDelay"`
+	// FailureThreshold is the number of consecutive failed calls after which a
+	// service is not called for OpenDuration (default 5).
+	FailureThreshold int `yaml:"failureThreshold"`
+	// OpenDuration is the initial pause of a failing service (default 1m). It
+	// doubles, up to 16 times as long, while the service keeps failing.
+	OpenDuration time.Duration `yaml:"openDuration"`
 }
 
 // ServiceConfig holds the full server configuration.
 type ServiceConfig struct {
-	Port                          int             `yaml:"port"`
-	Database                      Database        `yaml:"database"`
-	Commands                      []CommandConfig `yaml:"commands"`
-	Timezone                      string          `yaml:"timezone"`
-	ThumbnailWidth                int             `yaml:"thumbnailWidth"`
-	LogLevel                      string          `yaml:"logLevel"`
-	SvgFallbackLongSidePixelCount int             `yaml:"svgFallbackLongSidePixelCount"`
+	Port           int             `yaml:"port"`
+	Database       Database        `yaml:"database"`
+	Commands       []CommandConfig `yaml:"commands"`
+	Timezone       string          `yaml:"timezone"`
+	ThumbnailWidth int             `yaml:"thumbnailWidth"`
+	LogLevel       string          `yaml:"logLevel"`
+	// ImageTargetType is the format /api/image serves: ImageTargetTypePNG
+	// (default), ImageTargetTypeJPEG, ImageTargetTypeBMP or
+	// ImageTargetTypePacked. /api/image.png, .jpg, .bmp and .bin serve their
+	// own format regardless.
+	ImageTargetType string `yaml:"imageTargetType"`
+	// JPEGQuality is the quality of JPEG images, 1-100 (default 90).
+	JPEGQuality                   int `yaml:"jpegQuality"`
+	SvgFallbackLongSidePixelCount int `yaml:"svgFallbackLongSidePixelCount"`
+	// SVG limits the rasterization of uploaded SVGs.
+	SVG SVG `yaml:"svg"`
+	// Listeners are the addresses serving the UI and API (default ":<port>").
+	Listeners []Listener `yaml:"listeners"`
+	// ManagementListeners, when set, serve /api/metrics and the admin APIs instead
+	// of the regular listeners, e.g. on a port that is not exposed publicly.
+	ManagementListeners []Listener `yaml:"managementListeners"`
+	// AttributionOverlay draws the author and license of attributed images onto
+	// the processed image. Images without attribution are left untouched.
+	AttributionOverlay bool `yaml:"attributionOverlay"`
+	// ProcessedImages selects between storing processed images and generating them lazily.
+	ProcessedImages ProcessedImages `yaml:"processedImages"`
+	// ReadOnly rejects all mutating requests with 405 so the instance can be exposed
+	// publicly while another instance sharing the same storage handles uploads.
+	ReadOnly bool `yaml:"readOnly"`
+	// NormalizeAtRest trades original fidelity for a smaller database.
+	NormalizeAtRest NormalizeAtRest `yaml:"normalizeAtRest"`
+	// Uploads limits memory pressure from concurrent uploads.
+	Uploads Uploads `yaml:"uploads"`
+	// Limits bounds request duration and body size per group of routes.
+	Limits Limits `yaml:"limits"`
+	// Bandwidth throttles image downloads per connection.
+	Bandwidth Bandwidth `yaml:"bandwidth"`
+	// Display sets the locale, clock and first day of the week of shown dates.
+	Display Display `yaml:"display"`
+	// Quotas limits how much each client may upload.
+	Quotas Quotas `yaml:"quotas"`
+	// CORS allows browsers on other origins to call the API.
+	CORS CORS `yaml:"cors"`
+	// Replication mirrors images and order from another goframe instance.
+	Replication Replication `yaml:"replication"`
+	// UpdateCheck surfaces newer GitHub releases in the version API and frontend.
+	UpdateCheck UpdateCheck `yaml:"updateCheck"`
+	// Notifications sends alerts via email, ntfy, Pushover or webhooks.
+	Notifications Notifications `yaml:"notifications"`
+	// BulkImport exposes server-side directories for importing existing image collections.
+	BulkImport BulkImport `yaml:"bulkImport"`
+	// Moderation puts new images into an approval queue before they are shown.
+	Moderation Moderation `yaml:"moderation"`
+	// Captioning fills in the alt text of new images via a webhook.
+	Captioning Captioning `yaml:"captioning"`
+	// Sources are polled on their own schedules for images to add.
+	Sources []Source `yaml:"sources"`
+	// Hooks run webhooks or local commands before uploads, after processing
+	// and before images are served.
+	Hooks []Hook `yaml:"hooks"`
+	// Integrations retries and pauses calls to flaky external services.
+	Integrations Integrations `yaml:"integrations"`
+	// NearDuplicateDistance is the maximum number of differing perceptual hash bits
+	// (out of 64) for an upload to be reported as near-identical to an existing
+	// image (default 6). A negative value disables the check.
+	NearDuplicateDistance int `yaml:"nearDuplicateDistance"`
+	// CurrentImageDeletion controls deleting the image that is currently on the
+	// frame: CurrentImageDeletionAllow (default), Warn, Block or Advance.
+	CurrentImageDeletion string `yaml:"currentImageDeletion"`
+	// Panel protects e-ink panels from refreshing too often.
+	Panel Panel `yaml:"panel"`
+	// Pregeneration warms the next images before midnight to flatten the poll spike.
+	Pregeneration Pregeneration `yaml:"pregeneration"`
+	// GarbageCollection removes unreferenced blobs from storage.
+	GarbageCollection GarbageCollection `yaml:"garbageCollection"`
+	// Reprocessing bounds the parallelism of reprocessing all images.
+	Reprocessing Reprocessing `yaml:"reprocessing"`
+	// Overlays composite widgets such as a clock onto the images served to devices.
+	Overlays []Overlay `yaml:"overlays"`
+	// Devices are named profiles with their own pipeline, served at
+	// /api/devices/<name>/image.png.
+	Devices []DeviceProfile `yaml:"devices"`
+	// CurrentImageCacheTTL is how long the image resolved for a device poll is
+	// reused (default 2s), so frames polling at once after a rotation share one
+	// lookup. A negative value only coalesces concurrent lookups.
+	CurrentImageCacheTTL time.Duration `yaml:"currentImageCacheTTL"`
+	// MetadataIndexTTL is how long the in-memory index of image metadata and
+	// the rotation order is trusted (default 30s). Writes through this server
+	// refresh it at once; the ttl bounds how long changes made elsewhere, such
+	// as by the operator, stay unseen. A negative value only coalesces.
+	MetadataIndexTTL time.Duration `yaml:"metadataIndexTTL"`
+	// Runtime adapts memory use to small devices such as a Raspberry Pi.
+	Runtime Runtime `yaml:"runtime"`
+	// StartupSelfTest controls running a sample image through the pipeline at
+	// startup: StartupSelfTestWarn (default), Fail or Off.
+	StartupSelfTest string `yaml:"startupSelfTest"`
+	// Chaos makes the server fail on purpose for testing clients; test builds only.
+	Chaos Chaos `yaml:"chaos"`
+
+	// fileKeys and envKeys record where settings came from (see EffectiveSettings).
+	fileKeys map[string]bool
+	envKeys  map[string]bool
 }
 
+// errProcessorsKey rejects a top-level processors key, which would otherwise
+// be ignored and leave the pipeline empty without notice.
+var errProcessorsKey = errors.New(`"processors" is not supported; configure the pipeline under "commands" (see local.example.yaml)`)
+
 // LoadServerConfig reads and parses a YAML server config from the given path.
 func LoadServerConfig(path string) (*ServiceConfig, error) {
 	// #nosec G304 -- reading configuration from a user-provided path is intended; path is controlled via env/defaults
@@ -44,8 +661,26 @@ func LoadServerConfig(path string) (*ServiceConfig, error) {
 	if err := validateCommandConfigs(config.Commands); err != nil {
 		return nil, fmt.Errorf("invalid command configuration: %w", err)
 	}
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	if _, ok := raw["processors"]; ok {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, errProcessorsKey)
+	}
+	config.fileKeys = map[string]bool{}
+	for key := range flattenSettings("", raw) {
+		config.fileKeys[key] = true
+	}
+	config.envKeys = map[string]bool{}
 
 	// Defaults
+	if len(config.Listeners) == 0 {
+		config.Listeners = []Listener{{Address: fmt.Sprintf(":%d", config.Port)}}
+	}
+	if err := validateListeners(append(config.Listeners, config.ManagementListeners...)); err != nil {
+		return nil, fmt.Errorf("invalid listener configuration: %w", err)
+	}
 	if config.Timezone == "" {
 		config.Timezone = "UTC"
 	}
@@ -58,22 +693,404 @@ func LoadServerConfig(path string) (*ServiceConfig, error) {
 	if config.LogLevel == "" {
 		config.LogLevel = "info"
 	}
-	if config.Database.AccessKey == "" {
-		config.Database.AccessKey = os.Getenv("RUSTFS_ACCESS_KEY")
-	}
-	if config.Database.SecretKey == "" {
-		config.Database.SecretKey = os.Getenv("RUSTFS_SECRET_KEY")
-	}
-	if config.Database.ImageBaseURL == "" {
-		config.Database.ImageBaseURL = os.Getenv("RUSTFS_IMAGE_BASE_URL")
+	if err := applyOutputDefaults(&config); err != nil {
+		return nil, err
 	}
+	config.setFromEnv("database.accessKey", &config.Database.AccessKey, "RUSTFS_ACCESS_KEY")
+	config.setFromEnv("database.secretKey", &config.Database.SecretKey, "RUSTFS_SECRET_KEY")
+	config.setFromEnv("database.imageBaseURL", &config.Database.ImageBaseURL, "RUSTFS_IMAGE_BASE_URL")
 	if config.Database.ImageBaseURL == "" {
 		config.Database.ImageBaseURL = "/images"
 	}
+	if config.Runtime.MemoryLimitMB < 0 {
+		return nil, fmt.Errorf("invalid runtime configuration: memoryLimitMB must not be negative (got %d)", config.Runtime.MemoryLimitMB)
+	}
+	if err := applyProcessedImagesDefaults(&config.ProcessedImages, config.Runtime.LowMemory()); err != nil {
+		return nil, fmt.Errorf("invalid processedImages configuration: %w", err)
+	}
+	if config.NormalizeAtRest.MaxDimension <= 0 {
+		config.NormalizeAtRest.MaxDimension = 2048
+	}
+	if q := config.NormalizeAtRest.Quality; q != 0 {
+		if q < 1 || q > 100 {
+			return nil, fmt.Errorf("invalid normalizeAtRest configuration: quality must be between 1 and 100 (got %d)", q)
+		}
+		if config.NormalizeAtRest.BitsPerChannel != 0 {
+			return nil, fmt.Errorf("invalid normalizeAtRest configuration: set either quality or bitsPerChannel, not both")
+		}
+		config.NormalizeAtRest.BitsPerChannel = (q*8 + 99) / 100
+	}
+	if config.NormalizeAtRest.BitsPerChannel == 0 {
+		config.NormalizeAtRest.BitsPerChannel = 8
+	}
+	if b := config.NormalizeAtRest.BitsPerChannel; b < 1 || b > 8 {
+		return nil, fmt.Errorf("invalid normalizeAtRest configuration: bitsPerChannel must be between 1 and 8 (got %d)", b)
+	}
+	if config.Uploads.SpoolThresholdBytes <= 0 {
+		config.Uploads.SpoolThresholdBytes = 4 << 20
+	}
+	if config.Uploads.MemoryBudgetBytes <= 0 {
+		config.Uploads.MemoryBudgetBytes = 256 << 20
+		if limit := int64(config.Runtime.MemoryLimitMB) << 20; limit > 0 {
+			config.Uploads.MemoryBudgetBytes = min(config.Uploads.MemoryBudgetBytes, limit/4)
+		}
+	}
+	applyRouteLimitsDefaults(&config.Limits.API, time.Minute, 1<<20)
+	applyRouteLimitsDefaults(&config.Limits.HTMX, 30*time.Second, 1<<20)
+	applyRouteLimitsDefaults(&config.Limits.Uploads, 10*time.Minute, 128<<20)
+	if config.Replication.Interval <= 0 {
+		config.Replication.Interval = 5 * time.Minute
+	}
+	if config.UpdateCheck.Interval <= 0 {
+		config.UpdateCheck.Interval = 24 * time.Hour
+	}
+	switch config.CurrentImageDeletion {
+	case "":
+		config.CurrentImageDeletion = CurrentImageDeletionAllow
+	case CurrentImageDeletionAllow, CurrentImageDeletionWarn, CurrentImageDeletionBlock, CurrentImageDeletionAdvance:
+	default:
+		return nil, fmt.Errorf("currentImageDeletion must be %s, %s, %s or %s (got %q)",
+			CurrentImageDeletionAllow, CurrentImageDeletionWarn, CurrentImageDeletionBlock, CurrentImageDeletionAdvance, config.CurrentImageDeletion)
+	}
+	switch config.StartupSelfTest {
+	case "":
+		config.StartupSelfTest = StartupSelfTestWarn
+	case StartupSelfTestWarn, StartupSelfTestFail, StartupSelfTestOff:
+	default:
+		return nil, fmt.Errorf("startupSelfTest must be %s, %s or %s (got %q)",
+			StartupSelfTestWarn, StartupSelfTestFail, StartupSelfTestOff, config.StartupSelfTest)
+	}
+	if config.NearDuplicateDistance == 0 {
+		config.NearDuplicateDistance = 6
+	}
+	if config.Pregeneration.Lead <= 0 {
+		config.Pregeneration.Lead = 5 * time.Minute
+	}
+	if config.GarbageCollection.Interval <= 0 {
+		config.GarbageCollection.Interval = 24 * time.Hour
+	}
+	if config.GarbageCollection.MinAge <= 0 {
+		config.GarbageCollection.MinAge = time.Hour
+	}
+	if config.Reprocessing.Workers <= 0 {
+		config.Reprocessing.Workers = 2
+		if config.Runtime.LowMemory() {
+			config.Reprocessing.Workers = 1
+		}
+	}
+	if config.Moderation.WebhookTimeout <= 0 {
+		config.Moderation.WebhookTimeout = 10 * time.Second
+	}
+	if config.CurrentImageCacheTTL == 0 {
+		config.CurrentImageCacheTTL = 2 * time.Second
+	}
+	if config.MetadataIndexTTL == 0 {
+		config.MetadataIndexTTL = 30 * time.Second
+	}
+	if config.Captioning.WebhookTimeout <= 0 {
+		config.Captioning.WebhookTimeout = 30 * time.Second
+	}
+	if config.Panel.MinRefreshInterval < 0 {
+		return nil, fmt.Errorf("invalid panel configuration: minRefreshInterval must not be negative")
+	}
+	applyIntegrationsDefaults(&config.Integrations)
+	if err := applySourcesDefaults(config.Sources); err != nil {
+		return nil, fmt.Errorf("invalid sources configuration: %w", err)
+	}
+	if err := applyNotificationsDefaults(&config.Notifications); err != nil {
+		return nil, fmt.Errorf("invalid notifications configuration: %w", err)
+	}
+	if err := applyHooksDefaults(config.Hooks); err != nil {
+		return nil, fmt.Errorf("invalid hooks configuration: %w", err)
+	}
+	if err := applyOverlaysDefaults(config.Overlays); err != nil {
+		return nil, fmt.Errorf("invalid overlays configuration: %w", err)
+	}
+	if err := validateDeviceProfiles(config.Devices); err != nil {
+		return nil, fmt.Errorf("invalid devices configuration: %w", err)
+	}
+	if err := applyChaosDefaults(&config.Chaos); err != nil {
+		return nil, fmt.Errorf("invalid chaos configuration: %w", err)
+	}
+	if err := applyCORSDefaults(&config.CORS); err != nil {
+		return nil, fmt.Errorf("invalid cors configuration: %w", err)
+	}
+	if err := applySVGDefaults(&config.SVG, config.SvgFallbackLongSidePixelCount); err != nil {
+		return nil, fmt.Errorf("invalid svg configuration: %w", err)
+	}
+	if err := applyBandwidthDefaults(&config.Bandwidth); err != nil {
+		return nil, fmt.Errorf("invalid bandwidth configuration: %w", err)
+	}
+	if err := applyDisplayDefaults(&config.Display); err != nil {
+		return nil, fmt.Errorf("invalid display configuration: %w", err)
+	}
 
 	return &config, nil
 }
 
+// applyProcessedImagesDefaults validates the mode and fills in cache defaults,
+// which are smaller with lowMemory.
+func applyProcessedImagesDefaults(p *ProcessedImages, lowMemory bool) error {
+	switch p.Mode {
+	case "":
+		p.Mode = ProcessedImageModeStored
+	case ProcessedImageModeStored, ProcessedImageModeOnDemand:
+	default:
+		return fmt.Errorf("mode must be %s or %s (got %q)", ProcessedImageModeStored, ProcessedImageModeOnDemand, p.Mode)
+	}
+	if p.CacheTTL <= 0 {
+		p.CacheTTL = time.Hour
+	}
+	if p.CacheMaxEntries <= 0 {
+		p.CacheMaxEntries = 16
+		if lowMemory {
+			p.CacheMaxEntries = 4
+		}
+	}
+	return nil
+}
+
+// applyIntegrationsDefaults fills in unset retry and circuit breaker settings.
+func applyIntegrationsDefaults(i *Integrations) {
+	if i.Retries == 0 {
+		i.Retries = 2
+	}
+	if i.Retries < 0 {
+		i.Retries = 0
+	}
+	if i.RetryBaseDelay <= 0 {
+		i.RetryBaseDelay = time.Second
+	}
+	if i.RetryMaxDelay <= 0 {
+		i.RetryMaxDelay = 30 * time.Second
+	}
+	if i.FailureThreshold <= 0 {
+		i.FailureThreshold = 5
+	}
+	if i.OpenDuration <= 0 {
+		i.OpenDuration = time.Minute
+	}
+}
+
+// applySourcesDefaults validates names and types and fills in intervals.
+// Whether a type exists is checked when the sources are created.
+func applySourcesDefaults(sources []Source) error {
+	seenNames := make(map[string]bool, len(sources))
+	for i := range sources {
+		s := &sources[i]
+		if s.Name == "" || strings.ContainsAny(s.Name, ": /") {
+			return fmt.Errorf("source at index %d needs a name without colons, slashes or spaces (got %q)", i, s.Name)
+		}
+		if seenNames[s.Name] {
+			return fmt.Errorf("duplicate source name: %s", s.Name)
+		}
+		seenNames[s.Name] = true
+		if s.Type == "" {
+			return fmt.Errorf("source %s has no type", s.Name)
+		}
+		if s.Interval <= 0 {
+			s.Interval = time.Hour
+		}
+	}
+	return nil
+}
+
+// applyHooksDefaults validates hook points and targets and fills in timeouts.
+func applyHooksDefaults(hooks []Hook) error {
+	for i := range hooks {
+		h := &hooks[i]
+		switch h.On {
+		case HookPreUpload, HookPostProcess, HookPreServe:
+		default:
+			return fmt.Errorf("hook at index %d: on must be %s, %s or %s (got %q)", i, HookPreUpload, HookPostProcess, HookPreServe, h.On)
+		}
+		if (h.URL == "") == (len(h.Command) == 0) {
+			return fmt.Errorf("hook at index %d (%s) requires either url or command", i, h.On)
+		}
+		if h.Timeout <= 0 {
+			h.Timeout = 10 * time.Second
+		}
+	}
+	return nil
+}
+
+// applyNotificationsDefaults validates the channels and fills in check defaults.
+func applyNotificationsDefaults(n *Notifications) error {
+	for i := range n.Channels {
+		c := &n.Channels[i]
+		switch c.Type {
+		case NotificationChannelNtfy, NotificationChannelWebhook:
+			if c.URL == "" {
+				return fmt.Errorf("channel at index %d (%s) requires url", i, c.Type)
+			}
+		case NotificationChannelPushover:
+			if c.Token == "" || c.User == "" {
+				return fmt.Errorf("channel at index %d (pushover) requires token and user", i)
+			}
+		case NotificationChannelEmail:
+			if c.SMTPHost == "" || c.From == "" || len(c.To) == 0 {
+				return fmt.Errorf("channel at index %d (email) requires smtpHost, from and to", i)
+			}
+			if c.SMTPPort == 0 {
+				c.SMTPPort = 587
+			}
+		default:
+			return fmt.Errorf("channel at index %d has unknown type %q", i, c.Type)
+		}
+	}
+	if n.ProcessingFailureThreshold <= 0 {
+		n.ProcessingFailureThreshold = 3
+	}
+	if n.StorageWarnRatio <= 0 || n.StorageWarnRatio > 1 {
+		n.StorageWarnRatio = 0.9
+	}
+	if n.CheckInterval <= 0 {
+		n.CheckInterval = 5 * time.Minute
+	}
+	return nil
+}
+
+// applyOverlaysDefaults validates positions and widgets and fills in defaults.
+func applyOverlaysDefaults(overlays []Overlay) error {
+	for i := range overlays {
+		o := &overlays[i]
+		switch o.Position {
+		case "":
+			o.Position = OverlayPositionTopLeft
+		case OverlayPositionTopLeft, OverlayPositionTopRight, OverlayPositionBottomLeft, OverlayPositionBottomRight:
+		default:
+			return fmt.Errorf("overlay at index %d has unknown position %q", i, o.Position)
+		}
+		if len(o.Widgets) == 0 {
+			return fmt.Errorf("overlay at index %d has no widgets", i)
+		}
+		for j := range o.Widgets {
+			w := &o.Widgets[j]
+			switch w.Type {
+			case OverlayWidgetClock:
+				if w.Format == "" {
+					w.Format = "15:04"
+				}
+			case OverlayWidgetWeather:
+				if w.Latitude < -90 || w.Latitude > 90 || w.Longitude < -180 || w.Longitude > 180 {
+					return fmt.Errorf("weather widget %d of overlay %d has invalid coordinates", j, i)
+				}
+				if w.URL == "" {
+					w.URL = "https://api.open-meteo.com/v1/forecast"
+				}
+			case OverlayWidgetText:
+				if w.Template == "" {
+					return fmt.Errorf("text widget %d of overlay %d has no template", j, i)
+				}
+				if _, err := template.New("").Parse(w.Template); err != nil {
+					return fmt.Errorf("text widget %d of overlay %d has an invalid template: %w", j, i, err)
+				}
+			case OverlayWidgetBattery, OverlayWidgetCountdown:
+			default:
+				return fmt.Errorf("widget %d of overlay %d has unknown type %q", j, i, w.Type)
+			}
+		}
+	}
+	return nil
+}
+
+// applyRouteLimitsDefaults fills in unset limits; negative values stay and
+// disable the limit.
+func applyRouteLimitsDefaults(l *RouteLimits, timeout time.Duration, maxBodyBytes int64) {
+	if l.Timeout == 0 {
+		l.Timeout = timeout
+	}
+	if l.MaxBodyBytes == 0 {
+		l.MaxBodyBytes = maxBodyBytes
+	}
+}
+
+// deviceProfileNamePattern restricts profile names to URL path segments.
+var deviceProfileNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// validateDeviceProfiles checks that profiles have unique names and a pipeline.
+func validateDeviceProfiles(profiles []DeviceProfile) error {
+	seen := make(map[string]bool, len(profiles))
+	for i, p := range profiles {
+		if !deviceProfileNamePattern.MatchString(p.Name) {
+			return fmt.Errorf("device profile at index %d: name %q must be 1-64 letters, digits, '.', '_' or '-'", i, p.Name)
+		}
+		if seen[p.Name] {
+			return fmt.Errorf("device profile %q is defined twice", p.Name)
+		}
+		seen[p.Name] = true
+		if len(p.Commands) == 0 {
+			return fmt.Errorf("device profile %q has no commands", p.Name)
+		}
+		for j, c := range p.Commands {
+			if c.Name == "" {
+				return fmt.Errorf("command at index %d of device profile %q has no name", j, p.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// applyChaosDefaults validates the failure rates and fills in the path prefixes.
+func applyChaosDefaults(c *Chaos) error {
+	if c.ErrorRate < 0 || c.ErrorRate > 1 {
+		return fmt.Errorf("errorRate must be between 0 and 1 (got %g)", c.ErrorRate)
+	}
+	if c.DatabaseErrorRate < 0 || c.DatabaseErrorRate > 1 {
+		return fmt.Errorf("databaseErrorRate must be between 0 and 1 (got %g)", c.DatabaseErrorRate)
+	}
+	if len(c.PathPrefixes) == 0 {
+		c.PathPrefixes = []string{"/api/"}
+	}
+	return nil
+}
+
+// applyCORSDefaults validates the allowed origins and fills in the methods,
+// headers and preflight max age.
+func applyCORSDefaults(c *CORS) error {
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			if c.AllowCredentials {
+				return errors.New(`allowCredentials cannot be combined with the "*" origin; list the origins instead`)
+			}
+			continue
+		}
+		scheme, host, ok := strings.Cut(origin, "://")
+		if !ok || (scheme != "http" && scheme != "https") || host == "" || strings.ContainsAny(host, "/?#") {
+			return fmt.Errorf("origin %q must be \"*\" or have the form https://host[:port]", origin)
+		}
+	}
+	if len(c.AllowedMethods) == 0 {
+		c.AllowedMethods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE"}
+	}
+	if len(c.AllowedHeaders) == 0 {
+		c.AllowedHeaders = []string{"Content-Type", "Authorization", "X-API-Key"}
+	}
+	if c.MaxAge < 0 {
+		return fmt.Errorf("maxAge must not be negative (got %s)", c.MaxAge)
+	}
+	if c.MaxAge == 0 {
+		c.MaxAge = 10 * time.Minute
+	}
+	return nil
+}
+
+// validateListeners rejects empty and duplicate addresses.
+func validateListeners(listeners []Listener) error {
+	seen := make(map[string]bool, len(listeners))
+	for i, l := range listeners {
+		network, address := l.Network()
+		if address == "" {
+			return fmt.Errorf("listener at index %d has empty %s address", i, network)
+		}
+		if seen[l.Address] {
+			return fmt.Errorf("duplicate listener address: %s", l.Address)
+		}
+		seen[l.Address] = true
+	}
+	return nil
+}
+
 // validateCommandConfigs ensures all command configurations have required and unique names.
 func validateCommandConfigs(commands []CommandConfig) error {
 	seenNames := make(map[string]bool, len(commands))
@@ -88,3 +1105,99 @@ func validateCommandConfigs(commands []CommandConfig) error {
 	}
 	return nil
 }
+
+// applySVGDefaults fills in the SVG limits and checks that SVGs without an
+// explicit size are not rendered larger than allowed.
+func applySVGDefaults(s *SVG, fallbackLongSide int) error {
+	if s.MaxLongSidePixelCount == 0 {
+		s.MaxLongSidePixelCount = max(8192, fallbackLongSide)
+	}
+	if s.MaxLongSidePixelCount < fallbackLongSide {
+		return fmt.Errorf("maxLongSidePixelCount (%d) must not be below svgFallbackLongSidePixelCount (%d)", s.MaxLongSidePixelCount, fallbackLongSide)
+	}
+	if s.MaxElements < 0 {
+		return fmt.Errorf("maxElements must not be negative")
+	}
+	if s.MaxElements == 0 {
+		s.MaxElements = 100000
+	}
+	if s.RenderTimeout < 0 {
+		return fmt.Errorf("renderTimeout must not be negative")
+	}
+	if s.RenderTimeout == 0 {
+		s.RenderTimeout = 10 * time.Second
+	}
+	return nil
+}
+
+// applyBandwidthDefaults checks the download rate and fills in the burst.
+func applyBandwidthDefaults(b *Bandwidth) error {
+	if b.BytesPerSecond < 0 {
+		return fmt.Errorf("bytesPerSecond must not be negative")
+	}
+	if b.Burst < 0 {
+		return fmt.Errorf("burst must not be negative")
+	}
+	if !b.Enabled() {
+		return nil
+	}
+	if b.Burst == 0 {
+		b.Burst = 32 << 10
+	}
+	b.Burst = min(b.Burst, b.BytesPerSecond)
+	return nil
+}
+
+// applyDisplayDefaults normalizes the locale, clock and first day of the week.
+func applyDisplayDefaults(d *Display) error {
+	if d.Locale == "" {
+		d.Locale = DisplayLocaleISO
+	}
+	i := slices.IndexFunc(DisplayLocales, func(l string) bool { return strings.EqualFold(l, d.Locale) })
+	if i < 0 {
+		return fmt.Errorf("locale %q must be one of %s", d.Locale, strings.Join(DisplayLocales, ", "))
+	}
+	d.Locale = DisplayLocales[i]
+	switch d.Clock = strings.ToLower(d.Clock); d.Clock {
+	case "", DisplayClock12h, DisplayClock24h:
+	default:
+		return fmt.Errorf("clock %q must be %q or %q", d.Clock, DisplayClock12h, DisplayClock24h)
+	}
+	if d.FirstDayOfWeek == "" {
+		return nil
+	}
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(d.FirstDayOfWeek, day.String()) {
+			d.FirstDayOfWeek = strings.ToLower(day.String())
+			return nil
+		}
+	}
+	return fmt.Errorf("firstDayOfWeek %q must be a weekday such as monday or sunday", d.FirstDayOfWeek)
+}
+
+// imageTargetTypes lists the values accepted by imageTargetType.
+var imageTargetTypes = []string{ImageTargetTypePNG, ImageTargetTypeJPEG, ImageTargetTypeBMP, ImageTargetTypePacked}
+
+// applyOutputDefaults validates the format images are served in and fills in
+// the JPEG quality.
+func applyOutputDefaults(config *ServiceConfig) error {
+	switch strings.ToLower(config.ImageTargetType) {
+	case "", ImageTargetTypePNG:
+		config.ImageTargetType = ImageTargetTypePNG
+	case ImageTargetTypeJPEG, "jpg":
+		config.ImageTargetType = ImageTargetTypeJPEG
+	case ImageTargetTypeBMP, ImageTargetTypePacked:
+		config.ImageTargetType = strings.ToLower(config.ImageTargetType)
+	case "webp":
+		return fmt.Errorf("imageTargetType webp is not supported: no WebP encoder is available, use %s", strings.Join(imageTargetTypes, ", "))
+	default:
+		return fmt.Errorf("imageTargetType must be one of %s (got %q)", strings.Join(imageTargetTypes, ", "), config.ImageTargetType)
+	}
+	if config.JPEGQuality == 0 {
+		config.JPEGQuality = 90
+	}
+	if config.JPEGQuality < 1 || config.JPEGQuality > 100 {
+		return fmt.Errorf("jpegQuality must be between 1 and 100 (got %d)", config.JPEGQuality)
+	}
+	return nil
+}
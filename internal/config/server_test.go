@@ -1,9 +1,12 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoadServerConfig_Success(t *testing.T) {
@@ -170,3 +173,600 @@ commands:
 		t.Fatal("Expected error for invalid YAML, got nil")
 	}
 }
+
+// writeTestConfig writes content to a config.yaml in a temp dir and returns its path.
+func writeTestConfig(t *testing.T, content string) string {
+	t.Helper()
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to create test config file: %v", err)
+	}
+	return configPath
+}
+
+func TestLoadServerConfig_ProcessedImagesDefaults(t *testing.T) {
+	config, err := LoadServerConfig(writeTestConfig(t, `port: 8080`))
+	if err != nil {
+		t.Fatalf("LoadServerConfig failed: %v", err)
+	}
+
+	if config.ProcessedImages.Mode != ProcessedImageModeStored {
+		t.Errorf("Expected default mode %q, got %q", ProcessedImageModeStored, config.ProcessedImages.Mode)
+	}
+	if config.ProcessedImages.CacheTTL != time.Hour {
+		t.Errorf("Expected default cacheTTL 1h, got %v", config.ProcessedImages.CacheTTL)
+	}
+	if config.ProcessedImages.CacheMaxEntries != 16 {
+		t.Errorf("Expected default cacheMaxEntries 16, got %d", config.ProcessedImages.CacheMaxEntries)
+	}
+}
+
+func TestLoadServerConfig_ProcessedImagesOnDemand(t *testing.T) {
+	config, err := LoadServerConfig(writeTestConfig(t, `processedImages:
+  mode: onDemand
+  cacheTTL: 15m
+  cacheMaxEntries: 4`))
+	if err != nil {
+		t.Fatalf("LoadServerConfig failed: %v", err)
+	}
+
+	if config.ProcessedImages.Mode != ProcessedImageModeOnDemand {
+		t.Errorf("Expected mode %q, got %q", ProcessedImageModeOnDemand, config.ProcessedImages.Mode)
+	}
+	if config.ProcessedImages.CacheTTL != 15*time.Minute {
+		t.Errorf("Expected cacheTTL 15m, got %v", config.ProcessedImages.CacheTTL)
+	}
+	if config.ProcessedImages.CacheMaxEntries != 4 {
+		t.Errorf("Expected cacheMaxEntries 4, got %d", config.ProcessedImages.CacheMaxEntries)
+	}
+}
+
+func TestLoadServerConfig_ProcessedImagesInvalidMode(t *testing.T) {
+	_, err := LoadServerConfig(writeTestConfig(t, `processedImages:
+  mode: sometimes`))
+	if err == nil {
+		t.Fatal("Expected error for invalid processedImages mode, got nil")
+	}
+}
+
+func TestLoadServerConfig_UpdateCheckDefaults(t *testing.T) {
+	path := writeTestConfig(t, "updateCheck:\n  enabled: true\n")
+
+	cfg, err := LoadServerConfig(path)
+	if err != nil {
+		t.Fatalf("LoadServerConfig failed: %v", err)
+	}
+	if !cfg.UpdateCheck.Enabled {
+		t.Error("Expected update check to be enabled")
+	}
+	if cfg.UpdateCheck.Interval != 24*time.Hour {
+		t.Errorf("Expected default interval 24h, got %v", cfg.UpdateCheck.Interval)
+	}
+}
+
+func TestLoadServerConfig_UploadsDefaults(t *testing.T) {
+	cfg, err := LoadServerConfig(writeTestConfig(t, "port: 8080\n"))
+	if err != nil {
+		t.Fatalf("LoadServerConfig failed: %v", err)
+	}
+	if cfg.Uploads.SpoolThresholdBytes != 4<<20 {
+		t.Errorf("Expected default spool threshold 4 MiB, got %d", cfg.Uploads.SpoolThresholdBytes)
+	}
+	if cfg.Uploads.MemoryBudgetBytes != 256<<20 {
+		t.Errorf("Expected default memory budget 256 MiB, got %d", cfg.Uploads.MemoryBudgetBytes)
+	}
+}
+
+func TestLoadServerConfig_LimitsDefaults(t *testing.T) {
+	cfg, err := LoadServerConfig(writeTestConfig(t, `limits:
+  api:
+    timeout: 5s
+  uploads:
+    timeout: -1s
+    maxBodyBytes: -1
+`))
+	if err != nil {
+		t.Fatalf("LoadServerConfig failed: %v", err)
+	}
+	want := Limits{
+		API:     RouteLimits{Timeout: 5 * time.Second, MaxBodyBytes: 1 << 20},
+		HTMX:    RouteLimits{Timeout: 30 * time.Second, MaxBodyBytes: 1 << 20},
+		Uploads: RouteLimits{Timeout: -time.Second, MaxBodyBytes: -1},
+	}
+	if cfg.Limits != want {
+		t.Errorf("Expected limits %+v, got %+v", want, cfg.Limits)
+	}
+}
+
+func TestLoadServerConfig_ListenersDefaultToPort(t *testing.T) {
+	cfg, err := LoadServerConfig(writeTestConfig(t, "port: 8081\n"))
+	if err != nil {
+		t.Fatalf("LoadServerConfig failed: %v", err)
+	}
+	if len(cfg.Listeners) != 1 || cfg.Listeners[0].Address != ":8081" {
+		t.Errorf("Expected default listener :8081, got %+v", cfg.Listeners)
+	}
+}
+
+func TestLoadServerConfig_InvalidListeners(t *testing.T) {
+	for _, content := range []string{
+		"listeners:\n  - address: \"unix:\"\n",
+		"listeners:\n  - address: \"systemd:\"\n",
+		"listeners:\n  - address: \":8080\"\nmanagementListeners:\n  - address: \":8080\"\n",
+	} {
+		if _, err := LoadServerConfig(writeTestConfig(t, content)); err == nil {
+			t.Errorf("Expected error for config %q", content)
+		}
+	}
+}
+
+func TestListener_Network(t *testing.T) {
+	tests := []struct {
+		address, wantNetwork, wantAddress string
+	}{
+		{":8080", "tcp", ":8080"},
+		{"127.0.0.1:9090", "tcp", "127.0.0.1:9090"},
+		{"unix:/run/goframe.sock", "unix", "/run/goframe.sock"},
+		{"systemd:goframe.socket", "systemd", "goframe.socket"},
+	}
+	for _, tt := range tests {
+		network, address := Listener{Address: tt.address}.Network()
+		if network != tt.wantNetwork || address != tt.wantAddress {
+			t.Errorf("Network(%q) = %q, %q; want %q, %q", tt.address, network, address, tt.wantNetwork, tt.wantAddress)
+		}
+	}
+}
+
+func TestLoadServerConfig_Notifications(t *testing.T) {
+	cfg, err := LoadServerConfig(writeTestConfig(t, "notifications:\n  channels:\n    - type: email\n      smtpHost: mail.example.com\n      from: frame@example.com\n      to: [owner@example.com]\n"))
+	if err != nil {
+		t.Fatalf("LoadServerConfig failed: %v", err)
+	}
+	n := cfg.Notifications
+	if n.Channels[0].SMTPPort != 587 {
+		t.Errorf("Expected default SMTP port 587, got %d", n.Channels[0].SMTPPort)
+	}
+	if n.ProcessingFailureThreshold != 3 || n.StorageWarnRatio != 0.9 || n.CheckInterval != 5*time.Minute {
+		t.Errorf("Unexpected notification defaults: %+v", n)
+	}
+
+	for _, content := range []string{
+		"notifications:\n  channels:\n    - type: sms\n",
+		"notifications:\n  channels:\n    - type: ntfy\n",
+		"notifications:\n  channels:\n    - type: pushover\n      token: abc\n",
+	} {
+		if _, err := LoadServerConfig(writeTestConfig(t, content)); err == nil {
+			t.Errorf("Expected error for config %q", content)
+		}
+	}
+}
+
+func TestLoadServerConfig_PregenerationDefaults(t *testing.T) {
+	cfg, err := LoadServerConfig(writeTestConfig(t, "pregeneration:\n  enabled: true\n"))
+	if err != nil {
+		t.Fatalf("LoadServerConfig failed: %v", err)
+	}
+	if !cfg.Pregeneration.Enabled || cfg.Pregeneration.Lead != 5*time.Minute {
+		t.Errorf("Unexpected pregeneration config: %+v", cfg.Pregeneration)
+	}
+}
+
+func TestLoadServerConfig_GarbageCollectionDefaults(t *testing.T) {
+	cfg, err := LoadServerConfig(writeTestConfig(t, "garbageCollection:\n  enabled: true\n  minAge: 30m\n"))
+	if err != nil {
+		t.Fatalf("LoadServerConfig failed: %v", err)
+	}
+	gc := cfg.GarbageCollection
+	if !gc.Enabled || gc.Interval != 24*time.Hour || gc.MinAge != 30*time.Minute {
+		t.Errorf("Unexpected garbage collection config: %+v", gc)
+	}
+}
+
+func TestLoadServerConfig_Moderation(t *testing.T) {
+	cfg, err := LoadServerConfig(writeTestConfig(t, "moderation:\n  enabled: true\n  trustedSources: [xkcd]\n"))
+	if err != nil {
+		t.Fatalf("LoadServerConfig failed: %v", err)
+	}
+	m := cfg.Moderation
+	if !m.Enabled || len(m.TrustedSources) != 1 || m.WebhookTimeout != 10*time.Second {
+		t.Errorf("Unexpected moderation config: %+v", m)
+	}
+}
+
+func TestLoadServerConfig_CurrentImageDeletion(t *testing.T) {
+	cfg, err := LoadServerConfig(writeTestConfig(t, "port: 8080\n"))
+	if err != nil {
+		t.Fatalf("LoadServerConfig failed: %v", err)
+	}
+	if cfg.CurrentImageDeletion != CurrentImageDeletionAllow {
+		t.Errorf("Expected default %q, got %q", CurrentImageDeletionAllow, cfg.CurrentImageDeletion)
+	}
+	if _, err := LoadServerConfig(writeTestConfig(t, "currentImageDeletion: never\n")); err == nil {
+		t.Error("Expected error for unknown currentImageDeletion")
+	}
+}
+
+func TestLoadServerConfig_NormalizeAtRestQuality(t *testing.T) {
+	tests := []struct {
+		yaml     string
+		wantBits int
+	}{
+		{"port: 8080\n", 8},
+		{"normalizeAtRest:\n  quality: 100\n", 8},
+		{"normalizeAtRest:\n  quality: 50\n", 4},
+		{"normalizeAtRest:\n  quality: 1\n", 1},
+		{"normalizeAtRest:\n  bitsPerChannel: 5\n", 5},
+	}
+	for _, tt := range tests {
+		cfg, err := LoadServerConfig(writeTestConfig(t, tt.yaml))
+		if err != nil {
+			t.Fatalf("%q: LoadServerConfig failed: %v", tt.yaml, err)
+		}
+		if cfg.NormalizeAtRest.BitsPerChannel != tt.wantBits {
+			t.Errorf("%q: expected %d bits per channel, got %d", tt.yaml, tt.wantBits, cfg.NormalizeAtRest.BitsPerChannel)
+		}
+	}
+	for _, invalid := range []string{
+		"normalizeAtRest:\n  quality: 101\n",
+		"normalizeAtRest:\n  quality: 50\n  bitsPerChannel: 4\n",
+	} {
+		if _, err := LoadServerConfig(writeTestConfig(t, invalid)); err == nil {
+			t.Errorf("%q: expected an error", invalid)
+		}
+	}
+}
+
+func TestLoadServerConfig_StartupSelfTest(t *testing.T) {
+	cfg, err := LoadServerConfig(writeTestConfig(t, "port: 8080\n"))
+	if err != nil {
+		t.Fatalf("LoadServerConfig failed: %v", err)
+	}
+	if cfg.StartupSelfTest != StartupSelfTestWarn {
+		t.Errorf("Expected default %q, got %q", StartupSelfTestWarn, cfg.StartupSelfTest)
+	}
+	if _, err := LoadServerConfig(writeTestConfig(t, "startupSelfTest: maybe\n")); err == nil {
+		t.Error("Expected error for unknown startupSelfTest")
+	}
+}
+
+func TestLoadServerConfig_RejectsProcessors(t *testing.T) {
+	_, err := LoadServerConfig(writeTestConfig(t, "processors:\n  - name: ScaleCommand\n"))
+	if !errors.Is(err, errProcessorsKey) {
+		t.Errorf("Expected errProcessorsKey, got %v", err)
+	}
+}
+
+func TestLoadServerConfig_RuntimeMemoryLimit(t *testing.T) {
+	cfg, err := LoadServerConfig(writeTestConfig(t, "runtime:\n  memoryLimitMB: 512\n"))
+	if err != nil {
+		t.Fatalf("LoadServerConfig failed: %v", err)
+	}
+	if !cfg.Runtime.LowMemory() {
+		t.Error("Expected 512 MiB to count as low memory")
+	}
+	if cfg.Uploads.MemoryBudgetBytes != 128<<20 || cfg.Reprocessing.Workers != 1 || cfg.ProcessedImages.CacheMaxEntries != 4 {
+		t.Errorf("Expected smaller defaults, got budget %d, workers %d, cache %d",
+			cfg.Uploads.MemoryBudgetBytes, cfg.Reprocessing.Workers, cfg.ProcessedImages.CacheMaxEntries)
+	}
+
+	cfg, err = LoadServerConfig(writeTestConfig(t, "runtime:\n  memoryLimitMB: 512\nreprocessing:\n  workers: 3\n"))
+	if err != nil {
+		t.Fatalf("LoadServerConfig failed: %v", err)
+	}
+	if cfg.Reprocessing.Workers != 3 {
+		t.Errorf("Expected explicit workers to be kept, got %d", cfg.Reprocessing.Workers)
+	}
+
+	cfg, err = LoadServerConfig(writeTestConfig(t, "runtime:\n  memoryLimitMB: 4096\n"))
+	if err != nil {
+		t.Fatalf("LoadServerConfig failed: %v", err)
+	}
+	if cfg.Runtime.LowMemory() || cfg.Uploads.MemoryBudgetBytes != 256<<20 || cfg.Reprocessing.Workers != 2 {
+		t.Errorf("Expected regular defaults for 4 GiB, got %+v", cfg.Runtime)
+	}
+
+	if _, err := LoadServerConfig(writeTestConfig(t, "runtime:\n  memoryLimitMB: -1\n")); err == nil {
+		t.Error("Expected error for a negative memory limit")
+	}
+}
+
+func TestLoadServerConfig_Overlays(t *testing.T) {
+	cfg, err := LoadServerConfig(writeTestConfig(t, "overlays:\n  - devices: [kitchen]\n    widgets:\n      - type: clock\n      - type: weather\n        latitude: 52.5\n        longitude: 13.4\n"))
+	if err != nil {
+		t.Fatalf("LoadServerConfig failed: %v", err)
+	}
+	o := cfg.Overlays[0]
+	if o.Position != OverlayPositionTopLeft || o.Widgets[0].Format != "15:04" || o.Widgets[1].URL == "" {
+		t.Errorf("Unexpected overlay defaults: %+v", o)
+	}
+
+	for _, content := range []string{
+		"overlays:\n  - widgets:\n      - type: stocks\n",
+		"overlays:\n  - position: center\n    widgets:\n      - type: clock\n",
+		"overlays:\n  - devices: [kitchen]\n",
+		"overlays:\n  - widgets:\n      - type: weather\n        latitude: 120\n",
+		"overlays:\n  - widgets:\n      - type: text\n",
+		"overlays:\n  - widgets:\n      - type: text\n        template: \"{{.Title\"\n",
+	} {
+		if _, err := LoadServerConfig(writeTestConfig(t, content)); err == nil {
+			t.Errorf("Expected error for config %q", content)
+		}
+	}
+}
+
+func TestLoadServerConfig_Chaos(t *testing.T) {
+	cfg, err := LoadServerConfig(writeTestConfig(t, "chaos:\n  enabled: true\n  errorRate: 0.2\n  latency: 2s\n"))
+	if err != nil {
+		t.Fatalf("LoadServerConfig failed: %v", err)
+	}
+	c := cfg.Chaos
+	if !c.Enabled || c.ErrorRate != 0.2 || c.Latency != 2*time.Second || len(c.PathPrefixes) != 1 || c.PathPrefixes[0] != "/api/" {
+		t.Errorf("Unexpected chaos config: %+v", c)
+	}
+	for _, content := range []string{"chaos:\n  errorRate: 1.5\n", "chaos:\n  databaseErrorRate: -0.1\n"} {
+		if _, err := LoadServerConfig(writeTestConfig(t, content)); err == nil {
+			t.Errorf("Expected error for config %q", content)
+		}
+	}
+}
+
+func TestLoadServerConfig_CORS(t *testing.T) {
+	cfg, err := LoadServerConfig(writeTestConfig(t, "port: 8080\n"))
+	if err != nil {
+		t.Fatalf("LoadServerConfig failed: %v", err)
+	}
+	if cfg.CORS.Enabled() {
+		t.Error("Expected CORS to be disabled by default")
+	}
+
+	cfg, err = LoadServerConfig(writeTestConfig(t, "cors:\n  allowedOrigins: [\"https://dash.example.com:8443\"]\n  allowCredentials: true\n"))
+	if err != nil {
+		t.Fatalf("LoadServerConfig failed: %v", err)
+	}
+	c := cfg.CORS
+	if !c.Enabled() || len(c.AllowedMethods) != 6 || len(c.AllowedHeaders) != 3 || c.MaxAge != 10*time.Minute {
+		t.Errorf("Unexpected CORS config: %+v", c)
+	}
+
+	for _, content := range []string{
+		"cors:\n  allowedOrigins: [\"*\"]\n  allowCredentials: true\n",
+		"cors:\n  allowedOrigins: [\"dash.example.com\"]\n",
+		"cors:\n  allowedOrigins: [\"https://dash.example.com/app\"]\n",
+		"cors:\n  allowedOrigins: [\"*\"]\n  maxAge: -1s\n",
+	} {
+		if _, err := LoadServerConfig(writeTestConfig(t, content)); err == nil {
+			t.Errorf("Expected error for config %q", content)
+		}
+	}
+}
+
+func TestLoadServerConfig_SVG(t *testing.T) {
+	cfg, err := LoadServerConfig(writeTestConfig(t, "port: 8080\n"))
+	if err != nil {
+		t.Fatalf("LoadServerConfig failed: %v", err)
+	}
+	if s := cfg.SVG; s.MaxLongSidePixelCount != 8192 || s.MaxElements != 100000 || s.RenderTimeout != 10*time.Second {
+		t.Errorf("Unexpected SVG defaults: %+v", s)
+	}
+
+	cfg, err = LoadServerConfig(writeTestConfig(t, "svgFallbackLongSidePixelCount: 10000\n"))
+	if err != nil {
+		t.Fatalf("LoadServerConfig failed: %v", err)
+	}
+	if cfg.SVG.MaxLongSidePixelCount != 10000 {
+		t.Errorf("Expected the limit to default to the larger fallback, got %d", cfg.SVG.MaxLongSidePixelCount)
+	}
+
+	for _, content := range []string{
+		"svg:\n  maxLongSidePixelCount: 1000\n",
+		"svg:\n  maxElements: -1\n",
+		"svg:\n  renderTimeout: -1s\n",
+	} {
+		if _, err := LoadServerConfig(writeTestConfig(t, content)); err == nil {
+			t.Errorf("Expected error for config %q", content)
+		}
+	}
+}
+
+func TestLoadServerConfig_ImageTargetType(t *testing.T) {
+	cfg, err := LoadServerConfig(writeTestConfig(t, "port: 8080\n"))
+	if err != nil {
+		t.Fatalf("LoadServerConfig failed: %v", err)
+	}
+	if cfg.ImageTargetType != ImageTargetTypePNG || cfg.JPEGQuality != 90 {
+		t.Errorf("Expected PNG and JPEG quality 90 by default, got %q and %d", cfg.ImageTargetType, cfg.JPEGQuality)
+	}
+
+	cfg, err = LoadServerConfig(writeTestConfig(t, "imageTargetType: JPG\njpegQuality: 75\n"))
+	if err != nil {
+		t.Fatalf("LoadServerConfig failed: %v", err)
+	}
+	if cfg.ImageTargetType != ImageTargetTypeJPEG || cfg.JPEGQuality != 75 {
+		t.Errorf("Expected JPEG with quality 75, got %q and %d", cfg.ImageTargetType, cfg.JPEGQuality)
+	}
+
+	for _, want := range []string{ImageTargetTypeBMP, ImageTargetTypePacked} {
+		cfg, err = LoadServerConfig(writeTestConfig(t, "imageTargetType: "+strings.ToUpper(want)+"\n"))
+		if err != nil {
+			t.Fatalf("LoadServerConfig failed: %v", err)
+		}
+		if cfg.ImageTargetType != want {
+			t.Errorf("Expected %s, got %q", want, cfg.ImageTargetType)
+		}
+	}
+
+	for _, content := range []string{
+		"imageTargetType: webp\n",
+		"imageTargetType: gif\n",
+		"jpegQuality: 101\n",
+	} {
+		if _, err := LoadServerConfig(writeTestConfig(t, content)); err == nil {
+			t.Errorf("Expected error for config %q", content)
+		}
+	}
+}
+
+func TestLoadServerConfig_Bandwidth(t *testing.T) {
+	cfg, err := LoadServerConfig(writeTestConfig(t, "port: 8080\n"))
+	if err != nil {
+		t.Fatalf("LoadServerConfig failed: %v", err)
+	}
+	if cfg.Bandwidth.Enabled() || cfg.Bandwidth.Burst != 0 {
+		t.Errorf("Expected downloads to be unthrottled by default, got %+v", cfg.Bandwidth)
+	}
+
+	cfg, err = LoadServerConfig(writeTestConfig(t, "bandwidth:\n  bytesPerSecond: 1000000\n"))
+	if err != nil {
+		t.Fatalf("LoadServerConfig failed: %v", err)
+	}
+	if cfg.Bandwidth.Burst != 32<<10 {
+		t.Errorf("Expected the burst to default to 32 KiB, got %d", cfg.Bandwidth.Burst)
+	}
+
+	cfg, err = LoadServerConfig(writeTestConfig(t, "bandwidth:\n  bytesPerSecond: 8000\n"))
+	if err != nil {
+		t.Fatalf("LoadServerConfig failed: %v", err)
+	}
+	if cfg.Bandwidth.Burst != 8000 {
+		t.Errorf("Expected the burst to be capped at the rate, got %d", cfg.Bandwidth.Burst)
+	}
+
+	for _, content := range []string{
+		"bandwidth:\n  bytesPerSecond: -1\n",
+		"bandwidth:\n  bytesPerSecond: 1000\n  burst: -1\n",
+	} {
+		if _, err := LoadServerConfig(writeTestConfig(t, content)); err == nil {
+			t.Errorf("Expected error for config %q", content)
+		}
+	}
+}
+
+func TestLoadServerConfig_Display(t *testing.T) {
+	cfg, err := LoadServerConfig(writeTestConfig(t, "port: 8080\n"))
+	if err != nil {
+		t.Fatalf("LoadServerConfig failed: %v", err)
+	}
+	if cfg.Display != (Display{Locale: DisplayLocaleISO}) {
+		t.Errorf("Expected ISO dates with the locale's clock and week by default, got %+v", cfg.Display)
+	}
+
+	cfg, err = LoadServerConfig(writeTestConfig(t, "display:\n  locale: de-de\n  clock: 12H\n  firstDayOfWeek: Sunday\n"))
+	if err != nil {
+		t.Fatalf("LoadServerConfig failed: %v", err)
+	}
+	if want := (Display{Locale: "de-DE", Clock: DisplayClock12h, FirstDayOfWeek: "sunday"}); cfg.Display != want {
+		t.Errorf("Expected %+v, got %+v", want, cfg.Display)
+	}
+
+	for _, content := range []string{
+		"display:\n  locale: xx-XX\n",
+		"display:\n  clock: 25h\n",
+		"display:\n  firstDayOfWeek: someday\n",
+	} {
+		if _, err := LoadServerConfig(writeTestConfig(t, content)); err == nil {
+			t.Errorf("Expected error for config %q", content)
+		}
+	}
+}
+
+func TestLoadServerConfig_Devices(t *testing.T) {
+	cfg, err := LoadServerConfig(writeTestConfig(t, `devices:
+  - name: bw-7in5
+    commands:
+      - name: ScaleCommand
+        width: 800
+        height: 480
+  - name: color-5in65
+    commands:
+      - name: DitherCommand
+        palette: spectra6
+`))
+	if err != nil {
+		t.Fatalf("LoadServerConfig failed: %v", err)
+	}
+	if len(cfg.Devices) != 2 || cfg.Devices[0].Name != "bw-7in5" || cfg.Devices[0].Commands[0].Params["width"] != 800 {
+		t.Errorf("Expected both device profiles with their pipelines, got %+v", cfg.Devices)
+	}
+
+	for _, content := range []string{
+		"devices:\n  - name: a b\n    commands:\n      - name: ScaleCommand\n",
+		"devices:\n  - name: a\n",
+		"devices:\n  - name: a\n    commands:\n      - name: ScaleCommand\n  - name: a\n    commands:\n      - name: ScaleCommand\n",
+	} {
+		if _, err := LoadServerConfig(writeTestConfig(t, content)); err == nil {
+			t.Errorf("Expected error for config %q", content)
+		}
+	}
+}
+
+func TestLoadServerConfig_Sources(t *testing.T) {
+	cfg, err := LoadServerConfig(writeTestConfig(t, "sources:\n  - name: nas\n    type: directory\n    path: /mnt/photos\n"))
+	if err != nil {
+		t.Fatalf("LoadServerConfig failed: %v", err)
+	}
+	s := cfg.Sources[0]
+	if s.Interval != time.Hour || s.Disabled || s.Params["path"] != "/mnt/photos" {
+		t.Errorf("Unexpected source %+v", s)
+	}
+
+	for _, body := range []string{
+		"sources:\n  - type: directory\n",
+		"sources:\n  - name: a:b\n    type: directory\n",
+		"sources:\n  - name: nas\n",
+		"sources:\n  - name: nas\n    type: url\n  - name: nas\n    type: url\n",
+	} {
+		if _, err := LoadServerConfig(writeTestConfig(t, body)); err == nil {
+			t.Errorf("Expected error for %q", body)
+		}
+	}
+}
+
+func TestLoadServerConfig_Hooks(t *testing.T) {
+	cfg, err := LoadServerConfig(writeTestConfig(t, `hooks:
+  - on: preUpload
+    command: [/usr/local/bin/scan-upload, --quarantine, /var/quarantine]
+  - on: postProcess
+    url: http://backup.local/images
+    timeout: 1m
+`))
+	if err != nil {
+		t.Fatalf("LoadServerConfig failed: %v", err)
+	}
+	if len(cfg.Hooks) != 2 || cfg.Hooks[0].Timeout != 10*time.Second || len(cfg.Hooks[0].Command) != 3 || cfg.Hooks[1].Timeout != time.Minute {
+		t.Errorf("Unexpected hooks %+v", cfg.Hooks)
+	}
+
+	for _, body := range []string{
+		"hooks:\n  - on: afterUpload\n    url: http://x\n",
+		"hooks:\n  - on: preServe\n",
+		"hooks:\n  - on: preServe\n    url: http://x\n    command: [true]\n",
+	} {
+		if _, err := LoadServerConfig(writeTestConfig(t, body)); err == nil {
+			t.Errorf("Expected error for %q", body)
+		}
+	}
+}
+
+func TestLoadServerConfig_IntegrationsDefaults(t *testing.T) {
+	cfg, err := LoadServerConfig(writeTestConfig(t, "integrations:\n  retries: -1\n"))
+	if err != nil {
+		t.Fatalf("LoadServerConfig failed: %v", err)
+	}
+	want := Integrations{Retries: 0, RetryBaseDelay: time.Second, RetryMaxDelay: 30 * time.Second, FailureThreshold: 5, OpenDuration: time.Minute}
+	if cfg.Integrations != want {
+		t.Errorf("Expected %+v, got %+v", want, cfg.Integrations)
+	}
+}
+
+func TestLoadServerConfig_Panel(t *testing.T) {
+	cfg, err := LoadServerConfig(writeTestConfig(t, "panel:\n  minRefreshInterval: 3m\n"))
+	if err != nil {
+		t.Fatalf("LoadServerConfig failed: %v", err)
+	}
+	if cfg.Panel.MinRefreshInterval != 3*time.Minute {
+		t.Errorf("Expected 3m, got %v", cfg.Panel.MinRefreshInterval)
+	}
+	if _, err := LoadServerConfig(writeTestConfig(t, "panel:\n  minRefreshInterval: -1m\n")); err == nil {
+		t.Error("Expected an error for a negative interval")
+	}
+}
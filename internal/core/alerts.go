@@ -0,0 +1,256 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/notify"
+	"github.com/jo-hoe/goframe/internal/resilience"
+)
+
+// notifyTimeout bounds the delivery of a single alert to one channel.
+const notifyTimeout = 30 * time.Second
+
+// alerter decides when the owner must be notified. Every condition alerts once
+// when it starts and is re-armed when it clears, so a lasting problem does not
+// flood the configured channels. A nil *alerter ignores all events.
+type alerter struct {
+	channels []alertChannel
+	cfg      config.Notifications
+
+	mu                  sync.Mutex
+	consecutiveFailures int
+	failureAlerted      bool
+	storageAlerted      bool
+	lastPoll            map[string]time.Time
+	staleAlerted        map[string]bool
+	startedAt           time.Time
+	nowFn               func() time.Time
+	wg                  sync.WaitGroup
+}
+
+// alertChannel is a notification channel with its own breaker, so a failing
+// channel is retried and paused without resending to the others.
+type alertChannel struct {
+	name     string
+	notifier notify.Notifier
+	// breaker retries deliveries and pauses them while the channel fails; a
+	// nil breaker delivers once.
+	breaker *resilience.Breaker
+}
+
+func newAlerter(cfg config.Notifications, channels ...alertChannel) *alerter {
+	return &alerter{
+		channels:     channels,
+		cfg:          cfg,
+		lastPoll:     make(map[string]time.Time),
+		staleAlerted: make(map[string]bool),
+		startedAt:    time.Now(),
+		nowFn:        time.Now,
+	}
+}
+
+// processingFailed counts a failed pipeline run and alerts once the configured
+// number of consecutive failures is reached.
+func (a *alerter) processingFailed(err error) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.consecutiveFailures++
+	if a.failureAlerted || a.consecutiveFailures < a.cfg.ProcessingFailureThreshold {
+		return
+	}
+	a.failureAlerted = true
+	a.send(notify.Message{
+		Title: "goframe: image processing keeps failing",
+		Body:  fmt.Sprintf("The last %d images failed to process. Latest error: %v", a.consecutiveFailures, err),
+	})
+}
+
+// processingSucceeded resets the consecutive failure count.
+func (a *alerter) processingSucceeded() {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.consecutiveFailures = 0
+	a.failureAlerted = false
+}
+
+// polled records that deviceID fetched its image. IDs that no device could
+// register are ignored; checkFrames prunes the others unless they are known.
+func (a *alerter) polled(deviceID string) {
+	if a == nil || !deviceIDPattern.MatchString(deviceID) {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.lastPoll[deviceID] = a.nowFn()
+	delete(a.staleAlerted, deviceID)
+}
+
+// checkStorage alerts when storedBytes reaches the warning share of the limit.
+func (a *alerter) checkStorage(storedBytes int64) {
+	if a == nil || a.cfg.StorageLimitBytes <= 0 {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	threshold := int64(float64(a.cfg.StorageLimitBytes) * a.cfg.StorageWarnRatio)
+	if storedBytes < threshold {
+		a.storageAlerted = false
+		return
+	}
+	if a.storageAlerted {
+		return
+	}
+	a.storageAlerted = true
+	a.send(notify.Message{
+		Title: "goframe: storage nearly full",
+		Body: fmt.Sprintf("Stored images use %d of %d bytes (%.0f%%).",
+			storedBytes, a.cfg.StorageLimitBytes, 100*float64(storedBytes)/float64(a.cfg.StorageLimitBytes)),
+	})
+}
+
+// checkFrames alerts about frames that have not polled within FrameStaleAfter.
+// known lists the registered and grouped frames, which are expected to poll
+// even if they have not done so since start. Polls of other devices are
+// dropped, so anonymous device IDs neither pile up nor raise alerts.
+func (a *alerter) checkFrames(known []string) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	devices := make(map[string]time.Time, len(known))
+	for _, d := range known {
+		devices[d] = a.startedAt
+		if t, ok := a.lastPoll[d]; ok {
+			devices[d] = t
+		}
+	}
+	for d := range a.lastPoll {
+		if _, ok := devices[d]; !ok {
+			delete(a.lastPoll, d)
+		}
+	}
+	for d := range a.staleAlerted {
+		if _, ok := devices[d]; !ok {
+			delete(a.staleAlerted, d)
+		}
+	}
+	if a.cfg.FrameStaleAfter <= 0 {
+		return
+	}
+
+	now := a.nowFn()
+
+	var stale []string
+	for d, last := range devices {
+		if now.Sub(last) >= a.cfg.FrameStaleAfter && !a.staleAlerted[d] {
+			a.staleAlerted[d] = true
+			stale = append(stale, d)
+		}
+	}
+	sort.Strings(stale)
+	for _, d := range stale {
+		a.send(notify.Message{
+			Title: "goframe: frame offline",
+			Body:  fmt.Sprintf("Frame %q has not fetched an image for more than %s.", d, a.cfg.FrameStaleAfter),
+		})
+	}
+}
+
+// currentImageDeleted tells the owner that the image on the frame was deleted
+// and which image is shown instead; nextID is empty when none is left.
+func (a *alerter) currentImageDeleted(deletedID, nextID string) {
+	if a == nil {
+		return
+	}
+	body := fmt.Sprintf("Image %s was deleted while on display; frames now show %s.", deletedID, nextID)
+	if nextID == "" {
+		body = fmt.Sprintf("Image %s was deleted while on display and no images are left.", deletedID)
+	}
+	a.send(notify.Message{Title: "goframe: current image changed", Body: body})
+}
+
+// send delivers msg to every channel in the background so callers never wait
+// on a channel.
+func (a *alerter) send(msg notify.Message) {
+	slog.Warn("alert", "title", msg.Title, "message", msg.Body)
+	for _, c := range a.channels {
+		a.wg.Add(1)
+		go func() {
+			defer a.wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+			defer cancel()
+			if err := c.breaker.Retry(ctx, func(ctx context.Context) error { return c.notifier.Notify(ctx, msg) }); err != nil {
+				slog.Error("alerter: failed to deliver notification", "channel", c.name, "title", msg.Title, "error", err)
+			}
+		}()
+	}
+}
+
+// RunAlertChecks periodically checks storage usage and frame liveness until ctx
+// is cancelled. It returns immediately when no notification channel is configured.
+func (service *CoreService) RunAlertChecks(ctx context.Context) {
+	if service.alerts == nil {
+		return
+	}
+	ticker := time.NewTicker(service.config.Notifications.CheckInterval)
+	defer ticker.Stop()
+	for {
+		service.checkAlerts(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (service *CoreService) checkAlerts(ctx context.Context) {
+	images, err := service.databaseService.GetImageMetadata(ctx)
+	if err != nil {
+		slog.Warn("CoreService.checkAlerts: failed to read image metadata", "error", err)
+	} else {
+		var total int64
+		for _, img := range images {
+			total += img.StoredBytes
+		}
+		service.alerts.checkStorage(total)
+	}
+
+	groups, err := service.databaseService.GetFrameGroups(ctx)
+	if err != nil {
+		slog.Warn("CoreService.checkAlerts: failed to read frame groups", "error", err)
+		return
+	}
+	devices, err := service.databaseService.GetDevices(ctx)
+	if err != nil {
+		slog.Warn("CoreService.checkAlerts: failed to read devices", "error", err)
+		return
+	}
+	var known []string
+	for _, g := range groups {
+		known = append(known, g.Devices...)
+	}
+	for _, d := range devices {
+		known = append(known, d.ID)
+	}
+	service.devicePolls.track(known...)
+	service.alerts.checkFrames(known)
+}
@@ -0,0 +1,149 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/notify"
+	"github.com/jo-hoe/goframe/internal/resilience"
+)
+
+type recordingNotifier struct {
+	mu   sync.Mutex
+	msgs []notify.Message
+}
+
+func (r *recordingNotifier) Notify(_ context.Context, msg notify.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.msgs = append(r.msgs, msg)
+	return nil
+}
+
+func (r *recordingNotifier) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.msgs)
+}
+
+// flakyNotifier fails its first failures deliveries.
+type flakyNotifier struct {
+	recordingNotifier
+	failures int
+}
+
+func (f *flakyNotifier) Notify(ctx context.Context, msg notify.Message) error {
+	f.mu.Lock()
+	if f.failures > 0 {
+		f.failures--
+		f.mu.Unlock()
+		return errors.New("unavailable")
+	}
+	f.mu.Unlock()
+	return f.recordingNotifier.Notify(ctx, msg)
+}
+
+func TestAlerter_RetriesEachChannel(t *testing.T) {
+	policy := resilience.Policy{Retries: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+	healthy, flaky := &recordingNotifier{}, &flakyNotifier{failures: 2}
+	a := newAlerter(config.Notifications{StorageLimitBytes: 1000, StorageWarnRatio: 0.9},
+		alertChannel{name: "healthy", notifier: healthy, breaker: resilience.NewBreaker("healthy", policy)},
+		alertChannel{name: "flaky", notifier: flaky, breaker: resilience.NewBreaker("flaky", policy)},
+	)
+
+	a.checkStorage(950)
+	a.wg.Wait()
+	if healthy.count() != 1 || flaky.count() != 1 {
+		t.Errorf("expected one delivery per channel, got %d and %d", healthy.count(), flaky.count())
+	}
+}
+
+func TestAlerter_ProcessingFailures(t *testing.T) {
+	n := &recordingNotifier{}
+	a := newAlerter(config.Notifications{ProcessingFailureThreshold: 2}, alertChannel{notifier: n})
+
+	a.processingFailed(errors.New("boom"))
+	a.processingSucceeded()
+	a.processingFailed(errors.New("boom"))
+	a.wg.Wait()
+	if n.count() != 0 {
+		t.Fatalf("expected no alert before threshold, got %d", n.count())
+	}
+
+	a.processingFailed(errors.New("boom"))
+	a.processingFailed(errors.New("boom"))
+	a.wg.Wait()
+	if n.count() != 1 {
+		t.Errorf("expected exactly one alert for a failure streak, got %d", n.count())
+	}
+}
+
+func TestAlerter_Storage(t *testing.T) {
+	n := &recordingNotifier{}
+	a := newAlerter(config.Notifications{StorageLimitBytes: 1000, StorageWarnRatio: 0.9}, alertChannel{notifier: n})
+
+	a.checkStorage(899)
+	a.checkStorage(950)
+	a.checkStorage(990)
+	a.wg.Wait()
+	if n.count() != 1 {
+		t.Fatalf("expected one storage alert, got %d", n.count())
+	}
+
+	a.checkStorage(100)
+	a.checkStorage(950)
+	a.wg.Wait()
+	if n.count() != 2 {
+		t.Errorf("expected alert to re-arm after usage dropped, got %d", n.count())
+	}
+}
+
+func TestAlerter_StaleFrames(t *testing.T) {
+	n := &recordingNotifier{}
+	a := newAlerter(config.Notifications{FrameStaleAfter: time.Hour}, alertChannel{notifier: n})
+	now := a.startedAt
+	a.nowFn = func() time.Time { return now }
+
+	known := []string{"hall", "kitchen"}
+	a.polled("kitchen")
+	a.polled("random-1234")
+	a.polled("not a device id")
+	now = now.Add(30 * time.Minute)
+	a.checkFrames(known)
+	a.wg.Wait()
+	if n.count() != 0 {
+		t.Fatalf("expected no alert within the threshold, got %d", n.count())
+	}
+	if len(a.lastPoll) != 1 {
+		t.Errorf("expected polls of unknown devices to be dropped, got %v", a.lastPoll)
+	}
+
+	now = now.Add(time.Hour)
+	a.checkFrames(known)
+	a.checkFrames(known)
+	a.wg.Wait()
+	if n.count() != 2 {
+		t.Fatalf("expected one alert per stale frame, got %d", n.count())
+	}
+
+	a.polled("kitchen")
+	now = now.Add(2 * time.Hour)
+	a.checkFrames([]string{"kitchen"})
+	a.wg.Wait()
+	if n.count() != 3 {
+		t.Errorf("expected kitchen to alert again after polling and going stale, got %d", n.count())
+	}
+}
+
+func TestAlerter_NilIsNoop(t *testing.T) {
+	var a *alerter
+	a.processingFailed(errors.New("boom"))
+	a.processingSucceeded()
+	a.polled("x")
+	a.checkStorage(1)
+	a.checkFrames([]string{"x"})
+}
@@ -0,0 +1,115 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+// maxAltTextLength is the maximum alt text length in characters; screen
+// readers cut longer descriptions short anyway.
+const maxAltTextLength = 500
+
+// ErrInvalidAltText is returned for alt text that is too long.
+var ErrInvalidAltText = errors.New("invalid alt text")
+
+// CaptionRequest is posted to the captioning webhook for every new image.
+type CaptionRequest struct {
+	ID string `json:"id"`
+	// Image is the stored original PNG (base64 encoded in JSON).
+	Image []byte `json:"image"`
+}
+
+// CaptionResponse is the webhook's description of the image.
+type CaptionResponse struct {
+	AltText string `json:"altText"`
+}
+
+// SetAltText replaces the alt text of an image. Surrounding whitespace is
+// trimmed; an empty text clears it.
+func (service *CoreService) SetAltText(ctx context.Context, id, text string) error {
+	text = strings.TrimSpace(text)
+	if n := utf8.RuneCountInString(text); n > maxAltTextLength {
+		return fmt.Errorf("%w: %d characters exceed the limit of %d", ErrInvalidAltText, n, maxAltTextLength)
+	}
+	unlock, err := service.locks.tryLock("set alt text", id)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if _, err := service.databaseService.GetImageByID(ctx, id); err != nil {
+		return fmt.Errorf("%w: %s", ErrImageNotFound, id)
+	}
+	if err := service.databaseService.SetAltText(ctx, id, text); err != nil {
+		return err
+	}
+	service.recordEvent(ctx, id, database.ImageEvent{Type: database.ImageEventEdited, Detail: "alt text"})
+	return nil
+}
+
+// caption asks the captioning webhook to describe a new image and stores the
+// answer as its alt text. Failures are logged; the image simply has no alt text.
+func (service *CoreService) caption(ctx context.Context, id string, original []byte) {
+	webhookURL := service.config.Captioning.WebhookURL
+	if webhookURL == "" {
+		return
+	}
+	text, err := service.callCaptionWebhook(ctx, webhookURL, CaptionRequest{ID: id, Image: original})
+	if err != nil {
+		slog.Warn("CoreService.caption: webhook failed; image has no alt text", "id", id, "url", webhookURL, "error", err)
+		return
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	if runes := []rune(text); len(runes) > maxAltTextLength {
+		text = string(runes[:maxAltTextLength])
+	}
+	if err := service.databaseService.SetAltText(ctx, id, text); err != nil {
+		slog.Warn("CoreService.caption: failed to store alt text", "id", id, "error", err)
+	}
+}
+
+func (service *CoreService) callCaptionWebhook(ctx context.Context, url string, payload CaptionRequest) (string, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("encoding caption request: %w", err)
+	}
+	var caption CaptionResponse
+	err = service.integrations.Breaker("captioning").Do(ctx, func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, service.config.Captioning.WebhookTimeout)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("building caption request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("unexpected status %s", resp.Status)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&caption); err != nil {
+			return fmt.Errorf("decoding caption response: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return caption.AltText, nil
+}
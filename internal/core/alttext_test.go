@@ -0,0 +1,70 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+func TestSetAltText(t *testing.T) {
+	service, db := newTestCoreService(t, &config.ServiceConfig{})
+	ctx := context.Background()
+	ids := addTestImages(t, service, 1)
+
+	if err := service.SetAltText(ctx, ids[0], "  A lighthouse at dusk \n"); err != nil {
+		t.Fatalf("SetAltText failed: %v", err)
+	}
+	img, _ := db.GetImageByID(ctx, ids[0])
+	if img.AltText != "A lighthouse at dusk" {
+		t.Errorf("expected trimmed alt text, got %q", img.AltText)
+	}
+
+	if err := service.SetAltText(ctx, ids[0], strings.Repeat("ä", maxAltTextLength+1)); !errors.Is(err, ErrInvalidAltText) {
+		t.Errorf("expected ErrInvalidAltText, got %v", err)
+	}
+	if err := service.SetAltText(ctx, "missing", "text"); !errors.Is(err, ErrImageNotFound) {
+		t.Errorf("expected ErrImageNotFound, got %v", err)
+	}
+}
+
+func TestAddImage_CaptionsViaWebhook(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req CaptionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Image) == 0 {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(CaptionResponse{AltText: " A gray square "})
+	}))
+	defer srv.Close()
+
+	service, db := newTestCoreService(t, &config.ServiceConfig{
+		Captioning: config.Captioning{WebhookURL: srv.URL, WebhookTimeout: time.Second},
+	})
+	ctx := context.Background()
+	apiImg, err := service.AddImage(ctx, testPNG(t, 10, 10), "", database.Attribution{})
+	if err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+	img, _ := db.GetImageByID(ctx, apiImg.ID)
+	if img.AltText != "A gray square" {
+		t.Errorf("expected generated alt text, got %q", img.AltText)
+	}
+
+	srv.Close()
+	apiImg, err = service.AddImage(ctx, testPNG(t, 12, 12), "", database.Attribution{})
+	if err != nil {
+		t.Fatalf("expected upload to succeed without the webhook, got %v", err)
+	}
+	if img, _ := db.GetImageByID(ctx, apiImg.ID); img.AltText != "" {
+		t.Errorf("expected no alt text, got %q", img.AltText)
+	}
+}
@@ -0,0 +1,125 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+// ErrImageNotFound is returned when an image ID is unknown.
+var ErrImageNotFound = errors.New("image not found")
+
+// ArchiveFilter selects which images ListImages returns.
+type ArchiveFilter string
+
+const (
+	// ArchiveExclude lists only images in the rotation (default).
+	ArchiveExclude ArchiveFilter = ""
+	// ArchiveOnly lists only archived images.
+	ArchiveOnly ArchiveFilter = "true"
+	// ArchiveInclude lists rotation images followed by archived images.
+	ArchiveInclude ArchiveFilter = "all"
+)
+
+// ParseArchiveFilter parses the "archived" query parameter ("", "false", "true" or "all").
+func ParseArchiveFilter(s string) (ArchiveFilter, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "false":
+		return ArchiveExclude, true
+	case "true":
+		return ArchiveOnly, true
+	case "all":
+		return ArchiveInclude, true
+	}
+	return "", false
+}
+
+// ImageFilter narrows down ListImages.
+type ImageFilter struct {
+	Archived ArchiveFilter
+	// Query matches case-insensitively against ID, source and attribution.
+	Query string
+}
+
+// ListImages returns the images matching filter. Rotation images keep their
+// display order; archived images follow, newest first.
+func (service *CoreService) ListImages(ctx context.Context, filter ImageFilter) ([]*database.Image, error) {
+	var images []*database.Image
+	if filter.Archived != ArchiveOnly {
+		active, err := service.databaseService.GetImageMetadata(ctx)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, active...)
+	}
+	if filter.Archived != ArchiveExclude {
+		archived, err := service.databaseService.GetArchivedImages(ctx)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, archived...)
+	}
+
+	query := strings.ToLower(strings.TrimSpace(filter.Query))
+	if query == "" {
+		return images, nil
+	}
+	matches := make([]*database.Image, 0, len(images))
+	for _, img := range images {
+		if imageMatches(img, query) {
+			matches = append(matches, img)
+		}
+	}
+	return matches, nil
+}
+
+// imageMatches reports whether any searchable field contains the lower-cased query.
+func imageMatches(img *database.Image, query string) bool {
+	for _, field := range []string{img.ID, img.Source, img.Attribution.Author, img.Attribution.License, img.Attribution.SourceURL} {
+		if strings.Contains(strings.ToLower(field), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// ArchiveImages removes the images from the rotation without deleting them.
+func (service *CoreService) ArchiveImages(ctx context.Context, ids []string) error {
+	return service.setArchived(ctx, ids, true)
+}
+
+// UnarchiveImages returns archived images to the end of the rotation.
+func (service *CoreService) UnarchiveImages(ctx context.Context, ids []string) error {
+	return service.setArchived(ctx, ids, false)
+}
+
+func (service *CoreService) setArchived(ctx context.Context, ids []string, archived bool) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	unlock, err := service.locks.tryLock("archive", ids...)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	for _, id := range ids {
+		if _, err := service.databaseService.GetImageByID(ctx, id); err != nil {
+			return fmt.Errorf("%w: %s", ErrImageNotFound, id)
+		}
+	}
+	defer service.currentImages.invalidate()
+	if err := service.databaseService.SetArchived(ctx, ids, archived); err != nil {
+		return err
+	}
+	detail := "unarchived"
+	if archived {
+		detail = "archived"
+	}
+	for _, id := range ids {
+		service.recordEvent(ctx, id, database.ImageEvent{Type: database.ImageEventEdited, Detail: detail})
+	}
+	return nil
+}
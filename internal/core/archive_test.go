@@ -0,0 +1,63 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+func TestArchiveImages(t *testing.T) {
+	service, db := newTestCoreService(t, &config.ServiceConfig{})
+	ctx := context.Background()
+
+	var ids []string
+	for _, author := range []string{"Alice", "Bob", "Carol"} {
+		img, err := service.AddImage(ctx, testPNG(t, 4, 4), "", database.Attribution{Author: author})
+		if err != nil {
+			t.Fatalf("AddImage failed: %v", err)
+		}
+		ids = append(ids, img.ID)
+	}
+
+	if err := service.ArchiveImages(ctx, []string{ids[0], ids[2]}); err != nil {
+		t.Fatalf("ArchiveImages failed: %v", err)
+	}
+	order, _ := db.GetRotationOrderedIDs(ctx)
+	if len(order) != 1 || order[0] != ids[1] {
+		t.Fatalf("expected only %s in rotation, got %v", ids[1], order)
+	}
+
+	counts := map[ArchiveFilter]int{ArchiveExclude: 1, ArchiveOnly: 2, ArchiveInclude: 3}
+	for filter, want := range counts {
+		images, err := service.ListImages(ctx, ImageFilter{Archived: filter})
+		if err != nil {
+			t.Fatalf("ListImages(%q) failed: %v", filter, err)
+		}
+		if len(images) != want {
+			t.Errorf("ListImages(%q): expected %d images, got %d", filter, want, len(images))
+		}
+	}
+
+	found, _ := service.ListImages(ctx, ImageFilter{Archived: ArchiveOnly, Query: "carol"})
+	if len(found) != 1 || found[0].ID != ids[2] || !found[0].Archived {
+		t.Errorf("expected search to find archived image %s, got %+v", ids[2], found)
+	}
+
+	if err := service.UnarchiveImages(ctx, []string{ids[0]}); err != nil {
+		t.Fatalf("UnarchiveImages failed: %v", err)
+	}
+	order, _ = db.GetRotationOrderedIDs(ctx)
+	if len(order) != 2 || order[1] != ids[0] {
+		t.Errorf("expected unarchived image appended to rotation, got %v", order)
+	}
+
+	if err := service.ArchiveImages(ctx, []string{ids[1], "missing"}); !errors.Is(err, ErrImageNotFound) {
+		t.Errorf("expected ErrImageNotFound, got %v", err)
+	}
+	if order, _ = db.GetRotationOrderedIDs(ctx); len(order) != 2 {
+		t.Errorf("expected failed bulk archive to change nothing, got %v", order)
+	}
+}
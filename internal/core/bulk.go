@@ -0,0 +1,291 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/common"
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+// BulkMode selects how a bulk operation treats items that fail.
+type BulkMode string
+
+const (
+	// BulkBestEffort applies every item that can be applied (default).
+	BulkBestEffort BulkMode = "best-effort"
+	// BulkAllOrNothing applies no item unless all of them succeed.
+	BulkAllOrNothing BulkMode = "all-or-nothing"
+)
+
+// ErrInvalidBulkMode is returned by ParseBulkMode for unknown modes.
+var ErrInvalidBulkMode = errors.New("invalid bulk mode")
+
+// ParseBulkMode parses a bulk mode; "" selects BulkBestEffort.
+func ParseBulkMode(s string) (BulkMode, error) {
+	switch mode := BulkMode(strings.ToLower(strings.TrimSpace(s))); mode {
+	case "":
+		return BulkBestEffort, nil
+	case BulkBestEffort, BulkAllOrNothing:
+		return mode, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrInvalidBulkMode, s)
+}
+
+// BulkOutcome is what happened to a single item of a bulk operation.
+type BulkOutcome string
+
+const (
+	BulkSucceeded BulkOutcome = "succeeded"
+	BulkFailed    BulkOutcome = "failed"
+	// BulkSkipped items were not attempted because an all-or-nothing
+	// operation aborted.
+	BulkSkipped BulkOutcome = "skipped"
+	// BulkRolledBack items succeeded but were undone because an
+	// all-or-nothing operation aborted.
+	BulkRolledBack BulkOutcome = "rolledBack"
+)
+
+// BulkItemResult reports the outcome of one item of a bulk operation.
+type BulkItemResult struct {
+	// ID is the image ID; for uploads it is set once the image was created.
+	ID string
+	// Name is the uploaded file name (uploads only).
+	Name    string
+	Outcome BulkOutcome
+	// Err is set for BulkFailed items.
+	Err error
+	// Pending is set for uploads held for moderation.
+	Pending bool
+}
+
+// BulkResult reports the outcome of a bulk operation per item, in request order.
+type BulkResult struct {
+	Mode  BulkMode
+	Items []BulkItemResult
+}
+
+// Count returns the number of items with outcome.
+func (r *BulkResult) Count(outcome BulkOutcome) int {
+	n := 0
+	for _, item := range r.Items {
+		if item.Outcome == outcome {
+			n++
+		}
+	}
+	return n
+}
+
+// abort marks all items from index from on as skipped.
+func (r *BulkResult) abort(from int) {
+	for i := from; i < len(r.Items); i++ {
+		if r.Items[i].Outcome == "" {
+			r.Items[i].Outcome = BulkSkipped
+		}
+	}
+}
+
+func newBulkResult(mode BulkMode, ids []string) *BulkResult {
+	result := &BulkResult{Mode: mode, Items: make([]BulkItemResult, len(ids))}
+	for i, id := range ids {
+		result.Items[i].ID = id
+	}
+	return result
+}
+
+// uniqueIDs drops repeated IDs, keeping the first occurrence.
+func uniqueIDs(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	unique := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// BulkDeleteImages deletes the images ids under the current image deletion
+// policy (see DeleteImageChecked). In all-or-nothing mode every image is
+// checked first and nothing is deleted if any check fails; deletions cannot be
+// undone, so a storage error part way through is still reported per item.
+func (service *CoreService) BulkDeleteImages(ctx context.Context, ids []string, force bool, mode BulkMode) *BulkResult {
+	result := newBulkResult(mode, uniqueIDs(ids))
+	if mode == BulkAllOrNothing {
+		failed := false
+		for i := range result.Items {
+			if err := service.checkDeletable(ctx, result.Items[i].ID, force); err != nil {
+				result.Items[i].Outcome, result.Items[i].Err = BulkFailed, err
+				failed = true
+			}
+		}
+		if failed {
+			slog.Info("CoreService.BulkDeleteImages: aborting, not all images can be deleted", "count", len(result.Items))
+			result.abort(0)
+			return result
+		}
+	}
+
+	for i := range result.Items {
+		item := &result.Items[i]
+		if err := service.DeleteImageChecked(ctx, item.ID, force); err != nil {
+			if errors.Is(err, database.ErrImageNotFound) {
+				err = fmt.Errorf("%w: %s", ErrImageNotFound, item.ID)
+			}
+			item.Outcome, item.Err = BulkFailed, err
+			continue
+		}
+		item.Outcome = BulkSucceeded
+	}
+	return result
+}
+
+// BulkReprocessImages reprocesses the images ids (see ReprocessImage). In
+// all-or-nothing mode all images are locked and processed before any result is
+// stored, and nothing is stored if one of them fails.
+func (service *CoreService) BulkReprocessImages(ctx context.Context, ids []string, mode BulkMode) *BulkResult {
+	result := newBulkResult(mode, uniqueIDs(ids))
+	if mode != BulkAllOrNothing {
+		for i := range result.Items {
+			item := &result.Items[i]
+			if err := service.ReprocessImage(ctx, item.ID); err != nil {
+				item.Outcome, item.Err = BulkFailed, err
+				continue
+			}
+			item.Outcome = BulkSucceeded
+		}
+		return result
+	}
+
+	for i := range result.Items {
+		unlock, err := service.locks.tryLock("reprocess", result.Items[i].ID)
+		if err != nil {
+			result.Items[i].Outcome, result.Items[i].Err = BulkFailed, err
+			result.abort(0)
+			return result
+		}
+		defer unlock()
+	}
+	rendered := make([][]byte, len(result.Items))
+	for i := range result.Items {
+		processed, err := service.renderReprocessed(ctx, result.Items[i].ID)
+		if err != nil {
+			slog.Info("CoreService.BulkReprocessImages: aborting after failed image", "id", result.Items[i].ID, "error", err)
+			result.Items[i].Outcome, result.Items[i].Err = BulkFailed, err
+			result.abort(0)
+			return result
+		}
+		rendered[i] = processed
+	}
+	for i := range result.Items {
+		item := &result.Items[i]
+		if err := service.storeReprocessed(ctx, item.ID, rendered[i]); err != nil {
+			item.Outcome, item.Err = BulkFailed, err
+			continue
+		}
+		item.Outcome = BulkSucceeded
+	}
+	return result
+}
+
+// BulkUpload is one file of a bulk upload.
+type BulkUpload struct {
+	Name string
+	// Open returns the file content; it is not called when Err is set.
+	Open func() (io.ReadSeekCloser, error)
+	// Err fails the file without reading it, e.g. when its quota was rejected.
+	Err error
+}
+
+// BulkAddImages adds the uploaded files as images (see AddImageFromReader) in
+// the given order; the result still lists them in request order. In
+// all-or-nothing mode the first failure stops the upload and the images
+// already created are deleted again.
+func (service *CoreService) BulkAddImages(ctx context.Context, uploads []BulkUpload, source string, attribution database.Attribution, mode BulkMode, order ImportOrder) *BulkResult {
+	result := &BulkResult{Mode: mode, Items: make([]BulkItemResult, len(uploads))}
+	for i, upload := range uploads {
+		result.Items[i].Name = upload.Name
+	}
+	if mode == BulkAllOrNothing {
+		failed := false
+		for i, upload := range uploads {
+			if upload.Err != nil {
+				result.Items[i].Outcome, result.Items[i].Err = BulkFailed, upload.Err
+				failed = true
+			}
+		}
+		if failed {
+			result.abort(0)
+			return result
+		}
+	}
+
+	names := make([]string, len(uploads))
+	for i, upload := range uploads {
+		names[i] = upload.Name
+	}
+	for _, i := range importSequence(order, names, func(i int) (time.Time, bool) { return uploadCaptureTime(uploads[i]) }) {
+		upload := uploads[i]
+		item := &result.Items[i]
+		err := upload.Err
+		if err == nil {
+			var img *common.ApiImage
+			img, err = service.addBulkUpload(ctx, upload, source, attribution)
+			if err == nil {
+				item.ID, item.Pending, item.Outcome = img.ID, img.Pending, BulkSucceeded
+				continue
+			}
+		}
+		item.Outcome, item.Err = BulkFailed, err
+		if mode == BulkAllOrNothing {
+			slog.Info("CoreService.BulkAddImages: rolling back after failed upload", "file", upload.Name, "error", err)
+			service.rollBackUploads(ctx, result.Items)
+			result.abort(0)
+			return result
+		}
+	}
+	return result
+}
+
+func (service *CoreService) addBulkUpload(ctx context.Context, upload BulkUpload, source string, attribution database.Attribution) (*common.ApiImage, error) {
+	r, err := upload.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = r.Close() }()
+	return service.AddImageFromReader(ctx, r, source, attribution)
+}
+
+// uploadCaptureTime reads the EXIF capture date of an upload.
+func uploadCaptureTime(upload BulkUpload) (time.Time, bool) {
+	if upload.Err != nil {
+		return time.Time{}, false
+	}
+	r, err := upload.Open()
+	if err != nil {
+		return time.Time{}, false
+	}
+	defer func() { _ = r.Close() }()
+	return readCaptureTime(r)
+}
+
+// rollBackUploads deletes the images created for items. Items whose image
+// cannot be deleted keep their succeeded outcome, so the result stays accurate.
+func (service *CoreService) rollBackUploads(ctx context.Context, items []BulkItemResult) {
+	for i := range items {
+		if items[i].Outcome != BulkSucceeded {
+			continue
+		}
+		if err := service.DeleteImage(ctx, items[i].ID); err != nil {
+			slog.Error("CoreService.rollBackUploads: failed to delete uploaded image", "id", items[i].ID, "error", err)
+			continue
+		}
+		items[i].Outcome = BulkRolledBack
+	}
+}
@@ -0,0 +1,234 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image/png"
+	"io"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+	"github.com/jo-hoe/goframe/internal/imageprocessing"
+)
+
+func TestParseBulkMode(t *testing.T) {
+	for in, want := range map[string]BulkMode{"": BulkBestEffort, "best-effort": BulkBestEffort, "All-Or-Nothing": BulkAllOrNothing} {
+		if got, err := ParseBulkMode(in); err != nil || got != want {
+			t.Errorf("ParseBulkMode(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseBulkMode("sometimes"); !errors.Is(err, ErrInvalidBulkMode) {
+		t.Errorf("expected ErrInvalidBulkMode, got %v", err)
+	}
+}
+
+func addTestImages(t *testing.T, service *CoreService, n int) []string {
+	t.Helper()
+	var ids []string
+	for range n {
+		img, err := service.AddImage(context.Background(), testPNG(t, 4, 4), "", database.Attribution{})
+		if err != nil {
+			t.Fatalf("AddImage failed: %v", err)
+		}
+		ids = append(ids, img.ID)
+	}
+	return ids
+}
+
+func outcomes(result *BulkResult) []BulkOutcome {
+	var out []BulkOutcome
+	for _, item := range result.Items {
+		out = append(out, item.Outcome)
+	}
+	return out
+}
+
+func TestBulkDeleteImages(t *testing.T) {
+	tests := []struct {
+		mode         BulkMode
+		want         []BulkOutcome
+		wantRemained int
+	}{
+		{mode: BulkBestEffort, want: []BulkOutcome{BulkFailed, BulkSucceeded, BulkFailed}, wantRemained: 1},
+		{mode: BulkAllOrNothing, want: []BulkOutcome{BulkFailed, BulkSkipped, BulkFailed}, wantRemained: 2},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			service, db := newTestCoreService(t, &config.ServiceConfig{CurrentImageDeletion: config.CurrentImageDeletionBlock})
+			ctx := context.Background()
+			ids := addTestImages(t, service, 2)
+
+			result := service.BulkDeleteImages(ctx, []string{ids[0], ids[1], "missing", ids[1]}, false, tt.mode)
+			if got := outcomes(result); !slices.Equal(got, tt.want) {
+				t.Fatalf("expected outcomes %v, got %v", tt.want, got)
+			}
+			if !errors.Is(result.Items[0].Err, ErrCurrentImageProtected) {
+				t.Errorf("expected current image to be protected, got %v", result.Items[0].Err)
+			}
+			if !errors.Is(result.Items[2].Err, ErrImageNotFound) {
+				t.Errorf("expected missing image to be reported, got %v", result.Items[2].Err)
+			}
+			remaining, _ := db.GetRotationOrderedIDs(ctx)
+			if len(remaining) != tt.wantRemained {
+				t.Errorf("expected %d images to remain, got %v", tt.wantRemained, remaining)
+			}
+		})
+	}
+}
+
+// failingDeleteDatabase fails the deletion of stored images with a storage error.
+type failingDeleteDatabase struct {
+	*database.FakeDatabase
+}
+
+func (d *failingDeleteDatabase) DeleteImage(ctx context.Context, id string) error {
+	if _, err := d.GetImageByID(ctx, id); err != nil {
+		return err
+	}
+	return errors.New("storage unavailable")
+}
+
+func TestBulkDeleteImages_ReportsStorageErrors(t *testing.T) {
+	db := &failingDeleteDatabase{FakeDatabase: database.NewFakeDatabase("")}
+	service := newCoreService(&config.ServiceConfig{Timezone: "UTC"}, db)
+	ids := addTestImages(t, service, 1)
+
+	result := service.BulkDeleteImages(context.Background(), []string{ids[0], "missing"}, false, BulkBestEffort)
+	if err := result.Items[0].Err; err == nil || errors.Is(err, ErrImageNotFound) {
+		t.Errorf("expected the storage error, got %v", err)
+	}
+	if err := result.Items[1].Err; !errors.Is(err, ErrImageNotFound) {
+		t.Errorf("expected the missing image to be reported, got %v", err)
+	}
+}
+
+func TestBulkReprocessImages(t *testing.T) {
+	tests := []struct {
+		mode      BulkMode
+		want      []BulkOutcome
+		wantWidth int
+	}{
+		{mode: BulkBestEffort, want: []BulkOutcome{BulkSucceeded, BulkFailed}, wantWidth: 2},
+		{mode: BulkAllOrNothing, want: []BulkOutcome{BulkSkipped, BulkFailed}, wantWidth: 4},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			service, db := newTestCoreService(t, &config.ServiceConfig{
+				Commands: []config.CommandConfig{{Name: "ScaleCommand", Params: map[string]any{"height": 4, "width": 4}}},
+			})
+			ctx := context.Background()
+			ids := addTestImages(t, service, 1)
+			service.commandConfigs = []imageprocessing.CommandConfig{{Name: "ScaleCommand", Params: map[string]any{"height": 2, "width": 2}}}
+
+			result := service.BulkReprocessImages(ctx, []string{ids[0], "missing"}, tt.mode)
+			if got := outcomes(result); !slices.Equal(got, tt.want) {
+				t.Fatalf("expected outcomes %v, got %v", tt.want, got)
+			}
+			data, err := db.GetImageData(ctx, ids[0], "processed")
+			if err != nil {
+				t.Fatalf("GetImageData failed: %v", err)
+			}
+			cfg, err := png.DecodeConfig(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("failed to decode processed image: %v", err)
+			}
+			if cfg.Width != tt.wantWidth {
+				t.Errorf("expected processed width %d, got %d", tt.wantWidth, cfg.Width)
+			}
+			img, _ := db.GetImageByID(ctx, ids[0])
+			if img.ProcessedHash != database.ContentHash(data) {
+				t.Error("expected processed hash to match the stored blob")
+			}
+		})
+	}
+}
+
+func TestReprocessImage_OnDemandReplacesCachedRendition(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{
+		Commands:        []config.CommandConfig{{Name: "ScaleCommand", Params: map[string]any{"height": 4, "width": 4}}},
+		ProcessedImages: config.ProcessedImages{Mode: config.ProcessedImageModeOnDemand, CacheTTL: time.Hour, CacheMaxEntries: 4},
+	})
+	ctx := context.Background()
+	ids := addTestImages(t, service, 1)
+	before, err := service.GetProcessedImage(ctx, ids[0])
+	if err != nil {
+		t.Fatalf("GetProcessedImage failed: %v", err)
+	}
+	service.commandConfigs = []imageprocessing.CommandConfig{{Name: "ScaleCommand", Params: map[string]any{"height": 2, "width": 2}}}
+
+	if err := service.ReprocessImage(ctx, ids[0]); err != nil {
+		t.Fatalf("ReprocessImage failed: %v", err)
+	}
+	after, err := service.GetProcessedImage(ctx, ids[0])
+	if err != nil {
+		t.Fatalf("GetProcessedImage failed: %v", err)
+	}
+	if bytes.Equal(before, after) {
+		t.Error("expected the cached rendition to be replaced")
+	}
+}
+
+type bytesUpload struct{ *bytes.Reader }
+
+func (bytesUpload) Close() error { return nil }
+
+func testUpload(name string, data []byte) BulkUpload {
+	return BulkUpload{Name: name, Open: func() (io.ReadSeekCloser, error) {
+		return bytesUpload{bytes.NewReader(data)}, nil
+	}}
+}
+
+func TestBulkAddImages(t *testing.T) {
+	tests := []struct {
+		mode       BulkMode
+		want       []BulkOutcome
+		wantStored int
+	}{
+		{mode: BulkBestEffort, want: []BulkOutcome{BulkSucceeded, BulkFailed, BulkSucceeded}, wantStored: 2},
+		{mode: BulkAllOrNothing, want: []BulkOutcome{BulkRolledBack, BulkFailed, BulkSkipped}, wantStored: 0},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			service, db := newTestCoreService(t, &config.ServiceConfig{})
+			ctx := context.Background()
+			uploads := []BulkUpload{
+				testUpload("a.png", testPNG(t, 4, 4)),
+				testUpload("broken.png", []byte("not an image")),
+				testUpload("b.png", testPNG(t, 4, 4)),
+			}
+
+			result := service.BulkAddImages(ctx, uploads, "", database.Attribution{}, tt.mode, ImportOrderAsGiven)
+			if got := outcomes(result); !slices.Equal(got, tt.want) {
+				t.Fatalf("expected outcomes %v, got %v", tt.want, got)
+			}
+			if result.Items[1].Name != "broken.png" || result.Items[1].Err == nil {
+				t.Errorf("expected broken.png to report an error, got %+v", result.Items[1])
+			}
+			stored, _ := db.GetRotationOrderedIDs(ctx)
+			if len(stored) != tt.wantStored {
+				t.Errorf("expected %d stored images, got %v", tt.wantStored, stored)
+			}
+		})
+	}
+}
+
+func TestBulkAddImages_AllOrNothingRejectsPrefailedUploads(t *testing.T) {
+	service, db := newTestCoreService(t, &config.ServiceConfig{})
+	quotaErr := errors.New("quota exceeded")
+	uploads := []BulkUpload{testUpload("a.png", testPNG(t, 4, 4)), {Name: "big.png", Err: quotaErr}}
+
+	result := service.BulkAddImages(context.Background(), uploads, "", database.Attribution{}, BulkAllOrNothing, ImportOrderAsGiven)
+	if got, want := outcomes(result), []BulkOutcome{BulkSkipped, BulkFailed}; !slices.Equal(got, want) {
+		t.Fatalf("expected outcomes %v, got %v", want, got)
+	}
+	if !errors.Is(result.Items[1].Err, quotaErr) {
+		t.Errorf("expected quota error, got %v", result.Items[1].Err)
+	}
+	if stored, _ := db.GetRotationOrderedIDs(context.Background()); len(stored) != 0 {
+		t.Errorf("expected nothing to be stored, got %v", stored)
+	}
+}
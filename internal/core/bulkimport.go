@@ -0,0 +1,198 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/database"
+	"github.com/jo-hoe/goframe/internal/imageprocessing"
+)
+
+// BulkImportSource is the image source recorded for images imported from a server directory.
+const BulkImportSource = "import"
+
+// maxImportCandidates bounds the number of files returned by a single scan.
+const maxImportCandidates = 2000
+
+// importExtensions maps the file extensions considered images when scanning to
+// their format, so formats left out of slim builds are not offered.
+var importExtensions = map[string]string{
+	".png": "png", ".jpg": "jpeg", ".jpeg": "jpeg", ".gif": "gif", ".bmp": "bmp",
+	".tif": "tiff", ".tiff": "tiff", ".webp": "webp", ".svg": "svg", ".svgz": "svg",
+}
+
+// ErrImportDirectoryNotAllowed is returned for directories not listed in bulkImport.directories.
+var ErrImportDirectoryNotAllowed = errors.New("directory is not configured for bulk import")
+
+// ImportCandidate is an image file found in an import directory.
+type ImportCandidate struct {
+	// Path is slash-separated and relative to the import directory.
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+}
+
+// ImportScan lists the image files of an import directory.
+type ImportScan struct {
+	Directory string            `json:"directory"`
+	Files     []ImportCandidate `json:"files"`
+	// Truncated is set when the directory holds more images than a scan returns.
+	Truncated bool `json:"truncated"`
+}
+
+// ImportResult reports the outcome of importing one file.
+type ImportResult struct {
+	Path  string `json:"path"`
+	ID    string `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// ImportDirectories returns the directories available for bulk import.
+func (service *CoreService) ImportDirectories() []string {
+	return service.config.BulkImport.Directories
+}
+
+// openImportRoot opens dir for traversal-safe access after checking that it is configured.
+func (service *CoreService) openImportRoot(dir string) (*os.Root, error) {
+	if !slices.Contains(service.config.BulkImport.Directories, dir) {
+		return nil, ErrImportDirectoryNotAllowed
+	}
+	return os.OpenRoot(dir)
+}
+
+// ScanImportDirectory walks dir recursively and lists the image files in it,
+// sorted by path. Hidden files and directories are skipped.
+func (service *CoreService) ScanImportDirectory(dir string) (*ImportScan, error) {
+	root, err := service.openImportRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = root.Close() }()
+
+	scan := &ImportScan{Directory: dir, Files: []ImportCandidate{}}
+	err = fs.WalkDir(root.FS(), ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			slog.Warn("CoreService.ScanImportDirectory: skipping unreadable entry", "dir", dir, "path", p, "error", err)
+			return nil
+		}
+		if p != "." && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !d.Type().IsRegular() || !isImportableFile(p) {
+			return nil
+		}
+		if len(scan.Files) == maxImportCandidates {
+			scan.Truncated = true
+			return fs.SkipAll
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		scan.Files = append(scan.Files, ImportCandidate{Path: p, Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scanning %s: %w", dir, err)
+	}
+	return scan, nil
+}
+
+// ReadImportFile returns the content of an image file in an import directory,
+// e.g. to preview it before importing.
+func (service *CoreService) ReadImportFile(dir, file string) ([]byte, error) {
+	root, err := service.openImportRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = root.Close() }()
+
+	if !isImportableFile(file) {
+		return nil, fmt.Errorf("not an image file: %s", file)
+	}
+	return root.ReadFile(file)
+}
+
+// ImportFiles runs the given files of an import directory through the pipeline,
+// one after another in the given order. A failing file does not stop the
+// others; the result for each file reports the new image ID or the error, in
+// the order the files were imported.
+func (service *CoreService) ImportFiles(ctx context.Context, dir string, files []string, order ImportOrder) ([]ImportResult, error) {
+	root, err := service.openImportRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = root.Close() }()
+
+	results := make([]ImportResult, 0, len(files))
+	for _, i := range importSequence(order, files, func(i int) (time.Time, bool) { return importCaptureTime(root, files[i]) }) {
+		file := files[i]
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		result := ImportResult{Path: file}
+		if id, err := service.importFile(ctx, root, file); err != nil {
+			slog.Warn("CoreService.ImportFiles: failed to import file", "dir", dir, "path", file, "error", err)
+			result.Error = err.Error()
+		} else {
+			result.ID = id
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (service *CoreService) importFile(ctx context.Context, root *os.Root, file string) (string, error) {
+	if !isImportableFile(file) {
+		return "", fmt.Errorf("not an image file")
+	}
+	f, err := root.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+
+	img, err := service.AddImageFromReader(ctx, f, BulkImportSource, database.Attribution{})
+	if err != nil {
+		return "", err
+	}
+	return img.ID, nil
+}
+
+// importCaptureTime returns the EXIF capture date of an import file, falling
+// back to its modification time.
+func importCaptureTime(root *os.Root, file string) (time.Time, bool) {
+	if !isImportableFile(file) {
+		return time.Time{}, false
+	}
+	f, err := root.Open(file)
+	if err != nil {
+		return time.Time{}, false
+	}
+	defer func() { _ = f.Close() }()
+	if t, ok := readCaptureTime(f); ok {
+		return t, true
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return info.ModTime().UTC(), true
+}
+
+// isImportableFile reports whether the file name has a supported image extension.
+func isImportableFile(name string) bool {
+	format, ok := importExtensions[strings.ToLower(path.Ext(name))]
+	return ok && !slices.Contains(imageprocessing.DisabledFormats, format)
+}
@@ -0,0 +1,71 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jo-hoe/goframe/internal/config"
+)
+
+func TestBulkImport(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "2024", ".thumbs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{
+		"a.png":              testPNG(t, 4, 4),
+		"2024/b.PNG":         testPNG(t, 6, 6),
+		"2024/.thumbs/c.png": testPNG(t, 2, 2),
+		"notes.txt":          []byte("hello"),
+		"broken.jpg":         []byte("not an image"),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	service, db := newTestCoreService(t, &config.ServiceConfig{BulkImport: config.BulkImport{Directories: []string{dir}}})
+	ctx := context.Background()
+
+	scan, err := service.ScanImportDirectory(dir)
+	if err != nil {
+		t.Fatalf("ScanImportDirectory failed: %v", err)
+	}
+	var paths []string
+	for _, f := range scan.Files {
+		paths = append(paths, f.Path)
+	}
+	want := []string{"2024/b.PNG", "a.png", "broken.jpg"}
+	if len(paths) != len(want) {
+		t.Fatalf("expected %v, got %v", want, paths)
+	}
+	for i := range want {
+		if paths[i] != want[i] {
+			t.Errorf("expected %v, got %v", want, paths)
+			break
+		}
+	}
+
+	results, err := service.ImportFiles(ctx, dir, []string{"a.png", "broken.jpg", "../escape.png"}, ImportOrderAsGiven)
+	if err != nil {
+		t.Fatalf("ImportFiles failed: %v", err)
+	}
+	if results[0].ID == "" || results[0].Error != "" {
+		t.Errorf("expected a.png to import, got %+v", results[0])
+	}
+	if results[1].Error == "" || results[2].Error == "" {
+		t.Errorf("expected broken and escaping files to fail, got %+v", results[1:])
+	}
+	images, _ := db.GetImageMetadata(ctx)
+	if len(images) != 1 || images[0].Source != BulkImportSource {
+		t.Errorf("expected one imported image with source %q, got %+v", BulkImportSource, images)
+	}
+
+	if _, err := service.ScanImportDirectory(t.TempDir()); !errors.Is(err, ErrImportDirectoryNotAllowed) {
+		t.Errorf("expected ErrImportDirectoryNotAllowed, got %v", err)
+	}
+}
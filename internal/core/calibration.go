@@ -0,0 +1,283 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image/color"
+	"log/slog"
+	"maps"
+	"strings"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/database"
+	"github.com/jo-hoe/goframe/internal/imageprocessing"
+)
+
+// ErrInvalidCalibration is returned when a palette calibration is rejected.
+var ErrInvalidCalibration = errors.New("invalid calibration")
+
+const (
+	// calibratedCacheTTL and calibratedCacheMaxEntries bound the cache of
+	// images rendered with a device's calibrated palette.
+	calibratedCacheTTL        = 24 * time.Hour
+	calibratedCacheMaxEntries = 64
+)
+
+// Calibration is the palette a device's images are dithered with.
+type Calibration struct {
+	Device string `json:"device"`
+	// Colors is the device's calibrated palette, or the configured one while
+	// the device is not calibrated.
+	Colors []database.PaletteColor `json:"colors"`
+	// CalibratedAt is set once the device is calibrated.
+	CalibratedAt *time.Time `json:"calibratedAt,omitempty"`
+}
+
+// GetCalibrationChart renders the calibration chart for the configured panel:
+// one numbered patch per palette color, to be shown on the frame and then
+// matched by eye or photographed.
+func (service *CoreService) GetCalibrationChart() ([]byte, error) {
+	profile, err := imageprocessing.PanelProfileFromCommands(service.commandConfigs)
+	if err != nil {
+		return nil, err
+	}
+	return imageprocessing.RenderCalibrationChart(profile)
+}
+
+// GetPalettes returns the calibrated palettes keyed by device ID.
+func (service *CoreService) GetPalettes(ctx context.Context) (map[string]database.Palette, error) {
+	palettes, err := service.databaseService.GetPalettes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if palettes == nil {
+		palettes = map[string]database.Palette{}
+	}
+	return palettes, nil
+}
+
+// GetCalibration returns the palette deviceID is dithered with.
+func (service *CoreService) GetCalibration(ctx context.Context, deviceID string) (*Calibration, error) {
+	configured, err := service.configuredPalette()
+	if err != nil {
+		return nil, err
+	}
+	palettes, err := service.GetPalettes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	calibration := &Calibration{Device: deviceID, Colors: paletteColors(configured)}
+	if palette, ok := palettes[deviceID]; ok && deviceID != "" {
+		calibration.Colors = palette.Colors
+		calibration.CalibratedAt = &palette.CalibratedAt
+	}
+	return calibration, nil
+}
+
+// CalibratePalette derives deviceID's palette from the colors its panel shows
+// for the patches of the calibration chart, as "#rrggbb" in patch order, e.g.
+// picked by matching each patch by eye, and stores it.
+func (service *CoreService) CalibratePalette(ctx context.Context, deviceID string, measured []string) (database.Palette, error) {
+	colors := make([]color.RGBA, len(measured))
+	for i, s := range measured {
+		c, err := imageprocessing.ParseHexColor(s)
+		if err != nil {
+			return database.Palette{}, fmt.Errorf("%w: patch %d: %v", ErrInvalidCalibration, i+1, err)
+		}
+		colors[i] = c
+	}
+	return service.savePalette(ctx, deviceID, colors)
+}
+
+// CalibratePaletteFromPhoto derives deviceID's palette from a photo of its
+// panel showing the calibration chart, cropped to the chart, and stores it.
+func (service *CoreService) CalibratePaletteFromPhoto(ctx context.Context, deviceID string, photo []byte) (database.Palette, error) {
+	configured, err := service.configuredPalette()
+	if err != nil {
+		return database.Palette{}, err
+	}
+	release, err := service.decodeBudget.acquire(ctx, estimateDecodeCost(bytes.NewReader(photo), service.config.SvgFallbackLongSidePixelCount))
+	if err != nil {
+		return database.Palette{}, err
+	}
+	measured, err := imageprocessing.MeasureCalibrationPhoto(photo, len(configured))
+	release()
+	if err != nil {
+		return database.Palette{}, fmt.Errorf("%w: %v", ErrInvalidCalibration, err)
+	}
+	return service.savePalette(ctx, deviceID, measured)
+}
+
+// DeletePalette removes deviceID's calibrated palette; its images are
+// dithered with the configured palette again.
+func (service *CoreService) DeletePalette(ctx context.Context, deviceID string) error {
+	defer service.currentImages.invalidate()
+	return service.databaseService.DeletePalette(ctx, deviceID)
+}
+
+func (service *CoreService) savePalette(ctx context.Context, deviceID string, measured []color.RGBA) (database.Palette, error) {
+	if strings.TrimSpace(deviceID) == "" {
+		return database.Palette{}, fmt.Errorf("%w: a device is required", ErrInvalidCalibration)
+	}
+	configured, err := service.configuredPalette()
+	if err != nil {
+		return database.Palette{}, err
+	}
+	calibrated, err := imageprocessing.DeriveCalibratedPalette(configured, measured)
+	if err != nil {
+		return database.Palette{}, fmt.Errorf("%w: %v", ErrInvalidCalibration, err)
+	}
+	palette := database.Palette{Colors: paletteColors(calibrated), CalibratedAt: service.nowFn().UTC()}
+	defer service.currentImages.invalidate()
+	if err := service.databaseService.PutPalette(ctx, deviceID, palette); err != nil {
+		return database.Palette{}, err
+	}
+	slog.Info("CoreService.savePalette: calibrated palette", "device", deviceID, "colors", len(palette.Colors))
+	return palette, nil
+}
+
+// configuredPalette returns the palette of the pipeline's dithering steps. It
+// fails with ErrInvalidCalibration when no step dithers to a palette, as there
+// is nothing to calibrate then.
+func (service *CoreService) configuredPalette() ([]imageprocessing.ColorPair, error) {
+	if !hasPaletteStep(service.commandConfigs) {
+		return nil, fmt.Errorf("%w: the pipeline has no DitherCommand or AutoEnhanceCommand with a palette", ErrInvalidCalibration)
+	}
+	profile, err := imageprocessing.PanelProfileFromCommands(service.commandConfigs)
+	if err != nil {
+		return nil, err
+	}
+	return profile.Palette, nil
+}
+
+// hasPaletteStep reports whether a step of configs dithers to a palette.
+func hasPaletteStep(configs []imageprocessing.CommandConfig) bool {
+	for _, cfg := range configs {
+		if isPaletteStep(cfg) {
+			return true
+		}
+	}
+	return false
+}
+
+func isPaletteStep(cfg imageprocessing.CommandConfig) bool {
+	if cfg.Name == "DitherCommand" {
+		return true
+	}
+	_, ok := cfg.Params["palette"]
+	return ok && cfg.Name == "AutoEnhanceCommand"
+}
+
+// withPalette replaces the palette of every dithering step with pairs.
+func withPalette(configs []imageprocessing.CommandConfig, pairs []imageprocessing.ColorPair) []imageprocessing.CommandConfig {
+	palette := make([]any, len(pairs))
+	for i, p := range pairs {
+		palette[i] = []any{
+			[]any{int(p.Device.R), int(p.Device.G), int(p.Device.B)},
+			[]any{int(p.Dither.R), int(p.Dither.G), int(p.Dither.B)},
+		}
+	}
+	calibrated := make([]imageprocessing.CommandConfig, len(configs))
+	for i, cfg := range configs {
+		calibrated[i] = cfg
+		if !isPaletteStep(cfg) {
+			continue
+		}
+		params := make(map[string]any, len(cfg.Params)+1)
+		maps.Copy(params, cfg.Params)
+		params["palette"] = palette
+		calibrated[i].Params = params
+	}
+	return calibrated
+}
+
+func paletteColors(pairs []imageprocessing.ColorPair) []database.PaletteColor {
+	colors := make([]database.PaletteColor, len(pairs))
+	for i, p := range pairs {
+		colors[i] = database.PaletteColor{
+			Device: imageprocessing.FormatHexColor(p.Device),
+			Dither: imageprocessing.FormatHexColor(p.Dither),
+		}
+	}
+	return colors
+}
+
+// palettePairs converts a stored palette back into color pairs. ok is false
+// when it no longer matches the configured device colors, e.g. after the
+// panel was changed in the configuration; the palette is then ignored.
+func (service *CoreService) palettePairs(palette *database.Palette) (pairs []imageprocessing.ColorPair, ok bool) {
+	configured, err := service.configuredPalette()
+	if err != nil || len(configured) != len(palette.Colors) {
+		return nil, false
+	}
+	pairs = make([]imageprocessing.ColorPair, len(palette.Colors))
+	for i, c := range palette.Colors {
+		device, err1 := imageprocessing.ParseHexColor(c.Device)
+		dither, err2 := imageprocessing.ParseHexColor(c.Dither)
+		if err1 != nil || err2 != nil || device != configured[i].Device {
+			return nil, false
+		}
+		pairs[i] = imageprocessing.ColorPair{Device: device, Dither: dither}
+	}
+	return pairs, true
+}
+
+// paletteForDevice returns deviceID's calibrated palette, or nil.
+func (service *CoreService) paletteForDevice(ctx context.Context, deviceID string) (*database.Palette, error) {
+	snapshot, err := service.rotationSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.paletteFor(deviceID), nil
+}
+
+// calibratedID identifies image id as rendered with palette; it is id itself
+// without a palette. Renditions derived from it are keyed by it.
+func calibratedID(id string, palette *database.Palette) string {
+	if palette == nil {
+		return id
+	}
+	var b strings.Builder
+	for _, c := range palette.Colors {
+		b.WriteString(c.Device + c.Dither)
+	}
+	return id + "/palette/" + database.ContentHash([]byte(b.String()))[:16]
+}
+
+// deviceBaseImage returns the processed image id, dithered with palette when
+// the device has one, before mats and overlays are drawn onto it.
+func (service *CoreService) deviceBaseImage(ctx context.Context, id string, palette *database.Palette) ([]byte, error) {
+	if palette == nil {
+		return service.processedImageData(ctx, id)
+	}
+	key := calibratedID(id, palette)
+	if data, ok := service.calibrated.get(key); ok {
+		service.calibrated.put(database.ContentHash(data), data)
+		return data, nil
+	}
+	pairs, ok := service.palettePairs(palette)
+	if !ok {
+		slog.Warn("CoreService.deviceBaseImage: calibrated palette does not match the configured palette; ignoring it", "id", id)
+		return service.processedImageData(ctx, id)
+	}
+
+	img, err := service.databaseService.GetImageByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	original, err := service.databaseService.GetImageData(ctx, id, "original")
+	if err != nil {
+		return nil, err
+	}
+	data, _, err := service.processImageWith(withPalette(service.commandConfigs, pairs), original, img.Attribution, img.EnhancementPreset, img.Fit, img.FlatArt, img.Crop)
+	if err != nil {
+		return nil, err
+	}
+	// Cached by image and palette (storeReprocessed drops an image's entries)
+	// and by content hash, so the blob URL handed to devices can be served.
+	service.calibrated.put(key, data)
+	service.calibrated.put(database.ContentHash(data), data)
+	return data, nil
+}
@@ -0,0 +1,174 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+func calibrationTestConfig() *config.ServiceConfig {
+	return &config.ServiceConfig{Commands: []config.CommandConfig{{
+		Name: "DitherCommand",
+		Params: map[string]any{"palette": []any{
+			[]any{[]any{0, 0, 0}, []any{0, 0, 0}},
+			[]any{[]any{255, 255, 255}, []any{255, 255, 255}},
+		}},
+	}}}
+}
+
+// grayPNG encodes a w x h image of the gray level v.
+func grayPNG(t *testing.T, w, h int, v uint8) []byte {
+	t.Helper()
+	return patternPNG(t, w, h, func(int, int) color.Color { return color.Gray{Y: v} })
+}
+
+func blackPixels(t *testing.T, data []byte) int {
+	t.Helper()
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to decode image: %v", err)
+	}
+	n := 0
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if color.RGBAModel.Convert(img.At(x, y)) == (color.RGBA{A: 255}) {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func TestCalibratePalette_StoresDerivedPalette(t *testing.T) {
+	service, _ := newTestCoreService(t, calibrationTestConfig())
+	ctx := context.Background()
+
+	palette, err := service.CalibratePalette(ctx, "kitchen", []string{"#202020", "#c8c8b4"})
+	if err != nil {
+		t.Fatalf("CalibratePalette failed: %v", err)
+	}
+	want := []database.PaletteColor{{Device: "#000000", Dither: "#29292d"}, {Device: "#ffffff", Dither: "#ffffff"}}
+	if len(palette.Colors) != len(want) || palette.Colors[0] != want[0] || palette.Colors[1] != want[1] {
+		t.Errorf("expected palette %v, got %v", want, palette.Colors)
+	}
+
+	calibration, err := service.GetCalibration(ctx, "kitchen")
+	if err != nil {
+		t.Fatalf("GetCalibration failed: %v", err)
+	}
+	if calibration.CalibratedAt == nil || calibration.Colors[0] != want[0] {
+		t.Errorf("expected the stored palette, got %+v", calibration)
+	}
+	other, err := service.GetCalibration(ctx, "hall")
+	if err != nil {
+		t.Fatalf("GetCalibration failed: %v", err)
+	}
+	if other.CalibratedAt != nil || other.Colors[0].Dither != "#000000" {
+		t.Errorf("expected the configured palette for an uncalibrated device, got %+v", other)
+	}
+}
+
+func TestCalibratePalette_Invalid(t *testing.T) {
+	service, _ := newTestCoreService(t, calibrationTestConfig())
+	ctx := context.Background()
+	for name, tc := range map[string]struct {
+		device   string
+		measured []string
+	}{
+		"no device":     {"", []string{"#000000", "#ffffff"}},
+		"invalid color": {"kitchen", []string{"black", "#ffffff"}},
+		"missing patch": {"kitchen", []string{"#000000"}},
+		"black white":   {"kitchen", []string{"#000000", "#000000"}},
+	} {
+		if _, err := service.CalibratePalette(ctx, tc.device, tc.measured); !errors.Is(err, ErrInvalidCalibration) {
+			t.Errorf("%s: expected ErrInvalidCalibration, got %v", name, err)
+		}
+	}
+
+	plain, _ := newTestCoreService(t, &config.ServiceConfig{})
+	if _, err := plain.CalibratePalette(ctx, "kitchen", []string{"#000000", "#ffffff"}); !errors.Is(err, ErrInvalidCalibration) {
+		t.Errorf("expected ErrInvalidCalibration without a dithering step, got %v", err)
+	}
+}
+
+func TestCalibratePaletteFromPhoto_MeasuresChart(t *testing.T) {
+	service, _ := newTestCoreService(t, calibrationTestConfig())
+	chart, err := service.GetCalibrationChart()
+	if err != nil {
+		t.Fatalf("GetCalibrationChart failed: %v", err)
+	}
+	palette, err := service.CalibratePaletteFromPhoto(context.Background(), "kitchen", chart)
+	if err != nil {
+		t.Fatalf("CalibratePaletteFromPhoto failed: %v", err)
+	}
+	for _, c := range palette.Colors {
+		if c.Device != c.Dither {
+			t.Errorf("expected a perfect photo to reproduce the configured palette, got %v", palette.Colors)
+		}
+	}
+
+	if _, err := service.CalibratePaletteFromPhoto(context.Background(), "kitchen", []byte("not an image")); !errors.Is(err, ErrInvalidCalibration) {
+		t.Errorf("expected ErrInvalidCalibration for an undecodable photo, got %v", err)
+	}
+}
+
+func TestGetDeviceImageURL_UsesCalibratedPalette(t *testing.T) {
+	service, _ := newTestCoreService(t, calibrationTestConfig())
+	ctx := context.Background()
+	if _, err := service.AddImage(ctx, grayPNG(t, 16, 16, 128), "", database.Attribution{}); err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+	id, err := service.GetImageForDevice(ctx, "")
+	if err != nil {
+		t.Fatalf("GetImageForDevice failed: %v", err)
+	}
+	plainURL, err := service.GetDeviceImageURL(ctx, "kitchen", id)
+	if err != nil {
+		t.Fatalf("GetDeviceImageURL failed: %v", err)
+	}
+
+	// The panel's black looks mid gray, so mid gray dithers to mostly black.
+	if _, err := service.CalibratePalette(ctx, "kitchen", []string{"#787878", "#ffffff"}); err != nil {
+		t.Fatalf("CalibratePalette failed: %v", err)
+	}
+	calibratedURL, err := service.GetDeviceImageURL(ctx, "kitchen", id)
+	if err != nil {
+		t.Fatalf("GetDeviceImageURL failed: %v", err)
+	}
+	if calibratedURL == plainURL {
+		t.Fatal("expected a calibrated rendition")
+	}
+	hash := strings.TrimSuffix(strings.TrimPrefix(calibratedURL, BlobURLPrefix), ".png")
+	calibrated, err := service.GetBlobByHash(ctx, hash)
+	if err != nil {
+		t.Fatalf("GetBlobByHash failed: %v", err)
+	}
+	plain, err := service.GetBlobByHash(ctx, strings.TrimSuffix(strings.TrimPrefix(plainURL, BlobURLPrefix), ".png"))
+	if err != nil {
+		t.Fatalf("GetBlobByHash failed: %v", err)
+	}
+	if blackPixels(t, calibrated) <= blackPixels(t, plain) {
+		t.Errorf("expected more black pixels with the calibrated palette, got %d vs %d", blackPixels(t, calibrated), blackPixels(t, plain))
+	}
+	if patch, err := service.GetCurrentImagePatch(ctx, "kitchen", ""); err != nil || patch.To != hash {
+		t.Errorf("expected the patch to target the calibrated rendition %s, got %+v (err %v)", hash, patch, err)
+	}
+	if url, err := service.GetDeviceImageURL(ctx, "hall", id); err != nil || url != plainURL {
+		t.Errorf("expected other devices to keep the configured palette, got %s (err %v)", url, err)
+	}
+
+	if err := service.DeletePalette(ctx, "kitchen"); err != nil {
+		t.Fatalf("DeletePalette failed: %v", err)
+	}
+	if url, err := service.GetDeviceImageURL(ctx, "kitchen", id); err != nil || url != plainURL {
+		t.Errorf("expected the configured palette after deleting the calibration, got %s (err %v)", url, err)
+	}
+}
@@ -0,0 +1,161 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/imageprocessing"
+	"github.com/jo-hoe/goframe/internal/resilience"
+)
+
+// cameraSource grabs a snapshot from a network camera, e.g. a birdhouse cam.
+// HTTP(S) URLs are fetched as a still image; RTSP streams are sampled with
+// ffmpeg, which must be installed. Each snapshot replaces the previous one in
+// its place in the rotation, and polls only happen within the active hours.
+type cameraSource struct {
+	url string
+	// name is the URL with its password redacted, for errors shown in the
+	// source status.
+	name       string
+	ffmpeg     string
+	httpClient *http.Client
+	// from and until are the active hours as minutes after midnight; both are
+	// -1 when the camera is always active.
+	from, until int
+}
+
+func newCameraSource(params map[string]any) (Source, error) {
+	u := imageprocessing.GetStringParam(params, "url", "")
+	if !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") && !strings.HasPrefix(u, "rtsp://") {
+		return nil, fmt.Errorf("camera source requires an http, https or rtsp url (got %q)", u)
+	}
+	name := u
+	if parsed, err := url.Parse(u); err == nil {
+		name = parsed.Redacted()
+	}
+	s := &cameraSource{
+		url:        u,
+		name:       name,
+		ffmpeg:     imageprocessing.GetStringParam(params, "ffmpeg", "ffmpeg"),
+		httpClient: &http.Client{Timeout: time.Minute},
+		from:       -1,
+		until:      -1,
+	}
+	from := imageprocessing.GetStringParam(params, "activeFrom", "")
+	until := imageprocessing.GetStringParam(params, "activeUntil", "")
+	if (from == "") != (until == "") {
+		return nil, fmt.Errorf("camera source requires both activeFrom and activeUntil or neither")
+	}
+	if from != "" {
+		var err error
+		if s.from, err = parseClockMinutes(from); err != nil {
+			return nil, fmt.Errorf("invalid activeFrom: %w", err)
+		}
+		if s.until, err = parseClockMinutes(until); err != nil {
+			return nil, fmt.Errorf("invalid activeUntil: %w", err)
+		}
+	}
+	return s, nil
+}
+
+// parseClockMinutes parses "HH:MM" into minutes after midnight.
+func parseClockMinutes(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("expected HH:MM, got %q", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// live makes snapshots replace each other instead of piling up.
+func (s *cameraSource) live() bool {
+	return true
+}
+
+// activeAt reports whether now, in the rotation timezone, lies within the
+// active hours. Hours that wrap past midnight, such as 22:00 to 06:00, are
+// allowed.
+func (s *cameraSource) activeAt(now time.Time) bool {
+	if s.from < 0 {
+		return true
+	}
+	minute := now.Hour()*60 + now.Minute()
+	if s.from <= s.until {
+		return minute >= s.from && minute < s.until
+	}
+	return minute >= s.from || minute < s.until
+}
+
+// Poll takes a snapshot. Unchanged snapshots are skipped by their digest like
+// any repeated source image.
+func (s *cameraSource) Poll(ctx context.Context) ([]NewImage, error) {
+	var data []byte
+	var err error
+	if strings.HasPrefix(s.url, "rtsp://") {
+		data, err = s.grabFrame(ctx)
+	} else {
+		data, err = s.fetchSnapshot(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []NewImage{{Data: data}}, nil
+}
+
+func (s *cameraSource) fetchSnapshot(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, resilience.PermanentForStatus(fmt.Errorf("GET %s: unexpected status %d", s.name, resp.StatusCode), resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSourceDownloadBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", s.name, err)
+	}
+	if len(data) > maxSourceDownloadBytes {
+		return nil, resilience.Permanent(fmt.Errorf("GET %s: snapshot exceeds %s", s.name, FormatBytes(maxSourceDownloadBytes)))
+	}
+	return data, nil
+}
+
+// grabFrame decodes the first frame of the RTSP stream to PNG with ffmpeg.
+func (s *cameraSource) grabFrame(ctx context.Context) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, s.ffmpeg, "-nostdin", "-loglevel", "error", "-rtsp_transport", "tcp",
+		"-i", s.url, "-frames:v", "1", "-f", "image2", "-c:v", "png", "pipe:1")
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		var execErr *exec.Error
+		if errors.As(err, &execErr) {
+			return nil, resilience.Permanent(fmt.Errorf("running %s: %w", s.ffmpeg, err))
+		}
+		return nil, fmt.Errorf("grabbing a frame from %s: %w: %s", s.name, err, strings.TrimSpace(stderr.String()))
+	}
+	if stdout.Len() == 0 {
+		return nil, fmt.Errorf("grabbing a frame from %s: no frame received", s.name)
+	}
+	return stdout.Bytes(), nil
+}
+
+func init() {
+	if err := DefaultSourceRegistry.Register("camera", newCameraSource); err != nil {
+		panic(fmt.Sprintf("failed to register camera source: %v", err))
+	}
+}
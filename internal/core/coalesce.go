@@ -0,0 +1,208 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+	"golang.org/x/sync/singleflight"
+)
+
+// CoalescingStats counts how current-image lookups were answered.
+type CoalescingStats struct {
+	// Hits were answered from the short-lived cache.
+	Hits int64 `json:"hits"`
+	// Shared waited for a lookup another request had already started.
+	Shared int64 `json:"shared"`
+	// Loads read the database or rendered the image themselves.
+	Loads int64 `json:"loads"`
+}
+
+type coalescedEntry struct {
+	value      any
+	generation uint64
+	expiresAt  time.Time
+}
+
+// coalescer deduplicates concurrent lookups with the same key and keeps
+// results for ttl, so frames polling at the same second after a rotation
+// share one database read and one rendition. invalidate drops all results
+// after a change made through this server; changes made elsewhere, such as the
+// operator's rotation, show up once the ttl has passed.
+type coalescer struct {
+	group singleflight.Group
+	ttl   time.Duration
+	nowFn func() time.Time
+
+	mu         sync.Mutex
+	generation uint64
+	entries    map[string]coalescedEntry
+	// changed is closed and replaced by invalidate; see changes.
+	changed chan struct{}
+
+	hits, shared, loads atomic.Int64
+}
+
+func newCoalescer(ttl time.Duration) *coalescer {
+	return &coalescer{ttl: ttl, nowFn: time.Now, entries: make(map[string]coalescedEntry), changed: make(chan struct{})}
+}
+
+// do returns the cached value for key or runs load, sharing a single call
+// among concurrent callers. Errors are not cached.
+func (c *coalescer) do(key string, load func() (any, error)) (any, error) {
+	c.mu.Lock()
+	generation := c.generation
+	if e, ok := c.entries[key]; ok && e.generation == generation && c.nowFn().Before(e.expiresAt) {
+		c.mu.Unlock()
+		c.hits.Add(1)
+		return e.value, nil
+	}
+	c.mu.Unlock()
+
+	// Keying the flight by generation keeps callers arriving after an
+	// invalidation from joining a load that may have read the old state.
+	loaded := false
+	value, err, shared := c.group.Do(strconv.FormatUint(generation, 10)+"/"+key, func() (any, error) {
+		loaded = true
+		c.loads.Add(1)
+		value, err := load()
+		if err != nil || c.ttl <= 0 {
+			return value, err
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.generation == generation {
+			c.entries[key] = coalescedEntry{value: value, generation: generation, expiresAt: c.nowFn().Add(c.ttl)}
+		}
+		return value, nil
+	})
+	if shared && !loaded {
+		c.shared.Add(1)
+	}
+	return value, err
+}
+
+// invalidate drops all cached results.
+func (c *coalescer) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	clear(c.entries)
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// changes returns a channel that is closed by the next invalidate.
+func (c *coalescer) changes() <-chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.changed
+}
+
+func (c *coalescer) snapshot() CoalescingStats {
+	return CoalescingStats{Hits: c.hits.Load(), Shared: c.shared.Load(), Loads: c.loads.Load()}
+}
+
+// rotationSnapshot is the part of the rotation state needed to answer a poll.
+type rotationSnapshot struct {
+	ids    []string
+	groups []database.FrameGroup
+	mats   map[string]database.Mat
+	// palettes are the calibrated palettes keyed by device ID.
+	palettes map[string]database.Palette
+}
+
+// rotationSnapshot reads the rotation order, frame groups, mats and palettes, coalescing
+// concurrent reads.
+func (service *CoreService) rotationSnapshot(ctx context.Context) (*rotationSnapshot, error) {
+	// The read is shared, so one caller giving up must not fail the others.
+	ctx = context.WithoutCancel(ctx)
+	value, err := service.currentImages.do("rotation", func() (any, error) {
+		return service.loadRotationSnapshot(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*rotationSnapshot), nil
+}
+
+// loadRotationSnapshot reads the rotation state without the cache.
+func (service *CoreService) loadRotationSnapshot(ctx context.Context) (*rotationSnapshot, error) {
+	ids, err := service.databaseService.GetRotationOrderedIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := service.databaseService.GetFrameGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mats, err := service.databaseService.GetMats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	palettes, err := service.databaseService.GetPalettes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &rotationSnapshot{ids: ids, groups: groups, mats: mats, palettes: palettes}, nil
+}
+
+// imageFor returns the image deviceID should display; see GetImageForDevice.
+func (s *rotationSnapshot) imageFor(deviceID string) (string, error) {
+	if len(s.ids) == 0 {
+		return "", fmt.Errorf("no images")
+	}
+	return s.ids[s.offsetFor(deviceID)%len(s.ids)], nil
+}
+
+// offsetFor returns the position of deviceID in the rotation order: 0 without
+// a device, its group's offset or else one derived from its ID.
+func (s *rotationSnapshot) offsetFor(deviceID string) int {
+	if deviceID == "" {
+		return 0
+	}
+	for _, g := range s.groups {
+		if g.HasDevice(deviceID) {
+			return g.Offset
+		}
+	}
+	return deviceOffset(deviceID)
+}
+
+// matFor returns the mat deviceID should show: its own, else the global one.
+// ok is false when no mat applies.
+func (s *rotationSnapshot) matFor(deviceID string) (mat database.Mat, ok bool) {
+	if mat, ok := s.mats[deviceID]; ok {
+		return mat, mat.Width > 0 || mat.CornerRadius > 0
+	}
+	mat, ok = s.mats[database.GlobalMat]
+	return mat, ok && (mat.Width > 0 || mat.CornerRadius > 0)
+}
+
+// paletteFor returns the calibrated palette of deviceID, or nil.
+func (s *rotationSnapshot) paletteFor(deviceID string) *database.Palette {
+	if palette, ok := s.palettes[deviceID]; ok && deviceID != "" {
+		return &palette
+	}
+	return nil
+}
+
+// renditionKey identifies the image a device receives for image id: devices
+// sharing the palette and mat get the same rendition; overlays are drawn per
+// device.
+func renditionKey(deviceID, id string, palette *database.Palette, mat database.Mat, matted bool, overlay *config.Overlay) string {
+	id = calibratedID(id, palette)
+	key := "url/" + id
+	if matted {
+		key += "/" + mattedKey(id, mat)
+	}
+	if overlay != nil {
+		key += "/overlay/" + deviceID
+	}
+	return key
+}
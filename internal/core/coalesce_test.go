@@ -0,0 +1,152 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+// countingDatabase counts metadata reads, which back the rotation order, and
+// holds them until release is closed, so concurrent polls overlap.
+type countingDatabase struct {
+	*database.FakeDatabase
+	reads   atomic.Int64
+	release chan struct{}
+}
+
+func (d *countingDatabase) GetImageMetadata(ctx context.Context) ([]*database.Image, error) {
+	d.reads.Add(1)
+	<-d.release
+	return d.FakeDatabase.GetImageMetadata(ctx)
+}
+
+func TestGetImageForDevice_CoalescesConcurrentPolls(t *testing.T) {
+	db := &countingDatabase{FakeDatabase: database.NewFakeDatabase(""), release: make(chan struct{})}
+	service := newCoreService(&config.ServiceConfig{Timezone: "UTC", CurrentImageCacheTTL: time.Minute}, db)
+	ctx := context.Background()
+	close(db.release)
+	ids := addTestImages(t, service, 2)
+	db.release = make(chan struct{})
+	db.reads.Store(0)
+
+	const polls = 20
+	var wg sync.WaitGroup
+	got := make([]string, polls)
+	for i := range polls {
+		wg.Go(func() {
+			id, err := service.GetImageForDevice(ctx, "")
+			if err != nil {
+				t.Errorf("GetImageForDevice failed: %v", err)
+			}
+			got[i] = id
+		})
+	}
+	// Let the first read through once every poll has started waiting.
+	time.Sleep(50 * time.Millisecond)
+	close(db.release)
+	wg.Wait()
+
+	for _, id := range got {
+		if id != ids[0] {
+			t.Fatalf("expected every poll to get %s, got %v", ids[0], got)
+		}
+	}
+	if n := db.reads.Load(); n != 1 {
+		t.Errorf("expected one database read for %d concurrent polls, got %d", polls, n)
+	}
+	if _, err := service.GetImageForDevice(ctx, ""); err != nil || db.reads.Load() != 1 {
+		t.Errorf("expected the next poll to be cached, got %d reads (err %v)", db.reads.Load(), err)
+	}
+	m := service.GetMetrics().CurrentImage
+	if m.Loads != 1 || m.Shared+m.Hits != polls {
+		t.Errorf("unexpected coalescing stats %+v", m)
+	}
+}
+
+func TestGetImageForDevice_ChangesInvalidateCache(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{CurrentImageCacheTTL: time.Minute})
+	ctx := context.Background()
+	ids := addTestImages(t, service, 2)
+
+	if id, _ := service.GetImageForDevice(ctx, ""); id != ids[0] {
+		t.Fatalf("expected %s, got %s", ids[0], id)
+	}
+	if err := service.UpdateImageOrder(ctx, []string{ids[1], ids[0]}); err != nil {
+		t.Fatalf("UpdateImageOrder failed: %v", err)
+	}
+	if id, _ := service.GetImageForDevice(ctx, ""); id != ids[1] {
+		t.Errorf("expected the new order to apply at once, got %s", id)
+	}
+}
+
+func TestCoalescer_ExpiresAfterTTL(t *testing.T) {
+	c := newCoalescer(time.Second)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.nowFn = func() time.Time { return now }
+	loads := 0
+	load := func() (any, error) { loads++; return loads, nil }
+
+	_, _ = c.do("k", load)
+	_, _ = c.do("k", load)
+	now = now.Add(2 * time.Second)
+	if v, _ := c.do("k", load); v != 2 || loads != 2 {
+		t.Errorf("expected a reload after the ttl, got %v after %d loads", v, loads)
+	}
+}
+
+// originalReadsDatabase counts reads of originals, which precede every
+// pipeline run of an on-demand rendition, and holds them until release is
+// closed.
+type originalReadsDatabase struct {
+	*database.FakeDatabase
+	reads   atomic.Int64
+	release chan struct{}
+}
+
+func (d *originalReadsDatabase) GetImageData(ctx context.Context, id, variant string) ([]byte, error) {
+	if variant == "original" {
+		d.reads.Add(1)
+		<-d.release
+	}
+	return d.FakeDatabase.GetImageData(ctx, id, variant)
+}
+
+func TestGetProcessedImage_CoalescesConcurrentMisses(t *testing.T) {
+	db := &originalReadsDatabase{FakeDatabase: database.NewFakeDatabase(""), release: make(chan struct{})}
+	service := newCoreService(&config.ServiceConfig{
+		Timezone: "UTC",
+		ProcessedImages: config.ProcessedImages{
+			Mode:            config.ProcessedImageModeOnDemand,
+			CacheTTL:        time.Hour,
+			CacheMaxEntries: 2,
+		},
+	}, db)
+	ctx := context.Background()
+	close(db.release)
+	ids := addTestImages(t, service, 1)
+	db.release = make(chan struct{})
+	db.reads.Store(0)
+
+	const requests = 10
+	var wg sync.WaitGroup
+	for range requests {
+		wg.Go(func() {
+			if data, err := service.GetProcessedImage(ctx, ids[0]); err != nil || len(data) == 0 {
+				t.Errorf("GetProcessedImage failed: %v", err)
+			}
+		})
+	}
+	// Let the first read through once every request has started waiting.
+	time.Sleep(50 * time.Millisecond)
+	close(db.release)
+	wg.Wait()
+
+	if n := db.reads.Load(); n != 1 {
+		t.Errorf("expected one pipeline run for %d concurrent misses, got %d", requests, n)
+	}
+}
@@ -0,0 +1,64 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+// ServesOriginalsDirectly reports whether originals must be streamed by the
+// API because they are stored compressed and cannot be served by the blob store.
+func (service *CoreService) ServesOriginalsDirectly() bool {
+	return service.config.Database.CompressOriginals
+}
+
+// OpenOriginalImage streams the original PNG of image id, decompressing it if
+// it is stored compressed. The caller must close the reader.
+func (service *CoreService) OpenOriginalImage(ctx context.Context, id string) (io.ReadCloser, error) {
+	return service.databaseService.OpenImageData(ctx, id, "original")
+}
+
+// OriginalImageSize returns the size of the PNG streamed by OpenOriginalImage,
+// or 0 when it was not recorded for image id.
+func (service *CoreService) OriginalImageSize(ctx context.Context, id string) (int64, error) {
+	img, err := service.databaseService.GetImageByID(ctx, id)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrImageNotFound, id)
+	}
+	return img.Original.Bytes, nil
+}
+
+// RunOriginalCompressionMigration converts the stored originals to the
+// configured encoding: it compresses existing originals once
+// database.compressOriginals is enabled and decompresses them again once it is
+// disabled. Images are converted one at a time; failures are logged and
+// retried on the next start. Read-only instances leave the originals alone.
+func (service *CoreService) RunOriginalCompressionMigration(ctx context.Context) {
+	if service.config.ReadOnly {
+		return
+	}
+	images, err := service.allImages(ctx)
+	if err != nil {
+		slog.Error("CoreService.RunOriginalCompressionMigration: failed to list images", "error", err)
+		return
+	}
+	var migrated, failed int
+	for _, img := range images {
+		if ctx.Err() != nil {
+			return
+		}
+		changed, err := service.databaseService.MigrateOriginalEncoding(ctx, img.ID)
+		switch {
+		case err != nil:
+			failed++
+			slog.Warn("CoreService.RunOriginalCompressionMigration: failed to convert original", "id", img.ID, "error", err)
+		case changed:
+			migrated++
+		}
+	}
+	if migrated > 0 || failed > 0 {
+		slog.Info("CoreService.RunOriginalCompressionMigration: converted stored originals",
+			"compressed", service.config.Database.CompressOriginals, "converted", migrated, "failed", failed)
+	}
+}
@@ -0,0 +1,64 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+func TestCompressedOriginals(t *testing.T) {
+	service, db := newTestCoreService(t, &config.ServiceConfig{Database: config.Database{CompressOriginals: true}})
+	db.CompressOriginals = true
+	ctx := context.Background()
+
+	upload := testPNG(t, 16, 16)
+	img, err := service.AddImage(ctx, upload, "", database.Attribution{})
+	if err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+	url, err := service.GetImageURL(ctx, img.ID, "original")
+	if err != nil || url != "/api/images/"+img.ID+"/original.png" {
+		t.Errorf("GetImageURL = %q, %v; want the API route", url, err)
+	}
+
+	body, err := service.OpenOriginalImage(ctx, img.ID)
+	if err != nil {
+		t.Fatalf("OpenOriginalImage failed: %v", err)
+	}
+	defer func() { _ = body.Close() }()
+	data, _ := io.ReadAll(body)
+	stored, _ := db.GetImageByID(ctx, img.ID)
+	if database.ContentHash(data) != stored.OriginalHash {
+		t.Error("expected the streamed original to match the stored hash")
+	}
+	if !bytes.HasPrefix(data, []byte("\x89PNG")) {
+		t.Error("expected a decompressed PNG")
+	}
+}
+
+func TestRunOriginalCompressionMigration(t *testing.T) {
+	service, db := newTestCoreService(t, &config.ServiceConfig{})
+	ctx := context.Background()
+
+	var ids []string
+	for _, size := range []int{4, 6} {
+		img, err := service.AddImage(ctx, testPNG(t, size, size), "", database.Attribution{})
+		if err != nil {
+			t.Fatalf("AddImage failed: %v", err)
+		}
+		ids = append(ids, img.ID)
+	}
+
+	db.CompressOriginals = true
+	service.RunOriginalCompressionMigration(ctx)
+	for _, id := range ids {
+		img, _ := db.GetImageByID(ctx, id)
+		if img.OriginalEncoding != database.EncodingZstd {
+			t.Errorf("expected image %s to be compressed, got %q", id, img.OriginalEncoding)
+		}
+	}
+}
@@ -1,15 +1,23 @@
 package core
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
+	"maps"
 	"time"
 
+	"github.com/jo-hoe/goframe/internal/buildinfo"
+	"github.com/jo-hoe/goframe/internal/chaos"
 	"github.com/jo-hoe/goframe/internal/common"
 	"github.com/jo-hoe/goframe/internal/config"
 	"github.com/jo-hoe/goframe/internal/database"
+	"github.com/jo-hoe/goframe/internal/displayformat"
 	"github.com/jo-hoe/goframe/internal/imageprocessing"
+	"github.com/jo-hoe/goframe/internal/notify"
+	"github.com/jo-hoe/goframe/internal/resilience"
 )
 
 // CoreService is the central business logic layer for the goframe server.
@@ -18,6 +26,66 @@ type CoreService struct {
 	databaseService database.DatabaseService
 	commandConfigs  []imageprocessing.CommandConfig
 	tzLoc           *time.Location
+	// nowFn is the clock schedules and rotation info are computed against.
+	nowFn func() time.Time
+	// processedCache holds lazily generated processed images; nil unless
+	// processed images are configured to be generated on demand.
+	processedCache *processedCache
+	// renditions coalesces concurrent cache misses for the same processed image,
+	// so only one of them runs the pipeline.
+	renditions *coalescer
+	// decodeBudget throttles concurrent uploads by their estimated decode memory.
+	decodeBudget *decodeBudget
+	// quotas accounts uploads per client.
+	quotas *quotaTracker
+	// updateChecker looks up the latest GitHub release; nil unless enabled.
+	updateChecker *buildinfo.UpdateChecker
+	// alerts notifies the owner about failures; nil unless channels are configured.
+	alerts *alerter
+	// history remembers the last recorded display to avoid redundant writes.
+	history displayHistory
+	// devicePolls remembers when identified devices last polled.
+	devicePolls devicePolls
+	// processingTimes records how long the pipeline takes per image.
+	processingTimes processingTimes
+	// pregenerated holds the images rendered ahead of the next rotation.
+	pregenerated pregenerated
+	// matted caches processed images with a mat composited on, by image and by hash.
+	matted *processedCache
+	// reprocessing tracks reprocessing all images.
+	reprocessing reprocessJob
+	// locks serializes operations that modify the same image.
+	locks imageLocks
+	// composited caches images with overlay widgets drawn on, by minute and by hash.
+	composited *processedCache
+	// calibrated caches images dithered with a device's calibrated palette, by image and palette and by hash.
+	calibrated *processedCache
+	// thumbnails caches the previews shown in the UI's image lists.
+	thumbnails *processedCache
+	// experiments caches images rendered up to the dithering step for the dithering previews.
+	experiments *processedCache
+	// encoded caches images encoded in formats other than PNG, by hash and extension.
+	encoded *processedCache
+	// profiled caches images rendered for device profiles, by image ID and profile.
+	profiled *processedCache
+	// deviceProfiles are the pipelines of the configured device profiles by name.
+	deviceProfiles map[string][]imageprocessing.CommandConfig
+	// weather caches forecasts for the weather overlay widget.
+	weather weatherCache
+	// chaos slows down the pipeline on purpose; nil unless chaos mode is enabled.
+	chaos *chaos.Injector
+	// currentImages coalesces the lookups behind device polls.
+	currentImages *coalescer
+	// metadataIndex serves image metadata and the rotation order from memory.
+	metadataIndex *indexedDatabase
+	// sources are the configured image sources and their sync state.
+	sources imageSources
+	// integrations retries and pauses calls to external services.
+	integrations *resilience.Registry
+	// refreshes enforces the panel's minimum refresh interval.
+	refreshes refreshTracker
+	// display formats the human-readable dates of schedules and simulations.
+	display *displayformat.Formatter
 }
 
 // NewCoreService constructs and initialises a CoreService from the given config.
@@ -29,11 +97,17 @@ func NewCoreService(cfg *config.ServiceConfig) (*CoreService, error) {
 		cfg.Database.AccessKey,
 		cfg.Database.SecretKey,
 		cfg.Database.ImageBaseURL,
+		cfg.Database.CompressOriginals,
+		cfg.Database.IDFormat,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("initialising database: %w", err)
 	}
+	return newCoreService(cfg, db), nil
+}
 
+// newCoreService wires a CoreService around an already constructed database.
+func newCoreService(cfg *config.ServiceConfig, db database.DatabaseService) *CoreService {
 	cmdCfgs := make([]imageprocessing.CommandConfig, 0, len(cfg.Commands))
 	for _, c := range cfg.Commands {
 		cmdCfgs = append(cmdCfgs, imageprocessing.CommandConfig{
@@ -48,29 +122,202 @@ func NewCoreService(cfg *config.ServiceConfig) (*CoreService, error) {
 		loc = time.UTC
 	}
 
-	return &CoreService{
+	// Hosts with little memory keep fewer rendered images around.
+	cacheEntries := func(n int) int {
+		if cfg.Runtime.LowMemory() {
+			return max(1, n/4)
+		}
+		return n
+	}
+
+	injector := chaos.New(cfg.Chaos)
+	index := newIndexedDatabase(injector.WrapDatabase(db), cfg.MetadataIndexTTL)
+	service := &CoreService{
 		config:          cfg,
-		databaseService: db,
+		databaseService: index,
 		commandConfigs:  cmdCfgs,
 		tzLoc:           loc,
-	}, nil
+		nowFn:           time.Now,
+		decodeBudget:    newDecodeBudget(max(cfg.Uploads.MemoryBudgetBytes, 1)),
+		quotas:          newQuotaTracker(cfg.Quotas.UploadsPerDay, cfg.Quotas.MaxStoredBytes, loc),
+		matted:          newProcessedCache(mattedCacheTTL, cacheEntries(mattedCacheMaxEntries)),
+		composited:      newProcessedCache(compositedCacheTTL, cacheEntries(compositedCacheMaxEntries)),
+		calibrated:      newProcessedCache(calibratedCacheTTL, cacheEntries(calibratedCacheMaxEntries)),
+		thumbnails:      newProcessedCache(thumbnailCacheTTL, cacheEntries(thumbnailCacheMaxEntries)),
+		experiments:     newProcessedCache(experimentCacheTTL, cacheEntries(experimentCacheMaxEntries)),
+		encoded:         newProcessedCache(encodedCacheTTL, cacheEntries(encodedCacheMaxEntries)),
+		profiled:        newProcessedCache(profiledCacheTTL, cacheEntries(profiledCacheMaxEntries)),
+		deviceProfiles:  deviceProfileCommands(cfg.Devices),
+		chaos:           injector,
+		display:         displayformat.New(cfg.Display),
+		currentImages:   newCoalescer(cfg.CurrentImageCacheTTL),
+		renditions:      newCoalescer(0),
+		metadataIndex:   index,
+		sources:         newImageSources(cfg.Sources, DefaultSourceRegistry),
+		integrations: resilience.NewRegistry(resilience.Policy{
+			Retries:          cfg.Integrations.Retries,
+			BaseDelay:        cfg.Integrations.RetryBaseDelay,
+			MaxDelay:         cfg.Integrations.RetryMaxDelay,
+			FailureThreshold: cfg.Integrations.FailureThreshold,
+			OpenDuration:     cfg.Integrations.OpenDuration,
+		}),
+	}
+	if service.processesOnDemand() {
+		service.processedCache = newProcessedCache(cfg.ProcessedImages.CacheTTL, cfg.ProcessedImages.CacheMaxEntries)
+	}
+	if cfg.UpdateCheck.Enabled {
+		service.updateChecker = buildinfo.NewUpdateChecker(buildinfo.DefaultReleaseURL, cfg.UpdateCheck.Interval)
+	}
+	if notifiers, err := notify.NewChannels(cfg.Notifications.Channels); err != nil {
+		slog.Error("invalid notification channels; alerts disabled", "error", err)
+	} else if len(notifiers) > 0 {
+		channels := make([]alertChannel, len(notifiers))
+		for i, n := range notifiers {
+			name := fmt.Sprintf("notifications:%s#%d", cfg.Notifications.Channels[i].Type, i+1)
+			channels[i] = alertChannel{name: name, notifier: n, breaker: service.integrations.Breaker(name)}
+		}
+		service.alerts = newAlerter(cfg.Notifications, channels...)
+	}
+	return service
+}
+
+// processesOnDemand reports whether processed images are generated lazily instead of at upload.
+func (service *CoreService) processesOnDemand() bool {
+	return service.config.ProcessedImages.Mode == config.ProcessedImageModeOnDemand
 }
 
-// AddImage processes and persists a new image.
-func (service *CoreService) AddImage(ctx context.Context, image []byte, source string) (*common.ApiImage, error) {
+// ErrFormatDisabled is returned for uploads in a format this build leaves out
+// (slim builds have no SVG, TIFF or WebP support).
+var ErrFormatDisabled = imageprocessing.ErrFormatDisabled
+
+// ErrSVGLimit is returned for SVG uploads with too many elements or that take
+// longer than svg.renderTimeout to render.
+var ErrSVGLimit = imageprocessing.ErrSVGLimit
+
+// AddImage processes and persists a new image. attribution is optional and, when
+// attribution overlays are enabled, is drawn onto the processed image.
+func (service *CoreService) AddImage(ctx context.Context, image []byte, source string, attribution database.Attribution) (*common.ApiImage, error) {
 	slog.Info("CoreService.AddImage: start", "bytes", len(image), "source", source)
 
-	convertedImageData, processedImage, err := service.applyPipeline(image)
+	if err := service.runPreUploadHooks(ctx, image, source, attribution); err != nil {
+		return nil, err
+	}
+
+	convertedImageData, err := service.convertToPNG(image)
 	if err != nil {
+		service.alerts.processingFailed(err)
 		return nil, err
 	}
 
-	databaseImageID, err := service.databaseService.CreateImage(ctx, convertedImageData, processedImage, time.Now().In(service.tzLoc), source, "")
+	// In on-demand mode only the original is stored; the processed image is
+	// generated on first request by GetProcessedImage.
+	var processedImage []byte
+	var preset string
+	if !service.processesOnDemand() {
+		processedImage, preset, err = service.processImage(convertedImageData, attribution, "", "", "", nil)
+		if err != nil {
+			service.alerts.processingFailed(err)
+			return nil, err
+		}
+	}
+	service.alerts.processingSucceeded()
+
+	originalImage := convertedImageData
+	if service.config.NormalizeAtRest.Enabled {
+		originalImage, err = imageprocessing.ArchiveCopy(convertedImageData,
+			service.config.NormalizeAtRest.MaxDimension, service.config.NormalizeAtRest.BitsPerChannel)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create archive copy: %w", err)
+		}
+		slog.Info("CoreService.AddImage: replaced original with archive copy",
+			"originalBytes", len(convertedImageData), "archiveBytes", len(originalImage))
+	}
+
+	hash, similar := service.checkNearDuplicates(ctx, convertedImageData)
+
+	pending := service.requiresModeration(source)
+	databaseImageID, err := service.databaseService.CreateImage(ctx, originalImage, processedImage, time.Now().In(service.tzLoc), source, attribution, "", pending)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create database image: %w", err)
 	}
+	service.currentImages.invalidate()
+	if hash != "" {
+		if err := service.databaseService.SetPerceptualHash(ctx, databaseImageID, hash); err != nil {
+			slog.Warn("CoreService.AddImage: failed to store perceptual hash", "id", databaseImageID, "error", err)
+		}
+	}
+	if preset != "" {
+		if err := service.databaseService.SetEnhancementPreset(ctx, databaseImageID, preset); err != nil {
+			slog.Warn("CoreService.AddImage: failed to store enhancement preset", "id", databaseImageID, "error", err)
+		}
+	}
+	uploaded := "source " + source
+	if source == "" {
+		uploaded = ""
+	}
+	if pending {
+		uploaded = joinDetail(uploaded, "pending moderation")
+	}
+	service.recordEvent(ctx, databaseImageID, database.ImageEvent{Type: database.ImageEventUploaded, Detail: uploaded})
+	if !service.processesOnDemand() {
+		service.recordProcessed(ctx, databaseImageID, database.ImageEventProcessed, preset, "", "", nil)
+	}
+	service.caption(ctx, databaseImageID, originalImage)
+	if pending {
+		pending = service.moderate(ctx, databaseImageID, source, attribution, originalImage)
+	}
+
+	return &common.ApiImage{ID: databaseImageID, Pending: pending, Similar: similar}, nil
+}
+
+// AddImageFromReader ingests an upload that may be backed by a temporary file.
+// The image header is inspected first to estimate decode memory; the body is
+// only read into memory once that much of the configured budget is available.
+func (service *CoreService) AddImageFromReader(ctx context.Context, r io.ReadSeeker, source string, attribution database.Attribution) (*common.ApiImage, error) {
+	cost := estimateDecodeCost(r, service.config.SvgFallbackLongSidePixelCount)
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("rewinding upload: %w", err)
+	}
 
-	return &common.ApiImage{ID: databaseImageID}, nil
+	release, err := service.decodeBudget.acquire(ctx, cost)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	image, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading upload: %w", err)
+	}
+	return service.AddImage(ctx, image, source, attribution)
+}
+
+// ReserveUploadQuota accounts an upload of size bytes for the client key. It
+// returns ErrUploadQuotaExceeded or ErrStorageQuotaExceeded when a limit is
+// reached; otherwise the returned release func must be called if the upload fails.
+func (service *CoreService) ReserveUploadQuota(key string, size int64) (release func(), err error) {
+	return service.quotas.reserve(key, size)
+}
+
+// GetQuotaUsage returns the accounted usage of all clients.
+func (service *CoreService) GetQuotaUsage() []QuotaUsage {
+	return service.quotas.snapshot()
+}
+
+// ResetQuota clears the usage of the client key and reports whether it was tracked.
+func (service *CoreService) ResetQuota(key string) bool {
+	return service.quotas.reset(key)
+}
+
+// QuotaAdminToken returns the bearer token guarding the quota admin API ("" = disabled).
+func (service *CoreService) QuotaAdminToken() string {
+	return service.config.Quotas.AdminToken
+}
+
+// UploadSpoolThreshold returns the multipart size in bytes above which uploads
+// should be buffered on disk rather than in memory.
+func (service *CoreService) UploadSpoolThreshold() int64 {
+	return service.config.Uploads.SpoolThresholdBytes
 }
 
 // GetImageById returns a single image's metadata by ID. Blobs are not populated.
@@ -79,20 +326,279 @@ func (service *CoreService) GetImageById(ctx context.Context, id string) (*datab
 }
 
 // GetImageURL returns the browser-facing URL for the given image ID and variant
-// ("original" or "processed"), routed through the ingress.
+// ("original" or "processed"), routed through the ingress. In on-demand mode
+// processed images are served by the API instead of the blob store; so are
+// originals when they are stored compressed.
 func (service *CoreService) GetImageURL(ctx context.Context, id, variant string) (string, error) {
+	if variant == "processed" && service.processesOnDemand() {
+		return "/api/images/" + id + "/processed.png", nil
+	}
+	if variant == "original" && service.ServesOriginalsDirectly() {
+		return "/api/images/" + id + "/original.png", nil
+	}
 	return service.databaseService.GetCurrentImageURL(ctx, id, variant)
 }
 
-// DeleteImage removes an image by its ID.
+// BlobURLPrefix is the path prefix of immutable, content-addressed image URLs.
+const BlobURLPrefix = "/api/blob/"
+
+// GetContentAddressedURL returns the immutable /api/blob/<sha256>.png URL for the
+// given image variant. Images without a recorded hash (created before hashing was
+// introduced, or processed on demand) fall back to GetImageURL.
+func (service *CoreService) GetContentAddressedURL(ctx context.Context, id, variant string) (string, error) {
+	img, err := service.databaseService.GetImageByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if hash := img.Hash(variant); hash != "" {
+		return BlobURLPrefix + hash + ".png", nil
+	}
+	return service.GetImageURL(ctx, id, variant)
+}
+
+// GetBlobByHash returns the stored image bytes whose SHA-256 digest equals hash.
+func (service *CoreService) GetBlobByHash(ctx context.Context, hash string) ([]byte, error) {
+	if data, ok := service.pregenerated.blob(hash); ok {
+		return data, nil
+	}
+	if data, ok := service.matted.get(hash); ok {
+		return data, nil
+	}
+	if data, ok := service.composited.get(hash); ok {
+		return data, nil
+	}
+	if data, ok := service.calibrated.get(hash); ok {
+		return data, nil
+	}
+	ref, ok, err := service.metadataIndex.blobByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("blob %s not found", hash)
+	}
+	return service.databaseService.GetImageData(ctx, ref.id, ref.variant)
+}
+
+// maxPatchRects bounds the number of rectangles in a differential update.
+const maxPatchRects = 8
+
+// ImagePatch is a differential update from the image a device last showed to
+// the current processed image.
+type ImagePatch struct {
+	// From is the hash the patch applies to; empty for full patches.
+	From string `json:"from,omitempty"`
+	// To is the hash of the current processed image after applying the patch.
+	To string `json:"to"`
+	*imageprocessing.Patch
+}
+
+// GetCurrentImagePatch diffs the processed image the device should display (see
+// GetImageForDevice), with the device's palette, mat and overlay applied, against the image with hash sinceHash. When that image is unknown (or sinceHash is empty)
+// the patch contains the full current image.
+func (service *CoreService) GetCurrentImagePatch(ctx context.Context, deviceID, sinceHash string) (*ImagePatch, error) {
+	id, err := service.GetImageForDevice(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	palette, err := service.paletteForDevice(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	current, err := service.deviceBaseImage(ctx, id, palette)
+	if err != nil {
+		return nil, err
+	}
+	if current, err = service.applyDeviceMat(ctx, deviceID, calibratedID(id, palette), current); err != nil {
+		return nil, err
+	}
+	if current, err = service.applyDeviceOverlay(ctx, deviceID, id, current); err != nil {
+		return nil, err
+	}
+
+	result := &ImagePatch{To: database.ContentHash(current)}
+	if patch, ok := service.pregenerated.patch(sinceHash, result.To); ok {
+		return patch, nil
+	}
+	var previous []byte
+	if sinceHash != "" {
+		if sinceHash == result.To {
+			previous = current
+		} else if data, err := service.GetBlobByHash(ctx, sinceHash); err == nil {
+			previous = data
+		} else {
+			slog.Debug("CoreService.GetCurrentImagePatch: base image unknown; sending full image", "since", sinceHash)
+		}
+	}
+
+	return service.computePatch(sinceHash, previous, current)
+}
+
+// computePatch diffs current against previous, whose hash is sinceHash. A nil
+// previous yields a full patch.
+func (service *CoreService) computePatch(sinceHash string, previous, current []byte) (*ImagePatch, error) {
+	patch, err := imageprocessing.ComputePatch(previous, current, maxPatchRects)
+	if err != nil {
+		return nil, err
+	}
+	result := &ImagePatch{To: database.ContentHash(current), Patch: patch}
+	if !result.Full {
+		result.From = sinceHash
+	}
+	return result, nil
+}
+
+// processedImageData returns the processed PNG for id, generating it when
+// processed images are not stored.
+func (service *CoreService) processedImageData(ctx context.Context, id string) ([]byte, error) {
+	if data, ok := service.pregenerated.processedImage(id); ok {
+		return data, nil
+	}
+	if service.processesOnDemand() {
+		return service.GetProcessedImage(ctx, id)
+	}
+	return service.databaseService.GetImageData(ctx, id, "processed")
+}
+
+// ServesProcessedOnDemand reports whether processed images must be fetched via
+// GetProcessedImage rather than redirecting to the blob store.
+func (service *CoreService) ServesProcessedOnDemand() bool {
+	return service.processesOnDemand()
+}
+
+// GetProcessedImage returns the processed PNG for the given image ID, running the
+// pipeline on the stored original on a cache miss. Concurrent misses for the
+// same image share one run.
+func (service *CoreService) GetProcessedImage(ctx context.Context, id string) ([]byte, error) {
+	if service.processedCache != nil {
+		if data, ok := service.processedCache.get(id); ok {
+			return data, nil
+		}
+	}
+	// The run is shared, so one caller giving up must not fail the others.
+	ctx = context.WithoutCancel(ctx)
+	value, err := service.renditions.do(id, func() (any, error) {
+		return service.renderProcessedImage(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.([]byte), nil
+}
+
+// renderProcessedImage runs the pipeline on the stored original of image id
+// and caches the result.
+func (service *CoreService) renderProcessedImage(ctx context.Context, id string) ([]byte, error) {
+	img, err := service.databaseService.GetImageByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	original, err := service.databaseService.GetImageData(ctx, id, "original")
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("CoreService.GetProcessedImage: generating processed image", "id", id, "bytes", len(original))
+	processed, preset, err := service.processImage(original, img.Attribution, img.EnhancementPreset, img.Fit, img.FlatArt, img.Crop)
+	if err != nil {
+		service.alerts.processingFailed(err)
+		return nil, err
+	}
+	service.alerts.processingSucceeded()
+	if preset != "" && img.EnhancementPreset == "" {
+		if err := service.databaseService.SetEnhancementPreset(ctx, id, preset); err != nil {
+			slog.Warn("CoreService.GetProcessedImage: failed to store enhancement preset", "id", id, "error", err)
+		}
+	}
+	if service.processedCache != nil {
+		// Without a cache every request renders the image, so only cached
+		// renditions are worth an event.
+		service.processedCache.put(id, processed)
+		service.recordProcessed(ctx, id, database.ImageEventProcessed, cmp.Or(img.EnhancementPreset, preset), img.Fit, img.FlatArt, img.Crop)
+	}
+	return processed, nil
+}
+
+// DeleteImage removes an image by its ID. It fails with ErrImageLocked while
+// another operation modifies the image.
 func (service *CoreService) DeleteImage(ctx context.Context, id string) error {
+	unlock, err := service.locks.tryLock("delete", id)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return service.deleteImage(ctx, id)
+}
+
+// deleteImage removes an image; callers must hold its lock.
+func (service *CoreService) deleteImage(ctx context.Context, id string) error {
 	slog.Info("CoreService.DeleteImage: deleting image", "id", id)
+	if service.processedCache != nil {
+		service.processedCache.remove(id)
+	}
+	service.renditions.invalidate()
+	service.pregenerated.remove(id)
+	service.thumbnails.remove(id)
+	service.experiments.removePrefix(id + "/")
+	service.profiled.removePrefix(id + "/")
+	defer service.currentImages.invalidate()
 	return service.databaseService.DeleteImage(ctx, id)
 }
 
+// Metrics is a point-in-time snapshot of server-side counters.
+type Metrics struct {
+	// ProcessedCache is set only when processed images are generated on demand.
+	ProcessedCache *ProcessedCacheStats `json:"processedCache,omitempty"`
+	// CurrentImage counts how device polls were answered.
+	CurrentImage CoalescingStats `json:"currentImage"`
+	// MetadataIndex counts how metadata reads were answered.
+	MetadataIndex CoalescingStats `json:"metadataIndex"`
+	// Pipeline describes the command runs since the server started.
+	Pipeline imageprocessing.PipelineMetrics `json:"pipeline"`
+}
+
+// GetMetrics returns current server metrics.
+func (service *CoreService) GetMetrics() Metrics {
+	m := Metrics{
+		CurrentImage:  service.currentImages.snapshot(),
+		MetadataIndex: service.metadataIndex.index.snapshot(),
+		Pipeline:      imageprocessing.GetPipelineMetrics(),
+	}
+	if service.processedCache != nil {
+		stats := service.processedCache.snapshot()
+		m.ProcessedCache = &stats
+	}
+	return m
+}
+
+// VersionInfo describes the running build and, when the update check is
+// enabled, the latest published release.
+type VersionInfo struct {
+	buildinfo.Info
+	LatestVersion   string `json:"latestVersion,omitempty"`
+	ReleaseURL      string `json:"releaseUrl,omitempty"`
+	UpdateAvailable bool   `json:"updateAvailable"`
+}
+
+// GetVersion returns build information and update availability. It never
+// blocks on GitHub; release data appears once a background lookup completed.
+func (service *CoreService) GetVersion() VersionInfo {
+	v := VersionInfo{Info: buildinfo.Get()}
+	if service.updateChecker == nil {
+		return v
+	}
+	if release := service.updateChecker.Latest(); release != nil {
+		v.LatestVersion = release.Version
+		v.ReleaseURL = release.URL
+		v.UpdateAvailable = buildinfo.IsNewer(release.Version, v.Version)
+	}
+	return v
+}
+
 // Close gracefully closes underlying resources.
 func (service *CoreService) Close() error {
 	slog.Info("CoreService.Close: closing resources")
+	service.CancelReprocessAll()
 	return service.databaseService.Close()
 }
 
@@ -116,6 +622,7 @@ func (service *CoreService) UpdateImageOrder(ctx context.Context, order []string
 	if len(order) == 0 {
 		return nil
 	}
+	defer service.currentImages.invalidate()
 	return service.databaseService.UpdateOrder(ctx, order)
 }
 
@@ -123,43 +630,98 @@ func (service *CoreService) getOrderedImageIDs(ctx context.Context) ([]string, e
 	return service.databaseService.GetRotationOrderedIDs(ctx)
 }
 
-// applyPipeline converts the input image to PNG and applies the configured command pipeline.
-func (service *CoreService) applyPipeline(image []byte) (converted []byte, processed []byte, err error) {
+// convertToPNG normalizes the orientation of the input image and converts it to PNG.
+func (service *CoreService) convertToPNG(image []byte) ([]byte, error) {
 	if image == nil {
-		return nil, nil, fmt.Errorf("input image is nil")
+		return nil, fmt.Errorf("input image is nil")
 	}
 
 	normCmd, err := imageprocessing.NewNormalizeOrientationCommandWithParams()
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create NormalizeOrientationCommand: %w", err)
+		return nil, fmt.Errorf("failed to create NormalizeOrientationCommand: %w", err)
 	}
 	preProcessed, err := normCmd.Execute(image)
 	if err != nil {
-		return nil, nil, fmt.Errorf("NormalizeOrientationCommand failed: %w", err)
+		return nil, fmt.Errorf("NormalizeOrientationCommand failed: %w", err)
 	}
 
 	params := map[string]any{}
 	if service.config.SvgFallbackLongSidePixelCount > 0 {
 		params["svgFallbackLongSidePixelCount"] = service.config.SvgFallbackLongSidePixelCount
 	}
+	if svg := service.config.SVG; svg.MaxLongSidePixelCount > 0 {
+		params["svgMaxLongSidePixelCount"] = svg.MaxLongSidePixelCount
+		params["svgMaxElements"] = svg.MaxElements
+		params["svgRenderTimeout"] = svg.RenderTimeout
+	}
 	pngCmd, err := imageprocessing.NewPngConverterCommand(params)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create PNG converter command: %w", err)
+		return nil, fmt.Errorf("failed to create PNG converter command: %w", err)
 	}
 	convertedImageData, err := pngCmd.Execute(preProcessed)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to convert image to PNG: %w", err)
+		return nil, fmt.Errorf("failed to convert image to PNG: %w", err)
+	}
+	return convertedImageData, nil
+}
+
+// processImage applies the configured command pipeline to a converted PNG and,
+// when enabled, draws the attribution overlay. A non-empty fit overrides the
+// fit of ScaleCommand steps, a non-empty flatArt the flat-art mode of
+// DitherCommand steps, and a non-nil crop crops the image before the pipeline.
+func (service *CoreService) processImage(converted []byte, attribution database.Attribution, preset, fit, flatArt string, crop *database.Region) ([]byte, string, error) {
+	return service.processImageWith(service.commandConfigs, converted, attribution, preset, fit, flatArt, crop)
+}
+
+// processImageWith is processImage with the given pipeline instead of the
+// configured one.
+func (service *CoreService) processImageWith(commands []imageprocessing.CommandConfig, converted []byte, attribution database.Attribution, preset, fit, flatArt string, crop *database.Region) ([]byte, string, error) {
+	start := time.Now()
+	service.chaos.SlowPipeline()
+	processed := converted
+	commands = withCrop(commands, crop)
+	if len(commands) == 0 {
+		slog.Debug("CoreService.processImage: no commands configured, using converted image", "bytes", len(converted))
+	} else {
+		slog.Info("CoreService.processImage: executing configured commands", "count", len(commands), "input_size_bytes", len(converted))
+		out, selected, err := imageprocessing.ExecuteCommandsWithPreset(converted, withFlatArt(withFit(withEnhancementPreset(commands, preset), fit), flatArt))
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to apply configured commands: %w", err)
+		}
+		processed, preset = out, selected
 	}
 
-	if len(service.commandConfigs) == 0 {
-		slog.Debug("CoreService.applyPipeline: no commands configured, returning converted image", "bytes", len(convertedImageData))
-		return convertedImageData, convertedImageData, nil
+	if service.config.AttributionOverlay && !attribution.IsZero() {
+		out, err := imageprocessing.DrawAttributionOverlay(processed, attribution.String())
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to draw attribution overlay: %w", err)
+		}
+		processed = out
 	}
+	service.processingTimes.record(time.Now().In(service.tzLoc), time.Since(start))
+	return processed, preset, nil
+}
 
-	slog.Info("CoreService.applyPipeline: executing configured commands", "count", len(service.commandConfigs), "input_size_bytes", len(convertedImageData))
-	out, execErr := imageprocessing.ExecuteCommands(convertedImageData, service.commandConfigs)
-	if execErr != nil {
-		return nil, nil, fmt.Errorf("failed to apply configured commands: %w", execErr)
+// withEnhancementPreset pins AutoEnhanceCommand steps to a previously chosen
+// preset so reprocessing an image reproduces the earlier result. Steps that
+// configure a preset explicitly keep it.
+func withEnhancementPreset(configs []imageprocessing.CommandConfig, preset string) []imageprocessing.CommandConfig {
+	if preset == "" {
+		return configs
+	}
+	pinned := make([]imageprocessing.CommandConfig, len(configs))
+	for i, cfg := range configs {
+		pinned[i] = cfg
+		if cfg.Name != "AutoEnhanceCommand" {
+			continue
+		}
+		if configured := imageprocessing.GetStringParam(cfg.Params, "preset", ""); configured != "" && configured != "auto" {
+			continue
+		}
+		params := make(map[string]any, len(cfg.Params)+1)
+		maps.Copy(params, cfg.Params)
+		params["preset"] = preset
+		pinned[i].Params = params
 	}
-	return convertedImageData, out, nil
+	return pinned
 }
@@ -0,0 +1,342 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+	"github.com/jo-hoe/goframe/internal/imageprocessing"
+)
+
+// patternPNG encodes a w x h image whose pixels are produced by fn. The PNG
+// fixtures of this package's tests are built on it.
+func patternPNG(t *testing.T, w, h int, fn func(x, y int) color.Color) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, fn(x, y))
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("failed to encode test PNG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// testPNG returns a small solid-color PNG.
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	return patternPNG(t, w, h, func(int, int) color.Color { return color.RGBA{R: 10, G: 20, B: 30, A: 255} })
+}
+
+// newTestCoreService returns a CoreService backed by a FakeDatabase.
+func newTestCoreService(t *testing.T, cfg *config.ServiceConfig) (*CoreService, *database.FakeDatabase) {
+	t.Helper()
+	if cfg.Timezone == "" {
+		cfg.Timezone = "UTC"
+	}
+	db := database.NewFakeDatabase("")
+	return newCoreService(cfg, db), db
+}
+
+func TestAddImage_StoredModePersistsProcessed(t *testing.T) {
+	service, db := newTestCoreService(t, &config.ServiceConfig{
+		Commands: []config.CommandConfig{{Name: "ScaleCommand", Params: map[string]any{"height": 4, "width": 4}}},
+	})
+
+	apiImg, err := service.AddImage(context.Background(), testPNG(t, 8, 4), "", database.Attribution{})
+	if err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+
+	if _, err := db.GetImageData(context.Background(), apiImg.ID, "processed"); err != nil {
+		t.Errorf("Expected processed blob to be stored, got %v", err)
+	}
+	if service.ServesProcessedOnDemand() {
+		t.Error("Expected stored mode not to serve processed images on demand")
+	}
+}
+
+func TestAddImage_OnDemandModeGeneratesLazily(t *testing.T) {
+	service, db := newTestCoreService(t, &config.ServiceConfig{
+		Commands: []config.CommandConfig{{Name: "ScaleCommand", Params: map[string]any{"height": 4, "width": 4}}},
+		ProcessedImages: config.ProcessedImages{
+			Mode:            config.ProcessedImageModeOnDemand,
+			CacheTTL:        time.Hour,
+			CacheMaxEntries: 2,
+		},
+	})
+	ctx := context.Background()
+
+	apiImg, err := service.AddImage(ctx, testPNG(t, 8, 4), "", database.Attribution{})
+	if err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+	if _, err := db.GetImageData(ctx, apiImg.ID, "processed"); err == nil {
+		t.Error("Expected no processed blob to be stored in onDemand mode")
+	}
+
+	url, err := service.GetImageURL(ctx, apiImg.ID, "processed")
+	if err != nil {
+		t.Fatalf("GetImageURL failed: %v", err)
+	}
+	if url != "/api/images/"+apiImg.ID+"/processed.png" {
+		t.Errorf("Expected API URL for processed image, got %q", url)
+	}
+
+	for range 2 {
+		data, err := service.GetProcessedImage(ctx, apiImg.ID)
+		if err != nil {
+			t.Fatalf("GetProcessedImage failed: %v", err)
+		}
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("failed to decode processed image: %v", err)
+		}
+		if img.Bounds().Dx() != 4 || img.Bounds().Dy() != 4 {
+			t.Errorf("Expected 4x4 processed image, got %v", img.Bounds())
+		}
+	}
+
+	stats := service.GetMetrics().ProcessedCache
+	if stats == nil {
+		t.Fatal("Expected processed cache metrics in onDemand mode")
+	}
+	if stats.Hits != 1 || stats.Misses != 1 || stats.Entries != 1 {
+		t.Errorf("Expected 1 hit, 1 miss, 1 entry; got %+v", stats)
+	}
+
+	if err := service.DeleteImage(ctx, apiImg.ID); err != nil {
+		t.Fatalf("DeleteImage failed: %v", err)
+	}
+	if stats := service.GetMetrics().ProcessedCache; stats.Entries != 0 {
+		t.Errorf("Expected cache entry to be dropped on delete, got %+v", stats)
+	}
+}
+
+func TestGetContentAddressedURL_ResolvesToStoredBlob(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{})
+	ctx := context.Background()
+
+	apiImg, err := service.AddImage(ctx, testPNG(t, 4, 4), "", database.Attribution{})
+	if err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+
+	url, err := service.GetContentAddressedURL(ctx, apiImg.ID, "processed")
+	if err != nil {
+		t.Fatalf("GetContentAddressedURL failed: %v", err)
+	}
+	hash, ok := strings.CutSuffix(strings.TrimPrefix(url, BlobURLPrefix), ".png")
+	if !ok || len(hash) != 64 {
+		t.Fatalf("Expected content-addressed URL, got %q", url)
+	}
+
+	data, err := service.GetBlobByHash(ctx, hash)
+	if err != nil {
+		t.Fatalf("GetBlobByHash failed: %v", err)
+	}
+	if database.ContentHash(data) != hash {
+		t.Error("Expected blob content to match its hash")
+	}
+
+	if _, err := service.GetBlobByHash(ctx, strings.Repeat("0", 64)); err == nil {
+		t.Error("Expected error for unknown hash")
+	}
+}
+
+func TestGetContentAddressedURL_OnDemandFallsBack(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{
+		ProcessedImages: config.ProcessedImages{
+			Mode:            config.ProcessedImageModeOnDemand,
+			CacheTTL:        time.Hour,
+			CacheMaxEntries: 1,
+		},
+	})
+	ctx := context.Background()
+
+	apiImg, err := service.AddImage(ctx, testPNG(t, 4, 4), "", database.Attribution{})
+	if err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+
+	url, err := service.GetContentAddressedURL(ctx, apiImg.ID, "processed")
+	if err != nil {
+		t.Fatalf("GetContentAddressedURL failed: %v", err)
+	}
+	if want := "/api/images/" + apiImg.ID + "/processed.png"; url != want {
+		t.Errorf("Expected %q, got %q", want, url)
+	}
+}
+
+func TestAddImageFromReader_StoresImage(t *testing.T) {
+	service, db := newTestCoreService(t, &config.ServiceConfig{
+		Uploads: config.Uploads{MemoryBudgetBytes: 1},
+	})
+	ctx := context.Background()
+
+	apiImg, err := service.AddImageFromReader(ctx, bytes.NewReader(testPNG(t, 4, 4)), "", database.Attribution{})
+	if err != nil {
+		t.Fatalf("AddImageFromReader failed: %v", err)
+	}
+	if _, err := db.GetImageData(ctx, apiImg.ID, "original"); err != nil {
+		t.Errorf("Expected original blob to be stored, got %v", err)
+	}
+}
+
+func TestAddImage_NormalizeAtRestStoresArchiveCopy(t *testing.T) {
+	service, db := newTestCoreService(t, &config.ServiceConfig{
+		NormalizeAtRest: config.NormalizeAtRest{Enabled: true, MaxDimension: 8, BitsPerChannel: 8},
+	})
+	ctx := context.Background()
+
+	apiImg, err := service.AddImage(ctx, testPNG(t, 32, 16), "", database.Attribution{})
+	if err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+
+	original, err := db.GetImageData(ctx, apiImg.ID, "original")
+	if err != nil {
+		t.Fatalf("GetImageData failed: %v", err)
+	}
+	cfg, err := png.DecodeConfig(bytes.NewReader(original))
+	if err != nil {
+		t.Fatalf("failed to decode stored original: %v", err)
+	}
+	if cfg.Width != 8 || cfg.Height != 4 {
+		t.Errorf("Expected 8x4 archive copy, got %dx%d", cfg.Width, cfg.Height)
+	}
+
+	processed, err := db.GetImageData(ctx, apiImg.ID, "processed")
+	if err != nil {
+		t.Fatalf("GetImageData failed: %v", err)
+	}
+	if cfg, _ := png.DecodeConfig(bytes.NewReader(processed)); cfg.Width != 32 {
+		t.Errorf("Expected processed image from the full-size original, got width %d", cfg.Width)
+	}
+}
+
+func TestGetCurrentImagePatch(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{})
+	ctx := context.Background()
+
+	if _, err := service.AddImage(ctx, testPNG(t, 4, 4), "", database.Attribution{}); err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+
+	full, err := service.GetCurrentImagePatch(ctx, "", "")
+	if err != nil {
+		t.Fatalf("GetCurrentImagePatch failed: %v", err)
+	}
+	if !full.Full || full.From != "" || len(full.Rects) != 1 {
+		t.Fatalf("Expected full patch without base, got %+v", full)
+	}
+
+	same, err := service.GetCurrentImagePatch(ctx, "", full.To)
+	if err != nil {
+		t.Fatalf("GetCurrentImagePatch failed: %v", err)
+	}
+	if same.Full || same.From != full.To || len(same.Rects) != 0 {
+		t.Errorf("Expected empty patch against the current image, got full=%v from=%q rects=%d", same.Full, same.From, len(same.Rects))
+	}
+}
+
+func TestAddImage_StoresEnhancementPreset(t *testing.T) {
+	service, db := newTestCoreService(t, &config.ServiceConfig{
+		Commands: []config.CommandConfig{{Name: "AutoEnhanceCommand"}},
+	})
+	ctx := context.Background()
+
+	apiImg, err := service.AddImage(ctx, testPNG(t, 8, 4), "", database.Attribution{})
+	if err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+	img, _ := db.GetImageByID(ctx, apiImg.ID)
+	if img.EnhancementPreset != "flat" {
+		t.Errorf("Expected enhancement preset flat for a uniform image, got %q", img.EnhancementPreset)
+	}
+}
+
+func TestWithEnhancementPreset_PinsAutoSteps(t *testing.T) {
+	configs := []imageprocessing.CommandConfig{
+		{Name: "AutoEnhanceCommand", Params: map[string]any{"preset": "auto"}},
+		{Name: "AutoEnhanceCommand", Params: map[string]any{"preset": "graphic"}},
+		{Name: "ScaleCommand", Params: map[string]any{"width": 4}},
+	}
+	pinned := withEnhancementPreset(configs, "soft")
+
+	if got := pinned[0].Params["preset"]; got != "soft" {
+		t.Errorf("Expected auto step pinned to soft, got %v", got)
+	}
+	if got := pinned[1].Params["preset"]; got != "graphic" {
+		t.Errorf("Expected explicit preset to be kept, got %v", got)
+	}
+	if configs[0].Params["preset"] != "auto" {
+		t.Error("Expected configured params to remain unchanged")
+	}
+	if _, ok := pinned[2].Params["preset"]; ok {
+		t.Error("Expected other commands to be left alone")
+	}
+}
+
+func TestNewCoreService_LowMemoryShrinksCaches(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{Runtime: config.Runtime{MemoryLimitMB: 512}})
+	if got := service.matted.snapshot().MaxEntries; got != mattedCacheMaxEntries/4 {
+		t.Errorf("matted cache entries = %d, want %d", got, mattedCacheMaxEntries/4)
+	}
+
+	service, _ = newTestCoreService(t, &config.ServiceConfig{})
+	if got := service.matted.snapshot().MaxEntries; got != mattedCacheMaxEntries {
+		t.Errorf("matted cache entries = %d, want %d", got, mattedCacheMaxEntries)
+	}
+}
+
+func TestReadOnly_PollsAndBackgroundJobsStoreNothing(t *testing.T) {
+	service, db := newTestCoreService(t, &config.ServiceConfig{
+		ReadOnly:          true,
+		Database:          config.Database{CompressOriginals: true},
+		Pregeneration:     config.Pregeneration{Enabled: true, Lead: 24 * time.Hour},
+		GarbageCollection: config.GarbageCollection{Enabled: true, Interval: time.Hour},
+	})
+	ctx := context.Background()
+	ids := addTestImages(t, service, 2)
+	before := db.Fingerprint()
+
+	for _, device := range []string{"", "kitchen"} {
+		id, err := service.GetImageForDevice(ctx, device)
+		if err != nil {
+			t.Fatalf("GetImageForDevice failed: %v", err)
+		}
+		if _, err := service.GetDeviceImageURLAs(ctx, device, id, service.ImageTargetFormat()); err != nil {
+			t.Fatalf("GetDeviceImageURLAs failed: %v", err)
+		}
+	}
+	if _, err := service.GetProcessedImage(ctx, ids[0]); err != nil {
+		t.Fatalf("GetProcessedImage failed: %v", err)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		service.RunOriginalCompressionMigration(ctx)
+		service.RunGarbageCollection(ctx)
+		service.RunPregeneration(ctx)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the background jobs to return at once on a read-only instance")
+	}
+
+	if after := db.Fingerprint(); after != before {
+		t.Errorf("expected a read-only instance to store nothing, state changed from\n%s\nto\n%s", before, after)
+	}
+}
@@ -0,0 +1,140 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/jo-hoe/goframe/internal/database"
+	"github.com/jo-hoe/goframe/internal/imageprocessing"
+)
+
+// ErrInvalidCrop is returned for a region of interest that is empty or
+// reaches beyond the image.
+var ErrInvalidCrop = errors.New("invalid crop")
+
+// CropPreset is an aspect ratio offered when cropping an image: the size of
+// the picture the listed devices show, turned back like the pipeline turns
+// the image so a crop of this aspect ratio fills them exactly.
+type CropPreset struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	// DeviceIDs are the devices showing pictures of this size; "" stands for
+	// devices without a mat of their own.
+	DeviceIDs []string `json:"device_ids"`
+}
+
+// SetImageCrop stores the region of interest image id is cropped to before
+// the pipeline runs and reprocesses it; nil restores the whole image. It
+// fails with ErrImageLocked while another operation modifies the image.
+func (service *CoreService) SetImageCrop(ctx context.Context, id string, crop *database.Region) error {
+	if crop != nil {
+		params := imageprocessing.RegionCropParams{X: crop.X, Y: crop.Y, Width: crop.Width, Height: crop.Height}
+		if err := params.Validate(); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidCrop, err)
+		}
+	}
+	unlock, err := service.locks.tryLock("set crop", id)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	img, err := service.databaseService.GetImageByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrImageNotFound, id)
+	}
+	if (img.Crop == nil) == (crop == nil) && (crop == nil || *img.Crop == *crop) {
+		return nil
+	}
+	if err := service.databaseService.SetCrop(ctx, id, crop); err != nil {
+		return err
+	}
+	detail := "crop removed"
+	if crop != nil {
+		detail = "crop " + cropDetail(crop)
+	}
+	service.recordEvent(ctx, id, database.ImageEvent{Type: database.ImageEventEdited, Detail: detail})
+	processed, err := service.renderReprocessed(ctx, id)
+	if err != nil {
+		return err
+	}
+	return service.storeReprocessed(ctx, id, processed)
+}
+
+// GetCropPresets returns the picture sizes of the panel and of devices whose
+// mat leaves a smaller opening, largest first. Devices showing the same size
+// share a preset.
+func (service *CoreService) GetCropPresets(ctx context.Context) ([]CropPreset, error) {
+	profile, err := imageprocessing.PanelProfileFromCommands(service.commandConfigs)
+	if err != nil {
+		return nil, err
+	}
+	// Mats are drawn onto the pipeline's output, which is turned relative to
+	// the original when the pipeline rotates it.
+	estimate, err := imageprocessing.EstimatePipeline(service.commandConfigs, profile.Width, profile.Height)
+	if err != nil {
+		return nil, err
+	}
+	turns, err := imageprocessing.PipelineQuarterTurns(service.commandConfigs, profile.Width, profile.Height)
+	if err != nil {
+		return nil, err
+	}
+	devices, err := service.profiledDevices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := service.rotationSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	bySize := make(map[[2]int][]string)
+	for _, deviceID := range devices {
+		width, height := estimate.OutputWidth, estimate.OutputHeight
+		if mat, ok := snapshot.matFor(deviceID); ok && mat.Width > 0 {
+			width, height = width-2*mat.Width, height-2*mat.Width
+		}
+		if width <= 0 || height <= 0 {
+			continue
+		}
+		if turns%2 != 0 {
+			width, height = height, width
+		}
+		size := [2]int{width, height}
+		bySize[size] = append(bySize[size], deviceID)
+	}
+
+	presets := make([]CropPreset, 0, len(bySize))
+	for _, size := range slices.SortedFunc(maps.Keys(bySize), func(a, b [2]int) int {
+		if d := b[0]*b[1] - a[0]*a[1]; d != 0 {
+			return d
+		}
+		return b[0] - a[0]
+	}) {
+		presets = append(presets, CropPreset{Width: size[0], Height: size[1], DeviceIDs: bySize[size]})
+	}
+	return presets, nil
+}
+
+// withCrop prepends a RegionCropCommand step cropping to the per-image region
+// of interest.
+func withCrop(configs []imageprocessing.CommandConfig, crop *database.Region) []imageprocessing.CommandConfig {
+	if crop == nil {
+		return configs
+	}
+	step := imageprocessing.CommandConfig{Name: "RegionCropCommand", Params: map[string]any{
+		"x": crop.X, "y": crop.Y, "width": crop.Width, "height": crop.Height,
+	}}
+	return append([]imageprocessing.CommandConfig{step}, configs...)
+}
+
+// cropDetail formats crop for event details and cache keys; it is "" for nil.
+func cropDetail(crop *database.Region) string {
+	if crop == nil {
+		return ""
+	}
+	return fmt.Sprintf("%.4g,%.4g %.4gx%.4g", crop.X, crop.Y, crop.Width, crop.Height)
+}
@@ -0,0 +1,95 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image/png"
+	"slices"
+	"testing"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+func TestSetImageCrop_ReprocessesWithRegion(t *testing.T) {
+	service, db := newTestCoreService(t, &config.ServiceConfig{})
+	ctx := context.Background()
+	apiImg, err := service.AddImage(ctx, testPNG(t, 8, 4), "", database.Attribution{})
+	if err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+	processedWidth := func() int {
+		t.Helper()
+		data, err := db.GetImageData(ctx, apiImg.ID, "processed")
+		if err != nil {
+			t.Fatalf("GetImageData failed: %v", err)
+		}
+		cfg, err := png.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("decoding processed image failed: %v", err)
+		}
+		return cfg.Width
+	}
+
+	crop := &database.Region{X: 0.5, Width: 0.5, Height: 1}
+	if err := service.SetImageCrop(ctx, apiImg.ID, crop); err != nil {
+		t.Fatalf("SetImageCrop failed: %v", err)
+	}
+	if img, _ := db.GetImageByID(ctx, apiImg.ID); img.Crop == nil || *img.Crop != *crop {
+		t.Errorf("expected the crop to be stored, got %+v", img.Crop)
+	}
+	if got := processedWidth(); got != 4 {
+		t.Errorf("expected the processed image to be cropped to 4 pixels, got %d", got)
+	}
+	if err := service.SetImageCrop(ctx, apiImg.ID, nil); err != nil {
+		t.Fatalf("SetImageCrop failed: %v", err)
+	}
+	if got := processedWidth(); got != 8 {
+		t.Errorf("expected removing the crop to restore the whole image, got width %d", got)
+	}
+}
+
+func TestSetImageCrop_Errors(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{})
+	ids := addTestImages(t, service, 1)
+
+	if err := service.SetImageCrop(context.Background(), ids[0], &database.Region{X: 0.6, Width: 0.5, Height: 1}); !errors.Is(err, ErrInvalidCrop) {
+		t.Errorf("expected ErrInvalidCrop, got %v", err)
+	}
+	if err := service.SetImageCrop(context.Background(), "missing", nil); !errors.Is(err, ErrImageNotFound) {
+		t.Errorf("expected ErrImageNotFound, got %v", err)
+	}
+}
+
+func TestGetCropPresets_PanelAndMats(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{
+		Commands: []config.CommandConfig{
+			{Name: "ScaleCommand", Params: map[string]any{"height": 480, "width": 800}},
+			{Name: "RotationCommand", Params: map[string]any{"steps": 1}},
+		},
+	})
+	ctx := context.Background()
+	if err := service.SaveMat(ctx, "kitchen", database.Mat{Color: "#ffffff", Width: 40}); err != nil {
+		t.Fatalf("SaveMat failed: %v", err)
+	}
+	if err := service.SaveMat(ctx, "hall", database.Mat{Color: "#000000", Width: 40}); err != nil {
+		t.Fatalf("SaveMat failed: %v", err)
+	}
+
+	presets, err := service.GetCropPresets(ctx)
+	if err != nil {
+		t.Fatalf("GetCropPresets failed: %v", err)
+	}
+	// The pipeline turns the 800x480 image a quarter after scaling it, so
+	// the panel shows it in portrait and the crops are landscape.
+	want := []CropPreset{
+		{Width: 800, Height: 480, DeviceIDs: []string{""}},
+		{Width: 720, Height: 400, DeviceIDs: []string{"hall", "kitchen"}},
+	}
+	if !slices.EqualFunc(presets, want, func(a, b CropPreset) bool {
+		return a.Width == b.Width && a.Height == b.Height && slices.Equal(a.DeviceIDs, b.DeviceIDs)
+	}) {
+		t.Errorf("expected presets %+v, got %+v", want, presets)
+	}
+}
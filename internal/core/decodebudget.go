@@ -0,0 +1,47 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"io"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// decodeMemoryFactor approximates how many full-size RGBA copies of an image
+// exist at once while it runs through conversion and the pipeline (decoded
+// source, working canvas, encoded output).
+const decodeMemoryFactor = 3
+
+// decodeBudget limits the combined estimated memory of concurrent decodes.
+type decodeBudget struct {
+	capacity int64
+	sem      *semaphore.Weighted
+}
+
+func newDecodeBudget(capacity int64) *decodeBudget {
+	return &decodeBudget{capacity: capacity, sem: semaphore.NewWeighted(capacity)}
+}
+
+// acquire blocks until cost bytes are available or ctx is done. Costs above the
+// total capacity are clamped so that an oversized image runs alone instead of
+// waiting forever.
+func (b *decodeBudget) acquire(ctx context.Context, cost int64) (release func(), err error) {
+	cost = max(1, min(cost, b.capacity))
+	if err := b.sem.Acquire(ctx, cost); err != nil {
+		return nil, fmt.Errorf("waiting for decode memory budget: %w", err)
+	}
+	return func() { b.sem.Release(cost) }, nil
+}
+
+// estimateDecodeCost reads only the image header from r to estimate the memory
+// needed to process it. Formats without a registered header decoder (e.g. SVG)
+// are assumed to rasterize to fallbackLongSide x fallbackLongSide pixels.
+func estimateDecodeCost(r io.Reader, fallbackLongSide int) int64 {
+	w, h := fallbackLongSide, fallbackLongSide
+	if cfg, _, err := image.DecodeConfig(r); err == nil {
+		w, h = cfg.Width, cfg.Height
+	}
+	return int64(w) * int64(h) * 4 * decodeMemoryFactor
+}
@@ -0,0 +1,51 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDecodeBudget_BlocksUntilReleased(t *testing.T) {
+	budget := newDecodeBudget(100)
+
+	release, err := budget.acquire(context.Background(), 80)
+	if err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := budget.acquire(ctx, 30); err == nil {
+		t.Fatal("Expected acquire to block while budget is exhausted")
+	}
+
+	release()
+	release2, err := budget.acquire(context.Background(), 30)
+	if err != nil {
+		t.Fatalf("acquire after release failed: %v", err)
+	}
+	release2()
+}
+
+func TestDecodeBudget_ClampsOversizedCost(t *testing.T) {
+	budget := newDecodeBudget(100)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	release, err := budget.acquire(ctx, 1000)
+	if err != nil {
+		t.Fatalf("Expected oversized cost to be clamped, got %v", err)
+	}
+	release()
+}
+
+func TestEstimateDecodeCost(t *testing.T) {
+	if got, want := estimateDecodeCost(bytes.NewReader(testPNG(t, 10, 20)), 4096), int64(10*20*4*decodeMemoryFactor); got != want {
+		t.Errorf("Expected PNG cost %d, got %d", want, got)
+	}
+	if got, want := estimateDecodeCost(bytes.NewReader([]byte("<svg/>")), 100), int64(100*100*4*decodeMemoryFactor); got != want {
+		t.Errorf("Expected fallback cost %d, got %d", want, got)
+	}
+}
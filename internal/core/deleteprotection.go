@@ -0,0 +1,95 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+// ErrCurrentImageProtected is returned when the configured deletion policy
+// keeps the image currently on the frame from being deleted.
+var ErrCurrentImageProtected = errors.New("image is currently displayed")
+
+// CurrentImageDeletion returns the configured policy for deleting the current image.
+func (service *CoreService) CurrentImageDeletion() string {
+	if service.config.CurrentImageDeletion == "" {
+		return config.CurrentImageDeletionAllow
+	}
+	return service.config.CurrentImageDeletion
+}
+
+// DeleteImageChecked deletes an image on behalf of a user and applies the
+// current image deletion policy. force confirms deleting the current image
+// when the policy is to warn; it has no effect on other policies.
+func (service *CoreService) DeleteImageChecked(ctx context.Context, id string, force bool) error {
+	unlock, err := service.locks.tryLock("delete", id)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	policy := service.CurrentImageDeletion()
+	if policy == config.CurrentImageDeletionAllow {
+		return service.deleteImage(ctx, id)
+	}
+	current, err := service.databaseService.GetCurrentImageID(ctx)
+	if err != nil || current != id {
+		return service.deleteImage(ctx, id)
+	}
+
+	if currentImageProtected(policy, force) {
+		slog.Info("CoreService.DeleteImageChecked: refusing to delete current image", "id", id, "policy", policy)
+		return fmt.Errorf("%w: %s", ErrCurrentImageProtected, id)
+	}
+
+	if err := service.deleteImage(ctx, id); err != nil {
+		return err
+	}
+	if policy == config.CurrentImageDeletionAdvance {
+		service.advanceAfterDelete(ctx, id)
+	}
+	return nil
+}
+
+// currentImageProtected reports whether policy keeps the current image from
+// being deleted.
+func currentImageProtected(policy string, force bool) bool {
+	return policy == config.CurrentImageDeletionBlock ||
+		policy == config.CurrentImageDeletionWarn && !force
+}
+
+// checkDeletable returns the error DeleteImageChecked would fail with for id
+// before anything is deleted: ErrImageNotFound or ErrCurrentImageProtected.
+func (service *CoreService) checkDeletable(ctx context.Context, id string, force bool) error {
+	if _, err := service.databaseService.GetImageByID(ctx, id); errors.Is(err, database.ErrImageNotFound) {
+		return fmt.Errorf("%w: %s", ErrImageNotFound, id)
+	} else if err != nil {
+		return err
+	}
+	if !currentImageProtected(service.CurrentImageDeletion(), force) {
+		return nil
+	}
+	if current, err := service.databaseService.GetCurrentImageID(ctx); err == nil && current == id {
+		return fmt.Errorf("%w: %s", ErrCurrentImageProtected, id)
+	}
+	return nil
+}
+
+// advanceAfterDelete records the image that replaced the deleted current image
+// and notifies the owner, so frames can be refreshed without waiting for the
+// next poll.
+func (service *CoreService) advanceAfterDelete(ctx context.Context, deletedID string) {
+	next, err := service.databaseService.GetCurrentImageID(ctx)
+	if err != nil {
+		slog.Warn("CoreService.advanceAfterDelete: no image left to show", "deleted", deletedID, "error", err)
+		next = ""
+	} else {
+		service.recordDisplay(ctx, next)
+	}
+	slog.Info("CoreService.advanceAfterDelete: advanced rotation after deleting current image", "deleted", deletedID, "current", next)
+	service.alerts.currentImageDeleted(deletedID, next)
+}
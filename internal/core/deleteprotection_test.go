@@ -0,0 +1,77 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+func TestDeleteImageChecked(t *testing.T) {
+	tests := []struct {
+		policy      string
+		force       bool
+		wantBlocked bool
+	}{
+		{policy: "", wantBlocked: false},
+		{policy: config.CurrentImageDeletionBlock, force: true, wantBlocked: true},
+		{policy: config.CurrentImageDeletionWarn, wantBlocked: true},
+		{policy: config.CurrentImageDeletionWarn, force: true, wantBlocked: false},
+		{policy: config.CurrentImageDeletionAdvance, wantBlocked: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			service, db := newTestCoreService(t, &config.ServiceConfig{CurrentImageDeletion: tt.policy})
+			ctx := context.Background()
+			var ids []string
+			for range 3 {
+				img, err := service.AddImage(ctx, testPNG(t, 4, 4), "", database.Attribution{})
+				if err != nil {
+					t.Fatalf("AddImage failed: %v", err)
+				}
+				ids = append(ids, img.ID)
+			}
+
+			// Images other than the current one are never protected.
+			if err := service.DeleteImageChecked(ctx, ids[2], false); err != nil {
+				t.Fatalf("expected non-current image to be deleted, got %v", err)
+			}
+
+			err := service.DeleteImageChecked(ctx, ids[0], tt.force)
+			if blocked := errors.Is(err, ErrCurrentImageProtected); blocked != tt.wantBlocked {
+				t.Fatalf("expected blocked=%v, got error %v", tt.wantBlocked, err)
+			}
+			current, _ := db.GetCurrentImageID(ctx)
+			if tt.wantBlocked && current != ids[0] {
+				t.Errorf("expected %s to stay current, got %s", ids[0], current)
+			}
+			if !tt.wantBlocked && current != ids[1] {
+				t.Errorf("expected %s to become current, got %s", ids[1], current)
+			}
+		})
+	}
+}
+
+func TestDeleteImageChecked_AdvanceNotifies(t *testing.T) {
+	service, db := newTestCoreService(t, &config.ServiceConfig{CurrentImageDeletion: config.CurrentImageDeletionAdvance})
+	n := &recordingNotifier{}
+	service.alerts = newAlerter(config.Notifications{}, alertChannel{notifier: n})
+	ctx := context.Background()
+
+	first, _ := service.AddImage(ctx, testPNG(t, 4, 4), "", database.Attribution{})
+	second, _ := service.AddImage(ctx, testPNG(t, 4, 4), "", database.Attribution{})
+
+	if err := service.DeleteImageChecked(ctx, first.ID, false); err != nil {
+		t.Fatalf("DeleteImageChecked failed: %v", err)
+	}
+	service.alerts.wg.Wait()
+	if n.count() != 1 {
+		t.Errorf("expected one notification, got %d", n.count())
+	}
+	history, _ := db.GetDisplayHistory(ctx)
+	if len(history) != 1 || history[0].ImageID != second.ID {
+		t.Errorf("expected %s recorded as shown, got %+v", second.ID, history)
+	}
+}
@@ -0,0 +1,98 @@
+package core
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+// DemoSource is the source label of the embedded sample images.
+const DemoSource = "demo"
+
+//go:embed demo/*.png
+var demoFS embed.FS
+
+// SeedDemoImages adds the embedded sample images through the pipeline and
+// returns their IDs. Nothing is added while demo images are still stored.
+func (service *CoreService) SeedDemoImages(ctx context.Context) ([]string, error) {
+	existing, err := service.demoImageIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		return nil, nil
+	}
+
+	files, err := fs.Glob(demoFS, "demo/*.png")
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(files))
+	for _, file := range files {
+		data, err := demoFS.ReadFile(file)
+		if err != nil {
+			return ids, err
+		}
+		img, err := service.AddImage(ctx, data, DemoSource, database.Attribution{})
+		if err != nil {
+			return ids, fmt.Errorf("adding demo image %s: %w", file, err)
+		}
+		ids = append(ids, img.ID)
+	}
+	slog.Info("CoreService.SeedDemoImages: added demo images", "count", len(ids))
+	return ids, nil
+}
+
+// SeedDemoImagesIfEmpty seeds the demo images when no image is stored at all,
+// so a new install shows something without duplicating them on every start.
+func (service *CoreService) SeedDemoImagesIfEmpty(ctx context.Context) error {
+	images, err := service.allImages(ctx)
+	if err != nil {
+		return err
+	}
+	if len(images) > 0 {
+		slog.Info("CoreService.SeedDemoImagesIfEmpty: library not empty; skipping demo images")
+		return nil
+	}
+	_, err = service.SeedDemoImages(ctx)
+	return err
+}
+
+// HasDemoImages reports whether any demo image is stored.
+func (service *CoreService) HasDemoImages(ctx context.Context) (bool, error) {
+	ids, err := service.demoImageIDs(ctx)
+	return len(ids) > 0, err
+}
+
+// RemoveDemoImages deletes all demo images and returns how many were removed.
+func (service *CoreService) RemoveDemoImages(ctx context.Context) (int, error) {
+	ids, err := service.demoImageIDs(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for i, id := range ids {
+		if err := service.DeleteImage(ctx, id); err != nil {
+			return i, err
+		}
+	}
+	slog.Info("CoreService.RemoveDemoImages: removed demo images", "count", len(ids))
+	return len(ids), nil
+}
+
+func (service *CoreService) demoImageIDs(ctx context.Context) ([]string, error) {
+	images, err := service.allImages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, img := range images {
+		if img.Source == DemoSource {
+			ids = append(ids, img.ID)
+		}
+	}
+	return ids, nil
+}
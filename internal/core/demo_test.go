@@ -0,0 +1,57 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jo-hoe/goframe/internal/config"
+)
+
+func TestSeedAndRemoveDemoImages(t *testing.T) {
+	service, db := newTestCoreService(t, &config.ServiceConfig{Moderation: config.Moderation{Enabled: true}})
+	ctx := context.Background()
+	own := addTestImages(t, service, 1)[0]
+	if err := service.ApproveImage(ctx, own); err != nil {
+		t.Fatalf("ApproveImage failed: %v", err)
+	}
+
+	ids, err := service.SeedDemoImages(ctx)
+	if err != nil {
+		t.Fatalf("SeedDemoImages failed: %v", err)
+	}
+	if len(ids) == 0 {
+		t.Fatal("expected demo images to be added")
+	}
+	order, _ := db.GetRotationOrderedIDs(ctx)
+	if len(order) != len(ids)+1 {
+		t.Errorf("expected demo images to skip moderation, got order %v", order)
+	}
+	if again, _ := service.SeedDemoImages(ctx); len(again) != 0 {
+		t.Errorf("expected no duplicate demo images, got %v", again)
+	}
+
+	removed, err := service.RemoveDemoImages(ctx)
+	if err != nil || removed != len(ids) {
+		t.Fatalf("RemoveDemoImages = %d, %v; want %d", removed, err, len(ids))
+	}
+	order, _ = db.GetRotationOrderedIDs(ctx)
+	if len(order) != 1 || order[0] != own {
+		t.Errorf("expected only the own image to remain, got %v", order)
+	}
+	if has, _ := service.HasDemoImages(ctx); has {
+		t.Error("expected no demo images after removal")
+	}
+}
+
+func TestSeedDemoImagesIfEmpty(t *testing.T) {
+	service, db := newTestCoreService(t, &config.ServiceConfig{})
+	ctx := context.Background()
+	addTestImages(t, service, 1)
+
+	if err := service.SeedDemoImagesIfEmpty(ctx); err != nil {
+		t.Fatalf("SeedDemoImagesIfEmpty failed: %v", err)
+	}
+	if order, _ := db.GetRotationOrderedIDs(ctx); len(order) != 1 {
+		t.Errorf("expected a non-empty library to stay unchanged, got %v", order)
+	}
+}
@@ -0,0 +1,120 @@
+package core
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+// defaultDeviceAliveWindow is how recently a device must have fetched an
+// image to count as alive when notifications.frameStaleAfter is not set. It
+// covers frames that wake up once a day.
+const defaultDeviceAliveWindow = 25 * time.Hour
+
+// ErrInvalidDevice is returned when a device registration is rejected.
+var ErrInvalidDevice = errors.New("invalid device")
+
+// deviceIDPattern restricts registered device IDs to URL-safe identifiers;
+// colons allow MAC addresses.
+var deviceIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,64}$`)
+
+// ValidDeviceID reports whether id could be registered as a device. Polls
+// with other IDs are rejected, so they do not end up in the device state.
+func ValidDeviceID(id string) bool {
+	return deviceIDPattern.MatchString(id)
+}
+
+// DeviceStatus describes a frame: its registration and what it reported when
+// it last fetched an image. Fetch state is kept in memory and starts empty
+// when the server restarts.
+type DeviceStatus struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+	// Registered is false for devices that fetched images without registering.
+	Registered   bool       `json:"registered"`
+	RegisteredAt *time.Time `json:"registeredAt,omitempty"`
+	// LastSeen is when the device last fetched an image.
+	LastSeen       *time.Time `json:"lastSeen,omitempty"`
+	BatteryPercent *int       `json:"batteryPercent,omitempty"`
+	Firmware       string     `json:"firmware,omitempty"`
+	// Alive reports whether the device fetched an image within the alive
+	// window: notifications.frameStaleAfter, or 25 hours without it.
+	Alive bool `json:"alive"`
+}
+
+// RegisterDevice registers a frame under device.ID, or renames a registered
+// one. The time of the first registration is kept.
+func (service *CoreService) RegisterDevice(ctx context.Context, device database.Device) (database.Device, error) {
+	if !deviceIDPattern.MatchString(device.ID) {
+		return database.Device{}, fmt.Errorf("%w: id %q must be 1-64 letters, digits, '.', '_', ':' or '-'", ErrInvalidDevice, device.ID)
+	}
+	if len(device.Name) > 128 {
+		return database.Device{}, fmt.Errorf("%w: name must be at most 128 characters", ErrInvalidDevice)
+	}
+	devices, err := service.databaseService.GetDevices(ctx)
+	if err != nil {
+		return database.Device{}, err
+	}
+	device.RegisteredAt = service.nowFn()
+	if i := slices.IndexFunc(devices, func(d database.Device) bool { return d.ID == device.ID }); i >= 0 {
+		device.RegisteredAt = devices[i].RegisteredAt
+	}
+	if err := service.databaseService.PutDevice(ctx, device); err != nil {
+		return database.Device{}, err
+	}
+	service.devicePolls.track(device.ID)
+	return device, nil
+}
+
+// GetDevices returns the registered devices and the unregistered ones that
+// fetched an image since the server started, sorted by ID.
+func (service *CoreService) GetDevices(ctx context.Context) ([]DeviceStatus, error) {
+	registered, err := service.databaseService.GetDevices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	states := service.devicePolls.states()
+	aliveSince := service.nowFn().Add(-cmp.Or(service.config.Notifications.FrameStaleAfter, defaultDeviceAliveWindow))
+
+	statuses := make([]DeviceStatus, 0, len(registered)+len(states))
+	seen := make(map[string]bool, len(registered))
+	for _, d := range registered {
+		seen[d.ID] = true
+		service.devicePolls.track(d.ID)
+		status := DeviceStatus{ID: d.ID, Name: d.Name, Registered: true, RegisteredAt: &d.RegisteredAt}
+		statuses = append(statuses, withPollState(status, states[d.ID], aliveSince))
+	}
+	for id, state := range states {
+		if id == "" || seen[id] || state.lastSeen.IsZero() {
+			continue
+		}
+		statuses = append(statuses, withPollState(DeviceStatus{ID: id}, state, aliveSince))
+	}
+	slices.SortFunc(statuses, func(a, b DeviceStatus) int { return strings.Compare(a.ID, b.ID) })
+	return statuses, nil
+}
+
+// withPollState adds what the device reported with its polls to status.
+func withPollState(status DeviceStatus, state devicePollState, aliveSince time.Time) DeviceStatus {
+	if !state.lastSeen.IsZero() {
+		status.LastSeen = &state.lastSeen
+		status.Alive = !state.lastSeen.Before(aliveSince)
+	}
+	if state.hasBattery {
+		status.BatteryPercent = &state.battery
+	}
+	status.Firmware = state.firmware
+	return status
+}
+
+// ReportFirmware records the firmware version a device sent along with a poll.
+func (service *CoreService) ReportFirmware(deviceID, version string) {
+	service.devicePolls.recordFirmware(deviceID, version)
+}
@@ -0,0 +1,112 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+func TestRegisterDevice_KeepsFirstRegistration(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{})
+	ctx := context.Background()
+	first := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	service.nowFn = func() time.Time { return first }
+	if _, err := service.RegisterDevice(ctx, database.Device{ID: "kitchen"}); err != nil {
+		t.Fatalf("RegisterDevice failed: %v", err)
+	}
+
+	service.nowFn = func() time.Time { return first.Add(48 * time.Hour) }
+	device, err := service.RegisterDevice(ctx, database.Device{ID: "kitchen", Name: "Kitchen 7.5in"})
+	if err != nil {
+		t.Fatalf("RegisterDevice failed: %v", err)
+	}
+	if device.Name != "Kitchen 7.5in" || !device.RegisteredAt.Equal(first) {
+		t.Errorf("expected the rename to keep the registration time, got %+v", device)
+	}
+
+	for _, invalid := range []database.Device{{ID: ""}, {ID: "has space"}} {
+		if _, err := service.RegisterDevice(ctx, invalid); !errors.Is(err, ErrInvalidDevice) {
+			t.Errorf("%+v: expected ErrInvalidDevice, got %v", invalid, err)
+		}
+	}
+}
+
+func TestGetDevices_TracksPulls(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{})
+	ctx := context.Background()
+	addTestImages(t, service, 1)
+	if _, err := service.RegisterDevice(ctx, database.Device{ID: "hall"}); err != nil {
+		t.Fatalf("RegisterDevice failed: %v", err)
+	}
+	if _, err := service.RegisterDevice(ctx, database.Device{ID: "attic"}); err != nil {
+		t.Fatalf("RegisterDevice failed: %v", err)
+	}
+
+	service.ReportBattery("hall", 81)
+	service.ReportFirmware("hall", "1.4.2")
+	for _, device := range []string{"hall", "kitchen", ""} {
+		if _, err := service.GetImageForDevice(ctx, device); err != nil {
+			t.Fatalf("GetImageForDevice failed: %v", err)
+		}
+	}
+
+	devices, err := service.GetDevices(ctx)
+	if err != nil {
+		t.Fatalf("GetDevices failed: %v", err)
+	}
+	if len(devices) != 3 || devices[0].ID != "attic" || devices[1].ID != "hall" || devices[2].ID != "kitchen" {
+		t.Fatalf("expected attic, hall and kitchen, got %+v", devices)
+	}
+	if attic := devices[0]; !attic.Registered || attic.Alive || attic.LastSeen != nil {
+		t.Errorf("expected attic registered but never seen, got %+v", attic)
+	}
+	if hall := devices[1]; !hall.Alive || hall.BatteryPercent == nil || *hall.BatteryPercent != 81 || hall.Firmware != "1.4.2" {
+		t.Errorf("expected hall alive with its battery and firmware, got %+v", hall)
+	}
+	if kitchen := devices[2]; kitchen.Registered || !kitchen.Alive {
+		t.Errorf("expected kitchen alive but unregistered, got %+v", kitchen)
+	}
+
+	service.nowFn = func() time.Time { return time.Now().Add(defaultDeviceAliveWindow + time.Minute) }
+	if devices, _ = service.GetDevices(ctx); devices[1].Alive {
+		t.Errorf("expected hall to be silent after the alive window, got %+v", devices[1])
+	}
+}
+
+func TestDevicePolls_BoundsUntrackedDevices(t *testing.T) {
+	var polls devicePolls
+	polls.track("hall")
+	start := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	polls.record("hall", start)
+	polls.record("has space", start)
+	for i := 0; i < 2*maxPolledDevices; i++ {
+		id := fmt.Sprintf("flood-%d", i)
+		polls.record(id, start.Add(time.Duration(i+1)*time.Second))
+		polls.recordBattery(id, 50)
+	}
+
+	states := polls.states()
+	if len(states) > maxPolledDevices {
+		t.Errorf("expected at most %d remembered devices, got %d", maxPolledDevices, len(states))
+	}
+	if _, ok := states["hall"]; !ok {
+		t.Error("expected the tracked device to be kept")
+	}
+	if _, ok := states["flood-0"]; ok {
+		t.Error("expected the least recently seen untracked device to be forgotten")
+	}
+	if _, ok := states[fmt.Sprintf("flood-%d", 2*maxPolledDevices-1)]; !ok {
+		t.Error("expected the latest device to be remembered")
+	}
+	if len(polls.batteries) > maxPolledDevices {
+		t.Errorf("expected at most %d battery levels, got %d", maxPolledDevices, len(polls.batteries))
+	}
+	if counts := polls.daily[start.Format(time.DateOnly)]; counts[""] == 0 || counts["has space"] != 0 {
+		t.Errorf("expected the invalid device to be counted under \"\", got %v", counts[""])
+	}
+}
@@ -0,0 +1,220 @@
+package core
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// statsRetentionDays bounds the in-memory daily counters behind GetStats.
+const statsRetentionDays = 30
+
+// maxPolledDevices bounds the devices whose polls are remembered, so clients
+// inventing device IDs cannot grow memory without limit. When it is reached,
+// the untracked device that has not polled for the longest time is forgotten.
+const maxPolledDevices = 256
+
+// devicePolls remembers when each identified device last fetched its image and
+// counts polls per device and day. Requests without a valid device ID are
+// counted under "".
+type devicePolls struct {
+	mu   sync.Mutex
+	last map[string]time.Time
+	// daily maps "2006-01-02" days to poll counts per device.
+	daily map[string]map[string]int
+	// batteries holds the battery level in percent each device last reported.
+	batteries map[string]int
+	// firmware holds the firmware version each device last reported.
+	firmware map[string]string
+	// tracked holds the registered and grouped devices, which are never
+	// forgotten to make room for others.
+	tracked map[string]bool
+}
+
+// track marks devices as registered or grouped.
+func (d *devicePolls) track(ids ...string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.tracked == nil {
+		d.tracked = make(map[string]bool, len(ids))
+	}
+	for _, id := range ids {
+		d.tracked[id] = true
+	}
+}
+
+// record notes a poll at the given time; the day is taken from at's location.
+func (d *devicePolls) record(deviceID string, at time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.admit(deviceID) {
+		deviceID = ""
+	}
+	if d.daily == nil {
+		d.daily = make(map[string]map[string]int)
+	}
+	day := at.Format(time.DateOnly)
+	if d.daily[day] == nil {
+		d.daily[day] = make(map[string]int)
+		pruneDays(d.daily, at)
+	}
+	if _, ok := d.daily[day][deviceID]; !ok && !d.tracked[deviceID] && len(d.daily[day]) > maxPolledDevices {
+		deviceID = ""
+	}
+	d.daily[day][deviceID]++
+
+	if deviceID == "" {
+		return
+	}
+	if d.last == nil {
+		d.last = make(map[string]time.Time)
+	}
+	d.last[deviceID] = at
+}
+
+// recordBattery notes the battery level the device reported with a poll.
+func (d *devicePolls) recordBattery(deviceID string, percent int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.admit(deviceID) {
+		return
+	}
+	if d.batteries == nil {
+		d.batteries = make(map[string]int)
+	}
+	d.batteries[deviceID] = percent
+}
+
+// battery returns the battery level the device last reported.
+func (d *devicePolls) battery(deviceID string) (percent int, ok bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	percent, ok = d.batteries[deviceID]
+	return percent, ok
+}
+
+// recordFirmware notes the firmware version the device reported with a poll.
+func (d *devicePolls) recordFirmware(deviceID, version string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.admit(deviceID) {
+		return
+	}
+	if d.firmware == nil {
+		d.firmware = make(map[string]string)
+	}
+	d.firmware[deviceID] = version
+}
+
+// admit reports whether state may be kept for deviceID, forgetting the least
+// recently seen untracked device to make room for a new one. d.mu must be held.
+func (d *devicePolls) admit(deviceID string) bool {
+	if !deviceIDPattern.MatchString(deviceID) {
+		return false
+	}
+	_, seen := d.last[deviceID]
+	_, hasBattery := d.batteries[deviceID]
+	_, hasFirmware := d.firmware[deviceID]
+	if seen || hasBattery || hasFirmware {
+		return true
+	}
+	devices := make(map[string]time.Time, len(d.last))
+	for id, at := range d.last {
+		devices[id] = at
+	}
+	for id := range d.batteries {
+		devices[id] = d.last[id]
+	}
+	for id := range d.firmware {
+		devices[id] = d.last[id]
+	}
+	if len(devices) < maxPolledDevices || d.tracked[deviceID] {
+		return true
+	}
+	oldest, oldestAt := "", time.Time{}
+	for id, at := range devices {
+		if d.tracked[id] {
+			continue
+		}
+		if oldest == "" || at.Before(oldestAt) || (at.Equal(oldestAt) && id < oldest) {
+			oldest, oldestAt = id, at
+		}
+	}
+	if oldest == "" {
+		return false
+	}
+	delete(d.last, oldest)
+	delete(d.batteries, oldest)
+	delete(d.firmware, oldest)
+	return true
+}
+
+// devicePollState is what an identified device reported with its polls.
+type devicePollState struct {
+	lastSeen   time.Time
+	battery    int
+	hasBattery bool
+	firmware   string
+}
+
+// states returns the state of every device that polled or reported since the
+// server started, by device ID.
+func (d *devicePolls) states() map[string]devicePollState {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	states := make(map[string]devicePollState, len(d.last))
+	for id, at := range d.last {
+		states[id] = devicePollState{lastSeen: at}
+	}
+	for id, percent := range d.batteries {
+		s := states[id]
+		s.battery, s.hasBattery = percent, true
+		states[id] = s
+	}
+	for id, version := range d.firmware {
+		s := states[id]
+		s.firmware = version
+		states[id] = s
+	}
+	return states
+}
+
+// seenSince returns the sorted IDs of devices that polled at or after t.
+func (d *devicePolls) seenSince(t time.Time) []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	ids := make([]string, 0, len(d.last))
+	for id, at := range d.last {
+		if !at.Before(t) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// history returns the daily poll counts, oldest day first.
+func (d *devicePolls) history() []DevicePollDay {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	days := make([]DevicePollDay, 0, len(d.daily))
+	for day, counts := range d.daily {
+		polls := make(map[string]int, len(counts))
+		for id, n := range counts {
+			polls[id] = n
+		}
+		days = append(days, DevicePollDay{Day: day, Polls: polls})
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Day < days[j].Day })
+	return days
+}
+
+// pruneDays drops entries older than statsRetentionDays before now.
+func pruneDays[V any](days map[string]V, now time.Time) {
+	cutoff := now.AddDate(0, 0, -statsRetentionDays).Format(time.DateOnly)
+	for day := range days {
+		if day < cutoff {
+			delete(days, day)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+// DevicePreview is one distinct rendition of an image: the devices listed
+// receive the same picture at URL.
+type DevicePreview struct {
+	// DeviceIDs are the devices sharing the rendition; "" stands for devices
+	// without a palette, mat or overlay of their own.
+	DeviceIDs []string `json:"device_ids"`
+	URL       string   `json:"url"`
+}
+
+// GetDevicePreviews renders image id for every device profile, i.e. every
+// device with a calibrated palette, its own mat or an overlay, and for devices
+// without one. Renditions are produced concurrently and cached like the ones
+// devices fetch, and devices that would see the same picture share a preview.
+func (service *CoreService) GetDevicePreviews(ctx context.Context, id string) ([]DevicePreview, error) {
+	if _, err := service.databaseService.GetImageByID(ctx, id); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrImageNotFound, id)
+	}
+	devices, err := service.profiledDevices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	urls := make([]string, len(devices))
+	errs := make([]error, len(devices))
+	var wg sync.WaitGroup
+	for i, deviceID := range devices {
+		wg.Go(func() {
+			urls[i], errs[i] = service.GetDeviceImageURL(ctx, deviceID, id)
+		})
+	}
+	wg.Wait()
+
+	var previews []DevicePreview
+	byURL := make(map[string]int, len(devices))
+	for i, deviceID := range devices {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		if j, ok := byURL[urls[i]]; ok {
+			previews[j].DeviceIDs = append(previews[j].DeviceIDs, deviceID)
+			continue
+		}
+		byURL[urls[i]] = len(previews)
+		previews = append(previews, DevicePreview{DeviceIDs: []string{deviceID}, URL: urls[i]})
+	}
+	return previews, nil
+}
+
+// profiledDevices returns "" followed by the sorted IDs of the devices that
+// are rendered differently from it.
+func (service *CoreService) profiledDevices(ctx context.Context) ([]string, error) {
+	snapshot, err := service.rotationSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	devices := make(map[string]bool)
+	for deviceID := range snapshot.palettes {
+		devices[deviceID] = true
+	}
+	for deviceID := range snapshot.mats {
+		devices[deviceID] = true
+	}
+	for _, overlay := range service.config.Overlays {
+		for _, deviceID := range overlay.Devices {
+			devices[deviceID] = true
+		}
+	}
+	delete(devices, database.GlobalMat)
+	return append([]string{""}, slices.Sorted(maps.Keys(devices))...), nil
+}
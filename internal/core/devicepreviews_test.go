@@ -0,0 +1,54 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+func TestGetDevicePreviews_GroupsDevicesByRendition(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{})
+	ctx := context.Background()
+
+	img, err := service.AddImage(ctx, testPNG(t, 8, 8), "", database.Attribution{})
+	if err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+	previews, err := service.GetDevicePreviews(ctx, img.ID)
+	if err != nil {
+		t.Fatalf("GetDevicePreviews failed: %v", err)
+	}
+	if len(previews) != 1 || !slices.Equal(previews[0].DeviceIDs, []string{""}) {
+		t.Fatalf("without profiles got %+v, want only the default preview", previews)
+	}
+
+	for _, deviceID := range []string{"kitchen", "hall"} {
+		if err := service.SaveMat(ctx, deviceID, database.Mat{Color: "#000000", Width: 2}); err != nil {
+			t.Fatalf("SaveMat failed: %v", err)
+		}
+	}
+	previews, err = service.GetDevicePreviews(ctx, img.ID)
+	if err != nil {
+		t.Fatalf("GetDevicePreviews failed: %v", err)
+	}
+	if len(previews) != 2 {
+		t.Fatalf("got %d previews, want 2: %+v", len(previews), previews)
+	}
+	if !slices.Equal(previews[0].DeviceIDs, []string{""}) || !slices.Equal(previews[1].DeviceIDs, []string{"hall", "kitchen"}) {
+		t.Errorf("device groups = %v, %v; want [\"\"], [hall kitchen]", previews[0].DeviceIDs, previews[1].DeviceIDs)
+	}
+	if previews[0].URL == previews[1].URL {
+		t.Errorf("matted and plain previews share URL %q", previews[0].URL)
+	}
+}
+
+func TestGetDevicePreviews_UnknownImage(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{})
+	if _, err := service.GetDevicePreviews(context.Background(), "missing"); !errors.Is(err, ErrImageNotFound) {
+		t.Fatalf("GetDevicePreviews = %v, want ErrImageNotFound", err)
+	}
+}
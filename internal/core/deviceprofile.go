@@ -0,0 +1,80 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/imageprocessing"
+)
+
+const (
+	profiledCacheTTL        = 24 * time.Hour
+	profiledCacheMaxEntries = 32
+)
+
+// ErrDeviceProfileNotFound is returned for a device profile that is not configured.
+var ErrDeviceProfileNotFound = errors.New("device profile not found")
+
+// DeviceProfileNames returns the names of the configured device profiles.
+func (service *CoreService) DeviceProfileNames() []string {
+	names := make([]string, len(service.config.Devices))
+	for i, p := range service.config.Devices {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// GetDeviceProfileImage returns the ID of the image the device profile name
+// shows today and that image rendered with the profile's pipeline. The name
+// is the device ID the profile rotates as, so frame groups apply to it.
+// Renditions are cached until the image is reprocessed or deleted.
+func (service *CoreService) GetDeviceProfileImage(ctx context.Context, name string) (string, []byte, error) {
+	commands, ok := service.deviceProfiles[name]
+	if !ok {
+		return "", nil, fmt.Errorf("%w: %s", ErrDeviceProfileNotFound, name)
+	}
+	id, err := service.GetImageForDevice(ctx, name)
+	if err != nil {
+		return "", nil, err
+	}
+	key := id + "/" + name
+	if data, ok := service.profiled.get(key); ok {
+		return id, data, nil
+	}
+
+	img, err := service.databaseService.GetImageByID(ctx, id)
+	if err != nil {
+		return "", nil, err
+	}
+	original, err := service.databaseService.GetImageData(ctx, id, "original")
+	if err != nil {
+		return "", nil, err
+	}
+	slog.Info("CoreService.GetDeviceProfileImage: rendering image for device profile", "id", id, "profile", name)
+	data, _, err := service.processImageWith(commands, original, img.Attribution, img.EnhancementPreset, img.Fit, img.FlatArt, img.Crop)
+	if err != nil {
+		service.alerts.processingFailed(err)
+		return "", nil, err
+	}
+	service.alerts.processingSucceeded()
+	service.profiled.put(key, data)
+	return id, data, nil
+}
+
+// deviceProfileCommands converts the pipelines of the configured device
+// profiles, keyed by profile name.
+func deviceProfileCommands(devices []config.DeviceProfile) map[string][]imageprocessing.CommandConfig {
+	profiles := make(map[string][]imageprocessing.CommandConfig, len(devices))
+	for _, p := range devices {
+		commands := make([]imageprocessing.CommandConfig, len(p.Commands))
+		for i, c := range p.Commands {
+			commands[i] = imageprocessing.CommandConfig{Name: c.Name, Params: c.Params}
+		}
+		profiles[p.Name] = commands
+	}
+	return profiles
+}
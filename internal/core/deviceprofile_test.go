@@ -0,0 +1,55 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image/png"
+	"testing"
+
+	"github.com/jo-hoe/goframe/internal/config"
+)
+
+func TestGetDeviceProfileImage_UsesProfilePipeline(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{
+		Commands: []config.CommandConfig{{Name: "ScaleCommand", Params: map[string]any{"width": 4, "height": 4}}},
+		Devices: []config.DeviceProfile{
+			{Name: "bw", Commands: []config.CommandConfig{{Name: "ScaleCommand", Params: map[string]any{"width": 8, "height": 6}}}},
+		},
+	})
+	ctx := context.Background()
+	ids := addTestImages(t, service, 2)
+
+	id, data, err := service.GetDeviceProfileImage(ctx, "bw")
+	if err != nil {
+		t.Fatalf("GetDeviceProfileImage failed: %v", err)
+	}
+	if current, _ := service.GetImageForDevice(ctx, "bw"); id != current || (id != ids[0] && id != ids[1]) {
+		t.Errorf("expected the image the device rotates to, got %s", id)
+	}
+	cfg, err := png.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decoding the profile image failed: %v", err)
+	}
+	if cfg.Width != 8 || cfg.Height != 6 {
+		t.Errorf("expected the profile resolution 8x6, got %dx%d", cfg.Width, cfg.Height)
+	}
+	if _, ok := service.profiled.get(id + "/bw"); !ok {
+		t.Error("expected the rendition to be cached")
+	}
+
+	if err := service.ReprocessImage(ctx, id); err != nil {
+		t.Fatalf("ReprocessImage failed: %v", err)
+	}
+	if _, ok := service.profiled.get(id + "/bw"); ok {
+		t.Error("expected reprocessing to drop the cached rendition")
+	}
+}
+
+func TestGetDeviceProfileImage_UnknownProfile(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{})
+	addTestImages(t, service, 1)
+	if _, _, err := service.GetDeviceProfileImage(context.Background(), "missing"); !errors.Is(err, ErrDeviceProfileNotFound) {
+		t.Errorf("expected ErrDeviceProfileNotFound, got %v", err)
+	}
+}
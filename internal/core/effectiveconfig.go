@@ -0,0 +1,36 @@
+package core
+
+import (
+	"context"
+	"slices"
+
+	"github.com/jo-hoe/goframe/internal/config"
+)
+
+// GetEffectiveConfig lists the configuration the server runs with (see
+// config.EffectiveSettings), followed by the calibrated palettes stored per
+// device as "palettes.<device>". Those replace the configured palette for
+// their device, which the config file alone does not show.
+func (service *CoreService) GetEffectiveConfig(ctx context.Context) ([]config.Setting, error) {
+	settings, err := config.EffectiveSettings(service.config)
+	if err != nil {
+		return nil, err
+	}
+	palettes, err := service.GetPalettes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	devices := make([]string, 0, len(palettes))
+	for device := range palettes {
+		devices = append(devices, device)
+	}
+	slices.Sort(devices)
+	for _, device := range devices {
+		settings = append(settings, config.Setting{
+			Key:    "palettes." + device,
+			Value:  palettes[device],
+			Source: config.SourceDatabase,
+		})
+	}
+	return settings, nil
+}
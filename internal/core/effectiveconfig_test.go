@@ -0,0 +1,32 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+func TestGetEffectiveConfig_IncludesStoredPalettes(t *testing.T) {
+	service, db := newTestCoreService(t, calibrationTestConfig())
+	palette := database.Palette{Colors: []database.PaletteColor{{Device: "#000000", Dither: "#101010"}}}
+	if err := db.PutPalette(t.Context(), "kitchen", palette); err != nil {
+		t.Fatalf("PutPalette failed: %v", err)
+	}
+
+	settings, err := service.GetEffectiveConfig(t.Context())
+	if err != nil {
+		t.Fatalf("GetEffectiveConfig failed: %v", err)
+	}
+	last := settings[len(settings)-1]
+	if last.Key != "palettes.kitchen" || last.Source != config.SourceDatabase {
+		t.Errorf("Expected the stored palette last, got %+v", last)
+	}
+	found := false
+	for _, s := range settings {
+		found = found || (s.Key == "commands[0].name" && s.Value == "DitherCommand")
+	}
+	if !found {
+		t.Error("Expected the configured command among the settings")
+	}
+}
@@ -0,0 +1,83 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/imageprocessing"
+)
+
+// maxEstimateSide bounds the width and height estimates are made for.
+const maxEstimateSide = 1 << 17
+
+// ErrInvalidEstimate is returned for image sizes no estimate can be made for.
+var ErrInvalidEstimate = errors.New("invalid estimate")
+
+// ProcessingEstimate is the estimated cost of ingesting an image.
+type ProcessingEstimate struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	// DurationMs is the estimated pipeline runtime in milliseconds.
+	DurationMs float64 `json:"durationMs"`
+	// PeakBytes is the estimated peak memory of converting and processing the image.
+	PeakBytes int64 `json:"peakBytes"`
+	// MemoryBudgetBytes is the configured uploads.memoryBudgetBytes.
+	MemoryBudgetBytes int64 `json:"memoryBudgetBytes"`
+	// ExceedsMemoryBudget is set when the image needs more than the whole
+	// budget; it would be processed alone and may still exhaust the host.
+	ExceedsMemoryBudget bool               `json:"exceedsMemoryBudget"`
+	Steps               []StepCostEstimate `json:"steps"`
+}
+
+// StepCostEstimate is the estimated cost of one pipeline step.
+type StepCostEstimate struct {
+	Command string `json:"command"`
+	// Width and Height are the size of the step's input.
+	Width      int     `json:"width"`
+	Height     int     `json:"height"`
+	DurationMs float64 `json:"durationMs"`
+	PeakBytes  int64   `json:"peakBytes"`
+	// NanosPerPixel and Samples describe the performance model used: runs
+	// recorded on this host, or the built-in default while Samples is 0.
+	NanosPerPixel float64 `json:"nanosPerPixel"`
+	Samples       int     `json:"samples"`
+}
+
+// EstimateProcessing estimates the pipeline runtime and peak memory of an
+// image of width x height pixels without receiving it, so clients can warn
+// before uploading a huge image to a constrained host.
+func (service *CoreService) EstimateProcessing(width, height int) (*ProcessingEstimate, error) {
+	if width <= 0 || height <= 0 || width > maxEstimateSide || height > maxEstimateSide {
+		return nil, fmt.Errorf("%w: width and height must be between 1 and %d", ErrInvalidEstimate, maxEstimateSide)
+	}
+	pipeline, err := imageprocessing.EstimatePipeline(service.commandConfigs, width, height)
+	if err != nil {
+		return nil, err
+	}
+	estimate := &ProcessingEstimate{
+		Width:             width,
+		Height:            height,
+		DurationMs:        durationMs(pipeline.Duration),
+		PeakBytes:         max(pipeline.PeakBytes, int64(width)*int64(height)*4*decodeMemoryFactor),
+		MemoryBudgetBytes: service.config.Uploads.MemoryBudgetBytes,
+		Steps:             make([]StepCostEstimate, len(pipeline.Steps)),
+	}
+	estimate.ExceedsMemoryBudget = estimate.MemoryBudgetBytes > 0 && estimate.PeakBytes > estimate.MemoryBudgetBytes
+	for i, step := range pipeline.Steps {
+		estimate.Steps[i] = StepCostEstimate{
+			Command:       step.Command,
+			Width:         step.Width,
+			Height:        step.Height,
+			DurationMs:    durationMs(step.Duration),
+			PeakBytes:     step.PeakBytes,
+			NanosPerPixel: step.Cost.NanosPerPixel,
+			Samples:       step.Cost.Samples,
+		}
+	}
+	return estimate, nil
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,41 @@
+package core
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jo-hoe/goframe/internal/config"
+)
+
+func TestEstimateProcessing(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{
+		Commands: []config.CommandConfig{{Name: "ScaleCommand", Params: map[string]any{"width": 800, "height": 480}}},
+		Uploads:  config.Uploads{MemoryBudgetBytes: 256 << 20},
+	})
+
+	small, err := service.EstimateProcessing(1200, 900)
+	if err != nil {
+		t.Fatalf("EstimateProcessing failed: %v", err)
+	}
+	if small.ExceedsMemoryBudget || len(small.Steps) != 1 || small.Steps[0].Command != "ScaleCommand" || small.DurationMs <= 0 {
+		t.Errorf("unexpected estimate for a small image: %+v", small)
+	}
+	// Converting dominates: three RGBA copies of the upload.
+	if want := int64(1200 * 900 * 4 * decodeMemoryFactor); small.PeakBytes != want {
+		t.Errorf("expected peak memory %d, got %d", want, small.PeakBytes)
+	}
+
+	huge, err := service.EstimateProcessing(12000, 8500)
+	if err != nil {
+		t.Fatalf("EstimateProcessing failed: %v", err)
+	}
+	if !huge.ExceedsMemoryBudget || huge.DurationMs <= small.DurationMs {
+		t.Errorf("expected a 100 MP image to exceed the budget and take longer, got %+v", huge)
+	}
+
+	for _, size := range [][2]int{{0, 100}, {100, -1}, {maxEstimateSide + 1, 100}} {
+		if _, err := service.EstimateProcessing(size[0], size[1]); !errors.Is(err, ErrInvalidEstimate) {
+			t.Errorf("expected ErrInvalidEstimate for %v, got %v", size, err)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+// pipelineHashLength is the number of hex characters of a pipeline hash.
+const pipelineHashLength = 12
+
+// GetImageEvents returns the lifecycle log of image id, oldest first.
+func (service *CoreService) GetImageEvents(ctx context.Context, id string) ([]database.ImageEvent, error) {
+	if _, err := service.databaseService.GetImageByID(ctx, id); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrImageNotFound, id)
+	}
+	return service.databaseService.GetImageEvents(ctx, id)
+}
+
+// recordEvent appends an event to the log of image id. The log is a debugging
+// aid, so failures are logged instead of failing the operation.
+func (service *CoreService) recordEvent(ctx context.Context, id string, event database.ImageEvent) {
+	event.At = time.Now().In(service.tzLoc)
+	if err := service.databaseService.AddImageEvent(ctx, id, event); err != nil {
+		slog.Warn("CoreService.recordEvent: failed to record image event", "id", id, "type", event.Type, "error", err)
+	}
+}
+
+// recordProcessed logs that image id was rendered with the pipeline resolved
+// for preset, fit, flatArt and crop.
+func (service *CoreService) recordProcessed(ctx context.Context, id, eventType, preset, fit, flatArt string, crop *database.Region) {
+	detail := ""
+	if preset != "" {
+		detail = "preset " + preset
+	}
+	if fit != "" {
+		detail = joinDetail(detail, "fit "+fit)
+	}
+	if flatArt != "" {
+		detail = joinDetail(detail, "flat art "+flatArt)
+	}
+	if crop != nil {
+		detail = joinDetail(detail, "crop "+cropDetail(crop))
+	}
+	service.recordEvent(ctx, id, database.ImageEvent{
+		Type:         eventType,
+		PipelineHash: service.pipelineHash(preset, fit, flatArt, crop),
+		Detail:       detail,
+	})
+	service.runPostProcessHooks(ctx, id, eventType)
+}
+
+// pipelineHash identifies the commands an image with preset, fit, flatArt and
+// crop is processed with; it changes whenever the configuration changes how
+// the image is rendered.
+func (service *CoreService) pipelineHash(preset, fit, flatArt string, crop *database.Region) string {
+	encoded, err := json.Marshal(withCrop(withFlatArt(withFit(withEnhancementPreset(service.commandConfigs, preset), fit), flatArt), crop))
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])[:pipelineHashLength]
+}
+
+func joinDetail(detail, part string) string {
+	if detail == "" {
+		return part
+	}
+	return detail + ", " + part
+}
@@ -0,0 +1,56 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+func TestImageEvents_RecordLifecycle(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{
+		Commands: []config.CommandConfig{{Name: "ScaleCommand", Params: map[string]any{"height": 4, "width": 4}}},
+	})
+	ctx := context.Background()
+	apiImg, err := service.AddImage(ctx, testPNG(t, 8, 4), "upload", database.Attribution{})
+	if err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+	if err := service.SetImageFit(ctx, apiImg.ID, "cover"); err != nil {
+		t.Fatalf("SetImageFit failed: %v", err)
+	}
+	if err := service.SetAltText(ctx, apiImg.ID, "a test image"); err != nil {
+		t.Fatalf("SetAltText failed: %v", err)
+	}
+	service.recordDisplay(ctx, apiImg.ID)
+
+	events, err := service.GetImageEvents(ctx, apiImg.ID)
+	if err != nil {
+		t.Fatalf("GetImageEvents failed: %v", err)
+	}
+	want := []string{
+		database.ImageEventUploaded, database.ImageEventProcessed, database.ImageEventEdited,
+		database.ImageEventReprocessed, database.ImageEventEdited, database.ImageEventDisplayed,
+	}
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %+v", len(want), events)
+	}
+	for i, event := range events {
+		if event.Type != want[i] {
+			t.Errorf("event %d: expected %q, got %q", i, want[i], event.Type)
+		}
+	}
+	processed, reprocessed := events[1], events[3]
+	if len(processed.PipelineHash) != pipelineHashLength || reprocessed.PipelineHash == processed.PipelineHash {
+		t.Errorf("expected the fit change to change the pipeline hash, got %q and %q", processed.PipelineHash, reprocessed.PipelineHash)
+	}
+	if events[0].Detail != "source upload" || reprocessed.Detail != "fit cover" {
+		t.Errorf("unexpected details %q and %q", events[0].Detail, reprocessed.Detail)
+	}
+
+	if _, err := service.GetImageEvents(ctx, "missing"); !errors.Is(err, ErrImageNotFound) {
+		t.Errorf("expected ErrImageNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,184 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/imageprocessing"
+)
+
+// ErrInvalidExperiment is returned for dither variants that cannot be rendered.
+var ErrInvalidExperiment = errors.New("invalid experiment")
+
+const (
+	// experimentCacheTTL and experimentCacheMaxEntries bound the cache of
+	// images rendered up to the dithering step for previews.
+	experimentCacheTTL        = 15 * time.Minute
+	experimentCacheMaxEntries = 8
+
+	// ExperimentPaletteBW dithers to black and white.
+	ExperimentPaletteBW = "bw"
+	// experimentDevicePrefix prefixes the palette names of calibrated devices.
+	experimentDevicePrefix = "device:"
+)
+
+// DitherVariant is one combination of dithering settings to preview.
+type DitherVariant struct {
+	// Algorithm is one of imageprocessing.DitheringAlgorithms.
+	Algorithm string `json:"algorithm"`
+	// Strength scales the diffused error between 0 and 1.
+	Strength float64 `json:"strength"`
+	// Palette is one of the names returned by ExperimentPalettes; empty
+	// keeps the configured palette.
+	Palette string `json:"palette"`
+}
+
+// ExperimentPalettes returns the palettes variants can be dithered with: the
+// configured one (""), black and white, and "device:<id>" for every device
+// whose calibrated palette matches the configured panel.
+func (service *CoreService) ExperimentPalettes(ctx context.Context) ([]string, error) {
+	palettes, err := service.GetPalettes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{"", ExperimentPaletteBW}
+	for _, device := range slices.Sorted(maps.Keys(palettes)) {
+		palette := palettes[device]
+		if _, ok := service.palettePairs(&palette); ok {
+			names = append(names, experimentDevicePrefix+device)
+		}
+	}
+	return names, nil
+}
+
+// PreviewDitherVariant renders image id with the dithering step of the
+// pipeline replaced by variant, without storing anything. The image as it
+// enters the dithering step is cached, so a grid of previews of one image
+// runs the earlier steps once and can be rendered concurrently.
+func (service *CoreService) PreviewDitherVariant(ctx context.Context, id string, variant DitherVariant) ([]byte, error) {
+	commands, err := service.ExperimentCommands(ctx, variant)
+	if err != nil {
+		return nil, err
+	}
+	step := ditherStepIndex(service.commandConfigs)
+	base, err := service.experimentBase(ctx, id, step)
+	if err != nil {
+		return nil, err
+	}
+
+	release, err := service.decodeBudget.acquire(ctx, estimateDecodeCost(bytes.NewReader(base), service.config.SvgFallbackLongSidePixelCount))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	out, err := imageprocessing.ExecuteCommands(base, commands[step:])
+	if err != nil {
+		return nil, fmt.Errorf("rendering preview of %s: %w", id, err)
+	}
+	return out, nil
+}
+
+// ExperimentCommands returns the configured pipeline with its last
+// DitherCommand replaced by variant, or with one appended when the pipeline
+// does not dither, e.g. to be copied into the configuration.
+func (service *CoreService) ExperimentCommands(ctx context.Context, variant DitherVariant) ([]imageprocessing.CommandConfig, error) {
+	if !slices.Contains(imageprocessing.DitheringAlgorithms, variant.Algorithm) {
+		return nil, fmt.Errorf("%w: algorithm must be one of %s", ErrInvalidExperiment, strings.Join(imageprocessing.DitheringAlgorithms, ", "))
+	}
+	if variant.Strength < 0 || variant.Strength > 1 {
+		return nil, fmt.Errorf("%w: strength must be between 0 and 1", ErrInvalidExperiment)
+	}
+
+	commands := slices.Clone(service.commandConfigs)
+	step := ditherStepIndex(commands)
+	params := map[string]any{}
+	if step < len(commands) {
+		maps.Copy(params, commands[step].Params)
+	} else {
+		commands = append(commands, imageprocessing.CommandConfig{Name: "DitherCommand"})
+	}
+	params["ditheringAlgorithm"] = variant.Algorithm
+	params["strength"] = variant.Strength
+
+	switch {
+	case variant.Palette == "":
+	case variant.Palette == ExperimentPaletteBW:
+		delete(params, "palette")
+	case strings.HasPrefix(variant.Palette, experimentDevicePrefix):
+		palettes, err := service.GetPalettes(ctx)
+		if err != nil {
+			return nil, err
+		}
+		palette, ok := palettes[strings.TrimPrefix(variant.Palette, experimentDevicePrefix)]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown palette %s", ErrInvalidExperiment, variant.Palette)
+		}
+		pairs, ok := service.palettePairs(&palette)
+		if !ok {
+			return nil, fmt.Errorf("%w: palette %s does not match the configured panel", ErrInvalidExperiment, variant.Palette)
+		}
+		params = withPalette([]imageprocessing.CommandConfig{{Name: "DitherCommand", Params: params}}, pairs)[0].Params
+	default:
+		return nil, fmt.Errorf("%w: unknown palette %s", ErrInvalidExperiment, variant.Palette)
+	}
+	commands[step] = imageprocessing.CommandConfig{Name: "DitherCommand", Params: params}
+	return commands, nil
+}
+
+// ExperimentConfig renders commands as the commands section of the server
+// configuration.
+func ExperimentConfig(commands []imageprocessing.CommandConfig) []config.CommandConfig {
+	out := make([]config.CommandConfig, len(commands))
+	for i, c := range commands {
+		out[i] = config.CommandConfig{Name: c.Name, Params: c.Params}
+	}
+	return out
+}
+
+// experimentBase returns image id processed by the pipeline steps before
+// step, the dithering step previews replace.
+func (service *CoreService) experimentBase(ctx context.Context, id string, step int) ([]byte, error) {
+	img, err := service.databaseService.GetImageByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrImageNotFound, id)
+	}
+	key := id + "/" + img.Fit + "/" + img.EnhancementPreset + "/" + cropDetail(img.Crop)
+	if data, ok := service.experiments.get(key); ok {
+		return data, nil
+	}
+
+	original, err := service.databaseService.GetImageData(ctx, id, "original")
+	if err != nil {
+		return nil, err
+	}
+	release, err := service.decodeBudget.acquire(ctx, estimateDecodeCost(bytes.NewReader(original), service.config.SvgFallbackLongSidePixelCount))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	commands := withCrop(withFit(withEnhancementPreset(service.commandConfigs[:step], img.EnhancementPreset), img.Fit), img.Crop)
+	data, _, err := imageprocessing.ExecuteCommandsWithPreset(original, commands)
+	if err != nil {
+		return nil, fmt.Errorf("rendering %s for previews: %w", id, err)
+	}
+	service.experiments.put(key, data)
+	return data, nil
+}
+
+// ditherStepIndex returns the index of the last DitherCommand in configs, or
+// len(configs) when there is none.
+func ditherStepIndex(configs []imageprocessing.CommandConfig) int {
+	for i := len(configs) - 1; i >= 0; i-- {
+		if configs[i].Name == "DitherCommand" {
+			return i
+		}
+	}
+	return len(configs)
+}
@@ -0,0 +1,101 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+func TestPreviewDitherVariant_AppliesStrength(t *testing.T) {
+	service, _ := newTestCoreService(t, calibrationTestConfig())
+	ctx := context.Background()
+	added, err := service.AddImage(ctx, grayPNG(t, 16, 16, 128), "", database.Attribution{})
+	if err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+
+	full, err := service.PreviewDitherVariant(ctx, added.ID, DitherVariant{Algorithm: "floyd-steinberg", Strength: 1})
+	if err != nil {
+		t.Fatalf("PreviewDitherVariant failed: %v", err)
+	}
+	none, err := service.PreviewDitherVariant(ctx, added.ID, DitherVariant{Algorithm: "floyd-steinberg", Strength: 0})
+	if err != nil {
+		t.Fatalf("PreviewDitherVariant failed: %v", err)
+	}
+	// Mid gray is slightly nearer to white, so without diffusion no pixel is black.
+	if n := blackPixels(t, none); n != 0 {
+		t.Errorf("expected no black pixels without diffusion, got %d", n)
+	}
+	if n := blackPixels(t, full); n < 100 || n > 156 {
+		t.Errorf("expected about half of the pixels black with full diffusion, got %d", n)
+	}
+}
+
+func TestPreviewDitherVariant_Rejects(t *testing.T) {
+	service, _ := newTestCoreService(t, calibrationTestConfig())
+	ctx := context.Background()
+	added, err := service.AddImage(ctx, grayPNG(t, 8, 8, 128), "", database.Attribution{})
+	if err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+
+	for _, variant := range []DitherVariant{
+		{Algorithm: "bogus", Strength: 1},
+		{Algorithm: "atkinson", Strength: 1.5},
+		{Algorithm: "atkinson", Strength: 1, Palette: "device:unknown"},
+		{Algorithm: "atkinson", Strength: 1, Palette: "sepia"},
+	} {
+		if _, err := service.PreviewDitherVariant(ctx, added.ID, variant); !errors.Is(err, ErrInvalidExperiment) {
+			t.Errorf("expected ErrInvalidExperiment for %+v, got %v", variant, err)
+		}
+	}
+	if _, err := service.PreviewDitherVariant(ctx, "missing", DitherVariant{Algorithm: "atkinson", Strength: 1}); !errors.Is(err, ErrImageNotFound) {
+		t.Errorf("expected ErrImageNotFound, got %v", err)
+	}
+}
+
+func TestExperimentPalettes_ListsCalibratedDevices(t *testing.T) {
+	service, _ := newTestCoreService(t, calibrationTestConfig())
+	ctx := context.Background()
+	if _, err := service.CalibratePalette(ctx, "kitchen", []string{"#202020", "#ffffff"}); err != nil {
+		t.Fatalf("CalibratePalette failed: %v", err)
+	}
+
+	palettes, err := service.ExperimentPalettes(ctx)
+	if err != nil {
+		t.Fatalf("ExperimentPalettes failed: %v", err)
+	}
+	if want := []string{"", ExperimentPaletteBW, "device:kitchen"}; !slices.Equal(palettes, want) {
+		t.Errorf("expected palettes %v, got %v", want, palettes)
+	}
+
+	commands, err := service.ExperimentCommands(ctx, DitherVariant{Algorithm: "atkinson", Strength: 0.5, Palette: "device:kitchen"})
+	if err != nil {
+		t.Fatalf("ExperimentCommands failed: %v", err)
+	}
+	palette, _ := commands[0].Params["palette"].([]any)
+	if len(palette) != 2 || commands[0].Params["ditheringAlgorithm"] != "atkinson" || commands[0].Params["strength"] != 0.5 {
+		t.Errorf("expected the calibrated palette with the chosen settings, got %v", commands[0].Params)
+	}
+}
+
+func TestExperimentCommands_AppendsDitherStep(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{Commands: []config.CommandConfig{
+		{Name: "ScaleCommand", Params: map[string]any{"width": 8, "height": 8}},
+	}})
+
+	commands, err := service.ExperimentCommands(context.Background(), DitherVariant{Algorithm: "floyd-steinberg", Strength: 1, Palette: ExperimentPaletteBW})
+	if err != nil {
+		t.Fatalf("ExperimentCommands failed: %v", err)
+	}
+	if len(commands) != 2 || commands[0].Name != "ScaleCommand" || commands[1].Name != "DitherCommand" {
+		t.Fatalf("expected the dithering step after the configured steps, got %+v", commands)
+	}
+	if _, ok := commands[1].Params["palette"]; ok {
+		t.Errorf("expected the black and white default palette, got %v", commands[1].Params)
+	}
+}
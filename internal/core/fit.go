@@ -0,0 +1,67 @@
+package core
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+
+	"github.com/jo-hoe/goframe/internal/database"
+	"github.com/jo-hoe/goframe/internal/imageprocessing"
+)
+
+// ErrInvalidFit is returned for a fit other than "contain", "cover" or "".
+var ErrInvalidFit = errors.New("invalid fit")
+
+// SetImageFit stores whether image id is letterboxed ("contain") or cropped to
+// fill the frame ("cover") and reprocesses it; "" restores the configured fit.
+// The preference applies to ScaleCommand steps, so it has no effect on
+// pipelines without one. It fails with ErrImageLocked while another operation
+// modifies the image.
+func (service *CoreService) SetImageFit(ctx context.Context, id, fit string) error {
+	if fit != "" && fit != imageprocessing.FitContain && fit != imageprocessing.FitCover {
+		return fmt.Errorf("%w: %q", ErrInvalidFit, fit)
+	}
+	unlock, err := service.locks.tryLock("set fit", id)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	img, err := service.databaseService.GetImageByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrImageNotFound, id)
+	}
+	if img.Fit == fit {
+		return nil
+	}
+	if err := service.databaseService.SetFit(ctx, id, fit); err != nil {
+		return err
+	}
+	service.recordEvent(ctx, id, database.ImageEvent{Type: database.ImageEventEdited, Detail: "fit " + cmp.Or(fit, "default")})
+	processed, err := service.renderReprocessed(ctx, id)
+	if err != nil {
+		return err
+	}
+	return service.storeReprocessed(ctx, id, processed)
+}
+
+// withFit sets the fit of ScaleCommand steps to the per-image preference.
+func withFit(configs []imageprocessing.CommandConfig, fit string) []imageprocessing.CommandConfig {
+	if fit == "" {
+		return configs
+	}
+	fitted := make([]imageprocessing.CommandConfig, len(configs))
+	for i, cfg := range configs {
+		fitted[i] = cfg
+		if cfg.Name != "ScaleCommand" {
+			continue
+		}
+		params := make(map[string]any, len(cfg.Params)+1)
+		maps.Copy(params, cfg.Params)
+		params["fit"] = fit
+		fitted[i].Params = params
+	}
+	return fitted
+}
@@ -0,0 +1,69 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image/png"
+	"testing"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+func TestSetImageFit_ReprocessesWithOverride(t *testing.T) {
+	service, db := newTestCoreService(t, &config.ServiceConfig{
+		Commands: []config.CommandConfig{{Name: "ScaleCommand", Params: map[string]any{"height": 4, "width": 4}}},
+	})
+	ctx := context.Background()
+	apiImg, err := service.AddImage(ctx, testPNG(t, 8, 4), "", database.Attribution{})
+	if err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+	// topIsImage reports whether the top row shows the image rather than the
+	// white letterbox.
+	topIsImage := func() bool {
+		t.Helper()
+		data, err := db.GetImageData(ctx, apiImg.ID, "processed")
+		if err != nil {
+			t.Fatalf("GetImageData failed: %v", err)
+		}
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("decoding processed image failed: %v", err)
+		}
+		r, _, _, _ := img.At(2, 0).RGBA()
+		return r < 0x8000
+	}
+
+	if topIsImage() {
+		t.Fatal("expected the configured contain fit to letterbox the image")
+	}
+	if err := service.SetImageFit(ctx, apiImg.ID, "cover"); err != nil {
+		t.Fatalf("SetImageFit failed: %v", err)
+	}
+	if img, _ := db.GetImageByID(ctx, apiImg.ID); img.Fit != "cover" {
+		t.Errorf("expected fit to be stored, got %q", img.Fit)
+	}
+	if !topIsImage() {
+		t.Error("expected cover to fill the frame")
+	}
+	if err := service.SetImageFit(ctx, apiImg.ID, ""); err != nil {
+		t.Fatalf("SetImageFit failed: %v", err)
+	}
+	if topIsImage() {
+		t.Error("expected clearing the fit to restore the letterbox")
+	}
+}
+
+func TestSetImageFit_Errors(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{})
+	ids := addTestImages(t, service, 1)
+
+	if err := service.SetImageFit(context.Background(), ids[0], "stretch"); !errors.Is(err, ErrInvalidFit) {
+		t.Errorf("expected ErrInvalidFit, got %v", err)
+	}
+	if err := service.SetImageFit(context.Background(), "missing", "cover"); !errors.Is(err, ErrImageNotFound) {
+		t.Errorf("expected ErrImageNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package core
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+
+	"github.com/jo-hoe/goframe/internal/database"
+	"github.com/jo-hoe/goframe/internal/imageprocessing"
+)
+
+// ErrInvalidFlatArt is returned for a flat-art preference other than
+// "always", "never" or "".
+var ErrInvalidFlatArt = errors.New("invalid flat-art preference")
+
+// SetImageFlatArt stores whether image id is flat art that is mapped to the
+// nearest palette colors without error diffusion ("always"), is always
+// diffused ("never"), and reprocesses it; "" restores the configured
+// behavior, including its automatic detection. The preference applies to
+// DitherCommand steps. It fails with ErrImageLocked while another operation
+// modifies the image.
+func (service *CoreService) SetImageFlatArt(ctx context.Context, id, flatArt string) error {
+	if flatArt != "" && flatArt != imageprocessing.FlatArtAlways && flatArt != imageprocessing.FlatArtNever {
+		return fmt.Errorf("%w: %q", ErrInvalidFlatArt, flatArt)
+	}
+	unlock, err := service.locks.tryLock("set flat art", id)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	img, err := service.databaseService.GetImageByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrImageNotFound, id)
+	}
+	if img.FlatArt == flatArt {
+		return nil
+	}
+	if err := service.databaseService.SetFlatArt(ctx, id, flatArt); err != nil {
+		return err
+	}
+	service.recordEvent(ctx, id, database.ImageEvent{Type: database.ImageEventEdited, Detail: "flat art " + cmp.Or(flatArt, "default")})
+	processed, err := service.renderReprocessed(ctx, id)
+	if err != nil {
+		return err
+	}
+	return service.storeReprocessed(ctx, id, processed)
+}
+
+// withFlatArt sets the flat-art mode of DitherCommand steps to the per-image
+// preference.
+func withFlatArt(configs []imageprocessing.CommandConfig, flatArt string) []imageprocessing.CommandConfig {
+	if flatArt == "" {
+		return configs
+	}
+	out := make([]imageprocessing.CommandConfig, len(configs))
+	for i, cfg := range configs {
+		out[i] = cfg
+		if cfg.Name != "DitherCommand" {
+			continue
+		}
+		params := make(map[string]any, len(cfg.Params)+1)
+		maps.Copy(params, cfg.Params)
+		params["flatArt"] = flatArt
+		out[i].Params = params
+	}
+	return out
+}
@@ -0,0 +1,75 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image/png"
+	"testing"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+func TestSetImageFlatArt_ReprocessesWithOverride(t *testing.T) {
+	service, db := newTestCoreService(t, &config.ServiceConfig{
+		Commands: []config.CommandConfig{{Name: "DitherCommand", Params: map[string]any{}}},
+	})
+	ctx := context.Background()
+	apiImg, err := service.AddImage(ctx, grayPNG(t, 8, 8, 100), "", database.Attribution{})
+	if err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+	// diffused reports whether the dark gray was dithered into a pattern
+	// rather than mapped to black.
+	diffused := func() bool {
+		t.Helper()
+		data, err := db.GetImageData(ctx, apiImg.ID, "processed")
+		if err != nil {
+			t.Fatalf("GetImageData failed: %v", err)
+		}
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("decoding processed image failed: %v", err)
+		}
+		for y := range 8 {
+			for x := range 8 {
+				if r, _, _, _ := img.At(x, y).RGBA(); r >= 0x8000 {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	if !diffused() {
+		t.Fatal("expected the configured pipeline to diffuse the error")
+	}
+	if err := service.SetImageFlatArt(ctx, apiImg.ID, "always"); err != nil {
+		t.Fatalf("SetImageFlatArt failed: %v", err)
+	}
+	if img, _ := db.GetImageByID(ctx, apiImg.ID); img.FlatArt != "always" {
+		t.Errorf("expected flat art to be stored, got %q", img.FlatArt)
+	}
+	if diffused() {
+		t.Error("expected flat art to be mapped to the nearest color")
+	}
+	if err := service.SetImageFlatArt(ctx, apiImg.ID, ""); err != nil {
+		t.Fatalf("SetImageFlatArt failed: %v", err)
+	}
+	if !diffused() {
+		t.Error("expected clearing the preference to restore diffusion")
+	}
+}
+
+func TestSetImageFlatArt_Errors(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{})
+	ids := addTestImages(t, service, 1)
+
+	if err := service.SetImageFlatArt(context.Background(), ids[0], "auto"); !errors.Is(err, ErrInvalidFlatArt) {
+		t.Errorf("expected ErrInvalidFlatArt, got %v", err)
+	}
+	if err := service.SetImageFlatArt(context.Background(), "missing", "always"); !errors.Is(err, ErrImageNotFound) {
+		t.Errorf("expected ErrImageNotFound, got %v", err)
+	}
+}
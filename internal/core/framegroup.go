@@ -0,0 +1,111 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+var (
+	// ErrFrameGroupNotFound is returned when a frame group does not exist.
+	ErrFrameGroupNotFound = errors.New("frame group not found")
+	// ErrInvalidFrameGroup is returned when a frame group definition is rejected.
+	ErrInvalidFrameGroup = errors.New("invalid frame group")
+)
+
+// frameGroupNamePattern restricts group names to URL-safe identifiers.
+var frameGroupNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// GetFrameGroups returns all frame groups sorted by name.
+func (service *CoreService) GetFrameGroups(ctx context.Context) ([]database.FrameGroup, error) {
+	return service.databaseService.GetFrameGroups(ctx)
+}
+
+// SaveFrameGroup creates or replaces a frame group. A device may belong to at
+// most one group; the offset must be non-negative.
+func (service *CoreService) SaveFrameGroup(ctx context.Context, group database.FrameGroup) error {
+	if !frameGroupNamePattern.MatchString(group.Name) {
+		return fmt.Errorf("%w: name %q must be 1-64 letters, digits, '.', '_' or '-'", ErrInvalidFrameGroup, group.Name)
+	}
+	if group.Offset < 0 {
+		return fmt.Errorf("%w: offset must not be negative", ErrInvalidFrameGroup)
+	}
+
+	seen := make(map[string]bool, len(group.Devices))
+	for _, device := range group.Devices {
+		if device == "" {
+			return fmt.Errorf("%w: device IDs must not be empty", ErrInvalidFrameGroup)
+		}
+		if seen[device] {
+			return fmt.Errorf("%w: device %q listed twice", ErrInvalidFrameGroup, device)
+		}
+		seen[device] = true
+	}
+
+	groups, err := service.databaseService.GetFrameGroups(ctx)
+	if err != nil {
+		return err
+	}
+	for _, other := range groups {
+		if other.Name == group.Name {
+			continue
+		}
+		for _, device := range group.Devices {
+			if other.HasDevice(device) {
+				return fmt.Errorf("%w: device %q already belongs to group %q", ErrInvalidFrameGroup, device, other.Name)
+			}
+		}
+	}
+	if group.Devices == nil {
+		group.Devices = []string{}
+	}
+	defer service.currentImages.invalidate()
+	return service.databaseService.PutFrameGroup(ctx, group)
+}
+
+// DeleteFrameGroup removes a frame group. Its devices fall back to independent rotation.
+func (service *CoreService) DeleteFrameGroup(ctx context.Context, name string) error {
+	groups, err := service.databaseService.GetFrameGroups(ctx)
+	if err != nil {
+		return err
+	}
+	for _, g := range groups {
+		if g.Name == name {
+			defer service.currentImages.invalidate()
+			return service.databaseService.DeleteFrameGroup(ctx, name)
+		}
+	}
+	return ErrFrameGroupNotFound
+}
+
+// GetImageForDevice returns the image ID the given device should display.
+// Devices in a frame group share the group's pointer into the rotation order,
+// so all members show the same image and advance together. Other devices
+// rotate independently from a stable per-device position. An empty deviceID
+// selects the current image, as for devices that do not identify themselves.
+func (service *CoreService) GetImageForDevice(ctx context.Context, deviceID string) (string, error) {
+	service.alerts.polled(deviceID)
+	service.devicePolls.record(deviceID, time.Now().In(service.tzLoc))
+	snapshot, err := service.rotationSnapshot(ctx)
+	if err != nil {
+		return "", err
+	}
+	id, err := snapshot.imageFor(deviceID)
+	if err != nil {
+		return "", err
+	}
+	service.recordDisplay(ctx, snapshot.ids[0])
+	return id, nil
+}
+
+// deviceOffset derives a stable rotation position for an ungrouped device.
+func deviceOffset(deviceID string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(deviceID))
+	return int(h.Sum32() & 0x7fffffff)
+}
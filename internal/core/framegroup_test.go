@@ -0,0 +1,87 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+func TestGetImageForDevice_GroupMembersShareImage(t *testing.T) {
+	service, db := newTestCoreService(t, &config.ServiceConfig{})
+	ctx := context.Background()
+
+	for range 5 {
+		if _, err := service.AddImage(ctx, testPNG(t, 4, 4), "", database.Attribution{}); err != nil {
+			t.Fatalf("AddImage failed: %v", err)
+		}
+	}
+	if err := service.SaveFrameGroup(ctx, database.FrameGroup{Name: "hall", Devices: []string{"a", "b"}, Offset: 2}); err != nil {
+		t.Fatalf("SaveFrameGroup failed: %v", err)
+	}
+
+	ids, _ := db.GetRotationOrderedIDs(ctx)
+	for _, device := range []string{"a", "b"} {
+		got, err := service.GetImageForDevice(ctx, device)
+		if err != nil {
+			t.Fatalf("GetImageForDevice(%q) failed: %v", device, err)
+		}
+		if got != ids[2] {
+			t.Errorf("device %q: expected %s, got %s", device, ids[2], got)
+		}
+	}
+
+	// Rotating the shared order moves every member to the same next image.
+	if err := db.UpdateOrder(ctx, append(ids[1:], ids[0])); err != nil {
+		t.Fatalf("UpdateOrder failed: %v", err)
+	}
+	a, _ := service.GetImageForDevice(ctx, "a")
+	b, _ := service.GetImageForDevice(ctx, "b")
+	if a != ids[3] || b != ids[3] {
+		t.Errorf("expected both members on %s after rotation, got %s and %s", ids[3], a, b)
+	}
+
+	current, _ := service.GetImageForDevice(ctx, "")
+	if current != ids[1] {
+		t.Errorf("expected anonymous device to get the current image %s, got %s", ids[1], current)
+	}
+}
+
+func TestSaveFrameGroup_Validation(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{})
+	ctx := context.Background()
+
+	if err := service.SaveFrameGroup(ctx, database.FrameGroup{Name: "kitchen", Devices: []string{"a"}}); err != nil {
+		t.Fatalf("SaveFrameGroup failed: %v", err)
+	}
+
+	invalid := []database.FrameGroup{
+		{Name: ""},
+		{Name: "bad name"},
+		{Name: "hall", Offset: -1},
+		{Name: "hall", Devices: []string{"b", "b"}},
+		{Name: "hall", Devices: []string{"a"}},
+	}
+	for _, g := range invalid {
+		if err := service.SaveFrameGroup(ctx, g); !errors.Is(err, ErrInvalidFrameGroup) {
+			t.Errorf("SaveFrameGroup(%+v): expected ErrInvalidFrameGroup, got %v", g, err)
+		}
+	}
+
+	// Replacing a group may keep its own devices.
+	if err := service.SaveFrameGroup(ctx, database.FrameGroup{Name: "kitchen", Devices: []string{"a", "b"}}); err != nil {
+		t.Errorf("expected replacing a group to succeed, got %v", err)
+	}
+
+	if err := service.DeleteFrameGroup(ctx, "missing"); !errors.Is(err, ErrFrameGroupNotFound) {
+		t.Errorf("expected ErrFrameGroupNotFound, got %v", err)
+	}
+	if err := service.DeleteFrameGroup(ctx, "kitchen"); err != nil {
+		t.Errorf("DeleteFrameGroup failed: %v", err)
+	}
+	if groups, _ := service.GetFrameGroups(ctx); len(groups) != 0 {
+		t.Errorf("expected no groups after delete, got %+v", groups)
+	}
+}
@@ -0,0 +1,93 @@
+package core
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+// GarbageReport lists the unreferenced blobs found by a garbage collection.
+type GarbageReport struct {
+	DryRun bool `json:"dryRun"`
+	// Orphans are the unreferenced blobs old enough to be collected.
+	Orphans []database.OrphanedBlob `json:"orphans"`
+	// Bytes is the total size of Orphans.
+	Bytes int64 `json:"bytes"`
+	// Deferred counts unreferenced blobs younger than garbageCollection.minAge;
+	// they may belong to an upload in progress.
+	Deferred int `json:"deferred"`
+	// Deleted counts the removed blobs; it is 0 for dry runs.
+	Deleted int `json:"deleted"`
+}
+
+// CollectGarbage finds stored blobs that no image references, such as leftovers
+// of failed uploads, interrupted deletes or migrations, and deletes them unless
+// dryRun is set. Blobs younger than garbageCollection.minAge are kept.
+func (service *CoreService) CollectGarbage(ctx context.Context, dryRun bool) (*GarbageReport, error) {
+	orphans, err := service.databaseService.FindOrphanedBlobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cutoff := service.nowFn().Add(-service.config.GarbageCollection.MinAge)
+	report := &GarbageReport{DryRun: dryRun, Orphans: []database.OrphanedBlob{}}
+	keys := make([]string, 0, len(orphans))
+	for _, orphan := range orphans {
+		if orphan.LastModified.After(cutoff) {
+			report.Deferred++
+			continue
+		}
+		report.Orphans = append(report.Orphans, orphan)
+		report.Bytes += orphan.Size
+		keys = append(keys, orphan.Key)
+	}
+	if dryRun || len(keys) == 0 {
+		return report, nil
+	}
+
+	deleted, err := service.databaseService.DeleteOrphanedBlobs(ctx, keys)
+	report.Deleted = len(deleted)
+	if err != nil {
+		return report, err
+	}
+	slog.Info("CoreService.CollectGarbage: deleted orphaned blobs", "deleted", report.Deleted, "bytes", report.Bytes, "deferred", report.Deferred)
+	return report, nil
+}
+
+// RecoverStagedUploads removes the leftovers of uploads that were interrupted
+// by a crash, so that every upload is either stored completely or not at all.
+// It must run before uploads are accepted, since it treats every upload staged
+// until now as interrupted. It returns the number of removed uploads.
+func (service *CoreService) RecoverStagedUploads(ctx context.Context) (int, error) {
+	ids, err := service.databaseService.RecoverStagedUploads(ctx, service.nowFn())
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) > 0 {
+		slog.Warn("CoreService.RecoverStagedUploads: removed interrupted uploads", "ids", ids)
+	}
+	return len(ids), nil
+}
+
+// RunGarbageCollection collects unreferenced blobs every
+// garbageCollection.interval until ctx is cancelled. It returns immediately
+// when garbage collection is disabled or the instance is read-only.
+func (service *CoreService) RunGarbageCollection(ctx context.Context) {
+	gc := service.config.GarbageCollection
+	if !gc.Enabled || service.config.ReadOnly {
+		return
+	}
+	ticker := time.NewTicker(gc.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := service.CollectGarbage(ctx, false); err != nil {
+			slog.Error("CoreService.RunGarbageCollection: collection failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/config"
+)
+
+func TestCollectGarbage(t *testing.T) {
+	service, db := newTestCoreService(t, &config.ServiceConfig{})
+	ctx := context.Background()
+	id := addTestImages(t, service, 1)[0]
+	db.PutBlob("images/failed-upload/original.png", []byte("orphan"))
+	db.PutBlob("images/"+id+"/original.png.zst", []byte("old"))
+
+	report, err := service.CollectGarbage(ctx, true)
+	if err != nil {
+		t.Fatalf("CollectGarbage dry run failed: %v", err)
+	}
+	if len(report.Orphans) != 2 || report.Bytes != 9 || report.Deleted != 0 {
+		t.Fatalf("unexpected dry-run report: %+v", report)
+	}
+
+	report, err = service.CollectGarbage(ctx, false)
+	if err != nil {
+		t.Fatalf("CollectGarbage failed: %v", err)
+	}
+	if report.Deleted != 2 {
+		t.Errorf("expected 2 deleted blobs, got %+v", report)
+	}
+	if report, _ := service.CollectGarbage(ctx, true); len(report.Orphans) != 0 {
+		t.Errorf("expected no orphans after collection, got %+v", report.Orphans)
+	}
+	if _, err := db.GetImageData(ctx, id, "original"); err != nil {
+		t.Errorf("expected the referenced original to be kept, got %v", err)
+	}
+}
+
+func TestRecoverStagedUploads(t *testing.T) {
+	service, db := newTestCoreService(t, &config.ServiceConfig{})
+	ctx := context.Background()
+	id := addTestImages(t, service, 1)[0]
+	db.PutBlob("images/crashed/original.png", []byte("partial"))
+	db.StageUpload("crashed", time.Now().Add(-time.Minute))
+	db.StageUpload("later", time.Now().Add(time.Hour))
+
+	if report, _ := service.CollectGarbage(ctx, true); len(report.Orphans) != 0 {
+		t.Fatalf("expected staged blobs not to be collected, got %+v", report.Orphans)
+	}
+	recovered, err := service.RecoverStagedUploads(ctx)
+	if err != nil {
+		t.Fatalf("RecoverStagedUploads failed: %v", err)
+	}
+	if recovered != 1 {
+		t.Errorf("expected 1 recovered upload, got %d", recovered)
+	}
+	if report, _ := service.CollectGarbage(ctx, true); len(report.Orphans) != 0 {
+		t.Errorf("expected the partial blob to be deleted, got %+v", report.Orphans)
+	}
+	if _, err := db.GetImageData(ctx, id, "original"); err != nil {
+		t.Errorf("expected the stored image to be kept, got %v", err)
+	}
+	if recovered, _ := service.RecoverStagedUploads(ctx); recovered != 0 {
+		t.Errorf("expected the later upload to be left alone, got %d recovered", recovered)
+	}
+}
@@ -0,0 +1,110 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/database"
+	"github.com/jo-hoe/goframe/internal/imageprocessing"
+)
+
+const (
+	// maxTimelapseFrames bounds the days rendered into one time-lapse.
+	maxTimelapseFrames = 400
+	// maxTimelapseWidth bounds the time-lapse width in pixels.
+	maxTimelapseWidth = 1024
+)
+
+var (
+	// ErrNoDisplayHistory is returned when no image was recorded for the requested period.
+	ErrNoDisplayHistory = errors.New("no display history for period")
+	// ErrInvalidTimelapse is returned for time-lapse parameters out of range.
+	ErrInvalidTimelapse = errors.New("invalid time-lapse request")
+)
+
+// displayHistory remembers the last record written so polling frames do not
+// rewrite rotation.json on every request.
+type displayHistory struct {
+	mu   sync.Mutex
+	last database.DisplayRecord
+}
+
+// recordDisplay notes id as today's current image in the display history.
+// Read-only instances leave the history to the instance handling writes.
+func (service *CoreService) recordDisplay(ctx context.Context, id string) {
+	if service.config.ReadOnly {
+		return
+	}
+	record := database.DisplayRecord{Day: time.Now().In(service.tzLoc).Format(time.DateOnly), ImageID: id}
+
+	service.history.mu.Lock()
+	defer service.history.mu.Unlock()
+	if service.history.last == record {
+		return
+	}
+	if err := service.databaseService.RecordDisplay(ctx, record.Day, record.ImageID); err != nil {
+		slog.Warn("CoreService.recordDisplay: failed to record display history", "day", record.Day, "id", id, "error", err)
+		return
+	}
+	service.history.last = record
+	service.recordEvent(ctx, id, database.ImageEvent{Type: database.ImageEventDisplayed, Detail: "image of " + record.Day})
+}
+
+// GetDisplayHistory returns the images shown between fromDay and toDay
+// (inclusive, "2006-01-02" in the configured timezone). Empty days leave the
+// range open.
+func (service *CoreService) GetDisplayHistory(ctx context.Context, fromDay, toDay string) ([]database.DisplayRecord, error) {
+	history, err := service.databaseService.GetDisplayHistory(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]database.DisplayRecord, 0, len(history))
+	for _, r := range history {
+		if (fromDay != "" && r.Day < fromDay) || (toDay != "" && r.Day > toDay) {
+			continue
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+// RenderTimelapse renders an animated GIF with one frame per recorded day
+// between fromDay and toDay (see GetDisplayHistory), showing the processed image the frame displayed. Days
+// whose image has since been deleted are skipped. delay is in hundredths of a second.
+func (service *CoreService) RenderTimelapse(ctx context.Context, fromDay, toDay string, width, delay int) ([]byte, error) {
+	if width <= 0 || width > maxTimelapseWidth {
+		return nil, fmt.Errorf("%w: width must be between 1 and %d", ErrInvalidTimelapse, maxTimelapseWidth)
+	}
+	if delay < 0 {
+		return nil, fmt.Errorf("%w: delay must not be negative", ErrInvalidTimelapse)
+	}
+	records, err := service.GetDisplayHistory(ctx, fromDay, toDay)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) > maxTimelapseFrames {
+		return nil, fmt.Errorf("%w: period covers %d days, at most %d are supported", ErrInvalidTimelapse, len(records), maxTimelapseFrames)
+	}
+
+	frames := make([][]byte, 0, len(records))
+	for _, r := range records {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		data, err := service.processedImageData(ctx, r.ImageID)
+		if err != nil {
+			slog.Info("CoreService.RenderTimelapse: skipping unavailable image", "day", r.Day, "id", r.ImageID, "error", err)
+			continue
+		}
+		frames = append(frames, data)
+	}
+	if len(frames) == 0 {
+		return nil, ErrNoDisplayHistory
+	}
+	return imageprocessing.EncodeTimelapseGIF(frames, width, delay)
+}
@@ -0,0 +1,67 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image/gif"
+	"testing"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+func TestDisplayHistoryAndTimelapse(t *testing.T) {
+	service, db := newTestCoreService(t, &config.ServiceConfig{})
+	ctx := context.Background()
+
+	a, err := service.AddImage(ctx, testPNG(t, 8, 4), "", database.Attribution{})
+	if err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+	b, err := service.AddImage(ctx, testPNG(t, 8, 4), "", database.Attribution{})
+	if err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+
+	// Polling records today's image once.
+	for range 3 {
+		if _, err := service.GetImageForDevice(ctx, ""); err != nil {
+			t.Fatalf("GetImageForDevice failed: %v", err)
+		}
+	}
+	history, _ := db.GetDisplayHistory(ctx)
+	if len(history) != 1 || history[0].ImageID != a.ID {
+		t.Fatalf("expected one record for %s, got %+v", a.ID, history)
+	}
+
+	_ = db.RecordDisplay(ctx, "2000-01-01", b.ID)
+	_ = db.RecordDisplay(ctx, "2000-01-02", "deleted")
+
+	records, err := service.GetDisplayHistory(ctx, "2000-01-01", "2000-01-31")
+	if err != nil {
+		t.Fatalf("GetDisplayHistory failed: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records in January 2000, got %+v", records)
+	}
+
+	data, err := service.RenderTimelapse(ctx, "", "", 80, 20)
+	if err != nil {
+		t.Fatalf("RenderTimelapse failed: %v", err)
+	}
+	anim, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to decode time-lapse: %v", err)
+	}
+	if len(anim.Image) != 2 {
+		t.Errorf("expected 2 frames (deleted image skipped), got %d", len(anim.Image))
+	}
+
+	if _, err := service.RenderTimelapse(ctx, "1990-01-01", "1990-02-01", 80, 20); !errors.Is(err, ErrNoDisplayHistory) {
+		t.Errorf("expected ErrNoDisplayHistory, got %v", err)
+	}
+	if _, err := service.RenderTimelapse(ctx, "", "", 0, 20); !errors.Is(err, ErrInvalidTimelapse) {
+		t.Errorf("expected ErrInvalidTimelapse, got %v", err)
+	}
+}
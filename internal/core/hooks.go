@@ -0,0 +1,190 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os/exec"
+	"strings"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+	"github.com/jo-hoe/goframe/internal/resilience"
+)
+
+// ErrUploadRejected is returned when a preUpload hook fails for a new image.
+var ErrUploadRejected = errors.New("upload rejected by hook")
+
+// HookEvent is posted to hook webhooks and written to the standard input of
+// hook commands.
+type HookEvent struct {
+	// Hook is the hook point, e.g. config.HookPreUpload.
+	Hook string `json:"hook"`
+	// ID is empty for preUpload, which runs before the image is stored.
+	ID     string `json:"id,omitempty"`
+	Source string `json:"source,omitempty"`
+	// Device is the device the image is served to; preServe only.
+	Device string `json:"device,omitempty"`
+	// SHA256 is the hex-encoded digest of the upload; preUpload only.
+	SHA256 string `json:"sha256,omitempty"`
+	// Data is the uploaded file as received (base64 encoded in JSON); preUpload only.
+	Data []byte `json:"data,omitempty"`
+	// Event is the image event that triggered postProcess, e.g. "processed".
+	Event string `json:"event,omitempty"`
+	// Metadata is the stored image; postProcess and preServe only.
+	Metadata *database.Image `json:"metadata,omitempty"`
+}
+
+// hooksFor returns the configured hooks for the hook point on.
+func (service *CoreService) hooksFor(on string) []config.Hook {
+	var hooks []config.Hook
+	for _, h := range service.config.Hooks {
+		if h.On == on {
+			hooks = append(hooks, h)
+		}
+	}
+	return hooks
+}
+
+// runPreUploadHooks runs the preUpload hooks for a new image and returns
+// ErrUploadRejected when one of them fails. Demo images and images mirrored
+// from a primary were checked where they came from and skip the hooks.
+func (service *CoreService) runPreUploadHooks(ctx context.Context, data []byte, source string, attribution database.Attribution) error {
+	hooks := service.hooksFor(config.HookPreUpload)
+	if len(hooks) == 0 || source == DemoSource || strings.HasPrefix(source, ReplicaSourcePrefix) {
+		return nil
+	}
+	sum := sha256.Sum256(data)
+	event := HookEvent{
+		Hook:     config.HookPreUpload,
+		Source:   source,
+		SHA256:   hex.EncodeToString(sum[:]),
+		Data:     data,
+		Metadata: &database.Image{Source: source, Attribution: attribution},
+	}
+	for _, h := range hooks {
+		if err := service.runHook(ctx, h, event); err != nil {
+			slog.Info("CoreService.AddImage: upload rejected by hook", "source", source, "sha256", event.SHA256, "hook", hookTarget(h), "error", err)
+			return fmt.Errorf("%w: %v", ErrUploadRejected, err)
+		}
+	}
+	return nil
+}
+
+// runPostProcessHooks runs the postProcess hooks for the image id in the
+// background, so that slow backups or notifications do not hold up uploads.
+func (service *CoreService) runPostProcessHooks(ctx context.Context, id, eventType string) {
+	hooks := service.hooksFor(config.HookPostProcess)
+	if len(hooks) == 0 {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		img, err := service.databaseService.GetImageByID(ctx, id)
+		if err != nil {
+			slog.Warn("CoreService.runPostProcessHooks: failed to load image", "id", id, "error", err)
+			return
+		}
+		event := HookEvent{Hook: config.HookPostProcess, ID: id, Source: img.Source, Event: eventType, Metadata: img}
+		for _, h := range hooks {
+			if err := service.runHook(ctx, h, event); err != nil {
+				slog.Warn("CoreService.runPostProcessHooks: hook failed", "id", id, "hook", hookTarget(h), "error", err)
+			}
+		}
+	}()
+}
+
+// RunPreServeHooks runs the preServe hooks before the image id is served to
+// deviceID. Failures are logged; the frame still gets its image.
+func (service *CoreService) RunPreServeHooks(ctx context.Context, deviceID, id string) {
+	hooks := service.hooksFor(config.HookPreServe)
+	if len(hooks) == 0 {
+		return
+	}
+	img, err := service.databaseService.GetImageByID(ctx, id)
+	if err != nil {
+		slog.Warn("CoreService.RunPreServeHooks: failed to load image", "id", id, "error", err)
+		return
+	}
+	event := HookEvent{Hook: config.HookPreServe, ID: id, Source: img.Source, Device: deviceID, Metadata: img}
+	for _, h := range hooks {
+		if err := service.runHook(ctx, h, event); err != nil {
+			slog.Warn("CoreService.RunPreServeHooks: hook failed", "id", id, "device", deviceID, "hook", hookTarget(h), "error", err)
+		}
+	}
+}
+
+// runHook posts event to the hook's webhook or runs its command with event
+// on standard input, bounded by the hook's timeout. Webhooks of a hook point
+// share the circuit breaker "hook:<point>"; postProcess webhooks run in the
+// background and are retried.
+func (service *CoreService) runHook(ctx context.Context, h config.Hook, event HookEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encoding hook event: %w", err)
+	}
+	if len(h.Command) > 0 {
+		return runHookCommand(ctx, h, body)
+	}
+	breaker := service.integrations.Breaker("hook:" + h.On)
+	call := breaker.Do
+	if h.On == config.HookPostProcess {
+		call = breaker.Retry
+	}
+	return call(ctx, func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, h.Timeout)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+		if err != nil {
+			return resilience.Permanent(fmt.Errorf("building hook request: %w", err))
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return resilience.PermanentForStatus(fmt.Errorf("unexpected status %s", resp.Status), resp.StatusCode)
+		}
+		return nil
+	})
+}
+
+// runHookCommand runs the hook's command without a shell and reports a
+// non-zero exit status with the end of its standard error.
+func runHookCommand(ctx context.Context, h config.Hook, event []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, h.Timeout)
+	defer cancel()
+
+	// #nosec G204 -- hook commands come from the server configuration
+	cmd := exec.CommandContext(ctx, h.Command[0], h.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(event)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			if len(msg) > 256 {
+				msg = msg[len(msg)-256:]
+			}
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// hookTarget describes a hook in logs.
+func hookTarget(h config.Hook) string {
+	if len(h.Command) > 0 {
+		return h.Command[0]
+	}
+	return h.URL
+}
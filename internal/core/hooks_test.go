@@ -0,0 +1,89 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+func TestPreUploadHook_RejectsUpload(t *testing.T) {
+	var received HookEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		if received.Source == "email" {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	service, db := newTestCoreService(t, &config.ServiceConfig{
+		Hooks: []config.Hook{{On: config.HookPreUpload, URL: server.URL, Timeout: time.Second}},
+	})
+	ctx := context.Background()
+	upload := testPNG(t, 4, 4)
+
+	if _, err := service.AddImage(ctx, upload, "email", database.Attribution{}); !errors.Is(err, ErrUploadRejected) {
+		t.Fatalf("expected ErrUploadRejected, got %v", err)
+	}
+	if ids, _ := db.GetRotationOrderedIDs(ctx); len(ids) != 0 {
+		t.Errorf("expected the rejected upload not to be stored, got %v", ids)
+	}
+	if received.Hook != config.HookPreUpload || len(received.SHA256) != 64 || len(received.Data) != len(upload) {
+		t.Errorf("expected the upload and its digest in the event, got hook %q sha256 %q and %d bytes", received.Hook, received.SHA256, len(received.Data))
+	}
+
+	if _, err := service.AddImage(ctx, upload, "xkcd", database.Attribution{}); err != nil {
+		t.Errorf("expected the accepted upload to be stored, got %v", err)
+	}
+}
+
+func TestPostProcessAndPreServeHooks_RunCommands(t *testing.T) {
+	dir := t.TempDir()
+	postProcess := make(chan HookEvent, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event HookEvent
+		_ = json.NewDecoder(r.Body).Decode(&event)
+		postProcess <- event
+	}))
+	defer server.Close()
+
+	served := filepath.Join(dir, "served.json")
+	service, _ := newTestCoreService(t, &config.ServiceConfig{
+		Hooks: []config.Hook{
+			{On: config.HookPostProcess, URL: server.URL, Timeout: time.Second},
+			{On: config.HookPreServe, Command: []string{"sh", "-c", "cat > " + served}, Timeout: 5 * time.Second},
+		},
+	})
+	ids := addTestImages(t, service, 1)
+
+	select {
+	case event := <-postProcess:
+		if event.ID != ids[0] || event.Event != database.ImageEventProcessed || event.Metadata == nil || event.Metadata.ID != ids[0] {
+			t.Errorf("unexpected postProcess event %+v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the postProcess hook to be called")
+	}
+
+	service.RunPreServeHooks(context.Background(), "kitchen", ids[0])
+	data, err := os.ReadFile(served)
+	if err != nil {
+		t.Fatalf("expected the preServe command to run: %v", err)
+	}
+	var event HookEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		t.Fatalf("decoding the preServe event failed: %v", err)
+	}
+	if event.Hook != config.HookPreServe || event.ID != ids[0] || event.Device != "kitchen" {
+		t.Errorf("unexpected preServe event %+v", event)
+	}
+}
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"image/png"
+	"io"
+	"log/slog"
+
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+// GetImageDetails returns the metadata of image id. Renditions stored before
+// their dimensions and sizes were recorded are measured from the blobs, so
+// the result is complete for older images too; UpdatedAt stays unknown.
+func (service *CoreService) GetImageDetails(ctx context.Context, id string) (*database.Image, error) {
+	img, err := service.databaseService.GetImageByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrImageNotFound, id)
+	}
+	if img.Original == (database.Rendition{}) {
+		img.Original = service.measureRendition(ctx, id, "original")
+	}
+	if img.Processed == (database.Rendition{}) && img.ProcessedHash != "" {
+		img.Processed = service.measureRendition(ctx, id, "processed")
+	}
+	return img, nil
+}
+
+// measureRendition streams a stored blob to read its dimensions and size. It
+// returns a zero Rendition when the blob cannot be read.
+func (service *CoreService) measureRendition(ctx context.Context, id, variant string) database.Rendition {
+	body, err := service.databaseService.OpenImageData(ctx, id, variant)
+	if err != nil {
+		slog.Debug("measureRendition: blob unavailable", "imageId", id, "variant", variant, "error", err)
+		return database.Rendition{}
+	}
+	defer func() { _ = body.Close() }()
+
+	counter := &countingReader{r: body}
+	br := bufio.NewReader(counter)
+	var r database.Rendition
+	if cfg, err := png.DecodeConfig(br); err == nil {
+		r.Width, r.Height = cfg.Width, cfg.Height
+	}
+	if _, err := io.Copy(io.Discard, br); err != nil {
+		slog.Debug("measureRendition: reading blob failed", "imageId", id, "variant", variant, "error", err)
+		return database.Rendition{}
+	}
+	r.Bytes = counter.n
+	return r
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
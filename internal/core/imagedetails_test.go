@@ -0,0 +1,37 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+func TestGetImageDetails_MeasuresOlderImages(t *testing.T) {
+	service, db := newTestCoreService(t, &config.ServiceConfig{})
+	ctx := context.Background()
+	original, processed := testPNG(t, 12, 9), testPNG(t, 4, 3)
+	id, err := db.CreateImage(ctx, original, processed, time.Now(), "", database.Attribution{}, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.ClearRenditions(id)
+
+	img, err := service.GetImageDetails(ctx, id)
+	if err != nil {
+		t.Fatalf("GetImageDetails failed: %v", err)
+	}
+	if img.Original != (database.Rendition{Width: 12, Height: 9, Bytes: int64(len(original))}) {
+		t.Errorf("unexpected original rendition %+v", img.Original)
+	}
+	if img.Processed != (database.Rendition{Width: 4, Height: 3, Bytes: int64(len(processed))}) {
+		t.Errorf("unexpected processed rendition %+v", img.Processed)
+	}
+
+	if _, err := service.GetImageDetails(ctx, "missing"); !errors.Is(err, ErrImageNotFound) {
+		t.Errorf("expected ErrImageNotFound, got %v", err)
+	}
+}
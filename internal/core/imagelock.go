@@ -0,0 +1,45 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrImageLocked is returned when another operation is modifying the same image.
+var ErrImageLocked = errors.New("image is being modified")
+
+// imageLocks serializes mutating operations per image. Locks are advisory and
+// never wait: a conflicting operation fails with ErrImageLocked instead of
+// acting on an image another operation is about to change or delete.
+type imageLocks struct {
+	mu sync.Mutex
+	// held maps locked image IDs to the operation holding the lock.
+	held map[string]string
+}
+
+// tryLock locks all ids for op, or none of them if one is already locked.
+// The returned function releases the locks.
+func (l *imageLocks) tryLock(op string, ids ...string) (unlock func(), err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, id := range ids {
+		if holder, ok := l.held[id]; ok {
+			return nil, fmt.Errorf("%w: %s (%s in progress)", ErrImageLocked, id, holder)
+		}
+	}
+	if l.held == nil {
+		l.held = make(map[string]string)
+	}
+	for _, id := range ids {
+		l.held[id] = op
+	}
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		for _, id := range ids {
+			delete(l.held, id)
+		}
+	}, nil
+}
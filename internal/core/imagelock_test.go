@@ -0,0 +1,65 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jo-hoe/goframe/internal/config"
+)
+
+func TestImageLocks_TryLock(t *testing.T) {
+	var locks imageLocks
+	unlock, err := locks.tryLock("reprocess", "a", "b")
+	if err != nil {
+		t.Fatalf("tryLock failed: %v", err)
+	}
+
+	if _, err := locks.tryLock("delete", "b", "c"); !errors.Is(err, ErrImageLocked) {
+		t.Fatalf("expected ErrImageLocked, got %v", err)
+	}
+	// The failed attempt must not have locked c.
+	unlockC, err := locks.tryLock("delete", "c")
+	if err != nil {
+		t.Fatalf("expected c to be unlocked, got %v", err)
+	}
+	unlockC()
+
+	unlock()
+	if _, err := locks.tryLock("delete", "a"); err != nil {
+		t.Fatalf("expected a to be unlocked after release, got %v", err)
+	}
+}
+
+func TestImageLocks_ConflictingOperations(t *testing.T) {
+	service, db := newTestCoreService(t, &config.ServiceConfig{})
+	ctx := context.Background()
+	ids := addTestImages(t, service, 2)
+
+	unlock, err := service.locks.tryLock("reprocess", ids[0])
+	if err != nil {
+		t.Fatalf("tryLock failed: %v", err)
+	}
+	defer unlock()
+
+	if err := service.DeleteImageChecked(ctx, ids[0], false); !errors.Is(err, ErrImageLocked) {
+		t.Errorf("expected delete to fail with ErrImageLocked, got %v", err)
+	}
+	if _, err := db.GetImageByID(ctx, ids[0]); err != nil {
+		t.Errorf("expected locked image to survive, got %v", err)
+	}
+	if err := service.ReprocessImage(ctx, ids[0]); !errors.Is(err, ErrImageLocked) {
+		t.Errorf("expected reprocess to fail with ErrImageLocked, got %v", err)
+	}
+	if err := service.ArchiveImages(ctx, ids); !errors.Is(err, ErrImageLocked) {
+		t.Errorf("expected archive to fail with ErrImageLocked, got %v", err)
+	}
+
+	result := service.BulkDeleteImages(ctx, ids, false, BulkBestEffort)
+	if got := []BulkOutcome{result.Items[0].Outcome, result.Items[1].Outcome}; got[0] != BulkFailed || got[1] != BulkSucceeded {
+		t.Fatalf("expected only the locked image to fail, got %v", got)
+	}
+	if !errors.Is(result.Items[0].Err, ErrImageLocked) {
+		t.Errorf("expected ErrImageLocked for the locked image, got %v", result.Items[0].Err)
+	}
+}
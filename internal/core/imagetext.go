@@ -0,0 +1,68 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+// Maximum title and description lengths in characters.
+const (
+	maxTitleLength       = 200
+	maxDescriptionLength = 2000
+)
+
+// ErrInvalidImageText is returned for a title or description that is too long.
+var ErrInvalidImageText = errors.New("invalid image text")
+
+// ImageTextUpdate changes the title and description of an image; nil fields
+// are left as they are and empty strings clear them.
+type ImageTextUpdate struct {
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+}
+
+// UpdateImageText applies update to image id and returns the updated image.
+// Surrounding whitespace is trimmed. It fails with ErrImageLocked while
+// another operation modifies the image.
+func (service *CoreService) UpdateImageText(ctx context.Context, id string, update ImageTextUpdate) (*database.Image, error) {
+	unlock, err := service.locks.tryLock("update text", id)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	img, err := service.databaseService.GetImageByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrImageNotFound, id)
+	}
+	title, description := img.Title, img.Description
+	if update.Title != nil {
+		title = strings.TrimSpace(*update.Title)
+	}
+	if update.Description != nil {
+		description = strings.TrimSpace(*update.Description)
+	}
+	if n := utf8.RuneCountInString(title); n > maxTitleLength {
+		return nil, fmt.Errorf("%w: the title has %d characters, the limit is %d", ErrInvalidImageText, n, maxTitleLength)
+	}
+	if n := utf8.RuneCountInString(description); n > maxDescriptionLength {
+		return nil, fmt.Errorf("%w: the description has %d characters, the limit is %d", ErrInvalidImageText, n, maxDescriptionLength)
+	}
+	if title == img.Title && description == img.Description {
+		return img, nil
+	}
+
+	if err := service.databaseService.SetImageText(ctx, id, title, description); err != nil {
+		return nil, err
+	}
+	// Text overlay widgets may show the old text.
+	service.currentImages.invalidate()
+	service.recordEvent(ctx, id, database.ImageEvent{Type: database.ImageEventEdited, Detail: "title and description"})
+	img.Title, img.Description = title, description
+	return img, nil
+}
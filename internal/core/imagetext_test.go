@@ -0,0 +1,69 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/imageprocessing"
+)
+
+func TestUpdateImageText(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{})
+	ctx := context.Background()
+	ids := addTestImages(t, service, 1)
+	title, description := "  Harbour at dawn ", "Taken from the pier."
+
+	img, err := service.UpdateImageText(ctx, ids[0], ImageTextUpdate{Title: &title, Description: &description})
+	if err != nil {
+		t.Fatalf("UpdateImageText failed: %v", err)
+	}
+	if img.Title != "Harbour at dawn" || img.Description != description {
+		t.Errorf("unexpected text %q / %q", img.Title, img.Description)
+	}
+
+	// Omitted fields are kept, empty ones cleared.
+	empty := ""
+	if _, err := service.UpdateImageText(ctx, ids[0], ImageTextUpdate{Title: &empty}); err != nil {
+		t.Fatalf("UpdateImageText failed: %v", err)
+	}
+	stored, _ := service.GetImageById(ctx, ids[0])
+	if stored.Title != "" || stored.Description != description {
+		t.Errorf("expected only the title to be cleared, got %q / %q", stored.Title, stored.Description)
+	}
+
+	long := strings.Repeat("x", maxTitleLength+1)
+	if _, err := service.UpdateImageText(ctx, ids[0], ImageTextUpdate{Title: &long}); !errors.Is(err, ErrInvalidImageText) {
+		t.Errorf("expected ErrInvalidImageText, got %v", err)
+	}
+	if _, err := service.UpdateImageText(ctx, "missing", ImageTextUpdate{Title: &title}); !errors.Is(err, ErrImageNotFound) {
+		t.Errorf("expected ErrImageNotFound, got %v", err)
+	}
+}
+
+func TestOverlayItems_TextWidget(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{})
+	ctx := context.Background()
+	ids := addTestImages(t, service, 1)
+	overlay := &config.Overlay{Widgets: []config.OverlayWidget{
+		{Type: config.OverlayWidgetText, Template: "{{.Title}}"},
+		{Type: config.OverlayWidgetText, Template: "{{.Description}}"},
+	}}
+
+	if items := service.overlayItems(ctx, "", ids[0], overlay, service.nowFn()); len(items) != 0 {
+		t.Errorf("expected no items without text, got %+v", items)
+	}
+	title, description := "Harbour", strings.Repeat("word ", 30)
+	if _, err := service.UpdateImageText(ctx, ids[0], ImageTextUpdate{Title: &title, Description: &description}); err != nil {
+		t.Fatalf("UpdateImageText failed: %v", err)
+	}
+	items := service.overlayItems(ctx, "", ids[0], overlay, service.nowFn())
+	if len(items) != 2 || items[0] != (imageprocessing.OverlayItem{Text: "Harbour"}) {
+		t.Fatalf("unexpected items %+v", items)
+	}
+	if len(items[1].Text) != maxOverlayTextLength || !strings.HasSuffix(items[1].Text, "...") {
+		t.Errorf("expected the description to be cut short, got %q", items[1].Text)
+	}
+}
@@ -0,0 +1,58 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// imageWaitRecheckInterval bounds how late WaitForImageChange notices changes
+// that are not made through this server, such as the operator's rotation.
+const imageWaitRecheckInterval = 10 * time.Second
+
+// DeviceImage identifies the image a device should show.
+type DeviceImage struct {
+	ID string
+	// Tag is the hash of the PNG the device fetches, which its blob URL
+	// serves as ETag, or that PNG's URL for images without a stored hash.
+	Tag string
+}
+
+// CurrentDeviceImage returns the image the device should show; see
+// GetImageForDevice and GetDeviceImageURL.
+func (service *CoreService) CurrentDeviceImage(ctx context.Context, deviceID string) (DeviceImage, error) {
+	id, err := service.GetImageForDevice(ctx, deviceID)
+	if err != nil {
+		return DeviceImage{}, err
+	}
+	url, err := service.GetDeviceImageURL(ctx, deviceID, id)
+	if err != nil {
+		return DeviceImage{}, err
+	}
+	if file, ok := strings.CutPrefix(url, BlobURLPrefix); ok {
+		return DeviceImage{ID: id, Tag: strings.TrimSuffix(file, FormatPNG.Extension())}, nil
+	}
+	return DeviceImage{ID: id, Tag: url}, nil
+}
+
+// WaitForImageChange blocks until the image the device should show no longer
+// has the tag since, or until ctx is done, and returns the current image.
+// changed is false when ctx ended first. Changes made through this server wake
+// it at once; others are noticed within imageWaitRecheckInterval.
+func (service *CoreService) WaitForImageChange(ctx context.Context, deviceID, since string) (image DeviceImage, changed bool, err error) {
+	ticker := time.NewTicker(imageWaitRecheckInterval)
+	defer ticker.Stop()
+	for {
+		// Taken before the lookup, so a change made during it is not missed.
+		changes := service.currentImages.changes()
+		if image, err = service.CurrentDeviceImage(ctx, deviceID); err != nil || image.Tag != since {
+			return image, err == nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return image, false, nil
+		case <-changes:
+		case <-ticker.C:
+		}
+	}
+}
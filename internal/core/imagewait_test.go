@@ -0,0 +1,47 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+func TestWaitForImageChange(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{})
+	ctx := context.Background()
+	var ids []string
+	for _, width := range []int{4, 6} {
+		img, err := service.AddImage(ctx, testPNG(t, width, 4), "", database.Attribution{})
+		if err != nil {
+			t.Fatalf("AddImage failed: %v", err)
+		}
+		ids = append(ids, img.ID)
+	}
+	current, err := service.CurrentDeviceImage(ctx, "")
+	if err != nil {
+		t.Fatalf("CurrentDeviceImage failed: %v", err)
+	}
+	if current.ID != ids[0] || len(current.Tag) != 64 {
+		t.Fatalf("expected the first image tagged with its hash, got %+v", current)
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if image, changed, err := service.WaitForImageChange(timeout, "", current.Tag); err != nil || changed || image != current {
+		t.Errorf("expected no change before the timeout, got %+v, %v, %v", image, changed, err)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_ = service.UpdateImageOrder(ctx, []string{ids[1], ids[0]})
+	}()
+	waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	image, changed, err := service.WaitForImageChange(waitCtx, "", current.Tag)
+	if err != nil || !changed || image.ID != ids[1] {
+		t.Errorf("expected the reorder to end the wait with the second image, got %+v, %v, %v", image, changed, err)
+	}
+}
@@ -0,0 +1,98 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/imageprocessing"
+)
+
+// ImportOrder selects the order in which the images of a bulk upload or a
+// directory import are added, and so the order they join the rotation in.
+type ImportOrder string
+
+const (
+	// ImportOrderAsGiven adds images in the order of the request (default).
+	ImportOrderAsGiven ImportOrder = "given"
+	// ImportOrderFilename sorts by file name, ignoring case and directories.
+	ImportOrderFilename ImportOrder = "filename"
+	// ImportOrderDateAsc sorts by capture date, oldest first.
+	ImportOrderDateAsc ImportOrder = "date"
+	// ImportOrderDateDesc sorts by capture date, newest first.
+	ImportOrderDateDesc ImportOrder = "date-desc"
+	// ImportOrderShuffle adds images in random order.
+	ImportOrderShuffle ImportOrder = "shuffle"
+)
+
+// exifScanBytes is how much of a file is read to find its EXIF capture date;
+// the EXIF segment is limited to 64 KiB and comes first.
+const exifScanBytes = 128 << 10
+
+// ErrInvalidImportOrder is returned by ParseImportOrder for unknown orders.
+var ErrInvalidImportOrder = errors.New("invalid import order")
+
+// ParseImportOrder parses an import order; "" selects ImportOrderAsGiven.
+func ParseImportOrder(s string) (ImportOrder, error) {
+	switch order := ImportOrder(strings.ToLower(strings.TrimSpace(s))); order {
+	case "":
+		return ImportOrderAsGiven, nil
+	case ImportOrderAsGiven, ImportOrderFilename, ImportOrderDateAsc, ImportOrderDateDesc, ImportOrderShuffle:
+		return order, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrInvalidImportOrder, s)
+}
+
+// importSequence returns the indices of names in the order to add them.
+// captureTime is only called for the date orders; items without a date follow
+// the dated ones sorted by file name.
+func importSequence(order ImportOrder, names []string, captureTime func(i int) (time.Time, bool)) []int {
+	seq := make([]int, len(names))
+	for i := range seq {
+		seq[i] = i
+	}
+	byName := func(a, b int) int {
+		return strings.Compare(strings.ToLower(path.Base(names[a])), strings.ToLower(path.Base(names[b])))
+	}
+
+	switch order {
+	case ImportOrderFilename:
+		slices.SortStableFunc(seq, byName)
+	case ImportOrderDateAsc, ImportOrderDateDesc:
+		times := make([]time.Time, len(names))
+		for i := range names {
+			times[i], _ = captureTime(i)
+		}
+		slices.SortStableFunc(seq, func(a, b int) int {
+			switch ta, tb := times[a], times[b]; {
+			case ta.IsZero() && tb.IsZero():
+				return byName(a, b)
+			case ta.IsZero():
+				return 1
+			case tb.IsZero():
+				return -1
+			case order == ImportOrderDateDesc:
+				return tb.Compare(ta)
+			default:
+				return ta.Compare(tb)
+			}
+		})
+	case ImportOrderShuffle:
+		rand.Shuffle(len(seq), func(i, j int) { seq[i], seq[j] = seq[j], seq[i] })
+	}
+	return seq
+}
+
+// readCaptureTime returns the EXIF capture date of the image r holds.
+func readCaptureTime(r io.Reader) (time.Time, bool) {
+	head, err := io.ReadAll(io.LimitReader(r, exifScanBytes))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return imageprocessing.ReadJPEGCaptureTime(head)
+}
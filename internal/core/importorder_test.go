@@ -0,0 +1,72 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+func TestImportSequence(t *testing.T) {
+	names := []string{"b/IMG_2.jpg", "a/img_3.jpg", "IMG_1.jpg", "undated.jpg"}
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 0, 0, 0, 0, time.UTC) }
+	dates := []time.Time{day(3), day(1), day(2), {}}
+	captureTime := func(i int) (time.Time, bool) { return dates[i], !dates[i].IsZero() }
+
+	tests := []struct {
+		order ImportOrder
+		want  []int
+	}{
+		{ImportOrderAsGiven, []int{0, 1, 2, 3}},
+		{ImportOrderFilename, []int{2, 0, 1, 3}},
+		{ImportOrderDateAsc, []int{1, 2, 0, 3}},
+		{ImportOrderDateDesc, []int{0, 2, 1, 3}},
+	}
+	for _, tt := range tests {
+		if got := importSequence(tt.order, names, captureTime); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.order, tt.want, got)
+		}
+	}
+
+	shuffled := importSequence(ImportOrderShuffle, names, captureTime)
+	slices.Sort(shuffled)
+	if !slices.Equal(shuffled, []int{0, 1, 2, 3}) {
+		t.Errorf("expected a permutation, got %v", shuffled)
+	}
+}
+
+func TestParseImportOrder(t *testing.T) {
+	if order, err := ParseImportOrder(""); err != nil || order != ImportOrderAsGiven {
+		t.Errorf("expected the default order, got %q (%v)", order, err)
+	}
+	if order, err := ParseImportOrder(" Date-Desc "); err != nil || order != ImportOrderDateDesc {
+		t.Errorf("expected date-desc, got %q (%v)", order, err)
+	}
+	if _, err := ParseImportOrder("random"); !errors.Is(err, ErrInvalidImportOrder) {
+		t.Errorf("expected ErrInvalidImportOrder, got %v", err)
+	}
+}
+
+func TestBulkAddImages_OrdersRotation(t *testing.T) {
+	service, db := newTestCoreService(t, &config.ServiceConfig{})
+	ctx := context.Background()
+	uploads := []BulkUpload{
+		testUpload("c.png", testPNG(t, 4, 4)),
+		testUpload("a.png", testPNG(t, 5, 5)),
+		testUpload("b.png", testPNG(t, 6, 6)),
+	}
+
+	result := service.BulkAddImages(ctx, uploads, "", database.Attribution{}, BulkBestEffort, ImportOrderFilename)
+	if result.Items[0].Name != "c.png" {
+		t.Fatalf("expected results in request order, got %+v", result.Items)
+	}
+	stored, _ := db.GetRotationOrderedIDs(ctx)
+	want := []string{result.Items[1].ID, result.Items[2].ID, result.Items[0].ID}
+	if !slices.Equal(stored, want) {
+		t.Errorf("expected rotation order a, b, c, got %v (want %v)", stored, want)
+	}
+}
@@ -0,0 +1,10 @@
+package core
+
+import "github.com/jo-hoe/goframe/internal/resilience"
+
+// GetIntegrationHealth reports the circuit state of every external service
+// called so far: the moderation, captioning and hook webhooks, the weather
+// API, notification channels, the replication primary and each image source.
+func (service *CoreService) GetIntegrationHealth() []resilience.Health {
+	return service.integrations.Health()
+}
@@ -0,0 +1,170 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+	"github.com/jo-hoe/goframe/internal/imageprocessing"
+)
+
+// ErrInvalidMat is returned when a mat definition is rejected.
+var ErrInvalidMat = errors.New("invalid mat")
+
+const (
+	// maxMatSize bounds the border width and corner radius in pixels.
+	maxMatSize = 1000
+	// mattedCacheTTL and mattedCacheMaxEntries bound the cache of matted renditions.
+	mattedCacheTTL        = 24 * time.Hour
+	mattedCacheMaxEntries = 64
+)
+
+// GetMats returns the configured mats keyed by device ID; database.GlobalMat
+// holds the default for devices without their own.
+func (service *CoreService) GetMats(ctx context.Context) (map[string]database.Mat, error) {
+	mats, err := service.databaseService.GetMats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if mats == nil {
+		mats = map[string]database.Mat{}
+	}
+	return mats, nil
+}
+
+// SaveMat creates or replaces the mat for deviceID (database.GlobalMat for all
+// devices without their own).
+func (service *CoreService) SaveMat(ctx context.Context, deviceID string, mat database.Mat) error {
+	if _, err := imageprocessing.ParseHexColor(mat.Color); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMat, err)
+	}
+	if mat.Width < 0 || mat.Width > maxMatSize {
+		return fmt.Errorf("%w: width must be between 0 and %d", ErrInvalidMat, maxMatSize)
+	}
+	if mat.CornerRadius < 0 || mat.CornerRadius > maxMatSize {
+		return fmt.Errorf("%w: corner radius must be between 0 and %d", ErrInvalidMat, maxMatSize)
+	}
+	defer service.currentImages.invalidate()
+	return service.databaseService.PutMat(ctx, deviceID, mat)
+}
+
+// DeleteMat removes the mat for deviceID; the device falls back to the global mat.
+func (service *CoreService) DeleteMat(ctx context.Context, deviceID string) error {
+	defer service.currentImages.invalidate()
+	return service.databaseService.DeleteMat(ctx, deviceID)
+}
+
+// matForDevice returns the mat the device should show: its own, else the
+// global one. ok is false when no mat applies.
+func (service *CoreService) matForDevice(ctx context.Context, deviceID string) (mat database.Mat, ok bool, err error) {
+	snapshot, err := service.rotationSnapshot(ctx)
+	if err != nil {
+		return database.Mat{}, false, err
+	}
+	mat, ok = snapshot.matFor(deviceID)
+	return mat, ok, nil
+}
+
+// GetDeviceImageURL returns the URL the device should fetch for image id. With
+// a calibrated palette, mat or overlay configured it points to the device's
+// rendition, which is rendered here and kept in memory; otherwise it is
+// GetContentAddressedURL. Concurrent requests for the same rendition share one
+// lookup.
+func (service *CoreService) GetDeviceImageURL(ctx context.Context, deviceID, id string) (string, error) {
+	mat, matted, err := service.matForDevice(ctx, deviceID)
+	if err != nil {
+		return "", err
+	}
+	palette, err := service.paletteForDevice(ctx, deviceID)
+	if err != nil {
+		return "", err
+	}
+	overlay, overlaid := service.overlayForDevice(deviceID)
+	if !overlaid {
+		overlay = nil
+	}
+	ctx = context.WithoutCancel(ctx)
+	url, err := service.currentImages.do(renditionKey(deviceID, id, palette, mat, matted, overlay), func() (any, error) {
+		return service.deviceImageURL(ctx, deviceID, id, palette, mat, matted, overlay)
+	})
+	if err != nil {
+		return "", err
+	}
+	return url.(string), nil
+}
+
+// deviceImageURL renders the rendition for GetDeviceImageURL; palette and
+// overlay are nil when the device has none.
+func (service *CoreService) deviceImageURL(ctx context.Context, deviceID, id string, palette *database.Palette, mat database.Mat, matted bool, overlay *config.Overlay) (string, error) {
+	if palette == nil && !matted && overlay == nil {
+		return service.GetContentAddressedURL(ctx, id, "processed")
+	}
+	var data []byte
+	var err error
+	if matted {
+		data, err = service.mattedImage(ctx, id, palette, mat)
+	} else {
+		data, err = service.deviceBaseImage(ctx, id, palette)
+	}
+	if err != nil {
+		return "", err
+	}
+	if overlay != nil {
+		if data, err = service.compositeOverlay(ctx, deviceID, id, overlay, data); err != nil {
+			return "", err
+		}
+	}
+	return BlobURLPrefix + database.ContentHash(data) + ".png", nil
+}
+
+// applyDeviceMat composites the device's mat, if any, onto the processed image
+// id, which is the calibratedID when the device has a palette.
+func (service *CoreService) applyDeviceMat(ctx context.Context, deviceID, id string, processed []byte) ([]byte, error) {
+	mat, ok, err := service.matForDevice(ctx, deviceID)
+	if err != nil || !ok {
+		return processed, err
+	}
+	return service.mattedImageFrom(id, mat, processed)
+}
+
+// mattedImage returns the processed image id, dithered with palette if not
+// nil, with mat applied.
+func (service *CoreService) mattedImage(ctx context.Context, id string, palette *database.Palette, mat database.Mat) ([]byte, error) {
+	if data, ok := service.matted.get(mattedKey(calibratedID(id, palette), mat)); ok {
+		service.matted.put(database.ContentHash(data), data)
+		return data, nil
+	}
+	processed, err := service.deviceBaseImage(ctx, id, palette)
+	if err != nil {
+		return nil, err
+	}
+	return service.mattedImageFrom(calibratedID(id, palette), mat, processed)
+}
+
+// mattedImageFrom renders mat onto processed, the processed image id. Results
+// are cached both by image and mat (ReprocessImage drops an image's entries)
+// and by content hash, so the blob URL handed to devices can be served.
+func (service *CoreService) mattedImageFrom(id string, mat database.Mat, processed []byte) ([]byte, error) {
+	key := mattedKey(id, mat)
+	data, ok := service.matted.get(key)
+	if !ok {
+		c, err := imageprocessing.ParseHexColor(mat.Color)
+		if err != nil {
+			return nil, err
+		}
+		data, err = imageprocessing.DrawMat(processed, c, mat.Width, mat.CornerRadius)
+		if err != nil {
+			return nil, err
+		}
+		service.matted.put(key, data)
+	}
+	service.matted.put(database.ContentHash(data), data)
+	return data, nil
+}
+
+func mattedKey(id string, mat database.Mat) string {
+	return fmt.Sprintf("%s/%s/%d/%d", id, mat.Color, mat.Width, mat.CornerRadius)
+}
@@ -0,0 +1,82 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+func TestSaveMat_Validates(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{})
+	ctx := context.Background()
+
+	for _, mat := range []database.Mat{
+		{Color: "white", Width: 10},
+		{Color: "#ffffff", Width: -1},
+		{Color: "#ffffff", CornerRadius: maxMatSize + 1},
+	} {
+		if err := service.SaveMat(ctx, "", mat); !errors.Is(err, ErrInvalidMat) {
+			t.Errorf("SaveMat(%+v) = %v, want ErrInvalidMat", mat, err)
+		}
+	}
+	if err := service.SaveMat(ctx, "", database.Mat{Color: "#ffffff", Width: 2}); err != nil {
+		t.Fatalf("SaveMat failed: %v", err)
+	}
+	mats, err := service.GetMats(ctx)
+	if err != nil || len(mats) != 1 {
+		t.Fatalf("GetMats = %v, %v; want one mat", mats, err)
+	}
+}
+
+func TestGetDeviceImageURL_ServesMattedBlob(t *testing.T) {
+	service, db := newTestCoreService(t, &config.ServiceConfig{})
+	ctx := context.Background()
+
+	img, err := service.AddImage(ctx, testPNG(t, 8, 8), "", database.Attribution{})
+	if err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+	plain, err := service.GetDeviceImageURL(ctx, "hall", img.ID)
+	if err != nil {
+		t.Fatalf("GetDeviceImageURL failed: %v", err)
+	}
+
+	if err := service.SaveMat(ctx, database.GlobalMat, database.Mat{Color: "#000000", Width: 2}); err != nil {
+		t.Fatalf("SaveMat failed: %v", err)
+	}
+	matted, err := service.GetDeviceImageURL(ctx, "hall", img.ID)
+	if err != nil {
+		t.Fatalf("GetDeviceImageURL failed: %v", err)
+	}
+	if matted == plain || !strings.HasPrefix(matted, BlobURLPrefix) {
+		t.Fatalf("expected a different blob URL with a mat, got %q (plain %q)", matted, plain)
+	}
+	hash := strings.TrimSuffix(strings.TrimPrefix(matted, BlobURLPrefix), ".png")
+	data, err := service.GetBlobByHash(ctx, hash)
+	if err != nil {
+		t.Fatalf("GetBlobByHash failed for matted image: %v", err)
+	}
+	processed, _ := db.GetImageData(ctx, img.ID, "processed")
+	if database.ContentHash(data) != hash || database.ContentHash(processed) == hash {
+		t.Error("expected the blob to be the matted rendition")
+	}
+
+	// A device-specific mat with zero size disables the global one.
+	if err := service.SaveMat(ctx, "hall", database.Mat{Color: "#000000"}); err != nil {
+		t.Fatalf("SaveMat failed: %v", err)
+	}
+	if got, _ := service.GetDeviceImageURL(ctx, "hall", img.ID); got != plain {
+		t.Errorf("expected device mat to override the global one, got %q", got)
+	}
+	patch, err := service.GetCurrentImagePatch(ctx, "kitchen", "")
+	if err != nil {
+		t.Fatalf("GetCurrentImagePatch failed: %v", err)
+	}
+	if patch.To != hash {
+		t.Errorf("expected patch to target the matted image, got %q", patch.To)
+	}
+}
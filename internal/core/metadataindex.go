@@ -0,0 +1,219 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+// indexedDatabase keeps an in-memory index of the rotation order and the
+// metadata of the images in it, so hot read paths such as listings, schedules
+// and device polls do not read rotation.json every time. Writes go straight
+// through to the wrapped database and invalidate the index; it is reloaded on
+// the next read. Changes made by other writers, e.g. the operator's daily
+// rotation or another instance sharing the storage, show up once the ttl has
+// passed.
+//
+// Every method that changes image metadata or the order must invalidate the
+// index; methods that only touch blobs, groups, mats or the history pass
+// through unchanged.
+type indexedDatabase struct {
+	database.DatabaseService
+	index *coalescer
+}
+
+// metadataIndex is the indexed state. It is shared between readers and must
+// not be modified; accessors return copies.
+type metadataIndex struct {
+	ids  []string
+	byID map[string]*database.Image
+	// byHash maps the digests of the stored blobs to their image and variant.
+	byHash map[string]blobRef
+}
+
+// blobRef identifies a stored blob.
+type blobRef struct {
+	id      string
+	variant string
+}
+
+func newIndexedDatabase(db database.DatabaseService, ttl time.Duration) *indexedDatabase {
+	return &indexedDatabase{DatabaseService: db, index: newCoalescer(ttl)}
+}
+
+func (d *indexedDatabase) load(ctx context.Context) (*metadataIndex, error) {
+	// The load is shared, so one caller giving up must not fail the others.
+	ctx = context.WithoutCancel(ctx)
+	value, err := d.index.do("metadata", func() (any, error) {
+		images, err := d.DatabaseService.GetImageMetadata(ctx)
+		if err != nil {
+			return nil, err
+		}
+		idx := &metadataIndex{
+			ids:    make([]string, len(images)),
+			byID:   make(map[string]*database.Image, len(images)),
+			byHash: make(map[string]blobRef, 2*len(images)),
+		}
+		for i, img := range images {
+			idx.ids[i] = img.ID
+			idx.byID[img.ID] = img
+			for _, variant := range []string{"processed", "original"} {
+				if hash := img.Hash(variant); hash != "" {
+					if _, ok := idx.byHash[hash]; !ok {
+						idx.byHash[hash] = blobRef{id: img.ID, variant: variant}
+					}
+				}
+			}
+		}
+		return idx, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*metadataIndex), nil
+}
+
+func (d *indexedDatabase) GetImageMetadata(ctx context.Context) ([]*database.Image, error) {
+	idx, err := d.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	images := make([]*database.Image, len(idx.ids))
+	for i, id := range idx.ids {
+		img := *idx.byID[id]
+		images[i] = &img
+	}
+	return images, nil
+}
+
+func (d *indexedDatabase) GetRotationOrderedIDs(ctx context.Context) ([]string, error) {
+	idx, err := d.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return slices.Clone(idx.ids), nil
+}
+
+func (d *indexedDatabase) GetCurrentImageID(ctx context.Context) (string, error) {
+	idx, err := d.load(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(idx.ids) == 0 {
+		return "", fmt.Errorf("no images")
+	}
+	return idx.ids[0], nil
+}
+
+// GetImageByID answers from the index for images in the rotation; archived
+// and pending images are read from the database.
+func (d *indexedDatabase) GetImageByID(ctx context.Context, id string) (*database.Image, error) {
+	idx, err := d.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if img, ok := idx.byID[id]; ok {
+		img := *img
+		return &img, nil
+	}
+	return d.DatabaseService.GetImageByID(ctx, id)
+}
+
+// blobByHash returns the image and variant of the blob in the rotation whose
+// digest is hash.
+func (d *indexedDatabase) blobByHash(ctx context.Context, hash string) (blobRef, bool, error) {
+	idx, err := d.load(ctx)
+	if err != nil {
+		return blobRef{}, false, err
+	}
+	ref, ok := idx.byHash[hash]
+	return ref, ok, nil
+}
+
+func (d *indexedDatabase) CreateImage(ctx context.Context, original, processed []byte, createdAt time.Time, source string, attribution database.Attribution, afterID string, pending bool) (string, error) {
+	defer d.index.invalidate()
+	return d.DatabaseService.CreateImage(ctx, original, processed, createdAt, source, attribution, afterID, pending)
+}
+
+func (d *indexedDatabase) SetArchived(ctx context.Context, ids []string, archived bool) error {
+	defer d.index.invalidate()
+	return d.DatabaseService.SetArchived(ctx, ids, archived)
+}
+
+func (d *indexedDatabase) ApproveImage(ctx context.Context, id string) error {
+	defer d.index.invalidate()
+	return d.DatabaseService.ApproveImage(ctx, id)
+}
+
+func (d *indexedDatabase) SetPerceptualHash(ctx context.Context, id, hash string) error {
+	defer d.index.invalidate()
+	return d.DatabaseService.SetPerceptualHash(ctx, id, hash)
+}
+
+func (d *indexedDatabase) SetEnhancementPreset(ctx context.Context, id, preset string) error {
+	defer d.index.invalidate()
+	return d.DatabaseService.SetEnhancementPreset(ctx, id, preset)
+}
+
+func (d *indexedDatabase) SetAltText(ctx context.Context, id, text string) error {
+	defer d.index.invalidate()
+	return d.DatabaseService.SetAltText(ctx, id, text)
+}
+
+func (d *indexedDatabase) SetImageText(ctx context.Context, id, title, description string) error {
+	defer d.index.invalidate()
+	return d.DatabaseService.SetImageText(ctx, id, title, description)
+}
+
+func (d *indexedDatabase) SetFit(ctx context.Context, id, fit string) error {
+	defer d.index.invalidate()
+	return d.DatabaseService.SetFit(ctx, id, fit)
+}
+
+func (d *indexedDatabase) SetFlatArt(ctx context.Context, id, flatArt string) error {
+	defer d.index.invalidate()
+	return d.DatabaseService.SetFlatArt(ctx, id, flatArt)
+}
+
+func (d *indexedDatabase) SetCrop(ctx context.Context, id string, crop *database.Region) error {
+	defer d.index.invalidate()
+	return d.DatabaseService.SetCrop(ctx, id, crop)
+}
+
+func (d *indexedDatabase) PutProcessedImage(ctx context.Context, id string, processed []byte) error {
+	defer d.index.invalidate()
+	return d.DatabaseService.PutProcessedImage(ctx, id, processed)
+}
+
+func (d *indexedDatabase) DeleteImage(ctx context.Context, id string) error {
+	defer d.index.invalidate()
+	return d.DatabaseService.DeleteImage(ctx, id)
+}
+
+func (d *indexedDatabase) UpdateOrder(ctx context.Context, order []string) error {
+	defer d.index.invalidate()
+	return d.DatabaseService.UpdateOrder(ctx, order)
+}
+
+func (d *indexedDatabase) PutStory(ctx context.Context, story database.Story) error {
+	defer d.index.invalidate()
+	return d.DatabaseService.PutStory(ctx, story)
+}
+
+func (d *indexedDatabase) SwapCurrentImage(ctx context.Context, expectedID string, order []string) error {
+	defer d.index.invalidate()
+	return d.DatabaseService.SwapCurrentImage(ctx, expectedID, order)
+}
+
+func (d *indexedDatabase) MigrateOriginalEncoding(ctx context.Context, id string) (bool, error) {
+	defer d.index.invalidate()
+	return d.DatabaseService.MigrateOriginalEncoding(ctx, id)
+}
+
+func (d *indexedDatabase) DeleteProcessedImage(ctx context.Context, id string) (int64, error) {
+	defer d.index.invalidate()
+	return d.DatabaseService.DeleteProcessedImage(ctx, id)
+}
@@ -0,0 +1,121 @@
+package core
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+func newTestIndexedDatabase(t *testing.T, n int) (*indexedDatabase, *countingDatabase, []string) {
+	t.Helper()
+	db := &countingDatabase{FakeDatabase: database.NewFakeDatabase(""), release: make(chan struct{})}
+	close(db.release)
+	index := newIndexedDatabase(db, time.Minute)
+	var ids []string
+	for range n {
+		id, err := index.CreateImage(context.Background(), []byte("original"), []byte("processed"), time.Now(), "", database.Attribution{}, "", false)
+		if err != nil {
+			t.Fatalf("CreateImage failed: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	db.reads.Store(0)
+	return index, db, ids
+}
+
+func TestIndexedDatabase_ServesReadsFromOneLoad(t *testing.T) {
+	index, db, ids := newTestIndexedDatabase(t, 3)
+	ctx := context.Background()
+
+	for range 5 {
+		if got, err := index.GetRotationOrderedIDs(ctx); err != nil || !slices.Equal(got, ids) {
+			t.Fatalf("expected order %v, got %v (err %v)", ids, got, err)
+		}
+		if got, err := index.GetCurrentImageID(ctx); err != nil || got != ids[0] {
+			t.Fatalf("expected current image %s, got %s (err %v)", ids[0], got, err)
+		}
+		if img, err := index.GetImageByID(ctx, ids[1]); err != nil || img.ID != ids[1] {
+			t.Fatalf("expected image %s, got %+v (err %v)", ids[1], img, err)
+		}
+		if images, err := index.GetImageMetadata(ctx); err != nil || len(images) != len(ids) {
+			t.Fatalf("expected %d images, got %d (err %v)", len(ids), len(images), err)
+		}
+		if ref, ok, err := index.blobByHash(ctx, database.ContentHash([]byte("original"))); err != nil || !ok || ref != (blobRef{id: ids[0], variant: "original"}) {
+			t.Fatalf("expected the original of %s, got %+v (ok %v, err %v)", ids[0], ref, ok, err)
+		}
+	}
+	if n := db.reads.Load(); n != 1 {
+		t.Errorf("expected one metadata read, got %d", n)
+	}
+}
+
+func TestIndexedDatabase_WritesInvalidate(t *testing.T) {
+	index, db, ids := newTestIndexedDatabase(t, 3)
+	ctx := context.Background()
+	if _, err := index.GetRotationOrderedIDs(ctx); err != nil {
+		t.Fatalf("GetRotationOrderedIDs failed: %v", err)
+	}
+
+	reversed := slices.Clone(ids)
+	slices.Reverse(reversed)
+	if err := index.UpdateOrder(ctx, reversed); err != nil {
+		t.Fatalf("UpdateOrder failed: %v", err)
+	}
+	if got, err := index.GetRotationOrderedIDs(ctx); err != nil || !slices.Equal(got, reversed) {
+		t.Errorf("expected order %v after update, got %v (err %v)", reversed, got, err)
+	}
+
+	if err := index.SetAltText(ctx, ids[0], "a lighthouse"); err != nil {
+		t.Fatalf("SetAltText failed: %v", err)
+	}
+	if img, err := index.GetImageByID(ctx, ids[0]); err != nil || img.AltText != "a lighthouse" {
+		t.Errorf("expected updated alt text, got %+v (err %v)", img, err)
+	}
+	if n := db.reads.Load(); n != 3 {
+		t.Errorf("expected one metadata read per write, got %d", n)
+	}
+}
+
+func TestIndexedDatabase_ReturnsCopies(t *testing.T) {
+	index, _, ids := newTestIndexedDatabase(t, 2)
+	ctx := context.Background()
+
+	img, err := index.GetImageByID(ctx, ids[0])
+	if err != nil {
+		t.Fatalf("GetImageByID failed: %v", err)
+	}
+	img.AltText = "changed by caller"
+	order, err := index.GetRotationOrderedIDs(ctx)
+	if err != nil {
+		t.Fatalf("GetRotationOrderedIDs failed: %v", err)
+	}
+	order[0] = "changed by caller"
+
+	if img, err := index.GetImageByID(ctx, ids[0]); err != nil || img.AltText != "" {
+		t.Errorf("expected the index to be unaffected by callers, got %+v (err %v)", img, err)
+	}
+	if id, err := index.GetCurrentImageID(ctx); err != nil || id != ids[0] {
+		t.Errorf("expected current image %s, got %s (err %v)", ids[0], id, err)
+	}
+}
+
+func TestIndexedDatabase_ReadsArchivedImagesFromDatabase(t *testing.T) {
+	index, _, ids := newTestIndexedDatabase(t, 2)
+	ctx := context.Background()
+
+	if err := index.SetArchived(ctx, []string{ids[1]}, true); err != nil {
+		t.Fatalf("SetArchived failed: %v", err)
+	}
+	if got, err := index.GetRotationOrderedIDs(ctx); err != nil || !slices.Equal(got, ids[:1]) {
+		t.Errorf("expected order %v, got %v (err %v)", ids[:1], got, err)
+	}
+	if img, err := index.GetImageByID(ctx, ids[1]); err != nil || img.ID != ids[1] {
+		t.Errorf("expected archived image %s, got %+v (err %v)", ids[1], img, err)
+	}
+	if _, err := index.GetImageByID(ctx, "missing"); err == nil {
+		t.Error("expected an error for an unknown image")
+	}
+}
@@ -0,0 +1,169 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+// ErrNotPending is returned when approving or rejecting an image that is not awaiting moderation.
+var ErrNotPending = errors.New("image is not pending moderation")
+
+// Moderation decisions returned by the moderation webhook.
+const (
+	ModerationApprove = "approve"
+	ModerationReject  = "reject"
+	ModerationPending = "pending"
+)
+
+// ModerationRequest is posted to the moderation webhook for every pending image.
+type ModerationRequest struct {
+	ID          string               `json:"id"`
+	Source      string               `json:"source"`
+	Attribution database.Attribution `json:"attribution,omitzero"`
+	// Image is the stored original PNG (base64 encoded in JSON).
+	Image []byte `json:"image"`
+}
+
+// ModerationResponse is the webhook's verdict. Anything but approve or reject
+// leaves the image in the approval queue.
+type ModerationResponse struct {
+	Decision string `json:"decision"`
+}
+
+// requiresModeration reports whether images from source must be approved first.
+func (service *CoreService) requiresModeration(source string) bool {
+	m := service.config.Moderation
+	if !m.Enabled || source == DemoSource || strings.HasPrefix(source, ReplicaSourcePrefix) {
+		return false
+	}
+	if name := sourceName(source); name != "" && slices.Contains(m.TrustedSources, SourcePrefix+name) {
+		return false
+	}
+	return !slices.Contains(m.TrustedSources, source)
+}
+
+// moderate asks the moderation webhook about a new pending image and applies
+// its decision. It reports whether the image is still pending.
+func (service *CoreService) moderate(ctx context.Context, id, source string, attribution database.Attribution, original []byte) bool {
+	webhookURL := service.config.Moderation.WebhookURL
+	if webhookURL == "" {
+		return true
+	}
+	decision, err := service.callModerationWebhook(ctx, webhookURL, ModerationRequest{
+		ID: id, Source: source, Attribution: attribution, Image: original,
+	})
+	if err != nil {
+		slog.Warn("CoreService.moderate: webhook failed; image stays pending", "id", id, "url", webhookURL, "error", err)
+		return true
+	}
+
+	switch decision {
+	case ModerationApprove:
+		if err := service.ApproveImage(ctx, id); err != nil {
+			slog.Error("CoreService.moderate: failed to approve image", "id", id, "error", err)
+			return true
+		}
+		return false
+	case ModerationReject:
+		if err := service.RejectImage(ctx, id); err != nil {
+			slog.Error("CoreService.moderate: failed to reject image", "id", id, "error", err)
+			return true
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+func (service *CoreService) callModerationWebhook(ctx context.Context, url string, payload ModerationRequest) (string, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("encoding moderation request: %w", err)
+	}
+	var verdict ModerationResponse
+	err = service.integrations.Breaker("moderation").Do(ctx, func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, service.config.Moderation.WebhookTimeout)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("building moderation request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("unexpected status %s", resp.Status)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&verdict); err != nil {
+			return fmt.Errorf("decoding moderation response: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	slog.Info("CoreService.moderate: webhook decision", "id", payload.ID, "decision", verdict.Decision)
+	return verdict.Decision, nil
+}
+
+// GetPendingImages returns the images awaiting approval, newest first.
+func (service *CoreService) GetPendingImages(ctx context.Context) ([]*database.Image, error) {
+	return service.databaseService.GetPendingImages(ctx)
+}
+
+// ApproveImage moves a pending image into the rotation.
+func (service *CoreService) ApproveImage(ctx context.Context, id string) error {
+	unlock, err := service.locks.tryLock("approve", id)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := service.requirePending(ctx, id); err != nil {
+		return err
+	}
+	slog.Info("CoreService.ApproveImage: approving image", "id", id)
+	defer service.currentImages.invalidate()
+	if err := service.databaseService.ApproveImage(ctx, id); err != nil {
+		return err
+	}
+	service.recordEvent(ctx, id, database.ImageEvent{Type: database.ImageEventEdited, Detail: "approved"})
+	return nil
+}
+
+// RejectImage deletes a pending image.
+func (service *CoreService) RejectImage(ctx context.Context, id string) error {
+	unlock, err := service.locks.tryLock("reject", id)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := service.requirePending(ctx, id); err != nil {
+		return err
+	}
+	slog.Info("CoreService.RejectImage: rejecting image", "id", id)
+	return service.deleteImage(ctx, id)
+}
+
+func (service *CoreService) requirePending(ctx context.Context, id string) error {
+	img, err := service.databaseService.GetImageByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrImageNotFound, id)
+	}
+	if !img.Pending {
+		return fmt.Errorf("%w: %s", ErrNotPending, id)
+	}
+	return nil
+}
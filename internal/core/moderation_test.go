@@ -0,0 +1,141 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+func TestModeration_PendingUntilApproved(t *testing.T) {
+	service, db := newTestCoreService(t, &config.ServiceConfig{
+		Moderation: config.Moderation{Enabled: true, TrustedSources: []string{"xkcd"}},
+	})
+	ctx := context.Background()
+
+	trusted, err := service.AddImage(ctx, testPNG(t, 4, 4), "xkcd", database.Attribution{})
+	if err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+	if trusted.Pending {
+		t.Error("expected image from trusted source to skip moderation")
+	}
+	pending, err := service.AddImage(ctx, testPNG(t, 4, 4), "email", database.Attribution{})
+	if err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+	if !pending.Pending {
+		t.Fatal("expected image from untrusted source to be pending")
+	}
+
+	order, _ := db.GetRotationOrderedIDs(ctx)
+	if len(order) != 1 || order[0] != trusted.ID {
+		t.Fatalf("expected only %s in rotation, got %v", trusted.ID, order)
+	}
+	inbox, _ := service.GetPendingImages(ctx)
+	if len(inbox) != 1 || inbox[0].ID != pending.ID {
+		t.Fatalf("expected %s in approval inbox, got %+v", pending.ID, inbox)
+	}
+
+	if err := service.ApproveImage(ctx, pending.ID); err != nil {
+		t.Fatalf("ApproveImage failed: %v", err)
+	}
+	order, _ = db.GetRotationOrderedIDs(ctx)
+	if len(order) != 2 || order[1] != pending.ID {
+		t.Errorf("expected approved image appended to rotation, got %v", order)
+	}
+	if err := service.ApproveImage(ctx, pending.ID); !errors.Is(err, ErrNotPending) {
+		t.Errorf("expected ErrNotPending approving twice, got %v", err)
+	}
+	if err := service.RejectImage(ctx, "missing"); !errors.Is(err, ErrImageNotFound) {
+		t.Errorf("expected ErrImageNotFound, got %v", err)
+	}
+}
+
+func TestModeration_Webhook(t *testing.T) {
+	decisions := map[string]string{"auto": ModerationApprove, "spam": ModerationReject, "unsure": ModerationPending}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ModerationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Image) == 0 {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if req.Source == "broken" {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(ModerationResponse{Decision: decisions[req.Source]})
+	}))
+	defer srv.Close()
+
+	service, db := newTestCoreService(t, &config.ServiceConfig{
+		Moderation: config.Moderation{Enabled: true, WebhookURL: srv.URL, WebhookTimeout: time.Second},
+	})
+	ctx := context.Background()
+
+	for source, wantPending := range map[string]bool{"auto": false, "unsure": true, "broken": true} {
+		img, err := service.AddImage(ctx, testPNG(t, 4, 4), source, database.Attribution{})
+		if err != nil {
+			t.Fatalf("AddImage(%s) failed: %v", source, err)
+		}
+		if img.Pending != wantPending {
+			t.Errorf("source %s: expected pending=%v, got %v", source, wantPending, img.Pending)
+		}
+	}
+
+	rejected, err := service.AddImage(ctx, testPNG(t, 4, 4), "spam", database.Attribution{})
+	if err != nil {
+		t.Fatalf("AddImage(spam) failed: %v", err)
+	}
+	if rejected.Pending {
+		t.Error("expected rejected image to leave the queue")
+	}
+	if _, err := db.GetImageByID(ctx, rejected.ID); err == nil {
+		t.Error("expected rejected image to be deleted")
+	}
+
+	order, _ := db.GetRotationOrderedIDs(ctx)
+	if len(order) != 1 {
+		t.Errorf("expected only the approved image in rotation, got %v", order)
+	}
+	if inbox, _ := service.GetPendingImages(ctx); len(inbox) != 2 {
+		t.Errorf("expected 2 images awaiting approval, got %d", len(inbox))
+	}
+}
+
+func TestModeration_FailingWebhookIsPaused(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	service, _ := newTestCoreService(t, &config.ServiceConfig{
+		Moderation:   config.Moderation{Enabled: true, WebhookURL: srv.URL, WebhookTimeout: time.Second},
+		Integrations: config.Integrations{FailureThreshold: 2, OpenDuration: time.Hour},
+	})
+	ctx := context.Background()
+	for range 3 {
+		img, err := service.AddImage(ctx, testPNG(t, 4, 4), "web", database.Attribution{})
+		if err != nil {
+			t.Fatalf("AddImage failed: %v", err)
+		}
+		if !img.Pending {
+			t.Error("expected the image to stay pending")
+		}
+	}
+	if calls != 2 {
+		t.Errorf("expected the webhook to be paused after 2 failures, got %d calls", calls)
+	}
+	health := service.GetIntegrationHealth()
+	if len(health) != 1 || health[0].Name != "moderation" || health[0].State != "open" {
+		t.Errorf("unexpected integration health %+v", health)
+	}
+}
@@ -0,0 +1,199 @@
+package core
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+	"github.com/jo-hoe/goframe/internal/imageprocessing"
+	"golang.org/x/image/bmp"
+)
+
+const (
+	encodedCacheTTL        = 24 * time.Hour
+	encodedCacheMaxEntries = 64
+)
+
+// ErrUnsupportedFormat is returned for an image format that cannot be served.
+var ErrUnsupportedFormat = errors.New("unsupported image format")
+
+// ImageFormat is a format images are served to devices in.
+type ImageFormat string
+
+const (
+	// FormatPNG is the format images are stored and processed in.
+	FormatPNG ImageFormat = config.ImageTargetTypePNG
+	// FormatJPEG is encoded from the PNG on request.
+	FormatJPEG ImageFormat = config.ImageTargetTypeJPEG
+	// FormatBMP is encoded from the PNG on request for frames that only
+	// decode bitmaps.
+	FormatBMP ImageFormat = config.ImageTargetTypeBMP
+	// FormatPacked is the palette indices of the PNG packed for e-paper
+	// controllers (see imageprocessing.EncodePacked).
+	FormatPacked ImageFormat = config.ImageTargetTypePacked
+)
+
+// Extension returns the file extension of URLs serving the format.
+func (f ImageFormat) Extension() string {
+	switch f {
+	case FormatJPEG:
+		return ".jpg"
+	case FormatBMP:
+		return ".bmp"
+	case FormatPacked:
+		return ".bin"
+	}
+	return ".png"
+}
+
+// ContentType returns the MIME type of the format.
+func (f ImageFormat) ContentType() string {
+	switch f {
+	case FormatJPEG:
+		return "image/jpeg"
+	case FormatBMP:
+		return "image/bmp"
+	case FormatPacked:
+		return "application/octet-stream"
+	}
+	return "image/png"
+}
+
+// FormatForExtension returns the format served by URLs ending in ext, e.g. ".jpg".
+func FormatForExtension(ext string) (ImageFormat, bool) {
+	for _, f := range []ImageFormat{FormatPNG, FormatJPEG, FormatBMP, FormatPacked} {
+		if f.Extension() == ext {
+			return f, true
+		}
+	}
+	return "", false
+}
+
+// ImageTargetFormat returns the configured format of /api/image.
+func (service *CoreService) ImageTargetFormat() ImageFormat {
+	return ImageFormat(cmp.Or(service.config.ImageTargetType, config.ImageTargetTypePNG))
+}
+
+// GetDeviceImageURLAs is GetDeviceImageURL for the given format. Blob URLs
+// keep their hash, which always names the PNG the format is encoded from.
+func (service *CoreService) GetDeviceImageURLAs(ctx context.Context, deviceID, id string, format ImageFormat) (string, error) {
+	url, err := service.GetDeviceImageURL(ctx, deviceID, id)
+	if err != nil || format == FormatPNG {
+		return url, err
+	}
+	if file, ok := strings.CutPrefix(url, BlobURLPrefix); ok {
+		return BlobURLPrefix + strings.TrimSuffix(file, FormatPNG.Extension()) + format.Extension(), nil
+	}
+	// Images without a hash are served by their processed URL.
+	return "/api/images/" + id + "/processed" + format.Extension(), nil
+}
+
+// GetBlobByHashAs returns the blob whose PNG has the SHA-256 digest hash in
+// the given format.
+func (service *CoreService) GetBlobByHashAs(ctx context.Context, hash string, format ImageFormat) ([]byte, error) {
+	data, err := service.GetBlobByHash(ctx, hash)
+	if err != nil || format == FormatPNG {
+		return data, err
+	}
+	return service.encodeAs(hash, data, format)
+}
+
+// GetProcessedImageAs returns the processed image id in the given format.
+func (service *CoreService) GetProcessedImageAs(ctx context.Context, id string, format ImageFormat) ([]byte, error) {
+	var data []byte
+	var err error
+	if service.processesOnDemand() {
+		data, err = service.GetProcessedImage(ctx, id)
+	} else {
+		data, err = service.databaseService.GetImageData(ctx, id, "processed")
+	}
+	if err != nil || format == FormatPNG {
+		return data, err
+	}
+	return service.encodeAs(database.ContentHash(data), data, format)
+}
+
+// encodeAs encodes the PNG data with the SHA-256 digest hash in format,
+// caching the result by hash.
+func (service *CoreService) encodeAs(hash string, data []byte, format ImageFormat) ([]byte, error) {
+	switch format {
+	case FormatJPEG, FormatBMP, FormatPacked:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+	key := hash + format.Extension()
+	if encoded, ok := service.encoded.get(key); ok {
+		return encoded, nil
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decoding image %s: %w", hash, err)
+	}
+	var encoded []byte
+	switch format {
+	case FormatJPEG:
+		encoded, err = encodeJPEG(img, cmp.Or(service.config.JPEGQuality, 90))
+	case FormatBMP:
+		encoded, err = encodeBMP(img)
+	default:
+		encoded, err = service.encodePacked(img)
+	}
+	if err != nil {
+		return nil, err
+	}
+	service.encoded.put(key, encoded)
+	return encoded, nil
+}
+
+// encodePacked packs img as indices into the device colors of the pipeline's
+// palette; calibrated palettes only change the colors dithered with.
+func (service *CoreService) encodePacked(img image.Image) ([]byte, error) {
+	profile, err := imageprocessing.PanelProfileFromCommands(service.commandConfigs)
+	if err != nil {
+		return nil, err
+	}
+	palette := make([]color.RGBA, len(profile.Palette))
+	for i, pair := range profile.Palette {
+		palette[i] = pair.Device
+	}
+	return imageprocessing.EncodePacked(img, palette)
+}
+
+// encodeJPEG encodes img as a JPEG of the given quality.
+func encodeJPEG(img image.Image, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, flattenOnWhite(img), &jpeg.Options{Quality: quality}); err != nil {
+		return nil, fmt.Errorf("encoding JPEG: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeBMP encodes img as a 24-bit BMP, which legacy frames decode more
+// reliably than one with alpha.
+func encodeBMP(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := bmp.Encode(&buf, flattenOnWhite(img)); err != nil {
+		return nil, fmt.Errorf("encoding BMP: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// flattenOnWhite draws img over white like on a panel, for formats without
+// alpha.
+func flattenOnWhite(img image.Image) *image.RGBA {
+	flat := image.NewRGBA(img.Bounds())
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, img.Bounds().Min, draw.Over)
+	return flat
+}
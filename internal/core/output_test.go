@@ -0,0 +1,129 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+	"golang.org/x/image/bmp"
+)
+
+func TestGetDeviceImageURLAs_JPEGBlob(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{})
+	ctx := context.Background()
+	ids := addTestImages(t, service, 1)
+
+	pngURL, err := service.GetDeviceImageURLAs(ctx, "", ids[0], FormatPNG)
+	if err != nil {
+		t.Fatalf("GetDeviceImageURLAs failed: %v", err)
+	}
+	jpegURL, err := service.GetDeviceImageURLAs(ctx, "", ids[0], FormatJPEG)
+	if err != nil {
+		t.Fatalf("GetDeviceImageURLAs failed: %v", err)
+	}
+	if want := strings.TrimSuffix(pngURL, ".png") + ".jpg"; jpegURL != want {
+		t.Fatalf("expected the JPEG URL %q, got %q", want, jpegURL)
+	}
+
+	hash := strings.TrimSuffix(strings.TrimPrefix(jpegURL, BlobURLPrefix), ".jpg")
+	data, err := service.GetBlobByHashAs(ctx, hash, FormatJPEG)
+	if err != nil {
+		t.Fatalf("GetBlobByHashAs failed: %v", err)
+	}
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("expected a JPEG, got %v", err)
+	}
+	original, err := service.GetBlobByHashAs(ctx, hash, FormatPNG)
+	if err != nil {
+		t.Fatalf("GetBlobByHashAs failed: %v", err)
+	}
+	if database.ContentHash(original) != hash {
+		t.Errorf("expected the PNG blob to be served unchanged")
+	}
+	if cfg, err := png.DecodeConfig(bytes.NewReader(original)); err != nil || img.Bounds().Dx() != cfg.Width || img.Bounds().Dy() != cfg.Height {
+		t.Errorf("expected the JPEG to keep the size %+v, got %v (%v)", cfg, img.Bounds(), err)
+	}
+}
+
+func TestGetProcessedImageAs_EncodesOnce(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{JPEGQuality: 50})
+	ctx := context.Background()
+	apiImg, err := service.AddImage(ctx, testPNG(t, 64, 32), "", database.Attribution{})
+	if err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+	first, err := service.GetProcessedImageAs(ctx, apiImg.ID, FormatJPEG)
+	if err != nil {
+		t.Fatalf("GetProcessedImageAs failed: %v", err)
+	}
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(first))
+	if err != nil || cfg.Width != 64 || cfg.Height != 32 {
+		t.Fatalf("expected a 64x32 JPEG, got %+v (%v)", cfg, err)
+	}
+	second, err := service.GetProcessedImageAs(ctx, apiImg.ID, FormatJPEG)
+	if err != nil {
+		t.Fatalf("GetProcessedImageAs failed: %v", err)
+	}
+	if &first[0] != &second[0] {
+		t.Error("expected the second request to be served from the cache")
+	}
+}
+
+func TestGetDeviceImageURLAs_Packed(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{
+		Commands: []config.CommandConfig{
+			{Name: "ScaleCommand", Params: map[string]any{"height": 4, "width": 8}},
+			{Name: "DitherCommand", Params: map[string]any{}},
+		},
+	})
+	ctx := context.Background()
+	ids := addTestImages(t, service, 1)
+
+	url, err := service.GetDeviceImageURLAs(ctx, "", ids[0], FormatPacked)
+	if err != nil {
+		t.Fatalf("GetDeviceImageURLAs failed: %v", err)
+	}
+	hash, ok := strings.CutSuffix(strings.TrimPrefix(url, BlobURLPrefix), ".bin")
+	if !ok {
+		t.Fatalf("expected a .bin blob URL, got %q", url)
+	}
+	data, err := service.GetBlobByHashAs(ctx, hash, FormatPacked)
+	if err != nil {
+		t.Fatalf("GetBlobByHashAs failed: %v", err)
+	}
+	// Black and white packs 1 bit per pixel: a header, two colors and four
+	// rows of one byte.
+	if !strings.HasPrefix(string(data), "GFEP") || data[5] != 1 || len(data) != 12+2*3+4 {
+		t.Errorf("expected a 1 bit per pixel packed image, got % x", data)
+	}
+}
+
+func TestGetProcessedImageAs_BMP(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{})
+	ctx := context.Background()
+	apiImg, err := service.AddImage(ctx, testPNG(t, 6, 3), "", database.Attribution{})
+	if err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+	data, err := service.GetProcessedImageAs(ctx, apiImg.ID, FormatBMP)
+	if err != nil {
+		t.Fatalf("GetProcessedImageAs failed: %v", err)
+	}
+	img, err := bmp.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("expected a BMP, got %v", err)
+	}
+	if img.Bounds().Dx() != 6 || img.Bounds().Dy() != 3 {
+		t.Errorf("expected a 6x3 BMP, got %v", img.Bounds())
+	}
+	// 24-bit pixel data without alpha.
+	if bitCount := data[28]; bitCount != 24 {
+		t.Errorf("expected 24 bits per pixel, got %d", bitCount)
+	}
+}
@@ -0,0 +1,176 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+	"github.com/jo-hoe/goframe/internal/imageprocessing"
+)
+
+const (
+	// compositedCacheTTL keeps composited images long enough for devices to
+	// fetch the blob they were redirected to; widgets change every minute.
+	compositedCacheTTL        = 5 * time.Minute
+	compositedCacheMaxEntries = 64
+	// maxOverlayTextLength cuts text widgets short so a long description does
+	// not cover the image.
+	maxOverlayTextLength = 60
+)
+
+// overlayTextData is what text widget templates can refer to.
+type overlayTextData struct {
+	Title, Description, Author, License, Source string
+}
+
+// ReportBattery records the battery level in percent a device sent along with
+// a poll, for the battery overlay widget.
+func (service *CoreService) ReportBattery(deviceID string, percent int) {
+	service.devicePolls.recordBattery(deviceID, min(max(percent, 0), 100))
+}
+
+// overlayForDevice returns the overlay configured for deviceID: the first one
+// listing the device, else the first one without a device list.
+func (service *CoreService) overlayForDevice(deviceID string) (*config.Overlay, bool) {
+	var fallback *config.Overlay
+	for i := range service.config.Overlays {
+		o := &service.config.Overlays[i]
+		if slices.Contains(o.Devices, deviceID) {
+			return o, true
+		}
+		if len(o.Devices) == 0 && fallback == nil {
+			fallback = o
+		}
+	}
+	return fallback, fallback != nil
+}
+
+// applyDeviceOverlay composites the device's overlay, if any, onto data, the
+// image id as the device would otherwise receive it.
+func (service *CoreService) applyDeviceOverlay(ctx context.Context, deviceID, id string, data []byte) ([]byte, error) {
+	overlay, ok := service.overlayForDevice(deviceID)
+	if !ok {
+		return data, nil
+	}
+	return service.compositeOverlay(ctx, deviceID, id, overlay, data)
+}
+
+// compositeOverlay draws the widgets of overlay onto data. Widget values are
+// computed for the current minute, so results are cached per minute: by image,
+// base image and widget values, and by content hash so the blob URL handed to
+// devices can be served.
+func (service *CoreService) compositeOverlay(ctx context.Context, deviceID, id string, overlay *config.Overlay, data []byte) ([]byte, error) {
+	items := service.overlayItems(ctx, deviceID, id, overlay, service.nowFn())
+	texts := make([]string, len(items))
+	for i, item := range items {
+		texts[i] = fmt.Sprintf("%s:%s", item.Icon, item.Text)
+	}
+	key := fmt.Sprintf("%s/%s/%s/%s", id, database.ContentHash(data), overlay.Position, strings.Join(texts, "|"))
+
+	composited, ok := service.composited.get(key)
+	if !ok {
+		var err error
+		composited, err = imageprocessing.DrawOverlay(data, items, imageprocessing.OverlayPosition(overlay.Position))
+		if err != nil {
+			return nil, err
+		}
+		service.composited.put(key, composited)
+	}
+	service.composited.put(database.ContentHash(composited), composited)
+	return composited, nil
+}
+
+// overlayItems renders the widgets of overlay for deviceID showing image id at
+// the minute of now. Widgets without data, e.g. a battery the device never
+// reported or a title the image lacks, are left out.
+func (service *CoreService) overlayItems(ctx context.Context, deviceID, id string, overlay *config.Overlay, now time.Time) []imageprocessing.OverlayItem {
+	now = now.In(service.tzLoc).Truncate(time.Minute)
+	items := make([]imageprocessing.OverlayItem, 0, len(overlay.Widgets))
+	var textData *overlayTextData
+	for _, w := range overlay.Widgets {
+		switch w.Type {
+		case config.OverlayWidgetText:
+			if textData == nil {
+				textData = service.overlayTextData(ctx, id)
+			}
+			if text := renderOverlayText(w.Template, textData); text != "" {
+				items = append(items, imageprocessing.OverlayItem{Text: text})
+			}
+		case config.OverlayWidgetClock:
+			items = append(items, imageprocessing.OverlayItem{Text: now.Format(w.Format)})
+		case config.OverlayWidgetCountdown:
+			items = append(items, imageprocessing.OverlayItem{Text: "next in " + shortDuration(nextMidnight(now, service.tzLoc).Sub(now))})
+		case config.OverlayWidgetBattery:
+			if percent, ok := service.devicePolls.battery(deviceID); ok {
+				items = append(items, imageprocessing.OverlayItem{
+					Icon:  imageprocessing.OverlayIconBattery,
+					Text:  fmt.Sprintf("%d%%", percent),
+					Level: float64(percent) / 100,
+				})
+			}
+		case config.OverlayWidgetWeather:
+			if report, ok := service.currentWeather(ctx, w); ok {
+				// The overlay font only covers ASCII, hence no degree sign.
+				items = append(items, imageprocessing.OverlayItem{
+					Icon: report.Icon,
+					Text: fmt.Sprintf("%dC", int(math.Round(report.Temperature))),
+				})
+			}
+		}
+	}
+	return items
+}
+
+// overlayTextData looks up the text of image id for text widgets; unknown
+// images have none.
+func (service *CoreService) overlayTextData(ctx context.Context, id string) *overlayTextData {
+	img, err := service.databaseService.GetImageByID(ctx, id)
+	if err != nil {
+		slog.Warn("CoreService.overlayTextData: failed to get image", "id", id, "error", err)
+		return &overlayTextData{}
+	}
+	return &overlayTextData{
+		Title:       img.Title,
+		Description: img.Description,
+		Author:      img.Attribution.Author,
+		License:     img.Attribution.License,
+		Source:      img.Source,
+	}
+}
+
+// renderOverlayText fills in a text widget template, joins its lines and cuts
+// it to maxOverlayTextLength characters. The template was validated when the
+// configuration was loaded; failures yield no text.
+func renderOverlayText(text string, data *overlayTextData) string {
+	tmpl, err := template.New("overlay").Parse(text)
+	if err != nil {
+		return ""
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		slog.Warn("renderOverlayText: failed to render template", "error", err)
+		return ""
+	}
+	out := strings.Join(strings.Fields(b.String()), " ")
+	if utf8.RuneCountInString(out) > maxOverlayTextLength {
+		out = string([]rune(out)[:maxOverlayTextLength-3]) + "..."
+	}
+	return out
+}
+
+// shortDuration formats d in hours and minutes, e.g. "5h 20m" or "20m".
+func shortDuration(d time.Duration) string {
+	minutes := int(d.Round(time.Minute).Minutes())
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh %02dm", minutes/60, minutes%60)
+}
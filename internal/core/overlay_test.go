@@ -0,0 +1,125 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+	"github.com/jo-hoe/goframe/internal/imageprocessing"
+)
+
+func TestOverlayForDevice(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{Overlays: []config.Overlay{
+		{Position: config.OverlayPositionTopLeft},
+		{Devices: []string{"kitchen"}, Position: config.OverlayPositionBottomRight},
+	}})
+	if o, ok := service.overlayForDevice("kitchen"); !ok || o.Position != config.OverlayPositionBottomRight {
+		t.Errorf("expected the kitchen overlay, got %+v", o)
+	}
+	if o, ok := service.overlayForDevice("hall"); !ok || o.Position != config.OverlayPositionTopLeft {
+		t.Errorf("expected the fallback overlay, got %+v", o)
+	}
+
+	service, _ = newTestCoreService(t, &config.ServiceConfig{Overlays: []config.Overlay{{Devices: []string{"kitchen"}}}})
+	if _, ok := service.overlayForDevice("hall"); ok {
+		t.Error("expected no overlay for unlisted device")
+	}
+}
+
+func TestOverlayItems(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Query().Get("latitude") != "52.5" || r.URL.Query().Get("current") == "" {
+			t.Errorf("unexpected forecast query %q", r.URL.RawQuery)
+		}
+		_, _ = w.Write([]byte(`{"current":{"temperature_2m":-3.6,"weather_code":73}}`))
+	}))
+	defer srv.Close()
+
+	service, _ := newTestCoreService(t, &config.ServiceConfig{Timezone: "Europe/Berlin"})
+	service.nowFn = func() time.Time { return time.Date(2026, 3, 10, 20, 45, 30, 0, service.tzLoc) }
+	overlay := &config.Overlay{Widgets: []config.OverlayWidget{
+		{Type: config.OverlayWidgetClock, Format: "15:04"},
+		{Type: config.OverlayWidgetCountdown},
+		{Type: config.OverlayWidgetBattery},
+		{Type: config.OverlayWidgetWeather, Latitude: 52.5, Longitude: 13.4, URL: srv.URL},
+	}}
+
+	items := service.overlayItems(context.Background(), "kitchen", "", overlay, service.nowFn())
+	want := []imageprocessing.OverlayItem{
+		{Text: "20:45"},
+		{Text: "next in 3h 15m"},
+		{Icon: imageprocessing.OverlayIconSnow, Text: "-4C"},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("expected %d items without a battery report, got %+v", len(want), items)
+	}
+	for i := range want {
+		if items[i] != want[i] {
+			t.Errorf("item %d: expected %+v, got %+v", i, want[i], items[i])
+		}
+	}
+
+	service.ReportBattery("kitchen", 130)
+	items = service.overlayItems(context.Background(), "kitchen", "", overlay, service.nowFn())
+	if battery := items[2]; battery.Icon != imageprocessing.OverlayIconBattery || battery.Text != "100%" || battery.Level != 1 {
+		t.Errorf("expected clamped battery item, got %+v", battery)
+	}
+	if requests != 1 {
+		t.Errorf("expected the forecast to be cached, got %d requests", requests)
+	}
+}
+
+func TestGetDeviceImageURL_ServesCompositedBlobPerMinute(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{Overlays: []config.Overlay{{
+		Devices:  []string{"kitchen"},
+		Position: config.OverlayPositionTopLeft,
+		Widgets:  []config.OverlayWidget{{Type: config.OverlayWidgetClock, Format: "15:04"}},
+	}}})
+	now := time.Date(2026, 3, 10, 8, 0, 5, 0, time.UTC)
+	service.nowFn = func() time.Time { return now }
+	ctx := context.Background()
+
+	img, err := service.AddImage(ctx, testPNG(t, 120, 60), "", database.Attribution{})
+	if err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+	plain, err := service.GetDeviceImageURL(ctx, "hall", img.ID)
+	if err != nil {
+		t.Fatalf("GetDeviceImageURL failed: %v", err)
+	}
+	first, err := service.GetDeviceImageURL(ctx, "kitchen", img.ID)
+	if err != nil {
+		t.Fatalf("GetDeviceImageURL failed: %v", err)
+	}
+	if first == plain || !strings.HasPrefix(first, BlobURLPrefix) {
+		t.Fatalf("expected a different blob URL with an overlay, got %q (plain %q)", first, plain)
+	}
+	hash := strings.TrimSuffix(strings.TrimPrefix(first, BlobURLPrefix), ".png")
+	if data, err := service.GetBlobByHash(ctx, hash); err != nil || database.ContentHash(data) != hash {
+		t.Fatalf("expected composited blob to be served, got %v", err)
+	}
+
+	now = now.Add(40 * time.Second)
+	if again, _ := service.GetDeviceImageURL(ctx, "kitchen", img.ID); again != first {
+		t.Errorf("expected the same image within a minute, got %q", again)
+	}
+	now = now.Add(time.Minute)
+	if next, _ := service.GetDeviceImageURL(ctx, "kitchen", img.ID); next == first {
+		t.Error("expected a new image once the clock changed")
+	}
+
+	patch, err := service.GetCurrentImagePatch(ctx, "kitchen", "")
+	if err != nil {
+		t.Fatalf("GetCurrentImagePatch failed: %v", err)
+	}
+	if next, _ := service.GetDeviceImageURL(ctx, "kitchen", img.ID); !strings.Contains(next, patch.To) {
+		t.Errorf("expected patch to target the composited image, got %q", patch.To)
+	}
+}
@@ -0,0 +1,78 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var (
+	// ErrInvalidPosition is returned when a move request does not name exactly one target.
+	ErrInvalidPosition = errors.New("invalid position")
+	// ErrImageNotInOrder is returned when the moved image or its anchor is not in the display order.
+	ErrImageNotInOrder = errors.New("image not in display order")
+)
+
+// ImagePosition is the target of a single-image move. Exactly one field must be set.
+type ImagePosition struct {
+	// Before places the image directly before the image with this ID.
+	Before string `json:"before,omitempty"`
+	// After places the image directly after the image with this ID.
+	After string `json:"after,omitempty"`
+	// Index places the image at this position (0 = today); larger values move it to the end.
+	Index *int `json:"index,omitempty"`
+}
+
+// MoveImage moves a single image within the display order and returns the new
+// order, so clients do not have to send the complete order for one move. See
+// ReorderImages for moves that change the current image.
+func (service *CoreService) MoveImage(ctx context.Context, id string, pos ImagePosition) ([]string, error) {
+	set := 0
+	for _, ok := range []bool{pos.Before != "", pos.After != "", pos.Index != nil} {
+		if ok {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, fmt.Errorf("%w: exactly one of before, after or index must be set", ErrInvalidPosition)
+	}
+	if pos.Index != nil && *pos.Index < 0 {
+		return nil, fmt.Errorf("%w: index must not be negative", ErrInvalidPosition)
+	}
+	if pos.Before == id || pos.After == id {
+		return nil, fmt.Errorf("%w: an image cannot be positioned relative to itself", ErrInvalidPosition)
+	}
+
+	order, err := service.getOrderedImageIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	idx := slices.Index(order, id)
+	if idx < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrImageNotInOrder, id)
+	}
+	order = slices.Delete(slices.Clone(order), idx, idx+1)
+
+	var target int
+	switch {
+	case pos.Index != nil:
+		target = min(*pos.Index, len(order))
+	case pos.Before != "":
+		target = slices.Index(order, pos.Before)
+	default:
+		target = slices.Index(order, pos.After)
+		if target >= 0 {
+			target++
+		}
+	}
+	if target < 0 {
+		return nil, fmt.Errorf("%w: %s%s", ErrImageNotInOrder, pos.Before, pos.After)
+	}
+	order = slices.Insert(order, target, id)
+
+	if err := service.ReorderImages(ctx, order); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
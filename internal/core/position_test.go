@@ -0,0 +1,62 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+func TestMoveImage(t *testing.T) {
+	service, db := newTestCoreService(t, &config.ServiceConfig{})
+	ctx := context.Background()
+
+	ids := make([]string, 4)
+	for i := range ids {
+		img, err := service.AddImage(ctx, testPNG(t, 4, 4), "", database.Attribution{})
+		if err != nil {
+			t.Fatalf("AddImage failed: %v", err)
+		}
+		ids[i] = img.ID
+	}
+	a, b, c, d := ids[0], ids[1], ids[2], ids[3]
+	index := func(n int) *int { return &n }
+
+	tests := []struct {
+		name string
+		id   string
+		pos  ImagePosition
+		want []string
+	}{
+		{"before", d, ImagePosition{Before: b}, []string{a, d, b, c}},
+		{"after", a, ImagePosition{After: c}, []string{d, b, c, a}},
+		{"index front", c, ImagePosition{Index: index(0)}, []string{c, d, b, a}},
+		{"index beyond end", c, ImagePosition{Index: index(99)}, []string{d, b, a, c}},
+	}
+	for _, tt := range tests {
+		got, err := service.MoveImage(ctx, tt.id, tt.pos)
+		if err != nil {
+			t.Fatalf("%s: MoveImage failed: %v", tt.name, err)
+		}
+		stored, _ := db.GetRotationOrderedIDs(ctx)
+		if !slices.Equal(got, tt.want) || !slices.Equal(stored, tt.want) {
+			t.Errorf("%s: expected %v, got %v (stored %v)", tt.name, tt.want, got, stored)
+		}
+	}
+
+	if _, err := service.MoveImage(ctx, a, ImagePosition{Before: b, After: c}); !errors.Is(err, ErrInvalidPosition) {
+		t.Errorf("expected ErrInvalidPosition for two targets, got %v", err)
+	}
+	if _, err := service.MoveImage(ctx, a, ImagePosition{}); !errors.Is(err, ErrInvalidPosition) {
+		t.Errorf("expected ErrInvalidPosition without target, got %v", err)
+	}
+	if _, err := service.MoveImage(ctx, a, ImagePosition{Before: "missing"}); !errors.Is(err, ErrImageNotInOrder) {
+		t.Errorf("expected ErrImageNotInOrder for unknown anchor, got %v", err)
+	}
+	if _, err := service.MoveImage(ctx, "missing", ImagePosition{Index: index(0)}); !errors.Is(err, ErrImageNotInOrder) {
+		t.Errorf("expected ErrImageNotInOrder for unknown image, got %v", err)
+	}
+}
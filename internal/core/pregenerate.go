@@ -0,0 +1,173 @@
+package core
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+// pregenerationDeviceWindow is how recently a device must have polled for its
+// next image to be pre-generated.
+const pregenerationDeviceWindow = 48 * time.Hour
+
+// pregenerated holds renditions rendered ahead of the next rotation. Each run
+// replaces the previous set, so it never holds more than one day's images.
+type pregenerated struct {
+	mu sync.RWMutex
+	// processed maps image IDs to their processed PNG.
+	processed map[string][]byte
+	// blobs maps content hashes to the same bytes for /api/blob requests.
+	blobs map[string][]byte
+	// patches maps "<from>:<to>" hashes to the differential update between them.
+	patches map[string]*ImagePatch
+}
+
+func (p *pregenerated) processedImage(id string) ([]byte, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	data, ok := p.processed[id]
+	return data, ok
+}
+
+func (p *pregenerated) blob(hash string) ([]byte, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	data, ok := p.blobs[hash]
+	return data, ok
+}
+
+func (p *pregenerated) patch(from, to string) (*ImagePatch, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	patch, ok := p.patches[from+":"+to]
+	return patch, ok
+}
+
+// remove drops a deleted image. Its blob and patches stay: they are keyed by
+// content and no longer reachable once the image is gone from the rotation.
+func (p *pregenerated) remove(id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.processed, id)
+}
+
+func (p *pregenerated) replace(processed, blobs map[string][]byte, patches map[string]*ImagePatch) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.processed, p.blobs, p.patches = processed, blobs, patches
+}
+
+// RunPregeneration renders the images frames will request after the next
+// rotation shortly before midnight, so the poll spike at the boundary is
+// served from memory. It returns immediately unless pre-generation is enabled
+// and the instance may write.
+func (service *CoreService) RunPregeneration(ctx context.Context) {
+	if !service.config.Pregeneration.Enabled || service.config.ReadOnly {
+		return
+	}
+	lead := service.config.Pregeneration.Lead
+	for {
+		now := time.Now().In(service.tzLoc)
+		boundary := nextMidnight(now, service.tzLoc)
+		wait := boundary.Add(-lead).Sub(now)
+		if wait <= 0 {
+			if err := service.Pregenerate(ctx); err != nil {
+				slog.Error("CoreService.RunPregeneration: pre-generation failed", "error", err)
+			}
+			// Sleep past the boundary before planning the next run.
+			wait = boundary.Sub(now) + time.Second
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// Pregenerate renders the processed image and the differential update from
+// today's image for every rotation position that will be requested after the
+// next rotation: the default position, each frame group and each device that
+// polled recently.
+func (service *CoreService) Pregenerate(ctx context.Context) error {
+	start := time.Now()
+	ids, err := service.databaseService.GetRotationOrderedIDs(ctx)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	offsets := map[int]bool{0: true}
+	groups, err := service.databaseService.GetFrameGroups(ctx)
+	if err != nil {
+		return err
+	}
+	for _, g := range groups {
+		offsets[g.Offset%len(ids)] = true
+	}
+	for _, device := range service.devicePolls.seenSince(start.Add(-pregenerationDeviceWindow)) {
+		if !groupsHaveDevice(groups, device) {
+			offsets[deviceOffset(device)%len(ids)] = true
+		}
+	}
+
+	processed := make(map[string][]byte)
+	blobs := make(map[string][]byte)
+	patches := make(map[string]*ImagePatch)
+	for offset := range offsets {
+		todayID, tomorrowID := ids[offset], ids[(offset+1)%len(ids)]
+		today, err := service.renderForPregeneration(ctx, todayID, processed)
+		if err != nil {
+			slog.Warn("CoreService.Pregenerate: failed to render current image", "id", todayID, "error", err)
+			continue
+		}
+		tomorrow, err := service.renderForPregeneration(ctx, tomorrowID, processed)
+		if err != nil {
+			slog.Warn("CoreService.Pregenerate: failed to render next image", "id", tomorrowID, "error", err)
+			continue
+		}
+		todayHash := database.ContentHash(today)
+		blobs[todayHash] = today
+		blobs[database.ContentHash(tomorrow)] = tomorrow
+
+		patch, err := service.computePatch(todayHash, today, tomorrow)
+		if err != nil {
+			slog.Warn("CoreService.Pregenerate: failed to compute patch", "from", todayID, "to", tomorrowID, "error", err)
+			continue
+		}
+		patches[todayHash+":"+patch.To] = patch
+	}
+
+	service.pregenerated.replace(processed, blobs, patches)
+	slog.Info("CoreService.Pregenerate: rendered next images",
+		"positions", len(offsets), "images", len(processed), "patches", len(patches), "duration_ms", time.Since(start).Milliseconds())
+	return nil
+}
+
+// renderForPregeneration returns the processed image for id, rendering each
+// image only once per run.
+func (service *CoreService) renderForPregeneration(ctx context.Context, id string, rendered map[string][]byte) ([]byte, error) {
+	if data, ok := rendered[id]; ok {
+		return data, nil
+	}
+	data, err := service.processedImageData(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	rendered[id] = data
+	return data, nil
+}
+
+func groupsHaveDevice(groups []database.FrameGroup, deviceID string) bool {
+	for _, g := range groups {
+		if g.HasDevice(deviceID) {
+			return true
+		}
+	}
+	return false
+}
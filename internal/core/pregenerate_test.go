@@ -0,0 +1,90 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+func TestPregenerate_CachesNextImageAndPatch(t *testing.T) {
+	service, db := newTestCoreService(t, &config.ServiceConfig{})
+	ctx := context.Background()
+
+	for _, size := range []int{4, 6} {
+		if _, err := service.AddImage(ctx, testPNG(t, size, size), "", database.Attribution{}); err != nil {
+			t.Fatalf("AddImage failed: %v", err)
+		}
+	}
+	if err := service.Pregenerate(ctx); err != nil {
+		t.Fatalf("Pregenerate failed: %v", err)
+	}
+
+	ids, _ := db.GetRotationOrderedIDs(ctx)
+	today, _ := db.GetImageData(ctx, ids[0], "processed")
+	tomorrow, _ := db.GetImageData(ctx, ids[1], "processed")
+	todayHash, tomorrowHash := database.ContentHash(today), database.ContentHash(tomorrow)
+
+	if _, ok := service.pregenerated.processedImage(ids[1]); !ok {
+		t.Error("expected the next image to be pre-generated")
+	}
+	if _, ok := service.pregenerated.blob(tomorrowHash); !ok {
+		t.Error("expected the next image blob to be cached by hash")
+	}
+	patch, ok := service.pregenerated.patch(todayHash, tomorrowHash)
+	if !ok {
+		t.Fatal("expected the patch from today's image to be pre-computed")
+	}
+	if patch.To != tomorrowHash {
+		t.Errorf("patch.To = %q, want %q", patch.To, tomorrowHash)
+	}
+
+	if err := service.DeleteImage(ctx, ids[1]); err != nil {
+		t.Fatalf("DeleteImage failed: %v", err)
+	}
+	if _, ok := service.pregenerated.processedImage(ids[1]); ok {
+		t.Error("expected deleting an image to drop its pre-generated rendition")
+	}
+}
+
+func TestPregenerate_IncludesRecentlySeenDevices(t *testing.T) {
+	service, db := newTestCoreService(t, &config.ServiceConfig{})
+	ctx := context.Background()
+
+	for _, size := range []int{4, 5, 6, 7} {
+		if _, err := service.AddImage(ctx, testPNG(t, size, size), "", database.Attribution{}); err != nil {
+			t.Fatalf("AddImage failed: %v", err)
+		}
+	}
+	ids, _ := db.GetRotationOrderedIDs(ctx)
+	device := "kitchen"
+	offset := deviceOffset(device) % len(ids)
+	if offset == 0 {
+		t.Skip("device shares the default rotation position")
+	}
+
+	if _, err := service.GetImageForDevice(ctx, device); err != nil {
+		t.Fatalf("GetImageForDevice failed: %v", err)
+	}
+	if err := service.Pregenerate(ctx); err != nil {
+		t.Fatalf("Pregenerate failed: %v", err)
+	}
+	if _, ok := service.pregenerated.processedImage(ids[(offset+1)%len(ids)]); !ok {
+		t.Error("expected the device's next image to be pre-generated")
+	}
+}
+
+func TestDevicePolls_SeenSince(t *testing.T) {
+	var polls devicePolls
+	now := time.Now()
+	polls.record("old", now.Add(-time.Hour))
+	polls.record("new", now)
+	polls.record("", now)
+
+	got := polls.seenSince(now.Add(-time.Minute))
+	if len(got) != 1 || got[0] != "new" {
+		t.Errorf("seenSince = %v, want [new]", got)
+	}
+}
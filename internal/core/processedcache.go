@@ -0,0 +1,129 @@
+package core
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProcessedCacheStats reports the state and eviction counters of the on-demand
+// processed-image cache.
+type ProcessedCacheStats struct {
+	Entries    int   `json:"entries"`
+	MaxEntries int   `json:"maxEntries"`
+	Bytes      int64 `json:"bytes"`
+	Hits       int64 `json:"hits"`
+	Misses     int64 `json:"misses"`
+	// Evictions counts entries dropped to stay within MaxEntries.
+	Evictions int64 `json:"evictions"`
+	// Expirations counts entries dropped because they outlived the TTL.
+	Expirations int64 `json:"expirations"`
+}
+
+type processedCacheEntry struct {
+	id        string
+	data      []byte
+	expiresAt time.Time
+}
+
+// processedCache is a size-bounded LRU cache with per-entry TTL for processed
+// image bytes. It is safe for concurrent use.
+type processedCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	order      *list.List // front = most recently used
+	entries    map[string]*list.Element
+	stats      ProcessedCacheStats
+	nowFn      func() time.Time
+}
+
+func newProcessedCache(ttl time.Duration, maxEntries int) *processedCache {
+	return &processedCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+		stats:      ProcessedCacheStats{MaxEntries: maxEntries},
+		nowFn:      time.Now,
+	}
+}
+
+// get returns the cached bytes for id, dropping the entry if it has expired.
+func (c *processedCache) get(id string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[id]
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+	entry := el.Value.(*processedCacheEntry)
+	if !c.nowFn().Before(entry.expiresAt) {
+		c.removeElement(el)
+		c.stats.Expirations++
+		c.stats.Misses++
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	c.stats.Hits++
+	return entry.data, true
+}
+
+// put stores data for id, evicting the least recently used entries when full.
+func (c *processedCache) put(id string, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[id]; ok {
+		c.removeElement(el)
+	}
+	el := c.order.PushFront(&processedCacheEntry{id: id, data: data, expiresAt: c.nowFn().Add(c.ttl)})
+	c.entries[id] = el
+	c.stats.Bytes += int64(len(data))
+
+	for c.order.Len() > c.maxEntries {
+		c.removeElement(c.order.Back())
+		c.stats.Evictions++
+	}
+}
+
+// remove drops id from the cache, e.g. after the image was deleted.
+func (c *processedCache) remove(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[id]; ok {
+		c.removeElement(el)
+	}
+}
+
+// removePrefix drops all entries whose key starts with prefix.
+func (c *processedCache) removePrefix(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, el := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			c.removeElement(el)
+		}
+	}
+}
+
+// snapshot returns a copy of the current statistics.
+func (c *processedCache) snapshot() ProcessedCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := c.stats
+	s.Entries = c.order.Len()
+	return s
+}
+
+func (c *processedCache) removeElement(el *list.Element) {
+	entry := c.order.Remove(el).(*processedCacheEntry)
+	delete(c.entries, entry.id)
+	c.stats.Bytes -= int64(len(entry.data))
+}
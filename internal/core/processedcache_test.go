@@ -0,0 +1,77 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestProcessedCache(ttl time.Duration, maxEntries int) (*processedCache, *time.Time) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := newProcessedCache(ttl, maxEntries)
+	c.nowFn = func() time.Time { return now }
+	return c, &now
+}
+
+func TestProcessedCache_HitAndMiss(t *testing.T) {
+	c, _ := newTestProcessedCache(time.Hour, 2)
+
+	if _, ok := c.get("a"); ok {
+		t.Fatal("Expected miss on empty cache")
+	}
+	c.put("a", []byte("aaa"))
+	data, ok := c.get("a")
+	if !ok || string(data) != "aaa" {
+		t.Fatalf("Expected hit with data 'aaa', got ok=%v data=%q", ok, data)
+	}
+
+	stats := c.snapshot()
+	if stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("Expected 1 hit and 1 miss, got %+v", stats)
+	}
+	if stats.Entries != 1 || stats.Bytes != 3 {
+		t.Errorf("Expected 1 entry of 3 bytes, got %+v", stats)
+	}
+}
+
+func TestProcessedCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c, _ := newTestProcessedCache(time.Hour, 2)
+
+	c.put("a", []byte("a"))
+	c.put("b", []byte("b"))
+	c.get("a") // a becomes most recently used
+	c.put("c", []byte("c"))
+
+	if _, ok := c.get("b"); ok {
+		t.Error("Expected b to be evicted")
+	}
+	if _, ok := c.get("a"); !ok {
+		t.Error("Expected a to remain cached")
+	}
+	if stats := c.snapshot(); stats.Evictions != 1 || stats.Entries != 2 {
+		t.Errorf("Expected 1 eviction and 2 entries, got %+v", stats)
+	}
+}
+
+func TestProcessedCache_ExpiresAfterTTL(t *testing.T) {
+	c, now := newTestProcessedCache(time.Minute, 2)
+
+	c.put("a", []byte("a"))
+	*now = now.Add(time.Minute)
+
+	if _, ok := c.get("a"); ok {
+		t.Error("Expected entry to be expired")
+	}
+	if stats := c.snapshot(); stats.Expirations != 1 || stats.Entries != 0 || stats.Bytes != 0 {
+		t.Errorf("Expected 1 expiration and empty cache, got %+v", stats)
+	}
+}
+
+func TestProcessedCache_Remove(t *testing.T) {
+	c, _ := newTestProcessedCache(time.Hour, 2)
+
+	c.put("a", []byte("a"))
+	c.remove("a")
+	if _, ok := c.get("a"); ok {
+		t.Error("Expected removed entry to be gone")
+	}
+}
@@ -0,0 +1,124 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrUploadQuotaExceeded is returned when a client reached its daily upload limit.
+	ErrUploadQuotaExceeded = errors.New("daily upload quota exceeded")
+	// ErrStorageQuotaExceeded is returned when an upload would exceed a client's stored-bytes limit.
+	ErrStorageQuotaExceeded = errors.New("storage quota exceeded")
+)
+
+// QuotaUsage reports the accounted usage of one client (API key or IP).
+type QuotaUsage struct {
+	Key           string `json:"key"`
+	Day           string `json:"day"`
+	UploadsToday  int    `json:"uploadsToday"`
+	UploadedBytes int64  `json:"uploadedBytes"`
+}
+
+// quotaTracker counts uploads per client and calendar day as well as the total
+// uploaded bytes per client. Usage is kept in memory and resets on restart.
+type quotaTracker struct {
+	mu             sync.Mutex
+	uploadsPerDay  int
+	maxStoredBytes int64
+	loc            *time.Location
+	usage          map[string]*QuotaUsage
+	nowFn          func() time.Time
+}
+
+func newQuotaTracker(uploadsPerDay int, maxStoredBytes int64, loc *time.Location) *quotaTracker {
+	return &quotaTracker{
+		uploadsPerDay:  uploadsPerDay,
+		maxStoredBytes: maxStoredBytes,
+		loc:            loc,
+		usage:          make(map[string]*QuotaUsage),
+		nowFn:          time.Now,
+	}
+}
+
+// reserve accounts one upload of size bytes for key, returning a function that
+// undoes the reservation if the upload fails. Limits <= 0 are unlimited.
+func (q *quotaTracker) reserve(key string, size int64) (release func(), err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	u := q.usageFor(key)
+	if q.uploadsPerDay > 0 && u.UploadsToday >= q.uploadsPerDay {
+		return nil, ErrUploadQuotaExceeded
+	}
+	if q.maxStoredBytes > 0 && u.UploadedBytes+size > q.maxStoredBytes {
+		return nil, ErrStorageQuotaExceeded
+	}
+	u.UploadsToday++
+	u.UploadedBytes += size
+	day := u.Day
+
+	return func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		if u, ok := q.usage[key]; ok {
+			if u.Day == day {
+				u.UploadsToday--
+			}
+			u.UploadedBytes -= size
+		}
+	}, nil
+}
+
+// usageFor returns the usage entry for key, starting a new day's count when the
+// date changed. Callers must hold q.mu.
+func (q *quotaTracker) usageFor(key string) *QuotaUsage {
+	today := q.nowFn().In(q.loc).Format("2006-01-02")
+	u, ok := q.usage[key]
+	if !ok {
+		u = &QuotaUsage{Key: key, Day: today}
+		q.usage[key] = u
+	}
+	if u.Day != today {
+		u.Day = today
+		u.UploadsToday = 0
+	}
+	return u
+}
+
+// snapshot returns a copy of all usage entries sorted by key.
+func (q *quotaTracker) snapshot() []QuotaUsage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	result := make([]QuotaUsage, 0, len(q.usage))
+	for key := range q.usage {
+		result = append(result, *q.usageFor(key))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result
+}
+
+// reset clears the usage of key and reports whether it existed.
+func (q *quotaTracker) reset(key string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	_, ok := q.usage[key]
+	delete(q.usage, key)
+	return ok
+}
+
+// QuotaKey identifies the client for quota accounting: a digest of its API key
+// when one is sent (so keys never show up in admin listings), else its IP.
+func QuotaKey(apiKey, ip string) string {
+	if apiKey != "" {
+		h := sha256.Sum256([]byte(apiKey))
+		return "key:" + hex.EncodeToString(h[:8])
+	}
+	return "ip:" + ip
+}
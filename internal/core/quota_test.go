@@ -0,0 +1,65 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestQuotaTracker_UploadsPerDay(t *testing.T) {
+	q := newQuotaTracker(2, 0, time.UTC)
+	now := time.Date(2026, 3, 1, 23, 0, 0, 0, time.UTC)
+	q.nowFn = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if _, err := q.reserve("ip:1.2.3.4", 10); err != nil {
+			t.Fatalf("reserve %d failed: %v", i, err)
+		}
+	}
+	if _, err := q.reserve("ip:1.2.3.4", 10); !errors.Is(err, ErrUploadQuotaExceeded) {
+		t.Fatalf("Expected ErrUploadQuotaExceeded, got %v", err)
+	}
+	if _, err := q.reserve("ip:5.6.7.8", 10); err != nil {
+		t.Errorf("Expected other clients to be unaffected, got %v", err)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, err := q.reserve("ip:1.2.3.4", 10); err != nil {
+		t.Errorf("Expected count to reset on the next day, got %v", err)
+	}
+}
+
+func TestQuotaTracker_StoredBytesAndRelease(t *testing.T) {
+	q := newQuotaTracker(0, 100, time.UTC)
+
+	release, err := q.reserve("key:a", 80)
+	if err != nil {
+		t.Fatalf("reserve failed: %v", err)
+	}
+	if _, err := q.reserve("key:a", 30); !errors.Is(err, ErrStorageQuotaExceeded) {
+		t.Fatalf("Expected ErrStorageQuotaExceeded, got %v", err)
+	}
+
+	release()
+	if _, err := q.reserve("key:a", 30); err != nil {
+		t.Errorf("Expected released bytes to be available again, got %v", err)
+	}
+	usage := q.snapshot()
+	if len(usage) != 1 || usage[0].UploadsToday != 1 || usage[0].UploadedBytes != 30 {
+		t.Errorf("Unexpected usage after release: %+v", usage)
+	}
+
+	if !q.reset("key:a") || q.reset("key:a") {
+		t.Error("Expected reset to report whether the key was tracked")
+	}
+}
+
+func TestQuotaKey(t *testing.T) {
+	if got := QuotaKey("", "10.0.0.1"); got != "ip:10.0.0.1" {
+		t.Errorf("Expected IP key, got %q", got)
+	}
+	got := QuotaKey("secret", "10.0.0.1")
+	if got == QuotaKey("other", "10.0.0.1") || len(got) != len("key:")+16 {
+		t.Errorf("Expected distinct hashed API key, got %q", got)
+	}
+}
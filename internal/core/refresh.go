@@ -0,0 +1,97 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+// ErrRefreshTooSoon is returned when a change would show another image before
+// the panel's minimum refresh interval has passed.
+var ErrRefreshTooSoon = errors.New("panel refreshed too recently")
+
+// ErrCurrentImageChanged is returned when the current image changed, e.g. by
+// the daily rotation or a concurrent request, between reading the order and
+// applying a reorder based on it.
+var ErrCurrentImageChanged = database.ErrCurrentImageChanged
+
+// RefreshCooldownError reports how long to wait before the current image may
+// change again. It wraps ErrRefreshTooSoon.
+type RefreshCooldownError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RefreshCooldownError) Error() string {
+	return fmt.Sprintf("%v; try again in %s", ErrRefreshTooSoon, e.RetryAfter.Round(time.Second))
+}
+
+func (e *RefreshCooldownError) Unwrap() error { return ErrRefreshTooSoon }
+
+// refreshTracker remembers when the current image was last changed by a
+// request, as the daily rotation is recorded in the database.
+type refreshTracker struct {
+	mu   sync.Mutex
+	last time.Time
+}
+
+// ReorderImages is UpdateImageOrder for changes requested by users. When the
+// change shows another image, it fails with a *RefreshCooldownError within
+// panel.minRefreshInterval of the last change of the current image. The order
+// is only applied if the current image is still the one it was based on, so a
+// concurrent rotation or reorder fails with ErrCurrentImageChanged instead of
+// being overwritten.
+func (service *CoreService) ReorderImages(ctx context.Context, order []string) error {
+	if len(order) == 0 {
+		return nil
+	}
+	ids, err := service.getOrderedImageIDs(ctx)
+	if err != nil {
+		return err
+	}
+	current := ""
+	if len(ids) > 0 {
+		current = ids[0]
+	}
+	if current == order[0] {
+		return service.swapCurrentImage(ctx, current, order)
+	}
+
+	service.refreshes.mu.Lock()
+	defer service.refreshes.mu.Unlock()
+	if wait := service.refreshCooldown(ctx); wait > 0 {
+		return &RefreshCooldownError{RetryAfter: wait}
+	}
+	if err := service.swapCurrentImage(ctx, current, order); err != nil {
+		return err
+	}
+	service.refreshes.last = service.nowFn()
+	return nil
+}
+
+// swapCurrentImage applies order in one write if expectedID is still the
+// current image.
+func (service *CoreService) swapCurrentImage(ctx context.Context, expectedID string, order []string) error {
+	defer service.currentImages.invalidate()
+	return service.databaseService.SwapCurrentImage(ctx, expectedID, order)
+}
+
+// refreshCooldown returns how long the current image must stay on the panel;
+// the caller holds service.refreshes.mu.
+func (service *CoreService) refreshCooldown(ctx context.Context) time.Duration {
+	interval := service.config.Panel.MinRefreshInterval
+	if interval <= 0 {
+		return 0
+	}
+	last := service.refreshes.last
+	if rotated, err := service.databaseService.GetLastRotatedTime(ctx); err == nil && rotated.After(last) {
+		last = rotated
+	}
+	if last.IsZero() {
+		return 0
+	}
+	return max(0, last.Add(interval).Sub(service.nowFn()))
+}
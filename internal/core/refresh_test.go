@@ -0,0 +1,84 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+func TestMoveImage_RespectsMinRefreshInterval(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{Panel: config.Panel{MinRefreshInterval: 10 * time.Minute}})
+	ctx := context.Background()
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	service.nowFn = func() time.Time { return now }
+
+	ids := make([]string, 3)
+	for i := range ids {
+		img, err := service.AddImage(ctx, testPNG(t, 4, 4), "", database.Attribution{})
+		if err != nil {
+			t.Fatalf("AddImage failed: %v", err)
+		}
+		ids[i] = img.ID
+	}
+	front := func(n int) ImagePosition { return ImagePosition{Index: &n} }
+
+	if _, err := service.MoveImage(ctx, ids[2], front(0)); err != nil {
+		t.Fatalf("expected the first change to pass, got %v", err)
+	}
+	now = now.Add(4 * time.Minute)
+	_, err := service.MoveImage(ctx, ids[1], front(0))
+	var cooldown *RefreshCooldownError
+	if !errors.As(err, &cooldown) || !errors.Is(err, ErrRefreshTooSoon) || cooldown.RetryAfter != 6*time.Minute {
+		t.Fatalf("expected a cooldown of 6m, got %v", err)
+	}
+	// Moves behind the current image do not refresh the panel.
+	if _, err := service.MoveImage(ctx, ids[1], front(2)); err != nil {
+		t.Errorf("expected a move behind the current image to pass, got %v", err)
+	}
+
+	now = now.Add(6 * time.Minute)
+	if _, err := service.MoveImage(ctx, ids[1], front(0)); err != nil {
+		t.Errorf("expected the change to pass after the cooldown, got %v", err)
+	}
+	if info, _ := service.GetRotationInfo(ctx, ""); info.MinRefreshIntervalSeconds != 600 {
+		t.Errorf("expected the interval in the rotation info, got %d", info.MinRefreshIntervalSeconds)
+	}
+}
+
+func TestReorderImages_StaleOrderKeepsRotationConsistent(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{})
+	ctx := context.Background()
+	ids := make([]string, 3)
+	for i := range ids {
+		img, err := service.AddImage(ctx, testPNG(t, 4, 4), "", database.Attribution{})
+		if err != nil {
+			t.Fatalf("AddImage failed: %v", err)
+		}
+		ids[i] = img.ID
+	}
+
+	// A client computed this order before ids[1] was deleted and another
+	// image was uploaded.
+	stale := []string{ids[1], ids[2], ids[0]}
+	if err := service.DeleteImage(ctx, ids[1]); err != nil {
+		t.Fatalf("DeleteImage failed: %v", err)
+	}
+	added, err := service.AddImage(ctx, testPNG(t, 4, 4), "", database.Attribution{})
+	if err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+	if err := service.ReorderImages(ctx, stale); err != nil {
+		t.Fatalf("ReorderImages failed: %v", err)
+	}
+	current, err := service.GetImageForTime(ctx, time.Now())
+	if err != nil || current != ids[2] {
+		t.Errorf("expected %s to become current instead of the deleted image, got %q (%v)", ids[2], current, err)
+	}
+	if order, _ := service.GetOrderedImageIDs(ctx); len(order) != 3 || order[2] != added.ID {
+		t.Errorf("expected the new upload to be kept at the end, got %v", order)
+	}
+}
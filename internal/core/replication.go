@@ -0,0 +1,246 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+	"github.com/jo-hoe/goframe/internal/resilience"
+)
+
+// ReplicaSourcePrefix marks images mirrored from a primary; the primary's image
+// ID follows the prefix in the image source.
+const ReplicaSourcePrefix = "replica:"
+
+// ChangeSet is returned by the primary to replicating secondaries.
+type ChangeSet struct {
+	// Cursor is passed as "since" on the next poll.
+	Cursor time.Time `json:"cursor"`
+	// Order is the complete display order on the primary.
+	Order []string `json:"order"`
+	// Images holds the images created after the requested cursor.
+	Images []ChangedImage `json:"images"`
+}
+
+// ChangedImage describes an image a secondary may need to mirror.
+type ChangedImage struct {
+	ID          string               `json:"id"`
+	CreatedAt   time.Time            `json:"createdAt"`
+	Attribution database.Attribution `json:"attribution,omitzero"`
+	// OriginalURL is relative to the primary's base URL.
+	OriginalURL string `json:"originalUrl"`
+}
+
+// GetChanges returns the full order and all images created after since.
+func (service *CoreService) GetChanges(ctx context.Context, since time.Time) (*ChangeSet, error) {
+	images, err := service.databaseService.GetImageMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cs := &ChangeSet{Cursor: since, Order: make([]string, 0, len(images)), Images: []ChangedImage{}}
+	for _, img := range images {
+		cs.Order = append(cs.Order, img.ID)
+		if !img.CreatedAt.After(since) {
+			continue
+		}
+		originalURL := "/api/images/" + img.ID + "/original.png"
+		if img.OriginalHash != "" {
+			originalURL = BlobURLPrefix + img.OriginalHash + ".png"
+		}
+		cs.Images = append(cs.Images, ChangedImage{
+			ID:          img.ID,
+			CreatedAt:   img.CreatedAt,
+			Attribution: img.Attribution,
+			OriginalURL: originalURL,
+		})
+		if img.CreatedAt.After(cs.Cursor) {
+			cs.Cursor = img.CreatedAt
+		}
+	}
+	return cs, nil
+}
+
+// Replicator keeps a secondary instance in sync with a primary by polling its
+// change feed. Mirrored originals run through the secondary's own pipeline so
+// each frame keeps its own device profile.
+type Replicator struct {
+	service    *CoreService
+	primaryURL string
+	interval   time.Duration
+	httpClient *http.Client
+	cursor     time.Time
+}
+
+// NewReplicator creates a Replicator for the given configuration.
+func NewReplicator(service *CoreService, cfg config.Replication) *Replicator {
+	return &Replicator{
+		service:    service,
+		primaryURL: strings.TrimRight(cfg.PrimaryURL, "/"),
+		interval:   cfg.Interval,
+		httpClient: &http.Client{Timeout: 2 * time.Minute},
+	}
+}
+
+// Run polls the primary until ctx is cancelled.
+func (r *Replicator) Run(ctx context.Context) {
+	slog.Info("Replicator: starting", "primary", r.primaryURL, "interval", r.interval)
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		if err := r.SyncOnce(ctx); errors.Is(err, resilience.ErrOpen) {
+			slog.Debug("Replicator: primary paused after repeated failures", "primary", r.primaryURL, "error", err)
+		} else if err != nil {
+			slog.Warn("Replicator: sync failed", "primary", r.primaryURL, "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SyncOnce fetches new images from the primary, removes mirrored images the
+// primary no longer has and applies the primary's order. Images uploaded
+// directly to the secondary are kept after the mirrored ones.
+func (r *Replicator) SyncOnce(ctx context.Context) error {
+	changes, err := r.fetchChanges(ctx, r.cursor)
+	if err != nil {
+		return err
+	}
+
+	local, err := r.service.GetOrderedImages(ctx)
+	if err != nil {
+		return fmt.Errorf("listing local images: %w", err)
+	}
+	mirrored := make(map[string]string, len(local)) // primary ID -> local ID
+	var localOnly []string
+	for _, img := range local {
+		if primaryID, ok := strings.CutPrefix(img.Source, ReplicaSourcePrefix); ok {
+			mirrored[primaryID] = img.ID
+		} else {
+			localOnly = append(localOnly, img.ID)
+		}
+	}
+
+	// Images that re-enter the rotation on the primary, e.g. after being
+	// unarchived or approved, are older than the cursor and missing from the
+	// delta; fetch the full feed to mirror them.
+	if !r.cursor.IsZero() && slices.ContainsFunc(changes.Order, func(id string) bool {
+		_, ok := mirrored[id]
+		return !ok && !slices.ContainsFunc(changes.Images, func(img ChangedImage) bool { return img.ID == id })
+	}) {
+		if changes, err = r.fetchChanges(ctx, time.Time{}); err != nil {
+			return err
+		}
+	}
+
+	complete := true
+	for _, img := range changes.Images {
+		if _, ok := mirrored[img.ID]; ok || !slices.Contains(changes.Order, img.ID) {
+			continue
+		}
+		localID, err := r.mirrorImage(ctx, img)
+		if err != nil {
+			// Keep the cursor so the image is retried on the next poll.
+			slog.Warn("Replicator: failed to mirror image", "primaryId", img.ID, "error", err)
+			complete = false
+			continue
+		}
+		mirrored[img.ID] = localID
+	}
+
+	for primaryID, localID := range mirrored {
+		if slices.Contains(changes.Order, primaryID) {
+			continue
+		}
+		slog.Info("Replicator: removing image deleted on primary", "primaryId", primaryID, "id", localID)
+		if err := r.service.DeleteImage(ctx, localID); err != nil {
+			return fmt.Errorf("deleting mirrored image %s: %w", localID, err)
+		}
+		delete(mirrored, primaryID)
+	}
+
+	order := make([]string, 0, len(mirrored)+len(localOnly))
+	for _, primaryID := range changes.Order {
+		if localID, ok := mirrored[primaryID]; ok {
+			order = append(order, localID)
+		}
+	}
+	order = append(order, localOnly...)
+	current, err := r.service.GetOrderedImageIDs(ctx)
+	if err != nil {
+		return fmt.Errorf("reading local order: %w", err)
+	}
+	if !slices.Equal(current, order) {
+		if err := r.service.UpdateImageOrder(ctx, order); err != nil {
+			return fmt.Errorf("updating local order: %w", err)
+		}
+	}
+
+	if complete {
+		r.cursor = changes.Cursor
+	}
+	return nil
+}
+
+func (r *Replicator) fetchChanges(ctx context.Context, since time.Time) (*ChangeSet, error) {
+	u := r.primaryURL + "/api/sync/changes"
+	if !since.IsZero() {
+		u += "?since=" + url.QueryEscape(since.Format(time.RFC3339Nano))
+	}
+	body, err := r.get(ctx, u)
+	if err != nil {
+		return nil, fmt.Errorf("fetching changes: %w", err)
+	}
+	var changes ChangeSet
+	if err := json.Unmarshal(body, &changes); err != nil {
+		return nil, fmt.Errorf("decoding changes: %w", err)
+	}
+	return &changes, nil
+}
+
+func (r *Replicator) mirrorImage(ctx context.Context, img ChangedImage) (string, error) {
+	data, err := r.get(ctx, r.primaryURL+img.OriginalURL)
+	if err != nil {
+		return "", fmt.Errorf("downloading original: %w", err)
+	}
+	apiImg, err := r.service.AddImage(ctx, data, ReplicaSourcePrefix+img.ID, img.Attribution)
+	if err != nil {
+		return "", err
+	}
+	slog.Info("Replicator: mirrored image", "primaryId", img.ID, "id", apiImg.ID)
+	return apiImg.ID, nil
+}
+
+// get downloads u from the primary, retrying transient failures.
+func (r *Replicator) get(ctx context.Context, u string) ([]byte, error) {
+	var body []byte
+	err := r.service.integrations.Breaker("replication").Retry(ctx, func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return resilience.Permanent(err)
+		}
+		resp, err := r.httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != http.StatusOK {
+			return resilience.PermanentForStatus(fmt.Errorf("GET %s: unexpected status %d", u, resp.StatusCode), resp.StatusCode)
+		}
+		body, err = io.ReadAll(resp.Body)
+		return err
+	})
+	return body, err
+}
@@ -0,0 +1,171 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+// newPrimaryServer serves the subset of the API used by the Replicator.
+func newPrimaryServer(t *testing.T, primary *CoreService) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/sync/changes":
+			var since time.Time
+			if raw := r.URL.Query().Get("since"); raw != "" {
+				since, _ = time.Parse(time.RFC3339Nano, raw)
+			}
+			changes, err := primary.GetChanges(r.Context(), since)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(changes)
+		case strings.HasPrefix(r.URL.Path, BlobURLPrefix):
+			data, err := primary.GetBlobByHash(r.Context(), strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, BlobURLPrefix), ".png"))
+			if err != nil {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write(data)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestReplicator_MirrorsImagesOrderAndDeletes(t *testing.T) {
+	ctx := context.Background()
+	primary, _ := newTestCoreService(t, &config.ServiceConfig{})
+	secondary, _ := newTestCoreService(t, &config.ServiceConfig{})
+	srv := newPrimaryServer(t, primary)
+
+	first, err := primary.AddImage(ctx, testPNG(t, 4, 4), "", database.Attribution{Author: "Jane"})
+	if err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+	second, err := primary.AddImage(ctx, testPNG(t, 6, 4), "", database.Attribution{})
+	if err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+	localOnly, err := secondary.AddImage(ctx, testPNG(t, 2, 2), "", database.Attribution{})
+	if err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+
+	replicator := NewReplicator(secondary, config.Replication{PrimaryURL: srv.URL + "/", Interval: time.Minute})
+	if err := replicator.SyncOnce(ctx); err != nil {
+		t.Fatalf("SyncOnce failed: %v", err)
+	}
+
+	images, err := secondary.GetOrderedImages(ctx)
+	if err != nil {
+		t.Fatalf("GetOrderedImages failed: %v", err)
+	}
+	if len(images) != 3 {
+		t.Fatalf("Expected 3 images on secondary, got %d", len(images))
+	}
+	if images[0].Source != ReplicaSourcePrefix+first.ID || images[1].Source != ReplicaSourcePrefix+second.ID {
+		t.Errorf("Expected mirrored images first in primary order, got sources %q, %q", images[0].Source, images[1].Source)
+	}
+	if images[0].Attribution.Author != "Jane" {
+		t.Errorf("Expected attribution to be mirrored, got %+v", images[0].Attribution)
+	}
+	if images[2].ID != localOnly.ID {
+		t.Errorf("Expected local-only image last, got %q", images[2].ID)
+	}
+
+	if err := primary.UpdateImageOrder(ctx, []string{second.ID, first.ID}); err != nil {
+		t.Fatalf("UpdateImageOrder failed: %v", err)
+	}
+	if err := primary.DeleteImage(ctx, first.ID); err != nil {
+		t.Fatalf("DeleteImage failed: %v", err)
+	}
+	if err := replicator.SyncOnce(ctx); err != nil {
+		t.Fatalf("second SyncOnce failed: %v", err)
+	}
+
+	images, err = secondary.GetOrderedImages(ctx)
+	if err != nil {
+		t.Fatalf("GetOrderedImages failed: %v", err)
+	}
+	if len(images) != 2 || images[0].Source != ReplicaSourcePrefix+second.ID || images[1].ID != localOnly.ID {
+		t.Errorf("Expected deleted image to be removed, got %d images", len(images))
+	}
+}
+
+func TestReplicator_MirrorsUnarchivedImages(t *testing.T) {
+	ctx := context.Background()
+	primary, _ := newTestCoreService(t, &config.ServiceConfig{})
+	secondary, _ := newTestCoreService(t, &config.ServiceConfig{})
+	srv := newPrimaryServer(t, primary)
+
+	first, err := primary.AddImage(ctx, testPNG(t, 4, 4), "", database.Attribution{Author: "Jane"})
+	if err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+	if _, err := primary.AddImage(ctx, testPNG(t, 6, 4), "", database.Attribution{}); err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+
+	replicator := NewReplicator(secondary, config.Replication{PrimaryURL: srv.URL, Interval: time.Minute})
+	if err := primary.ArchiveImages(ctx, []string{first.ID}); err != nil {
+		t.Fatalf("ArchiveImages failed: %v", err)
+	}
+	if err := replicator.SyncOnce(ctx); err != nil {
+		t.Fatalf("SyncOnce failed: %v", err)
+	}
+	if err := primary.UnarchiveImages(ctx, []string{first.ID}); err != nil {
+		t.Fatalf("UnarchiveImages failed: %v", err)
+	}
+	for i := range 3 {
+		if err := replicator.SyncOnce(ctx); err != nil {
+			t.Fatalf("SyncOnce %d failed: %v", i+1, err)
+		}
+	}
+
+	images, err := secondary.GetOrderedImages(ctx)
+	if err != nil {
+		t.Fatalf("GetOrderedImages failed: %v", err)
+	}
+	if len(images) != 2 {
+		t.Fatalf("Expected 2 images on secondary, got %d", len(images))
+	}
+	if images[0].Source != ReplicaSourcePrefix+first.ID && images[1].Source != ReplicaSourcePrefix+first.ID {
+		t.Errorf("Expected the unarchived image to be mirrored, got sources %q, %q", images[0].Source, images[1].Source)
+	}
+}
+
+func TestGetChanges_FiltersBySince(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newTestCoreService(t, &config.ServiceConfig{})
+	if _, err := service.AddImage(ctx, testPNG(t, 4, 4), "", database.Attribution{}); err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+
+	all, err := service.GetChanges(ctx, time.Time{})
+	if err != nil {
+		t.Fatalf("GetChanges failed: %v", err)
+	}
+	if len(all.Images) != 1 || len(all.Order) != 1 {
+		t.Fatalf("Expected 1 image and order entry, got %d/%d", len(all.Images), len(all.Order))
+	}
+
+	none, err := service.GetChanges(ctx, all.Cursor)
+	if err != nil {
+		t.Fatalf("GetChanges failed: %v", err)
+	}
+	if len(none.Images) != 0 || len(none.Order) != 1 {
+		t.Errorf("Expected no new images but full order, got %d/%d", len(none.Images), len(none.Order))
+	}
+}
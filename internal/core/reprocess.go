@@ -0,0 +1,76 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+// ReprocessImage runs the configured pipeline again on the stored original of
+// image id, e.g. after the command configuration changed. The enhancement
+// preset chosen at upload stays pinned. In on-demand mode the result replaces
+// the cached rendition instead of a stored blob. It fails with ErrImageLocked
+// while another operation modifies the image.
+func (service *CoreService) ReprocessImage(ctx context.Context, id string) error {
+	unlock, err := service.locks.tryLock("reprocess", id)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	processed, err := service.renderReprocessed(ctx, id)
+	if err != nil {
+		return err
+	}
+	return service.storeReprocessed(ctx, id, processed)
+}
+
+// renderReprocessed returns the freshly processed image id without storing it.
+func (service *CoreService) renderReprocessed(ctx context.Context, id string) ([]byte, error) {
+	img, err := service.databaseService.GetImageByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrImageNotFound, id)
+	}
+	original, err := service.databaseService.GetImageData(ctx, id, "original")
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("CoreService.renderReprocessed: reprocessing image", "id", id, "bytes", len(original))
+	processed, preset, err := service.processImage(original, img.Attribution, img.EnhancementPreset, img.Fit, img.FlatArt, img.Crop)
+	if err != nil {
+		service.alerts.processingFailed(err)
+		return nil, err
+	}
+	service.alerts.processingSucceeded()
+	if preset != "" && img.EnhancementPreset == "" {
+		if err := service.databaseService.SetEnhancementPreset(ctx, id, preset); err != nil {
+			slog.Warn("CoreService.renderReprocessed: failed to store enhancement preset", "id", id, "error", err)
+		}
+	}
+	return processed, nil
+}
+
+// storeReprocessed replaces the processed image id and drops renditions derived
+// from the previous one.
+func (service *CoreService) storeReprocessed(ctx context.Context, id string, processed []byte) error {
+	if service.processesOnDemand() {
+		if service.processedCache != nil {
+			service.processedCache.put(id, processed)
+		}
+		service.renditions.invalidate()
+	} else if err := service.databaseService.PutProcessedImage(ctx, id, processed); err != nil {
+		return err
+	}
+	service.matted.removePrefix(id + "/")
+	service.calibrated.removePrefix(id + "/")
+	service.experiments.removePrefix(id + "/")
+	service.profiled.removePrefix(id + "/")
+	service.pregenerated.remove(id)
+	service.currentImages.invalidate()
+	if img, err := service.databaseService.GetImageByID(ctx, id); err == nil {
+		service.recordProcessed(ctx, id, database.ImageEventReprocessed, img.EnhancementPreset, img.Fit, img.FlatArt, img.Crop)
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrReprocessRunning is returned when reprocessing all images is already in progress.
+var ErrReprocessRunning = errors.New("reprocessing already in progress")
+
+// ReprocessProgress is a snapshot of reprocessing all images.
+type ReprocessProgress struct {
+	Running   bool `json:"running"`
+	Cancelled bool `json:"cancelled"`
+	Total     int  `json:"total"`
+	// Done counts the finished images, including failed ones.
+	Done   int `json:"done"`
+	Failed int `json:"failed"`
+	// Current lists the images being processed right now.
+	Current    []string  `json:"current"`
+	StartedAt  time.Time `json:"startedAt,omitzero"`
+	FinishedAt time.Time `json:"finishedAt,omitzero"`
+	// ETASeconds estimates the remaining time from the average time per image;
+	// -1 until the first image finished and 0 when not running.
+	ETASeconds int64 `json:"etaSeconds"`
+}
+
+// reprocessJob tracks the reprocess-all run and notifies progress subscribers.
+type reprocessJob struct {
+	mu          sync.Mutex
+	progress    ReprocessProgress
+	cancel      context.CancelFunc
+	subscribers map[chan ReprocessProgress]struct{}
+}
+
+// snapshot returns a copy of the progress; j.mu must be held.
+func (j *reprocessJob) snapshot() ReprocessProgress {
+	p := j.progress
+	p.Current = slices.Clone(p.Current)
+	if p.Current == nil {
+		p.Current = []string{}
+	}
+	p.ETASeconds = -1
+	switch {
+	case !p.Running:
+		p.ETASeconds = 0
+	case p.Done > 0:
+		perImage := time.Since(p.StartedAt) / time.Duration(p.Done)
+		p.ETASeconds = int64((perImage * time.Duration(p.Total-p.Done)).Seconds() + 0.5)
+	}
+	return p
+}
+
+// update applies change and sends the new progress to all subscribers. Slow
+// subscribers only miss intermediate snapshots, never the latest one.
+func (j *reprocessJob) update(change func(*ReprocessProgress)) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	change(&j.progress)
+	p := j.snapshot()
+	for ch := range j.subscribers {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- p
+	}
+}
+
+// StartReprocessAll reprocesses every stored image (see ReprocessImage) in the
+// background with reprocessing.workers images in parallel. It returns
+// ErrReprocessRunning while a previous run is in progress.
+func (service *CoreService) StartReprocessAll(ctx context.Context) (ReprocessProgress, error) {
+	images, err := service.allImages(ctx)
+	if err != nil {
+		return ReprocessProgress{}, err
+	}
+
+	job := &service.reprocessing
+	job.mu.Lock()
+	if job.progress.Running {
+		job.mu.Unlock()
+		return ReprocessProgress{}, ErrReprocessRunning
+	}
+	runCtx, cancel := context.WithCancel(context.Background())
+	job.cancel = cancel
+	job.progress = ReprocessProgress{Running: true, Total: len(images), StartedAt: time.Now()}
+	p := job.snapshot()
+	job.mu.Unlock()
+
+	ids := make([]string, len(images))
+	for i, img := range images {
+		ids[i] = img.ID
+	}
+	slog.Info("CoreService.StartReprocessAll: reprocessing all images", "count", len(ids), "workers", service.reprocessWorkers())
+	go service.runReprocessAll(runCtx, ids)
+	return p, nil
+}
+
+func (service *CoreService) reprocessWorkers() int {
+	return max(service.config.Reprocessing.Workers, 1)
+}
+
+func (service *CoreService) runReprocessAll(ctx context.Context, ids []string) {
+	job := &service.reprocessing
+	queue := make(chan string)
+	var wg sync.WaitGroup
+	for range service.reprocessWorkers() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range queue {
+				job.update(func(p *ReprocessProgress) { p.Current = append(p.Current, id) })
+				// Images already started are finished even when the run is cancelled.
+				err := service.ReprocessImage(context.WithoutCancel(ctx), id)
+				if err != nil {
+					slog.Warn("CoreService.runReprocessAll: failed to reprocess image", "id", id, "error", err)
+				}
+				job.update(func(p *ReprocessProgress) {
+					p.Current = slices.DeleteFunc(p.Current, func(c string) bool { return c == id })
+					p.Done++
+					if err != nil {
+						p.Failed++
+					}
+				})
+			}
+		}()
+	}
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			break
+		}
+		select {
+		case queue <- id:
+		case <-ctx.Done():
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	job.update(func(p *ReprocessProgress) {
+		p.Running = false
+		p.Cancelled = ctx.Err() != nil
+		p.FinishedAt = time.Now()
+	})
+	job.mu.Lock()
+	job.cancel()
+	job.mu.Unlock()
+	p := service.GetReprocessProgress()
+	slog.Info("CoreService.runReprocessAll: finished", "done", p.Done, "failed", p.Failed, "total", p.Total, "cancelled", p.Cancelled)
+}
+
+// CancelReprocessAll stops a running reprocess-all run after the images in
+// progress and reports whether one was running.
+func (service *CoreService) CancelReprocessAll() bool {
+	job := &service.reprocessing
+	job.mu.Lock()
+	defer job.mu.Unlock()
+	if !job.progress.Running {
+		return false
+	}
+	job.cancel()
+	return true
+}
+
+// GetReprocessProgress returns the progress of the current or last reprocess-all run.
+func (service *CoreService) GetReprocessProgress() ReprocessProgress {
+	job := &service.reprocessing
+	job.mu.Lock()
+	defer job.mu.Unlock()
+	return job.snapshot()
+}
+
+// SubscribeReprocessProgress returns a channel receiving the current progress
+// and every later change. The returned func unsubscribes.
+func (service *CoreService) SubscribeReprocessProgress() (<-chan ReprocessProgress, func()) {
+	job := &service.reprocessing
+	ch := make(chan ReprocessProgress, 1)
+	job.mu.Lock()
+	defer job.mu.Unlock()
+	if job.subscribers == nil {
+		job.subscribers = make(map[chan ReprocessProgress]struct{})
+	}
+	job.subscribers[ch] = struct{}{}
+	ch <- job.snapshot()
+	return ch, func() {
+		job.mu.Lock()
+		defer job.mu.Unlock()
+		delete(job.subscribers, ch)
+	}
+}
@@ -0,0 +1,72 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/config"
+)
+
+func waitForReprocess(t *testing.T, updates <-chan ReprocessProgress) ReprocessProgress {
+	t.Helper()
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case p := <-updates:
+			if !p.Running && !p.FinishedAt.IsZero() {
+				return p
+			}
+		case <-timeout:
+			t.Fatal("reprocessing did not finish")
+		}
+	}
+}
+
+func TestStartReprocessAll(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{Reprocessing: config.Reprocessing{Workers: 3}})
+	ctx := context.Background()
+	addTestImages(t, service, 5)
+	updates, unsubscribe := service.SubscribeReprocessProgress()
+	defer unsubscribe()
+
+	started, err := service.StartReprocessAll(ctx)
+	if err != nil {
+		t.Fatalf("StartReprocessAll failed: %v", err)
+	}
+	if !started.Running || started.Total != 5 {
+		t.Errorf("unexpected initial progress: %+v", started)
+	}
+
+	p := waitForReprocess(t, updates)
+	if p.Done != 5 || p.Failed != 0 || p.Cancelled || len(p.Current) != 0 || p.ETASeconds != 0 {
+		t.Errorf("unexpected final progress: %+v", p)
+	}
+	if service.CancelReprocessAll() {
+		t.Error("expected nothing to cancel after the run finished")
+	}
+}
+
+func TestStartReprocessAll_RejectsConcurrentRunAndCancels(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{Reprocessing: config.Reprocessing{Workers: 1}})
+	ctx := context.Background()
+	addTestImages(t, service, 50)
+	updates, unsubscribe := service.SubscribeReprocessProgress()
+	defer unsubscribe()
+
+	if _, err := service.StartReprocessAll(ctx); err != nil {
+		t.Fatalf("StartReprocessAll failed: %v", err)
+	}
+	if _, err := service.StartReprocessAll(ctx); !errors.Is(err, ErrReprocessRunning) {
+		t.Errorf("expected ErrReprocessRunning, got %v", err)
+	}
+	if !service.CancelReprocessAll() {
+		t.Fatal("expected the running job to be cancelled")
+	}
+
+	p := waitForReprocess(t, updates)
+	if !p.Cancelled || p.Done >= p.Total || p.Failed != 0 {
+		t.Errorf("expected a cancelled run without failures, got %+v", p)
+	}
+}
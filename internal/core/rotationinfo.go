@@ -0,0 +1,52 @@
+package core
+
+import (
+	"context"
+	"time"
+)
+
+// RotationInfo tells frames which image is current and when the next one is
+// due, so battery-powered devices can sleep until exactly then.
+type RotationInfo struct {
+	CurrentImageID string `json:"currentImageId"`
+	// Timezone is the IANA name of the zone whose midnight triggers rotation.
+	Timezone string `json:"timezone"`
+	// LastRotation is when the rotation last advanced; zero before the first rotation.
+	LastRotation time.Time `json:"lastRotation,omitzero"`
+	// NextRotation is the next midnight in Timezone.
+	NextRotation time.Time `json:"nextRotation"`
+	// SecondsUntilNextRotation spares devices without a synced clock the date math.
+	SecondsUntilNextRotation int64 `json:"secondsUntilNextRotation"`
+	// MinRefreshIntervalSeconds is the panel's recommended minimum time between
+	// refreshes; devices should not refresh more often. 0 means no limit.
+	MinRefreshIntervalSeconds int64 `json:"minRefreshIntervalSeconds,omitempty"`
+}
+
+// GetRotationInfo returns the image deviceID should show (see GetImageForDevice)
+// together with the configured timezone and the time of the next rotation.
+func (service *CoreService) GetRotationInfo(ctx context.Context, deviceID string) (*RotationInfo, error) {
+	id, err := service.GetImageForDevice(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	now := service.nowFn().In(service.tzLoc)
+	next := nextMidnight(now, service.tzLoc)
+	info := &RotationInfo{
+		CurrentImageID:            id,
+		Timezone:                  service.tzLoc.String(),
+		NextRotation:              next,
+		SecondsUntilNextRotation:  int64(next.Sub(now).Seconds() + 0.5),
+		MinRefreshIntervalSeconds: int64(service.config.Panel.MinRefreshInterval / time.Second),
+	}
+	if last, err := service.databaseService.GetLastRotatedTime(ctx); err == nil {
+		info.LastRotation = last.In(service.tzLoc)
+	}
+	return info, nil
+}
+
+// nextMidnight returns the start of the day after now in loc. time.Date
+// normalises days that begin at 01:00 because of a DST change.
+func nextMidnight(now time.Time, loc *time.Location) time.Time {
+	t := now.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+}
@@ -0,0 +1,56 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+func TestNextMidnight(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	tests := []struct {
+		now  time.Time
+		want time.Time
+	}{
+		{time.Date(2026, 3, 10, 15, 30, 0, 0, berlin), time.Date(2026, 3, 11, 0, 0, 0, 0, berlin)},
+		{time.Date(2026, 3, 10, 0, 0, 0, 0, berlin), time.Date(2026, 3, 11, 0, 0, 0, 0, berlin)},
+		// The day before the spring-forward change is still 24 hours long at midnight.
+		{time.Date(2026, 3, 28, 23, 0, 0, 0, berlin), time.Date(2026, 3, 29, 0, 0, 0, 0, berlin)},
+		// A UTC instant is interpreted in the configured zone.
+		{time.Date(2026, 12, 31, 23, 30, 0, 0, time.UTC), time.Date(2027, 1, 2, 0, 0, 0, 0, berlin)},
+	}
+	for _, tt := range tests {
+		if got := nextMidnight(tt.now, berlin); !got.Equal(tt.want) {
+			t.Errorf("nextMidnight(%v) = %v, want %v", tt.now, got, tt.want)
+		}
+	}
+}
+
+func TestGetRotationInfo(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{})
+	ctx := context.Background()
+
+	img, err := service.AddImage(ctx, testPNG(t, 4, 4), "", database.Attribution{})
+	if err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+	info, err := service.GetRotationInfo(ctx, "")
+	if err != nil {
+		t.Fatalf("GetRotationInfo failed: %v", err)
+	}
+	if info.CurrentImageID != img.ID || info.Timezone != "UTC" {
+		t.Errorf("unexpected rotation info: %+v", info)
+	}
+	if info.SecondsUntilNextRotation <= 0 || info.SecondsUntilNextRotation > 24*60*60 {
+		t.Errorf("expected next rotation within a day, got %ds", info.SecondsUntilNextRotation)
+	}
+	if h, m, s := info.NextRotation.Clock(); h != 0 || m != 0 || s != 0 {
+		t.Errorf("expected next rotation at midnight, got %v", info.NextRotation)
+	}
+}
@@ -0,0 +1,86 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// maxSimulationDays bounds GET /api/rotation/simulate to about a year.
+const maxSimulationDays = 366
+
+// ErrInvalidSimulationDays is returned for a simulation length outside 1..maxSimulationDays.
+var ErrInvalidSimulationDays = errors.New("invalid number of days")
+
+// SimulatedDay is one day of a rotation simulation.
+type SimulatedDay struct {
+	// Date is the calendar day in the rotation timezone, e.g. "2026-03-30".
+	Date string `json:"date"`
+	// ShowAt is the midnight that starts the day, with the zone's UTC offset.
+	ShowAt  time.Time `json:"showAt"`
+	ImageID string    `json:"imageId"`
+	// Label is the day with its weekday in the configured display format,
+	// e.g. "Mon 30.03.2026".
+	Label string `json:"label"`
+	// WeekStart is the first day of the day's week, e.g. "2026-03-30", so
+	// calendars can group days into rows.
+	WeekStart string `json:"weekStart"`
+	// Story names the story the image belongs to, if any.
+	Story string `json:"story,omitempty"`
+}
+
+// RotationSimulation lists the images a device will show day by day.
+type RotationSimulation struct {
+	Device   string `json:"device,omitempty"`
+	Timezone string `json:"timezone"`
+	// FirstDayOfWeek is the configured day weeks start on, e.g. "monday".
+	FirstDayOfWeek string         `json:"firstDayOfWeek"`
+	Days           []SimulatedDay `json:"days"`
+}
+
+// SimulateRotation returns the image deviceID will show on each of the next
+// days days, starting today, if the rotation order stays as it is. It applies
+// the same rules as GetImageForDevice: the order advances by one image per
+// day, frame groups share their offset and other devices rotate from a
+// position derived from their ID. Archived and pending images are not part of
+// the order and never appear. An empty rotation yields no days.
+func (service *CoreService) SimulateRotation(ctx context.Context, deviceID string, days int) (*RotationSimulation, error) {
+	if days < 1 || days > maxSimulationDays {
+		return nil, fmt.Errorf("%w: %d is not between 1 and %d", ErrInvalidSimulationDays, days, maxSimulationDays)
+	}
+	snapshot, err := service.loadRotationSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sim := &RotationSimulation{
+		Device:         deviceID,
+		Timezone:       service.tzLoc.String(),
+		FirstDayOfWeek: strings.ToLower(service.display.FirstDayOfWeek().String()),
+		Days:           []SimulatedDay{},
+	}
+	if len(snapshot.ids) == 0 {
+		return sim, nil
+	}
+
+	parts, err := service.storyParts(ctx, snapshot.ids)
+	if err != nil {
+		return nil, err
+	}
+	offset := snapshot.offsetFor(deviceID)
+	t := service.nowFn().In(service.tzLoc)
+	for i := range days {
+		showAt := time.Date(t.Year(), t.Month(), t.Day()+i, 0, 0, 0, 0, service.tzLoc)
+		id := snapshot.ids[(offset+i)%len(snapshot.ids)]
+		sim.Days = append(sim.Days, SimulatedDay{
+			Date:      showAt.Format(time.DateOnly),
+			ShowAt:    showAt,
+			ImageID:   id,
+			Label:     service.display.Day(showAt),
+			WeekStart: service.display.WeekStart(showAt).Format(time.DateOnly),
+			Story:     parts[id].story,
+		})
+	}
+	return sim, nil
+}
@@ -0,0 +1,81 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+func TestSimulateRotation(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{})
+	service.nowFn = func() time.Time { return time.Date(2026, 3, 30, 15, 0, 0, 0, time.UTC) }
+	ctx := context.Background()
+	ids := addTestImages(t, service, 3)
+	if err := service.SaveFrameGroup(ctx, database.FrameGroup{Name: "hall", Devices: []string{"kitchen"}, Offset: 2}); err != nil {
+		t.Fatalf("SaveFrameGroup failed: %v", err)
+	}
+
+	sim, err := service.SimulateRotation(ctx, "", 5)
+	if err != nil {
+		t.Fatalf("SimulateRotation failed: %v", err)
+	}
+	want := []string{ids[0], ids[1], ids[2], ids[0], ids[1]}
+	if len(sim.Days) != len(want) {
+		t.Fatalf("expected %d days, got %d", len(want), len(sim.Days))
+	}
+	for i, day := range sim.Days {
+		if day.ImageID != want[i] {
+			t.Errorf("day %d: expected %s, got %s", i, want[i], day.ImageID)
+		}
+	}
+	if sim.Days[0].Date != "2026-03-30" || sim.Days[4].Date != "2026-04-03" {
+		t.Errorf("unexpected dates %s..%s", sim.Days[0].Date, sim.Days[4].Date)
+	}
+
+	grouped, err := service.SimulateRotation(ctx, "kitchen", 2)
+	if err != nil {
+		t.Fatalf("SimulateRotation failed: %v", err)
+	}
+	if grouped.Days[0].ImageID != ids[2] || grouped.Days[1].ImageID != ids[0] {
+		t.Errorf("expected the group offset to apply, got %+v", grouped.Days)
+	}
+	current, _ := service.GetImageForDevice(ctx, "kitchen")
+	if grouped.Days[0].ImageID != current {
+		t.Errorf("expected today to match the current image %s, got %s", current, grouped.Days[0].ImageID)
+	}
+}
+
+func TestSimulateRotation_DisplayFormat(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{Display: config.Display{Locale: "en-US"}})
+	service.nowFn = func() time.Time { return time.Date(2026, 3, 30, 15, 0, 0, 0, time.UTC) }
+	addTestImages(t, service, 1)
+
+	sim, err := service.SimulateRotation(context.Background(), "", 7)
+	if err != nil {
+		t.Fatalf("SimulateRotation failed: %v", err)
+	}
+	if sim.FirstDayOfWeek != "sunday" {
+		t.Errorf("expected en-US weeks to start on sunday, got %q", sim.FirstDayOfWeek)
+	}
+	// Monday 2026-03-30 belongs to the week starting Sunday 2026-03-29; the
+	// following Sunday starts the next.
+	if day := sim.Days[0]; day.Label != "Mon 03/30/2026" || day.WeekStart != "2026-03-29" {
+		t.Errorf("unexpected first day %+v", day)
+	}
+	if day := sim.Days[6]; day.WeekStart != "2026-04-05" {
+		t.Errorf("expected Sunday to start a new week, got %+v", day)
+	}
+}
+
+func TestSimulateRotation_InvalidDays(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{})
+	for _, days := range []int{0, maxSimulationDays + 1} {
+		if _, err := service.SimulateRotation(context.Background(), "", days); !errors.Is(err, ErrInvalidSimulationDays) {
+			t.Errorf("days=%d: expected ErrInvalidSimulationDays, got %v", days, err)
+		}
+	}
+}
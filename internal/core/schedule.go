@@ -0,0 +1,96 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/displayformat"
+)
+
+// ImageSchedule tells when an image of the rotation order is shown next.
+type ImageSchedule struct {
+	ImageID string `json:"imageId"`
+	// ShowAt is the midnight in the rotation timezone that starts the image's
+	// day, encoded with that zone's UTC offset. It is today for the current image.
+	ShowAt time.Time `json:"showAt"`
+	// SecondsUntilShow counts down to ShowAt; it is 0 for the current image.
+	SecondsUntilShow int64 `json:"secondsUntilShow"`
+	// Relative describes ShowAt for people, e.g. "now", "in 5 hours" or "in 3 days".
+	Relative string `json:"relative"`
+	// ShowAtText is the day of ShowAt with its weekday in the configured
+	// display format, e.g. "Mon 2026-03-30".
+	ShowAtText string `json:"showAtText"`
+	// Story names the story the image belongs to; StoryDay counts its days
+	// from 1 to StoryDays.
+	Story     string `json:"story,omitempty"`
+	StoryDay  int    `json:"storyDay,omitempty"`
+	StoryDays int    `json:"storyDays,omitempty"`
+}
+
+// GetImageSchedules returns the show time of every image in rotation order.
+func (service *CoreService) GetImageSchedules(ctx context.Context) ([]ImageSchedule, error) {
+	ids, err := service.getOrderedImageIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	schedules := imageSchedules(ids, service.nowFn(), service.tzLoc, service.display)
+	parts, err := service.storyParts(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i, s := range schedules {
+		if part, ok := parts[s.ImageID]; ok {
+			schedules[i].Story, schedules[i].StoryDay, schedules[i].StoryDays = part.story, part.day, part.days
+		}
+	}
+	return schedules, nil
+}
+
+// DisplayFormat returns the formatter of dates shown to people.
+func (service *CoreService) DisplayFormat() *displayformat.Formatter {
+	return service.display
+}
+
+// imageSchedules assigns the image at position i the day i calendar days after
+// now's day in loc. Days are counted with time.Date rather than by adding 24h
+// multiples, so the show times stay at midnight across DST changes.
+func imageSchedules(ids []string, now time.Time, loc *time.Location, display *displayformat.Formatter) []ImageSchedule {
+	t := now.In(loc)
+	schedules := make([]ImageSchedule, len(ids))
+	for i, id := range ids {
+		showAt := time.Date(t.Year(), t.Month(), t.Day()+i, 0, 0, 0, 0, loc)
+		schedules[i] = ImageSchedule{
+			ImageID:          id,
+			ShowAt:           showAt,
+			SecondsUntilShow: max(int64(showAt.Sub(now).Seconds()+0.5), 0),
+			Relative:         relativeShowTime(t, showAt),
+			ShowAtText:       display.Day(showAt),
+		}
+	}
+	return schedules
+}
+
+// relativeShowTime describes showAt as seen at now. Times less than a day away
+// are given in hours or minutes, later ones in calendar days.
+func relativeShowTime(now, showAt time.Time) string {
+	d := showAt.Sub(now)
+	switch {
+	case d <= 0:
+		return "now"
+	case d < time.Hour:
+		return pluralize(max(int(d/time.Minute), 1), "minute")
+	case d < 24*time.Hour:
+		return pluralize(int(d/time.Hour), "hour")
+	}
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	to := time.Date(showAt.Year(), showAt.Month(), showAt.Day(), 0, 0, 0, 0, time.UTC)
+	return pluralize(int(to.Sub(from)/(24*time.Hour)), "day")
+}
+
+func pluralize(n int, unit string) string {
+	if n == 1 {
+		return fmt.Sprintf("in 1 %s", unit)
+	}
+	return fmt.Sprintf("in %d %ss", n, unit)
+}
@@ -0,0 +1,108 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/displayformat"
+)
+
+func TestImageSchedules_AcrossDST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	// 2026-03-29 has 23 hours and 2026-10-25 has 25 hours in Berlin.
+	for _, now := range []time.Time{
+		time.Date(2026, 3, 27, 22, 0, 0, 0, berlin),
+		time.Date(2026, 10, 23, 22, 0, 0, 0, berlin),
+	} {
+		schedules := imageSchedules([]string{"a", "b", "c", "d", "e"}, now, berlin, displayformat.New(config.Display{}))
+		for i, s := range schedules {
+			if h, m, _ := s.ShowAt.Clock(); h != 0 || m != 0 {
+				t.Errorf("expected %s to be shown at midnight, got %v", s.ImageID, s.ShowAt)
+			}
+			if want := now.Day() + i; s.ShowAt.Day() != want {
+				t.Errorf("expected %s on day %d, got %v", s.ImageID, want, s.ShowAt)
+			}
+		}
+	}
+}
+
+func TestImageSchedules_EncodesZoneOffset(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	schedules := imageSchedules([]string{"a", "b", "c"}, time.Date(2026, 3, 28, 12, 0, 0, 0, time.UTC), berlin, displayformat.New(config.Display{}))
+	data, err := json.Marshal(schedules)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	for _, want := range []string{`"2026-03-28T00:00:00+01:00"`, `"2026-03-29T00:00:00+01:00"`, `"2026-03-30T00:00:00+02:00"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected %s in %s", want, data)
+		}
+	}
+}
+
+func TestGetImageSchedules(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{})
+	ids := addTestImages(t, service, 2)
+
+	schedules, err := service.GetImageSchedules(context.Background())
+	if err != nil {
+		t.Fatalf("GetImageSchedules failed: %v", err)
+	}
+	if len(schedules) != 2 || schedules[0].ImageID != ids[0] || schedules[1].ImageID != ids[1] {
+		t.Fatalf("unexpected schedules: %+v", schedules)
+	}
+	if d := schedules[1].ShowAt.Sub(schedules[0].ShowAt); d != 24*time.Hour {
+		t.Errorf("expected consecutive UTC days, got %v apart", d)
+	}
+}
+
+func TestRelativeShowTime(t *testing.T) {
+	now := time.Date(2026, 5, 4, 22, 30, 0, 0, time.UTC)
+	tests := []struct {
+		showAt time.Time
+		want   string
+	}{
+		{time.Date(2026, 5, 4, 0, 0, 0, 0, time.UTC), "now"},
+		{now.Add(20 * time.Second), "in 1 minute"},
+		{now.Add(45 * time.Minute), "in 45 minutes"},
+		{time.Date(2026, 5, 5, 0, 0, 0, 0, time.UTC), "in 1 hour"},
+		{now.Add(5 * time.Hour), "in 5 hours"},
+		{time.Date(2026, 5, 6, 0, 0, 0, 0, time.UTC), "in 2 days"},
+		{time.Date(2026, 5, 7, 0, 0, 0, 0, time.UTC), "in 3 days"},
+	}
+	for _, tt := range tests {
+		if got := relativeShowTime(now, tt.showAt); got != tt.want {
+			t.Errorf("relativeShowTime(%v) = %q, want %q", tt.showAt, got, tt.want)
+		}
+	}
+}
+
+func TestGetImageSchedules_Countdown(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{})
+	service.nowFn = func() time.Time { return time.Date(2026, 5, 4, 18, 0, 0, 0, time.UTC) }
+	addTestImages(t, service, 3)
+
+	schedules, err := service.GetImageSchedules(context.Background())
+	if err != nil {
+		t.Fatalf("GetImageSchedules failed: %v", err)
+	}
+	want := []struct {
+		seconds  int64
+		relative string
+	}{{0, "now"}, {6 * 60 * 60, "in 6 hours"}, {30 * 60 * 60, "in 2 days"}}
+	for i, w := range want {
+		if schedules[i].SecondsUntilShow != w.seconds || schedules[i].Relative != w.relative {
+			t.Errorf("schedule %d: got %ds %q, want %ds %q", i, schedules[i].SecondsUntilShow, schedules[i].Relative, w.seconds, w.relative)
+		}
+	}
+}
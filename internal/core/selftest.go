@@ -0,0 +1,35 @@
+package core
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+// selfTestImage is the embedded demo image run through the pipeline by SelfTest.
+const selfTestImage = "demo/colorbars.png"
+
+// SelfTest runs a small embedded image through conversion and the configured
+// pipeline, including the attribution overlay when enabled, so that a command
+// failing at runtime is noticed at startup rather than on the first upload.
+// It also warms up the pipeline. Nothing is stored.
+func (service *CoreService) SelfTest() error {
+	data, err := demoFS.ReadFile(selfTestImage)
+	if err != nil {
+		return fmt.Errorf("reading self-test image: %w", err)
+	}
+
+	start := time.Now()
+	converted, err := service.convertToPNG(data)
+	if err != nil {
+		return fmt.Errorf("pipeline self-test: %w", err)
+	}
+	attribution := database.Attribution{Author: "goframe", License: "self-test"}
+	if _, _, err := service.processImage(converted, attribution, "", "", "", nil); err != nil {
+		return fmt.Errorf("pipeline self-test: %w", err)
+	}
+	slog.Info("CoreService.SelfTest: pipeline ok", "commands", len(service.commandConfigs), "duration", time.Since(start))
+	return nil
+}
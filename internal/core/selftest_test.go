@@ -0,0 +1,31 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/jo-hoe/goframe/internal/config"
+)
+
+func TestSelfTest_ValidPipeline(t *testing.T) {
+	service, db := newTestCoreService(t, &config.ServiceConfig{
+		Commands:           []config.CommandConfig{{Name: "ScaleCommand", Params: map[string]any{"height": 4, "width": 4}}},
+		AttributionOverlay: true,
+	})
+
+	if err := service.SelfTest(); err != nil {
+		t.Fatalf("SelfTest failed: %v", err)
+	}
+	if ids, _ := db.GetRotationOrderedIDs(t.Context()); len(ids) != 0 {
+		t.Errorf("Expected the self-test not to store images, got %v", ids)
+	}
+}
+
+func TestSelfTest_FailingCommand(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{
+		Commands: []config.CommandConfig{{Name: "NoSuchCommand"}},
+	})
+
+	if err := service.SelfTest(); err == nil {
+		t.Fatal("Expected SelfTest to fail for an unknown command")
+	}
+}
@@ -0,0 +1,131 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+
+	"github.com/jo-hoe/goframe/internal/database"
+	"github.com/jo-hoe/goframe/internal/imageprocessing"
+)
+
+// SimilarImage is an image that looks near-identical to another one.
+type SimilarImage struct {
+	*database.Image
+	// Distance is the number of differing perceptual hash bits; 0 is visually identical.
+	Distance int
+}
+
+// formatPerceptualHash encodes a difference hash as it is stored in the database.
+func formatPerceptualHash(hash uint64) string {
+	return fmt.Sprintf("%016x", hash)
+}
+
+// checkNearDuplicates hashes a new upload and returns the stored hash together
+// with the IDs of existing images within the configured distance. Only images
+// that already carry a hash are compared, so uploads never wait for a backfill.
+func (service *CoreService) checkNearDuplicates(ctx context.Context, pngData []byte) (string, []string) {
+	hash, err := imageprocessing.DifferenceHash(pngData)
+	if err != nil {
+		slog.Warn("CoreService.checkNearDuplicates: failed to compute perceptual hash", "error", err)
+		return "", nil
+	}
+	maxDistance := service.config.NearDuplicateDistance
+	if maxDistance < 0 {
+		return formatPerceptualHash(hash), nil
+	}
+
+	images, err := service.allImages(ctx)
+	if err != nil {
+		slog.Warn("CoreService.checkNearDuplicates: failed to list images", "error", err)
+		return formatPerceptualHash(hash), nil
+	}
+	var similar []string
+	for _, img := range images {
+		stored, err := strconv.ParseUint(img.PerceptualHash, 16, 64)
+		if err != nil {
+			continue
+		}
+		if imageprocessing.HashDistance(hash, stored) <= maxDistance {
+			similar = append(similar, img.ID)
+		}
+	}
+	if len(similar) > 0 {
+		slog.Warn("CoreService.checkNearDuplicates: upload looks like existing images", "similar", similar)
+	}
+	return formatPerceptualHash(hash), similar
+}
+
+// FindSimilarImages returns images whose perceptual hash is within maxDistance
+// bits of the given image, closest first. A negative maxDistance uses the
+// configured default. Images stored before hashing was introduced are hashed
+// and updated on the way.
+func (service *CoreService) FindSimilarImages(ctx context.Context, id string, maxDistance int) ([]SimilarImage, error) {
+	if maxDistance < 0 {
+		maxDistance = max(service.config.NearDuplicateDistance, 0)
+	}
+	target, err := service.databaseService.GetImageByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrImageNotFound, id)
+	}
+	targetHash, err := service.perceptualHash(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	images, err := service.allImages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	similar := make([]SimilarImage, 0)
+	for _, img := range images {
+		if img.ID == id {
+			continue
+		}
+		hash, err := service.perceptualHash(ctx, img)
+		if err != nil {
+			slog.Warn("CoreService.FindSimilarImages: skipping image", "id", img.ID, "error", err)
+			continue
+		}
+		if d := imageprocessing.HashDistance(targetHash, hash); d <= maxDistance {
+			similar = append(similar, SimilarImage{Image: img, Distance: d})
+		}
+	}
+	sort.SliceStable(similar, func(i, j int) bool { return similar[i].Distance < similar[j].Distance })
+	return similar, nil
+}
+
+// perceptualHash returns the stored hash of img, computing and storing it from
+// the original when missing.
+func (service *CoreService) perceptualHash(ctx context.Context, img *database.Image) (uint64, error) {
+	if hash, err := strconv.ParseUint(img.PerceptualHash, 16, 64); err == nil {
+		return hash, nil
+	}
+	original, err := service.databaseService.GetImageData(ctx, img.ID, "original")
+	if err != nil {
+		return 0, fmt.Errorf("reading original of %s: %w", img.ID, err)
+	}
+	hash, err := imageprocessing.DifferenceHash(original)
+	if err != nil {
+		return 0, fmt.Errorf("hashing %s: %w", img.ID, err)
+	}
+	if err := service.databaseService.SetPerceptualHash(ctx, img.ID, formatPerceptualHash(hash)); err != nil {
+		slog.Warn("CoreService.perceptualHash: failed to store perceptual hash", "id", img.ID, "error", err)
+	}
+	return hash, nil
+}
+
+// allImages returns every stored image: scheduled, archived and pending.
+func (service *CoreService) allImages(ctx context.Context) ([]*database.Image, error) {
+	images, err := service.ListImages(ctx, ImageFilter{Archived: ArchiveInclude})
+	if err != nil {
+		return nil, err
+	}
+	pending, err := service.databaseService.GetPendingImages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return append(images, pending...), nil
+}
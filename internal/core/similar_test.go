@@ -0,0 +1,89 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"image/color"
+	"testing"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+// stripesPNG encodes vertical stripes; flipping swaps light and dark stripes.
+func stripesPNG(t *testing.T, w, h int, flipped bool) []byte {
+	t.Helper()
+	return patternPNG(t, w, h, func(x, _ int) color.Color {
+		if light := (x*9/w)%2 == 0; light != flipped {
+			return color.White
+		}
+		return color.Black
+	})
+}
+
+func TestAddImage_ReportsNearDuplicates(t *testing.T) {
+	service, db := newTestCoreService(t, &config.ServiceConfig{NearDuplicateDistance: 6})
+	ctx := context.Background()
+
+	first, err := service.AddImage(ctx, stripesPNG(t, 180, 80, false), "", database.Attribution{})
+	if err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+	if len(first.Similar) != 0 {
+		t.Errorf("expected no similar images for first upload, got %v", first.Similar)
+	}
+	stored, _ := db.GetImageByID(ctx, first.ID)
+	if len(stored.PerceptualHash) != 16 {
+		t.Errorf("expected stored perceptual hash, got %q", stored.PerceptualHash)
+	}
+
+	resized, err := service.AddImage(ctx, stripesPNG(t, 90, 40, false), "", database.Attribution{})
+	if err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+	if len(resized.Similar) != 1 || resized.Similar[0] != first.ID {
+		t.Errorf("expected resized copy to be reported as similar to %s, got %v", first.ID, resized.Similar)
+	}
+
+	different, err := service.AddImage(ctx, stripesPNG(t, 180, 80, true), "", database.Attribution{})
+	if err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+	if len(different.Similar) != 0 {
+		t.Errorf("expected no similar images for a different image, got %v", different.Similar)
+	}
+}
+
+func TestFindSimilarImages_BackfillsMissingHashes(t *testing.T) {
+	service, db := newTestCoreService(t, &config.ServiceConfig{NearDuplicateDistance: 6})
+	ctx := context.Background()
+
+	// Images stored before perceptual hashing have no hash yet.
+	legacy, err := db.CreateImage(ctx, stripesPNG(t, 180, 80, false), nil, time.Now(), "", database.Attribution{}, "", false)
+	if err != nil {
+		t.Fatalf("CreateImage failed: %v", err)
+	}
+	if _, err := db.CreateImage(ctx, stripesPNG(t, 180, 80, true), nil, time.Now(), "", database.Attribution{}, "", false); err != nil {
+		t.Fatalf("CreateImage failed: %v", err)
+	}
+	copyImg, err := service.AddImage(ctx, stripesPNG(t, 120, 54, false), "", database.Attribution{})
+	if err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+
+	similar, err := service.FindSimilarImages(ctx, copyImg.ID, -1)
+	if err != nil {
+		t.Fatalf("FindSimilarImages failed: %v", err)
+	}
+	if len(similar) != 1 || similar[0].ID != legacy {
+		t.Fatalf("expected %s to be similar, got %+v", legacy, similar)
+	}
+	if img, _ := db.GetImageByID(ctx, legacy); img.PerceptualHash == "" {
+		t.Error("expected missing perceptual hash to be backfilled")
+	}
+
+	if _, err := service.FindSimilarImages(ctx, "missing", -1); !errors.Is(err, ErrImageNotFound) {
+		t.Errorf("expected ErrImageNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,382 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+	"github.com/jo-hoe/goframe/internal/resilience"
+)
+
+// SourcePrefix marks images added by an image source; the source name and a
+// short digest of the fetched bytes follow it, e.g. "source:nas:1f2e3d4c5b6a7988".
+const SourcePrefix = "source:"
+
+// ErrSourceNotFound is returned for names not listed under sources.
+var ErrSourceNotFound = errors.New("image source not found")
+
+// ErrSourceInvalid is returned for sources whose configuration is invalid.
+var ErrSourceInvalid = errors.New("image source is invalid")
+
+// NewImage is an image found by a Source.
+type NewImage struct {
+	Data        []byte
+	Attribution database.Attribution
+}
+
+// Source is polled for new images on its configured schedule. Poll should
+// return the images found since the previous poll; images the library already
+// holds from the same source are skipped, so returning an image again, e.g.
+// after a restart, is harmless. Failed polls are retried with backoff unless
+// the error is resilience.Permanent, and a source that keeps failing is paused.
+type Source interface {
+	Poll(ctx context.Context) ([]NewImage, error)
+}
+
+// liveSource is implemented by sources whose latest image takes the place of
+// their previous one in the rotation instead of being added next to it.
+type liveSource interface {
+	live() bool
+}
+
+// scheduledSource is implemented by sources that are only polled at certain
+// times, e.g. a camera that must not record at night.
+type scheduledSource interface {
+	activeAt(now time.Time) bool
+}
+
+// SourceFactory creates a Source from the parameters of a sources entry.
+type SourceFactory func(params map[string]any) (Source, error)
+
+// SourceRegistry manages the source types available in the sources config.
+type SourceRegistry struct {
+	factories map[string]SourceFactory
+}
+
+// NewSourceRegistry creates an empty source registry.
+func NewSourceRegistry() *SourceRegistry {
+	return &SourceRegistry{factories: make(map[string]SourceFactory)}
+}
+
+// Register adds a source factory under the given type name.
+func (r *SourceRegistry) Register(typeName string, factory SourceFactory) error {
+	if typeName == "" {
+		return fmt.Errorf("source type cannot be empty")
+	}
+	if factory == nil {
+		return fmt.Errorf("source factory cannot be nil")
+	}
+	if _, exists := r.factories[typeName]; exists {
+		return fmt.Errorf("source type %s is already registered", typeName)
+	}
+	r.factories[typeName] = factory
+	return nil
+}
+
+// Create instantiates a source of the given type with the given parameters.
+func (r *SourceRegistry) Create(typeName string, params map[string]any) (Source, error) {
+	factory, exists := r.factories[typeName]
+	if !exists {
+		return nil, fmt.Errorf("unknown source type: %s", typeName)
+	}
+	source, err := factory(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create source %s: %w", typeName, err)
+	}
+	return source, nil
+}
+
+// DefaultSourceRegistry holds the built-in source types.
+var DefaultSourceRegistry = NewSourceRegistry()
+
+// SourceStatus reports the configuration and last sync of an image source.
+type SourceStatus struct {
+	Name            string `json:"name"`
+	Type            string `json:"type"`
+	Enabled         bool   `json:"enabled"`
+	IntervalSeconds int64  `json:"intervalSeconds"`
+	// LastSync is when the last poll finished; zero before the first poll.
+	LastSync time.Time `json:"lastSync,omitzero"`
+	// LastAdded counts the images added by the last poll.
+	LastAdded int `json:"lastAdded"`
+	// LastSkipped counts the images of the last poll that were already known.
+	LastSkipped int `json:"lastSkipped"`
+	// LastError is set when the last poll or adding one of its images failed,
+	// or when the source could not be created.
+	LastError string `json:"lastError,omitempty"`
+	// TotalAdded counts the images added since startup.
+	TotalAdded int `json:"totalAdded"`
+}
+
+// imageSource is a configured source and its sync state.
+type imageSource struct {
+	cfg    config.Source
+	source Source // nil when the source could not be created
+
+	mu     sync.Mutex
+	status SourceStatus
+}
+
+func (s *imageSource) snapshot() SourceStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+func (s *imageSource) enabled() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status.Enabled
+}
+
+// imageSources holds the configured sources in config order.
+type imageSources []*imageSource
+
+// newImageSources creates the configured sources from registry. Sources that
+// cannot be created are kept with their error so the status shows them.
+func newImageSources(cfgs []config.Source, registry *SourceRegistry) imageSources {
+	sources := make(imageSources, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		s := &imageSource{cfg: cfg, status: SourceStatus{
+			Name:            cfg.Name,
+			Type:            cfg.Type,
+			Enabled:         !cfg.Disabled,
+			IntervalSeconds: int64(cfg.Interval / time.Second),
+		}}
+		source, err := registry.Create(cfg.Type, cfg.Params)
+		if err != nil {
+			slog.Error("invalid image source; it will not run", "source", cfg.Name, "error", err)
+			s.status.Enabled = false
+			s.status.LastError = err.Error()
+		}
+		s.source = source
+		sources = append(sources, s)
+	}
+	return sources
+}
+
+func (sources imageSources) find(name string) (*imageSource, bool) {
+	i := slices.IndexFunc(sources, func(s *imageSource) bool { return s.cfg.Name == name })
+	if i < 0 {
+		return nil, false
+	}
+	return sources[i], true
+}
+
+// GetSourceStatuses returns the status of all configured image sources.
+func (service *CoreService) GetSourceStatuses() []SourceStatus {
+	statuses := make([]SourceStatus, 0, len(service.sources))
+	for _, s := range service.sources {
+		statuses = append(statuses, s.snapshot())
+	}
+	return statuses
+}
+
+// SetSourceEnabled pauses or resumes the scheduled polls of an image source
+// until the next restart, which restores the configured state.
+func (service *CoreService) SetSourceEnabled(name string, enabled bool) error {
+	s, ok := service.sources.find(name)
+	if !ok {
+		return ErrSourceNotFound
+	}
+	if s.source == nil {
+		return fmt.Errorf("%w: %s", ErrSourceInvalid, s.snapshot().LastError)
+	}
+	s.mu.Lock()
+	s.status.Enabled = enabled
+	s.mu.Unlock()
+	slog.Info("CoreService.SetSourceEnabled", "source", name, "enabled", enabled)
+	return nil
+}
+
+// RunSources polls every valid image source on its own schedule until ctx is
+// cancelled. Disabled sources skip their polls until they are enabled.
+func (service *CoreService) RunSources(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, s := range service.sources {
+		if s.source == nil {
+			continue
+		}
+		wg.Go(func() {
+			slog.Info("CoreService.RunSources: starting", "source", s.cfg.Name, "type", s.cfg.Type, "interval", s.cfg.Interval)
+			ticker := time.NewTicker(s.cfg.Interval)
+			defer ticker.Stop()
+			for {
+				if s.enabled() {
+					service.syncSource(ctx, s)
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		})
+	}
+	wg.Wait()
+}
+
+// syncSource polls s once and adds the images that are new to the library.
+func (service *CoreService) syncSource(ctx context.Context, s *imageSource) {
+	if scheduled, ok := s.source.(scheduledSource); ok && !scheduled.activeAt(service.nowFn().In(service.tzLoc)) {
+		slog.Debug("CoreService.syncSource: outside of active hours", "source", s.cfg.Name)
+		return
+	}
+	added, skipped, err := service.pollSource(ctx, s)
+	if errors.Is(err, resilience.ErrOpen) {
+		slog.Debug("CoreService.syncSource: source paused after repeated failures", "source", s.cfg.Name, "error", err)
+	} else if err != nil {
+		slog.Warn("CoreService.syncSource: sync failed", "source", s.cfg.Name, "error", err)
+	} else if added > 0 {
+		slog.Info("CoreService.syncSource: synced", "source", s.cfg.Name, "added", added, "skipped", skipped)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.LastSync = service.nowFn()
+	s.status.LastAdded = added
+	s.status.LastSkipped = skipped
+	s.status.TotalAdded += added
+	s.status.LastError = ""
+	if err != nil {
+		s.status.LastError = err.Error()
+	}
+}
+
+func (service *CoreService) pollSource(ctx context.Context, s *imageSource) (added, skipped int, err error) {
+	var images []NewImage
+	err = service.integrations.Breaker(SourcePrefix+s.cfg.Name).Retry(ctx, func(ctx context.Context) error {
+		var err error
+		images, err = s.source.Poll(ctx)
+		return err
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(images) == 0 {
+		return 0, 0, nil
+	}
+	known, err := service.knownImageSources(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("listing images: %w", err)
+	}
+
+	live, _ := s.source.(liveSource)
+	var errs []error
+	for _, img := range images {
+		source := sourceImageSource(s.cfg.Name, img.Data)
+		if known[source] {
+			skipped++
+			continue
+		}
+		apiImg, err := service.AddImage(ctx, img.Data, source, img.Attribution)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		known[source] = true
+		added++
+		if live != nil && live.live() && !apiImg.Pending {
+			if err := service.replaceLiveImage(ctx, s.cfg.Name, apiImg.ID); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return added, skipped, errors.Join(errs...)
+}
+
+// replaceLiveImage moves image id, the latest image of the named live source,
+// to the position of the source's previous image in the rotation and deletes
+// the previous ones. Without a previous image in the rotation it stays where
+// it was added.
+func (service *CoreService) replaceLiveImage(ctx context.Context, name, id string) error {
+	images, err := service.databaseService.GetImageMetadata(ctx)
+	if err != nil {
+		return fmt.Errorf("listing images: %w", err)
+	}
+	order := make([]string, 0, len(images))
+	placed := false
+	for _, img := range images {
+		switch {
+		case img.ID == id:
+			continue
+		case !placed && sourceName(img.Source) == name:
+			order = append(order, id)
+			placed = true
+		default:
+			order = append(order, img.ID)
+		}
+	}
+	if placed {
+		if err := service.UpdateImageOrder(ctx, order); err != nil {
+			return fmt.Errorf("placing live image: %w", err)
+		}
+	}
+
+	var errs []error
+	for _, list := range []func(context.Context) ([]*database.Image, error){
+		service.databaseService.GetImageMetadata,
+		service.databaseService.GetArchivedImages,
+	} {
+		previous, err := list(ctx)
+		if err != nil {
+			return fmt.Errorf("listing images: %w", err)
+		}
+		for _, img := range previous {
+			if img.ID == id || sourceName(img.Source) != name {
+				continue
+			}
+			if err := service.DeleteImage(ctx, img.ID); err != nil {
+				errs = append(errs, fmt.Errorf("deleting previous live image %s: %w", img.ID, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// knownImageSources returns the sources of all images, including archived and
+// pending ones.
+func (service *CoreService) knownImageSources(ctx context.Context) (map[string]bool, error) {
+	known := map[string]bool{}
+	for _, list := range []func(context.Context) ([]*database.Image, error){
+		service.databaseService.GetImageMetadata,
+		service.databaseService.GetArchivedImages,
+		service.databaseService.GetPendingImages,
+	} {
+		images, err := list(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, img := range images {
+			known[img.Source] = true
+		}
+	}
+	return known, nil
+}
+
+// sourceImageSource returns the image source recorded for data found by the
+// named source.
+func sourceImageSource(name string, data []byte) string {
+	sum := sha256.Sum256(data)
+	return SourcePrefix + name + ":" + hex.EncodeToString(sum[:8])
+}
+
+// sourceName returns the name of the image source that added an image, or ""
+// for images from elsewhere.
+func sourceName(imageSource string) string {
+	rest, ok := strings.CutPrefix(imageSource, SourcePrefix)
+	if !ok {
+		return ""
+	}
+	name, _, _ := strings.Cut(rest, ":")
+	return name
+}
@@ -0,0 +1,225 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+func TestSyncSource_DirectoryAddsNewImagesOnce(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.png"), testPNG(t, 4, 4), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.Source{Name: "nas", Type: "directory", Interval: time.Hour, Params: map[string]any{"path": dir}}
+	service, db := newTestCoreService(t, &config.ServiceConfig{Sources: []config.Source{cfg}})
+	ctx := context.Background()
+
+	service.syncSource(ctx, service.sources[0])
+	if err := os.WriteFile(filepath.Join(dir, "b.png"), testPNG(t, 6, 6), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	service.syncSource(ctx, service.sources[0])
+
+	images, _ := db.GetImageMetadata(ctx)
+	if len(images) != 2 {
+		t.Fatalf("expected 2 images, got %d", len(images))
+	}
+	for _, img := range images {
+		if sourceName(img.Source) != "nas" {
+			t.Errorf("expected source of nas, got %q", img.Source)
+		}
+	}
+	status := service.GetSourceStatuses()[0]
+	if status.LastAdded != 1 || status.TotalAdded != 2 || status.LastError != "" || status.LastSync.IsZero() {
+		t.Errorf("unexpected status %+v", status)
+	}
+
+	// A restarted source returns all files again; known images are skipped.
+	restarted := newImageSources([]config.Source{cfg}, DefaultSourceRegistry)
+	service.syncSource(ctx, restarted[0])
+	if status := restarted[0].snapshot(); status.LastAdded != 0 || status.LastSkipped != 2 {
+		t.Errorf("expected both images to be skipped, got %+v", status)
+	}
+}
+
+func TestSyncSource_URLSkipsUnchangedImage(t *testing.T) {
+	data := testPNG(t, 4, 4)
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write(data)
+	}))
+	defer srv.Close()
+
+	service, db := newTestCoreService(t, &config.ServiceConfig{Sources: []config.Source{
+		{Name: "daily", Type: "url", Interval: time.Hour, Params: map[string]any{"url": srv.URL, "author": "Jane"}},
+	}})
+	ctx := context.Background()
+	service.syncSource(ctx, service.sources[0])
+	service.syncSource(ctx, service.sources[0])
+
+	images, _ := db.GetImageMetadata(ctx)
+	if len(images) != 1 || images[0].Attribution.Author != "Jane" {
+		t.Fatalf("expected one attributed image, got %+v", images)
+	}
+	if requests != 2 {
+		t.Errorf("expected 2 requests, got %d", requests)
+	}
+	if status := service.GetSourceStatuses()[0]; status.LastAdded != 0 || status.LastSkipped != 0 {
+		t.Errorf("expected the unchanged image not to be returned, got %+v", status)
+	}
+}
+
+func TestSyncSource_ReportsErrors(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{Sources: []config.Source{
+		{Name: "gone", Type: "directory", Interval: time.Hour, Params: map[string]any{"path": filepath.Join(t.TempDir(), "missing")}},
+	}})
+	service.syncSource(context.Background(), service.sources[0])
+	if status := service.GetSourceStatuses()[0]; status.LastError == "" || status.LastSync.IsZero() {
+		t.Errorf("expected a failed sync, got %+v", status)
+	}
+}
+
+func TestSetSourceEnabled(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{Sources: []config.Source{
+		{Name: "nas", Type: "directory", Interval: time.Hour, Disabled: true, Params: map[string]any{"path": t.TempDir()}},
+		{Name: "broken", Type: "nope", Interval: time.Hour},
+	}})
+
+	statuses := service.GetSourceStatuses()
+	if statuses[0].Enabled || statuses[1].Enabled {
+		t.Fatalf("expected both sources to start disabled, got %+v", statuses)
+	}
+	if !strings.Contains(statuses[1].LastError, "unknown source type") {
+		t.Errorf("expected the unknown type to be reported, got %q", statuses[1].LastError)
+	}
+
+	if err := service.SetSourceEnabled("nas", true); err != nil {
+		t.Fatalf("SetSourceEnabled failed: %v", err)
+	}
+	if !service.GetSourceStatuses()[0].Enabled {
+		t.Error("expected nas to be enabled")
+	}
+	if err := service.SetSourceEnabled("broken", true); !errors.Is(err, ErrSourceInvalid) {
+		t.Errorf("expected ErrSourceInvalid, got %v", err)
+	}
+	if err := service.SetSourceEnabled("missing", true); !errors.Is(err, ErrSourceNotFound) {
+		t.Errorf("expected ErrSourceNotFound, got %v", err)
+	}
+}
+
+func TestRequiresModeration_TrustedSource(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{Moderation: config.Moderation{
+		Enabled: true, TrustedSources: []string{"source:nas"},
+	}})
+	if service.requiresModeration(sourceImageSource("nas", []byte("x"))) {
+		t.Error("expected images of the trusted source to skip moderation")
+	}
+	if !service.requiresModeration(sourceImageSource("web", []byte("x"))) {
+		t.Error("expected images of other sources to require moderation")
+	}
+}
+
+func TestSyncSource_CameraReplacesLiveImage(t *testing.T) {
+	snapshot := testPNG(t, 4, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(snapshot)
+	}))
+	defer srv.Close()
+
+	service, db := newTestCoreService(t, &config.ServiceConfig{Sources: []config.Source{
+		{Name: "birds", Type: "camera", Interval: time.Minute, Params: map[string]any{"url": srv.URL}},
+	}})
+	ctx := context.Background()
+	first, err := service.AddImage(ctx, testPNG(t, 8, 8), "", database.Attribution{})
+	if err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+	service.syncSource(ctx, service.sources[0])
+	last, err := service.AddImage(ctx, testPNG(t, 10, 10), "", database.Attribution{})
+	if err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+
+	snapshot = testPNG(t, 6, 6)
+	service.syncSource(ctx, service.sources[0])
+
+	images, _ := db.GetImageMetadata(ctx)
+	if len(images) != 3 {
+		t.Fatalf("expected 3 images, got %d", len(images))
+	}
+	if images[0].ID != first.ID || sourceName(images[1].Source) != "birds" || images[2].ID != last.ID {
+		t.Fatalf("expected the new snapshot in the live slot, got order %v, %v, %v", images[0].ID, images[1].Source, images[2].ID)
+	}
+	if images[1].Source != sourceImageSource("birds", snapshot) {
+		t.Errorf("expected the latest snapshot in the live slot, got %q", images[1].Source)
+	}
+	if status := service.GetSourceStatuses()[0]; status.LastError != "" || status.TotalAdded != 2 {
+		t.Errorf("unexpected status %+v", status)
+	}
+}
+
+func TestSyncSource_CameraActiveHours(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = w.Write(testPNG(t, 4, 4))
+	}))
+	defer srv.Close()
+
+	service, _ := newTestCoreService(t, &config.ServiceConfig{Sources: []config.Source{
+		{Name: "birds", Type: "camera", Interval: time.Minute, Params: map[string]any{
+			"url": srv.URL, "activeFrom": "07:00", "activeUntil": "19:30",
+		}},
+	}})
+	for _, tc := range []struct {
+		clock string
+		want  int
+	}{{"06:59", 0}, {"07:00", 1}, {"19:29", 2}, {"19:30", 2}, {"23:00", 2}} {
+		now, _ := time.Parse("15:04", tc.clock)
+		service.nowFn = func() time.Time { return now }
+		service.syncSource(context.Background(), service.sources[0])
+		if requests != tc.want {
+			t.Errorf("at %s: got %d requests, want %d", tc.clock, requests, tc.want)
+		}
+	}
+}
+
+func TestCameraSource_ActiveHoursWrapMidnight(t *testing.T) {
+	source, err := newCameraSource(map[string]any{"url": "rtsp://cam.lan/stream", "activeFrom": "22:00", "activeUntil": "06:00"})
+	if err != nil {
+		t.Fatalf("newCameraSource failed: %v", err)
+	}
+	camera := source.(*cameraSource)
+	for clock, want := range map[string]bool{"21:59": false, "22:00": true, "03:00": true, "06:00": false} {
+		now, _ := time.Parse("15:04", clock)
+		if got := camera.activeAt(now); got != want {
+			t.Errorf("activeAt(%s) = %v, want %v", clock, got, want)
+		}
+	}
+
+	for _, params := range []map[string]any{
+		{"url": "ftp://cam.lan/snap.jpg"},
+		{"url": "http://cam.lan/snap.jpg", "activeFrom": "07:00"},
+		{"url": "http://cam.lan/snap.jpg", "activeFrom": "7am", "activeUntil": "19:00"},
+	} {
+		if _, err := newCameraSource(params); err == nil {
+			t.Errorf("newCameraSource(%v) succeeded, want an error", params)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/database"
+	"github.com/jo-hoe/goframe/internal/imageprocessing"
+	"github.com/jo-hoe/goframe/internal/resilience"
+)
+
+const (
+	// maxSourceImagesPerPoll bounds the images a directory source returns per
+	// poll; the rest follow on the next polls.
+	maxSourceImagesPerPoll = 20
+	// maxSourceDownloadBytes bounds the size of an image fetched by a url source.
+	maxSourceDownloadBytes = 128 << 20
+)
+
+// directorySource picks up image files added to or changed in a directory,
+// e.g. a mounted NAS share. Hidden files and directories are skipped.
+type directorySource struct {
+	path string
+
+	mu sync.Mutex
+	// seen maps the files returned so far to their modification time.
+	seen map[string]time.Time
+}
+
+func newDirectorySource(params map[string]any) (Source, error) {
+	path := imageprocessing.GetStringParam(params, "path", "")
+	if path == "" {
+		return nil, fmt.Errorf("directory source requires path")
+	}
+	return &directorySource{path: path, seen: map[string]time.Time{}}, nil
+}
+
+// Poll returns the files that are new or modified since they were last returned.
+func (s *directorySource) Poll(ctx context.Context) ([]NewImage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	root, err := os.OpenRoot(s.path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = root.Close() }()
+
+	var images []NewImage
+	err = fs.WalkDir(root.FS(), ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			slog.Warn("directorySource.Poll: skipping unreadable entry", "dir", s.path, "path", p, "error", err)
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if p != "." && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !d.Type().IsRegular() || !isImportableFile(p) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if modTime, ok := s.seen[p]; ok && modTime.Equal(info.ModTime()) {
+			return nil
+		}
+		if len(images) == maxSourceImagesPerPoll {
+			return fs.SkipAll
+		}
+		data, err := root.ReadFile(p)
+		if err != nil {
+			slog.Warn("directorySource.Poll: skipping unreadable file", "dir", s.path, "path", p, "error", err)
+			return nil
+		}
+		s.seen[p] = info.ModTime()
+		images = append(images, NewImage{Data: data})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scanning %s: %w", s.path, err)
+	}
+	return images, nil
+}
+
+// urlSource fetches an image from a fixed URL, e.g. a picture of the day. The
+// image is only downloaded again when the server reports a change.
+type urlSource struct {
+	url         string
+	attribution database.Attribution
+	httpClient  *http.Client
+
+	mu           sync.Mutex
+	etag         string
+	lastModified string
+}
+
+func newURLSource(params map[string]any) (Source, error) {
+	u := imageprocessing.GetStringParam(params, "url", "")
+	if !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") {
+		return nil, fmt.Errorf("url source requires an http or https url (got %q)", u)
+	}
+	return &urlSource{
+		url: u,
+		attribution: database.Attribution{
+			SourceURL: imageprocessing.GetStringParam(params, "sourceURL", ""),
+			Author:    imageprocessing.GetStringParam(params, "author", ""),
+			License:   imageprocessing.GetStringParam(params, "license", ""),
+		},
+		httpClient: &http.Client{Timeout: 2 * time.Minute},
+	}, nil
+}
+
+// Poll downloads the image unless the server answers that it is unchanged.
+func (s *urlSource) Poll(ctx context.Context) ([]NewImage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if s.etag != "" {
+		req.Header.Set("If-None-Match", s.etag)
+	}
+	if s.lastModified != "" {
+		req.Header.Set("If-Modified-Since", s.lastModified)
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil, nil
+	case http.StatusOK:
+	default:
+		return nil, resilience.PermanentForStatus(fmt.Errorf("GET %s: unexpected status %d", s.url, resp.StatusCode), resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSourceDownloadBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", s.url, err)
+	}
+	if len(data) > maxSourceDownloadBytes {
+		return nil, resilience.Permanent(fmt.Errorf("GET %s: image exceeds %s", s.url, FormatBytes(maxSourceDownloadBytes)))
+	}
+	s.etag = resp.Header.Get("ETag")
+	s.lastModified = resp.Header.Get("Last-Modified")
+	return []NewImage{{Data: data, Attribution: s.attribution}}, nil
+}
+
+func init() {
+	if err := DefaultSourceRegistry.Register("directory", newDirectorySource); err != nil {
+		panic(fmt.Sprintf("failed to register directory source: %v", err))
+	}
+	if err := DefaultSourceRegistry.Register("url", newURLSource); err != nil {
+		panic(fmt.Sprintf("failed to register url source: %v", err))
+	}
+}
@@ -0,0 +1,124 @@
+package core
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/imageprocessing"
+)
+
+// Stats summarises library growth and server activity for the dashboard.
+// Uploads and StoredBytes are derived from the stored images, so deleted
+// images are not counted. DevicePolls and ProcessingLatency are kept in memory
+// for the last statsRetentionDays days and reset on restart.
+type Stats struct {
+	// Uploads counts the stored images per creation day.
+	Uploads []DailyValue `json:"uploads"`
+	// StoredBytes is the cumulative size of the stored images at the end of each upload day.
+	StoredBytes []DailyValue `json:"storedBytes"`
+	// DevicePolls counts image requests per device and day; "" is requests without a device ID.
+	DevicePolls []DevicePollDay `json:"devicePolls"`
+	// ProcessingLatency is the average pipeline duration per day.
+	ProcessingLatency []DailyLatency `json:"processingLatency"`
+	// Pipeline describes the command runs since the server started.
+	Pipeline imageprocessing.PipelineMetrics `json:"pipeline"`
+}
+
+// DailyValue is a single value for one day ("2006-01-02" in the configured timezone).
+type DailyValue struct {
+	Day   string `json:"day"`
+	Value int64  `json:"value"`
+}
+
+// DevicePollDay holds the number of polls per device on one day.
+type DevicePollDay struct {
+	Day   string         `json:"day"`
+	Polls map[string]int `json:"polls"`
+}
+
+// DailyLatency is the average processing duration of the images processed on one day.
+type DailyLatency struct {
+	Day           string  `json:"day"`
+	Count         int     `json:"count"`
+	AverageMillis float64 `json:"averageMs"`
+}
+
+// GetStats returns the statistics shown on the dashboard. Days are oldest first.
+func (service *CoreService) GetStats(ctx context.Context) (Stats, error) {
+	images, err := service.allImages(ctx)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	uploads := make(map[string]int64)
+	bytes := make(map[string]int64)
+	for _, img := range images {
+		day := img.CreatedAt.In(service.tzLoc).Format(time.DateOnly)
+		uploads[day]++
+		bytes[day] += img.StoredBytes
+	}
+	days := make([]string, 0, len(uploads))
+	for day := range uploads {
+		days = append(days, day)
+	}
+	sort.Strings(days)
+
+	stats := Stats{
+		Uploads:           make([]DailyValue, 0, len(days)),
+		StoredBytes:       make([]DailyValue, 0, len(days)),
+		DevicePolls:       service.devicePolls.history(),
+		ProcessingLatency: service.processingTimes.history(),
+		Pipeline:          imageprocessing.GetPipelineMetrics(),
+	}
+	var total int64
+	for _, day := range days {
+		total += bytes[day]
+		stats.Uploads = append(stats.Uploads, DailyValue{Day: day, Value: uploads[day]})
+		stats.StoredBytes = append(stats.StoredBytes, DailyValue{Day: day, Value: total})
+	}
+	return stats, nil
+}
+
+// processingTimes accumulates pipeline durations per day.
+type processingTimes struct {
+	mu    sync.Mutex
+	daily map[string]*latencySum
+}
+
+type latencySum struct {
+	count int
+	total time.Duration
+}
+
+// record adds one processing run finished at the given time.
+func (p *processingTimes) record(at time.Time, d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.daily == nil {
+		p.daily = make(map[string]*latencySum)
+	}
+	day := at.Format(time.DateOnly)
+	sum := p.daily[day]
+	if sum == nil {
+		sum = &latencySum{}
+		p.daily[day] = sum
+		pruneDays(p.daily, at)
+	}
+	sum.count++
+	sum.total += d
+}
+
+// history returns the average duration per day, oldest day first.
+func (p *processingTimes) history() []DailyLatency {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	days := make([]DailyLatency, 0, len(p.daily))
+	for day, sum := range p.daily {
+		avg := float64(sum.total) / float64(sum.count) / float64(time.Millisecond)
+		days = append(days, DailyLatency{Day: day, Count: sum.count, AverageMillis: avg})
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Day < days[j].Day })
+	return days
+}
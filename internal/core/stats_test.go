@@ -0,0 +1,58 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+func TestGetStats(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{})
+	ctx := context.Background()
+
+	for _, size := range []int{4, 6} {
+		if _, err := service.AddImage(ctx, testPNG(t, size, size), "", database.Attribution{}); err != nil {
+			t.Fatalf("AddImage failed: %v", err)
+		}
+	}
+	if _, err := service.GetImageForDevice(ctx, "hall"); err != nil {
+		t.Fatalf("GetImageForDevice failed: %v", err)
+	}
+	if _, err := service.GetImageForDevice(ctx, ""); err != nil {
+		t.Fatalf("GetImageForDevice failed: %v", err)
+	}
+
+	stats, err := service.GetStats(ctx)
+	if err != nil {
+		t.Fatalf("GetStats failed: %v", err)
+	}
+	today := time.Now().UTC().Format(time.DateOnly)
+	if len(stats.Uploads) != 1 || stats.Uploads[0] != (DailyValue{Day: today, Value: 2}) {
+		t.Errorf("Uploads = %+v, want 2 on %s", stats.Uploads, today)
+	}
+	if len(stats.StoredBytes) != 1 || stats.StoredBytes[0].Value <= 0 {
+		t.Errorf("StoredBytes = %+v, want a positive total", stats.StoredBytes)
+	}
+	if len(stats.DevicePolls) != 1 || stats.DevicePolls[0].Polls["hall"] != 1 || stats.DevicePolls[0].Polls[""] != 1 {
+		t.Errorf("DevicePolls = %+v, want one poll each for hall and anonymous", stats.DevicePolls)
+	}
+	if len(stats.ProcessingLatency) != 1 || stats.ProcessingLatency[0].Count != 2 {
+		t.Errorf("ProcessingLatency = %+v, want two runs today", stats.ProcessingLatency)
+	}
+}
+
+func TestProcessingTimes_PrunesOldDays(t *testing.T) {
+	var times processingTimes
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	times.record(now.AddDate(0, 0, -statsRetentionDays-1), time.Second)
+	times.record(now, 2*time.Second)
+	times.record(now, 4*time.Second)
+
+	got := times.history()
+	if len(got) != 1 || got[0].Day != "2026-05-01" || got[0].Count != 2 || got[0].AverageMillis != 3000 {
+		t.Errorf("history = %+v, want one day averaging 3000ms", got)
+	}
+}
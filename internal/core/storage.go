@@ -0,0 +1,137 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+// trashWarnShare is the share of all stored bytes taken up by unreferenced
+// blobs above which a storage warning suggests a cleanup.
+const trashWarnShare = 0.1
+
+// StorageReport breaks down stored bytes per category and lists warnings about
+// the configured storage limit (notifications.storageLimitBytes).
+type StorageReport struct {
+	// Originals are the stored originals of all images.
+	Originals database.BlobUsage `json:"originals"`
+	// Processed are the stored processed images.
+	Processed database.BlobUsage `json:"processed"`
+	// Trash are blobs no image references, e.g. leftovers of failed uploads.
+	Trash      database.BlobUsage `json:"trash"`
+	TotalBytes int64              `json:"totalBytes"`
+	// LimitBytes is the configured storage limit; 0 when none is set.
+	LimitBytes int64 `json:"limitBytes,omitempty"`
+	// ReclaimableBytes is what CleanUpStorage frees: the trash and, when
+	// processed images are generated on demand, the stored processed images.
+	ReclaimableBytes int64    `json:"reclaimableBytes"`
+	Warnings         []string `json:"warnings"`
+}
+
+// CleanupReport summarises a storage cleanup.
+type CleanupReport struct {
+	// TrashDeleted counts the deleted unreferenced blobs. Blobs younger than
+	// garbageCollection.minAge are kept as they may belong to an upload in progress.
+	TrashDeleted int `json:"trashDeleted"`
+	// ProcessedDeleted counts the deleted processed images; they are only
+	// deleted when processed images are generated on demand.
+	ProcessedDeleted int `json:"processedDeleted"`
+	// FreedBytes is the total size of the deleted blobs.
+	FreedBytes int64 `json:"freedBytes"`
+}
+
+// GetStorageReport sums the stored blobs per category and warns when storage
+// approaches the configured limit or unreferenced blobs take up much space.
+func (service *CoreService) GetStorageReport(ctx context.Context) (*StorageReport, error) {
+	usage, err := service.databaseService.GetStorageUsage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cfg := service.config.Notifications
+	report := &StorageReport{
+		Originals:        usage.Originals,
+		Processed:        usage.Processed,
+		Trash:            usage.Orphaned,
+		TotalBytes:       usage.TotalBytes(),
+		LimitBytes:       cfg.StorageLimitBytes,
+		ReclaimableBytes: usage.Orphaned.Bytes,
+		Warnings:         []string{},
+	}
+	if service.processesOnDemand() {
+		report.ReclaimableBytes += usage.Processed.Bytes
+	}
+
+	if limit := cfg.StorageLimitBytes; limit > 0 && float64(report.TotalBytes) >= float64(limit)*cfg.StorageWarnRatio {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("Storage is %.0f%% full (%s of %s).",
+			100*float64(report.TotalBytes)/float64(limit), FormatBytes(report.TotalBytes), FormatBytes(limit)))
+	}
+	if report.TotalBytes > 0 && float64(usage.Orphaned.Bytes) >= float64(report.TotalBytes)*trashWarnShare {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("%d unreferenced blobs use %s; clean up to free the space.",
+			usage.Orphaned.Count, FormatBytes(usage.Orphaned.Bytes)))
+	}
+	return report, nil
+}
+
+// CleanUpStorage deletes the trash and, when processed images are generated on
+// demand, the stored processed images, which are regenerated when requested.
+// Images locked by another operation are skipped.
+func (service *CoreService) CleanUpStorage(ctx context.Context) (*CleanupReport, error) {
+	garbage, err := service.CollectGarbage(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	report := &CleanupReport{TrashDeleted: garbage.Deleted, FreedBytes: garbage.Bytes}
+	if !service.processesOnDemand() {
+		return report, nil
+	}
+
+	images, err := service.allImages(ctx)
+	if err != nil {
+		return report, err
+	}
+	for _, img := range images {
+		if img.ProcessedHash == "" {
+			continue
+		}
+		freed, err := service.deleteStoredProcessed(ctx, img.ID)
+		if errors.Is(err, ErrImageLocked) {
+			continue
+		}
+		if err != nil {
+			return report, err
+		}
+		report.ProcessedDeleted++
+		report.FreedBytes += freed
+	}
+	slog.Info("CoreService.CleanUpStorage: finished", "trashDeleted", report.TrashDeleted,
+		"processedDeleted", report.ProcessedDeleted, "freedBytes", report.FreedBytes)
+	return report, nil
+}
+
+// deleteStoredProcessed deletes the stored processed image of id and returns its size.
+func (service *CoreService) deleteStoredProcessed(ctx context.Context, id string) (int64, error) {
+	unlock, err := service.locks.tryLock("clean up", id)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	defer service.currentImages.invalidate()
+	return service.databaseService.DeleteProcessedImage(ctx, id)
+}
+
+// FormatBytes renders n in binary units, e.g. "1.5 MiB".
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
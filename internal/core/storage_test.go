@@ -0,0 +1,78 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+func TestGetStorageReport(t *testing.T) {
+	service, db := newTestCoreService(t, &config.ServiceConfig{})
+	ctx := context.Background()
+	ids := addTestImages(t, service, 2)
+	db.PutBlob("images/failed-upload/original.png", make([]byte, 4096))
+
+	report, err := service.GetStorageReport(ctx)
+	if err != nil {
+		t.Fatalf("GetStorageReport failed: %v", err)
+	}
+	if report.Originals.Count != 2 || report.Processed.Count != 2 || report.Trash.Count != 1 || report.Trash.Bytes != 4096 {
+		t.Fatalf("unexpected categories: %+v", report)
+	}
+	if report.TotalBytes != report.Originals.Bytes+report.Processed.Bytes+4096 || report.ReclaimableBytes != 4096 {
+		t.Errorf("unexpected totals: %+v", report)
+	}
+	if len(report.Warnings) != 1 || !strings.Contains(report.Warnings[0], "unreferenced") {
+		t.Errorf("expected a trash warning, got %v", report.Warnings)
+	}
+
+	service.config.Notifications = config.Notifications{StorageLimitBytes: report.TotalBytes, StorageWarnRatio: 0.9}
+	if report, _ := service.GetStorageReport(ctx); len(report.Warnings) != 2 || !strings.Contains(report.Warnings[0], "100% full") {
+		t.Errorf("expected a limit warning, got %v", report.Warnings)
+	}
+
+	cleanup, err := service.CleanUpStorage(ctx)
+	if err != nil {
+		t.Fatalf("CleanUpStorage failed: %v", err)
+	}
+	if cleanup.TrashDeleted != 1 || cleanup.ProcessedDeleted != 0 || cleanup.FreedBytes != 4096 {
+		t.Errorf("expected only the trash to be deleted in stored mode, got %+v", cleanup)
+	}
+	if _, err := db.GetImageData(ctx, ids[0], "processed"); err != nil {
+		t.Errorf("expected processed images to be kept in stored mode, got %v", err)
+	}
+}
+
+func TestCleanUpStorage_DeletesProcessedOnDemand(t *testing.T) {
+	service, db := newTestCoreService(t, &config.ServiceConfig{
+		ProcessedImages: config.ProcessedImages{Mode: config.ProcessedImageModeOnDemand, CacheTTL: time.Minute, CacheMaxEntries: 4},
+	})
+	ctx := context.Background()
+	// Processed images left over from before switching to on-demand mode.
+	id, err := db.CreateImage(ctx, testPNG(t, 8, 8), testPNG(t, 4, 4), time.Now(), "", database.Attribution{}, "", false)
+	if err != nil {
+		t.Fatalf("CreateImage failed: %v", err)
+	}
+
+	report, _ := service.GetStorageReport(ctx)
+	if report.ReclaimableBytes != report.Processed.Bytes || report.Processed.Count != 1 {
+		t.Fatalf("expected processed images to be reclaimable, got %+v", report)
+	}
+	cleanup, err := service.CleanUpStorage(ctx)
+	if err != nil {
+		t.Fatalf("CleanUpStorage failed: %v", err)
+	}
+	if cleanup.ProcessedDeleted != 1 || cleanup.FreedBytes != report.Processed.Bytes {
+		t.Errorf("expected the processed image to be deleted, got %+v", cleanup)
+	}
+	if img, _ := db.GetImageByID(ctx, id); img.ProcessedHash != "" {
+		t.Errorf("expected processed hash to be cleared, got %q", img.ProcessedHash)
+	}
+	if _, err := service.GetProcessedImage(ctx, id); err != nil {
+		t.Errorf("expected the processed image to be regenerated, got %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+var (
+	// ErrStoryNotFound is returned when a story does not exist.
+	ErrStoryNotFound = errors.New("story not found")
+	// ErrInvalidStory is returned when a story definition is rejected.
+	ErrInvalidStory = errors.New("invalid story")
+)
+
+// GetStories returns all stories sorted by name.
+func (service *CoreService) GetStories(ctx context.Context) ([]database.Story, error) {
+	return service.databaseService.GetStories(ctx)
+}
+
+// SaveStory creates or replaces a story and moves its images next to each
+// other in the rotation order, at the position of the earliest of them, so
+// they are shown on consecutive days. An image may belong to at most one story.
+func (service *CoreService) SaveStory(ctx context.Context, story database.Story) error {
+	if !frameGroupNamePattern.MatchString(story.Name) {
+		return fmt.Errorf("%w: name %q must be 1-64 letters, digits, '.', '_' or '-'", ErrInvalidStory, story.Name)
+	}
+	if len(story.ImageIDs) == 0 {
+		return fmt.Errorf("%w: a story needs at least one image", ErrInvalidStory)
+	}
+	seen := make(map[string]bool, len(story.ImageIDs))
+	for _, id := range story.ImageIDs {
+		if seen[id] {
+			return fmt.Errorf("%w: image %q listed twice", ErrInvalidStory, id)
+		}
+		seen[id] = true
+		if _, err := service.databaseService.GetImageByID(ctx, id); err != nil {
+			return fmt.Errorf("%w: image %q not found", ErrInvalidStory, id)
+		}
+	}
+
+	stories, err := service.databaseService.GetStories(ctx)
+	if err != nil {
+		return err
+	}
+	for _, other := range stories {
+		if other.Name == story.Name {
+			continue
+		}
+		for _, id := range story.ImageIDs {
+			if slices.Contains(other.ImageIDs, id) {
+				return fmt.Errorf("%w: image %q already belongs to story %q", ErrInvalidStory, id, other.Name)
+			}
+		}
+	}
+	defer service.currentImages.invalidate()
+	return service.databaseService.PutStory(ctx, story)
+}
+
+// DeleteStory removes a story. Its images stay where they are in the order.
+func (service *CoreService) DeleteStory(ctx context.Context, name string) error {
+	stories, err := service.databaseService.GetStories(ctx)
+	if err != nil {
+		return err
+	}
+	if !slices.ContainsFunc(stories, func(s database.Story) bool { return s.Name == name }) {
+		return ErrStoryNotFound
+	}
+	return service.databaseService.DeleteStory(ctx, name)
+}
+
+// storyPart locates images in their story.
+type storyPart struct {
+	story string
+	// day is the 1-based position of the image in the story.
+	day  int
+	days int
+}
+
+// storyParts maps the images of stories among ids, the rotation order, to
+// their place in the story. Archived and pending images are not counted.
+func (service *CoreService) storyParts(ctx context.Context, ids []string) (map[string]storyPart, error) {
+	stories, err := service.databaseService.GetStories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	parts := make(map[string]storyPart)
+	for _, s := range stories {
+		members := slices.DeleteFunc(s.ImageIDs, func(id string) bool { return !slices.Contains(ids, id) })
+		for i, id := range members {
+			parts[id] = storyPart{story: s.Name, day: i + 1, days: len(members)}
+		}
+	}
+	return parts, nil
+}
@@ -0,0 +1,83 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+func TestSaveStory_ShowsImagesOnConsecutiveDays(t *testing.T) {
+	service, db := newTestCoreService(t, &config.ServiceConfig{})
+	ctx := context.Background()
+	ids := addTestImages(t, service, 5)
+
+	if err := service.SaveStory(ctx, database.Story{Name: "trip", ImageIDs: []string{ids[4], ids[1], ids[3]}}); err != nil {
+		t.Fatalf("SaveStory failed: %v", err)
+	}
+	order, _ := db.GetRotationOrderedIDs(ctx)
+	if want := []string{ids[0], ids[4], ids[1], ids[3], ids[2]}; !slices.Equal(order, want) {
+		t.Errorf("expected the story at the position of its earliest image, got %v", order)
+	}
+
+	schedules, err := service.GetImageSchedules(ctx)
+	if err != nil {
+		t.Fatalf("GetImageSchedules failed: %v", err)
+	}
+	if s := schedules[2]; s.Story != "trip" || s.StoryDay != 2 || s.StoryDays != 3 {
+		t.Errorf("expected the second day of the story, got %+v", s)
+	}
+	if s := schedules[4]; s.Story != "" {
+		t.Errorf("expected no story for %s, got %+v", s.ImageID, s)
+	}
+
+	// The daily rotation plays the story to its end before the other images.
+	if err := db.UpdateOrder(ctx, append(order[2:], order[:2]...)); err != nil {
+		t.Fatalf("UpdateOrder failed: %v", err)
+	}
+	if order, _ = db.GetRotationOrderedIDs(ctx); order[0] != ids[1] || order[1] != ids[3] {
+		t.Errorf("expected the story to continue, got %v", order)
+	}
+}
+
+func TestSaveStory_Invalid(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{})
+	ctx := context.Background()
+	ids := addTestImages(t, service, 2)
+	if err := service.SaveStory(ctx, database.Story{Name: "trip", ImageIDs: []string{ids[0]}}); err != nil {
+		t.Fatalf("SaveStory failed: %v", err)
+	}
+
+	for _, story := range []database.Story{
+		{Name: "bad name", ImageIDs: []string{ids[1]}},
+		{Name: "empty"},
+		{Name: "twice", ImageIDs: []string{ids[1], ids[1]}},
+		{Name: "unknown", ImageIDs: []string{"missing"}},
+		{Name: "other", ImageIDs: []string{ids[0]}},
+	} {
+		if err := service.SaveStory(ctx, story); !errors.Is(err, ErrInvalidStory) {
+			t.Errorf("%+v: expected ErrInvalidStory, got %v", story, err)
+		}
+	}
+}
+
+func TestDeleteStory(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{})
+	ctx := context.Background()
+	ids := addTestImages(t, service, 1)
+	if err := service.SaveStory(ctx, database.Story{Name: "trip", ImageIDs: ids}); err != nil {
+		t.Fatalf("SaveStory failed: %v", err)
+	}
+	if err := service.DeleteStory(ctx, "trip"); err != nil {
+		t.Fatalf("DeleteStory failed: %v", err)
+	}
+	if stories, _ := service.GetStories(ctx); len(stories) != 0 {
+		t.Errorf("expected no stories, got %v", stories)
+	}
+	if err := service.DeleteStory(ctx, "trip"); !errors.Is(err, ErrStoryNotFound) {
+		t.Errorf("expected ErrStoryNotFound, got %v", err)
+	}
+}
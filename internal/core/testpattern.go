@@ -0,0 +1,40 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jo-hoe/goframe/internal/imageprocessing"
+)
+
+// maxTestPatternSize bounds the width and height of test patterns in pixels.
+const maxTestPatternSize = 8192
+
+// ErrInvalidTestPattern is returned for unknown test pattern types and sizes out of range.
+var ErrInvalidTestPattern = errors.New("invalid test pattern")
+
+// GetTestPattern renders the diagnostic image kind (one of
+// imageprocessing.TestPatternTypes) for the panel the pipeline produces images
+// for, so new frames can be checked without uploading files. Positive width
+// and height override the panel size.
+func (service *CoreService) GetTestPattern(kind string, width, height int) ([]byte, error) {
+	if !slices.Contains(imageprocessing.TestPatternTypes, kind) {
+		return nil, fmt.Errorf("%w: type must be one of %s", ErrInvalidTestPattern, strings.Join(imageprocessing.TestPatternTypes, ", "))
+	}
+	if width < 0 || width > maxTestPatternSize || height < 0 || height > maxTestPatternSize {
+		return nil, fmt.Errorf("%w: width and height must be between 1 and %d", ErrInvalidTestPattern, maxTestPatternSize)
+	}
+	profile, err := imageprocessing.PanelProfileFromCommands(service.commandConfigs)
+	if err != nil {
+		return nil, err
+	}
+	if width > 0 {
+		profile.Width = width
+	}
+	if height > 0 {
+		profile.Height = height
+	}
+	return imageprocessing.RenderTestPattern(kind, profile)
+}
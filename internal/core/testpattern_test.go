@@ -0,0 +1,45 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"testing"
+
+	"github.com/jo-hoe/goframe/internal/config"
+)
+
+func TestGetTestPattern(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{
+		Commands: []config.CommandConfig{{Name: "ScaleCommand", Params: map[string]any{"width": 64, "height": 48}}},
+	})
+
+	data, err := service.GetTestPattern("stripes", 0, 0)
+	if err != nil {
+		t.Fatalf("GetTestPattern failed: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to decode test pattern: %v", err)
+	}
+	if img.Bounds().Dx() != 64 || img.Bounds().Dy() != 48 {
+		t.Errorf("expected the configured panel size, got %v", img.Bounds())
+	}
+
+	data, err = service.GetTestPattern("palette", 32, 0)
+	if err != nil {
+		t.Fatalf("GetTestPattern failed: %v", err)
+	}
+	if img, _ := png.Decode(bytes.NewReader(data)); img.Bounds().Dx() != 32 || img.Bounds().Dy() != 48 {
+		t.Errorf("expected the width to be overridden, got %v", img.Bounds())
+	}
+
+	for _, tt := range []struct {
+		kind          string
+		width, height int
+	}{{"noise", 0, 0}, {"gradient", -1, 0}, {"gradient", 0, maxTestPatternSize + 1}} {
+		if _, err := service.GetTestPattern(tt.kind, tt.width, tt.height); !errors.Is(err, ErrInvalidTestPattern) {
+			t.Errorf("expected ErrInvalidTestPattern for %+v, got %v", tt, err)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/imageprocessing"
+)
+
+const (
+	// thumbnailCacheTTL and thumbnailCacheMaxEntries bound the cache of thumbnails.
+	thumbnailCacheTTL        = 24 * time.Hour
+	thumbnailCacheMaxEntries = 256
+)
+
+// Thumbnail is a small preview of an image, turned the way the configured
+// pipeline turns it for the frame.
+type Thumbnail struct {
+	// PNG is the preview image.
+	PNG []byte
+	// QuarterTurns is the clockwise rotation applied by the pipeline (0-3).
+	QuarterTurns int
+}
+
+// GetThumbnail returns the preview of image id, at most thumbnailWidth pixels
+// wide. Thumbnails are cached; an entry starts with its quarter turns.
+func (service *CoreService) GetThumbnail(ctx context.Context, id string) (*Thumbnail, error) {
+	if entry, ok := service.thumbnails.get(id); ok {
+		return &Thumbnail{PNG: entry[1:], QuarterTurns: int(entry[0])}, nil
+	}
+	original, err := service.databaseService.GetImageData(ctx, id, "original")
+	if err != nil {
+		return nil, err
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(original))
+	if err != nil {
+		return nil, fmt.Errorf("reading original of %s: %w", id, err)
+	}
+	turns, err := imageprocessing.PipelineQuarterTurns(service.commandConfigs, cfg.Width, cfg.Height)
+	if err != nil {
+		return nil, err
+	}
+	data, err := imageprocessing.Thumbnail(original, service.config.ThumbnailWidth, turns)
+	if err != nil {
+		return nil, fmt.Errorf("rendering thumbnail of %s: %w", id, err)
+	}
+	service.thumbnails.put(id, append([]byte{byte(turns)}, data...))
+	return &Thumbnail{PNG: data, QuarterTurns: turns}, nil
+}
@@ -0,0 +1,42 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"testing"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/database"
+)
+
+func TestGetThumbnail_TurnedLikeThePipeline(t *testing.T) {
+	service, _ := newTestCoreService(t, &config.ServiceConfig{
+		ThumbnailWidth: 4,
+		Commands:       []config.CommandConfig{{Name: "OrientationCommand", Params: map[string]any{"orientation": "portrait"}}},
+	})
+	img, err := service.AddImage(context.Background(), testPNG(t, 16, 8), "", database.Attribution{})
+	if err != nil {
+		t.Fatalf("AddImage failed: %v", err)
+	}
+
+	thumb, err := service.GetThumbnail(context.Background(), img.ID)
+	if err != nil {
+		t.Fatalf("GetThumbnail failed: %v", err)
+	}
+	if thumb.QuarterTurns != 1 {
+		t.Errorf("QuarterTurns = %d, want 1", thumb.QuarterTurns)
+	}
+	decoded, err := png.Decode(bytes.NewReader(thumb.PNG))
+	if err != nil {
+		t.Fatalf("thumbnail is not a PNG: %v", err)
+	}
+	if b := decoded.Bounds(); b.Dx() != 4 || b.Dy() != 8 {
+		t.Errorf("thumbnail size = %dx%d, want 4x8", b.Dx(), b.Dy())
+	}
+
+	cached, err := service.GetThumbnail(context.Background(), img.ID)
+	if err != nil || cached.QuarterTurns != 1 || !bytes.Equal(cached.PNG, thumb.PNG) {
+		t.Errorf("Expected the cached thumbnail, got %+v, %v", cached, err)
+	}
+}
@@ -0,0 +1,143 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/config"
+	"github.com/jo-hoe/goframe/internal/imageprocessing"
+)
+
+const (
+	// weatherRefreshInterval is how long a forecast (or a failed attempt) is reused.
+	weatherRefreshInterval = 30 * time.Minute
+	// weatherTimeout bounds a forecast request made while a device waits for its image.
+	weatherTimeout = 5 * time.Second
+)
+
+// weatherReport is the current weather at one location.
+type weatherReport struct {
+	Icon        imageprocessing.OverlayIcon
+	Temperature float64
+}
+
+// weatherEntry is a cached forecast lookup. ok is false while no lookup for
+// the location succeeded yet.
+type weatherEntry struct {
+	report    weatherReport
+	ok        bool
+	fetchedAt time.Time
+}
+
+// weatherCache keeps the last forecast per API and location, so overlays
+// rendered every minute query the forecast API only every half hour.
+type weatherCache struct {
+	mu      sync.Mutex
+	entries map[string]weatherEntry
+}
+
+// currentWeather returns the weather for the widget's location. When a lookup
+// fails the last successful report is kept; ok is false if there is none.
+func (service *CoreService) currentWeather(ctx context.Context, w config.OverlayWidget) (weatherReport, bool) {
+	key := fmt.Sprintf("%s?%g,%g", w.URL, w.Latitude, w.Longitude)
+	now := service.nowFn()
+
+	service.weather.mu.Lock()
+	entry, cached := service.weather.entries[key]
+	service.weather.mu.Unlock()
+	if cached && now.Sub(entry.fetchedAt) < weatherRefreshInterval {
+		return entry.report, entry.ok
+	}
+
+	var report weatherReport
+	err := service.integrations.Breaker("weather").Do(ctx, func(ctx context.Context) error {
+		var err error
+		report, err = fetchWeather(ctx, w)
+		return err
+	})
+	if err != nil {
+		slog.Warn("CoreService.currentWeather: failed to fetch weather", "url", w.URL, "error", err)
+	} else {
+		entry.report, entry.ok = report, true
+	}
+	entry.fetchedAt = now
+
+	service.weather.mu.Lock()
+	defer service.weather.mu.Unlock()
+	if service.weather.entries == nil {
+		service.weather.entries = make(map[string]weatherEntry)
+	}
+	service.weather.entries[key] = entry
+	return entry.report, entry.ok
+}
+
+// openMeteoResponse is the part of an Open-Meteo forecast response goframe reads.
+type openMeteoResponse struct {
+	Current struct {
+		Temperature float64 `json:"temperature_2m"`
+		WeatherCode int     `json:"weather_code"`
+	} `json:"current"`
+}
+
+// fetchWeather queries the Open-Meteo compatible forecast API of w.
+func fetchWeather(ctx context.Context, w config.OverlayWidget) (weatherReport, error) {
+	ctx, cancel := context.WithTimeout(ctx, weatherTimeout)
+	defer cancel()
+
+	u, err := url.Parse(w.URL)
+	if err != nil {
+		return weatherReport{}, fmt.Errorf("invalid weather url: %w", err)
+	}
+	q := u.Query()
+	q.Set("latitude", strconv.FormatFloat(w.Latitude, 'f', -1, 64))
+	q.Set("longitude", strconv.FormatFloat(w.Longitude, 'f', -1, 64))
+	q.Set("current", "temperature_2m,weather_code")
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return weatherReport{}, fmt.Errorf("building weather request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return weatherReport{}, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return weatherReport{}, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	var forecast openMeteoResponse
+	if err := json.NewDecoder(resp.Body).Decode(&forecast); err != nil {
+		return weatherReport{}, fmt.Errorf("decoding weather response: %w", err)
+	}
+	return weatherReport{
+		Icon:        weatherIcon(forecast.Current.WeatherCode),
+		Temperature: forecast.Current.Temperature,
+	}, nil
+}
+
+// weatherIcon maps a WMO weather interpretation code to an overlay icon.
+func weatherIcon(code int) imageprocessing.OverlayIcon {
+	switch {
+	case code <= 1:
+		return imageprocessing.OverlayIconClear
+	case code <= 3:
+		return imageprocessing.OverlayIconCloudy
+	case code == 45 || code == 48:
+		return imageprocessing.OverlayIconFog
+	case code >= 71 && code <= 77, code == 85, code == 86:
+		return imageprocessing.OverlayIconSnow
+	case code >= 95:
+		return imageprocessing.OverlayIconStorm
+	case code >= 51:
+		return imageprocessing.OverlayIconRain
+	}
+	return imageprocessing.OverlayIconCloudy
+}
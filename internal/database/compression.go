@@ -0,0 +1,67 @@
+package database
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// EncodingZstd marks original blobs stored zstd-compressed.
+const EncodingZstd = "zstd"
+
+// zstdEncoder is shared; EncodeAll is safe for concurrent use.
+var zstdEncoder, _ = zstd.NewWriter(nil)
+
+// originalContentType is the content type an original with encoding is stored with.
+func originalContentType(encoding string) string {
+	if encoding == EncodingZstd {
+		return "application/zstd"
+	}
+	return "image/png"
+}
+
+// originalStoredSize returns the size of original as stored with encoding.
+// Compression is deterministic, so the compressed size can be recomputed.
+func originalStoredSize(original []byte, encoding string) int64 {
+	if encoding == EncodingZstd {
+		return int64(len(compressBlob(original)))
+	}
+	return int64(len(original))
+}
+
+// compressBlob returns data compressed with zstd.
+func compressBlob(data []byte) []byte {
+	return zstdEncoder.EncodeAll(data, make([]byte, 0, len(data)/2))
+}
+
+// decompressBlob reads a zstd-compressed blob in full.
+func decompressBlob(data []byte) ([]byte, error) {
+	rc, err := decompressingReader(io.NopCloser(bytes.NewReader(data)))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rc.Close() }()
+	return io.ReadAll(rc)
+}
+
+// decompressingReader streams the zstd-compressed content of r. Closing the
+// result releases the decoder and closes r.
+func decompressingReader(r io.ReadCloser) (io.ReadCloser, error) {
+	dec, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		_ = r.Close()
+		return nil, err
+	}
+	return &zstdReadCloser{Decoder: dec, body: r}, nil
+}
+
+type zstdReadCloser struct {
+	*zstd.Decoder
+	body io.ReadCloser
+}
+
+func (z *zstdReadCloser) Close() error {
+	z.Decoder.Close()
+	return z.body.Close()
+}
@@ -0,0 +1,71 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestCompressBlob_RoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte("goframe "), 1000)
+	compressed := compressBlob(data)
+	if len(compressed) >= len(data) {
+		t.Errorf("expected compression to shrink repetitive data, got %d >= %d", len(compressed), len(data))
+	}
+	got, err := decompressBlob(compressed)
+	if err != nil {
+		t.Fatalf("decompressBlob failed: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Error("round trip changed the data")
+	}
+
+	rc, err := decompressingReader(io.NopCloser(bytes.NewReader(compressed)))
+	if err != nil {
+		t.Fatalf("decompressingReader failed: %v", err)
+	}
+	streamed, err := io.ReadAll(rc)
+	if err != nil || !bytes.Equal(streamed, data) {
+		t.Errorf("streaming decompression failed: %v", err)
+	}
+	if err := rc.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+}
+
+func TestFakeDatabase_MigrateOriginalEncoding(t *testing.T) {
+	ctx := context.Background()
+	db := NewFakeDatabase("")
+	original := bytes.Repeat([]byte{1, 2, 3, 4}, 512)
+	id, err := db.CreateImage(ctx, original, nil, time.Now(), "", Attribution{}, "", false)
+	if err != nil {
+		t.Fatalf("CreateImage failed: %v", err)
+	}
+
+	db.CompressOriginals = true
+	changed, err := db.MigrateOriginalEncoding(ctx, id)
+	if err != nil || !changed {
+		t.Fatalf("MigrateOriginalEncoding = %v, %v; want changed", changed, err)
+	}
+	img, _ := db.GetImageByID(ctx, id)
+	if img.OriginalEncoding != EncodingZstd || img.StoredBytes >= int64(len(original)) {
+		t.Errorf("expected a smaller compressed original, got %+v", img)
+	}
+	if data, err := db.GetImageData(ctx, id, "original"); err != nil || !bytes.Equal(data, original) {
+		t.Errorf("expected the original back uncompressed, got %d bytes, %v", len(data), err)
+	}
+	if changed, _ := db.MigrateOriginalEncoding(ctx, id); changed {
+		t.Error("expected a second migration to be a no-op")
+	}
+
+	db.CompressOriginals = false
+	if changed, err := db.MigrateOriginalEncoding(ctx, id); err != nil || !changed {
+		t.Fatalf("MigrateOriginalEncoding back = %v, %v; want changed", changed, err)
+	}
+	img, _ = db.GetImageByID(ctx, id)
+	if img.OriginalEncoding != "" || img.StoredBytes != int64(len(original)) {
+		t.Errorf("expected the original stored as uploaded again, got %+v", img)
+	}
+}
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"errors"
+	"slices"
+	"sort"
+)
+
+// ErrCurrentImageChanged is returned by SwapCurrentImage when the current
+// image is no longer the one the caller based its change on.
+var ErrCurrentImageChanged = errors.New("current image changed")
+
+// settleOrder restores the invariants of the rotation before rotation.json is
+// written: ordered_ids holds every image in the rotation exactly once, in the
+// given order with images missing from it appended oldest first and the
+// images of each story next to each other, and current_id points at its head. A deleted, archived or pending image can
+// therefore never be current, and an order computed before a concurrent
+// upload does not drop the new image.
+func (rs *rotationState) settleOrder() {
+	seen := make(map[string]bool, len(rs.OrderedIDs))
+	order := make([]string, 0, len(rs.OrderedIDs))
+	for _, id := range rs.OrderedIDs {
+		if meta, ok := rs.Images[id]; ok && meta.inRotation() && !seen[id] {
+			seen[id] = true
+			order = append(order, id)
+		}
+	}
+	var missing []string
+	for id, meta := range rs.Images {
+		if meta.inRotation() && !seen[id] {
+			missing = append(missing, id)
+		}
+	}
+	sort.Slice(missing, func(i, j int) bool {
+		a, b := rs.Images[missing[i]].CreatedAt, rs.Images[missing[j]].CreatedAt
+		if !a.Equal(b) {
+			return a.Before(b)
+		}
+		return missing[i] < missing[j]
+	})
+	rs.OrderedIDs = append(order, missing...)
+	rs.groupStories()
+
+	rs.CurrentID = ""
+	if len(rs.OrderedIDs) > 0 {
+		rs.CurrentID = rs.OrderedIDs[0]
+	}
+}
+
+// currentID returns the image currently selected for display. rotation.json
+// written before current_id existed, or by an older operator that drops it,
+// falls back to the first image of ordered_ids that is still in the rotation.
+func (rs rotationState) currentID() (string, bool) {
+	if len(rs.OrderedIDs) > 0 && rs.CurrentID == rs.OrderedIDs[0] {
+		return rs.CurrentID, true
+	}
+	for _, id := range rs.OrderedIDs {
+		if meta, ok := rs.Images[id]; ok && meta.inRotation() {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// swapCurrentImage replaces the order if the current image is still
+// expectedID ("" when the rotation is empty).
+func (rs *rotationState) swapCurrentImage(expectedID string, order []string) error {
+	if current, _ := rs.currentID(); current != expectedID {
+		return ErrCurrentImageChanged
+	}
+	rs.OrderedIDs = slices.Clone(order)
+	rs.settleOrder()
+	return nil
+}
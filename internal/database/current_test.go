@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRotationState_SettleOrder(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	rs := rotationState{
+		Images: map[string]imageMetadata{
+			"a":        {CreatedAt: base},
+			"b":        {CreatedAt: base.Add(time.Hour)},
+			"c":        {CreatedAt: base.Add(2 * time.Hour)},
+			"d":        {CreatedAt: base.Add(3 * time.Hour)},
+			"archived": {CreatedAt: base, Archived: true},
+			"pending":  {CreatedAt: base, Pending: true},
+		},
+		// A stale order: deleted, archived and pending IDs, a duplicate, and
+		// c and d added after it was read.
+		OrderedIDs: []string{"deleted", "b", "archived", "a", "b", "pending"},
+	}
+	rs.settleOrder()
+	if want := []string{"b", "a", "c", "d"}; !slices.Equal(rs.OrderedIDs, want) {
+		t.Errorf("expected order %v, got %v", want, rs.OrderedIDs)
+	}
+	if rs.CurrentID != "b" {
+		t.Errorf("expected current_id to point at the head, got %q", rs.CurrentID)
+	}
+
+	rs.Images = map[string]imageMetadata{}
+	rs.settleOrder()
+	if len(rs.OrderedIDs) != 0 || rs.CurrentID != "" {
+		t.Errorf("expected an empty rotation to clear the pointer, got %v and %q", rs.OrderedIDs, rs.CurrentID)
+	}
+}
+
+func TestRotationState_CurrentIDFallsBackWithoutPointer(t *testing.T) {
+	rs := rotationState{
+		Images:     map[string]imageMetadata{"a": {}, "b": {Archived: true}},
+		OrderedIDs: []string{"deleted", "b", "a"},
+	}
+	if id, ok := rs.currentID(); !ok || id != "a" {
+		t.Errorf("expected the first image still in the rotation, got %q", id)
+	}
+	if _, ok := (rotationState{}).currentID(); ok {
+		t.Error("expected no current image in an empty rotation")
+	}
+}
+
+func TestFakeDatabase_SwapCurrentImage(t *testing.T) {
+	ctx := context.Background()
+	db := NewFakeDatabase("")
+	ids := make([]string, 3)
+	for i := range ids {
+		id, err := db.CreateImage(ctx, encodeTestPNG(t, 2, 2), nil, time.Now(), "", Attribution{}, "", false)
+		if err != nil {
+			t.Fatalf("CreateImage failed: %v", err)
+		}
+		ids[i] = id
+	}
+
+	if err := db.SwapCurrentImage(ctx, ids[1], []string{ids[2], ids[0], ids[1]}); !errors.Is(err, ErrCurrentImageChanged) {
+		t.Fatalf("expected ErrCurrentImageChanged for a stale current image, got %v", err)
+	}
+	if current, _ := db.GetCurrentImageID(ctx); current != ids[0] {
+		t.Fatalf("expected a failed swap to leave the current image, got %q", current)
+	}
+
+	// The order was read before ids[2] was deleted.
+	if err := db.DeleteImage(ctx, ids[2]); err != nil {
+		t.Fatalf("DeleteImage failed: %v", err)
+	}
+	if err := db.SwapCurrentImage(ctx, ids[0], []string{ids[2], ids[1], ids[0]}); err != nil {
+		t.Fatalf("SwapCurrentImage failed: %v", err)
+	}
+	if current, _ := db.GetCurrentImageID(ctx); current != ids[1] {
+		t.Errorf("expected the deleted image to be skipped, got %q", current)
+	}
+	if order, _ := db.GetRotationOrderedIDs(ctx); !slices.Equal(order, []string{ids[1], ids[0]}) {
+		t.Errorf("unexpected order %v", order)
+	}
+}
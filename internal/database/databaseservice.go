@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"fmt"
+	"io"
 	"time"
 )
 
@@ -10,14 +11,59 @@ type DatabaseService interface {
 	Close() error
 
 	// CreateImage uploads blobs to RustFS and registers the image in the rotation state.
+	// processed may be nil to store only the original (processed images generated on demand).
 	// createdAt is stored as-is (caller is responsible for timezone).
 	// source is an informational origin label (empty string for manual uploads).
+	// attribution records the upstream URL, author, and license (zero value when unknown).
 	// afterID is the image ID to insert after in the display order; pass "" to append.
-	CreateImage(ctx context.Context, original []byte, processed []byte, createdAt time.Time, source string, afterID string) (string, error)
+	// pending images are stored but kept out of the display order until approved.
+	CreateImage(ctx context.Context, original []byte, processed []byte, createdAt time.Time, source string, attribution Attribution, afterID string, pending bool) (string, error)
 
 	// GetImageMetadata returns all image metadata in current display order (index 0 = today).
 	GetImageMetadata(ctx context.Context) ([]*Image, error)
 
+	// GetArchivedImages returns archived images, newest first. Archived images are
+	// stored but not part of the display order.
+	GetArchivedImages(ctx context.Context) ([]*Image, error)
+
+	// SetArchived archives (removes from the display order) or unarchives
+	// (appends to the display order) the given images.
+	SetArchived(ctx context.Context, ids []string, archived bool) error
+
+	// GetPendingImages returns images awaiting moderation, newest first.
+	GetPendingImages(ctx context.Context) ([]*Image, error)
+
+	// ApproveImage clears the pending state and appends the image to the display order.
+	ApproveImage(ctx context.Context, id string) error
+
+	// SetPerceptualHash stores the hex-encoded perceptual hash of an image.
+	SetPerceptualHash(ctx context.Context, id, hash string) error
+
+	// SetEnhancementPreset records the preset AutoEnhanceCommand chose for an image.
+	SetEnhancementPreset(ctx context.Context, id, preset string) error
+
+	// SetAltText stores the text describing an image for screen readers.
+	SetAltText(ctx context.Context, id, text string) error
+
+	// SetImageText stores the title and description of an image.
+	SetImageText(ctx context.Context, id, title, description string) error
+
+	// SetFit stores how an image is fitted to the frame ("contain" or "cover");
+	// "" uses the configured pipeline.
+	SetFit(ctx context.Context, id, fit string) error
+
+	// SetFlatArt stores whether an image is dithered without error diffusion
+	// ("always" or "never"); "" uses the configured pipeline.
+	SetFlatArt(ctx context.Context, id, flatArt string) error
+
+	// SetCrop stores the region of interest an image is cropped to before
+	// processing; nil processes the whole image.
+	SetCrop(ctx context.Context, id string, crop *Region) error
+
+	// PutProcessedImage replaces the stored processed blob of an image and
+	// updates its content hash, e.g. after reprocessing.
+	PutProcessedImage(ctx context.Context, id string, processed []byte) error
+
 	// GetImageByID returns metadata for a single image.
 	GetImageByID(ctx context.Context, id string) (*Image, error)
 
@@ -25,12 +71,20 @@ type DatabaseService interface {
 	DeleteImage(ctx context.Context, id string) error
 
 	// UpdateOrder replaces the display order with the given ID slice atomically.
+	// IDs not in the rotation are dropped and images missing from it are appended.
 	UpdateOrder(ctx context.Context, order []string) error
 
 	// GetRotationOrderedIDs returns the full ordered ID list from rotation.json
 	// (index 0 = today's image). This reflects the operator's latest rotation.
 	GetRotationOrderedIDs(ctx context.Context) ([]string, error)
 
+	// SwapCurrentImage replaces the display order with order if the current
+	// image is still expectedID ("" for an empty rotation), so the current
+	// image changes atomically. It fails with ErrCurrentImageChanged otherwise.
+	// IDs not in the rotation are dropped and images missing from order are
+	// appended.
+	SwapCurrentImage(ctx context.Context, expectedID string, order []string) error
+
 	// GetCurrentImageID returns the ID of the image currently selected for display.
 	GetCurrentImageID(ctx context.Context) (string, error)
 
@@ -38,18 +92,108 @@ type DatabaseService interface {
 	// variant ("original" or "processed"). The URL is routed through the ingress.
 	GetCurrentImageURL(ctx context.Context, id, variant string) (string, error)
 
+	// GetImageData returns the stored blob for the given image ID and variant
+	// ("original" or "processed"). Returns an error when the blob does not exist.
+	GetImageData(ctx context.Context, id, variant string) ([]byte, error)
+
 	// GetLastRotatedTime returns the timestamp of the last rotation advance.
 	GetLastRotatedTime(ctx context.Context) (time.Time, error)
+
+	// GetDisplayHistory returns which image was current on each recorded day, oldest first.
+	GetDisplayHistory(ctx context.Context) ([]DisplayRecord, error)
+
+	// RecordDisplay records imageID as the image shown on day ("2006-01-02").
+	RecordDisplay(ctx context.Context, day, imageID string) error
+
+	// AddImageEvent appends event to the lifecycle log of image id.
+	AddImageEvent(ctx context.Context, id string, event ImageEvent) error
+
+	// GetImageEvents returns the lifecycle log of image id, oldest first.
+	GetImageEvents(ctx context.Context, id string) ([]ImageEvent, error)
+
+	// GetFrameGroups returns all frame groups sorted by name.
+	GetFrameGroups(ctx context.Context) ([]FrameGroup, error)
+
+	// PutFrameGroup creates or replaces the frame group with group.Name.
+	PutFrameGroup(ctx context.Context, group FrameGroup) error
+
+	// DeleteFrameGroup removes the named frame group. Deleting an unknown group is a no-op.
+	DeleteFrameGroup(ctx context.Context, name string) error
+
+	// OpenImageData streams the blob for the given image and variant,
+	// decompressing compressed originals. The caller must close the reader.
+	OpenImageData(ctx context.Context, id, variant string) (io.ReadCloser, error)
+
+	// MigrateOriginalEncoding rewrites an image's original compressed or
+	// uncompressed to match new uploads and reports whether it changed.
+	MigrateOriginalEncoding(ctx context.Context, id string) (bool, error)
+
+	// FindOrphanedBlobs lists the image blobs that no image references.
+	FindOrphanedBlobs(ctx context.Context) ([]OrphanedBlob, error)
+
+	// DeleteOrphanedBlobs deletes those of keys that are still unreferenced and
+	// returns the deleted keys.
+	DeleteOrphanedBlobs(ctx context.Context, keys []string) ([]string, error)
+
+	// RecoverStagedUploads deletes the blobs of uploads staged before cutoff
+	// that never completed, e.g. because the process died, clears their
+	// markers and returns their IDs.
+	RecoverStagedUploads(ctx context.Context, cutoff time.Time) ([]string, error)
+
+	// GetStorageUsage sums the stored image blobs per category.
+	GetStorageUsage(ctx context.Context) (StorageUsage, error)
+
+	// DeleteProcessedImage removes the stored processed blob of an image, clears
+	// its hash and returns the size of the removed blob, e.g. when processed
+	// images are generated on demand.
+	DeleteProcessedImage(ctx context.Context, id string) (int64, error)
+
+	// GetMats returns the configured mats keyed by device ID; GlobalMat holds the default.
+	GetMats(ctx context.Context) (map[string]Mat, error)
+
+	// PutMat creates or replaces the mat for deviceID (GlobalMat for the default).
+	PutMat(ctx context.Context, deviceID string, mat Mat) error
+
+	// DeleteMat removes the mat for deviceID. Deleting an unknown mat is a no-op.
+	DeleteMat(ctx context.Context, deviceID string) error
+
+	// GetPalettes returns the calibrated palettes keyed by device ID.
+	GetPalettes(ctx context.Context) (map[string]Palette, error)
+
+	// PutPalette creates or replaces the calibrated palette for deviceID.
+	PutPalette(ctx context.Context, deviceID string, palette Palette) error
+
+	// DeletePalette removes the palette for deviceID. Deleting an unknown palette is a no-op.
+	DeletePalette(ctx context.Context, deviceID string) error
+
+	// GetStories returns all stories sorted by name.
+	GetStories(ctx context.Context) ([]Story, error)
+
+	// PutStory creates or replaces the story with story.Name and moves its
+	// images next to each other in the order.
+	PutStory(ctx context.Context, story Story) error
+
+	// DeleteStory removes the named story; its images stay where they are.
+	// Deleting an unknown story is a no-op.
+	DeleteStory(ctx context.Context, name string) error
+
+	// GetDevices returns the registered devices sorted by ID.
+	GetDevices(ctx context.Context) ([]Device, error)
+
+	// PutDevice creates or replaces the registration of device.ID.
+	PutDevice(ctx context.Context, device Device) error
 }
 
 // NewDatabaseWithNamespace constructs a DatabaseService from the given config.
 // dbType must be "rustfs". endpoint is the RustFS base URL, bucket is the S3
 // bucket name (used as the namespace), accessKey/secretKey are the credentials,
-// and imageBaseURL is the browser-facing URL prefix for image assets (e.g. "/images").
-func NewDatabaseWithNamespace(dbType, endpoint, bucket, accessKey, secretKey, imageBaseURL string) (DatabaseService, error) {
+// imageBaseURL is the browser-facing URL prefix for image assets (e.g. "/images"),
+// compressOriginals stores new originals zstd-compressed and idFormat selects
+// the format of new image IDs.
+func NewDatabaseWithNamespace(dbType, endpoint, bucket, accessKey, secretKey, imageBaseURL string, compressOriginals bool, idFormat string) (DatabaseService, error) {
 	switch dbType {
 	case "rustfs":
-		return NewRustFSDatabase(endpoint, bucket, accessKey, secretKey, "us-east-1", imageBaseURL)
+		return NewRustFSDatabase(endpoint, bucket, accessKey, secretKey, "us-east-1", imageBaseURL, compressOriginals, idFormat)
 	default:
 		return nil, fmt.Errorf("unsupported database driver: %s", dbType)
 	}
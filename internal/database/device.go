@@ -0,0 +1,25 @@
+package database
+
+import (
+	"sort"
+	"time"
+)
+
+// Device is a frame registered with the server. What it reports when it
+// fetches images, such as its battery level, is tracked in memory by the
+// server and not stored.
+type Device struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name,omitempty"`
+	RegisteredAt time.Time `json:"registered_at"`
+}
+
+// sortedDevices returns the registered devices ordered by ID.
+func (rs rotationState) sortedDevices() []Device {
+	devices := make([]Device, 0, len(rs.Devices))
+	for _, d := range rs.Devices {
+		devices = append(devices, d)
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].ID < devices[j].ID })
+	return devices
+}
@@ -0,0 +1,50 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// Image event types.
+const (
+	// ImageEventUploaded is recorded when an image is added.
+	ImageEventUploaded = "uploaded"
+	// ImageEventProcessed is recorded when the processed image is first rendered.
+	ImageEventProcessed = "processed"
+	// ImageEventReprocessed is recorded when the processed image is replaced.
+	ImageEventReprocessed = "reprocessed"
+	// ImageEventDisplayed is recorded when the image becomes the image of the day.
+	ImageEventDisplayed = "displayed"
+	// ImageEventEdited is recorded when the image's settings or text change.
+	ImageEventEdited = "edited"
+)
+
+// maxImageEvents bounds the events kept per image; older ones are dropped.
+const maxImageEvents = 200
+
+// ImageEvent is one entry of an image's lifecycle log.
+type ImageEvent struct {
+	At   time.Time `json:"at"`
+	Type string    `json:"type"`
+	// PipelineHash identifies the pipeline that rendered the processed image,
+	// so changes in how an image looks can be traced to configuration changes.
+	PipelineHash string `json:"pipeline_hash,omitempty"`
+	// Detail describes the event, e.g. which setting was edited.
+	Detail string `json:"detail,omitempty"`
+}
+
+// addImageEvent appends event to the log of image id.
+func (rs *rotationState) addImageEvent(id string, event ImageEvent) error {
+	if _, ok := rs.Images[id]; !ok {
+		return fmt.Errorf("%w: %s", ErrImageNotFound, id)
+	}
+	if rs.Events == nil {
+		rs.Events = make(map[string][]ImageEvent)
+	}
+	events := append(rs.Events[id], event)
+	if len(events) > maxImageEvents {
+		events = events[len(events)-maxImageEvents:]
+	}
+	rs.Events[id] = events
+	return nil
+}
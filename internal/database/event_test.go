@@ -0,0 +1,41 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFakeDatabase_ImageEvents(t *testing.T) {
+	ctx := context.Background()
+	db := NewFakeDatabase("")
+	id, err := db.CreateImage(ctx, encodeTestPNG(t, 4, 4), nil, time.Now(), "", Attribution{}, "", false)
+	if err != nil {
+		t.Fatalf("CreateImage failed: %v", err)
+	}
+
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	for i := range maxImageEvents + 5 {
+		event := ImageEvent{At: start.Add(time.Duration(i) * time.Minute), Type: ImageEventDisplayed}
+		if err := db.AddImageEvent(ctx, id, event); err != nil {
+			t.Fatalf("AddImageEvent failed: %v", err)
+		}
+	}
+	events, err := db.GetImageEvents(ctx, id)
+	if err != nil {
+		t.Fatalf("GetImageEvents failed: %v", err)
+	}
+	if len(events) != maxImageEvents || !events[0].At.Equal(start.Add(5*time.Minute)) {
+		t.Errorf("expected the newest %d events, got %d starting at %v", maxImageEvents, len(events), events[0].At)
+	}
+
+	if err := db.AddImageEvent(ctx, "missing", ImageEvent{Type: ImageEventEdited}); err == nil {
+		t.Error("expected an error for an unknown image")
+	}
+	if err := db.DeleteImage(ctx, id); err != nil {
+		t.Fatalf("DeleteImage failed: %v", err)
+	}
+	if _, ok := db.state.Events[id]; ok {
+		t.Error("expected the events to be deleted with the image")
+	}
+}
@@ -1,8 +1,14 @@
 package database
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 )
@@ -12,7 +18,14 @@ import (
 type FakeDatabase struct {
 	mu           sync.Mutex
 	state        rotationState
+	blobs        map[string][]byte
 	imageBaseURL string
+	// CompressOriginals stores new originals zstd-compressed, as with the
+	// database.compressOriginals option. Set it before use.
+	CompressOriginals bool
+	// IDFormat is the format of new image IDs, as with the database.idFormat
+	// option; "" is IDFormatUUIDv4.
+	IDFormat string
 }
 
 // NewFakeDatabase returns an empty FakeDatabase.
@@ -22,20 +35,18 @@ func NewFakeDatabase(imageBaseURL string) *FakeDatabase {
 	}
 	return &FakeDatabase{
 		state:        rotationState{Images: make(map[string]imageMetadata)},
+		blobs:        make(map[string][]byte),
 		imageBaseURL: imageBaseURL,
 	}
 }
 
 func (f *FakeDatabase) Close() error { return nil }
 
-func (f *FakeDatabase) CreateImage(_ context.Context, original, processed []byte, createdAt time.Time, source, afterID string) (string, error) {
+func (f *FakeDatabase) CreateImage(_ context.Context, original, processed []byte, createdAt time.Time, source string, attribution Attribution, afterID string, pending bool) (string, error) {
 	if original == nil {
 		return "", fmt.Errorf("original image data cannot be nil")
 	}
-	if processed == nil {
-		return "", fmt.Errorf("processed image data cannot be nil")
-	}
-	id, err := generateID()
+	id, err := newID(f.IDFormat, createdAt)
 	if err != nil {
 		return "", err
 	}
@@ -46,8 +57,29 @@ func (f *FakeDatabase) CreateImage(_ context.Context, original, processed []byte
 	if f.state.Images == nil {
 		f.state.Images = make(map[string]imageMetadata)
 	}
-	f.state.Images[id] = imageMetadata{CreatedAt: createdAt.UTC(), Source: source}
-	f.state.OrderedIDs = insertIDAfter(f.state.OrderedIDs, id, afterID)
+	if f.blobs == nil {
+		f.blobs = make(map[string][]byte)
+	}
+	stored, key, encoding := storedOriginal(id, original, f.CompressOriginals)
+	f.blobs[key] = stored
+	if processed != nil {
+		f.blobs[imageProcessedKey(id, 0)] = processed
+	}
+	f.state.Images[id] = imageMetadata{
+		CreatedAt:        createdAt.UTC(),
+		Source:           source,
+		Attribution:      attribution,
+		OriginalHash:     ContentHash(original),
+		ProcessedHash:    ContentHash(processed),
+		StoredBytes:      int64(len(stored) + len(processed)),
+		Pending:          pending,
+		OriginalEncoding: encoding,
+		Original:         NewRendition(original, createdAt),
+		Processed:        NewRendition(processed, createdAt),
+	}
+	if !pending {
+		f.state.OrderedIDs = insertIDAfter(f.state.OrderedIDs, id, afterID)
+	}
 	return id, nil
 }
 
@@ -57,31 +89,134 @@ func (f *FakeDatabase) GetImageMetadata(_ context.Context) ([]*Image, error) {
 
 	images := make([]*Image, 0, len(f.state.OrderedIDs))
 	for _, id := range f.state.OrderedIDs {
-		meta := f.state.Images[id]
-		images = append(images, &Image{ID: id, CreatedAt: meta.CreatedAt, Source: meta.Source})
+		images = append(images, f.state.Images[id].toImage(id))
 	}
 	return images, nil
 }
 
+func (f *FakeDatabase) GetArchivedImages(_ context.Context) ([]*Image, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.state.imagesWhere(isArchived), nil
+}
+
+func (f *FakeDatabase) SetArchived(_ context.Context, ids []string, archived bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.state.updateImages(ids, func(m *imageMetadata) { m.Archived = archived })
+}
+
+func (f *FakeDatabase) GetPendingImages(_ context.Context) ([]*Image, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.state.imagesWhere(isPending), nil
+}
+
+func (f *FakeDatabase) ApproveImage(_ context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.state.updateImages([]string{id}, func(m *imageMetadata) { m.Pending = false })
+}
+
+func (f *FakeDatabase) SetPerceptualHash(_ context.Context, id, hash string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.state.updateImages([]string{id}, func(m *imageMetadata) { m.PerceptualHash = hash })
+}
+
+func (f *FakeDatabase) SetEnhancementPreset(_ context.Context, id, preset string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.state.updateImages([]string{id}, func(m *imageMetadata) { m.EnhancementPreset = preset })
+}
+
+func (f *FakeDatabase) SetAltText(_ context.Context, id, text string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.state.updateImages([]string{id}, func(m *imageMetadata) { m.AltText = text })
+}
+
+func (f *FakeDatabase) SetImageText(_ context.Context, id, title, description string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.state.updateImages([]string{id}, func(m *imageMetadata) { m.Title, m.Description = title, description })
+}
+
+func (f *FakeDatabase) SetFit(_ context.Context, id, fit string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.state.updateImages([]string{id}, func(m *imageMetadata) { m.Fit = fit })
+}
+
+func (f *FakeDatabase) SetFlatArt(_ context.Context, id, flatArt string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.state.updateImages([]string{id}, func(m *imageMetadata) { m.FlatArt = flatArt })
+}
+
+func (f *FakeDatabase) SetCrop(_ context.Context, id string, crop *Region) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.state.updateImages([]string{id}, func(m *imageMetadata) { m.Crop = crop })
+}
+
+func (f *FakeDatabase) PutProcessedImage(_ context.Context, id string, processed []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	generation := f.state.Images[id].ProcessedGeneration
+	previousKey := imageProcessedKey(id, generation)
+	previous := len(f.blobs[previousKey])
+	if err := f.state.updateImages([]string{id}, func(m *imageMetadata) {
+		m.ProcessedHash = ContentHash(processed)
+		m.Processed = NewRendition(processed, time.Now())
+		m.ProcessedGeneration = generation + 1
+		if m.StoredBytes > 0 {
+			m.StoredBytes += int64(len(processed) - previous)
+		}
+	}); err != nil {
+		return err
+	}
+	f.blobs[imageProcessedKey(id, generation+1)] = processed
+	delete(f.blobs, previousKey)
+	return nil
+}
+
 func (f *FakeDatabase) GetImageByID(_ context.Context, id string) (*Image, error) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
 	meta, ok := f.state.Images[id]
 	if !ok {
-		return nil, fmt.Errorf("image not found: %s", id)
+		return nil, fmt.Errorf("%w: %s", ErrImageNotFound, id)
 	}
-	return &Image{ID: id, CreatedAt: meta.CreatedAt, Source: meta.Source}, nil
+	return meta.toImage(id), nil
 }
 
 func (f *FakeDatabase) DeleteImage(_ context.Context, id string) error {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
-	if _, ok := f.state.Images[id]; !ok {
-		return fmt.Errorf("image not found: %s", id)
+	meta, ok := f.state.Images[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrImageNotFound, id)
 	}
 	delete(f.state.Images, id)
+	delete(f.state.Events, id)
+	delete(f.blobs, imageOriginalKey(id))
+	delete(f.blobs, imageCompressedOriginalKey(id))
+	delete(f.blobs, imageProcessedKey(id, meta.ProcessedGeneration))
 	f.state.OrderedIDs = removeID(f.state.OrderedIDs, id)
 	return nil
 }
@@ -90,10 +225,18 @@ func (f *FakeDatabase) UpdateOrder(_ context.Context, order []string) error {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
-	f.state.OrderedIDs = order
+	f.state.OrderedIDs = slices.Clone(order)
+	f.state.settleOrder()
 	return nil
 }
 
+func (f *FakeDatabase) SwapCurrentImage(_ context.Context, expectedID string, order []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.state.swapCurrentImage(expectedID, order)
+}
+
 func (f *FakeDatabase) GetRotationOrderedIDs(_ context.Context) ([]string, error) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
@@ -107,21 +250,189 @@ func (f *FakeDatabase) GetCurrentImageID(_ context.Context) (string, error) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
-	if len(f.state.OrderedIDs) == 0 {
+	id, ok := f.state.currentID()
+	if !ok {
 		return "", fmt.Errorf("no images")
 	}
-	return f.state.OrderedIDs[0], nil
+	return id, nil
 }
 
 func (f *FakeDatabase) GetCurrentImageURL(_ context.Context, id, variant string) (string, error) {
 	switch variant {
 	case "processed":
-		return f.imageBaseURL + "/" + id + "/processed.png", nil
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		key := imageProcessedKey(id, f.state.Images[id].ProcessedGeneration)
+		return f.imageBaseURL + "/" + strings.TrimPrefix(key, imagesPrefix), nil
 	default:
 		return f.imageBaseURL + "/" + id + "/original.png", nil
 	}
 }
 
+func (f *FakeDatabase) GetImageData(_ context.Context, id, variant string) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.imageData(id, variant)
+}
+
+// imageData returns the decompressed blob; f.mu must be held.
+func (f *FakeDatabase) imageData(id, variant string) ([]byte, error) {
+	key := imageOriginalKey(id)
+	if variant == "processed" {
+		key = imageProcessedKey(id, f.state.Images[id].ProcessedGeneration)
+	} else if data, ok := f.blobs[imageCompressedOriginalKey(id)]; ok {
+		return decompressBlob(data)
+	}
+	data, ok := f.blobs[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s (%s)", ErrImageNotFound, id, variant)
+	}
+	return data, nil
+}
+
+func (f *FakeDatabase) OpenImageData(ctx context.Context, id, variant string) (io.ReadCloser, error) {
+	data, err := f.GetImageData(ctx, id, variant)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (f *FakeDatabase) MigrateOriginalEncoding(_ context.Context, id string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	meta, ok := f.state.Images[id]
+	if !ok {
+		return false, fmt.Errorf("%w: %s", ErrImageNotFound, id)
+	}
+	if (meta.OriginalEncoding == EncodingZstd) == f.CompressOriginals {
+		return false, nil
+	}
+	original, err := f.imageData(id, "original")
+	if err != nil {
+		return false, err
+	}
+	delete(f.blobs, imageOriginalKey(id))
+	delete(f.blobs, imageCompressedOriginalKey(id))
+	stored, key, encoding := storedOriginal(id, original, f.CompressOriginals)
+	f.blobs[key] = stored
+	if meta.StoredBytes > 0 {
+		meta.StoredBytes += int64(len(stored)) - originalStoredSize(original, meta.OriginalEncoding)
+	}
+	meta.OriginalEncoding = encoding
+	if meta.Original == (Rendition{}) {
+		meta.Original = NewRendition(original, meta.CreatedAt)
+	}
+	f.state.Images[id] = meta
+	return true, nil
+}
+
+// PutBlob stores data under key without registering an image, so tests can
+// simulate blobs left behind by failed uploads.
+func (f *FakeDatabase) PutBlob(key string, data []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.blobs[key] = data
+}
+
+// StageUpload marks id as an upload in progress since at, so tests can
+// simulate an upload interrupted by a crash.
+func (f *FakeDatabase) StageUpload(id string, at time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.state.stage(id, at)
+}
+
+// ClearRenditions forgets the recorded renditions of an image, so tests can
+// simulate images stored before renditions were recorded.
+func (f *FakeDatabase) ClearRenditions(id string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	_ = f.state.updateImages([]string{id}, func(m *imageMetadata) {
+		m.Original, m.Processed = Rendition{}, Rendition{}
+	})
+}
+
+// FindOrphanedBlobs reports unreferenced blobs; the fake does not track write
+// times, so LastModified is always zero.
+func (f *FakeDatabase) FindOrphanedBlobs(_ context.Context) ([]OrphanedBlob, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var orphans []OrphanedBlob
+	for _, key := range slices.Sorted(maps.Keys(f.blobs)) {
+		if reason := f.state.orphanReason(key); reason != "" {
+			orphans = append(orphans, OrphanedBlob{Key: key, Size: int64(len(f.blobs[key])), Reason: reason})
+		}
+	}
+	return orphans, nil
+}
+
+func (f *FakeDatabase) DeleteOrphanedBlobs(_ context.Context, keys []string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var deleted []string
+	for _, key := range keys {
+		if _, ok := f.blobs[key]; ok && f.state.orphanReason(key) != "" {
+			delete(f.blobs, key)
+			deleted = append(deleted, key)
+		}
+	}
+	return deleted, nil
+}
+
+func (f *FakeDatabase) RecoverStagedUploads(_ context.Context, cutoff time.Time) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ids := f.state.staleStaged(cutoff)
+	for _, id := range ids {
+		if _, registered := f.state.Images[id]; !registered {
+			for _, key := range stagedBlobKeys(id) {
+				delete(f.blobs, key)
+			}
+		}
+		f.state.unstage(id)
+	}
+	return ids, nil
+}
+
+func (f *FakeDatabase) GetStorageUsage(_ context.Context) (StorageUsage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var usage StorageUsage
+	for key, data := range f.blobs {
+		f.state.addBlob(&usage, key, int64(len(data)))
+	}
+	return usage, nil
+}
+
+func (f *FakeDatabase) DeleteProcessedImage(_ context.Context, id string) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := imageProcessedKey(id, f.state.Images[id].ProcessedGeneration)
+	previous := int64(len(f.blobs[key]))
+	if err := f.state.updateImages([]string{id}, func(m *imageMetadata) {
+		m.ProcessedHash = ""
+		m.Processed = Rendition{}
+		if m.StoredBytes > 0 {
+			m.StoredBytes -= previous
+		}
+	}); err != nil {
+		return 0, err
+	}
+	delete(f.blobs, key)
+	return previous, nil
+}
+
 func (f *FakeDatabase) GetLastRotatedTime(_ context.Context) (time.Time, error) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
@@ -131,3 +442,175 @@ func (f *FakeDatabase) GetLastRotatedTime(_ context.Context) (time.Time, error)
 	}
 	return f.state.LastRotated, nil
 }
+
+func (f *FakeDatabase) GetFrameGroups(_ context.Context) ([]FrameGroup, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.state.sortedGroups(), nil
+}
+
+func (f *FakeDatabase) PutFrameGroup(_ context.Context, group FrameGroup) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.state.Groups == nil {
+		f.state.Groups = make(map[string]FrameGroup)
+	}
+	f.state.Groups[group.Name] = group
+	return nil
+}
+
+func (f *FakeDatabase) DeleteFrameGroup(_ context.Context, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.state.Groups, name)
+	return nil
+}
+
+func (f *FakeDatabase) GetMats(_ context.Context) (map[string]Mat, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return maps.Clone(f.state.Mats), nil
+}
+
+func (f *FakeDatabase) PutMat(_ context.Context, deviceID string, mat Mat) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.state.Mats == nil {
+		f.state.Mats = make(map[string]Mat)
+	}
+	f.state.Mats[deviceID] = mat
+	return nil
+}
+
+func (f *FakeDatabase) DeleteMat(_ context.Context, deviceID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.state.Mats, deviceID)
+	return nil
+}
+
+func (f *FakeDatabase) GetPalettes(_ context.Context) (map[string]Palette, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return maps.Clone(f.state.Palettes), nil
+}
+
+func (f *FakeDatabase) PutPalette(_ context.Context, deviceID string, palette Palette) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.state.Palettes == nil {
+		f.state.Palettes = make(map[string]Palette)
+	}
+	f.state.Palettes[deviceID] = palette
+	return nil
+}
+
+func (f *FakeDatabase) DeletePalette(_ context.Context, deviceID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.state.Palettes, deviceID)
+	return nil
+}
+
+func (f *FakeDatabase) GetStories(_ context.Context) ([]Story, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.state.sortedStories(), nil
+}
+
+func (f *FakeDatabase) PutStory(_ context.Context, story Story) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.state.Stories == nil {
+		f.state.Stories = make(map[string]Story)
+	}
+	f.state.Stories[story.Name] = story
+	f.state.settleOrder()
+	return nil
+}
+
+func (f *FakeDatabase) DeleteStory(_ context.Context, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.state.Stories, name)
+	return nil
+}
+
+func (f *FakeDatabase) GetDevices(_ context.Context) ([]Device, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.state.sortedDevices(), nil
+}
+
+func (f *FakeDatabase) PutDevice(_ context.Context, device Device) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.state.Devices == nil {
+		f.state.Devices = make(map[string]Device)
+	}
+	f.state.Devices[device.ID] = device
+	return nil
+}
+
+func (f *FakeDatabase) AddImageEvent(_ context.Context, id string, event ImageEvent) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.state.addImageEvent(id, event)
+}
+
+func (f *FakeDatabase) GetImageEvents(_ context.Context, id string) ([]ImageEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.state.Images[id]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrImageNotFound, id)
+	}
+	return slices.Clone(f.state.Events[id]), nil
+}
+
+func (f *FakeDatabase) GetDisplayHistory(_ context.Context) ([]DisplayRecord, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]DisplayRecord(nil), f.state.History...), nil
+}
+
+func (f *FakeDatabase) RecordDisplay(_ context.Context, day, imageID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.state.recordDisplay(day, imageID)
+	return nil
+}
+
+// Fingerprint summarizes the stored state and blobs; it changes with every
+// write, so tests can assert that an operation stored nothing.
+func (f *FakeDatabase) Fingerprint() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	state, err := json.Marshal(f.state)
+	if err != nil {
+		panic(err)
+	}
+	var b strings.Builder
+	b.Write(state)
+	for _, key := range slices.Sorted(maps.Keys(f.blobs)) {
+		fmt.Fprintf(&b, "\n%s %s", key, ContentHash(f.blobs[key]))
+	}
+	return b.String()
+}
@@ -0,0 +1,20 @@
+package database
+
+// FrameGroup is a set of devices that always display the same image. Members
+// share one pointer into the rotation order: index Offset of ordered_ids (wrapping
+// around), so they advance together whenever the rotation advances.
+type FrameGroup struct {
+	Name    string   `json:"name"`
+	Devices []string `json:"devices"`
+	Offset  int      `json:"offset"`
+}
+
+// HasDevice reports whether deviceID is a member of the group.
+func (g FrameGroup) HasDevice(deviceID string) bool {
+	for _, d := range g.Devices {
+		if d == deviceID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package database
+
+import (
+	"strings"
+	"time"
+)
+
+// imagesPrefix is the key prefix of all image blobs.
+const imagesPrefix = "images/"
+
+// Reasons an OrphanedBlob is not referenced.
+const (
+	// OrphanUnknownImage blobs belong to an image that is not registered,
+	// e.g. after a failed upload or an interrupted delete.
+	OrphanUnknownImage = "unknown image"
+	// OrphanSupersededOriginal blobs are the copy of an original in the
+	// encoding the image no longer uses, e.g. after an interrupted migration.
+	OrphanSupersededOriginal = "superseded original"
+	// OrphanSupersededProcessed blobs are a previous generation of a processed
+	// image, e.g. after a replacement whose cleanup failed.
+	OrphanSupersededProcessed = "superseded processed"
+	// OrphanUnknownFile blobs have a name goframe never writes.
+	OrphanUnknownFile = "unknown file"
+)
+
+// OrphanedBlob is a stored blob that no image references.
+type OrphanedBlob struct {
+	Key  string `json:"key"`
+	Size int64  `json:"size"`
+	// LastModified is when the blob was written; zero when unknown.
+	LastModified time.Time `json:"lastModified,omitzero"`
+	Reason       string    `json:"reason"`
+}
+
+// orphanReason returns why the blob key is not referenced by any image in rs,
+// or "" when it is in use. Keys outside the images prefix are never orphans.
+func (rs *rotationState) orphanReason(key string) string {
+	rest, ok := strings.CutPrefix(key, imagesPrefix)
+	if !ok {
+		return ""
+	}
+	id, _, ok := strings.Cut(rest, "/")
+	if !ok {
+		return OrphanUnknownFile
+	}
+	if _, staged := rs.Staged[id]; staged {
+		// An upload in progress; RecoverStagedUploads owns its blobs.
+		return ""
+	}
+	meta, ok := rs.Images[id]
+	if !ok {
+		return OrphanUnknownImage
+	}
+	switch {
+	case key == imageProcessedKey(id, meta.ProcessedGeneration):
+		return ""
+	case isProcessedKey(key):
+		return OrphanSupersededProcessed
+	case key == imageOriginalKey(id) && meta.OriginalEncoding != EncodingZstd,
+		key == imageCompressedOriginalKey(id) && meta.OriginalEncoding == EncodingZstd:
+		return ""
+	case key == imageOriginalKey(id), key == imageCompressedOriginalKey(id):
+		return OrphanSupersededOriginal
+	}
+	return OrphanUnknownFile
+}
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOrphanReason(t *testing.T) {
+	rs := rotationState{Images: map[string]imageMetadata{
+		"plain":      {},
+		"compressed": {OriginalEncoding: EncodingZstd, ProcessedGeneration: 2},
+	}}
+	tests := map[string]string{
+		"rotation.json":                      "",
+		"images/plain/original.png":          "",
+		"images/plain/processed.png":         "",
+		"images/compressed/original.png.zst": "",
+		"images/compressed/processed-2.png":  "",
+		"images/compressed/processed.png":    OrphanSupersededProcessed,
+		"images/compressed/processed-1.png":  OrphanSupersededProcessed,
+		"images/plain/original.png.zst":      OrphanSupersededOriginal,
+		"images/compressed/original.png":     OrphanSupersededOriginal,
+		"images/gone/original.png":           OrphanUnknownImage,
+		"images/plain/thumbnail.png":         OrphanUnknownFile,
+		"images/stray.png":                   OrphanUnknownFile,
+	}
+	for key, want := range tests {
+		if got := rs.orphanReason(key); got != want {
+			t.Errorf("orphanReason(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestOrphanReason_StagedUpload(t *testing.T) {
+	rs := rotationState{}
+	rs.stage("uploading", time.Now())
+	if got := rs.orphanReason("images/uploading/original.png"); got != "" {
+		t.Errorf("expected the blob of a staged upload to be in use, got %q", got)
+	}
+	rs.unstage("uploading")
+	if rs.Staged != nil {
+		t.Errorf("expected no markers after unstaging, got %v", rs.Staged)
+	}
+	if got := rs.orphanReason("images/uploading/original.png"); got != OrphanUnknownImage {
+		t.Errorf("expected an unstaged blob to be an orphan, got %q", got)
+	}
+}
@@ -1,10 +1,143 @@
 package database
 
-import "time"
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"time"
+)
+
+// ErrImageNotFound is returned when the requested image is not stored.
+var ErrImageNotFound = errors.New("image not found")
 
 // Image holds per-image metadata. Blobs are stored in RustFS and accessed via URL redirects.
 type Image struct {
-	ID        string    `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	Source    string    `json:"source"`
+	ID          string      `json:"id"`
+	CreatedAt   time.Time   `json:"created_at"`
+	Source      string      `json:"source"`
+	Attribution Attribution `json:"attribution,omitzero"`
+	// OriginalHash and ProcessedHash are hex-encoded SHA-256 digests of the stored
+	// blobs. They are empty for images created before hashing was introduced and
+	// ProcessedHash is empty when processed images are generated on demand.
+	OriginalHash  string `json:"original_sha256,omitempty"`
+	ProcessedHash string `json:"processed_sha256,omitempty"`
+	// StoredBytes is the combined size of the stored blobs; 0 for images created
+	// before sizes were recorded.
+	StoredBytes int64 `json:"stored_bytes,omitempty"`
+	// Archived images are excluded from the rotation but remain stored.
+	Archived bool `json:"archived,omitempty"`
+	// Pending images await moderation and are not shown until approved.
+	Pending bool `json:"pending,omitempty"`
+	// PerceptualHash is the hex-encoded difference hash of the original used to
+	// find near-duplicates; empty until computed.
+	PerceptualHash string `json:"dhash,omitempty"`
+	// EnhancementPreset is the preset AutoEnhanceCommand chose for this image;
+	// it is reused when the image is processed again.
+	EnhancementPreset string `json:"enhancement_preset,omitempty"`
+	// OriginalEncoding is EncodingZstd when the original is stored compressed
+	// and "" when it is stored as uploaded.
+	OriginalEncoding string `json:"original_encoding,omitempty"`
+	// AltText describes the image for screen readers; empty until entered or
+	// generated by the captioning webhook.
+	AltText string `json:"alt_text,omitempty"`
+	// Title and Description are free text shown in the UI and available to
+	// text overlay widgets.
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Fit overrides the fit of ScaleCommand steps when the image is processed:
+	// "contain", "cover" or "" for the configured one.
+	Fit string `json:"fit,omitempty"`
+	// FlatArt overrides when DitherCommand steps skip error diffusion for the
+	// image: "always", "never" or "" for the configured behavior.
+	FlatArt string `json:"flat_art,omitempty"`
+	// Crop is the region of interest chosen in the UI; the original is cropped
+	// to it before the pipeline runs. Nil processes the whole image.
+	Crop *Region `json:"crop,omitempty"`
+	// Original and Processed describe the stored renditions; they are zero for
+	// images stored before renditions were recorded, and Processed is zero
+	// when processed images are generated on demand.
+	Original  Rendition `json:"original,omitzero"`
+	Processed Rendition `json:"processed,omitzero"`
+}
+
+// Region is a rectangle given as fractions (0-1) of an image's width and
+// height, so it applies to the image at any resolution.
+type Region struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// Rendition describes one stored rendition of an image.
+type Rendition struct {
+	Width  int `json:"width,omitempty"`
+	Height int `json:"height,omitempty"`
+	// Bytes is the size of the PNG as served, before any compression at rest.
+	Bytes int64 `json:"bytes,omitempty"`
+	// UpdatedAt is when the rendition was last written.
+	UpdatedAt time.Time `json:"updated_at,omitzero"`
+}
+
+// NewRendition describes the PNG data written at the given time. The
+// dimensions stay 0 when data is not a PNG; a nil data yields a zero Rendition.
+func NewRendition(data []byte, at time.Time) Rendition {
+	if data == nil {
+		return Rendition{}
+	}
+	r := Rendition{Bytes: int64(len(data)), UpdatedAt: at.UTC()}
+	if cfg, err := png.DecodeConfig(bytes.NewReader(data)); err == nil {
+		r.Width, r.Height = cfg.Width, cfg.Height
+	}
+	return r
+}
+
+// Hash returns the content hash for the given variant ("original" or "processed").
+func (i *Image) Hash(variant string) string {
+	if variant == "processed" {
+		return i.ProcessedHash
+	}
+	return i.OriginalHash
+}
+
+// ContentHash returns the hex-encoded SHA-256 digest used to address blobs.
+// It returns "" for nil data.
+func ContentHash(data []byte) string {
+	if data == nil {
+		return ""
+	}
+	return hashSHA256bytes(data)
+}
+
+// Attribution records where an image came from and under which license it may be shown.
+// All fields are optional; manual uploads typically leave them empty.
+type Attribution struct {
+	SourceURL string `json:"source_url,omitempty"`
+	Author    string `json:"author,omitempty"`
+	License   string `json:"license,omitempty"`
+}
+
+// IsZero reports whether no attribution field is set.
+func (a Attribution) IsZero() bool {
+	return a.SourceURL == "" && a.Author == "" && a.License == ""
+}
+
+// String renders the attribution as a single human-readable line, e.g.
+// "Randall Munroe | CC BY-NC 2.5". The source URL is omitted.
+func (a Attribution) String() string {
+	switch {
+	case a.Author != "" && a.License != "":
+		return a.Author + " | " + a.License
+	case a.Author != "":
+		return a.Author
+	default:
+		return a.License
+	}
+}
+
+// DisplayRecord notes which image was current on a calendar day.
+type DisplayRecord struct {
+	// Day is the date in the frame's timezone, formatted as "2006-01-02".
+	Day     string `json:"day"`
+	ImageID string `json:"image_id"`
 }
@@ -0,0 +1,90 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"testing"
+	"time"
+)
+
+func encodeTestPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestFakeDatabase_RecordsRenditions(t *testing.T) {
+	ctx := context.Background()
+	db := NewFakeDatabase("")
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	original, processed := encodeTestPNG(t, 40, 30), encodeTestPNG(t, 8, 6)
+	id, err := db.CreateImage(ctx, original, processed, createdAt, "", Attribution{}, "", false)
+	if err != nil {
+		t.Fatalf("CreateImage failed: %v", err)
+	}
+
+	img, _ := db.GetImageByID(ctx, id)
+	want := Rendition{Width: 40, Height: 30, Bytes: int64(len(original)), UpdatedAt: createdAt}
+	if img.Original != want {
+		t.Errorf("original = %+v, want %+v", img.Original, want)
+	}
+	if img.Processed.Width != 8 || img.Processed.Height != 6 || img.Processed.Bytes != int64(len(processed)) {
+		t.Errorf("unexpected processed rendition %+v", img.Processed)
+	}
+
+	reprocessed := encodeTestPNG(t, 16, 12)
+	if err := db.PutProcessedImage(ctx, id, reprocessed); err != nil {
+		t.Fatalf("PutProcessedImage failed: %v", err)
+	}
+	img, _ = db.GetImageByID(ctx, id)
+	if img.Processed.Width != 16 || !img.Processed.UpdatedAt.After(createdAt) {
+		t.Errorf("expected the new processed rendition, got %+v", img.Processed)
+	}
+
+	if _, err := db.DeleteProcessedImage(ctx, id); err != nil {
+		t.Fatalf("DeleteProcessedImage failed: %v", err)
+	}
+	if img, _ = db.GetImageByID(ctx, id); img.Processed != (Rendition{}) || img.Original != want {
+		t.Errorf("expected only the processed rendition to be cleared, got %+v / %+v", img.Original, img.Processed)
+	}
+}
+
+func TestNewRendition_NonPNG(t *testing.T) {
+	r := NewRendition([]byte("not a png"), time.Now())
+	if r.Bytes != 9 || r.Width != 0 || r.Height != 0 {
+		t.Errorf("unexpected rendition %+v", r)
+	}
+	if NewRendition(nil, time.Now()) != (Rendition{}) {
+		t.Error("expected a zero rendition for nil data")
+	}
+}
+
+func TestPutProcessedImage_SwapsGeneration(t *testing.T) {
+	ctx := context.Background()
+	db := NewFakeDatabase("")
+	id, err := db.CreateImage(ctx, []byte("original"), []byte("first"), time.Now(), "", Attribution{}, "", false)
+	if err != nil {
+		t.Fatalf("CreateImage failed: %v", err)
+	}
+	if err := db.PutProcessedImage(ctx, id, []byte("second")); err != nil {
+		t.Fatalf("PutProcessedImage failed: %v", err)
+	}
+
+	if data, _ := db.GetImageData(ctx, id, "processed"); string(data) != "second" {
+		t.Errorf("expected the new processed blob, got %q", data)
+	}
+	if url, _ := db.GetCurrentImageURL(ctx, id, "processed"); url != "/images/"+id+"/processed-1.png" {
+		t.Errorf("expected the URL of generation 1, got %s", url)
+	}
+	if _, ok := db.blobs[imageProcessedKey(id, 0)]; ok {
+		t.Error("expected the superseded blob to be deleted")
+	}
+	if n, _ := db.DeleteProcessedImage(ctx, id); n != int64(len("second")) {
+		t.Errorf("expected the current generation to be deleted, got %d bytes", n)
+	}
+}
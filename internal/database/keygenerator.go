@@ -2,9 +2,57 @@ package database
 
 import (
 	"crypto/rand"
+	"encoding/binary"
 	"fmt"
+	"math/big"
+	"time"
 )
 
+// ID formats for new images, set with the database.idFormat option. Existing
+// images keep their IDs, so a library may mix formats.
+const (
+	// IDFormatUUIDv4 generates random UUIDs (default).
+	IDFormatUUIDv4 = "uuidv4"
+	// IDFormatUUIDv7 generates UUIDs that sort by creation time.
+	IDFormatUUIDv7 = "uuidv7"
+	// IDFormatULID generates 26 character ULIDs that sort by creation time.
+	IDFormatULID = "ulid"
+	// IDFormatNanoID generates random 21 character URL-safe IDs.
+	IDFormatNanoID = "nanoid"
+)
+
+// crockfordAlphabet is the base32 alphabet of ULIDs, in ascending ASCII order.
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// nanoIDAlphabet holds the 64 URL-safe characters of nanoids.
+const nanoIDAlphabet = "_-0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// validateIDFormat rejects unknown ID formats; "" selects the default.
+func validateIDFormat(format string) error {
+	switch format {
+	case "", IDFormatUUIDv4, IDFormatUUIDv7, IDFormatULID, IDFormatNanoID:
+		return nil
+	}
+	return fmt.Errorf("unknown ID format %q (must be %s, %s, %s or %s)",
+		format, IDFormatUUIDv4, IDFormatUUIDv7, IDFormatULID, IDFormatNanoID)
+}
+
+// newID generates an image ID in format. Time-ordered formats order IDs by
+// millisecond; IDs created within the same millisecond are in random order.
+func newID(format string, now time.Time) (string, error) {
+	switch format {
+	case "", IDFormatUUIDv4:
+		return generateID()
+	case IDFormatUUIDv7:
+		return generateUUIDv7(now)
+	case IDFormatULID:
+		return generateULID(now)
+	case IDFormatNanoID:
+		return generateNanoID()
+	}
+	return "", validateIDFormat(format)
+}
+
 func generateID() (string, error) {
 	var uuid [16]byte
 	if _, err := rand.Read(uuid[:]); err != nil {
@@ -15,11 +63,66 @@ func generateID() (string, error) {
 	uuid[6] = (uuid[6] & 0x0f) | 0x40 // Version 4
 	uuid[8] = (uuid[8] & 0x3f) | 0x80 // Variant is 10
 
-	// Format as UUID string: xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx
+	return formatUUID(uuid), nil
+}
+
+// generateUUIDv7 returns a UUID whose first 48 bits are the Unix time in
+// milliseconds (RFC 9562), followed by random bits.
+func generateUUIDv7(now time.Time) (string, error) {
+	var uuid [16]byte
+	if _, err := rand.Read(uuid[6:]); err != nil {
+		return "", err
+	}
+	putMillis(uuid[:6], now)
+	uuid[6] = (uuid[6] & 0x0f) | 0x70 // Version 7
+	uuid[8] = (uuid[8] & 0x3f) | 0x80 // Variant is 10
+	return formatUUID(uuid), nil
+}
+
+// generateULID returns a ULID: 48 bits of Unix time in milliseconds and 80
+// random bits, encoded as 26 characters of Crockford base32.
+func generateULID(now time.Time) (string, error) {
+	var ulid [16]byte
+	if _, err := rand.Read(ulid[6:]); err != nil {
+		return "", err
+	}
+	putMillis(ulid[:6], now)
+
+	n := new(big.Int).SetBytes(ulid[:])
+	digit := new(big.Int)
+	out := make([]byte, 26)
+	for i := len(out) - 1; i >= 0; i-- {
+		n.DivMod(n, big.NewInt(32), digit)
+		out[i] = crockfordAlphabet[digit.Int64()]
+	}
+	return string(out), nil
+}
+
+// generateNanoID returns 21 random URL-safe characters.
+func generateNanoID() (string, error) {
+	var b [21]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = nanoIDAlphabet[b[i]&63]
+	}
+	return string(b[:]), nil
+}
+
+// putMillis writes the Unix time of now in milliseconds as 48 bits big-endian.
+func putMillis(dst []byte, now time.Time) {
+	var ms [8]byte
+	binary.BigEndian.PutUint64(ms[:], uint64(now.UnixMilli()))
+	copy(dst, ms[2:])
+}
+
+// formatUUID formats as UUID string: xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx
+func formatUUID(uuid [16]byte) string {
 	return fmt.Sprintf("%x-%x-%x-%x-%x",
 		uuid[0:4],
 		uuid[4:6],
 		uuid[6:8],
 		uuid[8:10],
-		uuid[10:16]), nil
+		uuid[10:16])
 }
@@ -3,6 +3,7 @@ package database
 import (
 	"regexp"
 	"testing"
+	"time"
 )
 
 func Test_generateID_FormatAndUniqueness(t *testing.T) {
@@ -26,3 +27,48 @@ func Test_generateID_FormatAndUniqueness(t *testing.T) {
 		seen[got] = struct{}{}
 	}
 }
+
+func Test_newID_Formats(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		format  string
+		pattern string
+	}{
+		{"", `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`},
+		{IDFormatUUIDv4, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`},
+		{IDFormatUUIDv7, `^018f3406-9e00-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`},
+		{IDFormatULID, `^01HWT0D7G0[0-9A-HJKMNP-TV-Z]{16}$`},
+		{IDFormatNanoID, `^[A-Za-z0-9_-]{21}$`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			got, err := newID(tt.format, now)
+			if err != nil {
+				t.Fatalf("newID() returned error: %v", err)
+			}
+			if !regexp.MustCompile(tt.pattern).MatchString(got) {
+				t.Errorf("newID() = %q, want match for %s", got, tt.pattern)
+			}
+		})
+	}
+
+	if _, err := newID("snowflake", now); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
+
+func Test_newID_TimeOrdered(t *testing.T) {
+	earlier := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for _, format := range []string{IDFormatUUIDv7, IDFormatULID} {
+		for i := 0; i < 64; i++ {
+			a, errA := newID(format, earlier)
+			b, errB := newID(format, earlier.Add(time.Millisecond))
+			if errA != nil || errB != nil {
+				t.Fatalf("newID() returned errors: %v, %v", errA, errB)
+			}
+			if a >= b {
+				t.Fatalf("%s: expected %q < %q", format, a, b)
+			}
+		}
+	}
+}
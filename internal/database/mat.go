@@ -0,0 +1,15 @@
+package database
+
+// Mat is a decorative border composited onto processed images when they are
+// served, so the frame looks like a matted print.
+type Mat struct {
+	// Color is the border color as "#rrggbb".
+	Color string `json:"color"`
+	// Width is the border width in pixels.
+	Width int `json:"width"`
+	// CornerRadius rounds the corners of the opening in pixels; 0 keeps them square.
+	CornerRadius int `json:"corner_radius,omitempty"`
+}
+
+// GlobalMat is the key of the mat applied to devices without their own.
+const GlobalMat = ""
@@ -0,0 +1,21 @@
+package database
+
+import "time"
+
+// Palette is a device's calibrated dither palette: the colors its panel was
+// measured to show, used in place of the configured dither colors when images
+// are rendered for the device.
+type Palette struct {
+	// Colors pairs each configured panel color with its measured appearance.
+	Colors []PaletteColor `json:"colors"`
+	// CalibratedAt is when the palette was derived.
+	CalibratedAt time.Time `json:"calibrated_at"`
+}
+
+// PaletteColor is one entry of a Palette; both colors are "#rrggbb".
+type PaletteColor struct {
+	// Device is the color sent to the panel.
+	Device string `json:"device"`
+	// Dither is the color the panel shows for it, used for dithering.
+	Dither string `json:"dither"`
+}
@@ -4,7 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
+	"maps"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -12,17 +20,176 @@ const rotationStateKey = "rotation.json"
 
 // imageMetadata holds the per-image data stored inside rotation.json.
 type imageMetadata struct {
-	CreatedAt time.Time `json:"created_at"`
-	Source    string    `json:"source"`
+	CreatedAt   time.Time   `json:"created_at"`
+	Source      string      `json:"source"`
+	Attribution Attribution `json:"attribution,omitzero"`
+	// OriginalHash and ProcessedHash are SHA-256 digests of the stored blobs.
+	OriginalHash  string `json:"original_sha256,omitempty"`
+	ProcessedHash string `json:"processed_sha256,omitempty"`
+	// ProcessedGeneration selects the key of the processed blob; see
+	// imageProcessedKey.
+	ProcessedGeneration int `json:"processed_generation,omitempty"`
+	// StoredBytes is the combined size of the stored blobs.
+	StoredBytes int64 `json:"stored_bytes,omitempty"`
+	// Archived images are kept but are not part of ordered_ids.
+	Archived bool `json:"archived,omitempty"`
+	// Pending images await moderation and are not part of ordered_ids.
+	Pending bool `json:"pending,omitempty"`
+	// PerceptualHash is the hex-encoded difference hash of the original.
+	PerceptualHash string `json:"dhash,omitempty"`
+	// EnhancementPreset is the preset chosen by AutoEnhanceCommand.
+	EnhancementPreset string `json:"enhancement_preset,omitempty"`
+	// OriginalEncoding is EncodingZstd when the original is stored compressed.
+	OriginalEncoding string `json:"original_encoding,omitempty"`
+	// AltText describes the image for screen readers.
+	AltText string `json:"alt_text,omitempty"`
+	// Title and Description are entered by users.
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Fit is the per-image fit preference applied to ScaleCommand steps.
+	Fit string `json:"fit,omitempty"`
+	// FlatArt is the per-image flat-art preference applied to DitherCommand steps.
+	FlatArt string `json:"flat_art,omitempty"`
+	// Crop is the per-image region of interest applied before the pipeline.
+	Crop *Region `json:"crop,omitempty"`
+	// Original and Processed describe the stored renditions.
+	Original  Rendition `json:"original,omitzero"`
+	Processed Rendition `json:"processed,omitzero"`
+}
+
+// toImage converts stored metadata into the public Image representation.
+func (m imageMetadata) toImage(id string) *Image {
+	return &Image{
+		ID:                id,
+		CreatedAt:         m.CreatedAt,
+		Source:            m.Source,
+		Attribution:       m.Attribution,
+		OriginalHash:      m.OriginalHash,
+		ProcessedHash:     m.ProcessedHash,
+		StoredBytes:       m.StoredBytes,
+		Archived:          m.Archived,
+		Pending:           m.Pending,
+		PerceptualHash:    m.PerceptualHash,
+		EnhancementPreset: m.EnhancementPreset,
+		OriginalEncoding:  m.OriginalEncoding,
+		AltText:           m.AltText,
+		Title:             m.Title,
+		Description:       m.Description,
+		Fit:               m.Fit,
+		FlatArt:           m.FlatArt,
+		Crop:              m.Crop,
+		Original:          m.Original,
+		Processed:         m.Processed,
+	}
 }
 
 // rotationState is the JSON structure stored as rotation.json in RustFS.
 // It is the single source of truth shared between the server and the operator.
-// The current image is always OrderedIDs[0].
+// The current image is always OrderedIDs[0], mirrored in CurrentID.
 type rotationState struct {
 	LastRotated time.Time                `json:"last_rotated"`
 	OrderedIDs  []string                 `json:"ordered_ids"`
 	Images      map[string]imageMetadata `json:"images"`
+	// CurrentID points at the image selected for display, the head of
+	// OrderedIDs; both are settled and written together (see settleOrder).
+	CurrentID string `json:"current_id,omitempty"`
+	// Groups maps frame group names to their members and shared pointer.
+	Groups map[string]FrameGroup `json:"groups,omitempty"`
+	// Mats maps device IDs to their mat; the "" key is the default for all devices.
+	Mats map[string]Mat `json:"mats,omitempty"`
+	// Palettes maps device IDs to their calibrated dither palette.
+	Palettes map[string]Palette `json:"palettes,omitempty"`
+	// Stories maps story names to the images shown on consecutive days.
+	Stories map[string]Story `json:"stories,omitempty"`
+	// Devices maps device IDs to their registration.
+	Devices map[string]Device `json:"devices,omitempty"`
+	// History records which image was current on each day, oldest first.
+	History []DisplayRecord `json:"history,omitempty"`
+	// Events maps image IDs to their lifecycle log, oldest first.
+	Events map[string][]ImageEvent `json:"events,omitempty"`
+	// Staged maps the IDs of uploads whose blobs are being written to when
+	// they started (see staging.go).
+	Staged map[string]time.Time `json:"staged,omitempty"`
+}
+
+// maxDisplayHistory bounds the number of days kept in rotation.json.
+const maxDisplayHistory = 1000
+
+// recordDisplay sets the image shown on day, replacing an earlier record for
+// the same day. It reports whether the history changed.
+func (rs *rotationState) recordDisplay(day, imageID string) bool {
+	if n := len(rs.History); n > 0 && rs.History[n-1].Day == day {
+		if rs.History[n-1].ImageID == imageID {
+			return false
+		}
+		rs.History[n-1].ImageID = imageID
+		return true
+	}
+	rs.History = append(rs.History, DisplayRecord{Day: day, ImageID: imageID})
+	if len(rs.History) > maxDisplayHistory {
+		rs.History = rs.History[len(rs.History)-maxDisplayHistory:]
+	}
+	return true
+}
+
+// inRotation reports whether the image belongs in ordered_ids.
+func (m imageMetadata) inRotation() bool {
+	return !m.Archived && !m.Pending
+}
+
+// updateImages applies update to the metadata of the given images, then removes
+// images that left the rotation from the display order and appends those that
+// joined it. Nothing is changed when any ID is unknown.
+func (rs *rotationState) updateImages(ids []string, update func(*imageMetadata)) error {
+	for _, id := range ids {
+		if _, ok := rs.Images[id]; !ok {
+			return fmt.Errorf("%w: %s", ErrImageNotFound, id)
+		}
+	}
+	for _, id := range ids {
+		meta := rs.Images[id]
+		wasInRotation := meta.inRotation()
+		update(&meta)
+		rs.Images[id] = meta
+		switch {
+		case wasInRotation && !meta.inRotation():
+			rs.OrderedIDs = removeID(rs.OrderedIDs, id)
+		case !wasInRotation && meta.inRotation():
+			rs.OrderedIDs = append(rs.OrderedIDs, id)
+		}
+	}
+	return nil
+}
+
+// imagesWhere returns the images whose metadata matches, newest first.
+func (rs rotationState) imagesWhere(match func(imageMetadata) bool) []*Image {
+	images := make([]*Image, 0)
+	for id, meta := range rs.Images {
+		if match(meta) {
+			images = append(images, meta.toImage(id))
+		}
+	}
+	sort.Slice(images, func(i, j int) bool {
+		if !images[i].CreatedAt.Equal(images[j].CreatedAt) {
+			return images[i].CreatedAt.After(images[j].CreatedAt)
+		}
+		return images[i].ID < images[j].ID
+	})
+	return images
+}
+
+func isArchived(m imageMetadata) bool { return m.Archived }
+
+func isPending(m imageMetadata) bool { return m.Pending }
+
+// sortedGroups returns the frame groups ordered by name.
+func (rs rotationState) sortedGroups() []FrameGroup {
+	groups := make([]FrameGroup, 0, len(rs.Groups))
+	for _, g := range rs.Groups {
+		groups = append(groups, g)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+	return groups
 }
 
 // RustFSDatabase implements DatabaseService using RustFS (S3-compatible) for
@@ -31,12 +198,21 @@ type rotationState struct {
 type RustFSDatabase struct {
 	*RotationStateClient
 	imageBaseURL string
+	// compressOriginals stores new originals zstd-compressed.
+	compressOriginals bool
+	// idFormat is the format of new image IDs (see IDFormatUUIDv4).
+	idFormat string
 }
 
 // NewRustFSDatabase connects to the RustFS endpoint and ensures the bucket exists.
 // bucket is the S3 bucket name used for image objects.
 // imageBaseURL is the browser-facing URL prefix for image assets (e.g. "/images").
-func NewRustFSDatabase(endpoint, bucket, accessKey, secretKey, region, imageBaseURL string) (DatabaseService, error) {
+// With compressOriginals, new originals are stored zstd-compressed.
+// idFormat selects the format of new image IDs; "" is IDFormatUUIDv4.
+func NewRustFSDatabase(endpoint, bucket, accessKey, secretKey, region, imageBaseURL string, compressOriginals bool, idFormat string) (DatabaseService, error) {
+	if err := validateIDFormat(idFormat); err != nil {
+		return nil, fmt.Errorf("rustfs: %w", err)
+	}
 	if endpoint == "" {
 		return nil, fmt.Errorf("rustfs: endpoint must not be empty")
 	}
@@ -63,6 +239,8 @@ func NewRustFSDatabase(endpoint, bucket, accessKey, secretKey, region, imageBase
 	return &RustFSDatabase{
 		RotationStateClient: &RotationStateClient{s3: s3},
 		imageBaseURL:        imageBaseURL,
+		compressOriginals:   compressOriginals,
+		idFormat:            idFormat,
 	}, nil
 }
 
@@ -72,47 +250,149 @@ func (r *RustFSDatabase) Close() error { return nil }
 // imageOriginalKey returns the S3 object key for the original image blob.
 func imageOriginalKey(id string) string { return "images/" + id + "/original.png" }
 
-// imageProcessedKey returns the S3 object key for the processed image blob.
-func imageProcessedKey(id string) string { return "images/" + id + "/processed.png" }
+// imageCompressedOriginalKey returns the S3 object key for a zstd-compressed
+// original. The suffix keeps the ingress from serving it as a PNG.
+func imageCompressedOriginalKey(id string) string { return imageOriginalKey(id) + ".zst" }
+
+// storedOriginal returns the blob and key to store original under, and its encoding.
+func storedOriginal(id string, original []byte, compress bool) (data []byte, key, encoding string) {
+	if compress {
+		return compressBlob(original), imageCompressedOriginalKey(id), EncodingZstd
+	}
+	return original, imageOriginalKey(id), ""
+}
+
+// imageProcessedKey returns the S3 object key for the given generation of the
+// processed image blob. Uploads write generation 0; every replacement writes
+// the next generation under a new key and then points rotation.json at it, so
+// a blob is never overwritten while devices may be reading it.
+func imageProcessedKey(id string, generation int) string {
+	if generation == 0 {
+		return "images/" + id + "/processed.png"
+	}
+	return "images/" + id + "/processed-" + strconv.Itoa(generation) + ".png"
+}
+
+// isProcessedKey reports whether key names a processed blob of any generation.
+func isProcessedKey(key string) bool {
+	name := path.Base(key)
+	return name == "processed.png" || (strings.HasPrefix(name, "processed-") && strings.HasSuffix(name, ".png"))
+}
 
 // CreateImage uploads blobs to RustFS, then atomically registers the image in
 // rotation.json. When afterID is empty the image is appended; otherwise it is
-// inserted immediately after that image in the ordered list.
-func (r *RustFSDatabase) CreateImage(ctx context.Context, original, processed []byte, createdAt time.Time, source, afterID string) (string, error) {
+// inserted immediately after that image in the ordered list. Pending images are
+// not added to the ordered list. A nil processed blob is skipped so that only
+// the original is stored.
+func (r *RustFSDatabase) CreateImage(ctx context.Context, original, processed []byte, createdAt time.Time, source string, attribution Attribution, afterID string, pending bool) (string, error) {
 	if original == nil {
 		return "", fmt.Errorf("original image data cannot be nil")
 	}
-	if processed == nil {
-		return "", fmt.Errorf("processed image data cannot be nil")
-	}
 
-	id, err := generateID()
+	id, err := newID(r.idFormat, createdAt)
 	if err != nil {
 		return "", err
 	}
 
-	if err := r.s3.PutObject(ctx, imageOriginalKey(id), "image/png", original); err != nil {
+	if err := r.updateStaged(ctx, func(rs *rotationState) { rs.stage(id, time.Now()) }); err != nil {
+		return "", fmt.Errorf("rustfs: staging upload %s: %w", id, err)
+	}
+
+	storedOriginal, originalKey, encoding := storedOriginal(id, original, r.compressOriginals)
+	if err := r.s3.PutObject(ctx, originalKey, originalContentType(encoding), storedOriginal); err != nil {
+		r.abortStagedUpload(ctx, id)
 		return "", fmt.Errorf("rustfs: uploading original for %s: %w", id, err)
 	}
-	if err := r.s3.PutObject(ctx, imageProcessedKey(id), "image/png", processed); err != nil {
-		_ = r.s3.DeleteObject(ctx, imageOriginalKey(id))
-		return "", fmt.Errorf("rustfs: uploading processed for %s: %w", id, err)
+	if processed != nil {
+		if err := r.s3.PutObject(ctx, imageProcessedKey(id, 0), "image/png", processed); err != nil {
+			r.abortStagedUpload(ctx, id)
+			return "", fmt.Errorf("rustfs: uploading processed for %s: %w", id, err)
+		}
 	}
 
+	err = r.updateStaged(ctx, func(rs *rotationState) {
+		rs.unstage(id)
+		if rs.Images == nil {
+			rs.Images = make(map[string]imageMetadata)
+		}
+		rs.Images[id] = imageMetadata{
+			CreatedAt:        createdAt.UTC(),
+			Source:           source,
+			Attribution:      attribution,
+			OriginalHash:     ContentHash(original),
+			ProcessedHash:    ContentHash(processed),
+			StoredBytes:      int64(len(storedOriginal) + len(processed)),
+			Pending:          pending,
+			OriginalEncoding: encoding,
+			Original:         NewRendition(original, createdAt),
+			Processed:        NewRendition(processed, createdAt),
+		}
+		if !pending {
+			rs.OrderedIDs = insertIDAfter(rs.OrderedIDs, id, afterID)
+		}
+	})
+	if err != nil {
+		r.abortStagedUpload(ctx, id)
+		return "", fmt.Errorf("rustfs: updating rotation state after create: %w", err)
+	}
+
+	return id, nil
+}
+
+// updateStaged applies update to rotation.json under r.mu.
+func (r *RustFSDatabase) updateStaged(ctx context.Context, update func(rs *rotationState)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	rs, err := r.getRotationState(ctx)
 	if err != nil {
-		return "", fmt.Errorf("rustfs: reading rotation state for create: %w", err)
+		return err
 	}
-	if rs.Images == nil {
-		rs.Images = make(map[string]imageMetadata)
+	update(&rs)
+	return r.putRotationState(ctx, rs)
+}
+
+// abortStagedUpload deletes the blobs of a failed upload and its marker. It
+// is best effort, also when ctx is cancelled; whatever remains is removed by
+// RecoverStagedUploads on the next start.
+func (r *RustFSDatabase) abortStagedUpload(ctx context.Context, id string) {
+	ctx = context.WithoutCancel(ctx)
+	for _, key := range stagedBlobKeys(id) {
+		if err := r.s3.DeleteObject(ctx, key); err != nil {
+			slog.Warn("rustfs: deleting blob of failed upload", "key", key, "error", err)
+		}
 	}
-	rs.Images[id] = imageMetadata{CreatedAt: createdAt.UTC(), Source: source}
-	rs.OrderedIDs = insertIDAfter(rs.OrderedIDs, id, afterID)
-	if err := r.putRotationState(ctx, rs); err != nil {
-		return "", fmt.Errorf("rustfs: updating rotation state after create: %w", err)
+	if err := r.updateStaged(ctx, func(rs *rotationState) { rs.unstage(id) }); err != nil {
+		slog.Warn("rustfs: clearing staged upload", "id", id, "error", err)
 	}
+}
 
-	return id, nil
+// RecoverStagedUploads deletes the blobs of uploads staged before cutoff that
+// never completed and clears their markers.
+func (r *RustFSDatabase) RecoverStagedUploads(ctx context.Context, cutoff time.Time) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rs, err := r.getRotationState(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("rustfs: reading rotation state for RecoverStagedUploads: %w", err)
+	}
+	ids := rs.staleStaged(cutoff)
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	for _, id := range ids {
+		if _, registered := rs.Images[id]; !registered {
+			for _, key := range stagedBlobKeys(id) {
+				if err := r.s3.DeleteObject(ctx, key); err != nil {
+					return nil, fmt.Errorf("rustfs: deleting blob %s of interrupted upload: %w", key, err)
+				}
+			}
+		}
+		rs.unstage(id)
+	}
+	if err := r.putRotationState(ctx, rs); err != nil {
+		return nil, fmt.Errorf("rustfs: updating rotation state after RecoverStagedUploads: %w", err)
+	}
+	return ids, nil
 }
 
 // GetImageMetadata returns all image metadata in current display order (index 0 = today).
@@ -123,16 +403,210 @@ func (r *RustFSDatabase) GetImageMetadata(ctx context.Context) ([]*Image, error)
 	}
 	images := make([]*Image, 0, len(rs.OrderedIDs))
 	for _, id := range rs.OrderedIDs {
-		meta := rs.Images[id]
-		images = append(images, &Image{
-			ID:        id,
-			CreatedAt: meta.CreatedAt,
-			Source:    meta.Source,
-		})
+		images = append(images, rs.Images[id].toImage(id))
 	}
 	return images, nil
 }
 
+// GetArchivedImages returns the archived images, newest first.
+func (r *RustFSDatabase) GetArchivedImages(ctx context.Context) ([]*Image, error) {
+	rs, err := r.getRotationState(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("rustfs: reading rotation state for archived images: %w", err)
+	}
+	return rs.imagesWhere(isArchived), nil
+}
+
+// SetArchived archives or unarchives the given images in rotation.json.
+func (r *RustFSDatabase) SetArchived(ctx context.Context, ids []string, archived bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rs, err := r.getRotationState(ctx)
+	if err != nil {
+		return fmt.Errorf("rustfs: reading rotation state for SetArchived: %w", err)
+	}
+	if err := rs.updateImages(ids, func(m *imageMetadata) { m.Archived = archived }); err != nil {
+		return err
+	}
+	return r.putRotationState(ctx, rs)
+}
+
+// GetPendingImages returns the images awaiting moderation, newest first.
+func (r *RustFSDatabase) GetPendingImages(ctx context.Context) ([]*Image, error) {
+	rs, err := r.getRotationState(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("rustfs: reading rotation state for pending images: %w", err)
+	}
+	return rs.imagesWhere(isPending), nil
+}
+
+// ApproveImage clears the pending flag in rotation.json, appending the image to the display order.
+func (r *RustFSDatabase) ApproveImage(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rs, err := r.getRotationState(ctx)
+	if err != nil {
+		return fmt.Errorf("rustfs: reading rotation state for ApproveImage: %w", err)
+	}
+	if err := rs.updateImages([]string{id}, func(m *imageMetadata) { m.Pending = false }); err != nil {
+		return err
+	}
+	return r.putRotationState(ctx, rs)
+}
+
+// SetPerceptualHash stores the perceptual hash of an image in rotation.json.
+func (r *RustFSDatabase) SetPerceptualHash(ctx context.Context, id, hash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rs, err := r.getRotationState(ctx)
+	if err != nil {
+		return fmt.Errorf("rustfs: reading rotation state for SetPerceptualHash: %w", err)
+	}
+	if err := rs.updateImages([]string{id}, func(m *imageMetadata) { m.PerceptualHash = hash }); err != nil {
+		return err
+	}
+	return r.putRotationState(ctx, rs)
+}
+
+// SetEnhancementPreset records the enhancement preset of an image in rotation.json.
+func (r *RustFSDatabase) SetEnhancementPreset(ctx context.Context, id, preset string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rs, err := r.getRotationState(ctx)
+	if err != nil {
+		return fmt.Errorf("rustfs: reading rotation state for SetEnhancementPreset: %w", err)
+	}
+	if err := rs.updateImages([]string{id}, func(m *imageMetadata) { m.EnhancementPreset = preset }); err != nil {
+		return err
+	}
+	return r.putRotationState(ctx, rs)
+}
+
+// SetAltText stores the alt text of an image in rotation.json.
+func (r *RustFSDatabase) SetAltText(ctx context.Context, id, text string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rs, err := r.getRotationState(ctx)
+	if err != nil {
+		return fmt.Errorf("rustfs: reading rotation state for SetAltText: %w", err)
+	}
+	if err := rs.updateImages([]string{id}, func(m *imageMetadata) { m.AltText = text }); err != nil {
+		return err
+	}
+	return r.putRotationState(ctx, rs)
+}
+
+// SetImageText stores the title and description of an image in rotation.json.
+func (r *RustFSDatabase) SetImageText(ctx context.Context, id, title, description string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rs, err := r.getRotationState(ctx)
+	if err != nil {
+		return fmt.Errorf("rustfs: reading rotation state for SetImageText: %w", err)
+	}
+	if err := rs.updateImages([]string{id}, func(m *imageMetadata) { m.Title, m.Description = title, description }); err != nil {
+		return err
+	}
+	return r.putRotationState(ctx, rs)
+}
+
+// SetFit stores the fit preference of an image in rotation.json.
+func (r *RustFSDatabase) SetFit(ctx context.Context, id, fit string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rs, err := r.getRotationState(ctx)
+	if err != nil {
+		return fmt.Errorf("rustfs: reading rotation state for SetFit: %w", err)
+	}
+	if err := rs.updateImages([]string{id}, func(m *imageMetadata) { m.Fit = fit }); err != nil {
+		return err
+	}
+	return r.putRotationState(ctx, rs)
+}
+
+// SetFlatArt stores the flat-art preference of an image in rotation.json.
+func (r *RustFSDatabase) SetFlatArt(ctx context.Context, id, flatArt string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rs, err := r.getRotationState(ctx)
+	if err != nil {
+		return fmt.Errorf("rustfs: reading rotation state for SetFlatArt: %w", err)
+	}
+	if err := rs.updateImages([]string{id}, func(m *imageMetadata) { m.FlatArt = flatArt }); err != nil {
+		return err
+	}
+	return r.putRotationState(ctx, rs)
+}
+
+// SetCrop stores the region of interest of an image in rotation.json.
+func (r *RustFSDatabase) SetCrop(ctx context.Context, id string, crop *Region) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rs, err := r.getRotationState(ctx)
+	if err != nil {
+		return fmt.Errorf("rustfs: reading rotation state for SetCrop: %w", err)
+	}
+	if err := rs.updateImages([]string{id}, func(m *imageMetadata) { m.Crop = crop }); err != nil {
+		return err
+	}
+	return r.putRotationState(ctx, rs)
+}
+
+// PutProcessedImage uploads a new processed blob for an image and records its
+// hash in rotation.json. The blob is written as the next generation and
+// swapped in by the rotation.json update, so readers get either the complete
+// previous blob or the complete new one. The previous blob is deleted once the
+// swap is stored. r.mu is held throughout so that concurrent replacements of an
+// image cannot write the same generation.
+func (r *RustFSDatabase) PutProcessedImage(ctx context.Context, id string, processed []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rs, err := r.getRotationState(ctx)
+	if err != nil {
+		return fmt.Errorf("rustfs: reading rotation state for PutProcessedImage: %w", err)
+	}
+	meta, ok := rs.Images[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrImageNotFound, id)
+	}
+	previousKey := imageProcessedKey(id, meta.ProcessedGeneration)
+	previous, _ := r.s3.GetObject(ctx, previousKey)
+	generation := meta.ProcessedGeneration + 1
+	key := imageProcessedKey(id, generation)
+	if err := r.s3.PutObject(ctx, key, "image/png", processed); err != nil {
+		return fmt.Errorf("rustfs: uploading processed for %s: %w", id, err)
+	}
+
+	if err := rs.updateImages([]string{id}, func(m *imageMetadata) {
+		m.ProcessedHash = ContentHash(processed)
+		m.Processed = NewRendition(processed, time.Now())
+		m.ProcessedGeneration = generation
+		if m.StoredBytes > 0 {
+			m.StoredBytes += int64(len(processed) - len(previous))
+		}
+	}); err != nil {
+		_ = r.s3.DeleteObject(ctx, key)
+		return err
+	}
+	if err := r.putRotationState(ctx, rs); err != nil {
+		_ = r.s3.DeleteObject(ctx, key)
+		return err
+	}
+	if err := r.s3.DeleteObject(ctx, previousKey); err != nil {
+		slog.Warn("rustfs: deleting superseded processed blob", "key", previousKey, "error", err)
+	}
+	return nil
+}
+
+// processedKey returns the key of the current processed blob of image id.
+func (r *RustFSDatabase) processedKey(ctx context.Context, id string) (string, error) {
+	rs, err := r.getRotationState(ctx)
+	if err != nil {
+		return "", fmt.Errorf("rustfs: reading rotation state for processed image %s: %w", id, err)
+	}
+	return imageProcessedKey(id, rs.Images[id].ProcessedGeneration), nil
+}
+
 // GetImageByID returns metadata for a single image.
 func (r *RustFSDatabase) GetImageByID(ctx context.Context, id string) (*Image, error) {
 	rs, err := r.getRotationState(ctx)
@@ -141,28 +615,33 @@ func (r *RustFSDatabase) GetImageByID(ctx context.Context, id string) (*Image, e
 	}
 	meta, ok := rs.Images[id]
 	if !ok {
-		return nil, fmt.Errorf("image not found: %s", id)
+		return nil, fmt.Errorf("%w: %s", ErrImageNotFound, id)
 	}
-	return &Image{ID: id, CreatedAt: meta.CreatedAt, Source: meta.Source}, nil
+	return meta.toImage(id), nil
 }
 
 // DeleteImage removes the image from rotation.json and deletes its blobs from RustFS.
 func (r *RustFSDatabase) DeleteImage(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	rs, err := r.getRotationState(ctx)
 	if err != nil {
 		return fmt.Errorf("rustfs: reading rotation state for delete: %w", err)
 	}
-	if _, ok := rs.Images[id]; !ok {
-		return fmt.Errorf("image not found: %s", id)
+	meta, ok := rs.Images[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrImageNotFound, id)
 	}
 	delete(rs.Images, id)
+	delete(rs.Events, id)
 	rs.OrderedIDs = removeID(rs.OrderedIDs, id)
 	if err := r.putRotationState(ctx, rs); err != nil {
 		return fmt.Errorf("rustfs: updating rotation state after delete: %w", err)
 	}
 
 	_ = r.s3.DeleteObject(ctx, imageOriginalKey(id))
-	_ = r.s3.DeleteObject(ctx, imageProcessedKey(id))
+	_ = r.s3.DeleteObject(ctx, imageCompressedOriginalKey(id))
+	_ = r.s3.DeleteObject(ctx, imageProcessedKey(id, meta.ProcessedGeneration))
 	return nil
 }
 
@@ -172,6 +651,8 @@ func (r *RustFSDatabase) UpdateOrder(ctx context.Context, order []string) error
 	if len(order) == 0 {
 		return nil
 	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	rs, err := r.getRotationState(ctx)
 	if err != nil {
 		return fmt.Errorf("rustfs: reading rotation state for UpdateOrder: %w", err)
@@ -189,30 +670,235 @@ func (r *RustFSDatabase) GetRotationOrderedIDs(ctx context.Context) ([]string, e
 	return rs.OrderedIDs, nil
 }
 
-// GetCurrentImageID returns the ID of the image currently selected for display.
-// The current image is always the first entry in ordered_ids.
+// SwapCurrentImage replaces the display order with order if the current image
+// is still expectedID, in a single write of rotation.json. Otherwise it fails
+// with ErrCurrentImageChanged and nothing is written.
+func (r *RustFSDatabase) SwapCurrentImage(ctx context.Context, expectedID string, order []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rs, err := r.getRotationState(ctx)
+	if err != nil {
+		return fmt.Errorf("rustfs: reading rotation state for SwapCurrentImage: %w", err)
+	}
+	if err := rs.swapCurrentImage(expectedID, order); err != nil {
+		return err
+	}
+	return r.putRotationState(ctx, rs)
+}
+
+// GetCurrentImageID returns the ID of the image currently selected for display,
+// the current_id pointer written together with ordered_ids.
 func (r *RustFSDatabase) GetCurrentImageID(ctx context.Context) (string, error) {
 	rs, err := r.getRotationState(ctx)
 	if err != nil {
 		return "", err
 	}
-	if len(rs.OrderedIDs) == 0 {
+	id, ok := rs.currentID()
+	if !ok {
 		return "", fmt.Errorf("no images")
 	}
-	return rs.OrderedIDs[0], nil
+	return id, nil
 }
 
 // GetCurrentImageURL returns the browser-facing URL for the given image ID and
 // variant ("original" or "processed"), routed through the ingress.
-func (r *RustFSDatabase) GetCurrentImageURL(_ context.Context, id, variant string) (string, error) {
+func (r *RustFSDatabase) GetCurrentImageURL(ctx context.Context, id, variant string) (string, error) {
 	switch variant {
 	case "processed":
-		return r.imageBaseURL + "/" + id + "/processed.png", nil
+		key, err := r.processedKey(ctx, id)
+		if err != nil {
+			return "", err
+		}
+		return r.imageBaseURL + "/" + strings.TrimPrefix(key, imagesPrefix), nil
 	default:
 		return r.imageBaseURL + "/" + id + "/original.png", nil
 	}
 }
 
+// GetImageData downloads the blob for the given image ID and variant from
+// RustFS, decompressing compressed originals.
+func (r *RustFSDatabase) GetImageData(ctx context.Context, id, variant string) ([]byte, error) {
+	body, err := r.OpenImageData(ctx, id, variant)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = body.Close() }()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("rustfs: reading %s image %s: %w", variant, id, err)
+	}
+	return data, nil
+}
+
+// OpenImageData streams the blob for the given image ID and variant from
+// RustFS. Compressed originals are decompressed while reading. The object key
+// tells whether an original is compressed, so no metadata lookup is needed: the
+// encoding new uploads use is tried first. Processed blobs are read from the
+// generation rotation.json points at.
+func (r *RustFSDatabase) OpenImageData(ctx context.Context, id, variant string) (io.ReadCloser, error) {
+	keys := []string{imageOriginalKey(id), imageCompressedOriginalKey(id)}
+	if r.compressOriginals {
+		keys[0], keys[1] = keys[1], keys[0]
+	}
+	if variant == "processed" {
+		key, err := r.processedKey(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		keys = []string{key}
+	}
+	for _, key := range keys {
+		body, err := r.s3.GetObjectStream(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("rustfs: reading %s image %s: %w", variant, id, err)
+		}
+		if body == nil {
+			continue
+		}
+		if key == imageCompressedOriginalKey(id) {
+			return decompressingReader(body)
+		}
+		return body, nil
+	}
+	return nil, fmt.Errorf("%w: %s (%s)", ErrImageNotFound, id, variant)
+}
+
+// MigrateOriginalEncoding rewrites the original of image id compressed or
+// uncompressed, whichever new uploads use, and reports whether it changed.
+func (r *RustFSDatabase) MigrateOriginalEncoding(ctx context.Context, id string) (bool, error) {
+	img, err := r.GetImageByID(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	if (img.OriginalEncoding == EncodingZstd) == r.compressOriginals {
+		return false, nil
+	}
+	original, err := r.GetImageData(ctx, id, "original")
+	if err != nil {
+		return false, err
+	}
+	oldKey := imageOriginalKey(id)
+	if img.OriginalEncoding == EncodingZstd {
+		oldKey = imageCompressedOriginalKey(id)
+	}
+	stored, key, encoding := storedOriginal(id, original, r.compressOriginals)
+	if err := r.s3.PutObject(ctx, key, originalContentType(encoding), stored); err != nil {
+		return false, fmt.Errorf("rustfs: uploading migrated original for %s: %w", id, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rs, err := r.getRotationState(ctx)
+	if err != nil {
+		return false, fmt.Errorf("rustfs: reading rotation state for MigrateOriginalEncoding: %w", err)
+	}
+	if err := rs.updateImages([]string{id}, func(m *imageMetadata) {
+		if m.StoredBytes > 0 {
+			m.StoredBytes += int64(len(stored)) - originalStoredSize(original, m.OriginalEncoding)
+		}
+		m.OriginalEncoding = encoding
+		if m.Original == (Rendition{}) {
+			m.Original = NewRendition(original, m.CreatedAt)
+		}
+	}); err != nil {
+		_ = r.s3.DeleteObject(ctx, key)
+		return false, err
+	}
+	if err := r.putRotationState(ctx, rs); err != nil {
+		_ = r.s3.DeleteObject(ctx, key)
+		return false, fmt.Errorf("rustfs: updating rotation state after MigrateOriginalEncoding: %w", err)
+	}
+	_ = r.s3.DeleteObject(ctx, oldKey)
+	return true, nil
+}
+
+// FindOrphanedBlobs lists the objects under images/ that rotation.json does not
+// reference. Objects are listed before the state is read, so images created in
+// between are not reported.
+func (r *RustFSDatabase) FindOrphanedBlobs(ctx context.Context) ([]OrphanedBlob, error) {
+	objects, err := r.s3.ListObjects(ctx, imagesPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("rustfs: listing image blobs: %w", err)
+	}
+	rs, err := r.getRotationState(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("rustfs: reading rotation state for FindOrphanedBlobs: %w", err)
+	}
+	var orphans []OrphanedBlob
+	for _, obj := range objects {
+		if reason := rs.orphanReason(obj.Key); reason != "" {
+			orphans = append(orphans, OrphanedBlob{Key: obj.Key, Size: obj.Size, LastModified: obj.LastModified, Reason: reason})
+		}
+	}
+	return orphans, nil
+}
+
+// DeleteOrphanedBlobs re-reads rotation.json and deletes the keys that are
+// still unreferenced.
+func (r *RustFSDatabase) DeleteOrphanedBlobs(ctx context.Context, keys []string) ([]string, error) {
+	rs, err := r.getRotationState(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("rustfs: reading rotation state for DeleteOrphanedBlobs: %w", err)
+	}
+	var deleted []string
+	for _, key := range keys {
+		if rs.orphanReason(key) == "" {
+			continue
+		}
+		if err := r.s3.DeleteObject(ctx, key); err != nil {
+			return deleted, fmt.Errorf("rustfs: deleting orphaned blob %s: %w", key, err)
+		}
+		deleted = append(deleted, key)
+	}
+	return deleted, nil
+}
+
+// GetStorageUsage lists all image blobs and sums their sizes per category.
+func (r *RustFSDatabase) GetStorageUsage(ctx context.Context) (StorageUsage, error) {
+	objects, err := r.s3.ListObjects(ctx, imagesPrefix)
+	if err != nil {
+		return StorageUsage{}, fmt.Errorf("rustfs: listing image blobs: %w", err)
+	}
+	rs, err := r.getRotationState(ctx)
+	if err != nil {
+		return StorageUsage{}, fmt.Errorf("rustfs: reading rotation state for GetStorageUsage: %w", err)
+	}
+	var usage StorageUsage
+	for _, obj := range objects {
+		rs.addBlob(&usage, obj.Key, obj.Size)
+	}
+	return usage, nil
+}
+
+// DeleteProcessedImage deletes the processed blob of an image and clears its
+// hash in rotation.json.
+func (r *RustFSDatabase) DeleteProcessedImage(ctx context.Context, id string) (int64, error) {
+	previous, _ := r.GetImageData(ctx, id, "processed")
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rs, err := r.getRotationState(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("rustfs: reading rotation state for DeleteProcessedImage: %w", err)
+	}
+	key := imageProcessedKey(id, rs.Images[id].ProcessedGeneration)
+	if err := rs.updateImages([]string{id}, func(m *imageMetadata) {
+		m.ProcessedHash = ""
+		m.Processed = Rendition{}
+		if m.StoredBytes > 0 {
+			m.StoredBytes -= int64(len(previous))
+		}
+	}); err != nil {
+		return 0, err
+	}
+	if err := r.putRotationState(ctx, rs); err != nil {
+		return 0, err
+	}
+	if err := r.s3.DeleteObject(ctx, key); err != nil {
+		return 0, fmt.Errorf("rustfs: deleting processed for %s: %w", id, err)
+	}
+	return int64(len(previous)), nil
+}
+
 // GetLastRotatedTime reads the last-rotated timestamp from rotation.json.
 // Returns an error when the timestamp is not yet set (first reconcile).
 func (r *RustFSDatabase) GetLastRotatedTime(ctx context.Context) (time.Time, error) {
@@ -226,6 +912,213 @@ func (r *RustFSDatabase) GetLastRotatedTime(ctx context.Context) (time.Time, err
 	return rs.LastRotated, nil
 }
 
+// GetFrameGroups returns all frame groups from rotation.json sorted by name.
+func (r *RustFSDatabase) GetFrameGroups(ctx context.Context) ([]FrameGroup, error) {
+	rs, err := r.getRotationState(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("rustfs: reading rotation state for frame groups: %w", err)
+	}
+	return rs.sortedGroups(), nil
+}
+
+// PutFrameGroup creates or replaces a frame group in rotation.json.
+func (r *RustFSDatabase) PutFrameGroup(ctx context.Context, group FrameGroup) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rs, err := r.getRotationState(ctx)
+	if err != nil {
+		return fmt.Errorf("rustfs: reading rotation state for PutFrameGroup: %w", err)
+	}
+	if rs.Groups == nil {
+		rs.Groups = make(map[string]FrameGroup)
+	}
+	rs.Groups[group.Name] = group
+	return r.putRotationState(ctx, rs)
+}
+
+// DeleteFrameGroup removes a frame group from rotation.json.
+func (r *RustFSDatabase) DeleteFrameGroup(ctx context.Context, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rs, err := r.getRotationState(ctx)
+	if err != nil {
+		return fmt.Errorf("rustfs: reading rotation state for DeleteFrameGroup: %w", err)
+	}
+	if _, ok := rs.Groups[name]; !ok {
+		return nil
+	}
+	delete(rs.Groups, name)
+	return r.putRotationState(ctx, rs)
+}
+
+// GetMats returns the mats stored in rotation.json keyed by device ID.
+func (r *RustFSDatabase) GetMats(ctx context.Context) (map[string]Mat, error) {
+	rs, err := r.getRotationState(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("rustfs: reading rotation state for mats: %w", err)
+	}
+	return maps.Clone(rs.Mats), nil
+}
+
+// PutMat creates or replaces the mat for deviceID in rotation.json.
+func (r *RustFSDatabase) PutMat(ctx context.Context, deviceID string, mat Mat) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rs, err := r.getRotationState(ctx)
+	if err != nil {
+		return fmt.Errorf("rustfs: reading rotation state for PutMat: %w", err)
+	}
+	if rs.Mats == nil {
+		rs.Mats = make(map[string]Mat)
+	}
+	rs.Mats[deviceID] = mat
+	return r.putRotationState(ctx, rs)
+}
+
+// DeleteMat removes the mat for deviceID from rotation.json.
+func (r *RustFSDatabase) DeleteMat(ctx context.Context, deviceID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rs, err := r.getRotationState(ctx)
+	if err != nil {
+		return fmt.Errorf("rustfs: reading rotation state for DeleteMat: %w", err)
+	}
+	if _, ok := rs.Mats[deviceID]; !ok {
+		return nil
+	}
+	delete(rs.Mats, deviceID)
+	return r.putRotationState(ctx, rs)
+}
+
+// GetPalettes returns the calibrated palettes stored in rotation.json keyed by device ID.
+func (r *RustFSDatabase) GetPalettes(ctx context.Context) (map[string]Palette, error) {
+	rs, err := r.getRotationState(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("rustfs: reading rotation state for palettes: %w", err)
+	}
+	return maps.Clone(rs.Palettes), nil
+}
+
+// PutPalette creates or replaces the calibrated palette for deviceID in rotation.json.
+func (r *RustFSDatabase) PutPalette(ctx context.Context, deviceID string, palette Palette) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rs, err := r.getRotationState(ctx)
+	if err != nil {
+		return fmt.Errorf("rustfs: reading rotation state for PutPalette: %w", err)
+	}
+	if rs.Palettes == nil {
+		rs.Palettes = make(map[string]Palette)
+	}
+	rs.Palettes[deviceID] = palette
+	return r.putRotationState(ctx, rs)
+}
+
+// DeletePalette removes the calibrated palette for deviceID from rotation.json.
+func (r *RustFSDatabase) DeletePalette(ctx context.Context, deviceID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rs, err := r.getRotationState(ctx)
+	if err != nil {
+		return fmt.Errorf("rustfs: reading rotation state for DeletePalette: %w", err)
+	}
+	if _, ok := rs.Palettes[deviceID]; !ok {
+		return nil
+	}
+	delete(rs.Palettes, deviceID)
+	return r.putRotationState(ctx, rs)
+}
+
+// GetStories returns all stories from rotation.json sorted by name.
+func (r *RustFSDatabase) GetStories(ctx context.Context) ([]Story, error) {
+	rs, err := r.getRotationState(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("rustfs: reading rotation state for stories: %w", err)
+	}
+	return rs.sortedStories(), nil
+}
+
+// PutStory creates or replaces a story in rotation.json; writing the state
+// groups its images.
+func (r *RustFSDatabase) PutStory(ctx context.Context, story Story) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rs, err := r.getRotationState(ctx)
+	if err != nil {
+		return fmt.Errorf("rustfs: reading rotation state for PutStory: %w", err)
+	}
+	if rs.Stories == nil {
+		rs.Stories = make(map[string]Story)
+	}
+	rs.Stories[story.Name] = story
+	return r.putRotationState(ctx, rs)
+}
+
+// DeleteStory removes a story from rotation.json.
+func (r *RustFSDatabase) DeleteStory(ctx context.Context, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rs, err := r.getRotationState(ctx)
+	if err != nil {
+		return fmt.Errorf("rustfs: reading rotation state for DeleteStory: %w", err)
+	}
+	if _, ok := rs.Stories[name]; !ok {
+		return nil
+	}
+	delete(rs.Stories, name)
+	return r.putRotationState(ctx, rs)
+}
+
+// GetDevices returns the registered devices from rotation.json sorted by ID.
+func (r *RustFSDatabase) GetDevices(ctx context.Context) ([]Device, error) {
+	rs, err := r.getRotationState(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("rustfs: reading rotation state for devices: %w", err)
+	}
+	return rs.sortedDevices(), nil
+}
+
+// PutDevice creates or replaces a device registration in rotation.json.
+func (r *RustFSDatabase) PutDevice(ctx context.Context, device Device) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rs, err := r.getRotationState(ctx)
+	if err != nil {
+		return fmt.Errorf("rustfs: reading rotation state for PutDevice: %w", err)
+	}
+	if rs.Devices == nil {
+		rs.Devices = make(map[string]Device)
+	}
+	rs.Devices[device.ID] = device
+	return r.putRotationState(ctx, rs)
+}
+
+// AddImageEvent appends event to the log of image id in rotation.json.
+func (r *RustFSDatabase) AddImageEvent(ctx context.Context, id string, event ImageEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rs, err := r.getRotationState(ctx)
+	if err != nil {
+		return fmt.Errorf("rustfs: reading rotation state for AddImageEvent: %w", err)
+	}
+	if err := rs.addImageEvent(id, event); err != nil {
+		return err
+	}
+	return r.putRotationState(ctx, rs)
+}
+
+// GetImageEvents returns the log of image id from rotation.json, oldest first.
+func (r *RustFSDatabase) GetImageEvents(ctx context.Context, id string) ([]ImageEvent, error) {
+	rs, err := r.getRotationState(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("rustfs: reading rotation state for GetImageEvents: %w", err)
+	}
+	if _, ok := rs.Images[id]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrImageNotFound, id)
+	}
+	return slices.Clone(rs.Events[id]), nil
+}
+
 // insertIDAfter inserts newID immediately after afterID in ids.
 // If afterID is empty or not found, newID is appended.
 func insertIDAfter(ids []string, newID, afterID string) []string {
@@ -260,6 +1153,9 @@ func removeID(ids []string, id string) []string {
 // the operator (which cannot access the server's storage directly).
 type RotationStateClient struct {
 	s3 *s3Client
+	// mu serializes read-modify-write cycles of rotation.json, so concurrent
+	// changes of this process cannot overwrite each other.
+	mu sync.Mutex
 }
 
 // NewRotationStateClient creates a client that reads and writes rotation.json
@@ -289,7 +1185,10 @@ func (c *RotationStateClient) getRotationState(ctx context.Context) (rotationSta
 	return rs, nil
 }
 
+// putRotationState writes rs after settling its order; the caller holds c.mu
+// since reading the state it modified.
 func (c *RotationStateClient) putRotationState(ctx context.Context, rs rotationState) error {
+	rs.settleOrder()
 	data, err := json.Marshal(rs)
 	if err != nil {
 		return fmt.Errorf("s3: marshalling rotation state: %w", err)
@@ -319,9 +1218,35 @@ func (c *RotationStateClient) GetLastRotatedTime(ctx context.Context) (time.Time
 	return rs.LastRotated, nil
 }
 
+// GetDisplayHistory returns the recorded display history, oldest first.
+func (c *RotationStateClient) GetDisplayHistory(ctx context.Context) ([]DisplayRecord, error) {
+	rs, err := c.getRotationState(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return rs.History, nil
+}
+
+// RecordDisplay records imageID as the image shown on day ("2006-01-02" in the
+// frame's timezone). rotation.json is only written when the history changes.
+func (c *RotationStateClient) RecordDisplay(ctx context.Context, day, imageID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	rs, err := c.getRotationState(ctx)
+	if err != nil {
+		return err
+	}
+	if !rs.recordDisplay(day, imageID) {
+		return nil
+	}
+	return c.putRotationState(ctx, rs)
+}
+
 // SetRotationKeys writes last_rotated and the ordered ID list to rotation.json.
 // The current image is always ordered_ids[0].
 func (c *RotationStateClient) SetRotationKeys(ctx context.Context, rotatedAt time.Time, orderedIDs []string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	rs, err := c.getRotationState(ctx)
 	if err != nil {
 		return err
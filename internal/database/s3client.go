@@ -6,6 +6,7 @@ import (
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/xml"
 	"fmt"
 	"io"
 	"net/http"
@@ -156,6 +157,21 @@ func (c *s3Client) SetPublicReadPolicy(ctx context.Context, prefix string) error
 // GetObject downloads the object at key and returns its body bytes.
 // Returns (nil, nil) when the object does not exist (404).
 func (c *s3Client) GetObject(ctx context.Context, key string) ([]byte, error) {
+	body, err := c.GetObjectStream(ctx, key)
+	if err != nil || body == nil {
+		return nil, err
+	}
+	defer func() { _ = body.Close() }()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("s3: reading body for %q: %w", key, err)
+	}
+	return data, nil
+}
+
+// GetObjectStream opens the object at key for reading; the caller must close it.
+// Returns (nil, nil) when the object does not exist (404).
+func (c *s3Client) GetObjectStream(ctx context.Context, key string) (io.ReadCloser, error) {
 	rawURL := c.objectURL(key)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
 	if err != nil {
@@ -167,19 +183,73 @@ func (c *s3Client) GetObject(ctx context.Context, key string) ([]byte, error) {
 	if err != nil {
 		return nil, fmt.Errorf("s3: GET %q: %w", key, err)
 	}
-	defer func() { _ = resp.Body.Close() }()
 	if resp.StatusCode == http.StatusNotFound {
+		_ = resp.Body.Close()
 		return nil, nil
 	}
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
 		return nil, fmt.Errorf("s3: GET %q: unexpected status %d: %s", key, resp.StatusCode, string(body))
 	}
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("s3: reading body for %q: %w", key, err)
+	return resp.Body, nil
+}
+
+// s3Object describes an object returned by ListObjects.
+type s3Object struct {
+	Key          string    `xml:"Key"`
+	Size         int64     `xml:"Size"`
+	LastModified time.Time `xml:"LastModified"`
+}
+
+type listObjectsResult struct {
+	Contents              []s3Object `xml:"Contents"`
+	NextContinuationToken string     `xml:"NextContinuationToken"`
+	IsTruncated           bool       `xml:"IsTruncated"`
+}
+
+// ListObjects returns all objects whose key starts with prefix, following
+// ListObjectsV2 continuation tokens.
+func (c *s3Client) ListObjects(ctx context.Context, prefix string) ([]s3Object, error) {
+	var objects []s3Object
+	token := ""
+	for {
+		params := url.Values{}
+		params.Set("list-type", "2")
+		params.Set("prefix", prefix)
+		if token != "" {
+			params.Set("continuation-token", token)
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.endpoint+"/"+c.bucket, nil)
+		if err != nil {
+			return nil, fmt.Errorf("s3: building list request for %q: %w", prefix, err)
+		}
+		// The canonical query string must encode spaces as %20.
+		req.URL.RawQuery = strings.ReplaceAll(params.Encode(), "+", "%20")
+		c.signRequest(req)
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("s3: listing %q: %w", prefix, err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("s3: reading list response for %q: %w", prefix, err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("s3: listing %q: unexpected status %d: %s", prefix, resp.StatusCode, string(body))
+		}
+		var result listObjectsResult
+		if err := xml.Unmarshal(body, &result); err != nil {
+			return nil, fmt.Errorf("s3: parsing list response for %q: %w", prefix, err)
+		}
+		objects = append(objects, result.Contents...)
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = result.NextContinuationToken
 	}
-	return data, nil
 }
 
 // signRequest signs a request with an empty body using AWS SigV4.
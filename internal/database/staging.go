@@ -0,0 +1,44 @@
+package database
+
+import (
+	"maps"
+	"slices"
+	"time"
+)
+
+// Uploads are staged: CreateImage records the new ID in rotation.json before
+// writing any blob and registers the image in the same write that clears the
+// marker. A process that dies in between leaves a marker behind instead of
+// untracked blobs, and RecoverStagedUploads removes both on the next start.
+
+// stage marks id as an upload in progress since at.
+func (rs *rotationState) stage(id string, at time.Time) {
+	if rs.Staged == nil {
+		rs.Staged = make(map[string]time.Time)
+	}
+	rs.Staged[id] = at.UTC()
+}
+
+// unstage clears the marker of id.
+func (rs *rotationState) unstage(id string) {
+	delete(rs.Staged, id)
+	if len(rs.Staged) == 0 {
+		rs.Staged = nil
+	}
+}
+
+// staleStaged returns the IDs, sorted, of the uploads staged before cutoff.
+func (rs *rotationState) staleStaged(cutoff time.Time) []string {
+	var ids []string
+	for _, id := range slices.Sorted(maps.Keys(rs.Staged)) {
+		if rs.Staged[id].Before(cutoff) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// stagedBlobKeys returns every key an upload of id may have written.
+func stagedBlobKeys(id string) []string {
+	return []string{imageOriginalKey(id), imageCompressedOriginalKey(id), imageProcessedKey(id, 0)}
+}
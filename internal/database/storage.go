@@ -0,0 +1,43 @@
+package database
+
+import "strings"
+
+// BlobUsage is the number and total size of stored blobs in one category.
+type BlobUsage struct {
+	Count int   `json:"count"`
+	Bytes int64 `json:"bytes"`
+}
+
+func (u *BlobUsage) add(size int64) {
+	u.Count++
+	u.Bytes += size
+}
+
+// StorageUsage breaks the stored image blobs down by category.
+type StorageUsage struct {
+	// Originals are the stored originals of all images, pending and archived included.
+	Originals BlobUsage `json:"originals"`
+	// Processed are the stored processed images.
+	Processed BlobUsage `json:"processed"`
+	// Orphaned are blobs no image references; see OrphanedBlob.
+	Orphaned BlobUsage `json:"orphaned"`
+}
+
+// TotalBytes returns the size of all stored image blobs.
+func (u StorageUsage) TotalBytes() int64 {
+	return u.Originals.Bytes + u.Processed.Bytes + u.Orphaned.Bytes
+}
+
+// addBlob accounts the blob key of the given size to its category in usage.
+// Keys outside the images prefix are ignored.
+func (rs *rotationState) addBlob(usage *StorageUsage, key string, size int64) {
+	switch {
+	case !strings.HasPrefix(key, imagesPrefix):
+	case rs.orphanReason(key) != "":
+		usage.Orphaned.add(size)
+	case isProcessedKey(key):
+		usage.Processed.add(size)
+	default:
+		usage.Originals.add(size)
+	}
+}
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"slices"
+	"sort"
+)
+
+// Story is an ordered set of images shown on consecutive days, e.g. the photos
+// of a vacation. Its images are kept next to each other in ordered_ids in
+// story order (see groupStories), so the daily rotation plays them one per day
+// before returning to the other images.
+type Story struct {
+	Name     string   `json:"name"`
+	ImageIDs []string `json:"image_ids"`
+}
+
+// sortedStories returns the stories ordered by name.
+func (rs rotationState) sortedStories() []Story {
+	stories := make([]Story, 0, len(rs.Stories))
+	for _, s := range rs.Stories {
+		s.ImageIDs = slices.Clone(s.ImageIDs)
+		stories = append(stories, s)
+	}
+	sort.Slice(stories, func(i, j int) bool { return stories[i].Name < stories[j].Name })
+	return stories
+}
+
+// groupStories drops deleted images from the stories and moves the images of
+// each story that are in the rotation next to each other in story order, at
+// the position of the earliest of them. A story that is already in one piece
+// is left alone, also when it wraps around the end of ordered_ids, so the
+// daily rotation, which moves the head to the end, never replays it.
+func (rs *rotationState) groupStories() {
+	for _, story := range rs.sortedStories() {
+		kept := slices.DeleteFunc(story.ImageIDs, func(id string) bool {
+			_, ok := rs.Images[id]
+			return !ok
+		})
+		if len(kept) != len(rs.Stories[story.Name].ImageIDs) {
+			rs.Stories[story.Name] = Story{Name: story.Name, ImageIDs: kept}
+		}
+
+		pos := make(map[string]int, len(rs.OrderedIDs))
+		for i, id := range rs.OrderedIDs {
+			pos[id] = i
+		}
+		var members []string
+		for _, id := range kept {
+			if _, ok := pos[id]; ok {
+				members = append(members, id)
+			}
+		}
+		if storyInOnePiece(members, pos, len(rs.OrderedIDs)) {
+			continue
+		}
+
+		anchor := len(rs.OrderedIDs)
+		isMember := make(map[string]bool, len(members))
+		for _, id := range members {
+			anchor = min(anchor, pos[id])
+			isMember[id] = true
+		}
+		order := make([]string, 0, len(rs.OrderedIDs))
+		for i, id := range rs.OrderedIDs {
+			if i == anchor {
+				order = append(order, members...)
+			}
+			if !isMember[id] {
+				order = append(order, id)
+			}
+		}
+		rs.OrderedIDs = order
+	}
+}
+
+// storyInOnePiece reports whether members follow each other in an order of n
+// images at the positions pos, wrapping around its end.
+func storyInOnePiece(members []string, pos map[string]int, n int) bool {
+	for i := 1; i < len(members); i++ {
+		if pos[members[i]] != (pos[members[i-1]]+1)%n {
+			return false
+		}
+	}
+	return true
+}
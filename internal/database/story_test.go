@@ -0,0 +1,40 @@
+package database
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRotationState_GroupStories(t *testing.T) {
+	rs := rotationState{
+		Images: map[string]imageMetadata{
+			"a": {}, "b": {}, "c": {}, "d": {}, "e": {}, "archived": {Archived: true},
+		},
+		OrderedIDs: []string{"a", "d", "b", "e", "c"},
+		Stories: map[string]Story{
+			"trip": {Name: "trip", ImageIDs: []string{"c", "archived", "deleted", "d"}},
+		},
+	}
+	rs.settleOrder()
+	// The story is moved to the position of its earliest image, d; the
+	// archived image is skipped but kept, the deleted one is dropped.
+	if want := []string{"a", "c", "d", "b", "e"}; !slices.Equal(rs.OrderedIDs, want) {
+		t.Errorf("expected order %v, got %v", want, rs.OrderedIDs)
+	}
+	if want := []string{"c", "archived", "d"}; !slices.Equal(rs.Stories["trip"].ImageIDs, want) {
+		t.Errorf("expected story images %v, got %v", want, rs.Stories["trip"].ImageIDs)
+	}
+}
+
+func TestRotationState_GroupStoriesKeepsWrappedStory(t *testing.T) {
+	// The daily rotation moved the first image of the story to the end.
+	rs := rotationState{
+		Images:     map[string]imageMetadata{"a": {}, "b": {}, "s1": {}, "s2": {}, "s3": {}},
+		OrderedIDs: []string{"s2", "s3", "a", "b", "s1"},
+		Stories:    map[string]Story{"trip": {Name: "trip", ImageIDs: []string{"s1", "s2", "s3"}}},
+	}
+	rs.settleOrder()
+	if want := []string{"s2", "s3", "a", "b", "s1"}; !slices.Equal(rs.OrderedIDs, want) {
+		t.Errorf("expected the story to continue with s2, got %v", rs.OrderedIDs)
+	}
+}
@@ -0,0 +1,103 @@
+// Package displayformat formats dates and times for people according to the
+// configured locale, clock and first day of the week, so the frontend and the
+// human-readable fields of the API agree on one format.
+package displayformat
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/config"
+)
+
+// locale holds the conventions of one config.DisplayLocales entry.
+type locale struct {
+	// dateLayout is a time layout for the calendar day.
+	dateLayout string
+	hour12     bool
+	firstDay   time.Weekday
+	// weekdays are the abbreviated day names, Sunday first.
+	weekdays [7]string
+}
+
+var englishWeekdays = [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"}
+
+var locales = map[string]locale{
+	config.DisplayLocaleISO: {dateLayout: "2006-01-02", firstDay: time.Monday, weekdays: englishWeekdays},
+	"en-US":                 {dateLayout: "01/02/2006", hour12: true, firstDay: time.Sunday, weekdays: englishWeekdays},
+	"en-GB":                 {dateLayout: "02/01/2006", firstDay: time.Monday, weekdays: englishWeekdays},
+	"de-DE":                 {dateLayout: "02.01.2006", firstDay: time.Monday, weekdays: [7]string{"So", "Mo", "Di", "Mi", "Do", "Fr", "Sa"}},
+	"fr-FR":                 {dateLayout: "02/01/2006", firstDay: time.Monday, weekdays: [7]string{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."}},
+	"nl-NL":                 {dateLayout: "02-01-2006", firstDay: time.Monday, weekdays: [7]string{"zo", "ma", "di", "wo", "do", "vr", "za"}},
+}
+
+// Formatter formats times for display. The zero value is not usable; use New.
+type Formatter struct {
+	locale   locale
+	hour12   bool
+	firstDay time.Weekday
+}
+
+// New returns a Formatter for cfg, which LoadServerConfig has validated. An
+// empty or unknown locale formats like ISO 8601; an empty clock or first day
+// takes the locale's convention.
+func New(cfg config.Display) *Formatter {
+	l, ok := locales[cfg.Locale]
+	if !ok {
+		l = locales[config.DisplayLocaleISO]
+	}
+	f := &Formatter{locale: l, hour12: l.hour12, firstDay: l.firstDay}
+	switch cfg.Clock {
+	case config.DisplayClock12h:
+		f.hour12 = true
+	case config.DisplayClock24h:
+		f.hour12 = false
+	}
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(cfg.FirstDayOfWeek, d.String()) {
+			f.firstDay = d
+		}
+	}
+	return f
+}
+
+// Date formats the calendar day of t, e.g. "2026-03-30" or "30.03.2026".
+func (f *Formatter) Date(t time.Time) string {
+	return t.Format(f.locale.dateLayout)
+}
+
+// Time formats the time of day of t, e.g. "15:04" or "3:04 PM".
+func (f *Formatter) Time(t time.Time) string {
+	if f.hour12 {
+		return t.Format("3:04 PM")
+	}
+	return t.Format("15:04")
+}
+
+// DateTime formats the day and time of t.
+func (f *Formatter) DateTime(t time.Time) string {
+	return f.Date(t) + " " + f.Time(t)
+}
+
+// Weekday returns the abbreviated name of d, e.g. "Mon" or "Mo".
+func (f *Formatter) Weekday(d time.Weekday) string {
+	return f.locale.weekdays[d]
+}
+
+// Day formats the calendar day of t with its weekday, e.g. "Mon 2026-03-30".
+func (f *Formatter) Day(t time.Time) string {
+	return f.Weekday(t.Weekday()) + " " + f.Date(t)
+}
+
+// FirstDayOfWeek returns the day weeks start on.
+func (f *Formatter) FirstDayOfWeek() time.Weekday {
+	return f.firstDay
+}
+
+// WeekStart returns the midnight in t's location that starts t's week. Days
+// are counted with time.Date, so the result stays at midnight across DST
+// changes.
+func (f *Formatter) WeekStart(t time.Time) time.Time {
+	back := (int(t.Weekday()) - int(f.firstDay) + 7) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-back, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,63 @@
+package displayformat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/config"
+)
+
+func TestNew_KnowsEveryConfigLocale(t *testing.T) {
+	for _, name := range config.DisplayLocales {
+		if _, ok := locales[name]; !ok {
+			t.Errorf("locale %q accepted by the config has no format", name)
+		}
+	}
+}
+
+func TestFormatter_Locales(t *testing.T) {
+	at := time.Date(2026, 3, 30, 15, 4, 0, 0, time.UTC) // a Monday
+	tests := []struct {
+		cfg      config.Display
+		dateTime string
+		day      string
+		firstDay time.Weekday
+	}{
+		{config.Display{}, "2026-03-30 15:04", "Mon 2026-03-30", time.Monday},
+		{config.Display{Locale: "en-US"}, "03/30/2026 3:04 PM", "Mon 03/30/2026", time.Sunday},
+		{config.Display{Locale: "de-DE"}, "30.03.2026 15:04", "Mo 30.03.2026", time.Monday},
+		{config.Display{Locale: "en-US", Clock: config.DisplayClock24h, FirstDayOfWeek: "monday"}, "03/30/2026 15:04", "Mon 03/30/2026", time.Monday},
+		{config.Display{Locale: "nl-NL", Clock: config.DisplayClock12h}, "30-03-2026 3:04 PM", "ma 30-03-2026", time.Monday},
+	}
+	for _, tt := range tests {
+		f := New(tt.cfg)
+		if got := f.DateTime(at); got != tt.dateTime {
+			t.Errorf("%+v: expected %q, got %q", tt.cfg, tt.dateTime, got)
+		}
+		if got := f.Day(at); got != tt.day {
+			t.Errorf("%+v: expected day %q, got %q", tt.cfg, tt.day, got)
+		}
+		if got := f.FirstDayOfWeek(); got != tt.firstDay {
+			t.Errorf("%+v: expected weeks to start on %s, got %s", tt.cfg, tt.firstDay, got)
+		}
+	}
+}
+
+func TestFormatter_WeekStart(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	// Wednesday after the switch to summer time on Sunday 2026-03-29.
+	wed := time.Date(2026, 4, 1, 10, 0, 0, 0, berlin)
+	if got, want := New(config.Display{}).WeekStart(wed), time.Date(2026, 3, 30, 0, 0, 0, 0, berlin); !got.Equal(want) {
+		t.Errorf("expected the week to start on Monday %v, got %v", want, got)
+	}
+	if got, want := New(config.Display{FirstDayOfWeek: "sunday"}).WeekStart(wed), time.Date(2026, 3, 29, 0, 0, 0, 0, berlin); !got.Equal(want) {
+		t.Errorf("expected the week to start on Sunday %v, got %v", want, got)
+	}
+	sunday := time.Date(2026, 3, 29, 12, 0, 0, 0, berlin)
+	if got := New(config.Display{FirstDayOfWeek: "sunday"}).WeekStart(sunday); got.Day() != 29 || got.Hour() != 0 {
+		t.Errorf("expected a Sunday to start its own week, got %v", got)
+	}
+}
@@ -1,24 +1,42 @@
 package frontend
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
+	"html"
 	"io"
+	"io/fs"
 	"log/slog"
+	"mime"
 	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
-	"text/template"
 	"time"
 
+	"github.com/jo-hoe/goframe/internal/common"
 	"github.com/jo-hoe/goframe/internal/config"
 	"github.com/jo-hoe/goframe/internal/core"
+	"github.com/jo-hoe/goframe/internal/database"
+	"github.com/jo-hoe/goframe/internal/imageprocessing"
 	"github.com/labstack/echo/v4"
+	"gopkg.in/yaml.v3"
 )
 
 const (
-	MainPageName = "index.html"
+	MainPageName       = "index.html"
+	StatsPageName      = "stats.html"
+	ExperimentPageName = "experiment.html"
 )
 
+// maxExperimentPreviews bounds the previews one dithering experiment renders.
+const maxExperimentPreviews = 24
+
 type moveDirection string
 
 const (
@@ -60,13 +78,33 @@ func sliceIndex(s []string, v string) int {
 type FrontendService struct {
 	coreService *core.CoreService
 	config      *config.ServiceConfig
+	// views holds the templates and static assets; the embedded ones unless
+	// developer mode loads them from disk.
+	views fs.FS
+	// devMode reloads changed templates and serves assets uncached.
+	devMode bool
 }
 
 func NewFrontendService(config *config.ServiceConfig, coreService *core.CoreService) *FrontendService {
 	return &FrontendService{
 		coreService: coreService,
 		config:      config,
+		views:       viewsFS(),
+	}
+}
+
+// UseViewsFromDisk enables developer mode: templates and static assets are
+// read from dir, usually SourceViewsDir, instead of the embedded copies, and
+// templates are parsed again when they change, so frontend edits show up on
+// the next page load without a rebuild. It must be called before SetRoutes.
+func (service *FrontendService) UseViewsFromDisk(dir string) error {
+	views, err := diskViews(dir)
+	if err != nil {
+		return err
 	}
+	service.views = views
+	service.devMode = true
+	return nil
 }
 
 // rootRedirectHandler redirects root path to index.html
@@ -76,42 +114,293 @@ func (service *FrontendService) rootRedirectHandler(ctx echo.Context) error {
 
 func (service *FrontendService) SetRoutes(e *echo.Echo) {
 	// Create template renderer
-	e.Renderer = &Template{
-		templates: template.Must(template.New("").ParseFS(templateFS, viewsPattern)),
+	renderer, err := newTemplate(service.views, service.devMode)
+	if err != nil {
+		panic(err)
 	}
+	e.Renderer = renderer
 
 	e.GET("/", service.rootRedirectHandler) // Redirect root to index.html
 	e.GET("/"+MainPageName, service.indexHandler)
+	e.GET("/"+StatsPageName, service.statsHandler)
+	e.GET("/"+ExperimentPageName, service.experimentHandler)
+	e.GET("/chart.js", service.chartScriptHandler)
 	e.POST("/htmx/uploadImage", service.htmxUploadImageHandler)
 
 	// Routes for listing, fetching by ID, and deleting images
 	e.GET("/htmx/images", service.htmxListImagesHandler)
 	e.GET("/htmx/image/original/:id", service.htmxRedirectOriginalByIDHandler)
+	e.GET("/htmx/image/:id/thumbnail.png", service.htmxThumbnailHandler)
+	e.GET("/htmx/image/:id/rotation", service.htmxRotationHandler)
 	e.DELETE("/htmx/image/:id", service.htmxDeleteImageHandler)
 	e.POST("/htmx/image/:id/move", service.htmxMoveImageHandler)
+	e.POST("/htmx/image/:id/archive", service.htmxArchiveImageHandler)
+	e.POST("/htmx/image/:id/unarchive", service.htmxUnarchiveImageHandler)
+	e.POST("/htmx/image/:id/alt", service.htmxSetAltTextHandler)
+	e.POST("/htmx/image/:id/fit", service.htmxSetFitHandler)
+	e.POST("/htmx/image/:id/flat-art", service.htmxSetFlatArtHandler)
+	e.GET("/htmx/image/:id/crop", service.htmxCropEditorHandler)
+	e.POST("/htmx/image/:id/crop", service.htmxSetCropHandler)
+	e.POST("/htmx/image/:id/text", service.htmxSetImageTextHandler)
+	e.GET("/htmx/image/:id/events", service.htmxImageEventsHandler)
+	e.GET("/htmx/image/:id/previews", service.htmxDevicePreviewsHandler)
+	e.POST("/htmx/demo", service.htmxSeedDemoHandler)
+	e.DELETE("/htmx/demo", service.htmxRemoveDemoHandler)
+
+	// Approval inbox for moderated images
+	e.GET("/htmx/pending", service.htmxPendingImagesHandler)
+	e.POST("/htmx/pending/:id/approve", service.htmxApproveImageHandler)
+	e.POST("/htmx/pending/:id/reject", service.htmxRejectImageHandler)
+
+	// Mat (border) settings
+	e.GET("/htmx/mats", service.htmxListMatsHandler)
+	e.POST("/htmx/mats", service.htmxSaveMatHandler)
+	e.DELETE("/htmx/mats", service.htmxDeleteMatHandler)
+
+	// Panel color calibration
+	e.GET("/htmx/calibration", service.htmxCalibrationHandler)
+	e.POST("/htmx/calibration", service.htmxCalibrateHandler)
+	e.POST("/htmx/calibration/photo", service.htmxCalibrateFromPhotoHandler)
+	e.DELETE("/htmx/calibration", service.htmxDeleteCalibrationHandler)
+
+	// Dithering experiments
+	e.GET("/htmx/experiment", service.htmxExperimentHandler)
+	e.GET("/htmx/experiment/config", service.htmxExperimentConfigHandler)
+
+	e.GET("/htmx/version", service.htmxVersionHandler)
+
+	// Storage usage and cleanup
+	e.GET("/htmx/storage", service.htmxStorageHandler)
+	e.POST("/htmx/storage/cleanup", service.htmxCleanUpStorageHandler)
+
+	// Image sources polled for new images
+	e.GET("/htmx/sources", service.htmxSourcesHandler)
+	e.POST("/htmx/sources/:name/enable", service.htmxEnableSourceHandler)
+	e.POST("/htmx/sources/:name/disable", service.htmxDisableSourceHandler)
+
+	// Frames and when they last fetched an image
+	e.GET("/htmx/devices", service.htmxDevicesHandler)
+
+	// Bulk import from server-side directories
+	e.GET("/htmx/import/scan", service.htmxScanImportHandler)
+	e.GET("/htmx/import/preview", service.htmxImportPreviewHandler)
+	e.POST("/htmx/import", service.htmxImportHandler)
 
 	// Favicon (SVG) route
 	e.GET("/icon.svg", service.iconHandler)
+
+	// PWA manifest and service worker (served from the root so its scope covers the whole UI)
+	e.GET("/manifest.webmanifest", service.manifestHandler)
+	e.GET("/sw.js", service.serviceWorkerHandler)
+}
+
+// indexData is the template data for the main page.
+type indexData struct {
+	ReadOnly bool
+	// ImportDirectories are the server-side directories offered for bulk import.
+	ImportDirectories []string
+	// Moderation shows the approval inbox for pending images.
+	Moderation bool
+	// Sources shows the last sync of the configured image sources.
+	Sources bool
 }
 
 func (service *FrontendService) indexHandler(ctx echo.Context) error {
-	return ctx.Render(http.StatusOK, MainPageName, nil)
+	return ctx.Render(http.StatusOK, MainPageName, indexData{
+		ReadOnly:          service.config.ReadOnly,
+		ImportDirectories: service.coreService.ImportDirectories(),
+		Moderation:        service.config.Moderation.Enabled,
+		Sources:           len(service.config.Sources) > 0,
+	})
+}
+
+// statsHandler renders the statistics dashboard; its charts load /api/stats
+// and /api/history in the browser.
+func (service *FrontendService) statsHandler(ctx echo.Context) error {
+	return ctx.Render(http.StatusOK, StatsPageName, nil)
+}
+
+// experimentOption is a choice offered on the experiment page.
+type experimentOption struct {
+	Value    string
+	Label    string
+	Selected bool
+}
+
+// experimentData is the template data for the experiment page.
+type experimentData struct {
+	Images     []experimentOption
+	Algorithms []experimentOption
+	Palettes   []experimentOption
+}
+
+func (service *FrontendService) experimentHandler(ctx echo.Context) error {
+	images, err := service.coreService.ListImages(ctx.Request().Context(), core.ImageFilter{Archived: core.ArchiveInclude})
+	if err != nil {
+		slog.Error("experimentHandler: failed to list images", "error", err)
+		return ctx.String(http.StatusInternalServerError, "Failed to list images")
+	}
+	palettes, err := service.coreService.ExperimentPalettes(ctx.Request().Context())
+	if err != nil {
+		slog.Error("experimentHandler: failed to list palettes", "error", err)
+		return ctx.String(http.StatusInternalServerError, "Failed to list palettes")
+	}
+
+	var data experimentData
+	selected := ctx.QueryParam("id")
+	for _, img := range images {
+		label := img.ID
+		if img.Title != "" {
+			label += " - " + img.Title
+		}
+		data.Images = append(data.Images, experimentOption{Value: img.ID, Label: label, Selected: img.ID == selected})
+	}
+	for _, algorithm := range imageprocessing.DitheringAlgorithms {
+		data.Algorithms = append(data.Algorithms, experimentOption{Value: algorithm, Label: algorithm, Selected: true})
+	}
+	for _, palette := range palettes {
+		data.Palettes = append(data.Palettes, experimentOption{Value: palette, Label: experimentPaletteLabel(palette), Selected: palette == ""})
+	}
+	return ctx.Render(http.StatusOK, ExperimentPageName, data)
+}
+
+// experimentPaletteLabel names a palette returned by ExperimentPalettes.
+func experimentPaletteLabel(palette string) string {
+	switch {
+	case palette == "":
+		return "Configured"
+	case palette == core.ExperimentPaletteBW:
+		return "Black and white"
+	default:
+		return "Calibrated for " + strings.TrimPrefix(palette, "device:")
+	}
+}
+
+// htmxExperimentHandler renders the grid of previews for every combination of
+// the selected algorithms, strengths and palettes. The browser loads the
+// previews in parallel from the preview endpoint.
+func (service *FrontendService) htmxExperimentHandler(ctx echo.Context) error {
+	id := ctx.QueryParam("id")
+	params := ctx.QueryParams()
+	algorithms, palettes := params["algorithm"], params["palette"]
+	if id == "" || len(algorithms) == 0 || len(palettes) == 0 {
+		return htmxError(ctx, http.StatusBadRequest, "Choose an image, an algorithm and a palette")
+	}
+	var strengths []float64
+	for _, field := range strings.Split(ctx.QueryParam("strengths"), ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		strength, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return htmxError(ctx, http.StatusBadRequest, fmt.Sprintf("Invalid strength %q", field))
+		}
+		if !slices.Contains(strengths, strength) {
+			strengths = append(strengths, strength)
+		}
+	}
+	if len(strengths) == 0 {
+		strengths = []float64{1}
+	}
+	if n := len(algorithms) * len(strengths) * len(palettes); n > maxExperimentPreviews {
+		return htmxError(ctx, http.StatusBadRequest, fmt.Sprintf("%d combinations exceed the limit of %d", n, maxExperimentPreviews))
+	}
+	if _, err := service.coreService.GetImageDetails(ctx.Request().Context(), id); err != nil {
+		return htmxError(ctx, http.StatusNotFound, "Image not found")
+	}
+
+	var b strings.Builder
+	b.WriteString(`<div class="grid" style="grid-template-columns:repeat(auto-fill,minmax(14rem,1fr))">`)
+	for _, algorithm := range algorithms {
+		for _, strength := range strengths {
+			for _, palette := range palettes {
+				variant := core.DitherVariant{Algorithm: algorithm, Strength: strength, Palette: palette}
+				if _, err := service.coreService.ExperimentCommands(ctx.Request().Context(), variant); err != nil {
+					if errors.Is(err, core.ErrInvalidExperiment) {
+						return htmxError(ctx, http.StatusBadRequest, err.Error())
+					}
+					slog.Error("htmxExperimentHandler: failed to check variant", "variant", variant, "error", err)
+					return htmxError(ctx, http.StatusInternalServerError, "Failed to prepare the experiment")
+				}
+				query := url.Values{
+					"algorithm": {algorithm},
+					"strength":  {strconv.FormatFloat(strength, 'g', -1, 64)},
+					"palette":   {palette},
+				}.Encode()
+				caption := fmt.Sprintf("%s, strength %g, %s", algorithm, strength, experimentPaletteLabel(palette))
+				fmt.Fprintf(&b, `
+	<figure>
+		<img src="/api/images/%s/preview.png?%s" alt="Preview: %s" loading="lazy" style="width:100%%;image-rendering:pixelated">
+		<figcaption><small>%s</small>
+			<button class="secondary outline" hx-get="/htmx/experiment/config?%s" hx-target="#experiment-config" hx-swap="innerHTML">Use this</button>
+		</figcaption>
+	</figure>`, url.PathEscape(id), html.EscapeString(query), html.EscapeString(caption), html.EscapeString(caption), html.EscapeString(query))
+			}
+		}
+	}
+	b.WriteString(`</div>`)
+	service.setNoCache(ctx)
+	return ctx.HTML(http.StatusOK, b.String())
+}
+
+// htmxExperimentConfigHandler shows the commands section that makes the
+// chosen combination the configured one. The configuration file is not
+// written by the server, so it is shown for copying.
+func (service *FrontendService) htmxExperimentConfigHandler(ctx echo.Context) error {
+	strength, err := strconv.ParseFloat(ctx.QueryParam("strength"), 64)
+	if err != nil {
+		return htmxError(ctx, http.StatusBadRequest, "Invalid strength")
+	}
+	variant := core.DitherVariant{Algorithm: ctx.QueryParam("algorithm"), Strength: strength, Palette: ctx.QueryParam("palette")}
+	commands, err := service.coreService.ExperimentCommands(ctx.Request().Context(), variant)
+	if errors.Is(err, core.ErrInvalidExperiment) {
+		return htmxError(ctx, http.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		slog.Error("htmxExperimentConfigHandler: failed to build commands", "variant", variant, "error", err)
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to build the configuration")
+	}
+	out, err := yaml.Marshal(map[string]any{"commands": core.ExperimentConfig(commands)})
+	if err != nil {
+		slog.Error("htmxExperimentConfigHandler: failed to encode commands", "error", err)
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to build the configuration")
+	}
+	return ctx.HTML(http.StatusOK, fmt.Sprintf(`<p>Replace the <code>commands</code> section of the server configuration with the following and restart the server, then reprocess all images:</p>
+<pre><code>%s</code></pre>`, html.EscapeString(string(out))))
 }
 
 func (service *FrontendService) htmxUploadImageHandler(ctx echo.Context) error {
+	// Parts above the spool threshold are buffered in temp files instead of memory.
+	if err := ctx.Request().ParseMultipartForm(service.config.Uploads.SpoolThresholdBytes); err != nil {
+		slog.Error("htmxUploadImageHandler: failed to parse multipart form",
+			"status", http.StatusBadRequest, "error", err)
+		return htmxError(ctx, http.StatusBadRequest, "Failed to get uploaded file")
+	}
+
 	// Get uploaded file
 	file, err := ctx.FormFile("image")
 	if err != nil {
 		slog.Error("htmxUploadImageHandler: failed to get uploaded file",
 			"status", http.StatusBadRequest, "error", err)
-		return ctx.String(http.StatusBadRequest, "Failed to get uploaded file")
+		return htmxError(ctx, http.StatusBadRequest, "Failed to get uploaded file")
+	}
+
+	quotaKey := core.QuotaKey(ctx.Request().Header.Get("X-API-Key"), ctx.RealIP())
+	releaseQuota, err := service.coreService.ReserveUploadQuota(quotaKey, file.Size)
+	if err != nil {
+		slog.Warn("htmxUploadImageHandler: upload rejected by quota", "client", quotaKey, "error", err)
+		if errors.Is(err, core.ErrStorageQuotaExceeded) {
+			return htmxError(ctx, http.StatusRequestEntityTooLarge, "Storage quota exceeded")
+		}
+		return htmxError(ctx, http.StatusTooManyRequests, "Upload quota exceeded")
 	}
 
 	src, err := file.Open()
 	if err != nil {
+		releaseQuota()
 		slog.Error("htmxUploadImageHandler: failed to open uploaded file",
 			"status", http.StatusInternalServerError, "error", err, "filename", file.Filename)
-		return ctx.String(http.StatusInternalServerError, "Failed to open uploaded file")
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to open uploaded file")
 	}
 	defer func() {
 		if cerr := src.Close(); cerr != nil {
@@ -119,19 +408,30 @@ func (service *FrontendService) htmxUploadImageHandler(ctx echo.Context) error {
 		}
 	}()
 
-	// Read file content reliably
-	image, err := io.ReadAll(src)
-	if err != nil {
-		slog.Error("htmxUploadImageHandler: failed to read uploaded file",
-			"status", http.StatusInternalServerError, "error", err, "filename", file.Filename)
-		return ctx.String(http.StatusInternalServerError, "Failed to read uploaded file")
+	attribution := database.Attribution{
+		SourceURL: strings.TrimSpace(ctx.FormValue("sourceUrl")),
+		Author:    strings.TrimSpace(ctx.FormValue("author")),
+		License:   strings.TrimSpace(ctx.FormValue("license")),
 	}
 
-	_, err = service.coreService.AddImage(ctx.Request().Context(), image, "")
+	apiImg, err := service.coreService.AddImageFromReader(ctx.Request().Context(), src, "", attribution)
 	if err != nil {
+		releaseQuota()
+		if errors.Is(err, core.ErrFormatDisabled) {
+			slog.Info("htmxUploadImageHandler: format disabled", "error", err, "filename", file.Filename)
+			return htmxError(ctx, http.StatusUnsupportedMediaType, "This server was built without support for this image format")
+		}
+		if errors.Is(err, core.ErrUploadRejected) {
+			slog.Info("htmxUploadImageHandler: upload rejected by hook", "error", err, "filename", file.Filename)
+			return htmxError(ctx, http.StatusUnprocessableEntity, "The upload was rejected by a server check")
+		}
+		if errors.Is(err, core.ErrSVGLimit) {
+			slog.Info("htmxUploadImageHandler: svg exceeds limits", "error", err, "filename", file.Filename)
+			return htmxError(ctx, http.StatusUnprocessableEntity, "This SVG is too complex to render")
+		}
 		slog.Error("htmxUploadImageHandler: failed to process uploaded image",
 			"status", http.StatusInternalServerError, "error", err, "filename", file.Filename)
-		return ctx.String(http.StatusInternalServerError, "Failed to process uploaded image")
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to process uploaded image")
 	}
 
 	// Return an out-of-band swap to refresh the displayed image, plus a simple status message
@@ -147,17 +447,29 @@ func (service *FrontendService) htmxUploadImageHandler(ctx echo.Context) error {
 	}
 	imageListOOB := fmt.Sprintf(`<div id="image-list" hx-swap-oob="true">%s</div>`, imageListHTML)
 
-	// Return HTML with OOB swap for image list
-	html := fmt.Sprintf(`<div id="upload-result">Uploaded file: %s</div>%s`, file.Filename, imageListOOB)
+	status := ""
+	if apiImg.Pending {
+		status = " (awaiting approval)"
+		if pendingHTML, err := service.buildPendingListHTML(ctx.Request().Context()); err == nil {
+			imageListOOB += fmt.Sprintf(`<div id="pending-list" hx-swap-oob="true">%s</div>`, pendingHTML)
+		}
+	}
+
+	// Return HTML with OOB swap for image list; the device previews load
+	// afterwards so rendering them does not delay the upload result.
+	html := fmt.Sprintf(`<div id="upload-result">Uploaded file: %s%s%s%s</div>%s`, file.Filename, status,
+		service.similarWarningHTML(ctx.Request().Context(), apiImg), devicePreviewsLoaderHTML(apiImg.ID), imageListOOB)
 	return ctx.HTML(http.StatusOK, html)
 }
 
+// htmxListImagesHandler renders the schedule, or the archived images when
+// ?archived=true is set by the frontend filter toggle.
 func (service *FrontendService) htmxListImagesHandler(ctx echo.Context) error {
-	listHTML, err := service.buildImageListHTML(ctx.Request().Context())
+	listHTML, err := service.buildListHTML(ctx.Request().Context(), ctx.QueryParam("archived") == "true")
 	if err != nil {
 		slog.Error("htmxListImagesHandler: failed to list images",
 			"status", http.StatusInternalServerError, "error", err)
-		return ctx.String(http.StatusInternalServerError, "Failed to list images")
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to list images")
 	}
 
 	// Prevent caching so the latest images are always shown
@@ -166,6 +478,46 @@ func (service *FrontendService) htmxListImagesHandler(ctx echo.Context) error {
 	return ctx.HTML(http.StatusOK, listHTML)
 }
 
+// htmxError answers an htmx request with a dismissible error toast. The
+// fragment is retargeted to the page's toast container, so a failure does not
+// replace the region the request was meant to update.
+func htmxError(ctx echo.Context, status int, message string) error {
+	ctx.Response().Header().Set("HX-Retarget", "#toasts")
+	ctx.Response().Header().Set("HX-Reswap", "beforeend")
+	return ctx.HTML(status, fmt.Sprintf(
+		`<div class="toast" role="alert"><span>%s</span><button type="button" class="toast-close" aria-label="Dismiss">&times;</button></div>`,
+		html.EscapeString(message)))
+}
+
+func (service *FrontendService) htmxThumbnailHandler(ctx echo.Context) error {
+	id := ctx.Param("id")
+	thumb, err := service.coreService.GetThumbnail(ctx.Request().Context(), id)
+	if err != nil {
+		slog.Warn("htmxThumbnailHandler: thumbnail not available", "image_id", id, "error", err)
+		return ctx.String(http.StatusNotFound, "Image not available")
+	}
+	ctx.Response().Header().Set("Cache-Control", "private, max-age=300")
+	return ctx.Blob(http.StatusOK, "image/png", thumb.PNG)
+}
+
+// htmxRotationHandler renders an icon telling that the pipeline turns the
+// image on the frame, or nothing. Failures render nothing as well, since the
+// indicator is decorative and loaded for every image in a list.
+func (service *FrontendService) htmxRotationHandler(ctx echo.Context) error {
+	id := ctx.Param("id")
+	thumb, err := service.coreService.GetThumbnail(ctx.Request().Context(), id)
+	if err != nil {
+		slog.Warn("htmxRotationHandler: thumbnail not available", "image_id", id, "error", err)
+		return ctx.HTML(http.StatusOK, "")
+	}
+	if thumb.QuarterTurns == 0 {
+		return ctx.HTML(http.StatusOK, "")
+	}
+	label := fmt.Sprintf("Turned %d° clockwise on the frame", thumb.QuarterTurns*90)
+	return ctx.HTML(http.StatusOK, fmt.Sprintf(`<small title="%s"><svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" aria-hidden="true" style="vertical-align:text-bottom"><path d="M21 12a9 9 0 1 1-3-6.7"/><polyline points="21 3 21 9 15 9"/></svg> %s</small>`,
+		label, label))
+}
+
 func (service *FrontendService) htmxRedirectOriginalByIDHandler(ctx echo.Context) error {
 	id := ctx.Param("id")
 	if id == "" {
@@ -191,21 +543,28 @@ func (service *FrontendService) htmxDeleteImageHandler(ctx echo.Context) error {
 		slog.Warn("htmxDeleteImageHandler: missing image id",
 			"status", http.StatusBadRequest,
 			"route", "/htmx/image/:id")
-		return ctx.String(http.StatusBadRequest, "Missing image ID")
+		return htmxError(ctx, http.StatusBadRequest, "Missing image ID")
 	}
 
-	if err := service.coreService.DeleteImage(ctx.Request().Context(), id); err != nil {
+	err := service.coreService.DeleteImageChecked(ctx.Request().Context(), id, ctx.QueryParam("force") == "true")
+	if errors.Is(err, core.ErrCurrentImageProtected) {
+		return htmxError(ctx, http.StatusConflict, "The image currently on the frame cannot be deleted")
+	}
+	if errors.Is(err, core.ErrImageLocked) {
+		return htmxError(ctx, http.StatusConflict, "The image is being modified; try again shortly")
+	}
+	if err != nil {
 		slog.Error("htmxDeleteImageHandler: failed to delete image",
 			"status", http.StatusInternalServerError, "image_id", id, "error", err)
-		return ctx.String(http.StatusInternalServerError, "Failed to delete image")
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to delete image")
 	}
 
-	// Build updated list HTML
-	listHTML, err := service.buildImageListHTML(ctx.Request().Context())
+	// Build updated list HTML for the view the image was deleted from
+	listHTML, err := service.buildListHTML(ctx.Request().Context(), ctx.QueryParam("archived") == "true")
 	if err != nil {
 		slog.Error("htmxDeleteImageHandler: failed to list images after delete",
 			"status", http.StatusInternalServerError, "error", err)
-		return ctx.String(http.StatusInternalServerError, "Failed to list images")
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to list images")
 	}
 
 	// Prevent caching so the latest state is shown
@@ -221,39 +580,135 @@ func (service *FrontendService) setNoCache(ctx echo.Context) {
 	ctx.Response().Header().Set("Expires", "0")
 }
 
+// setAssetCache sets the Cache-Control of a static asset, which developer
+// mode replaces with no-cache so that edits are picked up on reload.
+func (service *FrontendService) setAssetCache(ctx echo.Context, cacheControl string) {
+	if service.devMode {
+		service.setNoCache(ctx)
+		return
+	}
+	ctx.Response().Header().Set("Cache-Control", cacheControl)
+}
+
 func (service *FrontendService) formatNextShow(t time.Time) string {
 	if !t.IsZero() && t.Unix() > 0 && t.Year() > 1 {
-		return t.Format("2006-01-02")
+		return service.coreService.DisplayFormat().Day(t)
 	}
 	return "unknown"
 }
 
+// buildListHTML renders either the schedule or the archived images.
+func (service *FrontendService) buildListHTML(ctx context.Context, archived bool) (string, error) {
+	if archived {
+		return service.buildArchivedListHTML(ctx)
+	}
+	return service.buildImageListHTML(ctx)
+}
+
+// buildArchivedListHTML renders archived images, newest first, with controls to
+// return them to the rotation.
+func (service *FrontendService) buildArchivedListHTML(ctx context.Context) (string, error) {
+	images, err := service.coreService.ListImages(ctx, core.ImageFilter{Archived: core.ArchiveOnly})
+	if err != nil {
+		return "", err
+	}
+	if len(images) == 0 {
+		return `<p>No archived images.</p>`, nil
+	}
+
+	var b strings.Builder
+	b.WriteString(`<div class="vertical-list" role="list" aria-label="Archived images">`)
+	for _, img := range images {
+		controls := ""
+		if !service.config.ReadOnly {
+			controls = fmt.Sprintf(`
+		<div style="display:flex;gap:0.5rem">
+			<button hx-post="/htmx/image/%s/unarchive" hx-target="#image-list" hx-swap="innerHTML">Unarchive</button>
+			<button hx-delete="/htmx/image/%s?archived=true" hx-target="#image-list" hx-swap="innerHTML" class="secondary">Delete</button>
+		</div>`, img.ID, img.ID)
+		}
+		fmt.Fprintf(&b, `<div class="vertical-item" role="listitem" data-id="%s" style="margin-bottom:1rem"><article>%s
+	%s
+	<footer style="display:flex;gap:0.5rem;align-items:center;flex-wrap:wrap">
+		<small>Archived, added %s</small>%s%s%s
+	</footer>%s
+</article></div>`, img.ID, imageTextHTML(img), thumbnailHTML(img.ID, imageAltText(img, "Archived image "+img.ID)), service.coreService.DisplayFormat().Date(img.CreatedAt), rotationHTML(img.ID),
+			attributionHTML(img.Attribution), controls, service.imageDetailsHTML(img, true))
+	}
+	b.WriteString(`</div>`)
+	return b.String(), nil
+}
+
 func (service *FrontendService) buildImageListHTML(ctx context.Context) (string, error) {
 	// Render strictly in persisted DB order for deterministic Up/Down moves
-	ids, err := service.coreService.GetOrderedImageIDs(ctx)
+	images, err := service.coreService.GetOrderedImages(ctx)
 	if err != nil {
 		return "", err
 	}
 
 	var b strings.Builder
-	if len(ids) == 0 {
+	if len(images) == 0 {
 		b.WriteString(`<p>No images uploaded yet.</p>`)
+		if !service.config.ReadOnly {
+			b.WriteString(`<button hx-post="/htmx/demo" hx-target="#image-list" hx-swap="innerHTML" class="secondary outline">Load demo images</button>`)
+		}
 		return b.String(), nil
 	}
-	// compute per-position dates; top of list is today's image
-	base := time.Now()
-
-	b.WriteString(`<div class="vertical-list" id="image-sort-list">`)
-	for i, id := range ids {
-		showDate := base.AddDate(0, 0, i)
-		nextStr := service.formatNextShow(showDate)
+	if !service.config.ReadOnly && slices.ContainsFunc(images, func(img *database.Image) bool { return img.Source == core.DemoSource }) {
+		b.WriteString(`<p style="display:flex;gap:0.5rem;align-items:center;flex-wrap:wrap"><small>The schedule contains demo images.</small>
+	<button hx-delete="/htmx/demo" hx-target="#image-list" hx-swap="innerHTML" class="secondary outline">Remove demo images</button></p>`)
+	}
+	// Show dates come from the rotation timezone; top of list is today's image.
+	schedules, err := service.coreService.GetImageSchedules(ctx)
+	if err != nil {
+		return "", err
+	}
+	byID := make(map[string]core.ImageSchedule, len(schedules))
+	for _, s := range schedules {
+		byID[s.ImageID] = s
+	}
 
-		imgURL, _ := service.coreService.GetImageURL(ctx, id, "original")
+	b.WriteString(`<div class="vertical-list" id="image-sort-list" role="list" aria-label="Scheduled images">`)
+	for i, img := range images {
+		id := img.ID
+		schedule := byID[id]
+		nextStr := service.formatNextShow(schedule.ShowAt)
+		if schedule.Relative != "" {
+			nextStr += " (" + schedule.Relative + ")"
+		}
+		if schedule.Story != "" {
+			nextStr += fmt.Sprintf(", story %s, day %d of %d", schedule.Story, schedule.StoryDay, schedule.StoryDays)
+		}
 
-		fmt.Fprintf(&b, `<div class="vertical-item" data-id="%s" style="margin-bottom:1rem"><article>
-	<img src="%s" alt="Original image %s" loading="lazy" style="max-width:100%%;height:auto">
+		fmt.Fprintf(&b, `<div class="vertical-item" role="listitem" data-id="%s" style="margin-bottom:1rem"><article>%s
+	%s
 	<footer style="display:flex;gap:0.5rem;align-items:center;flex-wrap:wrap">
-		<small>Scheduled date: %s</small>
+		<small>Scheduled date: %s</small>%s%s%s
+	</footer>%s
+</article></div>`, id, imageTextHTML(img), thumbnailHTML(id, imageAltText(img, "Image scheduled for "+nextStr)), nextStr, rotationHTML(id),
+			attributionHTML(img.Attribution), service.imageControlsHTML(id, i == 0), service.imageDetailsHTML(img, false))
+	}
+	b.WriteString(`</div>`)
+	return b.String(), nil
+}
+
+// imageControlsHTML renders the move and delete buttons for an image; read-only
+// instances get none. The delete button of the current image follows the
+// configured current image deletion policy.
+func (service *FrontendService) imageControlsHTML(id string, current bool) string {
+	if service.config.ReadOnly {
+		return ""
+	}
+	deleteButton := fmt.Sprintf(`<button hx-delete="/htmx/image/%s" hx-target="#image-list" hx-swap="innerHTML" class="secondary">Delete</button>`, id)
+	if current {
+		switch service.coreService.CurrentImageDeletion() {
+		case config.CurrentImageDeletionBlock:
+			deleteButton = `<button class="secondary" disabled title="The image currently on the frame cannot be deleted">Delete</button>`
+		case config.CurrentImageDeletionWarn:
+			deleteButton = fmt.Sprintf(`<button hx-delete="/htmx/image/%s?force=true" hx-target="#image-list" hx-swap="innerHTML" hx-confirm="This image is on the frame right now. Delete it anyway?" class="secondary">Delete</button>`, id)
+		}
+	}
+	return fmt.Sprintf(`
 		<div style="display:flex;gap:0.5rem">
 			<button hx-post="/htmx/image/%s/move?dir=up" hx-target="#image-list" hx-swap="innerHTML" aria-label="Move up" title="Move up">
 				<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" aria-hidden="true">
@@ -265,13 +720,390 @@ func (service *FrontendService) buildImageListHTML(ctx context.Context) (string,
 					<polygon points="5,6 19,6 12,19" />
 				</svg>
 			</button>
-			<button hx-delete="/htmx/image/%s" hx-target="#image-list" hx-swap="innerHTML" class="secondary">Delete</button>
-		</div>
-	</footer>
-</article></div>`, id, imgURL, id, nextStr, id, id, id)
+			<button hx-post="/htmx/image/%s/archive" hx-target="#image-list" hx-swap="innerHTML" class="secondary outline">Archive</button>
+			%s
+		</div>`, id, id, id, deleteButton)
+}
+
+// similarWarningHTML warns about near-identical existing images and offers to
+// drop the new upload or the existing copies. Returns "" when there are none.
+func (service *FrontendService) similarWarningHTML(ctx context.Context, apiImg *common.ApiImage) string {
+	if len(apiImg.Similar) == 0 {
+		return ""
 	}
-	b.WriteString(`</div>`)
-	return b.String(), nil
+	var b strings.Builder
+	fmt.Fprintf(&b, `
+<div class="similar-warning" role="alert">
+	<p>This image looks like %d existing image(s):</p>
+	<div style="display:flex;gap:0.5rem;flex-wrap:wrap">`, len(apiImg.Similar))
+	for _, id := range apiImg.Similar {
+		imgURL, _ := service.coreService.GetImageURL(ctx, id, "original")
+		alt := html.EscapeString("Similar image " + id)
+		if img, err := service.coreService.GetImageById(ctx, id); err == nil {
+			alt = imageAltText(img, "Similar image "+id)
+		}
+		fmt.Fprintf(&b, `
+		<img src="%s" alt="%s" loading="lazy" style="max-width:8rem;height:auto">`, imgURL, alt)
+	}
+	b.WriteString(`
+	</div>`)
+	if !service.config.ReadOnly {
+		fmt.Fprintf(&b, `
+	<div style="display:flex;gap:0.5rem">
+		<button hx-delete="/htmx/image/%s" hx-target="#image-list" hx-swap="innerHTML" hx-on::after-request="this.closest('.similar-warning').remove()" class="secondary">Skip upload</button>`, apiImg.ID)
+		for _, id := range apiImg.Similar {
+			fmt.Fprintf(&b, `
+		<button hx-delete="/htmx/image/%s" hx-target="#image-list" hx-swap="innerHTML" hx-on::after-request="this.remove()" class="secondary outline">Remove existing %s</button>`, id, id)
+		}
+		b.WriteString(`
+		<button hx-on:click="this.closest('.similar-warning').remove()" class="outline">Keep both</button>
+	</div>`)
+	}
+	b.WriteString(`
+</div>`)
+	return b.String()
+}
+
+// attributionHTML renders the attribution as an escaped <small> element, linking
+// to the source URL when present. Returns "" for images without attribution.
+func attributionHTML(a database.Attribution) string {
+	if a.IsZero() {
+		return ""
+	}
+	label := a.String()
+	if label == "" {
+		label = "Source"
+	}
+	label = html.EscapeString(label)
+	if a.SourceURL != "" && (strings.HasPrefix(a.SourceURL, "https://") || strings.HasPrefix(a.SourceURL, "http://")) {
+		label = fmt.Sprintf(`<a href="%s" target="_blank" rel="noopener noreferrer">%s</a>`, html.EscapeString(a.SourceURL), label)
+	}
+	return "\n\t\t<small>Attribution: " + label + "</small>"
+}
+
+// imageAltText returns the escaped alt text of img, else its title, or
+// fallback for images with neither.
+func imageAltText(img *database.Image, fallback string) string {
+	switch {
+	case img.AltText != "":
+		return html.EscapeString(img.AltText)
+	case img.Title != "":
+		return html.EscapeString(img.Title)
+	}
+	return html.EscapeString(fallback)
+}
+
+// thumbnailHTML renders the preview of an image, turned like on the frame and
+// linked to the original.
+func thumbnailHTML(id, alt string) string {
+	return fmt.Sprintf(`<a href="/htmx/image/original/%s" target="_blank" rel="noopener"><img src="/htmx/image/%s/thumbnail.png" alt="%s" loading="lazy" style="max-width:100%%;height:auto"></a>`,
+		id, id, alt)
+}
+
+// rotationHTML renders a placeholder replaced by the rotation indicator of an
+// image once the list is shown.
+func rotationHTML(id string) string {
+	return fmt.Sprintf(`<span hx-get="/htmx/image/%s/rotation" hx-trigger="load" hx-swap="outerHTML"></span>`, id)
+}
+
+// imageTextHTML renders the title and description of img above it.
+func imageTextHTML(img *database.Image) string {
+	if img.Title == "" && img.Description == "" {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n\t<header>")
+	if img.Title != "" {
+		fmt.Fprintf(&b, "<strong>%s</strong>", html.EscapeString(img.Title))
+	}
+	if img.Description != "" {
+		fmt.Fprintf(&b, `<p style="white-space:pre-line;margin-bottom:0"><small>%s</small></p>`, html.EscapeString(img.Description))
+	}
+	b.WriteString("</header>")
+	return b.String()
+}
+
+// imageDetailsHTML renders the details of an image: its alt text and fit and,
+// unless the instance is read-only, forms to edit them together with the title
+// and description. archived selects the list the forms re-render.
+func (service *FrontendService) imageDetailsHTML(img *database.Image, archived bool) string {
+	alt := html.EscapeString(img.AltText)
+	if service.config.ReadOnly {
+		var b strings.Builder
+		if alt != "" {
+			fmt.Fprintf(&b, "\n\t\t<p><small>Alt text: %s</small></p>", alt)
+		}
+		if img.Fit != "" {
+			fmt.Fprintf(&b, "\n\t\t<p><small>Fit: %s</small></p>", html.EscapeString(img.Fit))
+		}
+		if img.FlatArt != "" {
+			fmt.Fprintf(&b, "\n\t\t<p><small>Flat art: %s</small></p>", html.EscapeString(img.FlatArt))
+		}
+		if img.Crop != nil {
+			fmt.Fprintf(&b, "\n\t\t<p><small>Crop: %.0f%% × %.0f%% of the original</small></p>", img.Crop.Width*100, img.Crop.Height*100)
+		}
+		b.WriteString(imageEventsHTML(img.ID))
+		return "\n\t<details>\n\t\t<summary>Details</summary>" + b.String() + "\n\t</details>"
+	}
+	return fmt.Sprintf(`
+	<details>
+		<summary>Details</summary>
+		<form hx-post="/htmx/image/%s/text" hx-target="#image-list" hx-swap="innerHTML">
+			<input type="hidden" name="archived" value="%t">
+			<label for="title-%s">Title</label>
+			<input id="title-%s" name="title" maxlength="200" value="%s">
+			<label for="description-%s">Description</label>
+			<textarea id="description-%s" name="description" rows="3" maxlength="2000">%s</textarea>
+			<button type="submit">Save title and description</button>
+		</form>
+		<form hx-post="/htmx/image/%s/alt" hx-target="#image-list" hx-swap="innerHTML">
+			<input type="hidden" name="archived" value="%t">
+			<label for="alt-%s">Alt text</label>
+			<textarea id="alt-%s" name="altText" rows="2" maxlength="500" aria-describedby="alt-help-%s">%s</textarea>
+			<small id="alt-help-%s">Describes the image for screen readers.</small>
+			<button type="submit">Save alt text</button>
+		</form>
+		<form hx-post="/htmx/image/%s/fit" hx-target="#image-list" hx-swap="innerHTML">
+			<input type="hidden" name="archived" value="%t">
+			<label for="fit-%s">Fit</label>
+			<select id="fit-%s" name="fit" aria-describedby="fit-help-%s">%s
+			</select>
+			<small id="fit-help-%s">Letterbox the whole image or crop it to fill the frame. Changing it reprocesses the image.</small>
+			<button type="submit">Save fit</button>
+		</form>
+		<form hx-post="/htmx/image/%s/flat-art" hx-target="#image-list" hx-swap="innerHTML">
+			<input type="hidden" name="archived" value="%t">
+			<label for="flat-art-%s">Flat art</label>
+			<select id="flat-art-%s" name="flatArt" aria-describedby="flat-art-help-%s">%s
+			</select>
+			<small id="flat-art-help-%s">Illustrations and logos look crisper mapped to the nearest colors than dithered. Changing it reprocesses the image.</small>
+			<button type="submit">Save flat art</button>
+		</form>%s%s
+	</details>`, img.ID, archived, img.ID, img.ID, html.EscapeString(img.Title), img.ID, img.ID, html.EscapeString(img.Description),
+		img.ID, archived, img.ID, img.ID, img.ID, alt, img.ID,
+		img.ID, archived, img.ID, img.ID, img.ID, fitOptionsHTML(img.Fit), img.ID,
+		img.ID, archived, img.ID, img.ID, img.ID, flatArtOptionsHTML(img.FlatArt), img.ID, cropEditorLoaderHTML(img.ID, archived), imageEventsHTML(img.ID))
+}
+
+// cropEditorLoaderHTML renders the collapsed crop editor of an image; the
+// presets are only fetched when it is opened.
+func cropEditorLoaderHTML(id string, archived bool) string {
+	return fmt.Sprintf(`
+		<details hx-get="/htmx/image/%s/crop?archived=%t" hx-trigger="toggle once" hx-target="find .crop-editor" hx-swap="innerHTML">
+			<summary>Crop</summary>
+			<div class="crop-editor" aria-live="polite"><small>Loading…</small></div>
+		</details>`, url.PathEscape(id), archived)
+}
+
+// htmxCropEditorHandler renders the crop editor of an image: aspect ratio
+// presets matching the panel and the devices' mats, and a frame over the
+// thumbnail that is dragged to the part of the image to show. The script in
+// index.html keeps the hidden region fields in sync with the frame.
+func (service *FrontendService) htmxCropEditorHandler(ctx echo.Context) error {
+	id := ctx.Param("id")
+	img, err := service.coreService.GetImageById(ctx.Request().Context(), id)
+	if err != nil {
+		return htmxError(ctx, http.StatusNotFound, "Image not found")
+	}
+	presets, err := service.coreService.GetCropPresets(ctx.Request().Context())
+	if err != nil {
+		slog.Error("htmxCropEditorHandler: failed to list crop presets", "image_id", id, "error", err)
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to list crop presets")
+	}
+
+	var options strings.Builder
+	options.WriteString("\n\t\t\t\t<option value=\"\">Whole image</option>")
+	for _, preset := range presets {
+		devices := make([]string, len(preset.DeviceIDs))
+		for i, deviceID := range preset.DeviceIDs {
+			devices[i] = cmp.Or(deviceID, "Default")
+		}
+		fmt.Fprintf(&options, "\n\t\t\t\t<option value=\"%dx%d\">%d×%d (%s)</option>",
+			preset.Width, preset.Height, preset.Width, preset.Height, html.EscapeString(strings.Join(devices, ", ")))
+	}
+	region := [4]string{}
+	if img.Crop != nil {
+		for i, v := range []float64{img.Crop.X, img.Crop.Y, img.Crop.Width, img.Crop.Height} {
+			region[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		}
+	}
+
+	escapedID := url.PathEscape(id)
+	service.setNoCache(ctx)
+	return ctx.HTML(http.StatusOK, fmt.Sprintf(`
+<form class="crop-form" hx-post="/htmx/image/%s/crop" hx-target="#image-list" hx-swap="innerHTML">
+	<input type="hidden" name="archived" value="%t">
+	<label for="crop-preset-%s">Aspect ratio</label>
+	<select id="crop-preset-%s" name="preset" class="crop-preset" aria-describedby="crop-help-%s">%s
+	</select>
+	<div class="crop-stage">
+		<img src="/htmx/image/%s/thumbnail.png" alt="">
+		<div class="crop-frame" tabindex="0" role="img" aria-label="Crop frame, move with the arrow keys" hidden></div>
+	</div>
+	<label for="crop-zoom-%s">Zoom</label>
+	<input id="crop-zoom-%s" class="crop-zoom" type="range" min="20" max="100" value="100">
+	<input type="hidden" name="x" value="%s">
+	<input type="hidden" name="y" value="%s">
+	<input type="hidden" name="width" value="%s">
+	<input type="hidden" name="height" value="%s">
+	<small id="crop-help-%s">Drag the frame over the part of the image the frames show. Saving reprocesses the image.</small>
+	<button type="submit">Save crop</button>
+</form>`, escapedID, ctx.QueryParam("archived") == "true", escapedID, escapedID, escapedID, options.String(),
+		escapedID, escapedID, escapedID, region[0], region[1], region[2], region[3], escapedID))
+}
+
+// htmxSetCropHandler stores the region chosen in an image's crop editor,
+// reprocesses the image and re-renders the list it was shown in. Empty region
+// fields restore the whole image.
+func (service *FrontendService) htmxSetCropHandler(ctx echo.Context) error {
+	id := ctx.Param("id")
+	var crop *database.Region
+	if ctx.FormValue("width") != "" {
+		values := make([]float64, 4)
+		for i, name := range []string{"x", "y", "width", "height"} {
+			v, err := strconv.ParseFloat(ctx.FormValue(name), 64)
+			if err != nil {
+				return htmxError(ctx, http.StatusBadRequest, "Invalid crop region")
+			}
+			values[i] = v
+		}
+		crop = &database.Region{X: values[0], Y: values[1], Width: values[2], Height: values[3]}
+	}
+	err := service.coreService.SetImageCrop(ctx.Request().Context(), id, crop)
+	switch {
+	case errors.Is(err, core.ErrInvalidCrop):
+		return htmxError(ctx, http.StatusBadRequest, "Invalid crop region")
+	case errors.Is(err, core.ErrImageNotFound):
+		return htmxError(ctx, http.StatusNotFound, "Image not found")
+	case errors.Is(err, core.ErrImageLocked):
+		return htmxError(ctx, http.StatusConflict, "The image is being modified; try again shortly")
+	case err != nil:
+		slog.Error("htmxSetCropHandler: failed to set crop", "image_id", id, "error", err)
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to save crop")
+	}
+
+	listHTML, err := service.buildListHTML(ctx.Request().Context(), ctx.FormValue("archived") == "true")
+	if err != nil {
+		slog.Error("htmxSetCropHandler: failed to rebuild image list", "error", err)
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to rebuild image list")
+	}
+	service.setNoCache(ctx)
+	return ctx.HTML(http.StatusOK, listHTML)
+}
+
+// imageEventsHTML renders the collapsed event log of an image; it is only
+// fetched when opened so the list does not read every log up front.
+func imageEventsHTML(id string) string {
+	return fmt.Sprintf(`
+		<details hx-get="/htmx/image/%s/events" hx-trigger="toggle once" hx-target="find .image-events" hx-swap="innerHTML">
+			<summary>History</summary>
+			<div class="image-events" aria-live="polite"><small>Loading…</small></div>
+		</details>`, id)
+}
+
+// htmxImageEventsHandler renders the lifecycle log of an image, newest first.
+func (service *FrontendService) htmxImageEventsHandler(ctx echo.Context) error {
+	id := ctx.Param("id")
+	events, err := service.coreService.GetImageEvents(ctx.Request().Context(), id)
+	if errors.Is(err, core.ErrImageNotFound) {
+		return htmxError(ctx, http.StatusNotFound, "Image not found")
+	}
+	if err != nil {
+		slog.Error("htmxImageEventsHandler: failed to read image events", "image_id", id, "error", err)
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to read image history")
+	}
+	service.setNoCache(ctx)
+	if len(events) == 0 {
+		return ctx.HTML(http.StatusOK, `<small>No events recorded.</small>`)
+	}
+
+	var b strings.Builder
+	b.WriteString(`<table><thead><tr><th scope="col">Time</th><th scope="col">Event</th><th scope="col">Pipeline</th><th scope="col">Detail</th></tr></thead><tbody>`)
+	for _, event := range slices.Backward(events) {
+		fmt.Fprintf(&b, `<tr><td><small>%s</small></td><td>%s</td><td><code>%s</code></td><td><small>%s</small></td></tr>`,
+			service.coreService.DisplayFormat().DateTime(event.At), html.EscapeString(event.Type), html.EscapeString(event.PipelineHash), html.EscapeString(event.Detail))
+	}
+	b.WriteString(`</tbody></table>`)
+	return ctx.HTML(http.StatusOK, b.String())
+}
+
+// devicePreviewsLoaderHTML loads the device previews of image id once shown.
+func devicePreviewsLoaderHTML(id string) string {
+	return fmt.Sprintf(`
+<div hx-get="/htmx/image/%s/previews" hx-trigger="load" hx-swap="outerHTML"></div>`, url.PathEscape(id))
+}
+
+// htmxDevicePreviewsHandler shows image id side by side as each device profile
+// will display it. A single profile renders nothing, since the upload result
+// then already tells how the image looks.
+func (service *FrontendService) htmxDevicePreviewsHandler(ctx echo.Context) error {
+	id := ctx.Param("id")
+	previews, err := service.coreService.GetDevicePreviews(ctx.Request().Context(), id)
+	if errors.Is(err, core.ErrImageNotFound) {
+		return htmxError(ctx, http.StatusNotFound, "Image not found")
+	}
+	if err != nil {
+		slog.Error("htmxDevicePreviewsHandler: failed to render device previews", "image_id", id, "error", err)
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to render device previews")
+	}
+	service.setNoCache(ctx)
+	if len(previews) < 2 {
+		return ctx.HTML(http.StatusOK, "")
+	}
+
+	var b strings.Builder
+	b.WriteString(`
+<div class="grid" style="grid-template-columns:repeat(auto-fill,minmax(12rem,1fr))">`)
+	for _, preview := range previews {
+		devices := make([]string, len(preview.DeviceIDs))
+		for i, deviceID := range preview.DeviceIDs {
+			devices[i] = cmp.Or(deviceID, "Default")
+		}
+		caption := html.EscapeString(strings.Join(devices, ", "))
+		fmt.Fprintf(&b, `
+	<figure>
+		<img src="%s" alt="Preview for %s" loading="lazy" style="width:100%%;image-rendering:pixelated">
+		<figcaption><small>%s</small></figcaption>
+	</figure>`, html.EscapeString(preview.URL), caption, caption)
+	}
+	b.WriteString(`
+</div>`)
+	return ctx.HTML(http.StatusOK, b.String())
+}
+
+// fitOptionsHTML renders the fit choices with current selected.
+func fitOptionsHTML(current string) string {
+	options := []struct{ value, label string }{
+		{"", "Default"},
+		{imageprocessing.FitContain, "Contain (letterbox)"},
+		{imageprocessing.FitCover, "Cover (crop to fill)"},
+	}
+	var b strings.Builder
+	for _, o := range options {
+		selected := ""
+		if o.value == current {
+			selected = " selected"
+		}
+		fmt.Fprintf(&b, "\n\t\t\t\t<option value=\"%s\"%s>%s</option>", o.value, selected, o.label)
+	}
+	return b.String()
+}
+
+func flatArtOptionsHTML(current string) string {
+	options := []struct{ value, label string }{
+		{"", "Default"},
+		{imageprocessing.FlatArtAlways, "Yes (no dithering)"},
+		{imageprocessing.FlatArtNever, "No (always dither)"},
+	}
+	var b strings.Builder
+	for _, o := range options {
+		selected := ""
+		if o.value == current {
+			selected = " selected"
+		}
+		fmt.Fprintf(&b, "\n\t\t\t\t<option value=\"%s\"%s>%s</option>", o.value, selected, o.label)
+	}
+	return b.String()
 }
 
 func (service *FrontendService) htmxMoveImageHandler(ctx echo.Context) error {
@@ -279,47 +1111,833 @@ func (service *FrontendService) htmxMoveImageHandler(ctx echo.Context) error {
 	dir, ok := parseMoveDirection(ctx.QueryParam("dir"))
 	if id == "" || !ok {
 		slog.Warn("htmxMoveImageHandler: invalid params", "id", id, "dir", ctx.QueryParam("dir"))
-		return ctx.String(http.StatusBadRequest, "Invalid parameters")
+		return htmxError(ctx, http.StatusBadRequest, "Invalid parameters")
 	}
 
 	order, err := service.coreService.GetOrderedImageIDs(ctx.Request().Context())
 	if err != nil {
 		slog.Error("htmxMoveImageHandler: failed to get order", "error", err)
-		return ctx.String(http.StatusInternalServerError, "Failed to fetch order")
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to fetch order")
 	}
 	if len(order) == 0 {
-		return ctx.String(http.StatusBadRequest, "No images")
+		return htmxError(ctx, http.StatusBadRequest, "No images")
 	}
 
 	idx := sliceIndex(order, id)
 	if idx < 0 {
-		return ctx.String(http.StatusBadRequest, "Image not found")
+		return htmxError(ctx, http.StatusBadRequest, "Image not found")
 	}
 
 	order = cycleMove(order, idx, dir)
 
-	if err := service.coreService.UpdateImageOrder(ctx.Request().Context(), order); err != nil {
+	if err := service.coreService.ReorderImages(ctx.Request().Context(), order); err != nil {
+		if errors.Is(err, core.ErrRefreshTooSoon) {
+			return htmxError(ctx, http.StatusTooManyRequests, err.Error())
+		}
+		if errors.Is(err, core.ErrCurrentImageChanged) {
+			return htmxError(ctx, http.StatusConflict, "The current image changed in the meantime; reload the list and try again")
+		}
 		slog.Error("htmxMoveImageHandler: failed to update order", "error", err)
-		return ctx.String(http.StatusInternalServerError, "Failed to update order")
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to update order")
 	}
 
 	listHTML, err := service.buildImageListHTML(ctx.Request().Context())
 	if err != nil {
 		slog.Error("htmxMoveImageHandler: failed to rebuild image list", "error", err)
-		return ctx.String(http.StatusInternalServerError, "Failed to rebuild image list")
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to rebuild image list")
 	}
 
 	service.setNoCache(ctx)
 	return ctx.HTML(http.StatusOK, listHTML)
 }
 
-func (service *FrontendService) iconHandler(ctx echo.Context) error {
-	data, err := assetsFS.ReadFile("views/icon.svg")
+func (service *FrontendService) htmxScanImportHandler(ctx echo.Context) error {
+	dir := ctx.QueryParam("dir")
+	scan, err := service.coreService.ScanImportDirectory(dir)
 	if err != nil {
-		slog.Error("iconHandler: failed to read icon.svg", "status", http.StatusInternalServerError, "error", err)
-		return ctx.String(http.StatusInternalServerError, "Failed to load icon")
+		slog.Error("htmxScanImportHandler: failed to scan directory", "dir", dir, "error", err)
+		if errors.Is(err, core.ErrImportDirectoryNotAllowed) {
+			return htmxError(ctx, http.StatusForbidden, "Directory is not configured for bulk import")
+		}
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to scan directory")
 	}
-	// Cache for 7 days
-	ctx.Response().Header().Set("Cache-Control", "public, max-age=604800, immutable")
-	return ctx.Blob(http.StatusOK, "image/svg+xml", data)
+	service.setNoCache(ctx)
+	return ctx.HTML(http.StatusOK, buildImportScanHTML(scan))
+}
+
+// buildImportScanHTML renders the scanned files as a selectable preview list.
+func buildImportScanHTML(scan *core.ImportScan) string {
+	if len(scan.Files) == 0 {
+		return `<p>No images found.</p>`
+	}
+	dir := html.EscapeString(scan.Directory)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<form hx-post="/htmx/import" hx-target="#import-files" hx-swap="innerHTML">
+	<input type="hidden" name="dir" value="%s">
+	<label><input type="checkbox" onchange="this.form.querySelectorAll('input[name=file]').forEach((c) => c.checked = this.checked)"> Select all (%d files)</label>`,
+		dir, len(scan.Files))
+	if scan.Truncated {
+		b.WriteString(`<p><small>Only the first files are shown; move imported files away and scan again for the rest.</small></p>`)
+	}
+	b.WriteString(`<div style="display:grid;grid-template-columns:repeat(auto-fill,minmax(10rem,1fr));gap:0.5rem">`)
+	for _, f := range scan.Files {
+		p := html.EscapeString(f.Path)
+		previewURL := "/htmx/import/preview?dir=" + url.QueryEscape(scan.Directory) + "&path=" + url.QueryEscape(f.Path)
+		fmt.Fprintf(&b, `
+	<label style="display:block"><input type="checkbox" name="file" value="%s">
+		<img src="%s" alt="%s" loading="lazy" style="max-width:100%%;height:auto"><br><small>%s (%d KiB)</small>
+	</label>`, p, html.EscapeString(previewURL), p, p, (f.Size+1023)/1024)
+	}
+	b.WriteString(`
+	</div>
+	<label for="import-order">Order</label>
+	<select id="import-order" name="order">
+		<option value="given">As listed</option>
+		<option value="filename">By file name</option>
+		<option value="date">By date taken, oldest first</option>
+		<option value="date-desc">By date taken, newest first</option>
+		<option value="shuffle">Shuffled</option>
+	</select>
+	<button type="submit">Import selected</button>
+	<span class="htmx-indicator"><span class="loading-spinner" aria-hidden="true"></span> Importing...</span>
+</form>`)
+	return b.String()
+}
+
+func (service *FrontendService) htmxImportPreviewHandler(ctx echo.Context) error {
+	dir, file := ctx.QueryParam("dir"), ctx.QueryParam("path")
+	data, err := service.coreService.ReadImportFile(dir, file)
+	if err != nil {
+		slog.Warn("htmxImportPreviewHandler: file not available", "dir", dir, "path", file, "error", err)
+		return ctx.String(http.StatusNotFound, "File not available")
+	}
+	contentType := mime.TypeByExtension(strings.ToLower(path.Ext(file)))
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	return ctx.Blob(http.StatusOK, contentType, data)
+}
+
+func (service *FrontendService) htmxImportHandler(ctx echo.Context) error {
+	dir := ctx.FormValue("dir")
+	form, err := ctx.FormParams()
+	if err != nil || len(form["file"]) == 0 {
+		return ctx.HTML(http.StatusOK, `<p>Select at least one file to import.</p>`)
+	}
+
+	order, err := core.ParseImportOrder(ctx.FormValue("order"))
+	if err != nil {
+		return htmxError(ctx, http.StatusBadRequest, "Unknown import order")
+	}
+	results, err := service.coreService.ImportFiles(ctx.Request().Context(), dir, form["file"], order)
+	if err != nil {
+		slog.Error("htmxImportHandler: failed to import files", "dir", dir, "error", err)
+		if errors.Is(err, core.ErrImportDirectoryNotAllowed) {
+			return htmxError(ctx, http.StatusForbidden, "Directory is not configured for bulk import")
+		}
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to import files")
+	}
+
+	var b strings.Builder
+	imported := 0
+	for _, r := range results {
+		if r.Error == "" {
+			imported++
+		}
+	}
+	fmt.Fprintf(&b, `<p>Imported %d of %d files.</p>`, imported, len(results))
+	if imported < len(results) {
+		b.WriteString(`<ul>`)
+		for _, r := range results {
+			if r.Error != "" {
+				fmt.Fprintf(&b, `<li>%s: %s</li>`, html.EscapeString(r.Path), html.EscapeString(r.Error))
+			}
+		}
+		b.WriteString(`</ul>`)
+	}
+
+	if listHTML, err := service.buildImageListHTML(ctx.Request().Context()); err != nil {
+		slog.Error("htmxImportHandler: failed to list images for OOB update", "error", err)
+	} else {
+		fmt.Fprintf(&b, `<div id="image-list" hx-swap-oob="true">%s</div>`, listHTML)
+	}
+	return ctx.HTML(http.StatusOK, b.String())
+}
+
+// htmxSetImageTextHandler stores the title and description entered in an
+// image's details and re-renders the list it was shown in.
+func (service *FrontendService) htmxSetImageTextHandler(ctx echo.Context) error {
+	id := ctx.Param("id")
+	title, description := ctx.FormValue("title"), ctx.FormValue("description")
+	_, err := service.coreService.UpdateImageText(ctx.Request().Context(), id, core.ImageTextUpdate{Title: &title, Description: &description})
+	switch {
+	case errors.Is(err, core.ErrInvalidImageText):
+		return htmxError(ctx, http.StatusBadRequest, "The title or description is too long")
+	case errors.Is(err, core.ErrImageNotFound):
+		return htmxError(ctx, http.StatusNotFound, "Image not found")
+	case errors.Is(err, core.ErrImageLocked):
+		return htmxError(ctx, http.StatusConflict, "The image is being modified; try again shortly")
+	case err != nil:
+		slog.Error("htmxSetImageTextHandler: failed to update image text", "image_id", id, "error", err)
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to save title and description")
+	}
+
+	listHTML, err := service.buildListHTML(ctx.Request().Context(), ctx.FormValue("archived") == "true")
+	if err != nil {
+		slog.Error("htmxSetImageTextHandler: failed to rebuild image list", "error", err)
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to rebuild image list")
+	}
+	service.setNoCache(ctx)
+	return ctx.HTML(http.StatusOK, listHTML)
+}
+
+// htmxSetFitHandler stores the fit chosen in an image's details, reprocesses
+// the image and re-renders the list it was shown in.
+func (service *FrontendService) htmxSetFitHandler(ctx echo.Context) error {
+	id := ctx.Param("id")
+	err := service.coreService.SetImageFit(ctx.Request().Context(), id, ctx.FormValue("fit"))
+	switch {
+	case errors.Is(err, core.ErrInvalidFit):
+		return htmxError(ctx, http.StatusBadRequest, "Unknown fit")
+	case errors.Is(err, core.ErrImageNotFound):
+		return htmxError(ctx, http.StatusNotFound, "Image not found")
+	case errors.Is(err, core.ErrImageLocked):
+		return htmxError(ctx, http.StatusConflict, "The image is being modified; try again shortly")
+	case err != nil:
+		slog.Error("htmxSetFitHandler: failed to set fit", "image_id", id, "error", err)
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to save fit")
+	}
+
+	listHTML, err := service.buildListHTML(ctx.Request().Context(), ctx.FormValue("archived") == "true")
+	if err != nil {
+		slog.Error("htmxSetFitHandler: failed to rebuild image list", "error", err)
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to rebuild image list")
+	}
+	service.setNoCache(ctx)
+	return ctx.HTML(http.StatusOK, listHTML)
+}
+
+// htmxSetFlatArtHandler stores the flat-art preference chosen in an image's
+// details, reprocesses the image and re-renders the list it was shown in.
+func (service *FrontendService) htmxSetFlatArtHandler(ctx echo.Context) error {
+	id := ctx.Param("id")
+	err := service.coreService.SetImageFlatArt(ctx.Request().Context(), id, ctx.FormValue("flatArt"))
+	switch {
+	case errors.Is(err, core.ErrInvalidFlatArt):
+		return htmxError(ctx, http.StatusBadRequest, "Unknown flat-art preference")
+	case errors.Is(err, core.ErrImageNotFound):
+		return htmxError(ctx, http.StatusNotFound, "Image not found")
+	case errors.Is(err, core.ErrImageLocked):
+		return htmxError(ctx, http.StatusConflict, "The image is being modified; try again shortly")
+	case err != nil:
+		slog.Error("htmxSetFlatArtHandler: failed to set flat art", "image_id", id, "error", err)
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to save flat art")
+	}
+
+	listHTML, err := service.buildListHTML(ctx.Request().Context(), ctx.FormValue("archived") == "true")
+	if err != nil {
+		slog.Error("htmxSetFlatArtHandler: failed to rebuild image list", "error", err)
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to rebuild image list")
+	}
+	service.setNoCache(ctx)
+	return ctx.HTML(http.StatusOK, listHTML)
+}
+
+// htmxSetAltTextHandler stores the alt text entered in an image's details and
+// re-renders the list it was shown in.
+func (service *FrontendService) htmxSetAltTextHandler(ctx echo.Context) error {
+	id := ctx.Param("id")
+	err := service.coreService.SetAltText(ctx.Request().Context(), id, ctx.FormValue("altText"))
+	switch {
+	case errors.Is(err, core.ErrInvalidAltText):
+		return htmxError(ctx, http.StatusBadRequest, "The alt text is too long")
+	case errors.Is(err, core.ErrImageNotFound):
+		return htmxError(ctx, http.StatusNotFound, "Image not found")
+	case errors.Is(err, core.ErrImageLocked):
+		return htmxError(ctx, http.StatusConflict, "The image is being modified; try again shortly")
+	case err != nil:
+		slog.Error("htmxSetAltTextHandler: failed to set alt text", "image_id", id, "error", err)
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to save alt text")
+	}
+
+	listHTML, err := service.buildListHTML(ctx.Request().Context(), ctx.FormValue("archived") == "true")
+	if err != nil {
+		slog.Error("htmxSetAltTextHandler: failed to rebuild image list", "error", err)
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to rebuild image list")
+	}
+	service.setNoCache(ctx)
+	return ctx.HTML(http.StatusOK, listHTML)
+}
+
+func (service *FrontendService) htmxArchiveImageHandler(ctx echo.Context) error {
+	return service.setArchived(ctx, true)
+}
+
+func (service *FrontendService) htmxUnarchiveImageHandler(ctx echo.Context) error {
+	return service.setArchived(ctx, false)
+}
+
+// setArchived archives or unarchives the image and re-renders the list it was shown in.
+func (service *FrontendService) setArchived(ctx echo.Context, archived bool) error {
+	id := ctx.Param("id")
+	apply := service.coreService.UnarchiveImages
+	if archived {
+		apply = service.coreService.ArchiveImages
+	}
+	if err := apply(ctx.Request().Context(), []string{id}); err != nil {
+		if errors.Is(err, core.ErrImageLocked) {
+			return htmxError(ctx, http.StatusConflict, "The image is being modified; try again shortly")
+		}
+		slog.Error("setArchived: failed to update image", "image_id", id, "archived", archived, "error", err)
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to update image")
+	}
+
+	listHTML, err := service.buildListHTML(ctx.Request().Context(), !archived)
+	if err != nil {
+		slog.Error("setArchived: failed to rebuild image list", "error", err)
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to rebuild image list")
+	}
+	service.setNoCache(ctx)
+	return ctx.HTML(http.StatusOK, listHTML)
+}
+
+// htmxSeedDemoHandler adds the sample images and re-renders the schedule.
+func (service *FrontendService) htmxSeedDemoHandler(ctx echo.Context) error {
+	if _, err := service.coreService.SeedDemoImages(ctx.Request().Context()); err != nil {
+		slog.Error("htmxSeedDemoHandler: failed to seed demo images", "error", err)
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to load demo images")
+	}
+	return service.renderImageList(ctx)
+}
+
+// htmxRemoveDemoHandler deletes the sample images and re-renders the schedule.
+func (service *FrontendService) htmxRemoveDemoHandler(ctx echo.Context) error {
+	if _, err := service.coreService.RemoveDemoImages(ctx.Request().Context()); err != nil {
+		slog.Error("htmxRemoveDemoHandler: failed to remove demo images", "error", err)
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to remove demo images")
+	}
+	return service.renderImageList(ctx)
+}
+
+func (service *FrontendService) renderImageList(ctx echo.Context) error {
+	listHTML, err := service.buildImageListHTML(ctx.Request().Context())
+	if err != nil {
+		slog.Error("renderImageList: failed to rebuild image list", "error", err)
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to rebuild image list")
+	}
+	service.setNoCache(ctx)
+	return ctx.HTML(http.StatusOK, listHTML)
+}
+
+// buildPendingListHTML renders the approval inbox, newest first.
+func (service *FrontendService) buildPendingListHTML(ctx context.Context) (string, error) {
+	images, err := service.coreService.GetPendingImages(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(images) == 0 {
+		return `<p>No images awaiting approval.</p>`, nil
+	}
+
+	var b strings.Builder
+	b.WriteString(`<div class="vertical-list" role="list" aria-label="Images awaiting approval">`)
+	for _, img := range images {
+		controls := ""
+		if !service.config.ReadOnly {
+			controls = fmt.Sprintf(`
+		<div style="display:flex;gap:0.5rem">
+			<button hx-post="/htmx/pending/%s/approve" hx-target="#pending-list" hx-swap="innerHTML">Approve</button>
+			<button hx-post="/htmx/pending/%s/reject" hx-target="#pending-list" hx-swap="innerHTML" class="secondary">Reject</button>
+		</div>`, img.ID, img.ID)
+		}
+		source := img.Source
+		if source == "" {
+			source = "upload"
+		}
+		fmt.Fprintf(&b, `<div class="vertical-item" role="listitem" data-id="%s" style="margin-bottom:1rem"><article>%s
+	%s
+	<footer style="display:flex;gap:0.5rem;align-items:center;flex-wrap:wrap">
+		<small>From %s, added %s</small>%s%s%s
+	</footer>
+</article></div>`, img.ID, imageTextHTML(img), thumbnailHTML(img.ID, imageAltText(img, "Pending image "+img.ID)), html.EscapeString(source), service.coreService.DisplayFormat().Date(img.CreatedAt), rotationHTML(img.ID), attributionHTML(img.Attribution), controls)
+	}
+	b.WriteString(`</div>`)
+	return b.String(), nil
+}
+
+func (service *FrontendService) buildMatListHTML(ctx context.Context) (string, error) {
+	mats, err := service.coreService.GetMats(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(mats) == 0 {
+		return `<p>No mat configured.</p>`, nil
+	}
+
+	devices := make([]string, 0, len(mats))
+	for device := range mats {
+		devices = append(devices, device)
+	}
+	sort.Strings(devices)
+
+	var b strings.Builder
+	b.WriteString(`<table><thead><tr><th>Device</th><th>Color</th><th>Width</th><th>Corner radius</th><th></th></tr></thead><tbody>`)
+	for _, device := range devices {
+		mat := mats[device]
+		label := html.EscapeString(device)
+		if device == database.GlobalMat {
+			label = "<em>All devices</em>"
+		}
+		color := html.EscapeString(mat.Color)
+		fmt.Fprintf(&b, `<tr><td>%s</td><td><span style="display:inline-block;width:1rem;height:1rem;border:1px solid #888;background:%s;vertical-align:middle"></span> %s</td><td>%d px</td><td>%d px</td>
+	<td><button class="secondary" hx-delete="/htmx/mats?device=%s" hx-target="#mat-list" hx-swap="innerHTML">Remove</button></td></tr>`,
+			label, color, color, mat.Width, mat.CornerRadius, url.QueryEscape(device))
+	}
+	b.WriteString(`</tbody></table>`)
+	return b.String(), nil
+}
+
+// buildCalibrationHTML renders the calibration wizard: the chart to show on the
+// frame, a form to match each patch by eye, a form to upload a photo of the
+// panel and the devices calibrated so far.
+func (service *FrontendService) buildCalibrationHTML(ctx context.Context) (string, error) {
+	configured, err := service.coreService.GetCalibration(ctx, "")
+	if errors.Is(err, core.ErrInvalidCalibration) {
+		return `<p>Calibration needs a <code>DitherCommand</code> or an <code>AutoEnhanceCommand</code> with a palette in the pipeline.</p>`, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	palettes, err := service.coreService.GetPalettes(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString(`<ol>
+	<li><p>Show the chart on the frame, e.g. by pointing the frame at <a href="/api/calibration/chart.png" target="_blank">/api/calibration/chart.png</a>.</p>
+	<img src="/api/calibration/chart.png" alt="Calibration chart with one numbered patch per palette color" style="max-width:100%;max-height:12rem"></li>
+	<li><p>Pick the color each patch shows on the panel, judged next to this screen in daylight:</p>
+	<form hx-post="/htmx/calibration" hx-target="#calibration" hx-swap="innerHTML">
+		<input type="text" name="device" required placeholder="Device ID" aria-label="Device ID">
+		<div class="grid">`)
+	for i, c := range configured.Colors {
+		fmt.Fprintf(&b, `
+			<label>Patch %d <small>(%s)</small><input type="color" name="measured" value="%s"></label>`,
+			i+1, html.EscapeString(c.Device), html.EscapeString(c.Dither))
+	}
+	b.WriteString(`
+		</div>
+		<button type="submit">Save Calibration</button>
+	</form>
+	<p>Or photograph the panel, crop the photo to the edges of the chart and upload it:</p>
+	<form hx-post="/htmx/calibration/photo" hx-encoding="multipart/form-data" hx-target="#calibration" hx-swap="innerHTML">
+		<div class="grid">
+			<input type="text" name="device" required placeholder="Device ID" aria-label="Device ID">
+			<input type="file" name="photo" accept="image/*" required aria-label="Photo of the panel">
+		</div>
+		<button type="submit">Measure Photo</button>
+		<span class="htmx-indicator" role="status"><span class="loading-spinner" aria-hidden="true"></span> Measuring...</span>
+	</form></li>
+</ol>`)
+
+	if len(palettes) == 0 {
+		b.WriteString(`<p>No device calibrated.</p>`)
+		return b.String(), nil
+	}
+	devices := make([]string, 0, len(palettes))
+	for device := range palettes {
+		devices = append(devices, device)
+	}
+	sort.Strings(devices)
+	b.WriteString(`<table><thead><tr><th>Device</th><th>Palette</th><th>Calibrated</th><th></th></tr></thead><tbody>`)
+	for _, device := range devices {
+		palette := palettes[device]
+		var swatches strings.Builder
+		for _, c := range palette.Colors {
+			fmt.Fprintf(&swatches, `<span title="%s shows as %s" style="display:inline-block;width:1rem;height:1rem;border:1px solid #888;background:%s;vertical-align:middle"></span> `,
+				html.EscapeString(c.Device), html.EscapeString(c.Dither), html.EscapeString(c.Dither))
+		}
+		fmt.Fprintf(&b, `<tr><td>%s</td><td>%s</td><td>%s</td>
+	<td><button class="secondary" hx-delete="/htmx/calibration?device=%s" hx-target="#calibration" hx-swap="innerHTML">Remove</button></td></tr>`,
+			html.EscapeString(device), swatches.String(), service.coreService.DisplayFormat().Date(palette.CalibratedAt), url.QueryEscape(device))
+	}
+	b.WriteString(`</tbody></table>`)
+	return b.String(), nil
+}
+
+func (service *FrontendService) htmxCalibrationHandler(ctx echo.Context) error {
+	calibrationHTML, err := service.buildCalibrationHTML(ctx.Request().Context())
+	if err != nil {
+		slog.Error("htmxCalibrationHandler: failed to load calibration", "error", err)
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to load calibration")
+	}
+	service.setNoCache(ctx)
+	return ctx.HTML(http.StatusOK, calibrationHTML)
+}
+
+func (service *FrontendService) htmxCalibrateHandler(ctx echo.Context) error {
+	form, err := ctx.FormParams()
+	if err != nil {
+		return htmxError(ctx, http.StatusBadRequest, "Invalid form")
+	}
+	device := strings.TrimSpace(form.Get("device"))
+	_, err = service.coreService.CalibratePalette(ctx.Request().Context(), device, form["measured"])
+	return service.calibrationResult(ctx, device, err)
+}
+
+func (service *FrontendService) htmxCalibrateFromPhotoHandler(ctx echo.Context) error {
+	file, err := ctx.FormFile("photo")
+	if err != nil {
+		return htmxError(ctx, http.StatusBadRequest, "Choose a photo of the panel")
+	}
+	src, err := file.Open()
+	if err != nil {
+		slog.Error("htmxCalibrateFromPhotoHandler: failed to open photo", "error", err, "filename", file.Filename)
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to read photo")
+	}
+	defer func() { _ = src.Close() }()
+	photo, err := io.ReadAll(src)
+	if err != nil {
+		slog.Error("htmxCalibrateFromPhotoHandler: failed to read photo", "error", err, "filename", file.Filename)
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to read photo")
+	}
+	device := strings.TrimSpace(ctx.FormValue("device"))
+	_, err = service.coreService.CalibratePaletteFromPhoto(ctx.Request().Context(), device, photo)
+	return service.calibrationResult(ctx, device, err)
+}
+
+// calibrationResult answers a calibration form with the refreshed wizard.
+func (service *FrontendService) calibrationResult(ctx echo.Context, device string, err error) error {
+	if errors.Is(err, core.ErrInvalidCalibration) {
+		return htmxError(ctx, http.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		slog.Error("calibrationResult: failed to calibrate palette", "device", device, "error", err)
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to calibrate palette")
+	}
+	return service.htmxCalibrationHandler(ctx)
+}
+
+func (service *FrontendService) htmxDeleteCalibrationHandler(ctx echo.Context) error {
+	device := ctx.QueryParam("device")
+	if err := service.coreService.DeletePalette(ctx.Request().Context(), device); err != nil {
+		slog.Error("htmxDeleteCalibrationHandler: failed to delete calibration", "device", device, "error", err)
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to delete calibration")
+	}
+	return service.htmxCalibrationHandler(ctx)
+}
+
+func (service *FrontendService) htmxStorageHandler(ctx echo.Context) error {
+	report, err := service.coreService.GetStorageReport(ctx.Request().Context())
+	if err != nil {
+		slog.Error("htmxStorageHandler: failed to compute storage usage", "error", err)
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to compute storage usage")
+	}
+	service.setNoCache(ctx)
+	return ctx.HTML(http.StatusOK, service.buildStorageHTML(report, ""))
+}
+
+func (service *FrontendService) htmxCleanUpStorageHandler(ctx echo.Context) error {
+	cleanup, err := service.coreService.CleanUpStorage(ctx.Request().Context())
+	if err != nil {
+		slog.Error("htmxCleanUpStorageHandler: failed to clean up storage", "error", err)
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to clean up storage")
+	}
+	report, err := service.coreService.GetStorageReport(ctx.Request().Context())
+	if err != nil {
+		slog.Error("htmxCleanUpStorageHandler: failed to compute storage usage", "error", err)
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to compute storage usage")
+	}
+	status := fmt.Sprintf("Deleted %d unreferenced blobs and %d processed images, freeing %s.",
+		cleanup.TrashDeleted, cleanup.ProcessedDeleted, core.FormatBytes(cleanup.FreedBytes))
+	service.setNoCache(ctx)
+	return ctx.HTML(http.StatusOK, service.buildStorageHTML(report, status))
+}
+
+// buildStorageHTML renders the stored bytes per category, the storage warnings
+// and, unless read-only, the cleanup button. status reports a finished cleanup.
+func (service *FrontendService) buildStorageHTML(report *core.StorageReport, status string) string {
+	var b strings.Builder
+	for _, warning := range report.Warnings {
+		fmt.Fprintf(&b, `<p role="alert"><mark>%s</mark></p>`, html.EscapeString(warning))
+	}
+	if status != "" {
+		fmt.Fprintf(&b, `<p role="status"><small>%s</small></p>`, html.EscapeString(status))
+	}
+	b.WriteString(`<table><thead><tr><th>Category</th><th>Blobs</th><th>Size</th></tr></thead><tbody>`)
+	for _, row := range []struct {
+		label string
+		usage database.BlobUsage
+	}{
+		{"Originals", report.Originals},
+		{"Processed images", report.Processed},
+		{"Trash", report.Trash},
+	} {
+		fmt.Fprintf(&b, `<tr><td>%s</td><td>%d</td><td>%s</td></tr>`, row.label, row.usage.Count, core.FormatBytes(row.usage.Bytes))
+	}
+	total := core.FormatBytes(report.TotalBytes)
+	if report.LimitBytes > 0 {
+		total += " of " + core.FormatBytes(report.LimitBytes)
+	}
+	fmt.Fprintf(&b, `</tbody><tfoot><tr><th>Total</th><th></th><th>%s</th></tr></tfoot></table>`, total)
+	if report.LimitBytes > 0 {
+		fmt.Fprintf(&b, `<progress value="%d" max="%d" aria-label="Storage used"></progress>`, min(report.TotalBytes, report.LimitBytes), report.LimitBytes)
+	}
+	if !service.config.ReadOnly {
+		disabled := ""
+		if report.ReclaimableBytes == 0 {
+			disabled = " disabled"
+		}
+		fmt.Fprintf(&b, `<button hx-post="/htmx/storage/cleanup" hx-target="#storage-usage" hx-swap="innerHTML" hx-confirm="Delete the trash and regenerable processed images?" class="secondary"%s>Clean up %s</button>`,
+			disabled, core.FormatBytes(report.ReclaimableBytes))
+	}
+	return b.String()
+}
+
+func (service *FrontendService) htmxSourcesHandler(ctx echo.Context) error {
+	service.setNoCache(ctx)
+	return ctx.HTML(http.StatusOK, service.buildSourceListHTML())
+}
+
+func (service *FrontendService) htmxEnableSourceHandler(ctx echo.Context) error {
+	return service.setSourceEnabled(ctx, true)
+}
+
+func (service *FrontendService) htmxDisableSourceHandler(ctx echo.Context) error {
+	return service.setSourceEnabled(ctx, false)
+}
+
+// setSourceEnabled pauses or resumes an image source and re-renders the list.
+func (service *FrontendService) setSourceEnabled(ctx echo.Context, enabled bool) error {
+	name := ctx.Param("name")
+	if err := service.coreService.SetSourceEnabled(name, enabled); err != nil {
+		switch {
+		case errors.Is(err, core.ErrSourceNotFound):
+			return htmxError(ctx, http.StatusNotFound, "Image source not found")
+		case errors.Is(err, core.ErrSourceInvalid):
+			return htmxError(ctx, http.StatusConflict, "The image source is misconfigured; check the server log")
+		}
+		slog.Error("setSourceEnabled: failed to update image source", "source", name, "error", err)
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to update image source")
+	}
+	service.setNoCache(ctx)
+	return ctx.HTML(http.StatusOK, service.buildSourceListHTML())
+}
+
+// buildSourceListHTML renders the image sources with the result of their last
+// sync and, unless read-only, buttons to pause or resume them.
+func (service *FrontendService) buildSourceListHTML() string {
+	var b strings.Builder
+	b.WriteString(`<table><thead><tr><th>Source</th><th>Every</th><th>Last sync</th><th>Added</th><th></th></tr></thead><tbody>`)
+	for _, s := range service.coreService.GetSourceStatuses() {
+		lastSync := "never"
+		if !s.LastSync.IsZero() {
+			lastSync = service.coreService.DisplayFormat().DateTime(s.LastSync)
+		}
+		result := fmt.Sprintf("%d new, %d known", s.LastAdded, s.LastSkipped)
+		if s.LastError != "" {
+			result = fmt.Sprintf(`<mark title="%s">failed</mark>`, html.EscapeString(s.LastError))
+		}
+		control := ""
+		switch {
+		case service.config.ReadOnly:
+		case s.Enabled:
+			control = fmt.Sprintf(`<button hx-post="/htmx/sources/%s/disable" hx-target="#source-list" hx-swap="innerHTML" class="secondary outline">Pause</button>`, url.PathEscape(s.Name))
+		case s.LastError == "" || !s.LastSync.IsZero():
+			control = fmt.Sprintf(`<button hx-post="/htmx/sources/%s/enable" hx-target="#source-list" hx-swap="innerHTML" class="secondary">Resume</button>`, url.PathEscape(s.Name))
+		}
+		fmt.Fprintf(&b, `<tr><td>%s <small>(%s)</small></td><td>%s</td><td>%s<br><small>%s</small></td><td>%d</td><td>%s</td></tr>`,
+			html.EscapeString(s.Name), html.EscapeString(s.Type), time.Duration(s.IntervalSeconds)*time.Second,
+			lastSync, result, s.TotalAdded, control)
+	}
+	b.WriteString(`</tbody></table>`)
+	return b.String()
+}
+
+func (service *FrontendService) htmxDevicesHandler(ctx echo.Context) error {
+	devices, err := service.coreService.GetDevices(ctx.Request().Context())
+	if err != nil {
+		slog.Error("htmxDevicesHandler: failed to list devices", "error", err)
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to list frames")
+	}
+	service.setNoCache(ctx)
+	return ctx.HTML(http.StatusOK, service.buildDeviceListHTML(devices))
+}
+
+// buildDeviceListHTML renders the frames with whether they are alive, when
+// they last fetched an image and what they reported with it.
+func (service *FrontendService) buildDeviceListHTML(devices []core.DeviceStatus) string {
+	if len(devices) == 0 {
+		return `<p>No frames have registered or fetched an image with <code>?device=&lt;id&gt;</code> yet.</p>`
+	}
+	var b strings.Builder
+	b.WriteString(`<table><thead><tr><th>Frame</th><th>Status</th><th>Last fetch</th><th>Battery</th><th>Firmware</th></tr></thead><tbody>`)
+	for _, d := range devices {
+		name := html.EscapeString(d.ID)
+		if d.Name != "" {
+			name = fmt.Sprintf(`%s <small>(%s)</small>`, html.EscapeString(d.Name), html.EscapeString(d.ID))
+		}
+		if !d.Registered {
+			name += ` <small>unregistered</small>`
+		}
+		status, lastFetch := `<mark>silent</mark>`, "never"
+		if d.Alive {
+			status = "alive"
+		}
+		if d.LastSeen != nil {
+			lastFetch = service.coreService.DisplayFormat().DateTime(*d.LastSeen)
+		}
+		battery := "–"
+		if d.BatteryPercent != nil {
+			battery = fmt.Sprintf("%d%%", *d.BatteryPercent)
+		}
+		fmt.Fprintf(&b, `<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>`,
+			name, status, lastFetch, battery, html.EscapeString(cmp.Or(d.Firmware, "–")))
+	}
+	b.WriteString(`</tbody></table>`)
+	return b.String()
+}
+
+func (service *FrontendService) htmxListMatsHandler(ctx echo.Context) error {
+	matHTML, err := service.buildMatListHTML(ctx.Request().Context())
+	if err != nil {
+		slog.Error("htmxListMatsHandler: failed to list mats", "error", err)
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to list mats")
+	}
+	service.setNoCache(ctx)
+	return ctx.HTML(http.StatusOK, matHTML)
+}
+
+func (service *FrontendService) htmxSaveMatHandler(ctx echo.Context) error {
+	width, err1 := strconv.Atoi(ctx.FormValue("width"))
+	radius, err2 := strconv.Atoi(ctx.FormValue("cornerRadius"))
+	if err := errors.Join(err1, err2); err != nil {
+		return htmxError(ctx, http.StatusBadRequest, "Width and corner radius must be whole numbers")
+	}
+	device := strings.TrimSpace(ctx.FormValue("device"))
+	mat := database.Mat{Color: ctx.FormValue("color"), Width: width, CornerRadius: radius}
+	if err := service.coreService.SaveMat(ctx.Request().Context(), device, mat); err != nil {
+		if errors.Is(err, core.ErrInvalidMat) {
+			return htmxError(ctx, http.StatusBadRequest, err.Error())
+		}
+		slog.Error("htmxSaveMatHandler: failed to save mat", "device", device, "error", err)
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to save mat")
+	}
+	return service.htmxListMatsHandler(ctx)
+}
+
+func (service *FrontendService) htmxDeleteMatHandler(ctx echo.Context) error {
+	device := ctx.QueryParam("device")
+	if err := service.coreService.DeleteMat(ctx.Request().Context(), device); err != nil {
+		slog.Error("htmxDeleteMatHandler: failed to delete mat", "device", device, "error", err)
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to delete mat")
+	}
+	return service.htmxListMatsHandler(ctx)
+}
+
+func (service *FrontendService) htmxPendingImagesHandler(ctx echo.Context) error {
+	pendingHTML, err := service.buildPendingListHTML(ctx.Request().Context())
+	if err != nil {
+		slog.Error("htmxPendingImagesHandler: failed to list pending images", "error", err)
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to list pending images")
+	}
+	service.setNoCache(ctx)
+	return ctx.HTML(http.StatusOK, pendingHTML)
+}
+
+func (service *FrontendService) htmxApproveImageHandler(ctx echo.Context) error {
+	return service.moderate(ctx, service.coreService.ApproveImage)
+}
+
+func (service *FrontendService) htmxRejectImageHandler(ctx echo.Context) error {
+	return service.moderate(ctx, service.coreService.RejectImage)
+}
+
+// moderate applies an approval decision and re-renders the inbox, refreshing
+// the schedule out of band since approved images join the rotation.
+func (service *FrontendService) moderate(ctx echo.Context, apply func(context.Context, string) error) error {
+	id := ctx.Param("id")
+	if err := apply(ctx.Request().Context(), id); err != nil {
+		if errors.Is(err, core.ErrImageLocked) {
+			return htmxError(ctx, http.StatusConflict, "The image is being modified; try again shortly")
+		}
+		slog.Error("moderate: failed to update image", "image_id", id, "error", err)
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to update image")
+	}
+
+	pendingHTML, err := service.buildPendingListHTML(ctx.Request().Context())
+	if err != nil {
+		slog.Error("moderate: failed to rebuild pending list", "error", err)
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to rebuild pending list")
+	}
+	listHTML, err := service.buildImageListHTML(ctx.Request().Context())
+	if err != nil {
+		slog.Error("moderate: failed to rebuild image list", "error", err)
+		return htmxError(ctx, http.StatusInternalServerError, "Failed to rebuild image list")
+	}
+	service.setNoCache(ctx)
+	return ctx.HTML(http.StatusOK, fmt.Sprintf(`%s<div id="image-list" hx-swap-oob="true">%s</div>`, pendingHTML, listHTML))
+}
+
+func (service *FrontendService) htmxVersionHandler(ctx echo.Context) error {
+	v := service.coreService.GetVersion()
+	footer := fmt.Sprintf(`goframe %s (%s)`, html.EscapeString(v.Version), html.EscapeString(shortCommit(v.Commit)))
+	if v.UpdateAvailable {
+		footer += fmt.Sprintf(` &middot; <a href="%s" target="_blank" rel="noopener noreferrer">update available: %s</a>`,
+			html.EscapeString(v.ReleaseURL), html.EscapeString(v.LatestVersion))
+	}
+	service.setNoCache(ctx)
+	return ctx.HTML(http.StatusOK, footer)
+}
+
+// shortCommit abbreviates a git revision for display.
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
+
+func (service *FrontendService) iconHandler(ctx echo.Context) error {
+	data, err := fs.ReadFile(service.views, "icon.svg")
+	if err != nil {
+		slog.Error("iconHandler: failed to read icon.svg", "status", http.StatusInternalServerError, "error", err)
+		return ctx.String(http.StatusInternalServerError, "Failed to load icon")
+	}
+	// Cache for 7 days
+	service.setAssetCache(ctx, "public, max-age=604800, immutable")
+	return ctx.Blob(http.StatusOK, "image/svg+xml", data)
+}
+
+func (service *FrontendService) manifestHandler(ctx echo.Context) error {
+	data, err := fs.ReadFile(service.views, "manifest.webmanifest")
+	if err != nil {
+		slog.Error("manifestHandler: failed to read manifest.webmanifest", "status", http.StatusInternalServerError, "error", err)
+		return ctx.String(http.StatusInternalServerError, "Failed to load manifest")
+	}
+	service.setAssetCache(ctx, "public, max-age=86400")
+	return ctx.Blob(http.StatusOK, "application/manifest+json", data)
+}
+
+func (service *FrontendService) chartScriptHandler(ctx echo.Context) error {
+	data, err := fs.ReadFile(service.views, "chart.js")
+	if err != nil {
+		slog.Error("chartScriptHandler: failed to read chart.js", "status", http.StatusInternalServerError, "error", err)
+		return ctx.String(http.StatusInternalServerError, "Failed to load chart script")
+	}
+	service.setAssetCache(ctx, "public, max-age=86400")
+	return ctx.Blob(http.StatusOK, "text/javascript; charset=utf-8", data)
+}
+
+func (service *FrontendService) serviceWorkerHandler(ctx echo.Context) error {
+	data, err := fs.ReadFile(service.views, "sw.js")
+	if err != nil {
+		slog.Error("serviceWorkerHandler: failed to read sw.js", "status", http.StatusInternalServerError, "error", err)
+		return ctx.String(http.StatusInternalServerError, "Failed to load service worker")
+	}
+	// Browsers must always revalidate the worker so updates roll out promptly
+	service.setNoCache(ctx)
+	return ctx.Blob(http.StatusOK, "text/javascript; charset=utf-8", data)
 }
@@ -2,26 +2,115 @@ package frontend
 
 import (
 	"embed"
+	"fmt"
 	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
 	"text/template"
 
 	"github.com/labstack/echo/v4"
 )
 
-const viewsPattern = "views/*.html"
+const viewsPattern = "*.html"
 
-var (
-	//go:embed views/*.html
-	templateFS embed.FS
+// SourceViewsDir is where the templates and assets live in the source tree,
+// relative to the repository root; developer mode loads them from there.
+const SourceViewsDir = "internal/frontend/views"
 
-	//go:embed views/icon.svg
-	assetsFS embed.FS
-)
+//go:embed views/*.html views/icon.svg views/manifest.webmanifest views/sw.js views/chart.js
+var embeddedViews embed.FS
+
+// viewsFS returns the embedded templates and assets with the views directory as root.
+func viewsFS() fs.FS {
+	views, err := fs.Sub(embeddedViews, "views")
+	if err != nil {
+		panic(err)
+	}
+	return views
+}
 
 type Template struct {
 	templates *template.Template
+
+	// reload parses the templates in views again when one of them was added,
+	// removed or modified since they were last parsed (developer mode).
+	reload  bool
+	views   fs.FS
+	mu      sync.Mutex
+	version string
+}
+
+// newTemplate parses the templates in views.
+func newTemplate(views fs.FS, reload bool) (*Template, error) {
+	t := &Template{reload: reload, views: views}
+	if _, err := t.current(); err != nil {
+		return nil, err
+	}
+	return t, nil
 }
 
 func (t *Template) Render(w io.Writer, name string, data interface{}, c echo.Context) error {
-	return t.templates.ExecuteTemplate(w, name, data)
+	templates, err := t.current()
+	if err != nil {
+		return err
+	}
+	return templates.ExecuteTemplate(w, name, data)
+}
+
+// current returns the parsed templates, parsing them again first if reloading
+// is on and the files changed. A template that no longer parses is reported
+// on every render until it is fixed.
+func (t *Template) current() (*template.Template, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.templates != nil && !t.reload {
+		return t.templates, nil
+	}
+	version, err := viewsVersion(t.views)
+	if err != nil {
+		return nil, err
+	}
+	if t.templates != nil && version == t.version {
+		return t.templates, nil
+	}
+	templates, err := template.New("").ParseFS(t.views, viewsPattern)
+	if err != nil {
+		return nil, fmt.Errorf("parsing templates: %w", err)
+	}
+	if t.templates != nil {
+		slog.Info("Template: reloaded changed templates")
+	}
+	t.templates, t.version = templates, version
+	return templates, nil
+}
+
+// viewsVersion identifies the names, sizes and modification times of the
+// templates in views; it changes whenever one of them is saved.
+func viewsVersion(views fs.FS) (string, error) {
+	names, err := fs.Glob(views, viewsPattern)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, name := range names {
+		info, err := fs.Stat(views, name)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "%s:%d:%d;", name, info.Size(), info.ModTime().UnixNano())
+	}
+	return b.String(), nil
+}
+
+// diskViews opens dir as the views of developer mode after checking that it
+// holds the templates.
+func diskViews(dir string) (fs.FS, error) {
+	views := os.DirFS(dir)
+	if _, err := fs.Stat(views, MainPageName); err != nil {
+		return nil, fmt.Errorf("%s does not contain the frontend views: %w", dir, err)
+	}
+	return views, nil
 }
@@ -0,0 +1,94 @@
+// Minimal SVG charts for the Go Frame dashboard.
+// GoFrameChart.bar(el, labels, values, options) draws one bar per label.
+// GoFrameChart.line(el, labels, series, options) draws one line per series,
+// where series is [{ name, values }] aligned with labels.
+// options.format turns a value into the text used for the axis and tooltips.
+(() => {
+  const NS = "http://www.w3.org/2000/svg";
+  const WIDTH = 640;
+  const HEIGHT = 220;
+  const PAD = { top: 10, right: 10, bottom: 30, left: 60 };
+  const COLORS = ["#1095c1", "#d93526", "#398712", "#9236a4", "#e2a300", "#5a6a78"];
+
+  const node = (name, attrs, text) => {
+    const n = document.createElementNS(NS, name);
+    for (const [k, v] of Object.entries(attrs)) {
+      n.setAttribute(k, v);
+    }
+    if (text !== undefined) {
+      n.textContent = text;
+    }
+    return n;
+  };
+
+  // frame draws the axes and returns the SVG plus scale helpers.
+  const frame = (el, labels, max, format) => {
+    const svg = node("svg", { viewBox: `0 0 ${WIDTH} ${HEIGHT}`, width: "100%", role: "img" });
+    const plotW = WIDTH - PAD.left - PAD.right;
+    const plotH = HEIGHT - PAD.top - PAD.bottom;
+    const top = max > 0 ? max : 1;
+    const y = (v) => PAD.top + plotH - (v / top) * plotH;
+    const step = plotW / Math.max(labels.length, 1);
+    const x = (i) => PAD.left + step * i + step / 2;
+
+    svg.appendChild(node("line", { x1: PAD.left, y1: y(0), x2: WIDTH - PAD.right, y2: y(0), stroke: "currentColor", "stroke-opacity": 0.4 }));
+    for (const v of [0, top / 2, top]) {
+      svg.appendChild(node("text", { x: PAD.left - 6, y: y(v) + 4, "text-anchor": "end", "font-size": 11, fill: "currentColor" }, format(v)));
+    }
+    // Label at most ~8 ticks so dates stay readable.
+    const every = Math.max(1, Math.ceil(labels.length / 8));
+    labels.forEach((label, i) => {
+      if (i % every === 0) {
+        svg.appendChild(node("text", { x: x(i), y: HEIGHT - 10, "text-anchor": "middle", "font-size": 11, fill: "currentColor" }, label));
+      }
+    });
+    el.replaceChildren(svg);
+    return { svg, x, y, step };
+  };
+
+  const empty = (el) => {
+    el.textContent = "No data yet.";
+  };
+
+  const bar = (el, labels, values, options = {}) => {
+    if (labels.length === 0) {
+      return empty(el);
+    }
+    const format = options.format || String;
+    const { svg, x, y, step } = frame(el, labels, Math.max(...values), format);
+    const w = Math.max(step * 0.7, 1);
+    values.forEach((v, i) => {
+      const rect = node("rect", { x: x(i) - w / 2, y: y(v), width: w, height: y(0) - y(v), fill: options.color || COLORS[0] });
+      rect.appendChild(node("title", {}, `${labels[i]}: ${format(v)}`));
+      svg.appendChild(rect);
+    });
+  };
+
+  const line = (el, labels, series, options = {}) => {
+    if (labels.length === 0 || series.length === 0) {
+      return empty(el);
+    }
+    const format = options.format || String;
+    const max = Math.max(...series.flatMap((s) => s.values));
+    const { svg, x, y } = frame(el, labels, max, format);
+    series.forEach((s, n) => {
+      const color = COLORS[n % COLORS.length];
+      const points = s.values.map((v, i) => `${x(i)},${y(v)}`).join(" ");
+      svg.appendChild(node("polyline", { points, fill: "none", stroke: color, "stroke-width": 2 }));
+      s.values.forEach((v, i) => {
+        const dot = node("circle", { cx: x(i), cy: y(v), r: 3, fill: color });
+        dot.appendChild(node("title", {}, `${s.name} ${labels[i]}: ${format(v)}`));
+        svg.appendChild(dot);
+      });
+    });
+    if (series.length > 1) {
+      const legend = document.createElement("small");
+      legend.innerHTML = series
+        .map((s, n) => `<span style="color:${COLORS[n % COLORS.length]}">&#9632;</span> ${s.name.replace(/[&<>"]/g, (c) => `&#${c.charCodeAt(0)};`)}`)
+        .join(" &nbsp; ");
+      el.appendChild(legend);
+    }
+  };
+
+  window.GoFrameChart = { bar, line };
+})();
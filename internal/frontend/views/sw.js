@@ -0,0 +1,167 @@
+// Service worker for the Go Frame PWA.
+// - Caches the app shell, including htmx and pico from their CDNs, so the UI
+//   opens offline.
+// - Queues uploads made while offline in IndexedDB and replays them once the
+//   browser is back online (Background Sync where available, otherwise when a
+//   page reports the "online" event).
+
+const SHELL_CACHE = "goframe-shell-v2";
+const SHELL_URLS = ["/index.html", "/icon.svg", "/manifest.webmanifest"];
+// Must match the <link> and <script> tags of the pages.
+const CDN_URLS = [
+  "https://cdn.jsdelivr.net/npm/@picocss/pico@2/css/pico.min.css",
+  "https://unpkg.com/htmx.org/dist/htmx.min.js",
+];
+const UPLOAD_URL = "/htmx/uploadImage";
+const DB_NAME = "goframe-upload-queue";
+const STORE = "uploads";
+const SYNC_TAG = "goframe-upload-queue";
+
+self.addEventListener("install", (event) => {
+  event.waitUntil(
+    caches.open(SHELL_CACHE).then((cache) =>
+      Promise.all([
+        cache.addAll(SHELL_URLS),
+        // An unreachable CDN must not keep the worker from installing; the
+        // assets are cached on their next fetch instead.
+        ...CDN_URLS.map((url) => cache.add(url).catch(() => {})),
+      ])
+    )
+  );
+  self.skipWaiting();
+});
+
+self.addEventListener("activate", (event) => {
+  event.waitUntil(
+    caches.keys()
+      .then((keys) => Promise.all(keys.filter((k) => k !== SHELL_CACHE).map((k) => caches.delete(k))))
+      .then(() => self.clients.claim())
+  );
+});
+
+self.addEventListener("fetch", (event) => {
+  const url = new URL(event.request.url);
+  if (event.request.method === "GET" && CDN_URLS.includes(url.href)) {
+    // Cache first: the CDN assets only change with a new worker version.
+    event.respondWith(
+      caches.match(url.href).then(
+        (cached) =>
+          cached ||
+          fetch(url.href).then((resp) => {
+            if (resp.ok) {
+              const copy = resp.clone();
+              caches.open(SHELL_CACHE).then((cache) => cache.put(url.href, copy));
+            }
+            return resp;
+          })
+      )
+    );
+    return;
+  }
+  if (url.origin !== self.location.origin) {
+    return;
+  }
+  if (event.request.method === "POST" && url.pathname === UPLOAD_URL) {
+    event.respondWith(uploadOrQueue(event.request));
+    return;
+  }
+  if (event.request.method === "GET" && SHELL_URLS.includes(url.pathname)) {
+    // Network first so the UI stays fresh; fall back to the cached shell offline.
+    event.respondWith(
+      fetch(event.request)
+        .then((resp) => {
+          const copy = resp.clone();
+          caches.open(SHELL_CACHE).then((cache) => cache.put(event.request, copy));
+          return resp;
+        })
+        .catch(() => caches.match(event.request))
+    );
+  }
+});
+
+self.addEventListener("sync", (event) => {
+  if (event.tag === SYNC_TAG) {
+    event.waitUntil(flushQueue());
+  }
+});
+
+self.addEventListener("message", (event) => {
+  if (event.data === "flush-upload-queue") {
+    event.waitUntil(flushQueue());
+  }
+});
+
+async function uploadOrQueue(request) {
+  const body = await request.clone().formData();
+  try {
+    return await fetch(request);
+  } catch (err) {
+    await enqueue(body);
+    if (self.registration.sync) {
+      try {
+        await self.registration.sync.register(SYNC_TAG);
+      } catch (_) {
+        // Background Sync unavailable; the page triggers a flush when back online.
+      }
+    }
+    const count = await queueLength();
+    return new Response(
+      `<div id="upload-result">Offline: upload queued (${count} waiting). It will be sent when you are back online.</div>`,
+      { status: 200, headers: { "Content-Type": "text/html; charset=utf-8" } }
+    );
+  }
+}
+
+async function flushQueue() {
+  const db = await openDB();
+  const entries = await tx(db, "readonly", (store) => store.getAll());
+  for (const entry of entries) {
+    const form = new FormData();
+    for (const [key, value] of entry.fields) {
+      form.append(key, value);
+    }
+    let resp;
+    try {
+      resp = await fetch(UPLOAD_URL, { method: "POST", body: form });
+    } catch (_) {
+      return; // Still offline; keep the remaining entries.
+    }
+    // Drop entries the server rejected as well, otherwise they would block the queue forever.
+    if (resp.ok || (resp.status >= 400 && resp.status < 500)) {
+      await tx(db, "readwrite", (store) => store.delete(entry.id));
+    }
+  }
+  const clients = await self.clients.matchAll();
+  clients.forEach((client) => client.postMessage("upload-queue-flushed"));
+}
+
+async function enqueue(formData) {
+  const db = await openDB();
+  const fields = [];
+  for (const [key, value] of formData.entries()) {
+    fields.push([key, value]);
+  }
+  await tx(db, "readwrite", (store) => store.add({ fields, queuedAt: Date.now() }));
+}
+
+async function queueLength() {
+  const db = await openDB();
+  return tx(db, "readonly", (store) => store.count());
+}
+
+function openDB() {
+  return new Promise((resolve, reject) => {
+    const req = indexedDB.open(DB_NAME, 1);
+    req.onupgradeneeded = () => req.result.createObjectStore(STORE, { keyPath: "id", autoIncrement: true });
+    req.onsuccess = () => resolve(req.result);
+    req.onerror = () => reject(req.error);
+  });
+}
+
+function tx(db, mode, fn) {
+  return new Promise((resolve, reject) => {
+    const req = fn(db.transaction(STORE, mode).objectStore(STORE));
+    req.onsuccess = () => resolve(req.result);
+    req.onerror = () => reject(req.error);
+  });
+}
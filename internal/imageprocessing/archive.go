@@ -0,0 +1,80 @@
+package imageprocessing
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"log/slog"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// ArchiveCopy returns a reduced PNG suitable for keeping instead of a full-size
+// original: the long side is limited to maxDimension pixels (0 keeps the size),
+// each channel is quantized to bitsPerChannel bits (8 keeps full depth) and the
+// result is encoded with the best PNG compression. Fewer bits produce long runs
+// of equal bytes, which PNG compresses far better.
+func ArchiveCopy(imageData []byte, maxDimension, bitsPerChannel int) ([]byte, error) {
+	if bitsPerChannel < 1 || bitsPerChannel > 8 {
+		return nil, fmt.Errorf("bitsPerChannel must be between 1 and 8, got %d", bitsPerChannel)
+	}
+	if maxDimension < 0 {
+		return nil, fmt.Errorf("maxDimension must not be negative, got %d", maxDimension)
+	}
+
+	img, err := decodePNG(imageData)
+	if err != nil {
+		slog.Error("ArchiveCopy: failed to decode PNG image", "error", err)
+		return nil, fmt.Errorf("failed to decode PNG image: %w", err)
+	}
+
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if longSide := max(w, h); maxDimension > 0 && longSide > maxDimension {
+		w = max(1, w*maxDimension/longSide)
+		h = max(1, h*maxDimension/longSide)
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	if w == bounds.Dx() && h == bounds.Dy() {
+		xdraw.Draw(dst, dst.Bounds(), img, bounds.Min, xdraw.Src)
+	} else {
+		xdraw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, xdraw.Src, nil)
+	}
+
+	if bitsPerChannel < 8 {
+		quantizeChannels(dst, bitsPerChannel)
+	}
+
+	var buf bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := encoder.Encode(&buf, dst); err != nil {
+		slog.Error("ArchiveCopy: failed to encode image", "error", err)
+		return nil, fmt.Errorf("failed to encode PNG image: %w", err)
+	}
+
+	slog.Debug("ArchiveCopy: archive copy created",
+		"input_size_bytes", len(imageData), "output_size_bytes", buf.Len(),
+		"width", w, "height", h, "bits_per_channel", bitsPerChannel)
+	return buf.Bytes(), nil
+}
+
+// quantizeChannels reduces every RGB channel to bits bits, mapping each level
+// back onto the full 0-255 range. Alpha is left untouched.
+func quantizeChannels(img *image.NRGBA, bits int) {
+	levels := (1 << bits) - 1
+	var lut [256]uint8
+	for v := range lut {
+		level := (v*levels + 127) / 255
+		lut[v] = uint8(level * 255 / levels) // #nosec G115 -- result is within 0..255
+	}
+	parallelFor(img.Rect.Dy(), func(y int) {
+		row := img.Pix[y*img.Stride : y*img.Stride+img.Rect.Dx()*4]
+		for i := 0; i < len(row); i += 4 {
+			row[i] = lut[row[i]]
+			row[i+1] = lut[row[i+1]]
+			row[i+2] = lut[row[i+2]]
+		}
+	})
+}
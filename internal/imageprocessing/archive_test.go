@@ -0,0 +1,49 @@
+package imageprocessing
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestArchiveCopy_DownscalesLongSide(t *testing.T) {
+	out, err := ArchiveCopy(solidPNG(t, 400, 200, color.RGBA{R: 200, G: 100, B: 50, A: 255}), 100, 8)
+	if err != nil {
+		t.Fatalf("ArchiveCopy failed: %v", err)
+	}
+	img, err := decodePNG(out)
+	if err != nil {
+		t.Fatalf("failed to decode output: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 100 || b.Dy() != 50 {
+		t.Errorf("expected 100x50, got %dx%d", b.Dx(), b.Dy())
+	}
+}
+
+func TestArchiveCopy_KeepsSmallImagesAndQuantizes(t *testing.T) {
+	out, err := ArchiveCopy(solidPNG(t, 10, 20, color.RGBA{R: 200, G: 100, B: 50, A: 255}), 100, 1)
+	if err != nil {
+		t.Fatalf("ArchiveCopy failed: %v", err)
+	}
+	img, err := decodePNG(out)
+	if err != nil {
+		t.Fatalf("failed to decode output: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 10 || b.Dy() != 20 {
+		t.Errorf("expected size to be kept, got %dx%d", b.Dx(), b.Dy())
+	}
+	got := color.NRGBAModel.Convert(img.At(0, 0)).(color.NRGBA)
+	want := color.NRGBA{R: 255, G: 0, B: 0, A: 255}
+	if got != want {
+		t.Errorf("expected 1-bit quantization %v, got %v", want, got)
+	}
+}
+
+func TestArchiveCopy_InvalidParams(t *testing.T) {
+	data := solidPNG(t, 4, 4, color.White)
+	if _, err := ArchiveCopy(data, 100, 0); err == nil {
+		t.Error("expected error for bitsPerChannel 0")
+	}
+	if _, err := ArchiveCopy(data, -1, 8); err == nil {
+		t.Error("expected error for negative maxDimension")
+	}
+}
@@ -0,0 +1,94 @@
+package imageprocessing
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"log/slog"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	// attributionPadding is the space in pixels between the label text and its background box.
+	attributionPadding = 4
+	// attributionMargin is the space in pixels between the label box and the image edge.
+	attributionMargin = 8
+)
+
+// DrawAttributionOverlay renders text as a small black-on-white label in the
+// bottom-right corner of the PNG image. Black and white are used so the label
+// survives any subsequent palette reduction on e-ink panels. Text that does not
+// fit the image width is truncated with an ellipsis; images too small to hold a
+// label are returned unchanged.
+func DrawAttributionOverlay(imageData []byte, text string) ([]byte, error) {
+	if text == "" {
+		return imageData, nil
+	}
+
+	img, err := decodePNG(imageData)
+	if err != nil {
+		slog.Error("DrawAttributionOverlay: failed to decode PNG image", "error", err)
+		return nil, fmt.Errorf("failed to decode PNG image: %w", err)
+	}
+
+	face := basicfont.Face7x13
+	bounds := img.Bounds()
+	maxTextWidth := bounds.Dx() - 2*(attributionMargin+attributionPadding)
+	text = truncateToWidth(face, text, maxTextWidth)
+	if text == "" || bounds.Dy() < face.Height+2*(attributionMargin+attributionPadding) {
+		slog.Debug("DrawAttributionOverlay: image too small for label; skipping",
+			"width", bounds.Dx(), "height", bounds.Dy())
+		return imageData, nil
+	}
+
+	textWidth := font.MeasureString(face, text).Ceil()
+	boxWidth := textWidth + 2*attributionPadding
+	boxHeight := face.Height + 2*attributionPadding
+	box := image.Rect(
+		bounds.Max.X-attributionMargin-boxWidth,
+		bounds.Max.Y-attributionMargin-boxHeight,
+		bounds.Max.X-attributionMargin,
+		bounds.Max.Y-attributionMargin,
+	)
+
+	dst := image.NewRGBA(bounds)
+	draw.Draw(dst, bounds, img, bounds.Min, draw.Src)
+	draw.Draw(dst, box, &image.Uniform{color.White}, image.Point{}, draw.Src)
+
+	drawer := &font.Drawer{
+		Dst:  dst,
+		Src:  &image.Uniform{color.Black},
+		Face: face,
+		Dot:  fixed.P(box.Min.X+attributionPadding, box.Min.Y+attributionPadding+face.Ascent),
+	}
+	drawer.DrawString(text)
+
+	out, err := encodePNG(dst)
+	if err != nil {
+		slog.Error("DrawAttributionOverlay: failed to encode image", "error", err)
+		return nil, fmt.Errorf("failed to encode PNG image: %w", err)
+	}
+	return out, nil
+}
+
+// truncateToWidth shortens text so that it renders within maxWidth pixels,
+// appending "..." when characters had to be dropped. Returns "" when not even
+// the ellipsis fits.
+func truncateToWidth(face font.Face, text string, maxWidth int) string {
+	if font.MeasureString(face, text).Ceil() <= maxWidth {
+		return text
+	}
+	const ellipsis = "..."
+	runes := []rune(text)
+	for n := len(runes) - 1; n >= 0; n-- {
+		candidate := string(runes[:n]) + ellipsis
+		if font.MeasureString(face, candidate).Ceil() <= maxWidth {
+			return candidate
+		}
+	}
+	return ""
+}
@@ -0,0 +1,93 @@
+package imageprocessing
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"golang.org/x/image/font/basicfont"
+)
+
+func TestDrawAttributionOverlay_DrawsLabelBottomRight(t *testing.T) {
+	input := solidPNG(t, 200, 100, color.RGBA{R: 200, G: 0, B: 0, A: 255})
+
+	out, err := DrawAttributionOverlay(input, "Author | CC0")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	img, err := png.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("Failed to decode output: %v", err)
+	}
+	if img.Bounds().Dx() != 200 || img.Bounds().Dy() != 100 {
+		t.Fatalf("Expected dimensions to be preserved, got %v", img.Bounds())
+	}
+
+	// Top-left stays untouched.
+	if r, g, b, _ := img.At(0, 0).RGBA(); r>>8 != 200 || g != 0 || b != 0 {
+		t.Errorf("Expected top-left pixel to be unchanged, got %v", img.At(0, 0))
+	}
+
+	// The label box contains both white background and black text pixels.
+	var sawWhite, sawBlack bool
+	for y := 100 - attributionMargin - 21; y < 100-attributionMargin; y++ {
+		for x := 100; x < 200-attributionMargin; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			if r == 0xffff && g == 0xffff && b == 0xffff {
+				sawWhite = true
+			}
+			if r == 0 && g == 0 && b == 0 {
+				sawBlack = true
+			}
+		}
+	}
+	if !sawWhite || !sawBlack {
+		t.Errorf("Expected label with white background and black text, white=%v black=%v", sawWhite, sawBlack)
+	}
+}
+
+func TestDrawAttributionOverlay_EmptyTextReturnsInput(t *testing.T) {
+	input := solidPNG(t, 50, 50, color.White)
+
+	out, err := DrawAttributionOverlay(input, "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !bytes.Equal(out, input) {
+		t.Error("Expected input bytes to be returned unchanged")
+	}
+}
+
+func TestDrawAttributionOverlay_TooSmallReturnsInput(t *testing.T) {
+	input := solidPNG(t, 10, 10, color.White)
+
+	out, err := DrawAttributionOverlay(input, "Some author")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !bytes.Equal(out, input) {
+		t.Error("Expected input bytes to be returned unchanged for tiny image")
+	}
+}
+
+func TestDrawAttributionOverlay_InvalidPNG(t *testing.T) {
+	if _, err := DrawAttributionOverlay([]byte("not a png"), "text"); err == nil {
+		t.Error("Expected error for invalid PNG data")
+	}
+}
+
+func TestTruncateToWidth(t *testing.T) {
+	face7x13Width := 7
+	got := truncateToWidth(basicfont.Face7x13, "abcdefghij", 6*face7x13Width)
+	if got != "abc..." {
+		t.Errorf("Expected %q, got %q", "abc...", got)
+	}
+	if got := truncateToWidth(basicfont.Face7x13, "abc", 100); got != "abc" {
+		t.Errorf("Expected text to be unchanged, got %q", got)
+	}
+	if got := truncateToWidth(basicfont.Face7x13, "abc", 5); got != "" {
+		t.Errorf("Expected empty string when nothing fits, got %q", got)
+	}
+}
@@ -0,0 +1,315 @@
+package imageprocessing
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"log/slog"
+	"math"
+	"slices"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// analysisLongSide bounds the size of the copy that is inspected to choose a preset.
+const analysisLongSide = 256
+
+// EnhancementPreset bundles the adjust, sharpen and dither parameters that
+// AutoEnhanceCommand applies to an image.
+type EnhancementPreset struct {
+	Name string
+	// Stretch maps the 2nd..98th luma percentile onto the full tonal range.
+	Stretch bool
+	// Contrast scales tones around mid grey; 1 leaves them unchanged.
+	Contrast float64
+	// Saturation scales chroma around luma; 1 leaves colors unchanged.
+	Saturation float64
+	// Sharpen is the unsharp mask amount; 0 disables sharpening.
+	Sharpen float64
+	// DitheringAlgorithm is used when the command is configured with a palette.
+	DitheringAlgorithm string
+}
+
+// EnhancementPresets are the presets AutoEnhanceCommand chooses from.
+var EnhancementPresets = map[string]EnhancementPreset{
+	// Line art and comics: keep flat areas clean and edges crisp.
+	"graphic": {Name: "graphic", Contrast: 1, Saturation: 1, DitheringAlgorithm: "atkinson"},
+	// Washed-out photos: restore the tonal range first.
+	"flat": {Name: "flat", Stretch: true, Contrast: 1.1, Saturation: 1.2, Sharpen: 0.5, DitheringAlgorithm: "floyd-steinberg"},
+	// Dull colors: boost saturation so hues survive palette reduction.
+	"muted": {Name: "muted", Contrast: 1.1, Saturation: 1.5, Sharpen: 0.3, DitheringAlgorithm: "floyd-steinberg"},
+	// Blurry images: sharpen before dithering smears detail further.
+	"soft": {Name: "soft", Contrast: 1.05, Saturation: 1.1, Sharpen: 1, DitheringAlgorithm: "floyd-steinberg"},
+	// Everything else gets a light touch.
+	"balanced": {Name: "balanced", Contrast: 1, Saturation: 1.15, Sharpen: 0.3, DitheringAlgorithm: "floyd-steinberg"},
+}
+
+// ImageStats summarises the properties AutoEnhanceCommand bases its choice on.
+// All values are normalised to roughly 0..1.
+type ImageStats struct {
+	// Contrast is the spread between the 2nd and 98th luma percentile.
+	Contrast float64
+	// Colorfulness is the Hasler-Süsstrunk colorfulness metric divided by 255.
+	Colorfulness float64
+	// Sharpness is the mean absolute luma Laplacian divided by 255.
+	Sharpness float64
+	// lowLuma and highLuma are the 2nd and 98th luma percentiles.
+	lowLuma, highLuma uint8
+}
+
+// SelectEnhancementPreset picks the preset name that suits the given statistics.
+func SelectEnhancementPreset(stats ImageStats) string {
+	switch {
+	case stats.Colorfulness < 0.05 && stats.Sharpness > 0.08:
+		return "graphic"
+	case stats.Contrast < 0.5:
+		return "flat"
+	case stats.Colorfulness < 0.12:
+		return "muted"
+	case stats.Sharpness < 0.015:
+		return "soft"
+	default:
+		return "balanced"
+	}
+}
+
+// AnalyzeImage computes the statistics of img on a downscaled copy.
+func AnalyzeImage(img image.Image) ImageStats {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if longSide := max(w, h); longSide > analysisLongSide {
+		w = max(1, w*analysisLongSide/longSide)
+		h = max(1, h*analysisLongSide/longSide)
+	}
+	small := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(small, small.Bounds(), image.White, image.Point{}, draw.Src)
+	xdraw.ApproxBiLinear.Scale(small, small.Bounds(), img, b, xdraw.Over, nil)
+
+	var stats ImageStats
+	luma := make([]uint8, w*h)
+	var hist [256]int
+	var sumRG, sumYB, sumRG2, sumYB2 float64
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := small.NRGBAAt(x, y)
+			r, g, bl := float64(c.R), float64(c.G), float64(c.B)
+			l := lumaOf(c.R, c.G, c.B)
+			luma[y*w+x] = l
+			hist[l]++
+			rg, yb := r-g, 0.5*(r+g)-bl
+			sumRG += rg
+			sumYB += yb
+			sumRG2 += rg * rg
+			sumYB2 += yb * yb
+		}
+	}
+
+	n := float64(w * h)
+	meanRG, meanYB := sumRG/n, sumYB/n
+	sigma := math.Sqrt(max(0, sumRG2/n-meanRG*meanRG) + max(0, sumYB2/n-meanYB*meanYB))
+	stats.Colorfulness = (sigma + 0.3*math.Hypot(meanRG, meanYB)) / 255
+
+	stats.lowLuma, stats.highLuma = histogramPercentile(hist, w*h, 0.02), histogramPercentile(hist, w*h, 0.98)
+	stats.Contrast = float64(stats.highLuma-stats.lowLuma) / 255
+
+	if w > 2 && h > 2 {
+		var sum float64
+		for y := 1; y < h-1; y++ {
+			for x := 1; x < w-1; x++ {
+				lap := 4*int(luma[y*w+x]) - int(luma[y*w+x-1]) - int(luma[y*w+x+1]) - int(luma[(y-1)*w+x]) - int(luma[(y+1)*w+x])
+				sum += math.Abs(float64(lap))
+			}
+		}
+		stats.Sharpness = sum / float64((w-2)*(h-2)) / 255
+	}
+	return stats
+}
+
+// histogramPercentile returns the luma value below which fraction p of the pixels fall.
+func histogramPercentile(hist [256]int, total int, p float64) uint8 {
+	target := int(p * float64(total))
+	count := 0
+	for v, c := range hist {
+		count += c
+		if count > target {
+			return uint8(v) // #nosec G115 -- v < 256
+		}
+	}
+	return 255
+}
+
+// lumaOf returns the Rec. 601 luma of an sRGB color.
+func lumaOf(r, g, b uint8) uint8 {
+	return uint8((299*int(r) + 587*int(g) + 114*int(b) + 500) / 1000) // #nosec G115 -- weighted mean of bytes
+}
+
+// AutoEnhanceParams represents typed parameters for the auto enhancement command.
+type AutoEnhanceParams struct {
+	// Preset forces a preset by name; empty or "auto" inspects each image.
+	Preset string
+	// PalettePairs, when set, dithers the enhanced image with the preset's algorithm.
+	PalettePairs []ColorPair
+}
+
+// NewAutoEnhanceParamsFromMap creates AutoEnhanceParams from a generic map.
+func NewAutoEnhanceParamsFromMap(params map[string]any) (*AutoEnhanceParams, error) {
+	p := &AutoEnhanceParams{Preset: GetStringParam(params, "preset", "")}
+	if p.Preset == "auto" {
+		p.Preset = ""
+	}
+	if _, ok := EnhancementPresets[p.Preset]; p.Preset != "" && !ok {
+		names := make([]string, 0, len(EnhancementPresets))
+		for name := range EnhancementPresets {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		return nil, fmt.Errorf("unknown preset %q (expected auto or one of %v)", p.Preset, names)
+	}
+	if paletteParam, ok := params["palette"]; ok {
+		pairs, err := parsePalettePairs(paletteParam)
+		if err != nil {
+			return nil, fmt.Errorf("invalid palette: %w", err)
+		}
+		if len(pairs) == 0 || len(pairs) > 256 {
+			return nil, fmt.Errorf("palette must contain 1 to 256 colors")
+		}
+		p.PalettePairs = pairs
+	}
+	return p, nil
+}
+
+// AutoEnhanceCommand inspects the histogram, sharpness and colorfulness of an
+// image and applies the matching adjust, sharpen and (optionally) dither
+// preset. The chosen preset is reported by SelectedPreset so callers can store
+// it and pass it back as the "preset" parameter to reproduce the result.
+type AutoEnhanceCommand struct {
+	name     string
+	params   *AutoEnhanceParams
+	selected string
+}
+
+// NewAutoEnhanceCommand creates a new auto enhancement command from configuration parameters.
+func NewAutoEnhanceCommand(params map[string]any) (Command, error) {
+	typedParams, err := NewAutoEnhanceParamsFromMap(params)
+	if err != nil {
+		return nil, err
+	}
+	return &AutoEnhanceCommand{name: "AutoEnhanceCommand", params: typedParams}, nil
+}
+
+// Name returns the command name
+func (c *AutoEnhanceCommand) Name() string {
+	return c.name
+}
+
+// SelectedPreset returns the preset applied by the last Execute call.
+func (c *AutoEnhanceCommand) SelectedPreset() string {
+	return c.selected
+}
+
+// Execute chooses a preset for the PNG image and applies it.
+func (c *AutoEnhanceCommand) Execute(imageData []byte) ([]byte, error) {
+	return executeEncoded(c, imageData)
+}
+
+// ExecuteImage chooses a preset for the image and applies it.
+func (c *AutoEnhanceCommand) ExecuteImage(img image.Image) (image.Image, error) {
+	stats := AnalyzeImage(img)
+	name := c.params.Preset
+	if name == "" {
+		name = SelectEnhancementPreset(stats)
+	}
+	preset := EnhancementPresets[name]
+	c.selected = name
+	slog.Debug("AutoEnhanceCommand: selected preset", "preset", name,
+		"contrast", stats.Contrast, "colorfulness", stats.Colorfulness, "sharpness", stats.Sharpness)
+
+	var out image.Image = enhance(img, preset, stats)
+	if len(c.params.PalettePairs) > 0 {
+		devicePalette, ditherPalette := palettesFromPairs(c.params.PalettePairs)
+		var err error
+		if preset.DitheringAlgorithm == "atkinson" {
+			out, err = ditherAndMapAtkinson(out, ditherPalette, devicePalette, fullDitherStrength)
+		} else {
+			out, err = ditherAndMapFloydSteinberg(out, ditherPalette, devicePalette, fullDitherStrength)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// enhance applies the tonal, saturation and sharpening steps of preset.
+func enhance(img image.Image, preset EnhancementPreset, stats ImageStats) *image.NRGBA {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	src := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+
+	// Tone curve: optional levels stretch followed by contrast around mid grey.
+	var lut [256]uint8
+	low, high := 0.0, 255.0
+	if preset.Stretch && stats.highLuma > stats.lowLuma {
+		low, high = float64(stats.lowLuma), float64(stats.highLuma)
+	}
+	for v := range lut {
+		t := (float64(v) - low) / (high - low) * 255
+		lut[v] = clampByte((t-128)*preset.Contrast + 128)
+	}
+
+	adjusted := image.NewNRGBA(src.Bounds())
+	parallelFor(h, func(y int) {
+		for x := 0; x < w; x++ {
+			c := src.NRGBAAt(x, y)
+			r, g, bl := float64(lut[c.R]), float64(lut[c.G]), float64(lut[c.B])
+			l := 0.299*r + 0.587*g + 0.114*bl
+			adjusted.SetNRGBA(x, y, color.NRGBA{
+				R: clampByte(l + (r-l)*preset.Saturation),
+				G: clampByte(l + (g-l)*preset.Saturation),
+				B: clampByte(l + (bl-l)*preset.Saturation),
+				A: c.A,
+			})
+		}
+	})
+	if preset.Sharpen <= 0 || w < 3 || h < 3 {
+		return adjusted
+	}
+
+	// Unsharp mask against a 3x3 box blur; border pixels are left as they are.
+	sharpened := image.NewNRGBA(adjusted.Bounds())
+	copy(sharpened.Pix, adjusted.Pix)
+	parallelFor(h-2, func(row int) {
+		y := row + 1
+		for x := 1; x < w-1; x++ {
+			var sum [3]int
+			for dy := -1; dy <= 1; dy++ {
+				off := adjusted.PixOffset(x-1, y+dy)
+				for i := 0; i < 3; i++ {
+					sum[0] += int(adjusted.Pix[off+i*4])
+					sum[1] += int(adjusted.Pix[off+i*4+1])
+					sum[2] += int(adjusted.Pix[off+i*4+2])
+				}
+			}
+			off := adjusted.PixOffset(x, y)
+			for ch := 0; ch < 3; ch++ {
+				v := float64(adjusted.Pix[off+ch])
+				sharpened.Pix[off+ch] = clampByte(v + preset.Sharpen*(v-float64(sum[ch])/9))
+			}
+		}
+	})
+	return sharpened
+}
+
+// clampByte rounds v and clamps it to 0..255.
+func clampByte(v float64) uint8 {
+	return uint8(math.Round(min(255, max(0, v)))) // #nosec G115 -- clamped to 0..255
+}
+
+func init() {
+	// Register the command in the default registry
+	if err := DefaultRegistry.Register("AutoEnhanceCommand", NewAutoEnhanceCommand); err != nil {
+		panic(fmt.Sprintf("failed to register AutoEnhanceCommand: %v", err))
+	}
+}
@@ -0,0 +1,107 @@
+package imageprocessing
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestSelectEnhancementPreset(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(x, y int) color.NRGBA
+		want string
+	}{
+		{"line art", func(x, y int) color.NRGBA {
+			if x%4 == 0 {
+				return gray(0)
+			}
+			return gray(255)
+		}, "graphic"},
+		{"washed out", func(x, y int) color.NRGBA { return gray(100 + x/4) }, "flat"},
+		{"grey photo", func(x, y int) color.NRGBA { return gray(x * 255 / 199) }, "muted"},
+		{"blurry colors", func(x, y int) color.NRGBA {
+			v := uint8(x * 255 / 199)
+			return color.NRGBA{R: v, G: v, B: 255 - v, A: 255}
+		}, "soft"},
+		{"detailed colors", func(x, y int) color.NRGBA {
+			n := x*7919 + y*104729
+			return color.NRGBA{R: uint8(n), G: uint8(n >> 3), B: uint8(n >> 6), A: 255}
+		}, "balanced"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := decodePNG(patternPNG(t, 200, 100, tt.fn))
+			if err != nil {
+				t.Fatalf("decode failed: %v", err)
+			}
+			stats := AnalyzeImage(img)
+			if got := SelectEnhancementPreset(stats); got != tt.want {
+				t.Errorf("expected preset %q, got %q (stats %+v)", tt.want, got, stats)
+			}
+		})
+	}
+}
+
+func TestAutoEnhanceCommand_ReportsPresetAndDithers(t *testing.T) {
+	cmd, err := NewAutoEnhanceCommand(map[string]any{
+		"palette": []any{
+			[]any{[]any{0, 0, 0}, []any{0, 0, 0}},
+			[]any{[]any{255, 255, 255}, []any{255, 255, 255}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewAutoEnhanceCommand failed: %v", err)
+	}
+	out, err := cmd.Execute(patternPNG(t, 200, 100, func(x, y int) color.NRGBA { return gray(100 + x/4) }))
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if got := cmd.(PresetSelector).SelectedPreset(); got != "flat" {
+		t.Errorf("expected selected preset flat, got %q", got)
+	}
+
+	img, err := decodePNG(out)
+	if err != nil {
+		t.Fatalf("decode output failed: %v", err)
+	}
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, _ := img.At(x, y).RGBA()
+			if (r != 0 && r != 0xffff) || r != g || g != bl {
+				t.Fatalf("expected only palette colors, got %v at (%d,%d)", img.At(x, y), x, y)
+			}
+		}
+	}
+}
+
+func TestAutoEnhanceCommand_ForcedPreset(t *testing.T) {
+	cmd, err := NewAutoEnhanceCommand(map[string]any{"preset": "graphic"})
+	if err != nil {
+		t.Fatalf("NewAutoEnhanceCommand failed: %v", err)
+	}
+	input := solidPNG(t, 20, 10, color.RGBA{R: 90, G: 120, B: 200, A: 255})
+	if _, err := cmd.Execute(input); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if got := cmd.(PresetSelector).SelectedPreset(); got != "graphic" {
+		t.Errorf("expected forced preset graphic, got %q", got)
+	}
+
+	if _, err := NewAutoEnhanceCommand(map[string]any{"preset": "vivid"}); err == nil {
+		t.Error("expected error for unknown preset")
+	}
+}
+
+func TestExecuteCommandsWithPreset(t *testing.T) {
+	input := solidPNG(t, 20, 10, color.RGBA{R: 90, G: 120, B: 200, A: 255})
+	_, preset, err := ExecuteCommandsWithPreset(input, []CommandConfig{
+		{Name: "AutoEnhanceCommand", Params: map[string]any{"preset": "soft"}},
+	})
+	if err != nil {
+		t.Fatalf("ExecuteCommandsWithPreset failed: %v", err)
+	}
+	if preset != "soft" {
+		t.Errorf("expected preset soft, got %q", preset)
+	}
+}
@@ -211,7 +211,7 @@ func BenchmarkCropCommand_Execute(b *testing.B) {
 
 // makeLargePNG creates a synthetic PNG image of given size with a simple gradient.
 // Larger images better expose parallel speedups.
-func makeLargePNG(b *testing.B, width, height int) []byte {
+func makeLargePNG(b testing.TB, width, height int) []byte {
 	b.Helper()
 	img := image.NewRGBA(image.Rect(0, 0, width, height))
 	// Simple gradient fill
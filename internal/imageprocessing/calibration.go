@@ -0,0 +1,148 @@
+package imageprocessing
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"slices"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// maxCalibrationColumns bounds the number of patches per row of the calibration chart.
+const maxCalibrationColumns = 4
+
+// calibrationPatches lays out one patch per palette color within bounds, in
+// palette order, in rows of up to maxCalibrationColumns cells. Each patch is
+// inset from its cell so neighboring colors do not bleed into each other.
+func calibrationPatches(bounds image.Rectangle, n int) []image.Rectangle {
+	cols := min(n, maxCalibrationColumns)
+	rows := (n + cols - 1) / cols
+	w, h := bounds.Dx(), bounds.Dy()
+	patches := make([]image.Rectangle, n)
+	for i := range n {
+		col, row := i%cols, i/cols
+		cell := image.Rect(
+			bounds.Min.X+col*w/cols, bounds.Min.Y+row*h/rows,
+			bounds.Min.X+(col+1)*w/cols, bounds.Min.Y+(row+1)*h/rows,
+		)
+		patches[i] = cell.Inset(min(cell.Dx(), cell.Dy()) / 10)
+	}
+	return patches
+}
+
+// RenderCalibrationChart draws one numbered patch per device color of the
+// profile's palette on a background of the darkest color and encodes it as
+// PNG. Shown on the panel, it is matched by eye or photographed to calibrate
+// the dither colors; see MeasureCalibrationPhoto.
+func RenderCalibrationChart(profile PanelProfile) ([]byte, error) {
+	if profile.Width <= 0 || profile.Height <= 0 {
+		return nil, fmt.Errorf("invalid panel size %dx%d", profile.Width, profile.Height)
+	}
+	if len(profile.Palette) == 0 || len(profile.Palette) > 256 {
+		return nil, fmt.Errorf("palette must have between 1 and 256 colors, got %d", len(profile.Palette))
+	}
+	devicePalette, _ := palettesFromPairs(profile.Palette)
+	dark, light := darkestAndLightest(devicePalette)
+	bounds := image.Rect(0, 0, profile.Width, profile.Height)
+	img := image.NewPaletted(bounds, toColorPalette(devicePalette))
+	draw.Draw(img, bounds, &image.Uniform{dark}, image.Point{}, draw.Src)
+
+	face := basicfont.Face7x13
+	for i, patch := range calibrationPatches(bounds, len(devicePalette)) {
+		c := devicePalette[i]
+		draw.Draw(img, patch, &image.Uniform{c}, image.Point{}, draw.Src)
+		label := fmt.Sprintf("%d", i+1)
+		if font.MeasureString(face, label).Ceil() > patch.Dx()/4 || face.Height > patch.Dy()/4 {
+			continue
+		}
+		// Number the patches in a corner, outside the area that is measured.
+		ink := dark
+		if 299*int(c.R)+587*int(c.G)+114*int(c.B) < 128*1000 {
+			ink = light
+		}
+		drawer := &font.Drawer{
+			Dst:  img,
+			Src:  &image.Uniform{ink},
+			Face: face,
+			Dot:  fixed.P(patch.Min.X+attributionPadding, patch.Min.Y+attributionPadding+face.Ascent),
+		}
+		drawer.DrawString(label)
+	}
+	return encodePNGImage(img)
+}
+
+// MeasureCalibrationPhoto returns the color of each of the n patches of the
+// calibration chart in a photo of the panel. The photo must be cropped to the
+// edges of the chart; its aspect ratio may differ slightly, and the EXIF
+// orientation of camera JPEGs is applied. Each color is the
+// per-channel median of the central half of the patch, which ignores the
+// number, glare spots and the patch edges.
+func MeasureCalibrationPhoto(photo []byte, n int) ([]color.RGBA, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("invalid number of patches %d", n)
+	}
+	img, _, err := image.Decode(bytes.NewReader(photo))
+	if err != nil {
+		return nil, fmt.Errorf("decoding photo: %w", err)
+	}
+	img = ApplyOrientation(img, readOrientationSilently(photo))
+	bounds := img.Bounds()
+	measured := make([]color.RGBA, n)
+	for i, patch := range calibrationPatches(bounds, n) {
+		sample := patch.Inset(min(patch.Dx(), patch.Dy()) / 4)
+		if sample.Dx() < 2 || sample.Dy() < 2 {
+			return nil, fmt.Errorf("photo is too small (%dx%d) to measure %d patches", bounds.Dx(), bounds.Dy(), n)
+		}
+		measured[i] = medianColor(img, sample)
+	}
+	return measured, nil
+}
+
+// medianColor returns the per-channel median of img within r.
+func medianColor(img image.Image, r image.Rectangle) color.RGBA {
+	var rs, gs, bs []uint8
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			c := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+			rs, gs, bs = append(rs, c.R), append(gs, c.G), append(bs, c.B)
+		}
+	}
+	median := func(v []uint8) uint8 {
+		slices.Sort(v)
+		return v[len(v)/2]
+	}
+	return color.RGBA{R: median(rs), G: median(gs), B: median(bs), A: 255}
+}
+
+// DeriveCalibratedPalette replaces the dither colors of pairs with the colors
+// the panel was measured to show, one per pair in the same order. Photos and
+// screens differ in exposure and white balance, so the measurements are
+// scaled per channel such that the lightest one becomes pure white; the other
+// colors keep their appearance relative to the panel's white.
+func DeriveCalibratedPalette(pairs []ColorPair, measured []color.RGBA) ([]ColorPair, error) {
+	if len(measured) != len(pairs) {
+		return nil, fmt.Errorf("expected %d measured colors, got %d", len(pairs), len(measured))
+	}
+	_, white := darkestAndLightest(measured)
+	if white.R == 0 || white.G == 0 || white.B == 0 {
+		return nil, fmt.Errorf("the lightest measured color %s has an empty channel and cannot serve as white", FormatHexColor(white))
+	}
+	scale := func(v, w uint8) uint8 {
+		return uint8(min(math.Round(float64(v)*255/float64(w)), 255))
+	}
+	calibrated := make([]ColorPair, len(pairs))
+	for i, p := range pairs {
+		m := measured[i]
+		calibrated[i] = ColorPair{
+			Device: p.Device,
+			Dither: color.RGBA{R: scale(m.R, white.R), G: scale(m.G, white.G), B: scale(m.B, white.B), A: 255},
+		}
+	}
+	return calibrated, nil
+}
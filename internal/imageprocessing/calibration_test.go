@@ -0,0 +1,148 @@
+package imageprocessing
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func calibrationTestPalette() []ColorPair {
+	colors := []color.RGBA{
+		{A: 255},
+		{R: 255, G: 255, B: 255, A: 255},
+		{R: 255, A: 255},
+		{R: 255, G: 255, A: 255},
+		{B: 255, A: 255},
+	}
+	pairs := make([]ColorPair, len(colors))
+	for i, c := range colors {
+		pairs[i] = ColorPair{Device: c, Dither: c}
+	}
+	return pairs
+}
+
+func TestRenderCalibrationChart_DrawsOnePatchPerColor(t *testing.T) {
+	profile := PanelProfile{Width: 400, Height: 240, Palette: calibrationTestPalette()}
+	data, err := RenderCalibrationChart(profile)
+	if err != nil {
+		t.Fatalf("RenderCalibrationChart failed: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to decode chart: %v", err)
+	}
+	if img.Bounds().Dx() != 400 || img.Bounds().Dy() != 240 {
+		t.Fatalf("expected a 400x240 chart, got %v", img.Bounds())
+	}
+	for i, patch := range calibrationPatches(img.Bounds(), len(profile.Palette)) {
+		center := image.Pt((patch.Min.X+patch.Max.X)/2, (patch.Min.Y+patch.Max.Y)/2)
+		if got := color.RGBAModel.Convert(img.At(center.X, center.Y)); got != profile.Palette[i].Device {
+			t.Errorf("patch %d: expected %v, got %v", i, profile.Palette[i].Device, got)
+		}
+	}
+	if got := color.RGBAModel.Convert(img.At(0, 0)); got != (color.RGBA{A: 255}) {
+		t.Errorf("expected the darkest color as background, got %v", got)
+	}
+}
+
+func TestRenderCalibrationChart_InvalidProfile(t *testing.T) {
+	if _, err := RenderCalibrationChart(PanelProfile{Width: 0, Height: 10, Palette: calibrationTestPalette()}); err == nil {
+		t.Error("expected an error for an empty size")
+	}
+	if _, err := RenderCalibrationChart(PanelProfile{Width: 10, Height: 10}); err == nil {
+		t.Error("expected an error for an empty palette")
+	}
+}
+
+// photographChart simulates a photo of the chart: scaled to w x h and tinted
+// by a warm, dim light.
+func photographChart(t *testing.T, chart []byte, w, h int) []byte {
+	t.Helper()
+	src := mustDecode(t, chart)
+	sb := src.Bounds()
+	return patternPNG(t, w, h, func(x, y int) color.NRGBA {
+		c := color.RGBAModel.Convert(src.At(x*sb.Dx()/w, y*sb.Dy()/h)).(color.RGBA)
+		return color.NRGBA{R: uint8(int(c.R) * 90 / 100), G: uint8(int(c.G) * 80 / 100), B: uint8(int(c.B) * 60 / 100), A: 255}
+	})
+}
+
+func TestMeasureCalibrationPhoto_RecoversPatchColors(t *testing.T) {
+	pairs := calibrationTestPalette()
+	chart, err := RenderCalibrationChart(PanelProfile{Width: 400, Height: 240, Palette: pairs})
+	if err != nil {
+		t.Fatalf("RenderCalibrationChart failed: %v", err)
+	}
+	measured, err := MeasureCalibrationPhoto(photographChart(t, chart, 600, 380), len(pairs))
+	if err != nil {
+		t.Fatalf("MeasureCalibrationPhoto failed: %v", err)
+	}
+	want := []color.RGBA{
+		{A: 255},
+		{R: 229, G: 204, B: 153, A: 255},
+		{R: 229, A: 255},
+		{R: 229, G: 204, A: 255},
+		{B: 153, A: 255},
+	}
+	for i := range want {
+		if measured[i] != want[i] {
+			t.Errorf("patch %d: expected %v, got %v", i, want[i], measured[i])
+		}
+	}
+
+	calibrated, err := DeriveCalibratedPalette(pairs, measured)
+	if err != nil {
+		t.Fatalf("DeriveCalibratedPalette failed: %v", err)
+	}
+	for i, p := range calibrated {
+		if p.Device != pairs[i].Device || p.Dither != pairs[i].Dither {
+			t.Errorf("pair %d: expected the tint to be balanced out to %v, got %v", i, pairs[i], p)
+		}
+	}
+}
+
+func TestMeasureCalibrationPhoto_Errors(t *testing.T) {
+	if _, err := MeasureCalibrationPhoto([]byte("not an image"), 2); err == nil {
+		t.Error("expected an error for undecodable data")
+	}
+	if _, err := MeasureCalibrationPhoto(solidPNG(t, 4, 4, color.White), 8); err == nil {
+		t.Error("expected an error for a photo too small to measure")
+	}
+	if _, err := MeasureCalibrationPhoto(solidPNG(t, 40, 40, color.White), 0); err == nil {
+		t.Error("expected an error for zero patches")
+	}
+}
+
+func TestDeriveCalibratedPalette_KeepsColorsRelativeToWhite(t *testing.T) {
+	pairs := calibrationTestPalette()[:3]
+	measured := []color.RGBA{
+		{R: 40, G: 40, B: 50, A: 255},
+		{R: 200, G: 200, B: 200, A: 255},
+		{R: 150, G: 30, B: 20, A: 255},
+	}
+	calibrated, err := DeriveCalibratedPalette(pairs, measured)
+	if err != nil {
+		t.Fatalf("DeriveCalibratedPalette failed: %v", err)
+	}
+	want := []color.RGBA{
+		{R: 51, G: 51, B: 64, A: 255},
+		{R: 255, G: 255, B: 255, A: 255},
+		{R: 191, G: 38, B: 26, A: 255},
+	}
+	for i := range want {
+		if calibrated[i].Dither != want[i] || calibrated[i].Device != pairs[i].Device {
+			t.Errorf("pair %d: expected dither %v for device %v, got %+v", i, want[i], pairs[i].Device, calibrated[i])
+		}
+	}
+}
+
+func TestDeriveCalibratedPalette_Errors(t *testing.T) {
+	pairs := calibrationTestPalette()[:2]
+	if _, err := DeriveCalibratedPalette(pairs, []color.RGBA{{A: 255}}); err == nil {
+		t.Error("expected an error for a missing measurement")
+	}
+	if _, err := DeriveCalibratedPalette(pairs, []color.RGBA{{A: 255}, {R: 200, G: 200, A: 255}}); err == nil {
+		t.Error("expected an error for a white without blue")
+	}
+}
@@ -1,11 +1,34 @@
 package imageprocessing
 
+import (
+	"fmt"
+	"image"
+	"log/slog"
+)
+
 // Command defines the interface for all image processing commands.
 type Command interface {
 	Name() string
 	Execute(imageData []byte) ([]byte, error)
 }
 
+// ImageCommand is implemented by commands that work on decoded pixels. A
+// pipeline passes the image from one ImageCommand to the next without
+// encoding it in between, so it decodes and encodes the PNG only once.
+// ExecuteImage must not modify img; it returns img itself when the command
+// leaves the image unchanged. Execute stays available as an adapter that
+// decodes and encodes around ExecuteImage.
+type ImageCommand interface {
+	Command
+	ExecuteImage(img image.Image) (image.Image, error)
+}
+
+// PresetSelector is implemented by commands that choose their parameters per
+// image. SelectedPreset reports the choice made by the last Execute call.
+type PresetSelector interface {
+	SelectedPreset() string
+}
+
 // CommandFactory is a function type that creates a command from configuration parameters.
 type CommandFactory func(params map[string]any) (Command, error)
 
@@ -14,3 +37,27 @@ type CommandConfig struct {
 	Name   string
 	Params map[string]any
 }
+
+// executeEncoded implements Command.Execute for an ImageCommand: it decodes
+// the PNG, runs the command and encodes the result. When the command leaves
+// the image unchanged, imageData is returned as it is.
+func executeEncoded(c ImageCommand, imageData []byte) ([]byte, error) {
+	img, err := decodePNG(imageData)
+	if err != nil {
+		slog.Error(c.Name()+": failed to decode PNG image", "error", err)
+		return nil, fmt.Errorf("failed to decode PNG image: %w", err)
+	}
+	out, err := c.ExecuteImage(img)
+	if err != nil {
+		return nil, err
+	}
+	if out == img {
+		return imageData, nil
+	}
+	outBytes, err := encodePNG(out)
+	if err != nil {
+		slog.Error(c.Name()+": failed to encode image", "error", err)
+		return nil, fmt.Errorf("failed to encode PNG image: %w", err)
+	}
+	return outBytes, nil
+}
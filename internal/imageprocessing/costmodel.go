@@ -0,0 +1,201 @@
+package imageprocessing
+
+import (
+	"image"
+	"sync"
+	"time"
+)
+
+const (
+	// costSmoothing is the weight of a new run in a command's recorded cost.
+	costSmoothing = 0.2
+	// fallbackNanosPerPixel is assumed for commands without a recorded run or default.
+	fallbackNanosPerPixel = 30.0
+)
+
+// defaultNanosPerPixel are the costs of the built-in commands per input pixel
+// until runs on this host have been recorded, derived from the performance
+// budget cases (testdata/perf_baseline.json).
+var defaultNanosPerPixel = map[string]float64{
+	"AutoEnhanceCommand": 90,
+	"CropCommand":        11,
+	"DitherCommand":      65,
+	"OrientationCommand": 45,
+	"PixelScaleCommand":  11,
+	"ScaleCommand":       12,
+	"SharpenCommand":     60,
+}
+
+// extraBytesPerPixel is the working memory commands need per input pixel on
+// top of the decoded input and output images.
+var extraBytesPerPixel = map[string]int64{
+	// The separable blur keeps a float32 RGB copy.
+	"SharpenCommand": 12,
+	// Histograms are small, but the enhanced copy is kept next to the dithered one.
+	"AutoEnhanceCommand": 4,
+}
+
+// CommandCost is the performance model of one command.
+type CommandCost struct {
+	Command string `json:"command"`
+	// NanosPerPixel is the smoothed processing time per input pixel.
+	NanosPerPixel float64 `json:"nanosPerPixel"`
+	// Samples is the number of recorded runs; without any, NanosPerPixel is
+	// the built-in default.
+	Samples int `json:"samples"`
+}
+
+// costModel records how long commands take on this host.
+type costModel struct {
+	mu    sync.Mutex
+	costs map[string]*CommandCost
+}
+
+// pipelineCosts is the cost model fed by every pipeline run.
+var pipelineCosts = &costModel{costs: make(map[string]*CommandCost)}
+
+// record adds a run of command on an input of pixels that took d.
+func (m *costModel) record(command string, pixels int64, d time.Duration) {
+	if pixels <= 0 {
+		return
+	}
+	perPixel := float64(d.Nanoseconds()) / float64(pixels)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cost, ok := m.costs[command]
+	if !ok {
+		m.costs[command] = &CommandCost{Command: command, NanosPerPixel: perPixel, Samples: 1}
+		return
+	}
+	cost.NanosPerPixel += costSmoothing * (perPixel - cost.NanosPerPixel)
+	cost.Samples++
+}
+
+// cost returns the recorded cost of command, or its default.
+func (m *costModel) cost(command string) CommandCost {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if cost, ok := m.costs[command]; ok {
+		return *cost
+	}
+	if perPixel, ok := defaultNanosPerPixel[command]; ok {
+		return CommandCost{Command: command, NanosPerPixel: perPixel}
+	}
+	return CommandCost{Command: command, NanosPerPixel: fallbackNanosPerPixel}
+}
+
+// StepEstimate is the estimated cost of one pipeline step.
+type StepEstimate struct {
+	Command string
+	// Width and Height are the size of the step's input.
+	Width  int
+	Height int
+	// Duration is the estimated processing time.
+	Duration time.Duration
+	// PeakBytes is the estimated memory held while the step runs.
+	PeakBytes int64
+	// Cost is the performance model the estimate is based on.
+	Cost CommandCost
+}
+
+// PipelineEstimate is the estimated cost of running a pipeline on an image.
+type PipelineEstimate struct {
+	Steps []StepEstimate
+	// Duration is the sum of the steps' durations.
+	Duration time.Duration
+	// PeakBytes is the largest memory estimate of any step.
+	PeakBytes int64
+	// OutputWidth and OutputHeight are the size of the result.
+	OutputWidth  int
+	OutputHeight int
+}
+
+// EstimatePipeline estimates the time and memory configs take on an image of
+// width x height pixels, from the recorded cost of each command and the
+// image sizes the steps produce. No pixels are processed.
+func EstimatePipeline(configs []CommandConfig, width, height int) (*PipelineEstimate, error) {
+	estimate := &PipelineEstimate{Steps: make([]StepEstimate, 0, len(configs))}
+	for _, cfg := range configs {
+		outWidth, outHeight, err := stepOutputSize(cfg, width, height)
+		if err != nil {
+			return nil, err
+		}
+		in, out := int64(width)*int64(height), int64(outWidth)*int64(outHeight)
+		cost := pipelineCosts.cost(cfg.Name)
+		step := StepEstimate{
+			Command:   cfg.Name,
+			Width:     width,
+			Height:    height,
+			Duration:  time.Duration(cost.NanosPerPixel * float64(in)),
+			PeakBytes: (in+out)*4 + extraBytesPerPixel[cfg.Name]*in,
+			Cost:      cost,
+		}
+		estimate.Steps = append(estimate.Steps, step)
+		estimate.Duration += step.Duration
+		estimate.PeakBytes = max(estimate.PeakBytes, step.PeakBytes)
+		width, height = outWidth, outHeight
+	}
+	estimate.OutputWidth, estimate.OutputHeight = width, height
+	return estimate, nil
+}
+
+// stepOutputSize returns the size of the image cfg produces from one of
+// width x height pixels.
+func stepOutputSize(cfg CommandConfig, width, height int) (int, int, error) {
+	switch cfg.Name {
+	case "ScaleCommand":
+		params, err := NewScaleParamsFromMap(cfg.Params)
+		if err != nil {
+			return 0, 0, err
+		}
+		return params.Width, params.Height, nil
+	case "CropCommand":
+		params, err := NewCropParamsFromMap(cfg.Params)
+		if err != nil {
+			return 0, 0, err
+		}
+		return min(width, params.Width), min(height, params.Height), nil
+	case "SmartCropCommand":
+		params, err := NewSmartCropParamsFromMap(cfg.Params)
+		if err != nil {
+			return 0, 0, err
+		}
+		return min(width, params.Width), min(height, params.Height), nil
+	case "RegionCropCommand":
+		params, err := NewRegionCropParamsFromMap(cfg.Params)
+		if err != nil {
+			return 0, 0, err
+		}
+		region := params.rect(image.Rect(0, 0, width, height))
+		return region.Dx(), region.Dy(), nil
+	case "PixelScaleCommand":
+		params, err := NewPixelScaleParamsFromMap(cfg.Params)
+		if err != nil {
+			return 0, 0, err
+		}
+		switch {
+		case params.Width != nil && params.Height != nil:
+			return *params.Width, *params.Height, nil
+		case params.Width != nil:
+			return *params.Width, max(1, height*(*params.Width)/max(width, 1)), nil
+		default:
+			return max(1, width*(*params.Height)/max(height, 1)), *params.Height, nil
+		}
+	case "RotationCommand", "OrientationCommand":
+		turns, err := PipelineQuarterTurns([]CommandConfig{cfg}, width, height)
+		if err != nil {
+			return 0, 0, err
+		}
+		if turns%2 != 0 {
+			return height, width, nil
+		}
+	case "RotateCommand":
+		params, err := NewRotateParamsFromMap(cfg.Params)
+		if err != nil {
+			return 0, 0, err
+		}
+		outWidth, outHeight := rotatedSize(width, height, params.Degrees)
+		return outWidth, outHeight, nil
+	}
+	return width, height, nil
+}
@@ -0,0 +1,59 @@
+package imageprocessing
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEstimatePipeline_FollowsImageSize(t *testing.T) {
+	configs := []CommandConfig{
+		{Name: "OrientationCommand", Params: map[string]any{"orientation": "portrait"}},
+		{Name: "PixelScaleCommand", Params: map[string]any{"width": 1000}},
+		{Name: "EstimateTestCommand"},
+	}
+	estimate, err := EstimatePipeline(configs, 4000, 3000)
+	if err != nil {
+		t.Fatalf("EstimatePipeline failed: %v", err)
+	}
+	if len(estimate.Steps) != 3 {
+		t.Fatalf("expected 3 steps, got %d", len(estimate.Steps))
+	}
+	if s := estimate.Steps[1]; s.Width != 3000 || s.Height != 4000 {
+		t.Errorf("expected the turned image to be scaled, got %dx%d", s.Width, s.Height)
+	}
+	if estimate.OutputWidth != 1000 || estimate.OutputHeight != 1333 {
+		t.Errorf("expected a 1000x1333 result, got %dx%d", estimate.OutputWidth, estimate.OutputHeight)
+	}
+	// Turning 12 MP holds the input and the turned copy.
+	if want := int64(2 * 12_000_000 * 4); estimate.PeakBytes != want {
+		t.Errorf("expected peak memory %d, got %d", want, estimate.PeakBytes)
+	}
+	if want := time.Duration(fallbackNanosPerPixel * 1000 * 1333); estimate.Steps[2].Duration != want {
+		t.Errorf("expected the fallback cost for an unknown command, got %v", estimate.Steps[2].Duration)
+	}
+	var sum time.Duration
+	for _, s := range estimate.Steps {
+		sum += s.Duration
+	}
+	if estimate.Duration != sum {
+		t.Errorf("expected the total to be the sum of the steps, got %v and %v", estimate.Duration, sum)
+	}
+}
+
+func TestCostModel_RecordsRuns(t *testing.T) {
+	model := &costModel{costs: make(map[string]*CommandCost)}
+	if got := model.cost("ScaleCommand").NanosPerPixel; got != defaultNanosPerPixel["ScaleCommand"] {
+		t.Errorf("expected the default before any run, got %g", got)
+	}
+	model.record("ScaleCommand", 1000, 100*time.Microsecond)
+	if got := model.cost("ScaleCommand").NanosPerPixel; got != 100 {
+		t.Errorf("expected the first run to replace the default, got %g", got)
+	}
+	model.record("ScaleCommand", 1000, 200*time.Microsecond)
+	if got := model.cost("ScaleCommand").NanosPerPixel; got != 120 {
+		t.Errorf("expected later runs to be smoothed in, got %g", got)
+	}
+	if model.costs["ScaleCommand"].Samples != 2 {
+		t.Errorf("expected 2 samples, got %d", model.costs["ScaleCommand"].Samples)
+	}
+}
@@ -1,11 +1,9 @@
 package imageprocessing
 
 import (
-	"bytes"
 	"fmt"
 	"image"
 	"image/draw"
-	"image/png"
 	"log/slog"
 
 )
@@ -82,24 +80,19 @@ func (c *CropCommand) Name() string {
 	return c.name
 }
 
-// Execute crops the image to the configured dimensions
+// Execute crops the PNG image to the configured dimensions
 func (c *CropCommand) Execute(imageData []byte) ([]byte, error) {
-	slog.Debug("CropCommand: decoding image",
-		"input_size_bytes", len(imageData))
-
-	// Decode the PNG image
-	img, err := png.Decode(bytes.NewReader(imageData))
-	if err != nil {
-		slog.Error("CropCommand: failed to decode PNG image", "error", err)
-		return nil, fmt.Errorf("failed to decode PNG image: %w", err)
-	}
+	return executeEncoded(c, imageData)
+}
 
+// ExecuteImage crops the image to the configured dimensions
+func (c *CropCommand) ExecuteImage(img image.Image) (image.Image, error) {
 	// Get original dimensions
 	bounds := img.Bounds()
 	originalWidth := bounds.Dx()
 	originalHeight := bounds.Dy()
 
-	slog.Debug("CropCommand: image decoded",
+	slog.Debug("CropCommand: cropping image",
 		"original_width", originalWidth,
 		"original_height", originalHeight,
 		"target_width", c.params.Width,
@@ -112,7 +105,7 @@ func (c *CropCommand) Execute(imageData []byte) ([]byte, error) {
 	// If requested dimensions are larger than original, return original
 	if cropWidth >= originalWidth && cropHeight >= originalHeight {
 		slog.Debug("CropCommand: no crop needed, dimensions already smaller or equal")
-		return imageData, nil
+		return img, nil
 	}
 
 	// Limit crop dimensions to original size
@@ -142,24 +135,10 @@ func (c *CropCommand) Execute(imageData []byte) ([]byte, error) {
 	// Create a new image with the cropped region
 	croppedImg := image.NewRGBA(image.Rect(0, 0, cropWidth, cropHeight))
 	// Use draw.Draw with a source offset for a faster crop than per-pixel loops
-	draw.Draw(croppedImg, croppedImg.Bounds(), img, image.Point{X: x0, Y: y0}, draw.Src)
-
-	slog.Debug("CropCommand: encoding cropped image")
-
-	// Encode the cropped image back to PNG bytes
-	var buf bytes.Buffer
-	bb := croppedImg.Bounds()
-	buf.Grow(bb.Dx() * bb.Dy())
-	err = png.Encode(&buf, croppedImg)
-	if err != nil {
-		slog.Error("CropCommand: failed to encode cropped image", "error", err)
-		return nil, fmt.Errorf("failed to encode cropped PNG image: %w", err)
-	}
-
-	slog.Debug("CropCommand: crop complete",
-		"output_size_bytes", buf.Len())
+	draw.Draw(croppedImg, croppedImg.Bounds(), img, bounds.Min.Add(image.Point{X: x0, Y: y0}), draw.Src)
 
-	return buf.Bytes(), nil
+	slog.Debug("CropCommand: crop complete")
+	return croppedImg, nil
 }
 
 // GetHeight returns the configured height
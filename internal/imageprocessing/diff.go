@@ -0,0 +1,142 @@
+package imageprocessing
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"log/slog"
+)
+
+// PatchRect is a changed region of an image with its new pixels encoded as PNG.
+type PatchRect struct {
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	PNG    []byte `json:"png"`
+}
+
+// Patch describes how to turn one image into another of the same size.
+// Full is set when the images differ in size (or no base image is known), in
+// which case Rects holds the complete new image.
+type Patch struct {
+	Width  int         `json:"width"`
+	Height int         `json:"height"`
+	Full   bool        `json:"full"`
+	Rects  []PatchRect `json:"rects"`
+}
+
+// ComputePatch diffs two PNG images and returns the changed rectangles of next.
+// Changed rows are grouped into horizontal bands, each reduced to the
+// horizontal extent of its changes; bands are merged until at most maxRects
+// remain. A nil prev produces a full patch.
+func ComputePatch(prev, next []byte, maxRects int) (*Patch, error) {
+	if maxRects < 1 {
+		return nil, fmt.Errorf("maxRects must be positive, got %d", maxRects)
+	}
+	nextImg, err := decodeToNRGBA(next)
+	if err != nil {
+		return nil, err
+	}
+	w, h := nextImg.Rect.Dx(), nextImg.Rect.Dy()
+	patch := &Patch{Width: w, Height: h, Rects: []PatchRect{}}
+
+	var prevImg *image.NRGBA
+	if prev != nil {
+		if prevImg, err = decodeToNRGBA(prev); err != nil {
+			return nil, err
+		}
+	}
+	if prevImg == nil || prevImg.Rect.Dx() != w || prevImg.Rect.Dy() != h {
+		patch.Full = true
+		rect, err := encodePatchRect(nextImg, nextImg.Rect)
+		if err != nil {
+			return nil, err
+		}
+		patch.Rects = append(patch.Rects, rect)
+		return patch, nil
+	}
+
+	for _, r := range mergeBands(changedBands(prevImg, nextImg), maxRects) {
+		rect, err := encodePatchRect(nextImg, r)
+		if err != nil {
+			return nil, err
+		}
+		patch.Rects = append(patch.Rects, rect)
+	}
+	slog.Debug("ComputePatch: diff complete", "width", w, "height", h, "rects", len(patch.Rects))
+	return patch, nil
+}
+
+func decodeToNRGBA(data []byte) (*image.NRGBA, error) {
+	img, err := decodePNG(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode PNG image: %w", err)
+	}
+	b := img.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	return dst, nil
+}
+
+// changedBands returns one rectangle per run of consecutive changed rows,
+// spanning the leftmost to rightmost changed pixel within the run.
+func changedBands(a, b *image.NRGBA) []image.Rectangle {
+	w, h := b.Rect.Dx(), b.Rect.Dy()
+	minX := make([]int, h)
+	maxX := make([]int, h)
+	parallelFor(h, func(y int) {
+		minX[y], maxX[y] = -1, -1
+		rowA := a.Pix[y*a.Stride : y*a.Stride+w*4]
+		rowB := b.Pix[y*b.Stride : y*b.Stride+w*4]
+		for x := 0; x < w; x++ {
+			i := x * 4
+			if rowA[i] != rowB[i] || rowA[i+1] != rowB[i+1] || rowA[i+2] != rowB[i+2] || rowA[i+3] != rowB[i+3] {
+				if minX[y] < 0 {
+					minX[y] = x
+				}
+				maxX[y] = x
+			}
+		}
+	})
+
+	var bands []image.Rectangle
+	for y := 0; y < h; y++ {
+		if minX[y] < 0 {
+			continue
+		}
+		if n := len(bands); n > 0 && bands[n-1].Max.Y == y {
+			last := &bands[n-1]
+			last.Min.X = min(last.Min.X, minX[y])
+			last.Max.X = max(last.Max.X, maxX[y]+1)
+			last.Max.Y = y + 1
+			continue
+		}
+		bands = append(bands, image.Rect(minX[y], y, maxX[y]+1, y+1))
+	}
+	return bands
+}
+
+// mergeBands repeatedly joins the two vertically closest bands until at most
+// maxRects remain.
+func mergeBands(bands []image.Rectangle, maxRects int) []image.Rectangle {
+	for len(bands) > maxRects {
+		best := 0
+		for i := 1; i < len(bands)-1; i++ {
+			if bands[i+1].Min.Y-bands[i].Max.Y < bands[best+1].Min.Y-bands[best].Max.Y {
+				best = i
+			}
+		}
+		bands[best] = bands[best].Union(bands[best+1])
+		bands = append(bands[:best+1], bands[best+2:]...)
+	}
+	return bands
+}
+
+func encodePatchRect(img *image.NRGBA, r image.Rectangle) (PatchRect, error) {
+	data, err := encodePNG(img.SubImage(r))
+	if err != nil {
+		return PatchRect{}, fmt.Errorf("failed to encode patch: %w", err)
+	}
+	return PatchRect{X: r.Min.X, Y: r.Min.Y, Width: r.Dx(), Height: r.Dy(), PNG: data}, nil
+}
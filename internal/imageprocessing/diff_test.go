@@ -0,0 +1,74 @@
+package imageprocessing
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+// pngWithRects encodes a white image with the black rectangles rects.
+func pngWithRects(t *testing.T, w, h int, rects ...image.Rectangle) []byte {
+	t.Helper()
+	return patternPNG(t, w, h, func(x, y int) color.NRGBA {
+		for _, r := range rects {
+			if (image.Point{X: x, Y: y}).In(r) {
+				return gray(0)
+			}
+		}
+		return gray(255)
+	})
+}
+
+func TestComputePatch_ChangedRegions(t *testing.T) {
+	prev := pngWithRects(t, 40, 40)
+	next := pngWithRects(t, 40, 40, image.Rect(2, 3, 5, 6), image.Rect(10, 30, 20, 32))
+
+	patch, err := ComputePatch(prev, next, 4)
+	if err != nil {
+		t.Fatalf("ComputePatch failed: %v", err)
+	}
+	if patch.Full || len(patch.Rects) != 2 {
+		t.Fatalf("expected 2 partial rects, got full=%v rects=%d", patch.Full, len(patch.Rects))
+	}
+	first := patch.Rects[0]
+	if first.X != 2 || first.Y != 3 || first.Width != 3 || first.Height != 3 {
+		t.Errorf("unexpected first rect: %+v", first)
+	}
+	cfg, err := png.DecodeConfig(bytes.NewReader(first.PNG))
+	if err != nil || cfg.Width != 3 || cfg.Height != 3 {
+		t.Errorf("expected 3x3 patch PNG, got %dx%d (err %v)", cfg.Width, cfg.Height, err)
+	}
+
+	merged, err := ComputePatch(prev, next, 1)
+	if err != nil {
+		t.Fatalf("ComputePatch failed: %v", err)
+	}
+	if len(merged.Rects) != 1 {
+		t.Fatalf("expected rects to be merged to 1, got %d", len(merged.Rects))
+	}
+	if r := merged.Rects[0]; r.X != 2 || r.Y != 3 || r.Width != 18 || r.Height != 29 {
+		t.Errorf("unexpected merged rect: %+v", r)
+	}
+}
+
+func TestComputePatch_IdenticalAndResized(t *testing.T) {
+	img := pngWithRects(t, 10, 10, image.Rect(1, 1, 2, 2))
+
+	same, err := ComputePatch(img, img, 4)
+	if err != nil {
+		t.Fatalf("ComputePatch failed: %v", err)
+	}
+	if same.Full || len(same.Rects) != 0 {
+		t.Errorf("expected empty patch for identical images, got %+v", same)
+	}
+
+	full, err := ComputePatch(pngWithRects(t, 5, 5), img, 4)
+	if err != nil {
+		t.Fatalf("ComputePatch failed: %v", err)
+	}
+	if !full.Full || len(full.Rects) != 1 || full.Rects[0].Width != 10 {
+		t.Errorf("expected full patch for resized image, got full=%v rects=%d", full.Full, len(full.Rects))
+	}
+}
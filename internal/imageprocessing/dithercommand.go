@@ -7,7 +7,9 @@ import (
 	"image/color"
 	"image/png"
 	"log/slog"
-
+	"maps"
+	"math"
+	"slices"
 )
 
 const (
@@ -33,8 +35,39 @@ type DitherParams struct {
 	PalettePairs []ColorPair
 	// Algorithm selects the dithering algorithm: "floyd-steinberg" (default) or "atkinson"
 	Algorithm string
+	// Strength scales the diffused error between 0 (plain nearest-color
+	// mapping) and 1 (full diffusion, the default).
+	Strength float64
+	// FlatArt selects when images are mapped to the nearest colors without
+	// diffusion: FlatArtNever (default), FlatArtAuto or FlatArtAlways.
+	FlatArt string
+	// FlatArtMaxColors is the number of colors that must cover nearly all
+	// pixels for FlatArtAuto to treat an image as flat art.
+	FlatArtMaxColors int
 }
 
+// Values of the flatArt parameter.
+const (
+	// FlatArtNever diffuses the error of every image.
+	FlatArtNever = "never"
+	// FlatArtAuto maps images made of few flat colors, such as rasterized
+	// SVGs, logos and comics, to the nearest colors and diffuses the rest.
+	FlatArtAuto = "auto"
+	// FlatArtAlways maps every image to the nearest colors.
+	FlatArtAlways = "always"
+)
+
+const (
+	// defaultFlatArtMaxColors is the default of the flatArtMaxColors parameter.
+	defaultFlatArtMaxColors = 32
+	// flatArtCoverage is the share of pixels the most frequent colors must
+	// cover; the rest are antialiased edges.
+	flatArtCoverage = 0.97
+)
+
+// DitheringAlgorithms lists the values accepted by the ditheringAlgorithm parameter.
+var DitheringAlgorithms = []string{"floyd-steinberg", "atkinson"}
+
 // Defaults to black/white with identical device and dithering colors
 func defaultBWPalettePairs() []ColorPair {
 	return []ColorPair{
@@ -78,6 +111,20 @@ func NewDitherParamsFromMap(params map[string]any) (*DitherParams, error) {
 		ditherParams.Algorithm = "floyd-steinberg"
 	}
 
+	ditherParams.Strength = GetFloatParam(params, "strength", 1)
+	if ditherParams.Strength < 0 || ditherParams.Strength > 1 {
+		return nil, fmt.Errorf("strength must be between 0 and 1, got %g", ditherParams.Strength)
+	}
+
+	ditherParams.FlatArt = GetStringParam(params, "flatArt", FlatArtNever)
+	if ditherParams.FlatArt != FlatArtNever && ditherParams.FlatArt != FlatArtAuto && ditherParams.FlatArt != FlatArtAlways {
+		return nil, fmt.Errorf("flatArt must be %q, %q or %q, got %q", FlatArtNever, FlatArtAuto, FlatArtAlways, ditherParams.FlatArt)
+	}
+	ditherParams.FlatArtMaxColors = GetIntParam(params, "flatArtMaxColors", defaultFlatArtMaxColors)
+	if ditherParams.FlatArtMaxColors <= 0 {
+		return nil, fmt.Errorf("flatArtMaxColors must be positive, got %d", ditherParams.FlatArtMaxColors)
+	}
+
 	return ditherParams, nil
 }
 
@@ -251,18 +298,16 @@ func (c *DitherCommand) Name() string {
 	return c.name
 }
 
-// Execute applies dithering using the dithering palette and outputs the image mapped to device colors
+// Execute applies dithering to the PNG image; see ExecuteImage.
 func (c *DitherCommand) Execute(imageData []byte) ([]byte, error) {
-	slog.Debug("DitherCommand: dither and map",
-		"input_size_bytes", len(imageData),
-		"ditheringAlgorithm", c.params.Algorithm)
+	return executeEncoded(c, imageData)
+}
 
-	// decode
-	img, err := decodePNGData(imageData)
-	if err != nil {
-		slog.Error("DitherCommand: failed to decode PNG image", "error", err)
-		return nil, fmt.Errorf("failed to decode PNG image: %w", err)
-	}
+// ExecuteImage applies dithering using the dithering palette and outputs the image mapped to device colors
+func (c *DitherCommand) ExecuteImage(img image.Image) (image.Image, error) {
+	slog.Debug("DitherCommand: dither and map",
+		"ditheringAlgorithm", c.params.Algorithm,
+		"strength", c.params.Strength)
 
 	// extract palettes
 	devicePalette, ditherPalette := palettesFromPairs(c.params.PalettePairs)
@@ -284,38 +329,78 @@ func (c *DitherCommand) Execute(imageData []byte) ([]byte, error) {
 	}
 
 	// Optimization: if the image already contains only exact device colors (after alpha compositing over white),
-	// skip dithering and mapping entirely and return the original bytes.
+	// skip dithering and mapping entirely and return the original image.
 	if !needsDitheringAgainst(img, devicePalette) {
 		slog.Debug("DitherCommand: image already matches device palette; skipping dithering")
-		return imageData, nil
+		return img, nil
 	}
 
 	// perform dithering with quantization against ditherPalette, write devicePalette colors
 	var outImg image.Image
+	var err error
+	strength := ditherStrengthPercent(c.params.Strength)
+	if c.flatArt(img) {
+		slog.Debug("DitherCommand: flat art; mapping to the nearest colors without diffusion")
+		strength = 0
+	}
 	switch c.params.Algorithm {
 	case "atkinson":
-		outImg, err = ditherAndMapAtkinson(img, ditherPalette, devicePalette)
+		outImg, err = ditherAndMapAtkinson(img, ditherPalette, devicePalette, strength)
 	default:
-		outImg, err = ditherAndMapFloydSteinberg(img, ditherPalette, devicePalette)
+		outImg, err = ditherAndMapFloydSteinberg(img, ditherPalette, devicePalette, strength)
 	}
 	if err != nil {
 		return nil, err
 	}
 
-	// encode
-	outBytes, err := encodePNGImage(outImg)
-	if err != nil {
-		slog.Error("DitherCommand: failed to encode mapped image", "error", err)
-		return nil, fmt.Errorf("failed to encode PNG image: %w", err)
-	}
+	slog.Debug("DitherCommand: complete")
+	return outImg, nil
+}
 
-	slog.Debug("DitherCommand: complete", "output_size_bytes", len(outBytes))
-	return outBytes, nil
+// flatArt reports whether img is mapped to the nearest colors without
+// diffusion. Error diffusion turns the flat areas of illustrations into noisy
+// patterns, while mapping keeps their edges crisp.
+func (c *DitherCommand) flatArt(img image.Image) bool {
+	switch c.params.FlatArt {
+	case FlatArtAlways:
+		return true
+	case FlatArtAuto:
+		return isFlatArt(img, c.params.FlatArtMaxColors)
+	default:
+		return false
+	}
 }
 
-// decodePNGData decodes PNG bytes into an image.Image
-func decodePNGData(data []byte) (image.Image, error) {
-	return png.Decode(bytes.NewReader(data))
+// isFlatArt reports whether the maxColors most frequent colors of img cover at
+// least flatArtCoverage of its pixels. Photos are recognized early since they
+// quickly exceed the number of distinct colors flat art may have.
+func isFlatArt(img image.Image, maxColors int) bool {
+	rgba := toRGBA(img)
+	b := rgba.Bounds()
+	total := b.Dx() * b.Dy()
+	if total == 0 {
+		return false
+	}
+	// Colors beyond the most frequent ones may cover at most the remaining
+	// share; each contributes at least one pixel.
+	maxDistinct := maxColors + int(float64(total)*(1-flatArtCoverage))
+	counts := make(map[[4]uint8]int)
+	for y := range b.Dy() {
+		row := rgba.Pix[y*rgba.Stride:]
+		for x := range b.Dx() {
+			p := row[x*4 : x*4+4]
+			counts[[4]uint8{p[0], p[1], p[2], p[3]}]++
+			if len(counts) > maxDistinct {
+				return false
+			}
+		}
+	}
+	frequencies := slices.SortedFunc(maps.Values(counts), func(a, b int) int { return b - a })
+	covered := 0
+	for _, n := range frequencies[:min(maxColors, len(frequencies))] {
+		covered += n
+	}
+	return float64(covered) >= flatArtCoverage*float64(total)
 }
 
 // palettesFromPairs extracts device and dither palettes from ColorPair slice
@@ -355,18 +440,14 @@ func needsDitheringAgainst(img image.Image, palette []color.RGBA) bool {
 	h := bounds.Dy()
 
 	paletteSet := buildPaletteSet(palette)
+	pixels := newPixelReader(img)
 
 	// Parallel row scan with early exit as soon as a non-palette pixel is found
 	found := parallelForStop(h, func(y int) bool {
-		yy := bounds.Min.Y + y
+		row := pixels.row(bounds.Min.Y+y, make([]uint8, 4*w))
 		for x := 0; x < w; x++ {
-			xx := bounds.Min.X + x
-
-			r16, g16, b16, a16 := img.At(xx, yy).RGBA()
-			r8 := int(uint8(r16 >> 8)) // #nosec G115 -- components are 16-bit; shifting >>8 ensures 0..255 before conversion
-			g8 := int(uint8(g16 >> 8)) // #nosec G115
-			b8 := int(uint8(b16 >> 8)) // #nosec G115
-			a8 := int(uint8(a16 >> 8)) // #nosec G115
+			px := row[x*4 : x*4+4]
+			r8, g8, b8, a8 := int(px[0]), int(px[1]), int(px[2]), int(px[3])
 
 			// Composite over white background (same formula used in dithering path)
 			r0, g0, b0 := compositeOverWhite(r8, g8, b8, a8)
@@ -416,6 +497,15 @@ func nearestPaletteIndex(r, g, b int, palette []color.RGBA) int {
 	return bestIdx
 }
 
+// fullDitherStrength diffuses the whole quantization error.
+const fullDitherStrength = 100
+
+// ditherStrengthPercent converts a strength in [0,1] to the integer percentage
+// the error diffusion works with.
+func ditherStrengthPercent(strength float64) int {
+	return int(math.Round(strength * fullDitherStrength))
+}
+
 // roundDiv16FloydSteinberg rounds an accumulated error scaled by 16 to nearest integer
 func roundDiv16FloydSteinberg(e int) int {
 	if e >= 0 {
@@ -452,7 +542,8 @@ func distributeFloydSteinbergError(x, y, w, h int, er, eg, eb int,
 // ditherAndMapFloydSteinberg applies integer-based Floyd–Steinberg error diffusion (non-serpentine)
 // with nearest-color mapping in 8-bit sRGB and alpha compositing over white.
 // Quantization (error target) uses ditherPalette; output pixel is written using devicePalette at the chosen index.
-func ditherAndMapFloydSteinberg(img image.Image, ditherPalette, devicePalette []color.RGBA) (image.Image, error) {
+// strength is the percentage of the error that is diffused.
+func ditherAndMapFloydSteinberg(img image.Image, ditherPalette, devicePalette []color.RGBA, strength int) (image.Image, error) {
 	bounds := img.Bounds()
 	w := bounds.Dx()
 	h := bounds.Dy()
@@ -467,17 +558,16 @@ func ditherAndMapFloydSteinberg(img image.Image, ditherPalette, devicePalette []
 	errNextG := make([]int, w)
 	errNextB := make([]int, w)
 
+	pixels := newPixelReader(img)
+	buf := make([]uint8, 4*w)
+
 	// Iterate rows top-to-bottom, left-to-right (no serpentine)
 	for y := 0; y < h; y++ {
+		row := pixels.row(bounds.Min.Y+y, buf)
+		outRow := out.Pix[y*out.Stride : y*out.Stride+w]
 		for x := 0; x < w; x++ {
-			xx := bounds.Min.X + x
-			yy := bounds.Min.Y + y
-
-			r16, g16, b16, a16 := img.At(xx, yy).RGBA()
-			r8 := int(uint8(r16 >> 8)) // #nosec G115 -- components are 16-bit; shifting >>8 ensures 0..255 before conversion
-			g8 := int(uint8(g16 >> 8)) // #nosec G115
-			b8 := int(uint8(b16 >> 8)) // #nosec G115
-			a8 := int(uint8(a16 >> 8)) // #nosec G115
+			px := row[x*4 : x*4+4]
+			r8, g8, b8, a8 := int(px[0]), int(px[1]), int(px[2]), int(px[3])
 
 			// Composite over white background (unpremultiplied) with rounding
 			r0, g0, b0 := compositeOverWhite(r8, g8, b8, a8)
@@ -491,13 +581,13 @@ func ditherAndMapFloydSteinberg(img image.Image, ditherPalette, devicePalette []
 			bestIdx := nearestPaletteIndex(rAdj, gAdj, bAdj, ditherPalette)
 			quant := ditherPalette[bestIdx]
 
-			// Error (unscaled) between adjusted source and quantized dither color
-			er := rAdj - int(quant.R)
-			eg := gAdj - int(quant.G)
-			eb := bAdj - int(quant.B)
+			// Error (unscaled) between adjusted source and quantized dither color, reduced to strength
+			er := (rAdj - int(quant.R)) * strength / fullDitherStrength
+			eg := (gAdj - int(quant.G)) * strength / fullDitherStrength
+			eb := (bAdj - int(quant.B)) * strength / fullDitherStrength
 
 			// Set output pixel to the corresponding device color index (paletted image)
-			out.SetColorIndex(xx, yy, uint8(bestIdx)) //nolint:gosec // bestIdx < 256 ensured by palette length validation
+			outRow[x] = uint8(bestIdx) //nolint:gosec // bestIdx < 256 ensured by palette length validation
 
 			// Distribute Floyd-Steinberg error to neighbors (L->R)
 			distributeFloydSteinbergError(x, y, w, h, er, eg, eb, errCurrR, errCurrG, errCurrB, errNextR, errNextG, errNextB)
@@ -572,7 +662,8 @@ func distributeAtkinsonError(
 // ditherAndMapAtkinson applies Standard Atkinson error diffusion (non-serpentine)
 // with nearest-color mapping in 8-bit sRGB and alpha compositing over white.
 // Quantization (error target) uses ditherPalette; output pixel is written using devicePalette at the chosen index.
-func ditherAndMapAtkinson(img image.Image, ditherPalette, devicePalette []color.RGBA) (image.Image, error) {
+// strength is the percentage of the error that is diffused.
+func ditherAndMapAtkinson(img image.Image, ditherPalette, devicePalette []color.RGBA, strength int) (image.Image, error) {
 	bounds := img.Bounds()
 	w := bounds.Dx()
 	h := bounds.Dy()
@@ -590,17 +681,16 @@ func ditherAndMapAtkinson(img image.Image, ditherPalette, devicePalette []color.
 	errNext2G := make([]int, w)
 	errNext2B := make([]int, w)
 
+	pixels := newPixelReader(img)
+	buf := make([]uint8, 4*w)
+
 	// Iterate rows top-to-bottom, left-to-right (no serpentine)
 	for y := 0; y < h; y++ {
+		row := pixels.row(bounds.Min.Y+y, buf)
+		outRow := out.Pix[y*out.Stride : y*out.Stride+w]
 		for x := 0; x < w; x++ {
-			xx := bounds.Min.X + x
-			yy := bounds.Min.Y + y
-
-			r16, g16, b16, a16 := img.At(xx, yy).RGBA()
-			r8 := int(uint8(r16 >> 8)) // #nosec G115 -- components are 16-bit; shifting >>8 ensures 0..255 before conversion
-			g8 := int(uint8(g16 >> 8)) // #nosec G115
-			b8 := int(uint8(b16 >> 8)) // #nosec G115
-			a8 := int(uint8(a16 >> 8)) // #nosec G115
+			px := row[x*4 : x*4+4]
+			r8, g8, b8, a8 := int(px[0]), int(px[1]), int(px[2]), int(px[3])
 
 			// Composite over white background (unpremultiplied) with rounding
 			r0, g0, b0 := compositeOverWhite(r8, g8, b8, a8)
@@ -614,13 +704,13 @@ func ditherAndMapAtkinson(img image.Image, ditherPalette, devicePalette []color.
 			bestIdx := nearestPaletteIndex(rAdj, gAdj, bAdj, ditherPalette)
 			quant := ditherPalette[bestIdx]
 
-			// Error (unscaled) between adjusted source and quantized dither color
-			er := rAdj - int(quant.R)
-			eg := gAdj - int(quant.G)
-			eb := bAdj - int(quant.B)
+			// Error (unscaled) between adjusted source and quantized dither color, reduced to strength
+			er := (rAdj - int(quant.R)) * strength / fullDitherStrength
+			eg := (gAdj - int(quant.G)) * strength / fullDitherStrength
+			eb := (bAdj - int(quant.B)) * strength / fullDitherStrength
 
 			// Set output pixel to the corresponding device color index (paletted image)
-			out.SetColorIndex(xx, yy, uint8(bestIdx)) //nolint:gosec // bestIdx < 256 ensured by palette length validation
+			outRow[x] = uint8(bestIdx) //nolint:gosec // bestIdx < 256 ensured by palette length validation
 
 			// Distribute Atkinson error to neighbors (each neighbor receives 1/8; arrays hold error scaled by 8)
 			distributeAtkinsonError(x, y, w, h, er, eg, eb, errCurrR, errCurrG, errCurrB, errNextR, errNextG, errNextB, errNext2R, errNext2G, errNext2B)
@@ -367,3 +367,132 @@ func TestNewDitherCommand_InvalidDitheringAlgorithm(t *testing.T) {
 		t.Error("Expected error for invalid ditheringAlgorithm")
 	}
 }
+
+func TestDitherCommand_ZeroStrengthMapsToNearestColor(t *testing.T) {
+	imageData := createTestImage(64, 8)
+
+	cmd, err := NewDitherCommand(map[string]any{"strength": 0.0})
+	if err != nil {
+		t.Fatalf("Failed to create command: %v", err)
+	}
+	result, err := cmd.Execute(imageData)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(result))
+	if err != nil {
+		t.Fatalf("Result is not valid PNG: %v", err)
+	}
+
+	// Without diffusion every pixel takes the nearest palette color, so the
+	// gradient splits into a black and a white half.
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 64; x++ {
+			r, _, _, _ := img.At(x, y).RGBA()
+			wantWhite := x*255/64 > 127
+			if (r>>8 == 255) != wantWhite {
+				t.Fatalf("pixel (%d,%d) = %d, want white=%v", x, y, r>>8, wantWhite)
+			}
+		}
+	}
+}
+
+// createFlatTestImage creates a two-tone illustration: dark gray on the left,
+// light gray on the right.
+func createFlatTestImage(width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			gray := uint8(100)
+			if x >= width/2 {
+				gray = 200
+			}
+			img.Set(x, y, color.RGBA{gray, gray, gray, 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		panic(fmt.Sprintf("failed to encode test image: %v", err))
+	}
+	return buf.Bytes()
+}
+
+func TestDitherCommand_FlatArt(t *testing.T) {
+	// countWhite returns the number of white pixels in the left half, which
+	// only diffusion produces.
+	countWhite := func(t *testing.T, params map[string]any, imageData []byte) int {
+		t.Helper()
+		cmd, err := NewDitherCommand(params)
+		if err != nil {
+			t.Fatalf("Failed to create command: %v", err)
+		}
+		result, err := cmd.Execute(imageData)
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		img, err := png.Decode(bytes.NewReader(result))
+		if err != nil {
+			t.Fatalf("Result is not valid PNG: %v", err)
+		}
+		white := 0
+		for y := 0; y < 16; y++ {
+			for x := 0; x < 32; x++ {
+				if r, _, _, _ := img.At(x, y).RGBA(); r>>8 == 255 {
+					white++
+				}
+			}
+		}
+		return white
+	}
+
+	flat := createFlatTestImage(64, 16)
+	if white := countWhite(t, map[string]any{}, flat); white == 0 {
+		t.Error("expected flat art to be diffused by default")
+	}
+	if white := countWhite(t, map[string]any{"flatArt": FlatArtAuto}, flat); white != 0 {
+		t.Errorf("expected flat art to be mapped without diffusion, got %d white pixels", white)
+	}
+	if white := countWhite(t, map[string]any{"flatArt": FlatArtAuto}, createTestImage(64, 16)); white == 0 {
+		t.Error("expected a gradient to be diffused in auto mode")
+	}
+	if white := countWhite(t, map[string]any{"flatArt": FlatArtAlways}, createTestImage(64, 16)); white != 0 {
+		t.Errorf("expected every image to be mapped without diffusion, got %d white pixels", white)
+	}
+}
+
+func TestIsFlatArt(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	for i := range 100 * 100 {
+		// Two flat colors and a few stray ones, like antialiased edges.
+		gray := uint8(50)
+		switch {
+		case i%100 < 2:
+			gray = uint8(i / 100) //nolint:gosec // i/100 is in 0..99
+		case i%100 >= 50:
+			gray = 220
+		}
+		img.Pix[i*4], img.Pix[i*4+1], img.Pix[i*4+2], img.Pix[i*4+3] = gray, gray, gray, 255
+	}
+	if !isFlatArt(img, 32) {
+		t.Error("expected a two-tone image with antialiased edges to be flat art")
+	}
+	if isFlatArt(img, 1) {
+		t.Error("expected a two-tone image not to be flat art with one color")
+	}
+}
+
+func TestNewDitherCommand_InvalidFlatArt(t *testing.T) {
+	for _, params := range []map[string]any{{"flatArt": "sometimes"}, {"flatArtMaxColors": 0}} {
+		if _, err := NewDitherCommand(params); err == nil {
+			t.Errorf("Expected error for %v", params)
+		}
+	}
+}
+
+func TestNewDitherCommand_InvalidStrength(t *testing.T) {
+	for _, strength := range []float64{-0.1, 1.5} {
+		if _, err := NewDitherCommand(map[string]any{"strength": strength}); err == nil {
+			t.Errorf("Expected error for strength %g", strength)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package imageprocessing
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+// exifDateLayout is the format of EXIF date tags; they carry no time zone.
+const exifDateLayout = "2006:01:02 15:04:05"
+
+// EXIF tags holding the capture time.
+const (
+	tagDateTime         = uint16(0x0132)
+	tagExifIFDPointer   = uint16(0x8769)
+	tagDateTimeOriginal = uint16(0x9003)
+)
+
+// ReadJPEGCaptureTime returns when a JPEG was taken according to its EXIF
+// DateTimeOriginal tag, falling back to DateTime. EXIF dates have no time zone,
+// so the result is in UTC and only suitable for ordering. data may be cut off
+// after the EXIF segment; ok is false when no date is found.
+func ReadJPEGCaptureTime(data []byte) (t time.Time, ok bool) {
+	if !isJPEG(data) {
+		return time.Time{}, false
+	}
+	pos := 2 // skip SOI
+	for pos+4 <= len(data) && data[pos] == 0xFF {
+		marker := data[pos+1]
+		segLen := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		if segLen < 2 { // the length includes its own two bytes
+			break
+		}
+		end := pos + 2 + segLen
+		if end > len(data) || marker == 0xDA { // truncated or start of scan
+			break
+		}
+		if marker == 0xE1 {
+			if t, ok := parseExifCaptureTime(data[pos+4 : end]); ok {
+				return t, true
+			}
+		}
+		pos = end
+	}
+	return time.Time{}, false
+}
+
+// parseExifCaptureTime reads the capture time from an APP1 payload.
+func parseExifCaptureTime(payload []byte) (time.Time, bool) {
+	const exifHeader = "Exif\x00\x00"
+	if len(payload) < len(exifHeader)+8 || string(payload[:len(exifHeader)]) != exifHeader {
+		return time.Time{}, false
+	}
+	tiff := payload[len(exifHeader):]
+	bo, err := tiffByteOrder(tiff)
+	if err != nil {
+		return time.Time{}, false
+	}
+	ifd0 := readIFDEntries(tiff, bo.Uint32(tiff[4:8]), bo)
+	if entry, ok := ifd0[tagExifIFDPointer]; ok {
+		exif := readIFDEntries(tiff, bo.Uint32(entry[8:12]), bo)
+		if t, ok := exifDate(tiff, exif[tagDateTimeOriginal], bo); ok {
+			return t, true
+		}
+	}
+	return exifDate(tiff, ifd0[tagDateTime], bo)
+}
+
+// readIFDEntries returns the 12-byte entries of the IFD at offset by tag.
+func readIFDEntries(tiff []byte, offset uint32, bo binary.ByteOrder) map[uint16][]byte {
+	entries := make(map[uint16][]byte)
+	if uint64(offset)+2 > uint64(len(tiff)) {
+		return entries
+	}
+	count := int(bo.Uint16(tiff[offset : offset+2]))
+	for i := range count {
+		start := int(offset) + 2 + i*12
+		if start+12 > len(tiff) {
+			break
+		}
+		entries[bo.Uint16(tiff[start:start+2])] = tiff[start : start+12]
+	}
+	return entries
+}
+
+// exifDate parses an ASCII date entry.
+func exifDate(tiff, entry []byte, bo binary.ByteOrder) (time.Time, bool) {
+	const typeASCII = 2
+	if entry == nil || bo.Uint16(entry[2:4]) != typeASCII {
+		return time.Time{}, false
+	}
+	count := bo.Uint32(entry[4:8])
+	if count < uint32(len(exifDateLayout)) {
+		return time.Time{}, false
+	}
+	offset := bo.Uint32(entry[8:12])
+	if uint64(offset)+uint64(len(exifDateLayout)) > uint64(len(tiff)) {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(exifDateLayout, string(tiff[offset:offset+uint32(len(exifDateLayout))]))
+	return t, err == nil
+}
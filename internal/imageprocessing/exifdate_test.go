@@ -0,0 +1,72 @@
+package imageprocessing
+
+import (
+	"encoding/binary"
+	"image"
+	"testing"
+	"time"
+)
+
+// buildDateExifAPP1 constructs a little-endian EXIF APP1 segment with DateTime
+// in IFD0 and, when original is set, DateTimeOriginal in the Exif IFD.
+func buildDateExifAPP1(dateTime, original string) []byte {
+	le := binary.LittleEndian
+	entry := func(tag, typ uint16, count, value uint32) []byte {
+		b := make([]byte, 12)
+		le.PutUint16(b[0:], tag)
+		le.PutUint16(b[2:], typ)
+		le.PutUint32(b[4:], count)
+		le.PutUint32(b[8:], value)
+		return b
+	}
+	// Layout: header (8) | IFD0 with 2 entries (2+24+4) at 8 | Exif IFD with 1
+	// entry (2+12+4) at 38 | DateTime at 56 | DateTimeOriginal at 76.
+	tiff := []byte{'I', 'I', 0x2A, 0x00, 0x08, 0x00, 0x00, 0x00}
+	tiff = append(tiff, 0x02, 0x00)
+	tiff = append(tiff, entry(tagDateTime, 2, 20, 56)...)
+	tiff = append(tiff, entry(tagExifIFDPointer, 4, 1, 38)...)
+	tiff = append(tiff, 0, 0, 0, 0)
+	tiff = append(tiff, 0x01, 0x00)
+	tag := tagDateTimeOriginal
+	if original == "" {
+		tag = 0x9999 // some other tag
+	}
+	tiff = append(tiff, entry(tag, 2, 20, 76)...)
+	tiff = append(tiff, 0, 0, 0, 0)
+	tiff = append(tiff, append([]byte(dateTime), 0)...)
+	tiff = append(tiff, append([]byte(original + "                   ")[:19], 0)...)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segLen := uint16(2 + len(payload))                                             // #nosec G115 -- payload is always tiny
+	return append([]byte{0xFF, 0xE1, byte(segLen >> 8), byte(segLen)}, payload...) // #nosec G115
+}
+
+func TestReadJPEGCaptureTime(t *testing.T) {
+	jpegBytes := encodeAsJPEG(t, image.NewRGBA(image.Rect(0, 0, 4, 4)))
+	withExif := func(app1 []byte) []byte {
+		return append(append(append([]byte{}, jpegBytes[:2]...), app1...), jpegBytes[2:]...)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want time.Time
+		ok   bool
+	}{
+		{"original", withExif(buildDateExifAPP1("2020:01:01 00:00:00", "2019:07:14 18:30:05")), time.Date(2019, 7, 14, 18, 30, 5, 0, time.UTC), true},
+		{"falls back to DateTime", withExif(buildDateExifAPP1("2020:01:01 08:00:00", "")), time.Date(2020, 1, 1, 8, 0, 0, 0, time.UTC), true},
+		{"header only", withExif(buildDateExifAPP1("2020:01:01 08:00:00", ""))[:200], time.Date(2020, 1, 1, 8, 0, 0, 0, time.UTC), true},
+		{"no exif", jpegBytes, time.Time{}, false},
+		{"not a jpeg", []byte("GIF89a"), time.Time{}, false},
+		{"empty segment length", []byte{0xFF, 0xD8, 0xFF, 0xE1, 0x00, 0x00, 0xFF, 0xD9}, time.Time{}, false},
+		{"segment length of one", []byte{0xFF, 0xD8, 0xFF, 0xE1, 0x00, 0x01, 0xFF, 0xD9}, time.Time{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ReadJPEGCaptureTime(tt.data)
+			if ok != tt.ok || !got.Equal(tt.want) {
+				t.Errorf("expected %v (%v), got %v (%v)", tt.want, tt.ok, got, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,312 @@
+package imageprocessing
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// exprVars holds the per-pixel inputs of a compiled expression, indexed by
+// the positions in exprVarNames.
+type exprVars [8]float64
+
+// exprVarNames lists the variables available to pixel expressions: the channel
+// values (0-255), the pixel coordinates and the image dimensions.
+var exprVarNames = [...]string{"r", "g", "b", "a", "x", "y", "w", "h"}
+
+// exprFunc is a compiled expression.
+type exprFunc func(v *exprVars) float64
+
+// exprBuiltin is a function callable from expressions; unused trailing
+// arguments are passed as 0.
+type exprBuiltin struct {
+	arity int
+	fn    func(a, b, c float64) float64
+}
+
+var exprBuiltins = map[string]exprBuiltin{
+	"min":   {2, func(a, b, _ float64) float64 { return math.Min(a, b) }},
+	"max":   {2, func(a, b, _ float64) float64 { return math.Max(a, b) }},
+	"clamp": {3, func(a, lo, hi float64) float64 { return math.Min(math.Max(a, lo), hi) }},
+	"abs":   {1, func(a, _, _ float64) float64 { return math.Abs(a) }},
+	"floor": {1, func(a, _, _ float64) float64 { return math.Floor(a) }},
+	"ceil":  {1, func(a, _, _ float64) float64 { return math.Ceil(a) }},
+	"round": {1, func(a, _, _ float64) float64 { return math.Round(a) }},
+	"sqrt":  {1, func(a, _, _ float64) float64 { return math.Sqrt(a) }},
+	"pow":   {2, func(a, b, _ float64) float64 { return math.Pow(a, b) }},
+}
+
+// compileExpression parses a C-like arithmetic expression into a closure.
+// Supported: numbers, the variables in exprVarNames, + - * / %, comparisons,
+// && || !, the ternary operator and the functions in exprBuiltins. Boolean
+// results are 1 (true) or 0 (false).
+func compileExpression(src string) (exprFunc, error) {
+	tokens, err := tokenizeExpression(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{tokens: tokens}
+	fn, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q at end of expression", p.tokens[p.pos])
+	}
+	return fn, nil
+}
+
+func tokenizeExpression(src string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case unicode.IsDigit(c) || c == '.':
+			j := i
+			for j < len(src) && (unicode.IsDigit(rune(src[j])) || src[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, src[i:j])
+			i = j
+		case unicode.IsLetter(c) || c == '_':
+			j := i
+			for j < len(src) && (unicode.IsLetter(rune(src[j])) || unicode.IsDigit(rune(src[j])) || src[j] == '_') {
+				j++
+			}
+			tokens = append(tokens, src[i:j])
+			i = j
+		default:
+			if i+1 < len(src) {
+				switch two := src[i : i+2]; two {
+				case "<=", ">=", "==", "!=", "&&", "||":
+					tokens = append(tokens, two)
+					i += 2
+					continue
+				}
+			}
+			if !strings.ContainsRune("+-*/%<>!?:(),", c) {
+				return nil, fmt.Errorf("unexpected character %q at offset %d", c, i)
+			}
+			tokens = append(tokens, string(c))
+			i++
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+	return tokens, nil
+}
+
+type exprParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *exprParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *exprParser) expect(tok string) error {
+	if p.peek() != tok {
+		if p.peek() == "" {
+			return fmt.Errorf("expected %q, got end of expression", tok)
+		}
+		return fmt.Errorf("expected %q, got %q", tok, p.peek())
+	}
+	p.pos++
+	return nil
+}
+
+func (p *exprParser) parseTernary() (exprFunc, error) {
+	cond, err := p.parseBinary(0)
+	if err != nil || p.peek() != "?" {
+		return cond, err
+	}
+	p.pos++
+	then, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	otherwise, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	return func(v *exprVars) float64 {
+		if cond(v) != 0 {
+			return then(v)
+		}
+		return otherwise(v)
+	}, nil
+}
+
+// exprPrecedence lists binary operators from lowest to highest precedence.
+var exprPrecedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *exprParser) parseBinary(level int) (exprFunc, error) {
+	if level == len(exprPrecedence) {
+		return p.parseUnary()
+	}
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peek()
+		if !containsString(exprPrecedence[level], op) {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr(op, left, right)
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func binaryExpr(op string, l, r exprFunc) exprFunc {
+	switch op {
+	case "||":
+		return func(v *exprVars) float64 { return boolToFloat(l(v) != 0 || r(v) != 0) }
+	case "&&":
+		return func(v *exprVars) float64 { return boolToFloat(l(v) != 0 && r(v) != 0) }
+	case "==":
+		return func(v *exprVars) float64 { return boolToFloat(l(v) == r(v)) }
+	case "!=":
+		return func(v *exprVars) float64 { return boolToFloat(l(v) != r(v)) }
+	case "<":
+		return func(v *exprVars) float64 { return boolToFloat(l(v) < r(v)) }
+	case "<=":
+		return func(v *exprVars) float64 { return boolToFloat(l(v) <= r(v)) }
+	case ">":
+		return func(v *exprVars) float64 { return boolToFloat(l(v) > r(v)) }
+	case ">=":
+		return func(v *exprVars) float64 { return boolToFloat(l(v) >= r(v)) }
+	case "+":
+		return func(v *exprVars) float64 { return l(v) + r(v) }
+	case "-":
+		return func(v *exprVars) float64 { return l(v) - r(v) }
+	case "*":
+		return func(v *exprVars) float64 { return l(v) * r(v) }
+	case "/":
+		return func(v *exprVars) float64 { return l(v) / r(v) }
+	default: // "%"
+		return func(v *exprVars) float64 { return math.Mod(l(v), r(v)) }
+	}
+}
+
+func (p *exprParser) parseUnary() (exprFunc, error) {
+	switch p.peek() {
+	case "-":
+		p.pos++
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(v *exprVars) float64 { return -operand(v) }, nil
+	case "!":
+		p.pos++
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(v *exprVars) float64 { return boolToFloat(operand(v) == 0) }, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (exprFunc, error) {
+	tok := p.peek()
+	if tok == "" {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	p.pos++
+
+	if tok == "(" {
+		inner, err := p.parseTernary()
+		if err != nil {
+			return nil, err
+		}
+		return inner, p.expect(")")
+	}
+
+	if n, err := strconv.ParseFloat(tok, 64); err == nil {
+		return func(*exprVars) float64 { return n }, nil
+	}
+
+	if p.peek() == "(" {
+		return p.parseCall(tok)
+	}
+
+	for i, name := range exprVarNames {
+		if tok == name {
+			return func(v *exprVars) float64 { return v[i] }, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown identifier %q", tok)
+}
+
+func (p *exprParser) parseCall(name string) (exprFunc, error) {
+	builtin, ok := exprBuiltins[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q", name)
+	}
+	p.pos++ // "("
+
+	var args []exprFunc
+	for p.peek() != ")" {
+		if len(args) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		arg, err := p.parseTernary()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	p.pos++ // ")"
+
+	if len(args) != builtin.arity {
+		return nil, fmt.Errorf("%s expects %d arguments, got %d", name, builtin.arity, len(args))
+	}
+	zero := func(*exprVars) float64 { return 0 }
+	for len(args) < 3 {
+		args = append(args, zero)
+	}
+	a, b, c, fn := args[0], args[1], args[2], builtin.fn
+	return func(v *exprVars) float64 { return fn(a(v), b(v), c(v)) }, nil
+}
@@ -0,0 +1,141 @@
+package imageprocessing
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"log/slog"
+	"math"
+)
+
+// ExpressionParams holds the typed parameters for an ExpressionCommand.
+// Each field is an expression producing the new channel value (0-255);
+// empty expressions leave the channel unchanged.
+type ExpressionParams struct {
+	R string
+	G string
+	B string
+	A string
+}
+
+// NewExpressionParamsFromMap creates ExpressionParams from a generic parameter map.
+func NewExpressionParamsFromMap(params map[string]any) (*ExpressionParams, error) {
+	return NewExpressionParams(
+		GetStringParam(params, "r", ""),
+		GetStringParam(params, "g", ""),
+		GetStringParam(params, "b", ""),
+		GetStringParam(params, "a", ""),
+	)
+}
+
+// NewExpressionParams creates and validates ExpressionParams from concrete values.
+func NewExpressionParams(r, g, b, a string) (*ExpressionParams, error) {
+	if r == "" && g == "" && b == "" && a == "" {
+		return nil, fmt.Errorf("at least one of 'r', 'g', 'b' or 'a' must be specified")
+	}
+	return &ExpressionParams{R: r, G: g, B: b, A: a}, nil
+}
+
+// ExpressionCommand applies per-pixel math expressions to the color channels,
+// e.g. r: "255 - r" to invert or r: "r > 128 ? 255 : 0" to threshold.
+// Expressions are compiled once when the command is created.
+type ExpressionCommand struct {
+	name   string
+	params *ExpressionParams
+	// channels holds the compiled expression per RGBA channel; nil keeps the channel.
+	channels [4]exprFunc
+}
+
+// NewExpressionCommand creates an ExpressionCommand from a generic parameter map.
+func NewExpressionCommand(params map[string]any) (Command, error) {
+	typedParams, err := NewExpressionParamsFromMap(params)
+	if err != nil {
+		return nil, err
+	}
+	return newExpressionCommandFromParams(typedParams)
+}
+
+// NewExpressionCommandWithParams creates an ExpressionCommand from concrete typed parameters.
+func NewExpressionCommandWithParams(r, g, b, a string) (*ExpressionCommand, error) {
+	typedParams, err := NewExpressionParams(r, g, b, a)
+	if err != nil {
+		return nil, err
+	}
+	return newExpressionCommandFromParams(typedParams)
+}
+
+func newExpressionCommandFromParams(params *ExpressionParams) (*ExpressionCommand, error) {
+	c := &ExpressionCommand{name: "ExpressionCommand", params: params}
+	for i, src := range []string{params.R, params.G, params.B, params.A} {
+		if src == "" {
+			continue
+		}
+		fn, err := compileExpression(src)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expression for %s: %w", exprVarNames[i], err)
+		}
+		c.channels[i] = fn
+	}
+	return c, nil
+}
+
+// Name returns the command name.
+func (c *ExpressionCommand) Name() string {
+	return c.name
+}
+
+// Execute evaluates the channel expressions for every pixel of the PNG image.
+func (c *ExpressionCommand) Execute(imageData []byte) ([]byte, error) {
+	return executeEncoded(c, imageData)
+}
+
+// ExecuteImage evaluates the channel expressions for every pixel.
+func (c *ExpressionCommand) ExecuteImage(img image.Image) (image.Image, error) {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Src)
+
+	parallelFor(height, func(y int) {
+		vars := exprVars{6: float64(width), 7: float64(height)}
+		vars[5] = float64(y)
+		row := dst.Pix[y*dst.Stride : y*dst.Stride+width*4]
+		for x := 0; x < width; x++ {
+			px := row[x*4 : x*4+4]
+			for i := 0; i < 4; i++ {
+				vars[i] = float64(px[i])
+			}
+			vars[4] = float64(x)
+			for i, fn := range c.channels {
+				if fn != nil {
+					px[i] = clampToByte(fn(&vars))
+				}
+			}
+		}
+	})
+
+	slog.Debug("ExpressionCommand: evaluation complete")
+	return dst, nil
+}
+
+// clampToByte rounds v to the nearest integer in [0, 255]; NaN maps to 0.
+func clampToByte(v float64) uint8 {
+	if math.IsNaN(v) || v <= 0 {
+		return 0
+	}
+	if v >= 255 {
+		return 255
+	}
+	return uint8(math.Round(v))
+}
+
+// GetParams returns the typed parameters.
+func (c *ExpressionCommand) GetParams() *ExpressionParams {
+	return c.params
+}
+
+func init() {
+	if err := DefaultRegistry.Register("ExpressionCommand", NewExpressionCommand); err != nil {
+		panic(fmt.Sprintf("failed to register ExpressionCommand: %v", err))
+	}
+}
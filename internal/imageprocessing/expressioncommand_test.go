@@ -0,0 +1,87 @@
+package imageprocessing
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestCompileExpression(t *testing.T) {
+	vars := &exprVars{10, 20, 30, 255, 3, 4, 100, 50}
+	tests := []struct {
+		src  string
+		want float64
+	}{
+		{"255 - r", 245},
+		{"r + g * 2", 50},
+		{"(r + g) * 2", 60},
+		{"-r + 5", -5},
+		{"b % 7", 2},
+		{"r > 5 ? 255 : 0", 255},
+		{"r > 5 && g < 10 ? 1 : 2", 2},
+		{"!(r == 10) || x == 3", 1},
+		{"x / w + y / h", 0.11},
+		{"clamp(r * 100, 0, 255)", 255},
+		{"max(min(r, g), 15)", 15},
+		{"round(sqrt(pow(g, 2)))", 20},
+	}
+	for _, tt := range tests {
+		fn, err := compileExpression(tt.src)
+		if err != nil {
+			t.Errorf("compileExpression(%q) error: %v", tt.src, err)
+			continue
+		}
+		if got := fn(vars); got < tt.want-1e-9 || got > tt.want+1e-9 {
+			t.Errorf("%q = %v, want %v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestCompileExpression_Invalid(t *testing.T) {
+	for _, src := range []string{"", "r +", "foo", "min(r)", "unknown(r)", "(r", "r ? 1", "r $ 2", "r g"} {
+		if _, err := compileExpression(src); err == nil {
+			t.Errorf("compileExpression(%q): expected error, got nil", src)
+		}
+	}
+}
+
+func TestNewExpressionCommand_RequiresExpression(t *testing.T) {
+	if _, err := NewExpressionCommand(map[string]any{}); err == nil {
+		t.Fatal("expected error when no channel expression is given")
+	}
+	if _, err := NewExpressionCommand(map[string]any{"r": "r +"}); err == nil {
+		t.Fatal("expected error for invalid expression")
+	}
+}
+
+func TestExpressionCommand_InvertAndSwap(t *testing.T) {
+	cmd, err := NewExpressionCommandWithParams("255 - r", "b", "g", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out, err := cmd.Execute(solidPNG(t, 4, 3, color.RGBA{R: 10, G: 20, B: 30, A: 255}))
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	img, err := decodePNG(out)
+	if err != nil {
+		t.Fatalf("failed to decode output: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 4 || b.Dy() != 3 {
+		t.Fatalf("expected 4x3 output, got %dx%d", b.Dx(), b.Dy())
+	}
+	got := color.NRGBAModel.Convert(img.At(2, 1)).(color.NRGBA)
+	want := color.NRGBA{R: 245, G: 30, B: 20, A: 255}
+	if got != want {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestClampToByte(t *testing.T) {
+	tests := map[float64]uint8{-3: 0, 0: 0, 127.6: 128, 255: 255, 400: 255}
+	for in, want := range tests {
+		if got := clampToByte(in); got != want {
+			t.Errorf("clampToByte(%v) = %d, want %d", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !pigo
+
+package imageprocessing
+
+import "fmt"
+
+// loadFaceDetector rejects the faces strategy in builds without the pigo tag.
+func loadFaceDetector(string) (faceDetector, error) {
+	return nil, fmt.Errorf("strategy %q requires a build with -tags pigo", SmartCropFaces)
+}
@@ -0,0 +1,75 @@
+//go:build pigo
+
+package imageprocessing
+
+import (
+	"fmt"
+	"image"
+	"os"
+
+	pigo "github.com/esimov/pigo/core"
+)
+
+const (
+	// pigoMinFaceSize is the side in pixels of the smallest face searched for.
+	pigoMinFaceSize = 20
+	// pigoShiftFactor moves the detection window by this share of its size.
+	pigoShiftFactor = 0.1
+	// pigoScaleFactor grows the detection window between passes.
+	pigoScaleFactor = 1.1
+	// pigoIoUThreshold merges detections overlapping by more than this share.
+	pigoIoUThreshold = 0.2
+	// pigoMinQuality drops detections the cascade is not confident about.
+	pigoMinQuality = 5
+)
+
+// pigoDetector finds faces with pigo, a pure Go implementation of
+// pixel-intensity-comparison cascades.
+type pigoDetector struct {
+	classifier *pigo.Pigo
+}
+
+// loadFaceDetector unpacks the pigo cascade at path, e.g. the facefinder
+// cascade shipped with pigo.
+func loadFaceDetector(path string) (faceDetector, error) {
+	cascade, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading face cascade: %w", err)
+	}
+	classifier, err := pigo.NewPigo().Unpack(cascade)
+	if err != nil {
+		return nil, fmt.Errorf("unpacking face cascade %s: %w", path, err)
+	}
+	return &pigoDetector{classifier: classifier}, nil
+}
+
+func (d *pigoDetector) detectFaces(img *image.RGBA) []image.Rectangle {
+	b := img.Bounds()
+	cols, rows := b.Dx(), b.Dy()
+	pixels := make([]uint8, cols*rows)
+	for y := range rows {
+		row := img.Pix[img.PixOffset(b.Min.X, b.Min.Y+y):]
+		for x := range cols {
+			p := row[x*4 : x*4+3]
+			pixels[y*cols+x] = uint8((299*int(p[0]) + 587*int(p[1]) + 114*int(p[2])) / 1000)
+		}
+	}
+	detections := d.classifier.RunCascade(pigo.CascadeParams{
+		MinSize:     pigoMinFaceSize,
+		MaxSize:     max(cols, rows),
+		ShiftFactor: pigoShiftFactor,
+		ScaleFactor: pigoScaleFactor,
+		ImageParams: pigo.ImageParams{Pixels: pixels, Rows: rows, Cols: cols, Dim: cols},
+	}, 0)
+	detections = d.classifier.ClusterDetections(detections, pigoIoUThreshold)
+
+	var faces []image.Rectangle
+	for _, det := range detections {
+		if det.Q < pigoMinQuality {
+			continue
+		}
+		r := det.Scale / 2
+		faces = append(faces, image.Rect(det.Col-r, det.Row-r, det.Col+r, det.Row+r).Intersect(image.Rect(0, 0, cols, rows)))
+	}
+	return faces
+}
@@ -0,0 +1,46 @@
+package imageprocessing
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+// patternPNG encodes a w x h image whose pixels are produced by fn. The PNG
+// fixtures of this package's tests are built on it.
+func patternPNG(t *testing.T, w, h int, fn func(x, y int) color.NRGBA) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetNRGBA(x, y, fn(x, y))
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("failed to encode test PNG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// solidPNG encodes a w x h image filled with c.
+func solidPNG(t *testing.T, w, h int, c color.Color) []byte {
+	t.Helper()
+	fill := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return patternPNG(t, w, h, func(int, int) color.NRGBA { return fill })
+}
+
+func gray(v int) color.NRGBA {
+	return color.NRGBA{R: uint8(v), G: uint8(v), B: uint8(v), A: 255}
+}
+
+func mustDecode(t *testing.T, data []byte) image.Image {
+	t.Helper()
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
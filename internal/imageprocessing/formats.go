@@ -0,0 +1,105 @@
+//go:build !slim
+
+package imageprocessing
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image/color"
+	"image/png"
+
+	"github.com/srwiley/oksvg"
+	"github.com/srwiley/rasterx"
+	"golang.org/x/image/math/fixed"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
+
+// DisabledFormats lists the input formats this build cannot convert. Regular
+// builds support all of them; see formats_slim.go.
+var DisabledFormats []string
+
+// renderSVGToPNG renders an SVG byte slice into a PNG with the given target
+// dimensions. Rendering is abandoned with ErrSVGLimit when ctx is done; the
+// rasterizer cannot be interrupted, so it runs in its own goroutine that
+// stops drawing at the next path or segment.
+func renderSVGToPNG(ctx context.Context, svgData []byte, targetW, targetH int) ([]byte, error) {
+	if targetW <= 0 || targetH <= 0 {
+		return nil, fmt.Errorf("invalid target dimensions for SVG rendering: %dx%d", targetW, targetH)
+	}
+
+	// Prepare target canvas (white background)
+	dst := createTargetCanvas(targetW, targetH, color.RGBA{255, 255, 255, 255})
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("failed to render SVG: %v", r)
+			}
+		}()
+		icon, err := oksvg.ReadIconStream(bytes.NewReader(svgData))
+		if err != nil {
+			done <- fmt.Errorf("failed to parse SVG: %w", err)
+			return
+		}
+
+		// Set drawing target rectangle
+		icon.SetTarget(0, 0, float64(targetW), float64(targetH))
+
+		// Rasterize SVG into the target canvas
+		scanner := &cancelableScanner{Scanner: rasterx.NewScannerGV(targetW, targetH, dst, dst.Bounds()), ctx: ctx}
+		dasher := rasterx.NewDasher(targetW, targetH, scanner)
+		for _, path := range icon.SVGPaths {
+			if ctx.Err() != nil {
+				break
+			}
+			path.DrawTransformed(dasher, 1.0, icon.Transform)
+		}
+		done <- nil
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return nil, err
+		}
+	case <-ctx.Done():
+	}
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("%w: rendering did not finish in time", ErrSVGLimit)
+	}
+
+	// Encode to PNG
+	var buf bytes.Buffer
+	buf.Grow(targetW * targetH)
+	if err := png.Encode(&buf, dst); err != nil {
+		return nil, fmt.Errorf("failed to encode rendered SVG as PNG: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// cancelableScanner skips all rasterization once ctx is done, so an abandoned
+// rendering winds down quickly instead of filling paths nobody waits for.
+type cancelableScanner struct {
+	rasterx.Scanner
+	ctx context.Context
+}
+
+func (s *cancelableScanner) Start(a fixed.Point26_6) {
+	if s.ctx.Err() == nil {
+		s.Scanner.Start(a)
+	}
+}
+
+func (s *cancelableScanner) Line(b fixed.Point26_6) {
+	if s.ctx.Err() == nil {
+		s.Scanner.Line(b)
+	}
+}
+
+func (s *cancelableScanner) Draw() {
+	if s.ctx.Err() == nil {
+		s.Scanner.Draw()
+	}
+}
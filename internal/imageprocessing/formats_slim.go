@@ -0,0 +1,18 @@
+//go:build slim
+
+package imageprocessing
+
+import (
+	"context"
+	"fmt"
+)
+
+// DisabledFormats lists the input formats this build cannot convert. Slim
+// builds leave out the SVG rasterizer and the TIFF and WebP decoders to reduce
+// binary size and attack surface for photo-only setups.
+var DisabledFormats = []string{"svg", "tiff", "webp"}
+
+// renderSVGToPNG is unavailable in slim builds.
+func renderSVGToPNG(_ context.Context, _ []byte, _, _ int) ([]byte, error) {
+	return nil, fmt.Errorf("%w: svg", ErrFormatDisabled)
+}
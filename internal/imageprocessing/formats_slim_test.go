@@ -0,0 +1,27 @@
+//go:build slim
+
+package imageprocessing
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPngConverterCommand_SlimRejectsDisabledFormats(t *testing.T) {
+	inputs := map[string][]byte{
+		"svg":  []byte(`<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10"/>`),
+		"tiff": []byte("II*\x00\x08\x00\x00\x00"),
+		"webp": []byte("RIFF\x24\x00\x00\x00WEBPVP8 "),
+	}
+	command, err := NewPngConverterCommand(map[string]any{"svgFallbackLongSidePixelCount": 64})
+	if err != nil {
+		t.Fatalf("Failed to create command: %v", err)
+	}
+	for format, data := range inputs {
+		t.Run(format, func(t *testing.T) {
+			if _, err := command.Execute(data); !errors.Is(err, ErrFormatDisabled) {
+				t.Errorf("Execute() error = %v, want ErrFormatDisabled", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+//go:build !slim
+
+package imageprocessing
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"testing"
+	"time"
+
+	"golang.org/x/image/tiff"
+)
+
+func TestPngConverterCommand_RenderSVG(t *testing.T) {
+	// Minimal inline SVG (red square) without explicit width/height to trigger fallback sizing
+	svgData := []byte(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 100 100"><rect width="100" height="100" fill="red"/></svg>`)
+
+	// Target small size for test
+	params := map[string]any{
+		"svgFallbackLongSidePixelCount": 64,
+	}
+	command, err := NewPngConverterCommand(params)
+	if err != nil {
+		t.Fatalf("Failed to create command: %v", err)
+	}
+
+	result, err := command.Execute(svgData)
+	if err != nil {
+		t.Fatalf("Execute failed for SVG: %v", err)
+	}
+	if len(result) == 0 {
+		t.Fatal("Expected non-empty PNG result for SVG input")
+	}
+
+	// Verify result is valid PNG and matches target dimensions
+	img, err := png.Decode(bytes.NewReader(result))
+	if err != nil {
+		t.Fatalf("Rendered SVG result is not valid PNG: %v", err)
+	}
+	b := img.Bounds()
+	if b.Dx() != 64 || b.Dy() != 64 {
+		t.Fatalf("Expected PNG dimensions 64x64, got %dx%d", b.Dx(), b.Dy())
+	}
+}
+
+func TestPngConverterCommand_SVGLimits(t *testing.T) {
+	square := []byte(`<svg xmlns="http://www.w3.org/2000/svg" width="20000" height="10000"><rect width="20000" height="10000" fill="red"/></svg>`)
+
+	t.Run("scales oversized SVGs down", func(t *testing.T) {
+		command, err := NewPngConverterCommand(map[string]any{"svgMaxLongSidePixelCount": 100})
+		if err != nil {
+			t.Fatalf("Failed to create command: %v", err)
+		}
+		result, err := command.Execute(square)
+		if err != nil {
+			t.Fatalf("Execute failed for SVG: %v", err)
+		}
+		img, err := png.Decode(bytes.NewReader(result))
+		if err != nil {
+			t.Fatalf("Rendered SVG result is not valid PNG: %v", err)
+		}
+		if b := img.Bounds(); b.Dx() != 100 || b.Dy() != 50 {
+			t.Errorf("Expected PNG dimensions 100x50, got %dx%d", b.Dx(), b.Dy())
+		}
+	})
+
+	t.Run("rejects too many elements", func(t *testing.T) {
+		command, err := NewPngConverterCommand(map[string]any{"svgMaxElements": 1})
+		if err != nil {
+			t.Fatalf("Failed to create command: %v", err)
+		}
+		if _, err := command.Execute(square); !errors.Is(err, ErrSVGLimit) {
+			t.Errorf("Expected ErrSVGLimit, got %v", err)
+		}
+	})
+
+	t.Run("abandons slow rendering", func(t *testing.T) {
+		command, err := NewPngConverterCommand(map[string]any{"svgMaxLongSidePixelCount": 100, "svgRenderTimeout": time.Nanosecond})
+		if err != nil {
+			t.Fatalf("Failed to create command: %v", err)
+		}
+		if _, err := command.Execute(square); !errors.Is(err, ErrSVGLimit) {
+			t.Errorf("Expected ErrSVGLimit, got %v", err)
+		}
+	})
+}
+
+func TestCountSVGElements(t *testing.T) {
+	svg := []byte(`<?xml version="1.0"?><!-- note --><svg><g><rect/><circle></circle></g></svg>`)
+	if got := countSVGElements(svg); got != 4 {
+		t.Errorf("countSVGElements = %d, want 4", got)
+	}
+}
+
+func TestPngConverterCommand_ConvertsTIFF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := tiff.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 3, 2)), nil); err != nil {
+		t.Fatalf("encode TIFF: %v", err)
+	}
+
+	result, err := NewPngConverterCommandDirect().Execute(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Execute failed for TIFF: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(result))
+	if err != nil {
+		t.Fatalf("result is not valid PNG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 3 || b.Dy() != 2 {
+		t.Errorf("dimensions = %dx%d, want 3x2", b.Dx(), b.Dy())
+	}
+}
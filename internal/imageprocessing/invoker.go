@@ -1,7 +1,9 @@
 package imageprocessing
 
 import (
+	"bytes"
 	"fmt"
+	"image"
 	"log/slog"
 	"time"
 )
@@ -18,6 +20,76 @@ func NewCommandInvoker(commands []Command) *CommandInvoker {
 	}
 }
 
+// pipelineImage is the image passed between the steps of a pipeline. It is
+// kept decoded between ImageCommand steps and only encoded when a step needs
+// PNG bytes or the pipeline ends, so a pipeline of ImageCommands decodes and
+// encodes once.
+type pipelineImage struct {
+	// data is the PNG encoding of the image; nil when only img is current.
+	data []byte
+	// img is the decoded image; nil until a step needs it.
+	img image.Image
+}
+
+// run applies command to the image.
+func (p *pipelineImage) run(command Command) error {
+	if imageCommand, ok := command.(ImageCommand); ok {
+		if p.img == nil {
+			img, err := decodePNG(p.data)
+			if err != nil {
+				return fmt.Errorf("failed to decode PNG image: %w", err)
+			}
+			p.img = img
+		}
+		out, err := imageCommand.ExecuteImage(p.img)
+		if err != nil {
+			return err
+		}
+		// A step returning its input leaves the encoding current.
+		if out != p.img {
+			p.data, p.img = nil, out
+		}
+		return nil
+	}
+
+	data, err := p.bytes()
+	if err != nil {
+		return err
+	}
+	out, err := command.Execute(data)
+	if err != nil {
+		return err
+	}
+	p.data, p.img = out, nil
+	return nil
+}
+
+// bytes returns the PNG encoding of the image, encoding it if necessary.
+func (p *pipelineImage) bytes() ([]byte, error) {
+	if p.data == nil {
+		data, err := encodePNG(p.img)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode PNG image: %w", err)
+		}
+		p.data = data
+	}
+	return p.data, nil
+}
+
+// pixels returns the number of pixels of the image, or 0 when its encoding
+// cannot be read.
+func (p *pipelineImage) pixels() int64 {
+	if p.img != nil {
+		b := p.img.Bounds()
+		return int64(b.Dx()) * int64(b.Dy())
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(p.data))
+	if err != nil {
+		return 0
+	}
+	return int64(cfg.Width) * int64(cfg.Height)
+}
+
 // Execute applies all commands in sequence to the image data
 func (i *CommandInvoker) Execute(imageData []byte) ([]byte, error) {
 	start := time.Now()
@@ -31,49 +103,62 @@ func (i *CommandInvoker) Execute(imageData []byte) ([]byte, error) {
 		return imageData, nil
 	}
 
-	currentData := imageData
+	current := &pipelineImage{data: imageData}
 
 	for idx, command := range i.commands {
 		commandStart := time.Now()
+		pixels := current.pixels()
 
 		slog.Info("executing command",
 			"index", idx,
 			"command_name", command.Name(),
-			"input_size_bytes", len(currentData))
+			"input_pixels", pixels)
 
 		// Execute the command
-		processedData, err := command.Execute(currentData)
-		if err != nil {
+		if err := current.run(command); err != nil {
+			pipelineMetrics.record(command.Name(), pixels, 0, time.Since(commandStart), true)
 			slog.Error("command execution failed",
 				"index", idx,
 				"command_name", command.Name(),
 				"error", err,
-				"input_size_bytes", len(currentData))
+				"input_pixels", pixels)
 			return nil, fmt.Errorf("command %s (index %d) failed: %w", command.Name(), idx, err)
 		}
 
 		commandDuration := time.Since(commandStart)
+		pipelineCosts.record(command.Name(), pixels, commandDuration)
+		pipelineMetrics.record(command.Name(), pixels, current.pixels(), commandDuration, false)
 		slog.Info("command completed",
 			"index", idx,
 			"command_name", command.Name(),
 			"duration_ms", commandDuration.Milliseconds(),
-			"input_size_bytes", len(currentData),
-			"output_size_bytes", len(processedData))
+			"input_pixels", pixels)
+	}
 
-		currentData = processedData
+	out, err := current.bytes()
+	if err != nil {
+		return nil, err
 	}
 
 	totalDuration := time.Since(start)
 	slog.Info("image processing pipeline completed",
 		"total_duration_ms", totalDuration.Milliseconds(),
 		"command_count", len(i.commands),
-		"final_size_bytes", len(currentData))
+		"final_size_bytes", len(out))
 
-	return currentData, nil
+	return out, nil
 }
 
 // ExecuteCommands applies a sequence of commands to an image in order
 func ExecuteCommands(imageData []byte, commandConfigs []CommandConfig) ([]byte, error) {
+	out, _, err := ExecuteCommandsWithPreset(imageData, commandConfigs)
+	return out, err
+}
+
+// ExecuteCommandsWithPreset applies a sequence of commands like ExecuteCommands
+// and also returns the preset chosen by the last PresetSelector command, or ""
+// when no command selected one.
+func ExecuteCommandsWithPreset(imageData []byte, commandConfigs []CommandConfig) ([]byte, string, error) {
 	start := time.Now()
 
 	slog.Info("starting image processing pipeline",
@@ -82,10 +167,11 @@ func ExecuteCommands(imageData []byte, commandConfigs []CommandConfig) ([]byte,
 
 	if len(commandConfigs) == 0 {
 		slog.Debug("no commands configured, returning original image")
-		return imageData, nil
+		return imageData, "", nil
 	}
 
-	currentData := imageData
+	current := &pipelineImage{data: imageData}
+	preset := ""
 
 	for i, config := range commandConfigs {
 		commandStart := time.Now()
@@ -102,41 +188,49 @@ func ExecuteCommands(imageData []byte, commandConfigs []CommandConfig) ([]byte,
 				"index", i,
 				"command_name", config.Name,
 				"error", err)
-			return nil, fmt.Errorf("failed to create command at index %d (%s): %w", i, config.Name, err)
+			return nil, "", fmt.Errorf("failed to create command at index %d (%s): %w", i, config.Name, err)
 		}
 
+		pixels := current.pixels()
 		slog.Info("executing command",
 			"index", i,
 			"command_name", config.Name,
-			"input_size_bytes", len(currentData))
+			"input_pixels", pixels)
 
 		// Execute the command
-		processedData, err := command.Execute(currentData)
-		if err != nil {
+		if err := current.run(command); err != nil {
+			pipelineMetrics.record(config.Name, pixels, 0, time.Since(commandStart), true)
 			slog.Error("command execution failed",
 				"index", i,
 				"command_name", config.Name,
 				"error", err,
-				"input_size_bytes", len(currentData))
-			return nil, fmt.Errorf("command %s (index %d) failed: %w", config.Name, i, err)
+				"input_pixels", pixels)
+			return nil, "", fmt.Errorf("command %s (index %d) failed: %w", config.Name, i, err)
+		}
+		if selector, ok := command.(PresetSelector); ok && selector.SelectedPreset() != "" {
+			preset = selector.SelectedPreset()
 		}
 
 		commandDuration := time.Since(commandStart)
+		pipelineCosts.record(config.Name, pixels, commandDuration)
+		pipelineMetrics.record(config.Name, pixels, current.pixels(), commandDuration, false)
 		slog.Info("command completed",
 			"index", i,
 			"command_name", config.Name,
 			"duration_ms", commandDuration.Milliseconds(),
-			"input_size_bytes", len(currentData),
-			"output_size_bytes", len(processedData))
+			"input_pixels", pixels)
+	}
 
-		currentData = processedData
+	out, err := current.bytes()
+	if err != nil {
+		return nil, "", err
 	}
 
 	totalDuration := time.Since(start)
 	slog.Info("image processing pipeline completed",
 		"total_duration_ms", totalDuration.Milliseconds(),
 		"command_count", len(commandConfigs),
-		"final_size_bytes", len(currentData))
+		"final_size_bytes", len(out))
 
-	return currentData, nil
+	return out, preset, nil
 }
@@ -1,7 +1,9 @@
 package imageprocessing
 
 import (
+	"bytes"
 	"errors"
+	"image/color"
 	"testing"
 )
 
@@ -153,3 +155,79 @@ func TestCommandInvoker_ErrorInMiddle(t *testing.T) {
 		t.Error("Expected non-empty error message")
 	}
 }
+
+func TestExecuteCommands_DecodedPipelineMatchesSteps(t *testing.T) {
+	input := patternPNG(t, 40, 20, func(x, y int) color.NRGBA {
+		return color.NRGBA{R: uint8(x * 6), G: uint8(y * 12), B: 80, A: 255}
+	})
+	configs := []CommandConfig{
+		{Name: "RegionCropCommand", Params: map[string]any{"x": 0.25, "width": 0.5, "height": 1}},
+		{Name: "SaturationCommand", Params: map[string]any{"factor": 1.5}},
+		{Name: "RotationCommand", Params: map[string]any{"steps": 1}},
+	}
+
+	stepwise := input
+	for _, config := range configs {
+		var err error
+		if stepwise, err = ExecuteCommands(stepwise, []CommandConfig{config}); err != nil {
+			t.Fatalf("%s failed: %v", config.Name, err)
+		}
+	}
+	output, err := ExecuteCommands(input, configs)
+	if err != nil {
+		t.Fatalf("ExecuteCommands failed: %v", err)
+	}
+
+	want, got := mustDecode(t, stepwise), mustDecode(t, output)
+	if want.Bounds() != got.Bounds() {
+		t.Fatalf("expected bounds %v, got %v", want.Bounds(), got.Bounds())
+	}
+	for y := got.Bounds().Min.Y; y < got.Bounds().Max.Y; y++ {
+		for x := got.Bounds().Min.X; x < got.Bounds().Max.X; x++ {
+			if want.At(x, y) != got.At(x, y) {
+				t.Fatalf("pixel (%d, %d) differs: expected %v, got %v", x, y, want.At(x, y), got.At(x, y))
+			}
+		}
+	}
+}
+
+func TestExecuteCommands_UnchangedImageKeepsEncoding(t *testing.T) {
+	input := patternPNG(t, 10, 10, func(x, y int) color.NRGBA { return gray(x * 20) })
+	output, err := ExecuteCommands(input, []CommandConfig{
+		{Name: "RegionCropCommand", Params: map[string]any{"width": 1, "height": 1}},
+		{Name: "SaturationCommand", Params: map[string]any{"factor": 1}},
+	})
+	if err != nil {
+		t.Fatalf("ExecuteCommands failed: %v", err)
+	}
+	if !bytes.Equal(output, input) {
+		t.Error("expected steps leaving the image unchanged to return the input bytes")
+	}
+}
+
+func TestCommandInvoker_MixesImageAndByteCommands(t *testing.T) {
+	input := patternPNG(t, 10, 6, func(x, y int) color.NRGBA { return gray(y * 40) })
+	rotate, err := NewRotationCommandWithParams(1, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var received []byte
+	inspect := &mockCommand{
+		name: "Inspect",
+		executeFunc: func(data []byte) ([]byte, error) {
+			received = data
+			return data, nil
+		},
+	}
+
+	output, err := NewCommandInvoker([]Command{rotate, inspect, rotate}).Execute(input)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if got := mustDecode(t, received).Bounds().Size(); got.X != 6 || got.Y != 10 {
+		t.Errorf("expected the byte command to receive the rotated 6x10 PNG, got %v", got)
+	}
+	if got := mustDecode(t, output).Bounds().Size(); got.X != 10 || got.Y != 6 {
+		t.Errorf("expected two quarter turns to give 10x6, got %v", got)
+	}
+}
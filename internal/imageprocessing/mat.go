@@ -0,0 +1,86 @@
+package imageprocessing
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// ParseHexColor parses a "#rrggbb" color.
+func ParseHexColor(s string) (color.RGBA, error) {
+	hex, ok := strings.CutPrefix(s, "#")
+	if !ok || len(hex) != 6 {
+		return color.RGBA{}, fmt.Errorf("color %q must have the form #rrggbb", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("color %q must have the form #rrggbb", s)
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 255}, nil
+}
+
+// FormatHexColor formats c as "#rrggbb", the form ParseHexColor reads.
+func FormatHexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// DrawMat paints a border of the given width and color over the edges of the
+// PNG image, like a mat covering the edges of a print. A positive radius rounds
+// the corners of the opening. The image keeps its dimensions so it still
+// matches the panel; choose a color from the panel's palette to avoid
+// introducing colors it cannot show. A zero width and radius returns the image
+// unchanged.
+func DrawMat(imageData []byte, c color.Color, width, radius int) ([]byte, error) {
+	if width <= 0 && radius <= 0 {
+		return imageData, nil
+	}
+
+	img, err := decodePNG(imageData)
+	if err != nil {
+		slog.Error("DrawMat: failed to decode PNG image", "error", err)
+		return nil, fmt.Errorf("failed to decode PNG image: %w", err)
+	}
+
+	bounds := img.Bounds()
+	dst := image.NewRGBA(bounds)
+	draw.Draw(dst, bounds, img, bounds.Min, draw.Src)
+
+	opening := bounds.Inset(max(width, 0))
+	matColor := rgba8(c)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if !insideRoundedRect(x, y, opening, radius) {
+				i := dst.PixOffset(x, y)
+				copy(dst.Pix[i:i+4], matColor[:])
+			}
+		}
+	}
+
+	out, err := encodePNG(dst)
+	if err != nil {
+		slog.Error("DrawMat: failed to encode image", "error", err)
+		return nil, fmt.Errorf("failed to encode PNG image: %w", err)
+	}
+	return out, nil
+}
+
+// insideRoundedRect reports whether the pixel at (x, y) lies within r with its
+// corners rounded by radius. Pixels are tested at their centers.
+func insideRoundedRect(x, y int, r image.Rectangle, radius int) bool {
+	if !(image.Point{X: x, Y: y}).In(r) {
+		return false
+	}
+	radius = min(radius, r.Dx()/2, r.Dy()/2)
+	if radius <= 0 {
+		return true
+	}
+	// Distance from the pixel center to the nearest corner circle center.
+	cx := min(max(float64(x)+0.5, float64(r.Min.X+radius)), float64(r.Max.X-radius))
+	cy := min(max(float64(y)+0.5, float64(r.Min.Y+radius)), float64(r.Max.Y-radius))
+	dx, dy := float64(x)+0.5-cx, float64(y)+0.5-cy
+	return dx*dx+dy*dy <= float64(radius*radius)
+}
@@ -0,0 +1,67 @@
+package imageprocessing
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestParseHexColor(t *testing.T) {
+	got, err := ParseHexColor("#1a2B3c")
+	if err != nil {
+		t.Fatalf("ParseHexColor failed: %v", err)
+	}
+	if got != (color.RGBA{R: 0x1a, G: 0x2b, B: 0x3c, A: 255}) {
+		t.Errorf("ParseHexColor = %v", got)
+	}
+	for _, s := range []string{"", "1a2b3c", "#123", "#gggggg"} {
+		if _, err := ParseHexColor(s); err == nil {
+			t.Errorf("expected error for %q", s)
+		}
+	}
+}
+
+func TestDrawMat(t *testing.T) {
+	white := color.RGBA{255, 255, 255, 255}
+	red := color.RGBA{255, 0, 0, 255}
+	out, err := DrawMat(solidPNG(t, 20, 10, white), red, 2, 3)
+	if err != nil {
+		t.Fatalf("DrawMat failed: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("decoding result failed: %v", err)
+	}
+	if img.Bounds().Dx() != 20 || img.Bounds().Dy() != 10 {
+		t.Fatalf("expected dimensions to be kept, got %v", img.Bounds())
+	}
+
+	tests := []struct {
+		x, y int
+		want color.RGBA
+	}{
+		{0, 5, red},    // border
+		{19, 5, red},   // border
+		{10, 1, red},   // border
+		{10, 5, white}, // opening
+		{2, 2, red},    // rounded corner of the opening
+		{4, 4, white},  // inside the corner arc
+	}
+	for _, tt := range tests {
+		if got := color.RGBAModel.Convert(img.At(tt.x, tt.y)).(color.RGBA); got != tt.want {
+			t.Errorf("pixel (%d,%d) = %v, want %v", tt.x, tt.y, got, tt.want)
+		}
+	}
+}
+
+func TestDrawMat_NoopWithoutWidthOrRadius(t *testing.T) {
+	data := solidPNG(t, 4, 4, color.RGBA{0, 0, 0, 255})
+	out, err := DrawMat(data, color.White, 0, 0)
+	if err != nil {
+		t.Fatalf("DrawMat failed: %v", err)
+	}
+	if !bytes.Equal(out, data) {
+		t.Error("expected the image to be returned unchanged")
+	}
+}
@@ -0,0 +1,155 @@
+package imageprocessing
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// recentRunCount is the number of command runs kept for graphing.
+const recentRunCount = 256
+
+var (
+	// durationBoundsMs are the upper bounds of the duration histogram buckets.
+	durationBoundsMs = []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+	// sizeBoundsBytes are the upper bounds of the image size histogram buckets.
+	sizeBoundsBytes = []float64{64 << 10, 256 << 10, 1 << 20, 4 << 20, 16 << 20, 64 << 20, 256 << 20}
+)
+
+// Histogram counts observations per bucket. Counts[i] holds the observations
+// up to Bounds[i]; the last count holds those above every bound.
+type Histogram struct {
+	Bounds []float64 `json:"bounds"`
+	Counts []int64   `json:"counts"`
+	Sum    float64   `json:"sum"`
+	Count  int64     `json:"count"`
+}
+
+func newHistogram(bounds []float64) Histogram {
+	return Histogram{Bounds: bounds, Counts: make([]int64, len(bounds)+1)}
+}
+
+func (h *Histogram) observe(v float64) {
+	i, _ := slices.BinarySearch(h.Bounds, v)
+	h.Counts[i]++
+	h.Sum += v
+	h.Count++
+}
+
+// CommandMetrics describes the runs of one command since the server started.
+// Sizes are those of the decoded image, 4 bytes per pixel, as images are
+// passed between commands decoded.
+type CommandMetrics struct {
+	Command string `json:"command"`
+	Runs    int64  `json:"runs"`
+	// Errors counts the runs that failed.
+	Errors      int64     `json:"errors"`
+	DurationMs  Histogram `json:"durationMs"`
+	InputBytes  Histogram `json:"inputBytes"`
+	OutputBytes Histogram `json:"outputBytes"`
+}
+
+// CommandRun is one recent run of a command.
+type CommandRun struct {
+	Command     string    `json:"command"`
+	At          time.Time `json:"at"`
+	DurationMs  float64   `json:"durationMs"`
+	InputBytes  int64     `json:"inputBytes"`
+	OutputBytes int64     `json:"outputBytes"`
+	Failed      bool      `json:"failed,omitempty"`
+}
+
+// PipelineMetrics is a snapshot of the command runs of all pipelines.
+type PipelineMetrics struct {
+	// Commands are sorted by name.
+	Commands []CommandMetrics `json:"commands"`
+	// Recent are the last runs, oldest first.
+	Recent []CommandRun `json:"recent"`
+}
+
+// metricsRecorder collects the runs of every pipeline.
+type metricsRecorder struct {
+	mu       sync.Mutex
+	commands map[string]*CommandMetrics
+	// recent is a ring buffer; next is the index the next run is written to.
+	recent []CommandRun
+	next   int
+	nowFn  func() time.Time
+}
+
+// pipelineMetrics is fed by every pipeline run.
+var pipelineMetrics = newMetricsRecorder()
+
+func newMetricsRecorder() *metricsRecorder {
+	return &metricsRecorder{commands: make(map[string]*CommandMetrics), nowFn: time.Now}
+}
+
+// record adds a run of command that turned an image of inputPixels into one
+// of outputPixels in d; outputPixels is ignored for failed runs.
+func (r *metricsRecorder) record(command string, inputPixels, outputPixels int64, d time.Duration, failed bool) {
+	run := CommandRun{
+		Command:    command,
+		At:         r.nowFn(),
+		DurationMs: float64(d.Microseconds()) / 1000,
+		InputBytes: inputPixels * 4,
+		Failed:     failed,
+	}
+	if !failed {
+		run.OutputBytes = outputPixels * 4
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m, ok := r.commands[command]
+	if !ok {
+		m = &CommandMetrics{
+			Command:     command,
+			DurationMs:  newHistogram(durationBoundsMs),
+			InputBytes:  newHistogram(sizeBoundsBytes),
+			OutputBytes: newHistogram(sizeBoundsBytes),
+		}
+		r.commands[command] = m
+	}
+	m.Runs++
+	m.DurationMs.observe(run.DurationMs)
+	m.InputBytes.observe(float64(run.InputBytes))
+	if failed {
+		m.Errors++
+	} else {
+		m.OutputBytes.observe(float64(run.OutputBytes))
+	}
+
+	if len(r.recent) < recentRunCount {
+		r.recent = append(r.recent, run)
+		return
+	}
+	r.recent[r.next] = run
+	r.next = (r.next + 1) % recentRunCount
+}
+
+func (r *metricsRecorder) snapshot() PipelineMetrics {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	snapshot := PipelineMetrics{
+		Commands: make([]CommandMetrics, 0, len(r.commands)),
+		Recent:   make([]CommandRun, 0, len(r.recent)),
+	}
+	for _, m := range r.commands {
+		c := *m
+		c.DurationMs.Counts = slices.Clone(m.DurationMs.Counts)
+		c.InputBytes.Counts = slices.Clone(m.InputBytes.Counts)
+		c.OutputBytes.Counts = slices.Clone(m.OutputBytes.Counts)
+		snapshot.Commands = append(snapshot.Commands, c)
+	}
+	slices.SortFunc(snapshot.Commands, func(a, b CommandMetrics) int { return strings.Compare(a.Command, b.Command) })
+	snapshot.Recent = append(snapshot.Recent, r.recent[r.next:]...)
+	snapshot.Recent = append(snapshot.Recent, r.recent[:r.next]...)
+	return snapshot
+}
+
+// GetPipelineMetrics returns the metrics of the commands run since the server
+// started.
+func GetPipelineMetrics() PipelineMetrics {
+	return pipelineMetrics.snapshot()
+}
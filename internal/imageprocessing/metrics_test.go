@@ -0,0 +1,69 @@
+package imageprocessing
+
+import (
+	"image/color"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestMetricsRecorder_Histograms(t *testing.T) {
+	r := newMetricsRecorder()
+	r.record("ScaleCommand", 100, 50, 3*time.Millisecond, false)
+	r.record("ScaleCommand", 100, 0, 30*time.Millisecond, true)
+	r.record("DitherCommand", 1<<20, 1<<20, 20*time.Second, false)
+
+	m := r.snapshot()
+	if len(m.Commands) != 2 || m.Commands[0].Command != "DitherCommand" || m.Commands[1].Command != "ScaleCommand" {
+		t.Fatalf("expected metrics for both commands sorted by name, got %+v", m.Commands)
+	}
+	scale := m.Commands[1]
+	if scale.Runs != 2 || scale.Errors != 1 {
+		t.Errorf("expected 2 runs and 1 error, got %d and %d", scale.Runs, scale.Errors)
+	}
+	// 3ms falls into the 5ms bucket and 30ms into the 50ms bucket.
+	if want := []int64{0, 1, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0}; !slices.Equal(scale.DurationMs.Counts, want) {
+		t.Errorf("expected duration counts %v, got %v", want, scale.DurationMs.Counts)
+	}
+	if scale.InputBytes.Count != 2 || scale.InputBytes.Sum != 800 || scale.OutputBytes.Count != 1 || scale.OutputBytes.Sum != 200 {
+		t.Errorf("expected the sizes of the decoded images, got input %+v and output %+v", scale.InputBytes, scale.OutputBytes)
+	}
+	if dither := m.Commands[0]; dither.DurationMs.Counts[len(durationBoundsMs)] != 1 {
+		t.Errorf("expected 20s to fall into the overflow bucket, got %v", dither.DurationMs.Counts)
+	}
+}
+
+func TestMetricsRecorder_RecentRing(t *testing.T) {
+	r := newMetricsRecorder()
+	for i := range recentRunCount + 10 {
+		r.record("ScaleCommand", int64(i+1), 1, time.Millisecond, false)
+	}
+	recent := r.snapshot().Recent
+	if len(recent) != recentRunCount {
+		t.Fatalf("expected %d recent runs, got %d", recentRunCount, len(recent))
+	}
+	if recent[0].InputBytes != 11*4 || recent[len(recent)-1].InputBytes != (recentRunCount+10)*4 {
+		t.Errorf("expected the last runs oldest first, got %d ... %d", recent[0].InputBytes, recent[len(recent)-1].InputBytes)
+	}
+}
+
+func TestExecuteCommands_RecordsMetrics(t *testing.T) {
+	before := commandRuns("SaturationCommand")
+	input := patternPNG(t, 4, 4, func(x, y int) color.NRGBA { return gray(x * 60) })
+	if _, err := ExecuteCommands(input, []CommandConfig{{Name: "SaturationCommand", Params: map[string]any{"factor": 2}}}); err != nil {
+		t.Fatalf("ExecuteCommands failed: %v", err)
+	}
+	if got := commandRuns("SaturationCommand"); got != before+1 {
+		t.Errorf("expected the run to be recorded, got %d runs after %d", got, before)
+	}
+}
+
+// commandRuns returns the recorded runs of command.
+func commandRuns(command string) int64 {
+	for _, m := range GetPipelineMetrics().Commands {
+		if m.Command == command {
+			return m.Runs
+		}
+	}
+	return 0
+}
@@ -161,24 +161,29 @@ func readIFD0Orientation(tiff []byte, ifdOffset uint32, bo binary.ByteOrder) (No
 
 // flipHorizontal mirrors img left-to-right.
 func flipHorizontal(img image.Image) image.Image {
-	b := img.Bounds()
+	src := toRGBA(img)
+	b := src.Bounds()
+	w := b.Dx()
 	dst := image.NewRGBA(b)
-	for y := b.Min.Y; y < b.Max.Y; y++ {
-		for x := b.Min.X; x < b.Max.X; x++ {
-			dst.Set(b.Max.X-1-x+b.Min.X, y, img.At(x, y))
+	parallelFor(b.Dy(), func(y int) {
+		from := src.Pix[src.PixOffset(b.Min.X, b.Min.Y+y):]
+		to := dst.Pix[dst.PixOffset(b.Min.X, b.Min.Y+y):]
+		for x := 0; x < w; x++ {
+			copy(to[(w-1-x)*4:(w-x)*4], from[x*4:x*4+4])
 		}
-	}
+	})
 	return dst
 }
 
 // flipVertical mirrors img top-to-bottom.
 func flipVertical(img image.Image) image.Image {
-	b := img.Bounds()
+	src := toRGBA(img)
+	b := src.Bounds()
 	dst := image.NewRGBA(b)
 	for y := b.Min.Y; y < b.Max.Y; y++ {
-		for x := b.Min.X; x < b.Max.X; x++ {
-			dst.Set(x, b.Max.Y-1-y+b.Min.Y, img.At(x, y))
-		}
+		from := src.Pix[src.PixOffset(b.Min.X, y):]
+		to := dst.Pix[dst.PixOffset(b.Min.X, b.Max.Y-1-y+b.Min.Y):]
+		copy(to[:4*b.Dx()], from)
 	}
 	return dst
 }
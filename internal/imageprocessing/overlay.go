@@ -0,0 +1,213 @@
+package imageprocessing
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"log/slog"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// OverlayPosition is the corner of the image an overlay panel is drawn in.
+type OverlayPosition string
+
+const (
+	OverlayTopLeft     OverlayPosition = "topLeft"
+	OverlayTopRight    OverlayPosition = "topRight"
+	OverlayBottomLeft  OverlayPosition = "bottomLeft"
+	OverlayBottomRight OverlayPosition = "bottomRight"
+)
+
+// OverlayIcon is a small pictogram drawn in front of an overlay line.
+type OverlayIcon string
+
+const (
+	OverlayIconNone    OverlayIcon = ""
+	OverlayIconClear   OverlayIcon = "clear"
+	OverlayIconCloudy  OverlayIcon = "cloudy"
+	OverlayIconFog     OverlayIcon = "fog"
+	OverlayIconRain    OverlayIcon = "rain"
+	OverlayIconSnow    OverlayIcon = "snow"
+	OverlayIconStorm   OverlayIcon = "storm"
+	OverlayIconBattery OverlayIcon = "battery"
+)
+
+// OverlayItem is one line of an overlay panel.
+type OverlayItem struct {
+	Icon OverlayIcon
+	Text string
+	// Level is the fill of the battery icon between 0 and 1.
+	Level float64
+}
+
+const (
+	// overlayIconSize is the width and height of icons; it matches the font height.
+	overlayIconSize = 13
+	// overlayIconGap is the space in pixels between an icon and its text.
+	overlayIconGap = 4
+)
+
+// DrawOverlay renders items as a panel of black-on-white lines in the given
+// corner of the PNG image, like DrawAttributionOverlay. Lines that do not fit
+// the image width are truncated; lines beyond the image height are dropped.
+// Images too small for a single line are returned unchanged.
+func DrawOverlay(imageData []byte, items []OverlayItem, position OverlayPosition) ([]byte, error) {
+	if len(items) == 0 {
+		return imageData, nil
+	}
+
+	img, err := decodePNG(imageData)
+	if err != nil {
+		slog.Error("DrawOverlay: failed to decode PNG image", "error", err)
+		return nil, fmt.Errorf("failed to decode PNG image: %w", err)
+	}
+
+	face := basicfont.Face7x13
+	bounds := img.Bounds()
+	inset := attributionMargin + attributionPadding
+	maxLines := (bounds.Dy() - 2*inset) / face.Height
+	items = items[:min(len(items), max(maxLines, 0))]
+
+	lineWidths := make([]int, len(items))
+	texts := make([]string, len(items))
+	boxWidth := 0
+	for i, item := range items {
+		iconWidth := 0
+		if item.Icon != OverlayIconNone {
+			iconWidth = overlayIconSize + overlayIconGap
+		}
+		texts[i] = truncateToWidth(face, item.Text, bounds.Dx()-2*inset-iconWidth)
+		lineWidths[i] = iconWidth + font.MeasureString(face, texts[i]).Ceil()
+		boxWidth = max(boxWidth, lineWidths[i])
+	}
+	if len(items) == 0 || boxWidth == 0 {
+		slog.Debug("DrawOverlay: image too small for overlay; skipping",
+			"width", bounds.Dx(), "height", bounds.Dy())
+		return imageData, nil
+	}
+	boxWidth += 2 * attributionPadding
+	boxHeight := len(items)*face.Height + 2*attributionPadding
+
+	box := image.Rect(0, 0, boxWidth, boxHeight)
+	switch position {
+	case OverlayTopRight:
+		box = box.Add(image.Pt(bounds.Max.X-attributionMargin-boxWidth, bounds.Min.Y+attributionMargin))
+	case OverlayBottomLeft:
+		box = box.Add(image.Pt(bounds.Min.X+attributionMargin, bounds.Max.Y-attributionMargin-boxHeight))
+	case OverlayBottomRight:
+		box = box.Add(image.Pt(bounds.Max.X-attributionMargin-boxWidth, bounds.Max.Y-attributionMargin-boxHeight))
+	default:
+		box = box.Add(image.Pt(bounds.Min.X+attributionMargin, bounds.Min.Y+attributionMargin))
+	}
+
+	dst := image.NewRGBA(bounds)
+	draw.Draw(dst, bounds, img, bounds.Min, draw.Src)
+	draw.Draw(dst, box, &image.Uniform{color.White}, image.Point{}, draw.Src)
+
+	for i, item := range items {
+		x := box.Min.X + attributionPadding
+		y := box.Min.Y + attributionPadding + i*face.Height
+		if item.Icon != OverlayIconNone {
+			drawOverlayIcon(dst, image.Pt(x, y), item.Icon, item.Level)
+			x += overlayIconSize + overlayIconGap
+		}
+		drawer := &font.Drawer{
+			Dst:  dst,
+			Src:  &image.Uniform{color.Black},
+			Face: face,
+			Dot:  fixed.P(x, y+face.Ascent),
+		}
+		drawer.DrawString(texts[i])
+	}
+
+	out, err := encodePNG(dst)
+	if err != nil {
+		slog.Error("DrawOverlay: failed to encode image", "error", err)
+		return nil, fmt.Errorf("failed to encode PNG image: %w", err)
+	}
+	return out, nil
+}
+
+// drawOverlayIcon draws icon in black into the overlayIconSize square at origin.
+func drawOverlayIcon(dst draw.Image, origin image.Point, icon OverlayIcon, level float64) {
+	set := func(x, y int) {
+		dst.Set(origin.X+x, origin.Y+y, color.Black)
+	}
+	circle := func(cx, cy, r float64) {
+		for y := range overlayIconSize {
+			for x := range overlayIconSize {
+				dx, dy := float64(x)+0.5-cx, float64(y)+0.5-cy
+				if dx*dx+dy*dy <= r*r {
+					set(x, y)
+				}
+			}
+		}
+	}
+	// cloud draws a cloud whose base is at row bottom.
+	cloud := func(bottom int) {
+		circle(4, float64(bottom)-1.5, 2.5)
+		circle(7, float64(bottom)-3, 3)
+		circle(10, float64(bottom)-1.5, 2.5)
+		for y := bottom - 2; y <= bottom; y++ {
+			for x := 2; x <= 11; x++ {
+				set(x, y)
+			}
+		}
+	}
+
+	switch icon {
+	case OverlayIconClear:
+		circle(6.5, 6.5, 3)
+		for _, p := range [][2]int{{6, 0}, {6, 1}, {6, 11}, {6, 12}, {0, 6}, {1, 6}, {11, 6}, {12, 6}, {2, 2}, {10, 2}, {2, 10}, {10, 10}} {
+			set(p[0], p[1])
+		}
+	case OverlayIconCloudy:
+		cloud(9)
+	case OverlayIconFog:
+		for _, y := range []int{3, 6, 9} {
+			for x := 1 + y%2; x <= 11; x++ {
+				set(x, y)
+			}
+		}
+	case OverlayIconRain:
+		cloud(7)
+		for _, x := range []int{4, 7, 10} {
+			set(x, 9)
+			set(x, 10)
+			set(x-1, 11)
+		}
+	case OverlayIconSnow:
+		cloud(7)
+		for _, p := range [][2]int{{3, 9}, {7, 9}, {11, 9}, {5, 11}, {9, 11}} {
+			set(p[0], p[1])
+		}
+	case OverlayIconStorm:
+		cloud(7)
+		for _, p := range [][2]int{{7, 8}, {6, 9}, {5, 10}, {6, 10}, {7, 10}, {6, 11}, {5, 12}} {
+			set(p[0], p[1])
+		}
+	case OverlayIconBattery:
+		for x := 0; x <= 10; x++ {
+			set(x, 3)
+			set(x, 9)
+		}
+		for y := 3; y <= 9; y++ {
+			set(0, y)
+			set(10, y)
+		}
+		for y := 5; y <= 7; y++ {
+			set(11, y)
+			set(12, y)
+		}
+		fill := int(min(max(level, 0), 1)*7 + 0.5)
+		for y := 5; y <= 7; y++ {
+			for x := 2; x < 2+fill; x++ {
+				set(x, y)
+			}
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package imageprocessing
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestDrawOverlay_DrawsPanelInCorner(t *testing.T) {
+	red := color.RGBA{R: 200, A: 255}
+	items := []OverlayItem{
+		{Text: "12:34"},
+		{Icon: OverlayIconRain, Text: "7C"},
+		{Icon: OverlayIconBattery, Text: "80%", Level: 0.8},
+	}
+	for _, position := range []OverlayPosition{OverlayTopLeft, OverlayTopRight, OverlayBottomLeft, OverlayBottomRight} {
+		t.Run(string(position), func(t *testing.T) {
+			out, err := DrawOverlay(solidPNG(t, 200, 120, red), items, position)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			img, err := png.Decode(bytes.NewReader(out))
+			if err != nil {
+				t.Fatalf("Failed to decode output: %v", err)
+			}
+			if img.Bounds() != image.Rect(0, 0, 200, 120) {
+				t.Fatalf("Expected dimensions to be preserved, got %v", img.Bounds())
+			}
+
+			// The panel starts attributionMargin from its corner and the
+			// opposite corner stays untouched.
+			x, y := attributionMargin, attributionMargin
+			ox, oy := 199, 119
+			switch position {
+			case OverlayTopRight:
+				x, ox = 199-attributionMargin, 0
+			case OverlayBottomLeft:
+				y, oy = 119-attributionMargin, 0
+			case OverlayBottomRight:
+				x, y, ox, oy = 199-attributionMargin, 119-attributionMargin, 0, 0
+			}
+			if img.At(x, y) != (color.RGBA{R: 255, G: 255, B: 255, A: 255}) {
+				t.Errorf("Expected white panel at (%d,%d), got %v", x, y, img.At(x, y))
+			}
+			if img.At(ox, oy) != color.Color(red) {
+				t.Errorf("Expected opposite corner to be unchanged, got %v", img.At(ox, oy))
+			}
+		})
+	}
+}
+
+func TestDrawOverlay_DropsLinesThatDoNotFit(t *testing.T) {
+	items := []OverlayItem{{Text: "a"}, {Text: "b"}, {Text: "c"}, {Text: "d"}}
+	// Room for two lines of the 13 pixel font between the insets.
+	height := 2*(attributionMargin+attributionPadding) + 2*13
+	out, err := DrawOverlay(solidPNG(t, 100, height, color.Black), items, OverlayTopLeft)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("Failed to decode output: %v", err)
+	}
+	// The panel ends right after the second line.
+	bottom := attributionMargin + 2*attributionPadding + 2*13
+	if r, _, _, _ := img.At(attributionMargin, bottom-1).RGBA(); r != 0xffff {
+		t.Errorf("Expected panel to cover two lines")
+	}
+	if r, _, _, _ := img.At(attributionMargin, bottom).RGBA(); r != 0 {
+		t.Errorf("Expected panel to end after two lines")
+	}
+}
+
+func TestDrawOverlay_NoItemsOrTooSmallReturnsInput(t *testing.T) {
+	input := solidPNG(t, 10, 10, color.White)
+	for _, items := range [][]OverlayItem{nil, {{Text: "12:34"}}} {
+		out, err := DrawOverlay(input, items, OverlayTopLeft)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !bytes.Equal(out, input) {
+			t.Error("Expected input bytes to be returned unchanged")
+		}
+	}
+}
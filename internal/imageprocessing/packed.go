@@ -0,0 +1,88 @@
+package imageprocessing
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// PackedMagic starts every image encoded by EncodePacked.
+const PackedMagic = "GFEP"
+
+// packedVersion is the version of the packed format written by EncodePacked.
+const packedVersion = 1
+
+// packedHeaderSize is the size of the fixed part of the packed header.
+const packedHeaderSize = 12
+
+// PackedBitsPerPixel returns the bits per pixel EncodePacked uses for a
+// palette of n colors: 1, 2, 4 or 8.
+func PackedBitsPerPixel(n int) int {
+	switch {
+	case n <= 2:
+		return 1
+	case n <= 4:
+		return 2
+	case n <= 16:
+		return 4
+	}
+	return 8
+}
+
+// EncodePacked encodes img as packed palette indices that e-paper controllers
+// take without decoding, e.g. 1 bit per pixel for black and white and 4 for a
+// 7-color panel. The layout is:
+//
+//	magic "GFEP", version (1 byte), bits per pixel (1),
+//	palette size minus one (1), reserved (1), width (uint16 LE),
+//	height (uint16 LE),
+//	palette as R, G, B bytes per color,
+//	rows top to bottom, leftmost pixel in the most significant bits, each
+//	row padded to a whole byte.
+//
+// Pixels are mapped to the nearest palette color, so antialiased overlays
+// still pack.
+func EncodePacked(img image.Image, palette []color.RGBA) ([]byte, error) {
+	if len(palette) == 0 || len(palette) > 256 {
+		return nil, fmt.Errorf("palette must have between 1 and 256 colors, got %d", len(palette))
+	}
+	b := img.Bounds()
+	if b.Dx() > 0xffff || b.Dy() > 0xffff {
+		return nil, fmt.Errorf("image %dx%d is too large to pack", b.Dx(), b.Dy())
+	}
+	bpp := PackedBitsPerPixel(len(palette))
+	stride := (b.Dx()*bpp + 7) / 8
+
+	out := make([]byte, packedHeaderSize+3*len(palette)+stride*b.Dy())
+	copy(out, PackedMagic)
+	out[4] = packedVersion
+	out[5] = byte(bpp)
+	out[6] = byte(len(palette) - 1) // 256 colors would not fit otherwise
+	binary.LittleEndian.PutUint16(out[8:], uint16(b.Dx()))
+	binary.LittleEndian.PutUint16(out[10:], uint16(b.Dy()))
+	for i, c := range palette {
+		copy(out[packedHeaderSize+3*i:], []byte{c.R, c.G, c.B})
+	}
+	pixels := out[packedHeaderSize+3*len(palette):]
+
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+	indices := make(map[[3]uint8]byte, len(palette))
+	for y := range b.Dy() {
+		row := pixels[y*stride : (y+1)*stride]
+		src := rgba.Pix[y*rgba.Stride:]
+		for x := range b.Dx() {
+			key := [3]uint8{src[4*x], src[4*x+1], src[4*x+2]}
+			index, ok := indices[key]
+			if !ok {
+				index = byte(nearestPaletteIndex(int(key[0]), int(key[1]), int(key[2]), palette))
+				indices[key] = index
+			}
+			bit := x * bpp
+			row[bit/8] |= index << (8 - bpp - bit%8)
+		}
+	}
+	return out, nil
+}
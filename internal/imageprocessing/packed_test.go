@@ -0,0 +1,85 @@
+package imageprocessing
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestEncodePacked_OneBitPerPixel(t *testing.T) {
+	black, white := color.RGBA{A: 255}, color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	// 10x2: the first row alternates starting with white, the second is black.
+	img := image.NewRGBA(image.Rect(0, 0, 10, 2))
+	for x := range 10 {
+		c := black
+		if x%2 == 0 {
+			c = white
+		}
+		img.Set(x, 0, c)
+		img.Set(x, 1, black)
+	}
+
+	out, err := EncodePacked(img, []color.RGBA{black, white})
+	if err != nil {
+		t.Fatalf("EncodePacked failed: %v", err)
+	}
+	header := []byte{'G', 'F', 'E', 'P', 1, 1, 1, 0, 10, 0, 2, 0, 0, 0, 0, 255, 255, 255}
+	if !bytes.HasPrefix(out, header) {
+		t.Fatalf("expected header % x, got % x", header, out[:min(len(out), len(header))])
+	}
+	// Rows are padded to two bytes each.
+	if want := []byte{0b10101010, 0b10000000, 0, 0}; !bytes.Equal(out[len(header):], want) {
+		t.Errorf("expected pixels %08b, got %08b", want, out[len(header):])
+	}
+}
+
+func TestEncodePacked_FourBitsNearestColor(t *testing.T) {
+	palette := []color.RGBA{
+		{A: 255}, {R: 255, G: 255, B: 255, A: 255}, {R: 255, A: 255},
+		{G: 255, A: 255}, {B: 255, A: 255}, {R: 255, G: 255, A: 255}, {R: 255, G: 128, A: 255},
+	}
+	if got := PackedBitsPerPixel(len(palette)); got != 4 {
+		t.Fatalf("expected 4 bits per pixel for 7 colors, got %d", got)
+	}
+	img := image.NewRGBA(image.Rect(0, 0, 3, 1))
+	img.Set(0, 0, color.RGBA{R: 250, G: 10, B: 5, A: 255}) // nearly red
+	img.Set(1, 0, color.RGBA{B: 255, A: 255})
+	img.Set(2, 0, color.RGBA{R: 255, G: 130, A: 255}) // nearly orange
+
+	out, err := EncodePacked(img, palette)
+	if err != nil {
+		t.Fatalf("EncodePacked failed: %v", err)
+	}
+	pixels := out[12+3*len(palette):]
+	if want := []byte{0x24, 0x60}; !bytes.Equal(pixels, want) {
+		t.Errorf("expected pixels % x, got % x", want, pixels)
+	}
+}
+
+func TestEncodePacked_256Colors(t *testing.T) {
+	palette := make([]color.RGBA, 256)
+	for i := range palette {
+		palette[i] = color.RGBA{R: uint8(i), G: uint8(i), B: uint8(i), A: 255}
+	}
+	img := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, color.RGBA{A: 255})
+	img.Set(1, 0, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+
+	out, err := EncodePacked(img, palette)
+	if err != nil {
+		t.Fatalf("EncodePacked failed: %v", err)
+	}
+	if out[5] != 8 || out[6] != 255 {
+		t.Errorf("expected 8 bits per pixel and palette size byte 255, got %d and %d", out[5], out[6])
+	}
+	if pixels := out[12+3*len(palette):]; !bytes.Equal(pixels, []byte{0, 255}) {
+		t.Errorf("expected pixels 00 ff, got % x", pixels)
+	}
+}
+
+func TestEncodePacked_InvalidPalette(t *testing.T) {
+	if _, err := EncodePacked(image.NewRGBA(image.Rect(0, 0, 1, 1)), nil); err == nil {
+		t.Error("expected an error for an empty palette")
+	}
+}
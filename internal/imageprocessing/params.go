@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // GetStringParam safely extracts a string parameter from the params map
@@ -76,6 +77,22 @@ func GetBoolParam(params map[string]any, key string, defaultValue bool) bool {
 	return defaultValue
 }
 
+// GetDurationParam safely extracts a duration parameter from the params map;
+// strings are parsed with time.ParseDuration, e.g. "10s".
+func GetDurationParam(params map[string]any, key string, defaultValue time.Duration) time.Duration {
+	if val, ok := params[key]; ok {
+		switch v := val.(type) {
+		case time.Duration:
+			return v
+		case string:
+			if d, err := time.ParseDuration(strings.TrimSpace(v)); err == nil {
+				return d
+			}
+		}
+	}
+	return defaultValue
+}
+
 // ValidateRequiredParams checks that all required parameters are present
 func ValidateRequiredParams(params map[string]any, required []string) error {
 	for _, key := range required {
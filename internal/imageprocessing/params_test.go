@@ -2,6 +2,7 @@ package imageprocessing
 
 import (
 	"testing"
+	"time"
 )
 
 func TestGetStringParam(t *testing.T) {
@@ -60,6 +61,27 @@ func TestGetIntParam(t *testing.T) {
 	}
 }
 
+func TestGetDurationParam(t *testing.T) {
+	params := map[string]any{
+		"key1": 3 * time.Second,
+		"key2": "250ms",
+		"key3": "soon",
+	}
+
+	if val := GetDurationParam(params, "key1", 0); val != 3*time.Second {
+		t.Errorf("Expected 3s, got %v", val)
+	}
+	if val := GetDurationParam(params, "key2", 0); val != 250*time.Millisecond {
+		t.Errorf("Expected 250ms, got %v", val)
+	}
+	if val := GetDurationParam(params, "key3", time.Minute); val != time.Minute {
+		t.Errorf("Expected 1m0s, got %v", val)
+	}
+	if val := GetDurationParam(params, "key4", time.Minute); val != time.Minute {
+		t.Errorf("Expected 1m0s, got %v", val)
+	}
+}
+
 func TestValidateRequiredParams(t *testing.T) {
 	params := map[string]any{
 		"param1": "value1",
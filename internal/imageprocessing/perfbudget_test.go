@@ -0,0 +1,158 @@
+//go:build perfbudget
+
+package imageprocessing
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// perfBaselinePath holds the recorded medians and the allowed regression.
+const perfBaselinePath = "testdata/perf_baseline.json"
+
+// perfSamples is the number of timed runs per case; the median is compared.
+const perfSamples = 7
+
+var updatePerfBaseline = flag.Bool("update-baseline", false, "record the measured medians as the new performance baseline")
+
+// perfBaseline is the content of perfBaselinePath.
+type perfBaseline struct {
+	// Tolerance is the allowed slowdown of a median as a fraction, e.g. 0.25
+	// for 25%. GOFRAME_PERF_TOLERANCE overrides it.
+	Tolerance float64 `json:"tolerance"`
+	// Tolerances overrides Tolerance for individual cases.
+	Tolerances map[string]float64 `json:"tolerances,omitempty"`
+	// MedianMillis maps each case to its recorded median latency.
+	MedianMillis map[string]float64 `json:"medianMs"`
+}
+
+// perfCase runs one command on a large image.
+type perfCase struct {
+	name    string
+	command string
+	params  map[string]any
+	width   int
+	height  int
+}
+
+var perfCases = []perfCase{
+	{"AutoEnhanceCommand", "AutoEnhanceCommand", map[string]any{}, 4000, 3000},
+	{"ScaleCommand/1920x1080", "ScaleCommand", map[string]any{"width": 1920, "height": 1080}, 4000, 3000},
+	{"PixelScaleCommand/WidthOnly-1920", "PixelScaleCommand", map[string]any{"width": 1920}, 4000, 3000},
+	{"CropCommand/2000x2000", "CropCommand", map[string]any{"width": 2000, "height": 2000}, 4000, 3000},
+	{"OrientationCommand/portrait", "OrientationCommand", map[string]any{"orientation": "portrait"}, 4000, 3000},
+	{"DitherCommand/default", "DitherCommand", map[string]any{}, 1600, 1200},
+}
+
+// TestPerformanceBudget fails when the median latency of a command on a large
+// image exceeds its recorded baseline by more than the tolerance. Baselines
+// depend on the machine; record them where the budget is enforced with
+// `make perf-baseline`.
+func TestPerformanceBudget(t *testing.T) {
+	images := map[[2]int][]byte{}
+	measured := make(map[string]float64, len(perfCases))
+	for _, pc := range perfCases {
+		size := [2]int{pc.width, pc.height}
+		if images[size] == nil {
+			images[size] = makeLargePNG(t, pc.width, pc.height)
+		}
+		median, err := measurePerfCase(pc, images[size])
+		if err != nil {
+			t.Fatalf("%s: %v", pc.name, err)
+		}
+		measured[pc.name] = median
+	}
+
+	if *updatePerfBaseline {
+		baseline := perfBaseline{Tolerance: 0.25}
+		if existing, err := readPerfBaseline(); err == nil {
+			baseline.Tolerance, baseline.Tolerances = existing.Tolerance, existing.Tolerances
+		}
+		baseline.MedianMillis = measured
+		data, err := json.MarshalIndent(baseline, "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(perfBaselinePath, append(data, '\n'), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		t.Logf("recorded baseline in %s", perfBaselinePath)
+		return
+	}
+
+	baseline, err := readPerfBaseline()
+	if err != nil {
+		t.Fatalf("reading baseline (record one with -update-baseline): %v", err)
+	}
+	if env := os.Getenv("GOFRAME_PERF_TOLERANCE"); env != "" {
+		tolerance, err := strconv.ParseFloat(env, 64)
+		if err != nil {
+			t.Fatalf("invalid GOFRAME_PERF_TOLERANCE %q: %v", env, err)
+		}
+		baseline.Tolerance, baseline.Tolerances = tolerance, nil
+	}
+
+	names := make([]string, 0, len(measured))
+	for name := range measured {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		want, ok := baseline.MedianMillis[name]
+		if !ok {
+			t.Errorf("%s: no baseline recorded", name)
+			continue
+		}
+		tolerance := baseline.Tolerance
+		if override, ok := baseline.Tolerances[name]; ok {
+			tolerance = override
+		}
+		got := measured[name]
+		limit := want * (1 + tolerance)
+		t.Logf("%-36s median %8.1fms  baseline %8.1fms  limit %8.1fms", name, got, want, limit)
+		if got > limit {
+			t.Errorf("%s: median %.1fms exceeds the budget of %.1fms (baseline %.1fms +%.0f%%)",
+				name, got, limit, want, tolerance*100)
+		}
+	}
+}
+
+// measurePerfCase returns the median latency of the case in milliseconds
+// after one warm-up run.
+func measurePerfCase(pc perfCase, imageData []byte) (float64, error) {
+	command, err := DefaultRegistry.Create(pc.command, pc.params)
+	if err != nil {
+		return 0, err
+	}
+	samples := make([]float64, 0, perfSamples)
+	for i := range perfSamples + 1 {
+		start := time.Now()
+		if _, err := command.Execute(imageData); err != nil {
+			return 0, fmt.Errorf("execute failed: %w", err)
+		}
+		if i > 0 {
+			samples = append(samples, math.Round(float64(time.Since(start).Microseconds())/100)/10)
+		}
+	}
+	slices.Sort(samples)
+	return samples[len(samples)/2], nil
+}
+
+func readPerfBaseline() (*perfBaseline, error) {
+	data, err := os.ReadFile(perfBaselinePath)
+	if err != nil {
+		return nil, err
+	}
+	var baseline perfBaseline
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, err
+	}
+	return &baseline, nil
+}
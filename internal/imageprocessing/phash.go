@@ -0,0 +1,40 @@
+package imageprocessing
+
+import (
+	"fmt"
+	"image"
+	"math/bits"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// DifferenceHash computes a 64-bit perceptual difference hash (dHash) of a PNG
+// image. The image is reduced to 9x8 grayscale pixels and each bit records
+// whether a pixel is brighter than its right neighbour, so re-encoded, resized
+// or lightly edited copies hash to nearby values.
+func DifferenceHash(imageData []byte) (uint64, error) {
+	img, err := decodePNG(imageData)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decode PNG image: %w", err)
+	}
+
+	small := image.NewGray(image.Rect(0, 0, 9, 8))
+	xdraw.ApproxBiLinear.Scale(small, small.Bounds(), img, img.Bounds(), xdraw.Src, nil)
+
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			hash <<= 1
+			if small.GrayAt(x, y).Y > small.GrayAt(x+1, y).Y {
+				hash |= 1
+			}
+		}
+	}
+	return hash, nil
+}
+
+// HashDistance returns the number of differing bits between two perceptual
+// hashes; 0 means visually identical, values up to ~10 indicate near-duplicates.
+func HashDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
@@ -0,0 +1,43 @@
+package imageprocessing
+
+import (
+	"image/color"
+	"testing"
+)
+
+// gradientPNG encodes a horizontal gradient, optionally mirrored.
+func gradientPNG(t *testing.T, w, h int, mirrored bool) []byte {
+	t.Helper()
+	return patternPNG(t, w, h, func(x, y int) color.NRGBA {
+		v := uint8(x * 255 / (w - 1))
+		if mirrored {
+			v = 255 - v
+		}
+		return color.NRGBA{R: v, G: uint8(y * 255 / (h - 1)), B: v, A: 255}
+	})
+}
+
+func TestDifferenceHash(t *testing.T) {
+	original, err := DifferenceHash(gradientPNG(t, 200, 100, false))
+	if err != nil {
+		t.Fatalf("DifferenceHash failed: %v", err)
+	}
+	resized, err := DifferenceHash(gradientPNG(t, 90, 45, false))
+	if err != nil {
+		t.Fatalf("DifferenceHash failed: %v", err)
+	}
+	mirrored, err := DifferenceHash(gradientPNG(t, 200, 100, true))
+	if err != nil {
+		t.Fatalf("DifferenceHash failed: %v", err)
+	}
+
+	if d := HashDistance(original, resized); d > 4 {
+		t.Errorf("expected resized copy to be near-identical, distance %d", d)
+	}
+	if d := HashDistance(original, mirrored); d < 32 {
+		t.Errorf("expected mirrored image to differ, distance %d", d)
+	}
+	if _, err := DifferenceHash([]byte("not a png")); err == nil {
+		t.Error("expected error for invalid PNG")
+	}
+}
@@ -0,0 +1,80 @@
+package imageprocessing
+
+import (
+	"image"
+	"image/color"
+)
+
+// pixelReader reads rows of an image as 8-bit alpha-premultiplied RGBA, the
+// values img.At(x, y).RGBA() yields shifted right by 8. The image types the
+// PNG decoder returns for photos and illustrations are read straight from
+// their Pix slices; At and its color conversion cost more than the work done
+// per pixel in most commands.
+type pixelReader struct {
+	img image.Image
+	// palette holds the converted colors of a *image.Paletted.
+	palette [256][4]uint8
+}
+
+// newPixelReader returns a reader for img.
+func newPixelReader(img image.Image) *pixelReader {
+	p := &pixelReader{img: img}
+	if paletted, ok := img.(*image.Paletted); ok {
+		for i, c := range paletted.Palette[:min(len(paletted.Palette), 256)] {
+			p.palette[i] = rgba8(c)
+		}
+	}
+	return p
+}
+
+// row returns row y of the image, 4 bytes per pixel from Bounds().Min.X.
+// Rows of a *image.RGBA are returned without copying and must not be
+// modified; other types are converted into buf, which must hold 4*Dx bytes.
+func (p *pixelReader) row(y int, buf []uint8) []uint8 {
+	b := p.img.Bounds()
+	w := b.Dx()
+	switch img := p.img.(type) {
+	case *image.RGBA:
+		start := img.PixOffset(b.Min.X, y)
+		return img.Pix[start : start+4*w]
+	case *image.NRGBA:
+		src := img.Pix[img.PixOffset(b.Min.X, y):]
+		for x := range w {
+			s, d := src[x*4:x*4+4], buf[x*4:x*4+4]
+			// Premultiplied as color.NRGBA.RGBA does, including its rounding.
+			a := uint32(s[3])
+			d[0] = premultiply8(s[0], a)
+			d[1] = premultiply8(s[1], a)
+			d[2] = premultiply8(s[2], a)
+			d[3] = s[3]
+		}
+	case *image.Paletted:
+		src := img.Pix[img.PixOffset(b.Min.X, y):]
+		for x := range w {
+			c := p.palette[src[x]]
+			copy(buf[x*4:x*4+4], c[:])
+		}
+	default:
+		for x := range w {
+			c := rgba8(img.At(b.Min.X+x, y))
+			copy(buf[x*4:x*4+4], c[:])
+		}
+	}
+	return buf[:4*w]
+}
+
+// premultiply8 scales the 8-bit channel v by the 8-bit alpha a the way
+// color.NRGBA.RGBA does and returns the high byte of the result.
+func premultiply8(v uint8, a uint32) uint8 {
+	c := uint32(v)
+	c |= c << 8
+	c = c * a / 0xff
+	return uint8(c >> 8) // #nosec G115 -- c <= 0xffff
+}
+
+// rgba8 returns the 8-bit alpha-premultiplied components of c.
+func rgba8(c color.Color) [4]uint8 {
+	r, g, b, a := c.RGBA()
+	// #nosec G115 -- components are 16-bit; shifting >>8 ensures 0..255
+	return [4]uint8{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), uint8(a >> 8)}
+}
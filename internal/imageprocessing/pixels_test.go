@@ -0,0 +1,64 @@
+package imageprocessing
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestPixelReader_MatchesAt(t *testing.T) {
+	r := image.Rect(2, 1, 7, 4)
+	palette := color.Palette{color.RGBA{10, 20, 30, 255}, color.NRGBA{200, 100, 50, 128}, color.Transparent}
+	images := map[string]image.Image{
+		"rgba":     image.NewRGBA(r),
+		"nrgba":    image.NewNRGBA(r),
+		"paletted": image.NewPaletted(r, palette),
+		"gray":     image.NewGray(r),
+	}
+	for name, img := range images {
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				v := uint8(x*40 + y*7) //nolint:gosec // test pattern, wraps on purpose
+				c := color.NRGBA{R: v, G: 255 - v, B: v / 2, A: v | 1}
+				switch img := img.(type) {
+				case *image.Paletted:
+					img.SetColorIndex(x, y, uint8((x+y)%len(palette))) //nolint:gosec // index < 3
+				case interface{ Set(int, int, color.Color) }:
+					img.Set(x, y, c)
+				}
+			}
+		}
+
+		pixels := newPixelReader(img)
+		buf := make([]uint8, 4*r.Dx())
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			row := pixels.row(y, buf)
+			for x := r.Min.X; x < r.Max.X; x++ {
+				want := rgba8(img.At(x, y))
+				got := [4]uint8(row[(x-r.Min.X)*4 : (x-r.Min.X)*4+4])
+				if got != want {
+					t.Errorf("%s: pixel (%d,%d) = %v, want %v", name, x, y, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestRotateAndFlip_OffsetBounds(t *testing.T) {
+	img := image.NewRGBA(image.Rect(3, 5, 6, 7))
+	img.Set(3, 5, color.RGBA{255, 0, 0, 255})
+
+	// The red top left pixel moves to the top right when rotating clockwise,
+	// to the top right when flipping horizontally and to the bottom left when
+	// flipping vertically.
+	red := color.RGBA{255, 0, 0, 255}
+	if got := rotate90(img, true).At(1, 0); got != red {
+		t.Errorf("rotate90 clockwise: top right = %v, want red", got)
+	}
+	if got := flipHorizontal(img).At(5, 5); got != red {
+		t.Errorf("flipHorizontal: top right = %v, want red", got)
+	}
+	if got := flipVertical(img).At(3, 6); got != red {
+		t.Errorf("flipVertical: bottom left = %v, want red", got)
+	}
+}
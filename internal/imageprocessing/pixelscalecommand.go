@@ -1,20 +1,17 @@
 package imageprocessing
 
 import (
-	"bytes"
 	"fmt"
 	"image"
-	"image/png"
 	"log/slog"
-
-
-	xdraw "golang.org/x/image/draw"
 )
 
 // PixelScaleParams represents typed parameters for pixel scale command
 type PixelScaleParams struct {
 	Height *int // Optional: if nil, will be calculated from width
 	Width  *int // Optional: if nil, will be calculated from height
+	// Interpolation is the resampling filter, e.g. "bilinear".
+	Interpolation string
 }
 
 // NewPixelScaleParamsFromMap creates PixelScaleParams from a generic map
@@ -27,7 +24,11 @@ func NewPixelScaleParamsFromMap(params map[string]any) (*PixelScaleParams, error
 		return nil, fmt.Errorf("at least one of 'height' or 'width' must be specified")
 	}
 
-	result := &PixelScaleParams{}
+	interpolation, err := interpolationParam(params)
+	if err != nil {
+		return nil, err
+	}
+	result := &PixelScaleParams{Interpolation: interpolation}
 
 	// Process height if provided
 	if hasHeight {
@@ -86,8 +87,9 @@ func NewPixelScaleCommandWithParams(height, width *int) (*PixelScaleCommand, err
 	return &PixelScaleCommand{
 		name: "PixelScaleCommand",
 		params: &PixelScaleParams{
-			Height: height,
-			Width:  width,
+			Height:        height,
+			Width:         width,
+			Interpolation: DefaultInterpolation,
 		},
 	}, nil
 }
@@ -97,18 +99,13 @@ func (c *PixelScaleCommand) Name() string {
 	return c.name
 }
 
-// Execute scales the image to target dimensions while preserving aspect ratio
+// Execute scales the PNG image to target dimensions while preserving aspect ratio
 func (c *PixelScaleCommand) Execute(imageData []byte) ([]byte, error) {
-	slog.Debug("PixelScaleCommand: decoding image",
-		"input_size_bytes", len(imageData))
-
-	// Decode the PNG image
-	img, err := png.Decode(bytes.NewReader(imageData))
-	if err != nil {
-		slog.Error("PixelScaleCommand: failed to decode PNG image", "error", err)
-		return nil, fmt.Errorf("failed to decode PNG image: %w", err)
-	}
+	return executeEncoded(c, imageData)
+}
 
+// ExecuteImage scales the image to target dimensions while preserving aspect ratio
+func (c *PixelScaleCommand) ExecuteImage(img image.Image) (image.Image, error) {
 	// Get original dimensions
 	bounds := img.Bounds()
 	originalWidth := bounds.Dx()
@@ -146,7 +143,7 @@ func (c *PixelScaleCommand) Execute(imageData []byte) ([]byte, error) {
 	// If target matches original dimensions, skip processing
 	if targetWidth == originalWidth && targetHeight == originalHeight {
 		slog.Debug("PixelScaleCommand: target dimensions equal original; skipping scaling")
-		return imageData, nil
+		return img, nil
 	}
 
 	slog.Debug("PixelScaleCommand: scaling image",
@@ -158,26 +155,10 @@ func (c *PixelScaleCommand) Execute(imageData []byte) ([]byte, error) {
 	// Create target image
 	targetImg := image.NewRGBA(image.Rect(0, 0, targetWidth, targetHeight))
 
-	// Use optimized scaler from golang.org/x/image/draw (NearestNeighbor)
-	xdraw.NearestNeighbor.Scale(targetImg, targetImg.Bounds(), img, bounds, xdraw.Src, nil)
-
-	slog.Debug("PixelScaleCommand: encoding scaled image")
-
-	// Encode the scaled image to PNG bytes
-	var buf bytes.Buffer
-	bb := targetImg.Bounds()
-	// Pre-grow buffer to reduce reallocations; rough heuristic ~1 byte per pixel
-	buf.Grow(bb.Dx() * bb.Dy())
-	err = png.Encode(&buf, targetImg)
-	if err != nil {
-		slog.Error("PixelScaleCommand: failed to encode scaled image", "error", err)
-		return nil, fmt.Errorf("failed to encode scaled PNG image: %w", err)
-	}
-
-	slog.Debug("PixelScaleCommand: scaling complete",
-		"output_size_bytes", buf.Len())
+	resample(targetImg, targetImg.Bounds(), img, c.params.Interpolation)
 
-	return buf.Bytes(), nil
+	slog.Debug("PixelScaleCommand: scaling complete")
+	return targetImg, nil
 }
 
 // GetHeight returns the configured height (may be nil if not specified)
@@ -2,23 +2,39 @@ package imageprocessing
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"image"
-	"image/color"
 	"image/png"
 	"log/slog"
 	"math"
+	"slices"
 	"strings"
-
+	"time"
 
 	_ "image/gif"
 	_ "image/jpeg"
 
-	"github.com/srwiley/oksvg"
-	"github.com/srwiley/rasterx"
 	_ "golang.org/x/image/bmp"
-	_ "golang.org/x/image/tiff"
-	_ "golang.org/x/image/webp"
+)
+
+// ErrFormatDisabled is returned for input in a format this build leaves out
+// (see DisabledFormats).
+var ErrFormatDisabled = errors.New("image format disabled in this build")
+
+// ErrSVGLimit is returned for SVGs with more elements than allowed or whose
+// rendering takes too long, e.g. hostile files crafted to tie up the CPU.
+var ErrSVGLimit = errors.New("svg exceeds rendering limits")
+
+// Defaults of the SVG limits of PngConverterCommand.
+const (
+	// DefaultSVGMaxLongSidePixelCount caps the long side an SVG is rendered at.
+	DefaultSVGMaxLongSidePixelCount = 8192
+	// DefaultSVGMaxElements is the largest number of elements rendered.
+	DefaultSVGMaxElements = 100000
+	// DefaultSVGRenderTimeout bounds the time spent rendering one SVG.
+	DefaultSVGRenderTimeout = 10 * time.Second
 )
 
 // hasCorrectPngSignature checks whether the provided data begins with a valid PNG signature
@@ -35,16 +51,37 @@ func hasCorrectPngSignature(data []byte) bool {
 type PngConverterCommand struct {
 	name                          string
 	svgFallbackLongSidePixelCount int
+	// svgMaxLongSidePixelCount scales larger SVG render sizes down.
+	svgMaxLongSidePixelCount int
+	// svgMaxElements rejects SVGs with more elements.
+	svgMaxElements int
+	// svgRenderTimeout abandons SVG rendering that takes longer.
+	svgRenderTimeout time.Duration
 }
 
 // NewPngConverterCommand creates a new PNG converter command
 func NewPngConverterCommand(params map[string]any) (Command, error) {
 	// Read optional SVG fallback long-side pixel count (used only when SVG lacks explicit size)
 	ls := GetIntParam(params, "svgFallbackLongSidePixelCount", 0)
+	maxLongSide := GetIntParam(params, "svgMaxLongSidePixelCount", DefaultSVGMaxLongSidePixelCount)
+	if maxLongSide <= 0 {
+		return nil, fmt.Errorf("svgMaxLongSidePixelCount must be positive, got %d", maxLongSide)
+	}
+	maxElements := GetIntParam(params, "svgMaxElements", DefaultSVGMaxElements)
+	if maxElements <= 0 {
+		return nil, fmt.Errorf("svgMaxElements must be positive, got %d", maxElements)
+	}
+	timeout := GetDurationParam(params, "svgRenderTimeout", DefaultSVGRenderTimeout)
+	if timeout <= 0 {
+		return nil, fmt.Errorf("svgRenderTimeout must be positive, got %s", timeout)
+	}
 
 	return &PngConverterCommand{
 		name:                          "PngConverterCommand",
 		svgFallbackLongSidePixelCount: ls,
+		svgMaxLongSidePixelCount:      maxLongSide,
+		svgMaxElements:                maxElements,
+		svgRenderTimeout:              timeout,
 	}, nil
 }
 
@@ -53,6 +90,9 @@ func NewPngConverterCommandDirect() *PngConverterCommand {
 	return &PngConverterCommand{
 		name:                          "PngConverterCommand",
 		svgFallbackLongSidePixelCount: 0,
+		svgMaxLongSidePixelCount:      DefaultSVGMaxLongSidePixelCount,
+		svgMaxElements:                DefaultSVGMaxElements,
+		svgRenderTimeout:              DefaultSVGRenderTimeout,
 	}
 }
 
@@ -72,6 +112,11 @@ func (c *PngConverterCommand) Execute(imageData []byte) ([]byte, error) {
 		return imageData, nil
 	}
 
+	if format := sniffOptionalFormat(imageData); slices.Contains(DisabledFormats, format) {
+		slog.Warn("PngConverterCommand: input format disabled in this build", "format", format)
+		return nil, fmt.Errorf("%w: %s", ErrFormatDisabled, format)
+	}
+
 	// Handle SVG input explicitly
 	if isSVGData(imageData) {
 		return c.convertSVG(imageData)
@@ -102,10 +147,15 @@ func (c *PngConverterCommand) Execute(imageData []byte) ([]byte, error) {
 func (c *PngConverterCommand) convertSVG(imageData []byte) ([]byte, error) {
 	slog.Debug("PngConverterCommand: detected SVG input; determining render size")
 
+	if elements := countSVGElements(imageData); elements > c.svgMaxElements {
+		slog.Warn("PngConverterCommand: SVG has too many elements", "elements", elements, "max", c.svgMaxElements)
+		return nil, fmt.Errorf("%w: %d elements, at most %d allowed", ErrSVGLimit, elements, c.svgMaxElements)
+	}
+
 	// Try to extract explicit width/height from SVG; if missing, use AR-derived fallback.
 	if w, h, ok := parseSvgExplicitSize(imageData); ok {
 		slog.Debug("PngConverterCommand: SVG has explicit size", "width", w, "height", h)
-		out, err := renderSVGToPNG(imageData, w, h)
+		out, err := c.renderSVG(imageData, w, h)
 		if err != nil {
 			slog.Error("PngConverterCommand: failed to render SVG (explicit size)", "error", err)
 			return nil, fmt.Errorf("failed to render SVG to PNG: %w", err)
@@ -147,7 +197,7 @@ func (c *PngConverterCommand) convertSVG(imageData []byte) ([]byte, error) {
 			"target_w", targetW, "target_h", targetH)
 	}
 
-	out, err := renderSVGToPNG(imageData, targetW, targetH)
+	out, err := c.renderSVG(imageData, targetW, targetH)
 	if err != nil {
 		slog.Error("PngConverterCommand: failed to render SVG (fallback size)", "error", err)
 		return nil, fmt.Errorf("failed to render SVG to PNG: %w", err)
@@ -156,6 +206,36 @@ func (c *PngConverterCommand) convertSVG(imageData []byte) ([]byte, error) {
 	return out, nil
 }
 
+// renderSVG renders the SVG at w x h, scaled down to svgMaxLongSidePixelCount,
+// within svgRenderTimeout.
+func (c *PngConverterCommand) renderSVG(imageData []byte, w, h int) ([]byte, error) {
+	if longSide := max(w, h); longSide > c.svgMaxLongSidePixelCount {
+		scale := float64(c.svgMaxLongSidePixelCount) / float64(longSide)
+		w = max(1, int(math.Round(float64(w)*scale)))
+		h = max(1, int(math.Round(float64(h)*scale)))
+		slog.Info("PngConverterCommand: SVG size exceeds the limit; scaling down",
+			"max_long_side", c.svgMaxLongSidePixelCount, "width", w, "height", h)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.svgRenderTimeout)
+	defer cancel()
+	return renderSVGToPNG(ctx, imageData, w, h)
+}
+
+// countSVGElements counts the start tags of data: every "<" followed by a
+// letter. Comments, declarations and end tags are not counted.
+func countSVGElements(data []byte) int {
+	count := 0
+	for i := 0; i+1 < len(data); i++ {
+		if data[i] == '<' {
+			next := data[i+1] | 0x20
+			if next >= 'a' && next <= 'z' {
+				count++
+			}
+		}
+	}
+	return count
+}
+
 func init() {
 	// Register the command in the default registry
 	if err := DefaultRegistry.Register("PngConverterCommand", NewPngConverterCommand); err != nil {
@@ -337,32 +417,16 @@ func isSVGData(data []byte) bool {
 		bytes.Contains(header, []byte("xmlns='http://www.w3.org/2000/svg'"))
 }
 
-// renderSVGToPNG renders an SVG byte slice into a PNG with the given target dimensions.
-func renderSVGToPNG(svgData []byte, targetW, targetH int) ([]byte, error) {
-	if targetW <= 0 || targetH <= 0 {
-		return nil, fmt.Errorf("invalid target dimensions for SVG rendering: %dx%d", targetW, targetH)
-	}
-	icon, err := oksvg.ReadIconStream(bytes.NewReader(svgData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse SVG: %w", err)
-	}
-
-	// Set drawing target rectangle
-	icon.SetTarget(0, 0, float64(targetW), float64(targetH))
-
-	// Prepare target canvas (white background)
-	dst := createTargetCanvas(targetW, targetH, color.RGBA{255, 255, 255, 255})
-
-	// Rasterize SVG into the target canvas
-	scanner := rasterx.NewScannerGV(targetW, targetH, dst, dst.Bounds())
-	dasher := rasterx.NewDasher(targetW, targetH, scanner)
-	icon.Draw(dasher, 1.0)
-
-	// Encode to PNG
-	var buf bytes.Buffer
-	buf.Grow(targetW * targetH)
-	if err := png.Encode(&buf, dst); err != nil {
-		return nil, fmt.Errorf("failed to encode rendered SVG as PNG: %w", err)
-	}
-	return buf.Bytes(), nil
+// sniffOptionalFormat names the format of data if it is one of those slim
+// builds leave out ("svg", "tiff" or "webp") and returns "" otherwise.
+func sniffOptionalFormat(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte("II*\x00")), bytes.HasPrefix(data, []byte("MM\x00*")):
+		return "tiff"
+	case len(data) >= 12 && bytes.Equal(data[:4], []byte("RIFF")) && bytes.Equal(data[8:12], []byte("WEBP")):
+		return "webp"
+	case isSVGData(data):
+		return "svg"
+	}
+	return ""
 }
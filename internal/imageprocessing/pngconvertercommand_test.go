@@ -184,35 +184,25 @@ func TestPngConverterCommand_WithRealImage(t *testing.T) {
 	}
 }
 
-// New test to verify SVG rendering and target sizing
-func TestPngConverterCommand_RenderSVG(t *testing.T) {
-	// Minimal inline SVG (red square) without explicit width/height to trigger fallback sizing
-	svgData := []byte(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 100 100"><rect width="100" height="100" fill="red"/></svg>`)
-
-	// Target small size for test
-	params := map[string]any{
-		"svgFallbackLongSidePixelCount": 64,
-	}
-	command, err := NewPngConverterCommand(params)
-	if err != nil {
-		t.Fatalf("Failed to create command: %v", err)
-	}
-
-	result, err := command.Execute(svgData)
-	if err != nil {
-		t.Fatalf("Execute failed for SVG: %v", err)
-	}
-	if len(result) == 0 {
-		t.Fatal("Expected non-empty PNG result for SVG input")
-	}
-
-	// Verify result is valid PNG and matches target dimensions
-	img, err := png.Decode(bytes.NewReader(result))
-	if err != nil {
-		t.Fatalf("Rendered SVG result is not valid PNG: %v", err)
+func TestSniffOptionalFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"tiff little endian", []byte("II*\x00\x08\x00\x00\x00"), "tiff"},
+		{"tiff big endian", []byte("MM\x00*\x00\x00\x00\x08"), "tiff"},
+		{"webp", []byte("RIFF\x24\x00\x00\x00WEBPVP8 "), "webp"},
+		{"svg", []byte(`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"/>`), "svg"},
+		{"other RIFF", []byte("RIFF\x24\x00\x00\x00WAVEfmt "), ""},
+		{"png", []byte{0x89, 'P', 'N', 'G', 0x0D, 0x0A, 0x1A, 0x0A}, ""},
+		{"empty", nil, ""},
 	}
-	b := img.Bounds()
-	if b.Dx() != 64 || b.Dy() != 64 {
-		t.Fatalf("Expected PNG dimensions 64x64, got %dx%d", b.Dx(), b.Dy())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sniffOptionalFormat(tt.data); got != tt.want {
+				t.Errorf("sniffOptionalFormat() = %q, want %q", got, tt.want)
+			}
+		})
 	}
 }
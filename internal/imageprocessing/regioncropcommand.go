@@ -0,0 +1,108 @@
+package imageprocessing
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"log/slog"
+	"math"
+)
+
+// regionEpsilon tolerates rounding in regions computed by clients, e.g. an
+// x of 0.3 and a width of 0.7000001.
+const regionEpsilon = 1e-6
+
+// RegionCropParams describes a region of the image as fractions of its width
+// and height, so it applies to the image at any resolution.
+type RegionCropParams struct {
+	X, Y          float64
+	Width, Height float64
+}
+
+// NewRegionCropParamsFromMap creates RegionCropParams from a generic map.
+func NewRegionCropParamsFromMap(params map[string]any) (*RegionCropParams, error) {
+	if err := ValidateRequiredParams(params, []string{"width", "height"}); err != nil {
+		return nil, err
+	}
+	p := &RegionCropParams{
+		X:      GetFloatParam(params, "x", 0),
+		Y:      GetFloatParam(params, "y", 0),
+		Width:  GetFloatParam(params, "width", 0),
+		Height: GetFloatParam(params, "height", 0),
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Validate reports whether the region is non-empty and lies within the image.
+func (p *RegionCropParams) Validate() error {
+	if p.Width <= 0 || p.Height <= 0 {
+		return fmt.Errorf("width and height must be positive, got %gx%g", p.Width, p.Height)
+	}
+	if p.X < 0 || p.Y < 0 || p.X+p.Width > 1+regionEpsilon || p.Y+p.Height > 1+regionEpsilon {
+		return fmt.Errorf("region (%g, %g) %gx%g must lie within the image (0-1)", p.X, p.Y, p.Width, p.Height)
+	}
+	return nil
+}
+
+// rect returns the pixels of the region within bounds; it holds at least one pixel.
+func (p *RegionCropParams) rect(bounds image.Rectangle) image.Rectangle {
+	w, h := float64(bounds.Dx()), float64(bounds.Dy())
+	x0, y0 := int(math.Round(p.X*w)), int(math.Round(p.Y*h))
+	x1, y1 := int(math.Round((p.X+p.Width)*w)), int(math.Round((p.Y+p.Height)*h))
+	x1, y1 = max(x1, x0+1), max(y1, y0+1)
+	return image.Rect(x0, y0, x1, y1).Add(bounds.Min).Intersect(bounds)
+}
+
+// RegionCropCommand crops the image to a region given as fractions of its
+// size, e.g. the region of interest chosen for an image in the UI. Unlike
+// CropCommand the result depends on the image size rather than the panel.
+type RegionCropCommand struct {
+	name   string
+	params *RegionCropParams
+}
+
+// NewRegionCropCommand creates a RegionCropCommand from configuration parameters.
+func NewRegionCropCommand(params map[string]any) (Command, error) {
+	typedParams, err := NewRegionCropParamsFromMap(params)
+	if err != nil {
+		return nil, err
+	}
+	return &RegionCropCommand{name: "RegionCropCommand", params: typedParams}, nil
+}
+
+// Name returns the command name.
+func (c *RegionCropCommand) Name() string {
+	return c.name
+}
+
+// Execute crops the PNG image to the region.
+func (c *RegionCropCommand) Execute(imageData []byte) ([]byte, error) {
+	return executeEncoded(c, imageData)
+}
+
+// ExecuteImage crops the image to the region.
+func (c *RegionCropCommand) ExecuteImage(img image.Image) (image.Image, error) {
+	b := img.Bounds()
+	region := c.params.rect(b)
+	if region == b {
+		return img, nil
+	}
+	slog.Debug("RegionCropCommand: cropping", "x", region.Min.X, "y", region.Min.Y, "width", region.Dx(), "height", region.Dy())
+	cropped := image.NewRGBA(image.Rect(0, 0, region.Dx(), region.Dy()))
+	draw.Draw(cropped, cropped.Bounds(), img, region.Min, draw.Src)
+	return cropped, nil
+}
+
+// GetParams returns the typed parameters.
+func (c *RegionCropCommand) GetParams() *RegionCropParams {
+	return c.params
+}
+
+func init() {
+	if err := DefaultRegistry.Register("RegionCropCommand", NewRegionCropCommand); err != nil {
+		panic(fmt.Sprintf("failed to register RegionCropCommand: %v", err))
+	}
+}
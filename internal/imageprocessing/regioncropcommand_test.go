@@ -0,0 +1,61 @@
+package imageprocessing
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestNewRegionCropParamsFromMap(t *testing.T) {
+	p, err := NewRegionCropParamsFromMap(map[string]any{"width": 0.5, "height": 1})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if p.X != 0 || p.Y != 0 {
+		t.Errorf("expected the region to start at the top left by default, got (%g, %g)", p.X, p.Y)
+	}
+	for _, params := range []map[string]any{
+		{"width": 0.5},
+		{"width": 0, "height": 1},
+		{"x": -0.1, "width": 0.5, "height": 1},
+		{"x": 0.6, "width": 0.5, "height": 1},
+		{"y": 0.5, "width": 1, "height": 0.6},
+	} {
+		if _, err := NewRegionCropParamsFromMap(params); err == nil {
+			t.Errorf("expected an error for %v", params)
+		}
+	}
+}
+
+func TestRegionCropCommand_CropsRegion(t *testing.T) {
+	// White on the left half, black on the right.
+	input := patternPNG(t, 100, 50, func(x, y int) color.NRGBA {
+		if x < 50 {
+			return gray(255)
+		}
+		return gray(0)
+	})
+	command, err := NewRegionCropCommand(map[string]any{"x": 0.5, "y": 0.2, "width": 0.5, "height": 0.6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := command.Execute(input)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	img := mustDecode(t, output)
+	if img.Bounds() != image.Rect(0, 0, 50, 30) {
+		t.Fatalf("expected 50x30, got %v", img.Bounds())
+	}
+	if r, _, _, _ := img.At(0, 0).RGBA(); r != 0 {
+		t.Errorf("expected the black right half, got red %d", r)
+	}
+
+	full, err := NewRegionCropCommand(map[string]any{"width": 1, "height": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output, err := full.Execute(input); err != nil || len(output) != len(input) {
+		t.Errorf("expected the full region to return the input unchanged, got %d bytes, %v", len(output), err)
+	}
+}
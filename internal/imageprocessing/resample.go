@@ -0,0 +1,71 @@
+package imageprocessing
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// Interpolations of ScaleCommand and PixelScaleCommand.
+const (
+	// InterpolationNearest repeats or drops pixels; it keeps hard edges, e.g.
+	// for pixel art, but aliases photos.
+	InterpolationNearest = "nearest"
+	// InterpolationBilinear averages the four nearest pixels.
+	InterpolationBilinear = "bilinear"
+	// InterpolationBicubic fits a Catmull-Rom spline through 4x4 pixels,
+	// keeping more detail than bilinear.
+	InterpolationBicubic = "bicubic"
+	// InterpolationLanczos uses a 3-lobed Lanczos kernel, the sharpest option
+	// and the slowest.
+	InterpolationLanczos = "lanczos"
+)
+
+// DefaultInterpolation is used when a scale command does not set one.
+const DefaultInterpolation = InterpolationBilinear
+
+// lanczos3 is the Lanczos kernel with a support of 3 pixels.
+var lanczos3 = &xdraw.Kernel{Support: 3, At: func(t float64) float64 {
+	if t == 0 {
+		return 1
+	}
+	if t >= 3 || t <= -3 {
+		return 0
+	}
+	x := math.Pi * t
+	return 3 * math.Sin(x) * math.Sin(x/3) / (x * x)
+}}
+
+// interpolationParam reads and validates the interpolation parameter.
+func interpolationParam(params map[string]any) (string, error) {
+	interpolation := GetStringParam(params, "interpolation", DefaultInterpolation)
+	if _, ok := interpolators[interpolation]; !ok {
+		return "", fmt.Errorf("interpolation must be one of nearest, bilinear, bicubic or lanczos, got %q", interpolation)
+	}
+	return interpolation, nil
+}
+
+var interpolators = map[string]xdraw.Interpolator{
+	InterpolationNearest:  xdraw.NearestNeighbor,
+	InterpolationBilinear: xdraw.BiLinear,
+	InterpolationBicubic:  xdraw.CatmullRom,
+	InterpolationLanczos:  lanczos3,
+}
+
+// resample scales src into the rectangle dr of dst with the given
+// interpolation. dr may extend beyond dst, e.g. when an image covering the
+// canvas is cropped; only the part inside dst is drawn. Builds with the neon
+// tag scale RGBA sources with nearest or bilinear interpolation in C and use
+// x/image/draw for everything else.
+func resample(dst *image.RGBA, dr image.Rectangle, src image.Image, interpolation string) {
+	if scaleAccelerated(dst, dr, src, interpolation) {
+		return
+	}
+	interpolator, ok := interpolators[interpolation]
+	if !ok {
+		interpolator = interpolators[DefaultInterpolation]
+	}
+	interpolator.Scale(dst, dr, src, src.Bounds(), xdraw.Src, nil)
+}
@@ -0,0 +1,16 @@
+//go:build !(neon && arm64 && cgo)
+
+package imageprocessing
+
+import "image"
+
+// ScalingBackend names the implementation resample uses for nearest and
+// bilinear scaling. Builds with the neon tag on arm64 use "neon"; see
+// resample_neon.go.
+const ScalingBackend = "go"
+
+// scaleAccelerated is unavailable in this build; resample always uses
+// x/image/draw.
+func scaleAccelerated(_ *image.RGBA, _ image.Rectangle, _ image.Image, _ string) bool {
+	return false
+}
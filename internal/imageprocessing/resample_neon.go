@@ -0,0 +1,219 @@
+//go:build neon && arm64 && cgo
+
+package imageprocessing
+
+/*
+#cgo CFLAGS: -O3
+#cgo LDFLAGS: -lm
+
+#include <arm_neon.h>
+#include <math.h>
+#include <stdint.h>
+#include <stdlib.h>
+#include <string.h>
+
+// Destination pixels sample the source at their centers, like x/image/draw.
+
+static int nearest_index(int src, int dst, int i) {
+	int s = (int)(((int64_t)2 * i + 1) * src / (2 * (int64_t)dst));
+	return s < src ? s : src - 1;
+}
+
+// goframe_scale_nearest scales the sw x sh source into a dw x dh rectangle
+// and writes its columns cx0..cx1 and rows cy0..cy1 to dst.
+static int goframe_scale_nearest(const uint8_t *src, int sstride, int sw, int sh,
+		uint8_t *dst, int dstride, int dw, int dh, int cx0, int cy0, int cx1, int cy1) {
+	int n = cx1 - cx0;
+	int *xs = malloc(sizeof(int) * n);
+	if (xs == NULL) {
+		return 0;
+	}
+	for (int x = 0; x < n; x++) {
+		xs[x] = 4 * nearest_index(sw, dw, cx0 + x);
+	}
+	for (int y = cy0; y < cy1; y++) {
+		const uint8_t *row = src + (size_t)nearest_index(sh, dh, y) * sstride;
+		uint8_t *out = dst + (size_t)(y - cy0) * dstride;
+		for (int x = 0; x < n; x++) {
+			memcpy(out + 4 * x, row + xs[x], 4);
+		}
+	}
+	free(xs);
+	return 1;
+}
+
+// tent_width is the most source pixels a destination pixel of a src -> dst
+// axis reads with the tent kernel.
+static int tent_width(int src, int dst) {
+	double scale = (double)src / dst;
+	return (int)ceil(2 * (scale > 1 ? scale : 1)) + 2;
+}
+
+// tent_taps computes the source pixels and weights of the destination
+// positions lo..hi of a src -> dst axis, m slots per position. Like
+// x/image/draw's BiLinear kernel, the tent widens when shrinking so that
+// every source pixel contributes. Weights are 14-bit fixed point and add up
+// to exactly 1 << 14.
+static void tent_taps(int src, int dst, int lo, int hi, int m, double *scratch,
+		int *index, uint16_t *weight, int *count) {
+	double scale = (double)src / dst, half = 1, arg = 1;
+	if (scale > 1) {
+		half = scale;
+		arg = 1 / scale;
+	}
+	for (int p = lo; p < hi; p++) {
+		double center = (p + 0.5) * scale - 0.5;
+		int i = (int)floor(center - half), j = (int)ceil(center + half);
+		if (i < 0) {
+			i = 0;
+		}
+		if (j > src) {
+			j = src;
+		}
+		int *idx = &index[(p - lo) * m];
+		uint16_t *w = &weight[(p - lo) * m];
+		int k = 0;
+		double total = 0;
+		for (int s = i; s < j && k < m; s++) {
+			double t = fabs((center - s) * arg);
+			if (t < 1) {
+				idx[k] = s;
+				scratch[k] = 1 - t;
+				total += scratch[k];
+				k++;
+			}
+		}
+		if (k == 0) {
+			idx[k] = nearest_index(src, dst, p);
+			scratch[k] = total = 1;
+			k++;
+		}
+		int sum = 0, heaviest = 0;
+		for (int t = 0; t < k; t++) {
+			w[t] = (uint16_t)(scratch[t] / total * (1 << 14) + 0.5);
+			sum += w[t];
+			if (w[t] > w[heaviest]) {
+				heaviest = t;
+			}
+		}
+		w[heaviest] += (1 << 14) - sum;
+		count[p - lo] = k;
+	}
+}
+
+// goframe_scale_bilinear is goframe_scale_nearest with the tent kernel.
+// Each destination row first filters the source rows into 16-bit columns
+// with NEON, eight bytes at a time, then filters those columns into pixels,
+// four channels at a time.
+static int goframe_scale_bilinear(const uint8_t *src, int sstride, int sw, int sh,
+		uint8_t *dst, int dstride, int dw, int dh, int cx0, int cy0, int cx1, int cy1) {
+	int n = cx1 - cx0, rows = cy1 - cy0, width = 4 * sw;
+	int mx = tent_width(sw, dw), my = tent_width(sh, dh);
+	int m = mx > my ? mx : my;
+	int *xi = malloc(sizeof(int) * n * mx), *xc = malloc(sizeof(int) * n);
+	int *yi = malloc(sizeof(int) * rows * my), *yc = malloc(sizeof(int) * rows);
+	uint16_t *xw = malloc(sizeof(uint16_t) * n * mx), *yw = malloc(sizeof(uint16_t) * rows * my);
+	uint16_t *columns = malloc(sizeof(uint16_t) * width);
+	const uint8_t **taps = malloc(sizeof(uint8_t *) * my);
+	double *scratch = malloc(sizeof(double) * m);
+	int ok = xi && xc && yi && yc && xw && yw && columns && taps && scratch;
+	if (ok) {
+		tent_taps(sw, dw, cx0, cx1, mx, scratch, xi, xw, xc);
+		tent_taps(sh, dh, cy0, cy1, my, scratch, yi, yw, yc);
+	}
+	for (int y = 0; ok && y < rows; y++) {
+		const uint16_t *w = &yw[y * my];
+		int k = yc[y];
+		for (int t = 0; t < k; t++) {
+			taps[t] = src + (size_t)yi[y * my + t] * sstride;
+		}
+		int i = 0;
+		for (; i + 8 <= width; i += 8) {
+			uint32x4_t lo = vdupq_n_u32(0), hi = vdupq_n_u32(0);
+			for (int t = 0; t < k; t++) {
+				uint16x8_t v = vmovl_u8(vld1_u8(taps[t] + i));
+				lo = vmlal_n_u16(lo, vget_low_u16(v), w[t]);
+				hi = vmlal_n_u16(hi, vget_high_u16(v), w[t]);
+			}
+			vst1q_u16(columns + i, vcombine_u16(vrshrn_n_u32(lo, 6), vrshrn_n_u32(hi, 6)));
+		}
+		for (; i < width; i++) {
+			uint32_t v = 0;
+			for (int t = 0; t < k; t++) {
+				v += (uint32_t)taps[t][i] * w[t];
+			}
+			columns[i] = (uint16_t)((v + 32) >> 6);
+		}
+
+		uint8_t *out = dst + (size_t)y * dstride;
+		for (int x = 0; x < n; x++) {
+			const int *idx = &xi[x * mx];
+			const uint16_t *wx = &xw[x * mx];
+			uint32x4_t v = vdupq_n_u32(0);
+			for (int t = 0; t < xc[x]; t++) {
+				v = vmlal_n_u16(v, vld1_u16(columns + 4 * idx[t]), wx[t]);
+			}
+			uint16x4_t px = vmovn_u32(vrshrq_n_u32(v, 22));
+			uint32_t packed = vget_lane_u32(vreinterpret_u32_u8(vmovn_u16(vcombine_u16(px, px))), 0);
+			memcpy(out + 4 * x, &packed, 4);
+		}
+	}
+	free(xi);
+	free(xc);
+	free(yi);
+	free(yc);
+	free(xw);
+	free(yw);
+	free(columns);
+	free(taps);
+	free(scratch);
+	return ok;
+}
+
+static int goframe_scale(int bilinear, const uint8_t *src, int sstride, int sw, int sh,
+		uint8_t *dst, int dstride, int dw, int dh, int cx0, int cy0, int cx1, int cy1) {
+	if (bilinear) {
+		return goframe_scale_bilinear(src, sstride, sw, sh, dst, dstride, dw, dh, cx0, cy0, cx1, cy1);
+	}
+	return goframe_scale_nearest(src, sstride, sw, sh, dst, dstride, dw, dh, cx0, cy0, cx1, cy1);
+}
+*/
+import "C"
+
+import (
+	"image"
+	"unsafe"
+)
+
+// ScalingBackend names the implementation resample uses for nearest and
+// bilinear scaling of RGBA sources.
+const ScalingBackend = "neon"
+
+// scaleAccelerated scales RGBA sources with nearest or bilinear
+// interpolation using NEON intrinsics, which is several times faster than
+// x/image/draw on a Raspberry Pi 4 or 5. It returns false, leaving the work
+// to x/image/draw, for other sources and interpolations and when the C code
+// cannot allocate its buffers.
+func scaleAccelerated(dst *image.RGBA, dr image.Rectangle, src image.Image, interpolation string) bool {
+	rgba, ok := src.(*image.RGBA)
+	if !ok || (interpolation != InterpolationNearest && interpolation != InterpolationBilinear) {
+		return false
+	}
+	sb := rgba.Bounds()
+	clip := dr.Intersect(dst.Rect)
+	if sb.Empty() || clip.Empty() {
+		return false
+	}
+
+	bilinear := C.int(0)
+	if interpolation == InterpolationBilinear {
+		bilinear = 1
+	}
+	srcPix := (*C.uint8_t)(unsafe.Pointer(&rgba.Pix[rgba.PixOffset(sb.Min.X, sb.Min.Y)]))
+	dstPix := (*C.uint8_t)(unsafe.Pointer(&dst.Pix[dst.PixOffset(clip.Min.X, clip.Min.Y)]))
+	clip = clip.Sub(dr.Min)
+	return C.goframe_scale(bilinear,
+		srcPix, C.int(rgba.Stride), C.int(sb.Dx()), C.int(sb.Dy()),
+		dstPix, C.int(dst.Stride), C.int(dr.Dx()), C.int(dr.Dy()),
+		C.int(clip.Min.X), C.int(clip.Min.Y), C.int(clip.Max.X), C.int(clip.Max.Y)) != 0
+}
@@ -0,0 +1,113 @@
+package imageprocessing
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+func TestInterpolationParam(t *testing.T) {
+	if got, err := interpolationParam(map[string]any{}); err != nil || got != InterpolationBilinear {
+		t.Errorf("default = %q, %v; want bilinear", got, err)
+	}
+	for _, name := range []string{"nearest", "bilinear", "bicubic", "lanczos"} {
+		if got, err := interpolationParam(map[string]any{"interpolation": name}); err != nil || got != name {
+			t.Errorf("%s = %q, %v", name, got, err)
+		}
+	}
+	if _, err := interpolationParam(map[string]any{"interpolation": "cubic"}); err == nil {
+		t.Error("expected an error for an unknown interpolation")
+	}
+	if _, err := NewScaleCommand(map[string]any{"width": 10, "height": 10, "interpolation": "cubic"}); err == nil {
+		t.Error("expected ScaleCommand to reject an unknown interpolation")
+	}
+	if _, err := NewPixelScaleCommand(map[string]any{"width": 10, "interpolation": "cubic"}); err == nil {
+		t.Error("expected PixelScaleCommand to reject an unknown interpolation")
+	}
+}
+
+func TestLanczos3Kernel(t *testing.T) {
+	if got := lanczos3.At(0); got != 1 {
+		t.Errorf("At(0) = %g, want 1", got)
+	}
+	for _, x := range []float64{1, 2, -2, 3, 4} {
+		if got := lanczos3.At(x); got > 1e-12 || got < -1e-12 {
+			t.Errorf("At(%g) = %g, want 0", x, got)
+		}
+	}
+}
+
+func TestResample_SmoothsDownscaledCheckerboard(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	for y := range 64 {
+		for x := range 64 {
+			if (x+y)%2 == 0 {
+				src.SetRGBA(x, y, color.RGBA{255, 255, 255, 255})
+			} else {
+				src.SetRGBA(x, y, color.RGBA{0, 0, 0, 255})
+			}
+		}
+	}
+	for _, interpolation := range []string{InterpolationBilinear, InterpolationBicubic, InterpolationLanczos} {
+		dst := image.NewRGBA(image.Rect(0, 0, 16, 16))
+		resample(dst, dst.Bounds(), src, interpolation)
+		if v := dst.RGBAAt(8, 8).R; v < 96 || v > 160 {
+			t.Errorf("%s: expected the checkerboard to average to gray, got %d", interpolation, v)
+		}
+	}
+
+	// Nearest neighbor keeps single pixels of the pattern, which aliases.
+	dst := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	resample(dst, dst.Bounds(), src, InterpolationNearest)
+	if v := dst.RGBAAt(8, 8).R; v != 0 && v != 255 {
+		t.Errorf("nearest: expected black or white, got %d", v)
+	}
+}
+
+func TestResample_ClipsToCanvas(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for x := range 4 {
+		for y := range 2 {
+			src.SetRGBA(x, y, color.RGBA{uint8(x * 80), 0, 0, 255})
+		}
+	}
+	// Cover a 4x4 canvas with an 8x4 scaled image offset by -2.
+	dst := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	resample(dst, image.Rect(-2, 0, 6, 4), src, InterpolationBilinear)
+	if left, right := dst.RGBAAt(0, 2).R, dst.RGBAAt(3, 2).R; left == 0 || left >= right {
+		t.Errorf("expected the cropped middle of the gradient, got %d..%d", left, right)
+	}
+}
+
+// TestResample_MatchesDraw keeps accelerated builds (see ScalingBackend) within
+// rounding of x/image/draw.
+func TestResample_MatchesDraw(t *testing.T) {
+	src := image.NewRGBA(image.Rect(3, 5, 160, 98))
+	for y := 5; y < 98; y++ {
+		for x := 3; x < 160; x++ {
+			src.SetRGBA(x, y, color.RGBA{uint8(x * 3), uint8(y * 5), uint8(x ^ y), 255})
+		}
+	}
+	for _, dr := range []image.Rectangle{
+		image.Rect(0, 0, 40, 30),
+		image.Rect(-7, 0, 47, 30),
+		image.Rect(-100, -50, 200, 100),
+	} {
+		for interpolation, interpolator := range map[string]xdraw.Interpolator{
+			InterpolationNearest:  xdraw.NearestNeighbor,
+			InterpolationBilinear: xdraw.BiLinear,
+		} {
+			got := image.NewRGBA(image.Rect(0, 0, 40, 30))
+			resample(got, dr, src, interpolation)
+			want := image.NewRGBA(got.Rect)
+			interpolator.Scale(want, dr, src, src.Bounds(), xdraw.Src, nil)
+			for i := range got.Pix {
+				if d := int(got.Pix[i]) - int(want.Pix[i]); d < -1 || d > 1 {
+					t.Fatalf("%s %v: byte %d is %d, x/image/draw gives %d", interpolation, dr, i, got.Pix[i], want.Pix[i])
+				}
+			}
+		}
+	}
+}
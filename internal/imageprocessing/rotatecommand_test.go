@@ -0,0 +1,117 @@
+package imageprocessing
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestNewRotateParamsFromMap(t *testing.T) {
+	tests := []struct {
+		params map[string]any
+		want   float64
+	}{
+		{map[string]any{}, 90},
+		{map[string]any{"degrees": 180}, 180},
+		{map[string]any{"degrees": -90}, 270},
+		{map[string]any{"degrees": 360}, 0},
+		{map[string]any{"degrees": "2.5"}, 2.5},
+	}
+	for _, tt := range tests {
+		p, err := NewRotateParamsFromMap(tt.params)
+		if err != nil {
+			t.Fatalf("%v: unexpected error %v", tt.params, err)
+		}
+		if p.Degrees != tt.want {
+			t.Errorf("%v: expected %g degrees, got %g", tt.params, tt.want, p.Degrees)
+		}
+	}
+	for _, params := range []map[string]any{{"degrees": 400}, {"background": "white"}} {
+		if _, err := NewRotateParamsFromMap(params); err == nil {
+			t.Errorf("expected an error for %v", params)
+		}
+	}
+}
+
+func TestRotateCommand_QuarterTurns(t *testing.T) {
+	// A 3x2 image with a red top-left pixel.
+	input := patternPNG(t, 3, 2, func(x, y int) color.NRGBA {
+		if x == 0 && y == 0 {
+			return color.NRGBA{R: 255, A: 255}
+		}
+		return gray(0)
+	})
+	tests := []struct {
+		degrees      any
+		wantW, wantH int
+		red          image.Point
+	}{
+		{90, 2, 3, image.Pt(1, 0)},
+		{180, 3, 2, image.Pt(2, 1)},
+		{-90, 2, 3, image.Pt(0, 2)},
+	}
+	for _, tt := range tests {
+		command, err := NewRotateCommand(map[string]any{"degrees": tt.degrees})
+		if err != nil {
+			t.Fatal(err)
+		}
+		output, err := command.Execute(input)
+		if err != nil {
+			t.Fatalf("%v: Execute failed: %v", tt.degrees, err)
+		}
+		img, err := decodePNG(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b := img.Bounds(); b.Dx() != tt.wantW || b.Dy() != tt.wantH {
+			t.Errorf("%v: expected %dx%d, got %v", tt.degrees, tt.wantW, tt.wantH, b)
+		}
+		if r, _, _, _ := img.At(tt.red.X, tt.red.Y).RGBA(); r>>8 != 255 {
+			t.Errorf("%v: expected the red pixel at %v", tt.degrees, tt.red)
+		}
+	}
+
+	unchanged, _ := NewRotateCommand(map[string]any{"degrees": 0})
+	if output, _ := unchanged.Execute(input); !bytes.Equal(output, input) {
+		t.Error("expected 0 degrees to return the input")
+	}
+}
+
+func TestRotateCommand_ArbitraryAngleFillsBackground(t *testing.T) {
+	input := patternPNG(t, 40, 20, func(x, y int) color.NRGBA { return gray(0) })
+	command, err := NewRotateCommand(map[string]any{"degrees": 30, "background": "#ff0000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := command.Execute(input)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	img, err := decodePNG(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantW, wantH := rotatedSize(40, 20, 30)
+	if b := img.Bounds(); b.Dx() != wantW || b.Dy() != wantH || wantW != 45 || wantH != 38 {
+		t.Fatalf("expected the 45x38 bounding box, got %v", img.Bounds())
+	}
+	if got := color.RGBAModel.Convert(img.At(0, 0)); got != (color.RGBA{R: 255, A: 255}) {
+		t.Errorf("expected the corner to be background, got %v", got)
+	}
+	if got := color.RGBAModel.Convert(img.At(wantW/2, wantH/2)); got != (color.RGBA{A: 255}) {
+		t.Errorf("expected the center to be the image, got %v", got)
+	}
+}
+
+func TestPipelineQuarterTurns_RotateCommand(t *testing.T) {
+	for degrees, want := range map[float64]int{90: 1, -90: 3, 170: 2, 30: 0} {
+		turns, err := PipelineQuarterTurns([]CommandConfig{{Name: "RotateCommand", Params: map[string]any{"degrees": degrees}}}, 4, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if turns != want {
+			t.Errorf("%g degrees: expected %d quarter turns, got %d", degrees, want, turns)
+		}
+	}
+}
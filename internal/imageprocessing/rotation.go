@@ -7,20 +7,24 @@ import (
 // rotate90 rotates an image by exactly 90 degrees.
 // If clockwise is true the rotation is clockwise, otherwise counterclockwise.
 func rotate90(img image.Image, clockwise bool) image.Image {
-	b := img.Bounds()
+	src := toRGBA(img)
+	b := src.Bounds()
 	w, h := b.Dx(), b.Dy()
 	dst := image.NewRGBA(image.Rect(0, 0, h, w))
-	for y := 0; y < h; y++ {
+	parallelFor(h, func(y int) {
+		row := src.Pix[src.PixOffset(b.Min.X, b.Min.Y+y):]
 		for x := 0; x < w; x++ {
+			var i int
 			if clockwise {
 				// (x,y) -> (h-1-y, x)
-				dst.Set(h-1-y, x, img.At(x, y))
+				i = dst.PixOffset(h-1-y, x)
 			} else {
 				// (x,y) -> (y, w-1-x)
-				dst.Set(y, w-1-x, img.At(x, y))
+				i = dst.PixOffset(y, w-1-x)
 			}
+			copy(dst.Pix[i:i+4], row[x*4:x*4+4])
 		}
-	}
+	})
 	return dst
 }
 
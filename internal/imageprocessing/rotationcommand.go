@@ -2,6 +2,7 @@ package imageprocessing
 
 import (
 	"fmt"
+	"image"
 	"log/slog"
 
 )
@@ -70,29 +71,17 @@ func (c *RotationCommand) Name() string {
 	return c.name
 }
 
-// Execute rotates the image by the configured number of 90-degree steps.
+// Execute rotates the PNG image by the configured number of 90-degree steps.
 func (c *RotationCommand) Execute(imageData []byte) ([]byte, error) {
-	slog.Debug("RotationCommand: decoding image",
-		"input_size_bytes", len(imageData),
+	return executeEncoded(c, imageData)
+}
+
+// ExecuteImage rotates the image by the configured number of 90-degree steps.
+func (c *RotationCommand) ExecuteImage(img image.Image) (image.Image, error) {
+	slog.Debug("RotationCommand: rotating image",
 		"steps", c.params.Steps,
 		"clockwise", c.params.Clockwise)
-
-	img, err := decodePNG(imageData)
-	if err != nil {
-		slog.Error("RotationCommand: failed to decode PNG image", "error", err)
-		return nil, err
-	}
-
-	rotated := applyRotationSteps(img, c.params.Steps, c.params.Clockwise)
-
-	result, err := encodePNG(rotated)
-	if err != nil {
-		slog.Error("RotationCommand: failed to encode image", "error", err)
-		return nil, err
-	}
-
-	slog.Debug("RotationCommand: rotation complete", "output_size_bytes", len(result))
-	return result, nil
+	return applyRotationSteps(img, c.params.Steps, c.params.Clockwise), nil
 }
 
 // GetParams returns the typed parameters.
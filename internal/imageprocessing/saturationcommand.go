@@ -0,0 +1,108 @@
+package imageprocessing
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+)
+
+const (
+	// DefaultSaturationFactor is the default saturation boost, in the range
+	// vendors of color e-paper panels recommend before dithering.
+	DefaultSaturationFactor = 1.3
+	// maxSaturationFactor bounds the factor; larger values saturate every color fully.
+	maxSaturationFactor = 5.0
+)
+
+// SaturationParams represents typed parameters for the saturation command
+type SaturationParams struct {
+	// Factor multiplies the HSL saturation of every pixel; 1 leaves the
+	// image unchanged, 0 turns it gray.
+	Factor float64
+}
+
+// NewSaturationParamsFromMap creates SaturationParams from a generic map
+func NewSaturationParamsFromMap(params map[string]any) (*SaturationParams, error) {
+	p := &SaturationParams{
+		Factor: GetFloatParam(params, "factor", DefaultSaturationFactor),
+	}
+	if p.Factor < 0 || p.Factor > maxSaturationFactor {
+		return nil, fmt.Errorf("factor must be between 0 and %g, got %g", maxSaturationFactor, p.Factor)
+	}
+	return p, nil
+}
+
+// SaturationCommand scales the saturation of an image while keeping hue and
+// lightness, e.g. before DitherCommand, as the palettes of color e-paper
+// panels are muted.
+type SaturationCommand struct {
+	name   string
+	params *SaturationParams
+}
+
+// NewSaturationCommand creates a new saturation command from configuration parameters
+func NewSaturationCommand(params map[string]any) (Command, error) {
+	typedParams, err := NewSaturationParamsFromMap(params)
+	if err != nil {
+		return nil, err
+	}
+	return &SaturationCommand{name: "SaturationCommand", params: typedParams}, nil
+}
+
+// Name returns the command name
+func (c *SaturationCommand) Name() string {
+	return c.name
+}
+
+// Execute scales the saturation of the PNG image. Transparency is kept as it is.
+func (c *SaturationCommand) Execute(imageData []byte) ([]byte, error) {
+	return executeEncoded(c, imageData)
+}
+
+// ExecuteImage scales the saturation of the image. Transparency is kept as it is.
+func (c *SaturationCommand) ExecuteImage(img image.Image) (image.Image, error) {
+	if c.params.Factor == 1 {
+		return img, nil
+	}
+	return scaleSaturation(img, c.params.Factor), nil
+}
+
+// scaleSaturation multiplies the HSL saturation of every pixel by factor,
+// capped at full saturation. With hue and lightness fixed, every channel is
+// linear in saturation around the lightness, so the channels are scaled
+// around it instead of converting to HSL and back.
+func scaleSaturation(img image.Image, factor float64) *image.NRGBA {
+	b := img.Bounds()
+	out := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(out, out.Bounds(), img, b.Min, draw.Src)
+
+	parallelFor(b.Dy(), func(y int) {
+		row := out.Pix[y*out.Stride : y*out.Stride+b.Dx()*4]
+		for off := 0; off < len(row); off += 4 {
+			r, g, bl := float64(row[off])/255, float64(row[off+1])/255, float64(row[off+2])/255
+			hi, lo := max(r, g, bl), min(r, g, bl)
+			if hi == lo {
+				continue
+			}
+			l := (hi + lo) / 2
+			var s float64
+			if l > 0.5 {
+				s = (hi - lo) / (2 - hi - lo)
+			} else {
+				s = (hi - lo) / (hi + lo)
+			}
+			scale := min(s*factor, 1) / s
+			row[off] = clampByte((l + (r-l)*scale) * 255)
+			row[off+1] = clampByte((l + (g-l)*scale) * 255)
+			row[off+2] = clampByte((l + (bl-l)*scale) * 255)
+		}
+	})
+	return out
+}
+
+func init() {
+	// Register the command in the default registry
+	if err := DefaultRegistry.Register("SaturationCommand", NewSaturationCommand); err != nil {
+		panic(fmt.Sprintf("failed to register SaturationCommand: %v", err))
+	}
+}
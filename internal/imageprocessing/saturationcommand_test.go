@@ -0,0 +1,74 @@
+package imageprocessing
+
+import (
+	"bytes"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestNewSaturationParamsFromMap(t *testing.T) {
+	p, err := NewSaturationParamsFromMap(map[string]any{})
+	if err != nil {
+		t.Fatalf("defaults failed: %v", err)
+	}
+	if p.Factor != DefaultSaturationFactor {
+		t.Errorf("unexpected default factor %g", p.Factor)
+	}
+	for _, params := range []map[string]any{{"factor": -0.5}, {"factor": 6}} {
+		if _, err := NewSaturationParamsFromMap(params); err == nil {
+			t.Errorf("expected an error for %v", params)
+		}
+	}
+}
+
+func TestSaturationCommand_ScalesSaturation(t *testing.T) {
+	colors := []color.NRGBA{
+		{R: 150, G: 110, B: 110, A: 255}, // muted red
+		{R: 90, G: 100, B: 140, A: 128},  // muted blue, half transparent
+		gray(120),
+		{R: 255, A: 255}, // already fully saturated
+	}
+	input := patternPNG(t, len(colors), 1, func(x, y int) color.NRGBA { return colors[x] })
+
+	run := func(factor float64) []color.NRGBA {
+		t.Helper()
+		command, err := NewSaturationCommand(map[string]any{"factor": factor})
+		if err != nil {
+			t.Fatal(err)
+		}
+		output, err := command.Execute(input)
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		img, err := decodePNG(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := make([]color.NRGBA, len(colors))
+		for x := range out {
+			out[x] = color.NRGBAModel.Convert(img.At(x, 0)).(color.NRGBA)
+		}
+		return out
+	}
+
+	boosted := run(1.5)
+	if got, want := boosted[0], (color.NRGBA{R: 160, G: 100, B: 100, A: 255}); got != want {
+		t.Errorf("expected the muted red to become %v, got %v", want, got)
+	}
+	if got := boosted[1]; got.B-got.R <= 50 || got.A != 128 || math.Abs(float64(got.R)+float64(got.B)-230) > 1 {
+		t.Errorf("expected a more saturated blue with the same lightness and alpha, got %v", got)
+	}
+	if boosted[2] != gray(120) || boosted[3] != colors[3] {
+		t.Errorf("expected gray and fully saturated colors to be unchanged, got %v and %v", boosted[2], boosted[3])
+	}
+
+	if desaturated := run(0); desaturated[0] != gray(130) {
+		t.Errorf("expected factor 0 to turn the red gray, got %v", desaturated[0])
+	}
+
+	unchanged, _ := NewSaturationCommand(map[string]any{"factor": 1})
+	if output, _ := unchanged.Execute(input); !bytes.Equal(output, input) {
+		t.Error("expected factor 1 to return the input")
+	}
+}
@@ -8,16 +8,28 @@ import (
 	"image/draw"
 	"image/png"
 	"log/slog"
+	"math"
 
 )
 
 // ScaleParams represents typed parameters for scale command
 const DefaultEdgeGradientBWThreshold = 0.75 // default fraction of full luminance [0..1]
+
+// Fit modes of ScaleCommand: contain letterboxes the whole image, cover fills
+// the target and crops the overflow around the center.
+const (
+	FitContain = "contain"
+	FitCover   = "cover"
+)
+
 type ScaleParams struct {
 	Height                  int
 	Width                   int
 	EdgeGradient            bool
 	EdgeGradientBWThreshold float64
+	Fit                     string
+	// Interpolation is the resampling filter, e.g. "bilinear".
+	Interpolation string
 }
 
 // NewScaleParamsFromMap creates ScaleParams from a generic map
@@ -37,6 +49,15 @@ func NewScaleParamsFromMap(params map[string]any) (*ScaleParams, error) {
 		edgeGradientBWThreshold = 1
 	}
 
+	fit := GetStringParam(params, "fit", FitContain)
+	if fit != FitContain && fit != FitCover {
+		return nil, fmt.Errorf("invalid fit: %s", fit)
+	}
+	interpolation, err := interpolationParam(params)
+	if err != nil {
+		return nil, err
+	}
+
 	// Validate dimensions are positive
 	if height <= 0 {
 		return nil, fmt.Errorf("height must be positive, got %d", height)
@@ -50,6 +71,8 @@ func NewScaleParamsFromMap(params map[string]any) (*ScaleParams, error) {
 		Width:                   width,
 		EdgeGradient:            edgeGradient,
 		EdgeGradientBWThreshold: edgeGradientBWThreshold,
+		Fit:                     fit,
+		Interpolation:           interpolation,
 	}, nil
 }
 
@@ -88,6 +111,8 @@ func NewScaleCommandWithParams(height, width int) (*ScaleCommand, error) {
 			Width:                   width,
 			EdgeGradient:            false,
 			EdgeGradientBWThreshold: DefaultEdgeGradientBWThreshold,
+			Fit:                     FitContain,
+			Interpolation:           DefaultInterpolation,
 		},
 	}, nil
 }
@@ -97,18 +122,13 @@ func (c *ScaleCommand) Name() string {
 	return c.name
 }
 
-// Execute scales the image to target dimensions while preserving aspect ratio
+// Execute scales the PNG image to target dimensions while preserving aspect ratio.
 func (c *ScaleCommand) Execute(imageData []byte) ([]byte, error) {
-	slog.Debug("ScaleCommand: decoding image",
-		"input_size_bytes", len(imageData))
-
-	// Decode the PNG image
-	img, err := decodePNG(imageData)
-	if err != nil {
-		slog.Error("ScaleCommand: failed to decode PNG image", "error", err)
-		return nil, fmt.Errorf("failed to decode PNG image: %w", err)
-	}
+	return executeEncoded(c, imageData)
+}
 
+// ExecuteImage scales the image to target dimensions while preserving aspect ratio.
+func (c *ScaleCommand) ExecuteImage(img image.Image) (image.Image, error) {
 	// Get original dimensions
 	bounds := img.Bounds()
 	originalWidth := bounds.Dx()
@@ -120,7 +140,7 @@ func (c *ScaleCommand) Execute(imageData []byte) ([]byte, error) {
 	// If target matches original dimensions, skip processing
 	if targetWidth == originalWidth && targetHeight == originalHeight {
 		slog.Debug("ScaleCommand: target dimensions equal original; skipping scaling")
-		return imageData, nil
+		return img, nil
 	}
 
 	// Calculate aspect ratios for debugging
@@ -136,6 +156,9 @@ func (c *ScaleCommand) Execute(imageData []byte) ([]byte, error) {
 
 	// Compute scaled dimensions with aspect ratio preserved
 	scaledWidth, scaledHeight := computeScaledDimensions(originalWidth, originalHeight, targetWidth, targetHeight)
+	if c.params.Fit == FitCover {
+		scaledWidth, scaledHeight = computeCoverDimensions(originalWidth, originalHeight, targetWidth, targetHeight)
+	}
 	slog.Debug("ScaleCommand: scaled dimensions calculated",
 		"scaled_width", scaledWidth,
 		"scaled_height", scaledHeight)
@@ -147,9 +170,14 @@ func (c *ScaleCommand) Execute(imageData []byte) ([]byte, error) {
 		"offset_x", offsetX,
 		"offset_y", offsetY)
 
-	// Build index maps and draw scaled image
-	xMap, yMap := buildIndexMaps(originalWidth, originalHeight, scaledWidth, scaledHeight)
-	drawScaledNearest(targetImg, img, offsetX, offsetY, scaledWidth, scaledHeight, xMap, yMap)
+	// Draw the scaled image; with fit cover the offsets are negative and
+	// pixels outside the canvas are dropped.
+	if c.params.Interpolation == InterpolationNearest {
+		xMap, yMap := buildIndexMaps(originalWidth, originalHeight, scaledWidth, scaledHeight)
+		drawScaledNearest(targetImg, img, offsetX, offsetY, scaledWidth, scaledHeight, xMap, yMap)
+	} else {
+		resample(targetImg, image.Rect(offsetX, offsetY, offsetX+scaledWidth, offsetY+scaledHeight), img, c.params.Interpolation)
+	}
 
 	// Optional: Fill padding areas with gradient from image edge colors to black/white border.
 	// Use scaled vs target size to detect any padding (including 1px on one side when centering odd differences).
@@ -157,19 +185,8 @@ func (c *ScaleCommand) Execute(imageData []byte) ([]byte, error) {
 		fillEdgeGradientPadding(targetImg, offsetX, offsetY, scaledWidth, scaledHeight, c.params.EdgeGradientBWThreshold)
 	}
 
-	slog.Debug("ScaleCommand: encoding scaled image")
-
-	// Encode the scaled image to PNG bytes
-	out, err := encodePNG(targetImg)
-	if err != nil {
-		slog.Error("ScaleCommand: failed to encode scaled image", "error", err)
-		return nil, fmt.Errorf("failed to encode scaled PNG image: %w", err)
-	}
-
-	slog.Debug("ScaleCommand: scaling complete",
-		"output_size_bytes", len(out))
-
-	return out, nil
+	slog.Debug("ScaleCommand: scaling complete")
+	return targetImg, nil
 }
 
 // GetHeight returns the configured height
@@ -207,6 +224,19 @@ func computeScaledDimensions(originalWidth, originalHeight, targetWidth, targetH
 	return scaledWidth, scaledHeight
 }
 
+// computeCoverDimensions returns the smallest size with the original aspect
+// ratio that covers the target.
+func computeCoverDimensions(originalWidth, originalHeight, targetWidth, targetHeight int) (int, int) {
+	originalAspect := float64(originalWidth) / float64(originalHeight)
+	targetAspect := float64(targetWidth) / float64(targetHeight)
+	if originalAspect > targetAspect {
+		// Original is wider - scale to target height and crop the sides
+		return max(targetWidth, int(math.Ceil(float64(targetHeight)*originalAspect))), targetHeight
+	}
+	// Original is taller - scale to target width and crop top and bottom
+	return targetWidth, max(targetHeight, int(math.Ceil(float64(targetWidth)/originalAspect)))
+}
+
 func createTargetCanvas(w, h int, bg color.Color) *image.RGBA {
 	dst := image.NewRGBA(image.Rect(0, 0, w, h))
 	draw.Draw(dst, dst.Bounds(), &image.Uniform{bg}, image.Point{}, draw.Src)
@@ -236,11 +266,21 @@ func buildIndexMaps(originalWidth, originalHeight, scaledWidth, scaledHeight int
 }
 
 func drawScaledNearest(dst *image.RGBA, src image.Image, offsetX, offsetY, scaledWidth, scaledHeight int, xMap, yMap []int) {
+	pixels := newPixelReader(src)
+	srcBounds, dstBounds := src.Bounds(), dst.Bounds()
 	parallelFor(scaledHeight, func(y int) {
+		dy := offsetY + y
+		if dy < dstBounds.Min.Y || dy >= dstBounds.Max.Y {
+			return
+		}
+		row := pixels.row(srcBounds.Min.Y+yMap[y], make([]uint8, 4*srcBounds.Dx()))
 		for x := 0; x < scaledWidth; x++ {
-			srcX := xMap[x]
-			srcY := yMap[y]
-			dst.Set(offsetX+x, offsetY+y, src.At(srcX, srcY))
+			dx := offsetX + x
+			if dx < dstBounds.Min.X || dx >= dstBounds.Max.X {
+				continue
+			}
+			i := dst.PixOffset(dx, dy)
+			copy(dst.Pix[i:i+4], row[xMap[x]*4:xMap[x]*4+4])
 		}
 	})
 }
@@ -2,6 +2,7 @@ package imageprocessing
 
 import (
 	"bytes"
+	"image/color"
 	"image/png"
 	"os"
 	"testing"
@@ -253,3 +254,45 @@ func TestScaleCommand_WithRealImage(t *testing.T) {
 		t.Errorf("Expected output dimensions 300x300, got %dx%d", bounds.Dx(), bounds.Dy())
 	}
 }
+
+func TestScaleCommand_Fit(t *testing.T) {
+	// A wide red image on a square target: contain letterboxes it in white,
+	// cover fills the whole canvas.
+	input := solidPNG(t, 200, 100, color.RGBA{255, 0, 0, 255})
+	tests := []struct {
+		fit      string
+		topIsRed bool
+	}{
+		{FitContain, false},
+		{FitCover, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fit, func(t *testing.T) {
+			command, err := NewScaleCommand(map[string]any{"height": 100, "width": 100, "fit": tt.fit})
+			if err != nil {
+				t.Fatalf("Failed to create command: %v", err)
+			}
+			result, err := command.Execute(input)
+			if err != nil {
+				t.Fatalf("Execute failed: %v", err)
+			}
+			img, err := png.Decode(bytes.NewReader(result))
+			if err != nil {
+				t.Fatalf("Result is not valid PNG: %v", err)
+			}
+			if b := img.Bounds(); b.Dx() != 100 || b.Dy() != 100 {
+				t.Fatalf("Expected output dimensions 100x100, got %dx%d", b.Dx(), b.Dy())
+			}
+			r, g, _, _ := img.At(50, 0).RGBA()
+			if isRed := r > 0xf000 && g < 0x1000; isRed != tt.topIsRed {
+				t.Errorf("Expected top edge red=%v, got r=%d g=%d", tt.topIsRed, r, g)
+			}
+		})
+	}
+}
+
+func TestNewScaleCommand_InvalidFit(t *testing.T) {
+	if _, err := NewScaleCommand(map[string]any{"height": 100, "width": 100, "fit": "stretch"}); err == nil {
+		t.Error("Expected error for invalid fit")
+	}
+}
@@ -0,0 +1,160 @@
+package imageprocessing
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+)
+
+const (
+	// DefaultSharpenRadius is the default standard deviation of the blur, in pixels.
+	DefaultSharpenRadius = 1.0
+	// DefaultSharpenAmount is the default strength of the unsharp mask.
+	DefaultSharpenAmount = 1.0
+	// maxSharpenRadius bounds the blur so its kernel stays small.
+	maxSharpenRadius = 10.0
+)
+
+// SharpenParams represents typed parameters for the sharpen command
+type SharpenParams struct {
+	// Radius is the standard deviation of the Gaussian blur the image is
+	// compared against; larger values sharpen coarser edges.
+	Radius float64
+	// Amount scales the difference to the blurred image that is added back;
+	// 1 doubles the local contrast of edges.
+	Amount float64
+	// Threshold is the smallest difference (0-255) that is sharpened, so
+	// flat areas and noise are left alone.
+	Threshold int
+}
+
+// NewSharpenParamsFromMap creates SharpenParams from a generic map
+func NewSharpenParamsFromMap(params map[string]any) (*SharpenParams, error) {
+	p := &SharpenParams{
+		Radius:    GetFloatParam(params, "radius", DefaultSharpenRadius),
+		Amount:    GetFloatParam(params, "amount", DefaultSharpenAmount),
+		Threshold: GetIntParam(params, "threshold", 0),
+	}
+	if p.Radius <= 0 || p.Radius > maxSharpenRadius {
+		return nil, fmt.Errorf("radius must be greater than 0 and at most %g, got %g", maxSharpenRadius, p.Radius)
+	}
+	if p.Amount < 0 {
+		return nil, fmt.Errorf("amount must not be negative, got %g", p.Amount)
+	}
+	if p.Threshold < 0 || p.Threshold > 255 {
+		return nil, fmt.Errorf("threshold must be between 0 and 255, got %d", p.Threshold)
+	}
+	return p, nil
+}
+
+// SharpenCommand restores edge contrast with an unsharp mask, e.g. between
+// ScaleCommand and DitherCommand, where downscaling and dithering soften an image.
+type SharpenCommand struct {
+	name   string
+	params *SharpenParams
+}
+
+// NewSharpenCommand creates a new sharpen command from configuration parameters
+func NewSharpenCommand(params map[string]any) (Command, error) {
+	typedParams, err := NewSharpenParamsFromMap(params)
+	if err != nil {
+		return nil, err
+	}
+	return &SharpenCommand{name: "SharpenCommand", params: typedParams}, nil
+}
+
+// Name returns the command name
+func (c *SharpenCommand) Name() string {
+	return c.name
+}
+
+// Execute sharpens the PNG image. Transparency is kept as it is.
+func (c *SharpenCommand) Execute(imageData []byte) ([]byte, error) {
+	return executeEncoded(c, imageData)
+}
+
+// ExecuteImage sharpens the image. Transparency is kept as it is.
+func (c *SharpenCommand) ExecuteImage(img image.Image) (image.Image, error) {
+	if c.params.Amount == 0 {
+		return img, nil
+	}
+	return unsharpMask(img, *c.params), nil
+}
+
+// unsharpMask adds the difference between img and a Gaussian blur of it,
+// scaled by p.Amount, to every color channel whose difference reaches
+// p.Threshold.
+func unsharpMask(img image.Image, p SharpenParams) *image.NRGBA {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	src := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+
+	kernel := gaussianKernel(p.Radius)
+	k := len(kernel) / 2
+
+	// Separable blur: horizontal into tmp, then vertical while sharpening.
+	tmp := make([]float32, w*h*3)
+	parallelFor(h, func(y int) {
+		row := src.Pix[y*src.Stride:]
+		for x := 0; x < w; x++ {
+			var sum [3]float64
+			for i, weight := range kernel {
+				sx := min(max(x+i-k, 0), w-1)
+				sum[0] += weight * float64(row[sx*4])
+				sum[1] += weight * float64(row[sx*4+1])
+				sum[2] += weight * float64(row[sx*4+2])
+			}
+			off := (y*w + x) * 3
+			tmp[off], tmp[off+1], tmp[off+2] = float32(sum[0]), float32(sum[1]), float32(sum[2])
+		}
+	})
+
+	out := image.NewNRGBA(src.Bounds())
+	copy(out.Pix, src.Pix)
+	threshold := float64(p.Threshold)
+	parallelFor(h, func(y int) {
+		for x := 0; x < w; x++ {
+			var blurred [3]float64
+			for i, weight := range kernel {
+				off := (min(max(y+i-k, 0), h-1)*w + x) * 3
+				blurred[0] += weight * float64(tmp[off])
+				blurred[1] += weight * float64(tmp[off+1])
+				blurred[2] += weight * float64(tmp[off+2])
+			}
+			off := out.PixOffset(x, y)
+			for ch := 0; ch < 3; ch++ {
+				v := float64(src.Pix[off+ch])
+				if diff := v - blurred[ch]; math.Abs(diff) >= threshold {
+					out.Pix[off+ch] = clampByte(v + p.Amount*diff)
+				}
+			}
+		}
+	})
+	return out
+}
+
+// gaussianKernel returns the normalised 1D Gaussian weights for sigma,
+// covering three standard deviations on each side.
+func gaussianKernel(sigma float64) []float64 {
+	k := max(1, int(math.Ceil(3*sigma)))
+	kernel := make([]float64, 2*k+1)
+	var sum float64
+	for i := range kernel {
+		d := float64(i - k)
+		kernel[i] = math.Exp(-d * d / (2 * sigma * sigma))
+		sum += kernel[i]
+	}
+	for i := range kernel {
+		kernel[i] /= sum
+	}
+	return kernel
+}
+
+func init() {
+	// Register the command in the default registry
+	if err := DefaultRegistry.Register("SharpenCommand", NewSharpenCommand); err != nil {
+		panic(fmt.Sprintf("failed to register SharpenCommand: %v", err))
+	}
+}
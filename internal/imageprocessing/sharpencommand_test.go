@@ -0,0 +1,80 @@
+package imageprocessing
+
+import (
+	"bytes"
+	"image/color"
+	"testing"
+)
+
+func TestNewSharpenParamsFromMap(t *testing.T) {
+	p, err := NewSharpenParamsFromMap(map[string]any{})
+	if err != nil {
+		t.Fatalf("defaults failed: %v", err)
+	}
+	if p.Radius != DefaultSharpenRadius || p.Amount != DefaultSharpenAmount || p.Threshold != 0 {
+		t.Errorf("unexpected defaults %+v", p)
+	}
+	for _, params := range []map[string]any{
+		{"radius": 0},
+		{"radius": 11},
+		{"amount": -1},
+		{"threshold": 256},
+	} {
+		if _, err := NewSharpenParamsFromMap(params); err == nil {
+			t.Errorf("expected an error for %v", params)
+		}
+	}
+}
+
+func TestSharpenCommand_IncreasesEdgeContrast(t *testing.T) {
+	// A soft ramp from dark to light grey across the middle of the image.
+	input := patternPNG(t, 40, 10, func(x, y int) color.NRGBA {
+		return gray(min(200, max(50, 50+(x-16)*19)))
+	})
+	command, err := NewSharpenCommand(map[string]any{"radius": 1.5, "amount": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := command.Execute(input)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	img, err := decodePNG(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nrgba := func(x, y int) color.NRGBA { return color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA) }
+	dark, light := nrgba(16, 5), nrgba(24, 5)
+	if dark.R >= 50 || light.R <= 200 {
+		t.Errorf("expected overshoot at both sides of the edge, got %d and %d", dark.R, light.R)
+	}
+	if flat := nrgba(2, 5); flat.R != 50 || flat.A != 255 {
+		t.Errorf("expected flat areas to be unchanged, got %v", flat)
+	}
+}
+
+func TestSharpenCommand_ThresholdSkipsSmallDifferences(t *testing.T) {
+	input := patternPNG(t, 20, 20, func(x, y int) color.NRGBA { return gray(100 + (x+y)%2*4) })
+	command, err := NewSharpenCommand(map[string]any{"threshold": 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := command.Execute(input)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	in, _ := decodePNG(input)
+	out, _ := decodePNG(output)
+	for y := range 20 {
+		for x := range 20 {
+			if in.At(x, y) != out.At(x, y) {
+				t.Fatalf("pixel %d,%d changed below the threshold", x, y)
+			}
+		}
+	}
+
+	zero, _ := NewSharpenCommand(map[string]any{"amount": 0})
+	if unchanged, _ := zero.Execute(input); !bytes.Equal(unchanged, input) {
+		t.Error("expected amount 0 to return the input")
+	}
+}
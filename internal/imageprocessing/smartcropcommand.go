@@ -0,0 +1,285 @@
+package imageprocessing
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"log/slog"
+	"math"
+)
+
+// Strategies of SmartCropCommand.
+const (
+	// SmartCropEdges scores regions by their luminance gradients.
+	SmartCropEdges = "edges"
+	// SmartCropEntropy scores regions by the variety of their luminance.
+	SmartCropEntropy = "entropy"
+	// SmartCropFaces centers the crop on the faces found by a face detection
+	// cascade; without faces it falls back to edges. It needs a build with the
+	// pigo tag.
+	SmartCropFaces = "faces"
+)
+
+const (
+	// smartCropGridSize is the longest side of the grid the energy is computed
+	// on; larger images are sampled in blocks.
+	smartCropGridSize = 256
+	// smartCropEntropyRadius is the radius in grid cells of the neighborhood
+	// whose luminance histogram the entropy is computed from.
+	smartCropEntropyRadius = 2
+	// smartCropEntropyBins is the number of luminance bins of the histogram.
+	smartCropEntropyBins = 16
+)
+
+// SmartCropParams holds the typed parameters for a SmartCropCommand.
+type SmartCropParams struct {
+	Height int
+	Width  int
+	// Strategy selects how the interesting region is found.
+	Strategy string
+	// Cascade is the path to the face detection cascade; faces strategy only.
+	Cascade string
+}
+
+// NewSmartCropParamsFromMap creates SmartCropParams from a generic parameter map.
+func NewSmartCropParamsFromMap(params map[string]any) (*SmartCropParams, error) {
+	if err := ValidateRequiredParams(params, []string{"height", "width"}); err != nil {
+		return nil, err
+	}
+	height := GetIntParam(params, "height", 0)
+	width := GetIntParam(params, "width", 0)
+	if height <= 0 {
+		return nil, fmt.Errorf("height must be positive, got %d", height)
+	}
+	if width <= 0 {
+		return nil, fmt.Errorf("width must be positive, got %d", width)
+	}
+	strategy := GetStringParam(params, "strategy", SmartCropEdges)
+	if strategy != SmartCropEdges && strategy != SmartCropEntropy && strategy != SmartCropFaces {
+		return nil, fmt.Errorf("strategy must be %q, %q or %q, got %q", SmartCropEdges, SmartCropEntropy, SmartCropFaces, strategy)
+	}
+	cascade := GetStringParam(params, "cascade", "")
+	if strategy == SmartCropFaces && cascade == "" {
+		return nil, fmt.Errorf("cascade must be specified for strategy %q", SmartCropFaces)
+	}
+	return &SmartCropParams{Height: height, Width: width, Strategy: strategy, Cascade: cascade}, nil
+}
+
+// SmartCropCommand crops the image to the configured size like CropCommand,
+// but places the crop over the most detailed region instead of the center, so
+// a portrait cropped for a landscape panel keeps the heads rather than the
+// belts. Without a clear winner the crop stays centered.
+type SmartCropCommand struct {
+	name   string
+	params *SmartCropParams
+	// faces is set for the faces strategy.
+	faces faceDetector
+}
+
+// faceDetector finds faces in an image.
+type faceDetector interface {
+	// detectFaces returns the bounding boxes of the faces in img, relative to
+	// its bounds.
+	detectFaces(img *image.RGBA) []image.Rectangle
+}
+
+// NewSmartCropCommand creates a SmartCropCommand from a generic parameter map.
+func NewSmartCropCommand(params map[string]any) (Command, error) {
+	typedParams, err := NewSmartCropParamsFromMap(params)
+	if err != nil {
+		return nil, err
+	}
+	c := &SmartCropCommand{name: "SmartCropCommand", params: typedParams}
+	if typedParams.Strategy == SmartCropFaces {
+		if c.faces, err = loadFaceDetector(typedParams.Cascade); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Name returns the command name.
+func (c *SmartCropCommand) Name() string {
+	return c.name
+}
+
+// Execute crops the PNG image around its most interesting region.
+func (c *SmartCropCommand) Execute(imageData []byte) ([]byte, error) {
+	return executeEncoded(c, imageData)
+}
+
+// ExecuteImage crops the image around its most interesting region.
+func (c *SmartCropCommand) ExecuteImage(img image.Image) (image.Image, error) {
+	b := img.Bounds()
+	cropWidth, cropHeight := min(c.params.Width, b.Dx()), min(c.params.Height, b.Dy())
+	if cropWidth == b.Dx() && cropHeight == b.Dy() {
+		return img, nil
+	}
+
+	rgba := toRGBA(img)
+	var offset image.Point
+	if faces := c.detectFaces(rgba); len(faces) > 0 {
+		offset = faceCropOffset(rgba.Bounds().Size(), faces, cropWidth, cropHeight)
+	} else {
+		offset = smartCropOffset(rgba, cropWidth, cropHeight, c.params.Strategy)
+	}
+	slog.Debug("SmartCropCommand: cropping", "x", offset.X, "y", offset.Y, "width", cropWidth, "height", cropHeight)
+	cropped := image.NewRGBA(image.Rect(0, 0, cropWidth, cropHeight))
+	draw.Draw(cropped, cropped.Bounds(), img, b.Min.Add(offset), draw.Src)
+	return cropped, nil
+}
+
+// GetParams returns the typed parameters.
+func (c *SmartCropCommand) GetParams() *SmartCropParams {
+	return c.params
+}
+
+// detectFaces returns the faces in img for the faces strategy.
+func (c *SmartCropCommand) detectFaces(img *image.RGBA) []image.Rectangle {
+	if c.faces == nil {
+		return nil
+	}
+	faces := c.faces.detectFaces(img)
+	slog.Debug("SmartCropCommand: detected faces", "count", len(faces))
+	return faces
+}
+
+// faceCropOffset returns the top left corner of the cropWidth x cropHeight
+// window centered on the faces, weighting each face by its area so that the
+// main subjects win over faces in the background.
+func faceCropOffset(size image.Point, faces []image.Rectangle, cropWidth, cropHeight int) image.Point {
+	var cx, cy, total float64
+	for _, f := range faces {
+		area := float64(f.Dx() * f.Dy())
+		cx += area * float64(f.Min.X+f.Max.X) / 2
+		cy += area * float64(f.Min.Y+f.Max.Y) / 2
+		total += area
+	}
+	if total == 0 {
+		return image.Point{X: (size.X - cropWidth) / 2, Y: (size.Y - cropHeight) / 2}
+	}
+	return image.Point{
+		X: min(max(int(math.Round(cx/total-float64(cropWidth)/2)), 0), size.X-cropWidth),
+		Y: min(max(int(math.Round(cy/total-float64(cropHeight)/2)), 0), size.Y-cropHeight),
+	}
+}
+
+// smartCropOffset returns the top left corner, relative to the image bounds,
+// of the cropWidth x cropHeight window holding the most energy.
+func smartCropOffset(img *image.RGBA, cropWidth, cropHeight int, strategy string) image.Point {
+	lum, gridW, gridH, step := luminanceGrid(img)
+	var energy []float64
+	switch strategy {
+	case SmartCropEntropy:
+		energy = entropyEnergy(lum, gridW, gridH)
+	default:
+		energy = edgeEnergy(lum, gridW, gridH)
+	}
+
+	// sums is the summed-area table of energy with a zero first row and column.
+	sums := make([]float64, (gridW+1)*(gridH+1))
+	for y := range gridH {
+		row := 0.0
+		for x := range gridW {
+			row += energy[y*gridW+x]
+			sums[(y+1)*(gridW+1)+x+1] = sums[y*(gridW+1)+x+1] + row
+		}
+	}
+	windowW := max(1, min(gridW, int(math.Round(float64(cropWidth)/step))))
+	windowH := max(1, min(gridH, int(math.Round(float64(cropHeight)/step))))
+
+	// The window with the most energy wins; among equal ones the most central,
+	// so flat images are cropped like CropCommand does.
+	centerX, centerY := float64(gridW-windowW)/2, float64(gridH-windowH)/2
+	best, bestScore, bestDistance := image.Point{}, -1.0, math.Inf(1)
+	for y := 0; y+windowH <= gridH; y++ {
+		for x := 0; x+windowW <= gridW; x++ {
+			score := sums[(y+windowH)*(gridW+1)+x+windowW] - sums[y*(gridW+1)+x+windowW] -
+				sums[(y+windowH)*(gridW+1)+x] + sums[y*(gridW+1)+x]
+			distance := math.Hypot(float64(x)-centerX, float64(y)-centerY)
+			// Sums of equal windows may differ by rounding.
+			tolerance := 1e-9 * max(1, bestScore)
+			if score > bestScore+tolerance || (score >= bestScore-tolerance && distance < bestDistance) {
+				best, bestScore, bestDistance = image.Point{X: x, Y: y}, score, distance
+			}
+		}
+	}
+
+	b := img.Bounds()
+	return image.Point{
+		X: min(max(int(math.Round(float64(best.X)*step)), 0), b.Dx()-cropWidth),
+		Y: min(max(int(math.Round(float64(best.Y)*step)), 0), b.Dy()-cropHeight),
+	}
+}
+
+// luminanceGrid averages the luminance of img in square blocks of step pixels
+// so that the longer side has at most smartCropGridSize cells.
+func luminanceGrid(img *image.RGBA) (lum []float64, gridW, gridH int, step float64) {
+	b := img.Bounds()
+	blockSize := max(1, int(math.Ceil(float64(max(b.Dx(), b.Dy()))/smartCropGridSize)))
+	gridW, gridH = (b.Dx()+blockSize-1)/blockSize, (b.Dy()+blockSize-1)/blockSize
+	lum = make([]float64, gridW*gridH)
+	parallelFor(gridH, func(gy int) {
+		for gx := range gridW {
+			sum, n := 0.0, 0
+			for y := gy * blockSize; y < min((gy+1)*blockSize, b.Dy()); y++ {
+				row := img.Pix[y*img.Stride:]
+				for x := gx * blockSize; x < min((gx+1)*blockSize, b.Dx()); x++ {
+					p := row[x*4 : x*4+3]
+					sum += 0.2126*float64(p[0]) + 0.7152*float64(p[1]) + 0.0722*float64(p[2])
+					n++
+				}
+			}
+			lum[gy*gridW+gx] = sum / float64(n)
+		}
+	})
+	return lum, gridW, gridH, float64(blockSize)
+}
+
+// edgeEnergy is the gradient magnitude of the luminance grid.
+func edgeEnergy(lum []float64, gridW, gridH int) []float64 {
+	at := func(x, y int) float64 {
+		return lum[min(max(y, 0), gridH-1)*gridW+min(max(x, 0), gridW-1)]
+	}
+	energy := make([]float64, len(lum))
+	parallelFor(gridH, func(y int) {
+		for x := range gridW {
+			energy[y*gridW+x] = math.Hypot(at(x+1, y)-at(x-1, y), at(x, y+1)-at(x, y-1))
+		}
+	})
+	return energy
+}
+
+// entropyEnergy is the Shannon entropy of the luminance histogram around each
+// cell of the grid.
+func entropyEnergy(lum []float64, gridW, gridH int) []float64 {
+	energy := make([]float64, len(lum))
+	parallelFor(gridH, func(y int) {
+		var hist [smartCropEntropyBins]int
+		for x := range gridW {
+			clear(hist[:])
+			n := 0
+			for ny := max(y-smartCropEntropyRadius, 0); ny <= min(y+smartCropEntropyRadius, gridH-1); ny++ {
+				for nx := max(x-smartCropEntropyRadius, 0); nx <= min(x+smartCropEntropyRadius, gridW-1); nx++ {
+					hist[min(int(lum[ny*gridW+nx])*smartCropEntropyBins/256, smartCropEntropyBins-1)]++
+					n++
+				}
+			}
+			entropy := 0.0
+			for _, count := range hist {
+				if count > 0 {
+					p := float64(count) / float64(n)
+					entropy -= p * math.Log2(p)
+				}
+			}
+			energy[y*gridW+x] = entropy
+		}
+	})
+	return energy
+}
+
+func init() {
+	if err := DefaultRegistry.Register("SmartCropCommand", NewSmartCropCommand); err != nil {
+		panic(fmt.Sprintf("failed to register SmartCropCommand: %v", err))
+	}
+}
@@ -0,0 +1,132 @@
+package imageprocessing
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestNewSmartCropParamsFromMap(t *testing.T) {
+	p, err := NewSmartCropParamsFromMap(map[string]any{"width": 800, "height": 480})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if p.Strategy != SmartCropEdges {
+		t.Errorf("expected the %q strategy by default, got %q", SmartCropEdges, p.Strategy)
+	}
+	for _, params := range []map[string]any{
+		{"width": 800},
+		{"width": 0, "height": 480},
+		{"width": 800, "height": 480, "strategy": "saliency"},
+	} {
+		if _, err := NewSmartCropParamsFromMap(params); err == nil {
+			t.Errorf("expected an error for %v", params)
+		}
+	}
+}
+
+func TestSmartCropCommand_FindsDetail(t *testing.T) {
+	// A tall gray image with a checkerboard near the top, like a head above a
+	// plain body.
+	input := patternPNG(t, 40, 120, func(x, y int) color.NRGBA {
+		if y >= 10 && y < 30 && x >= 10 && x < 30 && (x/2+y/2)%2 == 0 {
+			return gray(255)
+		}
+		return gray(128)
+	})
+	for _, strategy := range []string{SmartCropEdges, SmartCropEntropy} {
+		command, err := NewSmartCropCommand(map[string]any{"width": 40, "height": 40, "strategy": strategy})
+		if err != nil {
+			t.Fatal(err)
+		}
+		output, err := command.Execute(input)
+		if err != nil {
+			t.Fatalf("%s: Execute failed: %v", strategy, err)
+		}
+		img, err := png.Decode(bytes.NewReader(output))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if img.Bounds() != image.Rect(0, 0, 40, 40) {
+			t.Fatalf("%s: expected 40x40, got %v", strategy, img.Bounds())
+		}
+		offset := smartCropOffset(toRGBA(mustDecode(t, input)), 40, 40, strategy)
+		if offset.X != 0 || offset.Y > 10 {
+			t.Errorf("%s: expected the crop to cover the checkerboard at the top, got offset %v", strategy, offset)
+		}
+	}
+}
+
+// stubFaces is a faceDetector that reports fixed faces.
+type stubFaces []image.Rectangle
+
+func (f stubFaces) detectFaces(*image.RGBA) []image.Rectangle { return f }
+
+func TestSmartCropCommand_FacesCentersOnFaces(t *testing.T) {
+	// A busy checkerboard on the left that edges would pick; the red channel
+	// encodes the column so the crop offset can be read from the output.
+	input := mustDecode(t, patternPNG(t, 120, 40, func(x, y int) color.NRGBA {
+		g := uint8(0)
+		if x < 40 && (x+y)%2 == 0 {
+			g = 255
+		}
+		return color.NRGBA{R: uint8(x), G: g, A: 255}
+	}))
+	params := &SmartCropParams{Width: 40, Height: 40, Strategy: SmartCropFaces, Cascade: "facefinder"}
+	tests := []struct {
+		name  string
+		faces stubFaces
+		wantX int
+	}{
+		{"one face", stubFaces{image.Rect(85, 10, 105, 30)}, 75},
+		{"larger face wins", stubFaces{image.Rect(0, 0, 6, 6), image.Rect(60, 5, 90, 35)}, 52},
+		{"face at the edge", stubFaces{image.Rect(110, 10, 120, 20)}, 80},
+		{"no face falls back to edges", nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &SmartCropCommand{name: "SmartCropCommand", params: params, faces: tt.faces}
+			out, err := c.ExecuteImage(input)
+			if err != nil {
+				t.Fatalf("ExecuteImage failed: %v", err)
+			}
+			if r, _, _, _ := out.At(0, 0).RGBA(); int(r>>8) != tt.wantX {
+				t.Errorf("expected the crop at x=%d, got x=%d", tt.wantX, r>>8)
+			}
+		})
+	}
+}
+
+func TestNewSmartCropParams_FacesNeedCascade(t *testing.T) {
+	if _, err := NewSmartCropParamsFromMap(map[string]any{"width": 10, "height": 10, "strategy": SmartCropFaces}); err == nil {
+		t.Error("expected an error for the faces strategy without a cascade")
+	}
+	if _, err := NewSmartCropParamsFromMap(map[string]any{"width": 10, "height": 10, "strategy": "skin"}); err == nil {
+		t.Error("expected an error for an unknown strategy")
+	}
+}
+
+func TestSmartCropCommand_CentersFlatImage(t *testing.T) {
+	input := patternPNG(t, 90, 30, func(x, y int) color.NRGBA { return gray(200) })
+	offset := smartCropOffset(toRGBA(mustDecode(t, input)), 30, 30, SmartCropEdges)
+	if offset != image.Pt(30, 0) {
+		t.Errorf("expected a centered crop, got offset %v", offset)
+	}
+}
+
+func TestSmartCropCommand_SmallerImageUnchanged(t *testing.T) {
+	input := patternPNG(t, 20, 10, func(x, y int) color.NRGBA { return gray(x * 10) })
+	command, err := NewSmartCropCommand(map[string]any{"width": 40, "height": 40})
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := command.Execute(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(output, input) {
+		t.Error("expected an image within the crop size to be returned unchanged")
+	}
+}
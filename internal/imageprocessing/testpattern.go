@@ -0,0 +1,209 @@
+package imageprocessing
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"slices"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Test pattern types.
+const (
+	// TestPatternStripes shows checkerboards and line pairs of increasing pitch
+	// in the darkest and lightest palette colors, to check sharpness and ghosting.
+	TestPatternStripes = "stripes"
+	// TestPatternGradient shows ramps from white to each palette color, dithered
+	// to the palette like photos are.
+	TestPatternGradient = "gradient"
+	// TestPatternPalette shows one labeled color bar per palette color.
+	TestPatternPalette = "palette"
+)
+
+// TestPatternTypes lists the supported test pattern types.
+var TestPatternTypes = []string{TestPatternStripes, TestPatternGradient, TestPatternPalette}
+
+const (
+	// defaultPanelWidth and defaultPanelHeight are used when no command fixes
+	// the output size; 800x480 is a common e-paper resolution.
+	defaultPanelWidth  = 800
+	defaultPanelHeight = 480
+)
+
+// PanelProfile is the size and palette processed images are produced for.
+type PanelProfile struct {
+	Width, Height int
+	// Palette pairs the colors the panel shows with the colors used for dithering.
+	Palette []ColorPair
+	// Algorithm is the dithering algorithm, "floyd-steinberg" or "atkinson".
+	Algorithm string
+}
+
+// PanelProfileFromCommands derives the panel profile from a pipeline: the size
+// of the last ScaleCommand, CropCommand or SmartCropCommand and the palette of
+// the last DitherCommand or AutoEnhanceCommand with a palette. Without such
+// commands the profile is 800x480 in black and white.
+func PanelProfileFromCommands(configs []CommandConfig) (PanelProfile, error) {
+	profile := PanelProfile{
+		Width:     defaultPanelWidth,
+		Height:    defaultPanelHeight,
+		Palette:   defaultBWPalettePairs(),
+		Algorithm: "floyd-steinberg",
+	}
+	for _, cfg := range configs {
+		switch cfg.Name {
+		case "ScaleCommand", "CropCommand", "SmartCropCommand":
+			if w, h := GetIntParam(cfg.Params, "width", 0), GetIntParam(cfg.Params, "height", 0); w > 0 && h > 0 {
+				profile.Width, profile.Height = w, h
+			}
+		case "AutoEnhanceCommand", "DitherCommand":
+			if _, ok := cfg.Params["palette"]; !ok && cfg.Name == "AutoEnhanceCommand" {
+				// AutoEnhanceCommand only dithers when given a palette.
+				continue
+			}
+			params, err := NewDitherParamsFromMap(cfg.Params)
+			if err != nil {
+				return PanelProfile{}, fmt.Errorf("%s: %w", cfg.Name, err)
+			}
+			profile.Palette, profile.Algorithm = params.PalettePairs, params.Algorithm
+		}
+	}
+	return profile, nil
+}
+
+// RenderTestPattern draws the diagnostic image kind (see TestPatternTypes) at
+// the size of profile, using only the colors of its palette, and encodes it as PNG.
+func RenderTestPattern(kind string, profile PanelProfile) ([]byte, error) {
+	if profile.Width <= 0 || profile.Height <= 0 {
+		return nil, fmt.Errorf("invalid panel size %dx%d", profile.Width, profile.Height)
+	}
+	if len(profile.Palette) == 0 || len(profile.Palette) > 256 {
+		return nil, fmt.Errorf("palette must have between 1 and 256 colors, got %d", len(profile.Palette))
+	}
+	devicePalette, ditherPalette := palettesFromPairs(profile.Palette)
+	bounds := image.Rect(0, 0, profile.Width, profile.Height)
+
+	var img image.Image
+	switch kind {
+	case TestPatternStripes:
+		img = drawStripesPattern(bounds, devicePalette)
+	case TestPatternPalette:
+		img = drawPalettePattern(bounds, devicePalette)
+	case TestPatternGradient:
+		ramps := drawGradientPattern(bounds, profile.Palette)
+		var err error
+		if profile.Algorithm == "atkinson" {
+			img, err = ditherAndMapAtkinson(ramps, ditherPalette, devicePalette, fullDitherStrength)
+		} else {
+			img, err = ditherAndMapFloydSteinberg(ramps, ditherPalette, devicePalette, fullDitherStrength)
+		}
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown test pattern %q", kind)
+	}
+	return encodePNGImage(img)
+}
+
+// darkestAndLightest returns the palette colors with the lowest and highest luma.
+func darkestAndLightest(palette []color.RGBA) (dark, light color.RGBA) {
+	luma := func(c color.RGBA) int { return 299*int(c.R) + 587*int(c.G) + 114*int(c.B) }
+	dark = slices.MinFunc(palette, func(a, b color.RGBA) int { return luma(a) - luma(b) })
+	light = slices.MaxFunc(palette, func(a, b color.RGBA) int { return luma(a) - luma(b) })
+	return dark, light
+}
+
+// drawStripesPattern fills the top half with checkerboards and the bottom half
+// with alternating vertical and horizontal line pairs, in columns of 1, 2, 4
+// and 8 pixel pitch.
+func drawStripesPattern(bounds image.Rectangle, palette []color.RGBA) image.Image {
+	dark, light := darkestAndLightest(palette)
+	img := image.NewPaletted(bounds, toColorPalette([]color.RGBA{dark, light}))
+	pitches := []int{1, 2, 4, 8}
+	w, h := bounds.Dx(), bounds.Dy()
+	for y := range h {
+		for x := range w {
+			col := min(x*len(pitches)/w, len(pitches)-1)
+			p := pitches[col]
+			var on bool
+			switch {
+			case y < h/2:
+				on = (x/p+y/p)%2 == 0
+			case y < h*3/4:
+				on = (x/p)%2 == 0
+			default:
+				on = (y/p)%2 == 0
+			}
+			if on {
+				img.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+	return img
+}
+
+// drawGradientPattern stacks horizontal bands of ramps: black to white, then
+// white to the dithering color of each palette entry whose device color is
+// not a shade of gray.
+func drawGradientPattern(bounds image.Rectangle, palette []ColorPair) image.Image {
+	type ramp struct{ from, to color.RGBA }
+	ramps := []ramp{{from: color.RGBA{A: 255}, to: color.RGBA{R: 255, G: 255, B: 255, A: 255}}}
+	for _, p := range palette {
+		if c := p.Device; c.R != c.G || c.G != c.B {
+			ramps = append(ramps, ramp{from: color.RGBA{R: 255, G: 255, B: 255, A: 255}, to: p.Dither})
+		}
+	}
+
+	img := image.NewRGBA(bounds)
+	w, h := bounds.Dx(), bounds.Dy()
+	lerp := func(a, b uint8, t float64) uint8 { return uint8(float64(a) + (float64(b)-float64(a))*t + 0.5) }
+	for y := range h {
+		r := ramps[min(y*len(ramps)/h, len(ramps)-1)]
+		for x := range w {
+			t := float64(x) / float64(max(w-1, 1))
+			img.SetRGBA(x, y, color.RGBA{
+				R: lerp(r.from.R, r.to.R, t),
+				G: lerp(r.from.G, r.to.G, t),
+				B: lerp(r.from.B, r.to.B, t),
+				A: 255,
+			})
+		}
+	}
+	return img
+}
+
+// drawPalettePattern draws one vertical bar per palette color, labeled with
+// its index and hex value where the bar is wide enough.
+func drawPalettePattern(bounds image.Rectangle, palette []color.RGBA) image.Image {
+	dark, light := darkestAndLightest(palette)
+	img := image.NewPaletted(bounds, toColorPalette(palette))
+	w := bounds.Dx()
+	for i, c := range palette {
+		bar := image.Rect(i*w/len(palette), bounds.Min.Y, (i+1)*w/len(palette), bounds.Max.Y)
+		draw.Draw(img, bar, &image.Uniform{c}, image.Point{}, draw.Src)
+
+		face := basicfont.Face7x13
+		label := fmt.Sprintf("%d #%02x%02x%02x", i, c.R, c.G, c.B)
+		if font.MeasureString(face, label).Ceil() > bar.Dx()-2*attributionPadding || bar.Dy() < face.Height+2*attributionPadding {
+			continue
+		}
+		// Label light colors in the darkest color and dark ones in the lightest.
+		ink := dark
+		if 299*int(c.R)+587*int(c.G)+114*int(c.B) < 128*1000 {
+			ink = light
+		}
+		drawer := &font.Drawer{
+			Dst:  img,
+			Src:  &image.Uniform{ink},
+			Face: face,
+			Dot:  fixed.P(bar.Min.X+attributionPadding, bar.Min.Y+attributionPadding+face.Ascent),
+		}
+		drawer.DrawString(label)
+	}
+	return img
+}
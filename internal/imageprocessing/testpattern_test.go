@@ -0,0 +1,93 @@
+package imageprocessing
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestPanelProfileFromCommands(t *testing.T) {
+	profile, err := PanelProfileFromCommands(nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if profile.Width != defaultPanelWidth || profile.Height != defaultPanelHeight || len(profile.Palette) != 2 {
+		t.Errorf("Expected black and white default profile, got %+v", profile)
+	}
+
+	profile, err = PanelProfileFromCommands([]CommandConfig{
+		{Name: "ScaleCommand", Params: map[string]any{"width": 1600, "height": 1200}},
+		{Name: "AutoEnhanceCommand", Params: map[string]any{}},
+		{Name: "CropCommand", Params: map[string]any{"width": 1200, "height": 1600}},
+		{Name: "DitherCommand", Params: map[string]any{
+			"ditheringAlgorithm": "atkinson",
+			"palette": []any{
+				[]any{[]any{0, 0, 0}, []any{25, 30, 33}},
+				[]any{[]any{255, 255, 255}, []any{232, 232, 232}},
+				[]any{[]any{255, 0, 0}, []any{200, 40, 40}},
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if profile.Width != 1200 || profile.Height != 1600 || len(profile.Palette) != 3 || profile.Algorithm != "atkinson" {
+		t.Errorf("Expected profile of the last crop and dither, got %+v", profile)
+	}
+
+	if _, err := PanelProfileFromCommands([]CommandConfig{{Name: "DitherCommand", Params: map[string]any{"palette": "red"}}}); err == nil {
+		t.Error("Expected error for invalid palette")
+	}
+}
+
+func TestRenderTestPattern_UsesPanelSizeAndPalette(t *testing.T) {
+	profile := PanelProfile{
+		Width:  200,
+		Height: 120,
+		Palette: []ColorPair{
+			{Device: color.RGBA{A: 255}, Dither: color.RGBA{R: 25, G: 30, B: 33, A: 255}},
+			{Device: color.RGBA{R: 255, G: 255, B: 255, A: 255}, Dither: color.RGBA{R: 232, G: 232, B: 232, A: 255}},
+			{Device: color.RGBA{R: 255, A: 255}, Dither: color.RGBA{R: 200, G: 40, B: 40, A: 255}},
+		},
+	}
+	device := map[color.RGBA]bool{}
+	for _, p := range profile.Palette {
+		device[p.Device] = true
+	}
+
+	for _, kind := range TestPatternTypes {
+		t.Run(kind, func(t *testing.T) {
+			out, err := RenderTestPattern(kind, profile)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			img, err := png.Decode(bytes.NewReader(out))
+			if err != nil {
+				t.Fatalf("Failed to decode output: %v", err)
+			}
+			if img.Bounds().Dx() != 200 || img.Bounds().Dy() != 120 {
+				t.Fatalf("Expected panel size, got %v", img.Bounds())
+			}
+			seen := map[color.RGBA]bool{}
+			for y := 0; y < 120; y++ {
+				for x := 0; x < 200; x++ {
+					c := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+					if !device[c] {
+						t.Fatalf("Expected only palette colors, got %v at (%d,%d)", c, x, y)
+					}
+					seen[c] = true
+				}
+			}
+			if len(seen) < 2 {
+				t.Errorf("Expected a pattern, got a single color")
+			}
+		})
+	}
+}
+
+func TestRenderTestPattern_RejectsUnknownType(t *testing.T) {
+	if _, err := RenderTestPattern("noise", PanelProfile{Width: 10, Height: 10, Palette: defaultBWPalettePairs()}); err == nil {
+		t.Error("Expected error for unknown pattern")
+	}
+}
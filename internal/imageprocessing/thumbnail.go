@@ -0,0 +1,85 @@
+package imageprocessing
+
+import (
+	"fmt"
+	"image"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// PipelineQuarterTurns reports by how many clockwise quarter turns (0-3) the
+// RotationCommand, RotateCommand and OrientationCommand steps of configs turn
+// an image of the given size. RotateCommand angles between quarter turns
+// count as the nearest one. Other steps are assumed to keep its aspect ratio.
+func PipelineQuarterTurns(configs []CommandConfig, width, height int) (int, error) {
+	turns := 0
+	for _, cfg := range configs {
+		step := 0
+		switch cfg.Name {
+		case "RotationCommand":
+			params, err := NewRotationParamsFromMap(cfg.Params)
+			if err != nil {
+				return 0, err
+			}
+			step = params.Steps
+			if !params.Clockwise {
+				step = -step
+			}
+		case "RotateCommand":
+			params, err := NewRotateParamsFromMap(cfg.Params)
+			if err != nil {
+				return 0, err
+			}
+			step, _ = params.quarterTurns()
+		case "OrientationCommand":
+			params, err := NewOrientationParamsFromMap(cfg.Params)
+			if err != nil {
+				return 0, err
+			}
+			rotate := params.RotateWhenSquare
+			if width != height {
+				rotate = (height > width) != (params.Orientation == "portrait")
+			}
+			if rotate {
+				step = 1
+				if !params.Clockwise {
+					step = -1
+				}
+			}
+		}
+		if step%2 != 0 {
+			width, height = height, width
+		}
+		turns = ((turns+step)%4 + 4) % 4
+	}
+	return turns, nil
+}
+
+// Thumbnail renders a PNG preview of imageData turned clockwise by quarterTurns
+// (see PipelineQuarterTurns), so it shows the image the way the frame does.
+// The preview is at most width pixels wide; smaller images are not enlarged.
+func Thumbnail(imageData []byte, width, quarterTurns int) ([]byte, error) {
+	if width <= 0 {
+		return nil, fmt.Errorf("thumbnail width must be positive, got %d", width)
+	}
+	img, err := decodePNG(imageData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode PNG image: %w", err)
+	}
+
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	// The width limit applies after turning, i.e. to the height for odd turns.
+	limit := w
+	if quarterTurns%2 != 0 {
+		limit = h
+	}
+	if limit > width {
+		w = max(1, w*width/limit)
+		h = max(1, h*width/limit)
+	}
+
+	small := image.NewRGBA(image.Rect(0, 0, w, h))
+	xdraw.ApproxBiLinear.Scale(small, small.Bounds(), img, bounds, xdraw.Src, nil)
+	return encodePNG(applyRotationSteps(small, ((quarterTurns%4)+4)%4, true))
+}
@@ -0,0 +1,71 @@
+package imageprocessing
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestPipelineQuarterTurns(t *testing.T) {
+	tests := []struct {
+		name          string
+		configs       []CommandConfig
+		width, height int
+		want          int
+	}{
+		{"no rotation", []CommandConfig{{Name: "ScaleCommand"}}, 40, 30, 0},
+		{"rotation default", []CommandConfig{{Name: "RotationCommand", Params: map[string]any{}}}, 40, 30, 1},
+		{"rotation counterclockwise", []CommandConfig{{Name: "RotationCommand", Params: map[string]any{"steps": 1, "clockwise": false}}}, 40, 30, 3},
+		{"orientation rotates landscape", []CommandConfig{{Name: "OrientationCommand", Params: map[string]any{"orientation": "portrait"}}}, 40, 30, 1},
+		{"orientation keeps portrait", []CommandConfig{{Name: "OrientationCommand", Params: map[string]any{"orientation": "portrait"}}}, 30, 40, 0},
+		{"orientation after rotation", []CommandConfig{
+			{Name: "RotationCommand", Params: map[string]any{"steps": 1}},
+			{Name: "OrientationCommand", Params: map[string]any{"orientation": "landscape"}},
+		}, 40, 30, 2},
+		{"square", []CommandConfig{{Name: "OrientationCommand", Params: map[string]any{"rotateWhenSquare": true}}}, 30, 30, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PipelineQuarterTurns(tt.configs, tt.width, tt.height)
+			if err != nil {
+				t.Fatalf("PipelineQuarterTurns failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("PipelineQuarterTurns() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := PipelineQuarterTurns([]CommandConfig{{Name: "RotationCommand", Params: map[string]any{"steps": 5}}}, 1, 1); err == nil {
+		t.Error("Expected an error for invalid rotation steps")
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	src := solidPNG(t, 400, 200, color.White)
+	tests := []struct {
+		name         string
+		width, turns int
+		wantW, wantH int
+	}{
+		{"downscaled", 100, 0, 100, 50},
+		{"turned", 100, 1, 100, 200},
+		{"not enlarged", 1000, 0, 400, 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := Thumbnail(src, tt.width, tt.turns)
+			if err != nil {
+				t.Fatalf("Thumbnail failed: %v", err)
+			}
+			img, err := png.Decode(bytes.NewReader(out))
+			if err != nil {
+				t.Fatalf("result is not a PNG: %v", err)
+			}
+			if b := img.Bounds(); b.Dx() != tt.wantW || b.Dy() != tt.wantH {
+				t.Errorf("size = %dx%d, want %dx%d", b.Dx(), b.Dy(), tt.wantW, tt.wantH)
+			}
+		})
+	}
+}
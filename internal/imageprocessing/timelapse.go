@@ -0,0 +1,77 @@
+package imageprocessing
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"log/slog"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// EncodeTimelapseGIF renders the PNG frames into an animated GIF that loops
+// forever. The canvas is width pixels wide with the aspect ratio of the first
+// frame; every frame is scaled to fit and centered on white. delay is the time
+// each frame is shown, in hundredths of a second.
+func EncodeTimelapseGIF(frames [][]byte, width, delay int) ([]byte, error) {
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("no frames to encode")
+	}
+	if width <= 0 {
+		return nil, fmt.Errorf("width must be positive, got %d", width)
+	}
+	if delay < 0 {
+		return nil, fmt.Errorf("delay must not be negative, got %d", delay)
+	}
+
+	anim := &gif.GIF{}
+	var canvas image.Rectangle
+	for i, data := range frames {
+		img, err := decodePNG(data)
+		if err != nil {
+			slog.Error("EncodeTimelapseGIF: failed to decode frame", "frame", i, "error", err)
+			return nil, fmt.Errorf("failed to decode frame %d: %w", i, err)
+		}
+		if i == 0 {
+			b := img.Bounds()
+			canvas = image.Rect(0, 0, width, max(1, width*b.Dy()/max(1, b.Dx())))
+		}
+
+		rgba := image.NewRGBA(canvas)
+		draw.Draw(rgba, canvas, image.NewUniform(color.White), image.Point{}, draw.Src)
+		xdraw.CatmullRom.Scale(rgba, fitRect(img.Bounds(), canvas), img, img.Bounds(), xdraw.Over, nil)
+
+		paletted := image.NewPaletted(canvas, palette.Plan9)
+		draw.FloydSteinberg.Draw(paletted, canvas, rgba, image.Point{})
+		anim.Image = append(anim.Image, paletted)
+		anim.Delay = append(anim.Delay, delay)
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		slog.Error("EncodeTimelapseGIF: failed to encode GIF", "error", err)
+		return nil, fmt.Errorf("failed to encode GIF: %w", err)
+	}
+	slog.Debug("EncodeTimelapseGIF: time-lapse encoded",
+		"frames", len(frames), "width", canvas.Dx(), "height", canvas.Dy(), "output_size_bytes", buf.Len())
+	return buf.Bytes(), nil
+}
+
+// fitRect returns the largest rectangle with the aspect ratio of src that fits
+// centered inside dst.
+func fitRect(src, dst image.Rectangle) image.Rectangle {
+	sw, sh := max(1, src.Dx()), max(1, src.Dy())
+	w, h := dst.Dx(), dst.Dy()
+	if sw*h > sh*w {
+		h = max(1, sh*w/sw)
+	} else {
+		w = max(1, sw*h/sh)
+	}
+	x := dst.Min.X + (dst.Dx()-w)/2
+	y := dst.Min.Y + (dst.Dy()-h)/2
+	return image.Rect(x, y, x+w, y+h)
+}
@@ -0,0 +1,46 @@
+package imageprocessing
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+)
+
+func TestEncodeTimelapseGIF(t *testing.T) {
+	frames := [][]byte{
+		solidPNG(t, 40, 20, color.RGBA{R: 255, A: 255}),
+		solidPNG(t, 20, 40, color.RGBA{B: 255, A: 255}),
+	}
+	data, err := EncodeTimelapseGIF(frames, 100, 50)
+	if err != nil {
+		t.Fatalf("EncodeTimelapseGIF failed: %v", err)
+	}
+
+	anim, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to decode GIF: %v", err)
+	}
+	if len(anim.Image) != 2 || anim.Delay[1] != 50 {
+		t.Fatalf("expected 2 frames with delay 50, got %d frames, delays %v", len(anim.Image), anim.Delay)
+	}
+	if b := anim.Image[1].Bounds(); b.Dx() != 100 || b.Dy() != 50 {
+		t.Errorf("expected every frame on the first frame's 100x50 canvas, got %v", b)
+	}
+	// The portrait frame is letterboxed: its corners stay white.
+	if r, g, b, _ := anim.Image[1].At(0, 0).RGBA(); r>>8 < 240 || g>>8 < 240 || b>>8 < 240 {
+		t.Errorf("expected white letterbox, got %v", anim.Image[1].At(0, 0))
+	}
+
+	if _, err := EncodeTimelapseGIF(nil, 100, 50); err == nil {
+		t.Error("expected error without frames")
+	}
+}
+
+func TestFitRect(t *testing.T) {
+	got := fitRect(image.Rect(0, 0, 20, 40), image.Rect(0, 0, 100, 50))
+	if want := image.Rect(37, 0, 62, 50); got != want {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,209 @@
+//go:build wazero
+
+package imageprocessing
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/draw"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+// WasmCommand runs a user-supplied WebAssembly module as a pixel filter.
+//
+// The module must export:
+//
+//	memory                                   linear memory
+//	alloc(size u32) -> ptr u32               returns a buffer of at least size bytes
+//	filter(ptr u32, width u32, height u32) -> i32
+//
+// filter receives width*height*4 bytes of non-premultiplied RGBA pixels
+// (row-major, no padding) at ptr, modifies them in place, and returns 0 on
+// success. Modules run with WASI imports but no filesystem, network or
+// environment access, and are bounded by a memory limit and a timeout.
+
+const (
+	wasmPageSize         = 64 * 1024
+	defaultWasmTimeoutMs = 10000
+	defaultWasmMaxMemMB  = 256
+	wasmFilterExport     = "filter"
+	wasmAllocExport      = "alloc"
+	wasmFilterStatusOK   = 0
+)
+
+// WasmParams holds the typed parameters for a WasmCommand.
+type WasmParams struct {
+	// ModulePath is the path to the .wasm file implementing the filter ABI.
+	ModulePath string
+	// Timeout bounds a single filter invocation.
+	Timeout time.Duration
+	// MaxMemoryMB caps the module's linear memory.
+	MaxMemoryMB int
+}
+
+// NewWasmParamsFromMap creates WasmParams from a generic parameter map.
+func NewWasmParamsFromMap(params map[string]any) (*WasmParams, error) {
+	modulePath := GetStringParam(params, "module", "")
+	timeoutMs := GetIntParam(params, "timeoutMs", defaultWasmTimeoutMs)
+	maxMemoryMB := GetIntParam(params, "maxMemoryMB", defaultWasmMaxMemMB)
+	return NewWasmParams(modulePath, time.Duration(timeoutMs)*time.Millisecond, maxMemoryMB)
+}
+
+// NewWasmParams creates and validates WasmParams from concrete values.
+func NewWasmParams(modulePath string, timeout time.Duration, maxMemoryMB int) (*WasmParams, error) {
+	if modulePath == "" {
+		return nil, fmt.Errorf("module must be specified")
+	}
+	if timeout <= 0 {
+		return nil, fmt.Errorf("timeoutMs must be positive, got %d", timeout.Milliseconds())
+	}
+	// wazero limits memory to 65536 pages (4 GiB).
+	if maxMemoryMB <= 0 || maxMemoryMB > 4096 {
+		return nil, fmt.Errorf("maxMemoryMB must be between 1 and 4096, got %d", maxMemoryMB)
+	}
+	return &WasmParams{ModulePath: modulePath, Timeout: timeout, MaxMemoryMB: maxMemoryMB}, nil
+}
+
+// WasmCommand applies a sandboxed WebAssembly pixel filter.
+type WasmCommand struct {
+	name   string
+	params *WasmParams
+	module []byte
+}
+
+// NewWasmCommand creates a WasmCommand from a generic parameter map.
+func NewWasmCommand(params map[string]any) (Command, error) {
+	typedParams, err := NewWasmParamsFromMap(params)
+	if err != nil {
+		return nil, err
+	}
+	return newWasmCommandFromParams(typedParams)
+}
+
+// NewWasmCommandWithParams creates a WasmCommand from concrete typed parameters.
+func NewWasmCommandWithParams(modulePath string, timeout time.Duration, maxMemoryMB int) (*WasmCommand, error) {
+	typedParams, err := NewWasmParams(modulePath, timeout, maxMemoryMB)
+	if err != nil {
+		return nil, err
+	}
+	return newWasmCommandFromParams(typedParams)
+}
+
+func newWasmCommandFromParams(params *WasmParams) (*WasmCommand, error) {
+	// #nosec G304 -- the module path comes from operator-controlled configuration
+	module, err := os.ReadFile(params.ModulePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read wasm module %s: %w", params.ModulePath, err)
+	}
+	return &WasmCommand{name: "WasmCommand", params: params, module: module}, nil
+}
+
+// Name returns the command name.
+func (c *WasmCommand) Name() string {
+	return c.name
+}
+
+// Execute runs the wasm filter over the image pixels.
+func (c *WasmCommand) Execute(imageData []byte) ([]byte, error) {
+	slog.Debug("WasmCommand: decoding image",
+		"input_size_bytes", len(imageData),
+		"module", c.params.ModulePath)
+
+	img, err := decodePNG(imageData)
+	if err != nil {
+		slog.Error("WasmCommand: failed to decode PNG image", "error", err)
+		return nil, fmt.Errorf("failed to decode PNG image: %w", err)
+	}
+
+	bounds := img.Bounds()
+	nrgba := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(nrgba, nrgba.Bounds(), img, bounds.Min, draw.Src)
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.params.Timeout)
+	defer cancel()
+
+	if err := c.runFilter(ctx, nrgba); err != nil {
+		slog.Error("WasmCommand: filter failed", "module", c.params.ModulePath, "error", err)
+		return nil, err
+	}
+
+	result, err := encodePNG(nrgba)
+	if err != nil {
+		slog.Error("WasmCommand: failed to encode image", "error", err)
+		return nil, fmt.Errorf("failed to encode PNG image: %w", err)
+	}
+
+	slog.Debug("WasmCommand: filter complete", "output_size_bytes", len(result))
+	return result, nil
+}
+
+// runFilter instantiates the module in a fresh runtime, copies the pixels in,
+// calls filter and copies the result back into img.
+func (c *WasmCommand) runFilter(ctx context.Context, img *image.NRGBA) error {
+	cfg := wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(uint32(c.params.MaxMemoryMB * 1024 * 1024 / wasmPageSize)). // #nosec G115 -- bounded by NewWasmParams
+		WithCloseOnContextDone(true)
+	runtime := wazero.NewRuntimeWithConfig(ctx, cfg)
+	defer func() { _ = runtime.Close(ctx) }()
+
+	wasi_snapshot_preview1.MustInstantiate(ctx, runtime)
+
+	// No FS, env or stdio are configured, so the module cannot reach the host.
+	// Reactor-style modules (TinyGo, Rust cdylib) initialise via _initialize.
+	mod, err := runtime.InstantiateWithConfig(ctx, c.module,
+		wazero.NewModuleConfig().WithName("filter").WithStartFunctions("_initialize"))
+	if err != nil {
+		return fmt.Errorf("failed to instantiate wasm module: %w", err)
+	}
+
+	alloc := mod.ExportedFunction(wasmAllocExport)
+	filter := mod.ExportedFunction(wasmFilterExport)
+	if alloc == nil || filter == nil {
+		return fmt.Errorf("wasm module must export %q and %q", wasmAllocExport, wasmFilterExport)
+	}
+
+	size := uint64(len(img.Pix))
+	res, err := alloc.Call(ctx, size)
+	if err != nil {
+		return fmt.Errorf("wasm alloc(%d) failed: %w", size, err)
+	}
+	ptr := uint32(res[0]) // #nosec G115 -- wasm32 pointers fit in uint32
+
+	mem := mod.Memory()
+	if mem == nil || !mem.Write(ptr, img.Pix) {
+		return fmt.Errorf("wasm alloc returned out-of-range buffer at %d", ptr)
+	}
+
+	bounds := img.Bounds()
+	res, err = filter.Call(ctx, uint64(ptr), uint64(bounds.Dx()), uint64(bounds.Dy())) // #nosec G115 -- image dimensions are non-negative
+	if err != nil {
+		return fmt.Errorf("wasm filter failed: %w", err)
+	}
+	if status := int32(res[0]); status != wasmFilterStatusOK { // #nosec G115 -- i32 result
+		return fmt.Errorf("wasm filter returned status %d", status)
+	}
+
+	out, ok := mem.Read(ptr, uint32(size)) // #nosec G115 -- size was accepted by alloc
+	if !ok {
+		return fmt.Errorf("wasm filter buffer out of range after call")
+	}
+	copy(img.Pix, out)
+	return nil
+}
+
+// GetParams returns the typed parameters.
+func (c *WasmCommand) GetParams() *WasmParams {
+	return c.params
+}
+
+func init() {
+	if err := DefaultRegistry.Register("WasmCommand", NewWasmCommand); err != nil {
+		panic(fmt.Sprintf("failed to register WasmCommand: %v", err))
+	}
+}
@@ -0,0 +1,121 @@
+//go:build wazero
+
+package imageprocessing
+
+import (
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// invertRedFilter is the body of a filter that replaces the red channel of
+// every pixel with 255 - red and returns 0.
+var invertRedFilter = []byte{
+	0x01, 0x01, 0x7f, // one i32 local: end
+	0x20, 0x00, 0x20, 0x01, 0x20, 0x02, 0x6c, 0x41, 0x02, 0x74, 0x6a, 0x21, 0x03, // end = ptr + w*h*4
+	0x02, 0x40, 0x03, 0x40, // block, loop
+	0x20, 0x00, 0x20, 0x03, 0x4f, 0x0d, 0x01, // break when ptr >= end
+	0x20, 0x00, 0x41, 0xff, 0x01, 0x20, 0x00, 0x2d, 0x00, 0x00, 0x6b, 0x3a, 0x00, 0x00, // *ptr = 255 - *ptr
+	0x20, 0x00, 0x41, 0x04, 0x6a, 0x21, 0x00, // ptr += 4
+	0x0c, 0x00, 0x0b, 0x0b, // continue
+	0x41, 0x00, 0x0b, // return 0
+}
+
+// wasmFilterModule assembles a module exporting memory, an alloc that always
+// returns offset 1024 and a filter with the given body.
+func wasmFilterModule(filterBody []byte) []byte {
+	section := func(id byte, content []byte) []byte {
+		return append([]byte{id, byte(len(content))}, content...)
+	}
+	allocBody := []byte{0x00, 0x41, 0x80, 0x08, 0x0b}
+	code := []byte{0x02, byte(len(allocBody))}
+	code = append(code, allocBody...)
+	code = append(code, byte(len(filterBody)))
+	code = append(code, filterBody...)
+
+	module := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	module = append(module, section(1, []byte{0x02, 0x60, 0x01, 0x7f, 0x01, 0x7f, 0x60, 0x03, 0x7f, 0x7f, 0x7f, 0x01, 0x7f})...)
+	module = append(module, section(3, []byte{0x02, 0x00, 0x01})...)
+	module = append(module, section(5, []byte{0x01, 0x00, 0x01})...)
+	exports := []byte{0x03}
+	exports = append(append(append(exports, 0x06), "memory"...), 0x02, 0x00)
+	exports = append(append(append(exports, 0x05), "alloc"...), 0x00, 0x00)
+	exports = append(append(append(exports, 0x06), "filter"...), 0x00, 0x01)
+	module = append(module, section(7, exports)...)
+	return append(module, section(10, code)...)
+}
+
+func writeWasmModule(t *testing.T, module []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "filter.wasm")
+	if err := os.WriteFile(path, module, 0o600); err != nil {
+		t.Fatalf("failed to write module: %v", err)
+	}
+	return path
+}
+
+func TestWasmCommand_RunsInPipeline(t *testing.T) {
+	path := writeWasmModule(t, wasmFilterModule(invertRedFilter))
+	out, err := ExecuteCommands(solidPNG(t, 4, 3, color.RGBA{R: 10, G: 20, B: 30, A: 255}),
+		[]CommandConfig{{Name: "WasmCommand", Params: map[string]any{"module": path}}})
+	if err != nil {
+		t.Fatalf("ExecuteCommands failed: %v", err)
+	}
+	img, err := decodePNG(out)
+	if err != nil {
+		t.Fatalf("failed to decode output: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 4 || b.Dy() != 3 {
+		t.Fatalf("expected 4x3 output, got %dx%d", b.Dx(), b.Dy())
+	}
+	for _, p := range [][2]int{{0, 0}, {3, 2}} {
+		got := color.NRGBAModel.Convert(img.At(p[0], p[1])).(color.NRGBA)
+		if want := (color.NRGBA{R: 245, G: 20, B: 30, A: 255}); got != want {
+			t.Errorf("pixel %v: expected %v, got %v", p, want, got)
+		}
+	}
+}
+
+func TestWasmCommand_Errors(t *testing.T) {
+	input := solidPNG(t, 2, 2, color.RGBA{A: 255})
+	tests := []struct {
+		name    string
+		module  []byte
+		timeout time.Duration
+		want    string
+	}{
+		{"not wasm", []byte("not wasm"), time.Second, "failed to instantiate"},
+		{"missing exports", []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}, time.Second, "must export"},
+		{"failing status", wasmFilterModule([]byte{0x00, 0x41, 0x07, 0x0b}), time.Second, "status 7"},
+		{"endless loop", wasmFilterModule([]byte{0x00, 0x03, 0x40, 0x0c, 0x00, 0x0b, 0x41, 0x00, 0x0b}), 50 * time.Millisecond, "filter failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := NewWasmCommandWithParams(writeWasmModule(t, tt.module), tt.timeout, 16)
+			if err != nil {
+				t.Fatalf("NewWasmCommandWithParams failed: %v", err)
+			}
+			if _, err := cmd.Execute(input); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestNewWasmCommand_InvalidParams(t *testing.T) {
+	path := writeWasmModule(t, wasmFilterModule(invertRedFilter))
+	for _, params := range []map[string]any{
+		{},
+		{"module": path, "timeoutMs": 0},
+		{"module": path, "maxMemoryMB": 0},
+		{"module": path, "maxMemoryMB": 8192},
+		{"module": filepath.Join(t.TempDir(), "missing.wasm")},
+	} {
+		if _, err := NewWasmCommand(params); err == nil {
+			t.Errorf("expected error for %v", params)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strings"
+)
+
+// pushoverAPIURL is the Pushover message endpoint.
+const pushoverAPIURL = "https://api.pushover.net/1/messages.json"
+
+// ntfyNotifier publishes to an ntfy topic (https://ntfy.sh or self-hosted).
+type ntfyNotifier struct {
+	httpClient *http.Client
+	topicURL   string
+	token      string
+}
+
+func (n *ntfyNotifier) Notify(ctx context.Context, msg Message) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.topicURL, strings.NewReader(msg.Body))
+	if err != nil {
+		return fmt.Errorf("ntfy: building request: %w", err)
+	}
+	req.Header.Set("Title", msg.Title)
+	if n.token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.token)
+	}
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("ntfy: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	return checkStatus("ntfy", resp)
+}
+
+// pushoverNotifier sends messages through the Pushover API.
+type pushoverNotifier struct {
+	httpClient *http.Client
+	apiURL     string
+	token      string
+	user       string
+}
+
+func (p *pushoverNotifier) Notify(ctx context.Context, msg Message) error {
+	form := url.Values{
+		"token":   {p.token},
+		"user":    {p.user},
+		"title":   {msg.Title},
+		"message": {msg.Body},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.apiURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("pushover: building request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("pushover: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	return checkStatus("pushover", resp)
+}
+
+// webhookNotifier posts the message as JSON ({"title": ..., "message": ...}).
+type webhookNotifier struct {
+	httpClient *http.Client
+	url        string
+}
+
+func (w *webhookNotifier) Notify(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("webhook: encoding message: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("webhook: building request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	return checkStatus("webhook", resp)
+}
+
+// emailNotifier sends plain-text mail via SMTP, using STARTTLS when offered.
+type emailNotifier struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+	to       []string
+}
+
+// Notify sends msg like smtp.SendMail, but the connection is closed when ctx
+// is done, so a stalled server cannot hold up the delivery.
+func (e *emailNotifier) Notify(ctx context.Context, msg Message) error {
+	if err := e.send(ctx, msg); err != nil {
+		return fmt.Errorf("email: %w", err)
+	}
+	return nil
+}
+
+func (e *emailNotifier) send(ctx context.Context, msg Message) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", e.addr)
+	if err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, e.host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer func() { _ = c.Close() }()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: e.host, MinVersion: tls.VersionTLS12}); err != nil {
+			return err
+		}
+	}
+	if e.username != "" {
+		if err := c.Auth(smtp.PlainAuth("", e.username, e.password, e.host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(e.from); err != nil {
+		return err
+	}
+	for _, to := range e.to {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(e.compose(msg)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// compose renders the message as an RFC 5322 mail.
+func (e *emailNotifier) compose(msg Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", e.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(e.to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Title)
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
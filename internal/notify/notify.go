@@ -0,0 +1,59 @@
+// Package notify delivers owner alerts through email, ntfy, Pushover and webhooks.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/config"
+)
+
+// Message is a short alert for the owner.
+type Message struct {
+	Title string `json:"title"`
+	Body  string `json:"message"`
+}
+
+// Notifier delivers a message through one channel.
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
+
+// NewChannels builds one notifier per configured channel, in the order of
+// channels, for callers that deliver to and retry each channel on its own.
+func NewChannels(channels []config.NotificationChannel) ([]Notifier, error) {
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+	notifiers := make([]Notifier, 0, len(channels))
+	for i, c := range channels {
+		switch c.Type {
+		case config.NotificationChannelEmail:
+			notifiers = append(notifiers, &emailNotifier{
+				addr:     fmt.Sprintf("%s:%d", c.SMTPHost, c.SMTPPort),
+				host:     c.SMTPHost,
+				username: c.Username,
+				password: c.Password,
+				from:     c.From,
+				to:       c.To,
+			})
+		case config.NotificationChannelNtfy:
+			notifiers = append(notifiers, &ntfyNotifier{httpClient: httpClient, topicURL: c.URL, token: c.Token})
+		case config.NotificationChannelPushover:
+			notifiers = append(notifiers, &pushoverNotifier{httpClient: httpClient, apiURL: pushoverAPIURL, token: c.Token, user: c.User})
+		case config.NotificationChannelWebhook:
+			notifiers = append(notifiers, &webhookNotifier{httpClient: httpClient, url: c.URL})
+		default:
+			return nil, fmt.Errorf("notification channel at index %d has unknown type %q", i, c.Type)
+		}
+	}
+	return notifiers, nil
+}
+
+// checkStatus turns a non-2xx response into an error.
+func checkStatus(channel string, resp *http.Response) error {
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s: unexpected status %s", channel, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jo-hoe/goframe/internal/config"
+)
+
+func TestNewChannels_NoChannels(t *testing.T) {
+	n, err := NewChannels(nil)
+	if err != nil || len(n) != 0 {
+		t.Errorf("expected no notifiers without channels, got %v, %v", n, err)
+	}
+}
+
+func TestNotify_HTTPChannels(t *testing.T) {
+	var got []*http.Request
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		got = append(got, r)
+		bodies = append(bodies, string(data))
+	}))
+	defer srv.Close()
+
+	notifiers, err := NewChannels([]config.NotificationChannel{
+		{Type: config.NotificationChannelNtfy, URL: srv.URL + "/frame", Token: "tk"},
+		{Type: config.NotificationChannelWebhook, URL: srv.URL + "/hook"},
+		{Type: config.NotificationChannelPushover, Token: "app", User: "me"},
+	})
+	if err != nil {
+		t.Fatalf("NewChannels failed: %v", err)
+	}
+	notifiers[2].(*pushoverNotifier).apiURL = srv.URL + "/pushover"
+
+	for _, n := range notifiers {
+		if err := n.Notify(context.Background(), Message{Title: "Alert", Body: "processing failed"}); err != nil {
+			t.Fatalf("Notify failed: %v", err)
+		}
+	}
+	if len(got) != 3 {
+		t.Fatalf("expected 3 requests, got %d", len(got))
+	}
+
+	if got[0].Header.Get("Title") != "Alert" || got[0].Header.Get("Authorization") != "Bearer tk" || bodies[0] != "processing failed" {
+		t.Errorf("unexpected ntfy request: headers %v body %q", got[0].Header, bodies[0])
+	}
+	var hook Message
+	if err := json.Unmarshal([]byte(bodies[1]), &hook); err != nil || hook.Title != "Alert" || hook.Body != "processing failed" {
+		t.Errorf("unexpected webhook body %q (%v)", bodies[1], err)
+	}
+	if !strings.Contains(bodies[2], "token=app") || !strings.Contains(bodies[2], "user=me") {
+		t.Errorf("unexpected pushover body %q", bodies[2])
+	}
+}
+
+func TestNotify_ErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	notifiers, err := NewChannels([]config.NotificationChannel{{Type: config.NotificationChannelWebhook, URL: srv.URL}})
+	if err != nil {
+		t.Fatalf("NewChannels failed: %v", err)
+	}
+	if err := notifiers[0].Notify(context.Background(), Message{Title: "x"}); err == nil {
+		t.Error("expected error for non-2xx response")
+	}
+}
+
+func TestEmailCompose(t *testing.T) {
+	e := &emailNotifier{from: "frame@example.com", to: []string{"a@example.com", "b@example.com"}}
+	mail := string(e.compose(Message{Title: "Alert", Body: "line1\nline2"}))
+	for _, want := range []string{"To: a@example.com, b@example.com\r\n", "Subject: Alert\r\n", "line1\r\nline2"} {
+		if !strings.Contains(mail, want) {
+			t.Errorf("expected mail to contain %q, got %q", want, mail)
+		}
+	}
+}
+
+func TestEmailNotify_StalledServerHonorsContext(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer func() { _ = ln.Close() }()
+	go func() {
+		// Accept the connection but never send the SMTP greeting.
+		conn, err := ln.Accept()
+		if err == nil {
+			defer func() { _ = conn.Close() }()
+			time.Sleep(5 * time.Second)
+		}
+	}()
+
+	e := &emailNotifier{addr: ln.Addr().String(), host: "127.0.0.1", from: "frame@example.com", to: []string{"a@example.com"}}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := e.Notify(ctx, Message{Title: "Alert", Body: "body"}); err == nil {
+		t.Fatal("expected an error from a stalled server")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected Notify to give up with the context, took %s", elapsed)
+	}
+}
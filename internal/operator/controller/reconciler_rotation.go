@@ -64,6 +64,9 @@ func (r *GoFrameReconciler) reconcileRotation(ctx context.Context, gf *goframev1
 	}
 
 	currentID := ids[0]
+	if err := rc.RecordDisplay(ctx, now.Format(time.DateOnly), currentID); err != nil {
+		logger.Info("could not record display history", "err", err)
+	}
 
 	now2 := metav1.Now()
 	gf.Status.CurrentImageID = currentID
@@ -107,7 +110,7 @@ func advanceRotation(ctx context.Context, rc *database.RotationStateClient, now
 		return nil // Same day — no rotation needed.
 	}
 
-	days := int(todayMid.Sub(lastMid).Hours() / 24.0)
+	days := daysBetween(lastMid, todayMid)
 	if days > 0 {
 		k := days % len(ids)
 		newOrder := append([]string{}, ids[k:]...)
@@ -129,6 +132,14 @@ func durationUntilNextMidnight(now time.Time, loc *time.Location) time.Duration
 	return d
 }
 
+// daysBetween returns the number of calendar days from from to to. Days that
+// are 23 or 25 hours long because of a DST change count as one day.
+func daysBetween(from, to time.Time) int {
+	f := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	t := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(t.Sub(f).Hours() / 24)
+}
+
 // dayStart returns 00:00 in the given location for the day of t.
 func dayStart(t time.Time, loc *time.Location) time.Time {
 	tt := t.In(loc)
@@ -0,0 +1,280 @@
+// Package resilience guards calls to external services such as webhooks,
+// feeds and photo services with retries and circuit breakers, so a flaky
+// service neither floods the log nor holds up the loop calling it.
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrOpen is returned without calling the service while its circuit is open.
+var ErrOpen = errors.New("circuit open")
+
+// Circuit states reported by Health.
+const (
+	StateClosed   = "closed"
+	StateOpen     = "open"
+	StateHalfOpen = "half-open"
+)
+
+// maxOpenDoublings bounds how often the open duration doubles while a service
+// keeps failing its trial calls.
+const maxOpenDoublings = 4
+
+// Policy configures retries and circuit breaking.
+type Policy struct {
+	// Retries is the number of attempts after the first one made by Retry.
+	Retries int
+	// BaseDelay is the delay before the first retry; it doubles per retry up
+	// to MaxDelay. Delays are jittered by up to half their length.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// FailureThreshold is the number of consecutive failed calls that opens the
+	// circuit; the circuit never opens when it is not positive.
+	FailureThreshold int
+	// OpenDuration is how long an open circuit rejects calls before a trial
+	// call is let through. It doubles every time the trial fails.
+	OpenDuration time.Duration
+}
+
+// Health is the state of one guarded service.
+type Health struct {
+	Name                string    `json:"name"`
+	State               string    `json:"state"`
+	ConsecutiveFailures int       `json:"consecutiveFailures"`
+	LastError           string    `json:"lastError,omitempty"`
+	LastFailure         time.Time `json:"lastFailure,omitzero"`
+	LastSuccess         time.Time `json:"lastSuccess,omitzero"`
+	// OpenUntil is when an open circuit lets the next trial call through.
+	OpenUntil time.Time `json:"openUntil,omitzero"`
+}
+
+// permanentError marks an error that retrying cannot fix.
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so Retry returns it at once, e.g. for a 4xx response.
+// It still counts as a failure of the service.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// PermanentForStatus marks err as Permanent for HTTP client errors other than
+// 408 and 429, which retrying the same request cannot fix.
+func PermanentForStatus(err error, status int) error {
+	if status >= 400 && status < 500 && status != http.StatusRequestTimeout && status != http.StatusTooManyRequests {
+		return Permanent(err)
+	}
+	return err
+}
+
+// Breaker guards the calls to one service. A nil *Breaker calls through.
+type Breaker struct {
+	name   string
+	policy Policy
+	nowFn  func() time.Time
+	sleep  func(ctx context.Context, d time.Duration) error
+
+	mu        sync.Mutex
+	failures  int
+	opens     int
+	openUntil time.Time
+	trial     bool
+	lastError string
+	lastFail  time.Time
+	lastOK    time.Time
+}
+
+// NewBreaker creates a breaker for the named service.
+func NewBreaker(name string, policy Policy) *Breaker {
+	return &Breaker{name: name, policy: policy, nowFn: time.Now, sleep: sleep}
+}
+
+// Do calls fn once unless the circuit is open. Use it where a caller waits,
+// e.g. while handling a request.
+func (b *Breaker) Do(ctx context.Context, fn func(context.Context) error) error {
+	if b == nil {
+		return unwrapPermanent(fn(ctx))
+	}
+	if err := b.allow(); err != nil {
+		return err
+	}
+	err := fn(ctx)
+	b.record(err)
+	return unwrapPermanent(err)
+}
+
+// Retry calls fn until it succeeds, returns a Permanent error or the retries
+// of the policy are used up, backing off exponentially in between. The
+// attempts count as one call for the circuit.
+func (b *Breaker) Retry(ctx context.Context, fn func(context.Context) error) error {
+	if b == nil {
+		return unwrapPermanent(fn(ctx))
+	}
+	if err := b.allow(); err != nil {
+		return err
+	}
+	delay := b.policy.BaseDelay
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = fn(ctx)
+		var permanent *permanentError
+		if err == nil || errors.As(err, &permanent) || attempt >= b.policy.Retries || ctx.Err() != nil {
+			break
+		}
+		slog.Debug("resilience: retrying", "service", b.name, "attempt", attempt+1, "delay", delay, "error", err)
+		if b.sleep(ctx, jitter(delay)) != nil {
+			break
+		}
+		delay = min(delay*2, max(b.policy.MaxDelay, b.policy.BaseDelay))
+	}
+	b.record(err)
+	return unwrapPermanent(err)
+}
+
+// allow rejects calls while the circuit is open and lets a single trial call
+// through once the open duration has passed.
+func (b *Breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.openUntil.IsZero() {
+		return nil
+	}
+	if b.trial || b.nowFn().Before(b.openUntil) {
+		return fmt.Errorf("%s: %w until %s", b.name, ErrOpen, b.openUntil.Format(time.RFC3339))
+	}
+	b.trial = true
+	return nil
+}
+
+// record updates the circuit with the outcome of a call.
+func (b *Breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.nowFn()
+	if err == nil {
+		if !b.openUntil.IsZero() {
+			slog.Info("resilience: service recovered", "service", b.name)
+		}
+		b.failures, b.opens, b.openUntil, b.trial = 0, 0, time.Time{}, false
+		b.lastOK = now
+		return
+	}
+
+	b.failures++
+	b.lastError, b.lastFail = err.Error(), now
+	if b.policy.FailureThreshold > 0 && (b.trial || b.failures >= b.policy.FailureThreshold) {
+		b.opens++
+		b.trial = false
+		openFor := b.policy.OpenDuration << min(b.opens-1, maxOpenDoublings)
+		b.openUntil = now.Add(openFor)
+		slog.Warn("resilience: service keeps failing; pausing calls",
+			"service", b.name, "failures", b.failures, "pause", openFor, "error", err)
+	}
+}
+
+// Health returns the current state of the circuit.
+func (b *Breaker) Health() Health {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	h := Health{
+		Name:                b.name,
+		State:               StateClosed,
+		ConsecutiveFailures: b.failures,
+		LastError:           b.lastError,
+		LastFailure:         b.lastFail,
+		LastSuccess:         b.lastOK,
+		OpenUntil:           b.openUntil,
+	}
+	switch {
+	case b.openUntil.IsZero():
+	case b.trial || !b.nowFn().Before(b.openUntil):
+		h.State = StateHalfOpen
+	default:
+		h.State = StateOpen
+	}
+	return h
+}
+
+// Registry hands out one breaker per service name, sharing a policy.
+type Registry struct {
+	policy Policy
+
+	mu       sync.Mutex
+	breakers map[string]*Breaker
+}
+
+// NewRegistry creates a registry whose breakers follow policy.
+func NewRegistry(policy Policy) *Registry {
+	return &Registry{policy: policy, breakers: make(map[string]*Breaker)}
+}
+
+// Breaker returns the breaker of the named service, creating it on first use.
+func (r *Registry) Breaker(name string) *Breaker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.breakers[name]
+	if !ok {
+		b = NewBreaker(name, r.policy)
+		r.breakers[name] = b
+	}
+	return b
+}
+
+// Health returns the state of every service called so far, sorted by name.
+func (r *Registry) Health() []Health {
+	r.mu.Lock()
+	breakers := make([]*Breaker, 0, len(r.breakers))
+	for _, b := range r.breakers {
+		breakers = append(breakers, b)
+	}
+	r.mu.Unlock()
+
+	health := make([]Health, 0, len(breakers))
+	for _, b := range breakers {
+		health = append(health, b.Health())
+	}
+	slices.SortFunc(health, func(a, b Health) int { return strings.Compare(a.Name, b.Name) })
+	return health
+}
+
+func unwrapPermanent(err error) error {
+	var permanent *permanentError
+	if errors.As(err, &permanent) {
+		return permanent.err
+	}
+	return err
+}
+
+// jitter returns d shortened by a random amount of up to half its length.
+func jitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	return d - rand.N(d/2)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,117 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// newTestBreaker returns a breaker on a fake clock that records its sleeps
+// instead of waiting.
+func newTestBreaker(policy Policy) (*Breaker, *time.Time, *[]time.Duration) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var sleeps []time.Duration
+	b := NewBreaker("test", policy)
+	b.nowFn = func() time.Time { return now }
+	b.sleep = func(_ context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return nil
+	}
+	return b, &now, &sleeps
+}
+
+func TestRetry_BacksOffUntilSuccess(t *testing.T) {
+	b, _, sleeps := newTestBreaker(Policy{Retries: 3, BaseDelay: time.Second, MaxDelay: 3 * time.Second, FailureThreshold: 5})
+	calls := 0
+	err := b.Retry(context.Background(), func(context.Context) error {
+		calls++
+		if calls < 4 {
+			return errors.New("flaky")
+		}
+		return nil
+	})
+	if err != nil || calls != 4 {
+		t.Fatalf("expected success on the fourth call, got %v after %d calls", err, calls)
+	}
+	maxDelays := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}
+	for i, d := range *sleeps {
+		if d > maxDelays[i] || d < maxDelays[i]/2 {
+			t.Errorf("retry %d: delay %v outside [%v, %v]", i, d, maxDelays[i]/2, maxDelays[i])
+		}
+	}
+	if h := b.Health(); h.State != StateClosed || h.ConsecutiveFailures != 0 || h.LastSuccess.IsZero() {
+		t.Errorf("unexpected health %+v", h)
+	}
+}
+
+func TestRetry_StopsOnPermanentError(t *testing.T) {
+	b, _, sleeps := newTestBreaker(Policy{Retries: 3, BaseDelay: time.Second, FailureThreshold: 5})
+	notFound := errors.New("not found")
+	calls := 0
+	err := b.Retry(context.Background(), func(context.Context) error {
+		calls++
+		return Permanent(notFound)
+	})
+	if err != notFound || calls != 1 || len(*sleeps) != 0 {
+		t.Errorf("expected one call returning the unwrapped error, got %v after %d calls", err, calls)
+	}
+	if h := b.Health(); h.ConsecutiveFailures != 1 || h.LastError != "not found" {
+		t.Errorf("expected the failure to count, got %+v", h)
+	}
+}
+
+func TestBreaker_OpensAndRecovers(t *testing.T) {
+	b, now, _ := newTestBreaker(Policy{FailureThreshold: 2, OpenDuration: time.Minute})
+	ctx := context.Background()
+	failing := func(context.Context) error { return errors.New("down") }
+
+	_ = b.Do(ctx, failing)
+	_ = b.Do(ctx, failing)
+	if h := b.Health(); h.State != StateOpen || !h.OpenUntil.Equal(now.Add(time.Minute)) {
+		t.Fatalf("expected an open circuit for a minute, got %+v", h)
+	}
+	calls := 0
+	if err := b.Do(ctx, func(context.Context) error { calls++; return nil }); !errors.Is(err, ErrOpen) || calls != 0 {
+		t.Fatalf("expected ErrOpen without calling, got %v after %d calls", err, calls)
+	}
+
+	// A failed trial reopens the circuit for twice as long.
+	*now = now.Add(time.Minute)
+	if h := b.Health(); h.State != StateHalfOpen {
+		t.Errorf("expected half-open, got %s", h.State)
+	}
+	_ = b.Do(ctx, failing)
+	if h := b.Health(); h.State != StateOpen || !h.OpenUntil.Equal(now.Add(2*time.Minute)) {
+		t.Fatalf("expected the circuit to reopen for two minutes, got %+v", h)
+	}
+
+	*now = now.Add(2 * time.Minute)
+	if err := b.Do(ctx, func(context.Context) error { return nil }); err != nil {
+		t.Fatalf("expected the trial call to pass, got %v", err)
+	}
+	if h := b.Health(); h.State != StateClosed || h.ConsecutiveFailures != 0 || !h.OpenUntil.IsZero() {
+		t.Errorf("expected a closed circuit, got %+v", h)
+	}
+}
+
+func TestNilBreakerCallsThrough(t *testing.T) {
+	var b *Breaker
+	calls := 0
+	err := b.Retry(context.Background(), func(context.Context) error { calls++; return Permanent(errors.New("x")) })
+	if err == nil || err.Error() != "x" || calls != 1 {
+		t.Errorf("expected a single call, got %v after %d calls", err, calls)
+	}
+}
+
+func TestRegistry_Health(t *testing.T) {
+	r := NewRegistry(Policy{FailureThreshold: 1, OpenDuration: time.Minute})
+	if r.Breaker("b") != r.Breaker("b") {
+		t.Error("expected the same breaker per name")
+	}
+	_ = r.Breaker("a").Do(context.Background(), func(context.Context) error { return errors.New("down") })
+	health := r.Health()
+	if len(health) != 2 || health[0].Name != "a" || health[0].State != StateOpen || health[1].State != StateClosed {
+		t.Errorf("unexpected health %+v", health)
+	}
+}
@@ -19,6 +19,9 @@ const (
 	// maxFetchAttempts is the number of random objects to try before giving up.
 	// Some highlighted objects have hasImages=true but an empty primaryImage field.
 	maxFetchAttempts = 10
+
+	// openAccessLicense is the license of all Met Open Access (public-domain) images.
+	openAccessLicense = "CC0"
 )
 
 // MetMuseumSource fetches a random highlighted, public-domain artwork image from
@@ -50,12 +53,19 @@ func (m *MetMuseumSource) Name() string {
 // Fetch retrieves a random highlighted, public-domain artwork image.
 // It tries up to maxFetchAttempts random objects, skipping any that lack a primaryImage.
 func (m *MetMuseumSource) Fetch(ctx context.Context) ([]byte, error) {
+	data, _, err := m.FetchWithAttribution(ctx)
+	return data, err
+}
+
+// FetchWithAttribution behaves like Fetch and additionally returns the artwork's
+// collection page, artist, and CC0 license.
+func (m *MetMuseumSource) FetchWithAttribution(ctx context.Context) ([]byte, scheduler.Attribution, error) {
 	ids, err := m.collectObjectIDs(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("fetching met museum object IDs: %w", err)
+		return nil, scheduler.Attribution{}, fmt.Errorf("fetching met museum object IDs: %w", err)
 	}
 	if len(ids) == 0 {
-		return nil, fmt.Errorf("met museum search returned no results for departments %v", m.departmentIDs)
+		return nil, scheduler.Attribution{}, fmt.Errorf("met museum search returned no results for departments %v", m.departmentIDs)
 	}
 
 	// #nosec G404 -- math/rand is intentional; artwork selection does not require cryptographic randomness
@@ -63,17 +73,17 @@ func (m *MetMuseumSource) Fetch(ctx context.Context) ([]byte, error) {
 
 	attempts := min(maxFetchAttempts, len(ids))
 	for _, objectID := range ids[:attempts] {
-		imageURL, err := m.fetchImageURL(ctx, objectID)
+		meta, err := m.fetchObjectMeta(ctx, objectID)
 		if err != nil {
 			continue
 		}
-		data, err := scheduler.FetchBytes(ctx, m.httpClient, imageURL)
+		data, err := scheduler.FetchBytes(ctx, m.httpClient, meta.PrimaryImage)
 		if err != nil {
-			return nil, fmt.Errorf("downloading met museum object %d image: %w", objectID, err)
+			return nil, scheduler.Attribution{}, fmt.Errorf("downloading met museum object %d image: %w", objectID, err)
 		}
-		return data, nil
+		return data, meta.attribution(), nil
 	}
-	return nil, fmt.Errorf("met museum: no object with a primary image found after %d attempts", attempts)
+	return nil, scheduler.Attribution{}, fmt.Errorf("met museum: no object with a primary image found after %d attempts", attempts)
 }
 
 // collectObjectIDs returns the union of object IDs across all configured departments.
@@ -108,7 +118,18 @@ type searchResult struct {
 
 // objectMeta holds the fields we need from the Met object API response.
 type objectMeta struct {
-	PrimaryImage string `json:"primaryImage"`
+	PrimaryImage      string `json:"primaryImage"`
+	ObjectURL         string `json:"objectURL"`
+	ArtistDisplayName string `json:"artistDisplayName"`
+}
+
+// attribution returns the attribution for the object. Unknown artists are left empty.
+func (o objectMeta) attribution() scheduler.Attribution {
+	return scheduler.Attribution{
+		SourceURL: o.ObjectURL,
+		Author:    o.ArtistDisplayName,
+		License:   openAccessLicense,
+	}
 }
 
 // fetchObjectIDsForDepartment fetches highlighted public-domain object IDs for a department.
@@ -122,13 +143,13 @@ func (m *MetMuseumSource) fetchObjectIDsForDepartment(ctx context.Context, depar
 	return parseSearchResult(data)
 }
 
-func (m *MetMuseumSource) fetchImageURL(ctx context.Context, objectID int) (string, error) {
+func (m *MetMuseumSource) fetchObjectMeta(ctx context.Context, objectID int) (objectMeta, error) {
 	u := fmt.Sprintf(m.objectBaseURL, objectID)
 	data, err := scheduler.FetchBytes(ctx, m.httpClient, u)
 	if err != nil {
-		return "", err
+		return objectMeta{}, err
 	}
-	return parseImageURL(data)
+	return parseObjectMeta(data)
 }
 
 // buildSearchURL constructs the Met search URL for highlighted public-domain images.
@@ -153,13 +174,14 @@ func parseSearchResult(data []byte) ([]int, error) {
 	return result.ObjectIDs, nil
 }
 
-func parseImageURL(data []byte) (string, error) {
+// parseObjectMeta decodes a Met object response, rejecting objects without a primary image.
+func parseObjectMeta(data []byte) (objectMeta, error) {
 	var meta objectMeta
 	if err := json.Unmarshal(data, &meta); err != nil {
-		return "", fmt.Errorf("parsing met museum object response: %w", err)
+		return objectMeta{}, fmt.Errorf("parsing met museum object response: %w", err)
 	}
 	if meta.PrimaryImage == "" {
-		return "", fmt.Errorf("met museum object has no primary image")
+		return objectMeta{}, fmt.Errorf("met museum object has no primary image")
 	}
-	return meta.PrimaryImage, nil
+	return meta, nil
 }
@@ -7,6 +7,8 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"github.com/jo-hoe/goframe/internal/scheduler"
 )
 
 func TestParseSearchResult_Valid(t *testing.T) {
@@ -27,27 +29,40 @@ func TestParseSearchResult_Invalid(t *testing.T) {
 	}
 }
 
-func TestParseImageURL_Valid(t *testing.T) {
-	data, _ := json.Marshal(objectMeta{PrimaryImage: "https://example.com/img.jpg"})
-	u, err := parseImageURL(data)
+func TestParseObjectMeta_Valid(t *testing.T) {
+	data, _ := json.Marshal(objectMeta{
+		PrimaryImage:      "https://example.com/img.jpg",
+		ObjectURL:         "https://www.metmuseum.org/art/collection/search/42",
+		ArtistDisplayName: "Vincent van Gogh",
+	})
+	meta, err := parseObjectMeta(data)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if u != "https://example.com/img.jpg" {
-		t.Errorf("unexpected URL: %q", u)
+	if meta.PrimaryImage != "https://example.com/img.jpg" {
+		t.Errorf("unexpected URL: %q", meta.PrimaryImage)
+	}
+
+	want := scheduler.Attribution{
+		SourceURL: "https://www.metmuseum.org/art/collection/search/42",
+		Author:    "Vincent van Gogh",
+		License:   "CC0",
+	}
+	if got := meta.attribution(); got != want {
+		t.Errorf("expected attribution %+v, got %+v", want, got)
 	}
 }
 
-func TestParseImageURL_Missing(t *testing.T) {
+func TestParseObjectMeta_Missing(t *testing.T) {
 	data, _ := json.Marshal(objectMeta{PrimaryImage: ""})
-	_, err := parseImageURL(data)
+	_, err := parseObjectMeta(data)
 	if err == nil {
 		t.Fatal("expected error for missing primary image, got nil")
 	}
 }
 
-func TestParseImageURL_Invalid(t *testing.T) {
-	_, err := parseImageURL([]byte("not json"))
+func TestParseObjectMeta_Invalid(t *testing.T) {
+	_, err := parseObjectMeta([]byte("not json"))
 	if err == nil {
 		t.Fatal("expected error for invalid JSON, got nil")
 	}
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/jo-hoe/goframe/internal/scheduler"
@@ -24,6 +25,14 @@ const (
 	demoAPIKey = "DEMO_KEY"
 
 	mediaTypeImage = "image"
+
+	// apodPageURLFormat is the human-facing APOD page for a date formatted as yymmdd.
+	apodPageURLFormat = "https://apod.nasa.gov/apod/ap%s.html"
+
+	// publicDomainLicense applies to APOD entries without a copyright holder (NASA works).
+	publicDomainLicense = "Public Domain"
+	// copyrightedLicense applies to APOD entries that carry a third-party copyright.
+	copyrightedLicense = "All rights reserved"
 )
 
 // NASAAPODSource fetches a random image from the NASA APOD archive.
@@ -58,20 +67,27 @@ func (n *NASAAPODSource) Name() string {
 // Fetch retrieves a random APOD image from the archive.
 // Returns an error when the API returns only video entries.
 func (n *NASAAPODSource) Fetch(ctx context.Context) ([]byte, error) {
+	data, _, err := n.FetchWithAttribution(ctx)
+	return data, err
+}
+
+// FetchWithAttribution behaves like Fetch and additionally returns the APOD page,
+// the copyright holder (if any), and the resulting license.
+func (n *NASAAPODSource) FetchWithAttribution(ctx context.Context) ([]byte, scheduler.Attribution, error) {
 	meta, err := n.fetchRandomMeta(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("fetching nasa apod metadata: %w", err)
+		return nil, scheduler.Attribution{}, fmt.Errorf("fetching nasa apod metadata: %w", err)
 	}
 	if meta.MediaType != mediaTypeImage {
-		return nil, fmt.Errorf("nasa apod: random entry %q is %q (not an image), skipping", meta.Date, meta.MediaType)
+		return nil, scheduler.Attribution{}, fmt.Errorf("nasa apod: random entry %q is %q (not an image), skipping", meta.Date, meta.MediaType)
 	}
 
 	imageURL := meta.bestImageURL()
 	data, err := scheduler.FetchBytes(ctx, n.httpClient, imageURL)
 	if err != nil {
-		return nil, fmt.Errorf("downloading nasa apod image from %q: %w", imageURL, err)
+		return nil, scheduler.Attribution{}, fmt.Errorf("downloading nasa apod image from %q: %w", imageURL, err)
 	}
-	return data, nil
+	return data, meta.attribution(), nil
 }
 
 // apodEntry holds the fields returned by the NASA APOD API for a single entry.
@@ -83,6 +99,21 @@ type apodEntry struct {
 	URL string `json:"url"`
 	// HDUrl is the full-resolution image URL; absent for video entries.
 	HDUrl string `json:"hdurl"`
+	// Copyright names the copyright holder; absent for public-domain NASA works.
+	Copyright string `json:"copyright"`
+}
+
+// attribution returns the APOD page, copyright holder, and license for the entry.
+func (e apodEntry) attribution() scheduler.Attribution {
+	a := scheduler.Attribution{License: publicDomainLicense}
+	if d, err := time.Parse("2006-01-02", e.Date); err == nil {
+		a.SourceURL = fmt.Sprintf(apodPageURLFormat, d.Format("060102"))
+	}
+	if holder := strings.TrimSpace(e.Copyright); holder != "" {
+		a.Author = strings.Join(strings.Fields(holder), " ")
+		a.License = copyrightedLicense
+	}
+	return a
 }
 
 // bestImageURL returns the HD image URL when present, falling back to URL.
@@ -226,6 +226,28 @@ func TestFetch_ImageDownloadError(t *testing.T) {
 	}
 }
 
+func TestAttribution_PublicDomain(t *testing.T) {
+	e := apodEntry{Date: "2024-01-15", MediaType: "image"}
+	got := e.attribution()
+	if got.SourceURL != "https://apod.nasa.gov/apod/ap240115.html" {
+		t.Errorf("unexpected source URL: %q", got.SourceURL)
+	}
+	if got.Author != "" || got.License != "Public Domain" {
+		t.Errorf("expected public-domain attribution, got %+v", got)
+	}
+}
+
+func TestAttribution_Copyrighted(t *testing.T) {
+	e := apodEntry{Date: "bad-date", Copyright: "\nJane   Doe\n"}
+	got := e.attribution()
+	if got.SourceURL != "" {
+		t.Errorf("expected empty source URL for unparseable date, got %q", got.SourceURL)
+	}
+	if got.Author != "Jane Doe" || got.License != "All rights reserved" {
+		t.Errorf("expected copyrighted attribution, got %+v", got)
+	}
+}
+
 func contains(s, sub string) bool {
 	return len(s) >= len(sub) && (s == sub || len(s) > 0 && containsStr(s, sub))
 }
@@ -72,7 +72,7 @@ func RunOnce(ctx context.Context, cfg Config) error {
 		}
 	}
 
-	imageData, err := cfg.Source.Fetch(ctx)
+	imageData, attribution, err := fetchFromSource(ctx, cfg.Source)
 	if err != nil {
 		return fmt.Errorf("fetching image from source %q: %w", cfg.Source.Name(), err)
 	}
@@ -96,7 +96,7 @@ func RunOnce(ctx context.Context, cfg Config) error {
 		slog.Info("image-scheduler: applied command pipeline", "source", cfg.SourceName, "commands", len(cfg.Commands), "bytes", len(imageData))
 	}
 
-	if err := client.uploadImage(ctx, imageData, cfg.SourceName); err != nil {
+	if err := client.uploadImage(ctx, imageData, cfg.SourceName, attribution); err != nil {
 		return fmt.Errorf("uploading image: %w", err)
 	}
 	slog.Info("image-scheduler: uploaded new image", "source", cfg.SourceName)
@@ -121,6 +121,15 @@ func RunOnce(ctx context.Context, cfg Config) error {
 	return pruneOwnImages(ctx, client, images, cfg.SourceName)
 }
 
+// fetchFromSource fetches an image, including its attribution when the source provides one.
+func fetchFromSource(ctx context.Context, source ImageSource) ([]byte, Attribution, error) {
+	if attributed, ok := source.(AttributedImageSource); ok {
+		return attributed.FetchWithAttribution(ctx)
+	}
+	data, err := source.Fetch(ctx)
+	return data, Attribution{}, err
+}
+
 // hasExternalImages returns true if any image is not owned by sourceName or a group member.
 func hasExternalImages(images []apiImageItem, sourceName string, groupMembers []string) bool {
 	known := makeKnownSet(sourceName, groupMembers)
@@ -275,7 +284,7 @@ func (c *goframeClient) listImages(ctx context.Context) ([]apiImageItem, error)
 	return items, nil
 }
 
-func (c *goframeClient) uploadImage(ctx context.Context, data []byte, sourceName string) error {
+func (c *goframeClient) uploadImage(ctx context.Context, data []byte, sourceName string, attribution Attribution) error {
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
 
@@ -286,8 +295,19 @@ func (c *goframeClient) uploadImage(ctx context.Context, data []byte, sourceName
 	if _, err := io.Copy(part, bytes.NewReader(data)); err != nil {
 		return err
 	}
-	if err := writer.WriteField("source", sourceName); err != nil {
-		return err
+	fields := []struct{ key, value string }{
+		{"source", sourceName},
+		{"sourceUrl", attribution.SourceURL},
+		{"author", attribution.Author},
+		{"license", attribution.License},
+	}
+	for _, f := range fields {
+		if f.value == "" {
+			continue
+		}
+		if err := writer.WriteField(f.key, f.value); err != nil {
+			return err
+		}
 	}
 	if err := writer.Close(); err != nil {
 		return err
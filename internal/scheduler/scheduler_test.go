@@ -36,11 +36,23 @@ type staticSource struct {
 func (s *staticSource) Name() string                             { return s.name }
 func (s *staticSource) Fetch(_ context.Context) ([]byte, error) { return s.data, s.err }
 
+// attributedSource is a test AttributedImageSource that returns fixed bytes and attribution.
+type attributedSource struct {
+	staticSource
+	attribution Attribution
+}
+
+func (s *attributedSource) FetchWithAttribution(_ context.Context) ([]byte, Attribution, error) {
+	return s.data, s.attribution, s.err
+}
+
 // goframeTestServer simulates the goframe REST API for image scheduler integration tests.
 type goframeTestServer struct {
 	images []apiImageItem
 	// uploadedSource records the source form field value from the last upload.
 	uploadedSource string
+	// uploadedAttribution records the attribution form fields from the last upload.
+	uploadedAttribution Attribution
 	// deletedIDs records all deleted image IDs in order.
 	deletedIDs []string
 }
@@ -66,6 +78,11 @@ func (g *goframeTestServer) handler() http.Handler {
 			return
 		}
 		g.uploadedSource = r.FormValue("source")
+		g.uploadedAttribution = Attribution{
+			SourceURL: r.FormValue("sourceUrl"),
+			Author:    r.FormValue("author"),
+			License:   r.FormValue("license"),
+		}
 		newID := "new-id-" + g.uploadedSource
 		g.images = append(g.images, apiImageItem{
 			ID:        newID,
@@ -123,6 +140,29 @@ func TestRunOnce_Uploads(t *testing.T) {
 	}
 }
 
+func TestRunOnce_UploadsAttribution(t *testing.T) {
+	srv, state := newGoframeTestServer(nil)
+	defer srv.Close()
+
+	want := Attribution{SourceURL: "https://example.com/art/1", Author: "Jane Doe", License: "CC0"}
+	cfg := Config{
+		GoframeBaseURL: srv.URL,
+		SourceName:     "test-source",
+		Source: &attributedSource{
+			staticSource: staticSource{name: "test-source", data: minimalPNG()},
+			attribution:  want,
+		},
+	}
+
+	if err := RunOnce(context.Background(), cfg); err != nil {
+		t.Fatalf("RunOnce error: %v", err)
+	}
+
+	if state.uploadedAttribution != want {
+		t.Errorf("expected attribution %+v, got %+v", want, state.uploadedAttribution)
+	}
+}
+
 func TestRunOnce_PrunesExcessOwnImages(t *testing.T) {
 	// Two existing own images; always keeps 1 → oldest should be pruned after upload.
 	initialImages := []apiImageItem{
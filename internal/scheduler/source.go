@@ -9,3 +9,22 @@ type ImageSource interface {
 	// Fetch retrieves raw image bytes from the source.
 	Fetch(ctx context.Context) ([]byte, error)
 }
+
+// Attribution describes where a fetched image came from and under which license it is published.
+type Attribution struct {
+	// SourceURL is a human-facing page for the image (not the raw image URL).
+	SourceURL string
+	// Author is the creator or copyright holder.
+	Author string
+	// License is a short license identifier (e.g. "CC0", "CC BY-NC 2.5").
+	License string
+}
+
+// AttributedImageSource is optionally implemented by sources that know the origin,
+// author, and license of the images they fetch. RunOnce prefers FetchWithAttribution
+// over Fetch when available and forwards the attribution on upload.
+type AttributedImageSource interface {
+	ImageSource
+	// FetchWithAttribution retrieves raw image bytes together with their attribution.
+	FetchWithAttribution(ctx context.Context) ([]byte, Attribution, error)
+}
@@ -14,6 +14,11 @@ import (
 const (
 	latestComicURL = "https://xkcd.com/info.0.json"
 	comicURLFormat = "https://xkcd.com/%d/info.0.json"
+	comicPageURL   = "https://xkcd.com/%d/"
+
+	// xkcdAuthor and xkcdLicense describe every xkcd comic (see https://xkcd.com/license.html).
+	xkcdAuthor  = "Randall Munroe"
+	xkcdLicense = "CC BY-NC 2.5"
 
 	// comicNum404 is the xkcd comic that is intentionally missing (a meta-joke about HTTP 404).
 	comicNum404 = 404
@@ -38,9 +43,16 @@ func (x *XKCDSource) Name() string {
 
 // Fetch retrieves a random XKCD comic image as raw PNG/JPEG bytes.
 func (x *XKCDSource) Fetch(ctx context.Context) ([]byte, error) {
+	data, _, err := x.FetchWithAttribution(ctx)
+	return data, err
+}
+
+// FetchWithAttribution retrieves a random XKCD comic image together with a link
+// to the comic page and its CC BY-NC license.
+func (x *XKCDSource) FetchWithAttribution(ctx context.Context) ([]byte, scheduler.Attribution, error) {
 	latest, err := x.fetchComicMeta(ctx, latestComicURL)
 	if err != nil {
-		return nil, fmt.Errorf("fetching latest xkcd comic metadata: %w", err)
+		return nil, scheduler.Attribution{}, fmt.Errorf("fetching latest xkcd comic metadata: %w", err)
 	}
 
 	comicNum := randomComicNumber(latest.Num)
@@ -48,14 +60,23 @@ func (x *XKCDSource) Fetch(ctx context.Context) ([]byte, error) {
 	url := fmt.Sprintf(comicURLFormat, comicNum)
 	comic, err := x.fetchComicMeta(ctx, url)
 	if err != nil {
-		return nil, fmt.Errorf("fetching xkcd comic %d metadata: %w", comicNum, err)
+		return nil, scheduler.Attribution{}, fmt.Errorf("fetching xkcd comic %d metadata: %w", comicNum, err)
 	}
 
 	data, err := scheduler.FetchBytes(ctx, x.httpClient, comic.ImgURL)
 	if err != nil {
-		return nil, fmt.Errorf("downloading xkcd comic %d image: %w", comicNum, err)
+		return nil, scheduler.Attribution{}, fmt.Errorf("downloading xkcd comic %d image: %w", comicNum, err)
+	}
+	return data, comicAttribution(comicNum), nil
+}
+
+// comicAttribution returns the attribution for the given comic number.
+func comicAttribution(comicNum int) scheduler.Attribution {
+	return scheduler.Attribution{
+		SourceURL: fmt.Sprintf(comicPageURL, comicNum),
+		Author:    xkcdAuthor,
+		License:   xkcdLicense,
 	}
-	return data, nil
 }
 
 // comicMeta holds the fields we need from the XKCD JSON API.
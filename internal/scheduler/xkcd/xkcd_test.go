@@ -134,3 +134,14 @@ func TestRandomComicNumber_LatestBelowExclusion(t *testing.T) {
 		}
 	}
 }
+
+func TestComicAttribution(t *testing.T) {
+	want := scheduler.Attribution{
+		SourceURL: "https://xkcd.com/327/",
+		Author:    "Randall Munroe",
+		License:   "CC BY-NC 2.5",
+	}
+	if got := comicAttribution(327); got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}